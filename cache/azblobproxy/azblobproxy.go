@@ -50,15 +50,15 @@ func (c *azBlobCache) Put(ctx context.Context, kind cache.EntryKind, hash string
 		return
 	}
 
-	select {
-	case c.uploadQueue <- backendproxy.UploadReq{
+	item := backendproxy.UploadReq{
 		Hash:        hash,
 		LogicalSize: logicalSize,
 		SizeOnDisk:  sizeOnDisk,
 		Kind:        kind,
 		Rc:          rc,
-	}:
-	default:
+	}
+
+	if !backendproxy.Enqueue(c.uploadQueue, item) {
 		c.errorLogger.Printf("too many uploads queued\n")
 		rc.Close()
 	}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"io"
+	"time"
 )
 
 // EntryKind describes the kind of cache entry
@@ -54,6 +55,11 @@ type Error struct {
 	Code int
 	// A human-readable string describing the error
 	Text string
+	// Optional, for errors caused by a limit or quota, what it applies
+	// to, eg "instance:<name>" or "max_size"
+	QuotaSubject string
+	// Optional, how long the client should wait before retrying
+	RetryAfter time.Duration
 }
 
 func (e *Error) Error() string {
@@ -85,6 +91,80 @@ type Proxy interface {
 	Contains(ctx context.Context, kind EntryKind, hash string) (bool, int64)
 }
 
+// HealthChecker may be implemented by proxy backends which need more than
+// a single request to check if they are reachable, see CheckProxyHealth.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// BackendNamer may be implemented by proxies which forward each request
+// to one of several backends, to name the backend which handles requests
+// with ctx, eg in metric labels.
+type BackendNamer interface {
+	BackendName(ctx context.Context) string
+}
+
+// The name of the backend of proxies which don't implement BackendNamer.
+const DefaultBackendName = "default"
+
+// ProxyBackendName returns the name of the backend of p which handles
+// requests with ctx.
+func ProxyBackendName(ctx context.Context, p Proxy) string {
+	if bn, ok := p.(BackendNamer); ok {
+		return bn.BackendName(ctx)
+	}
+	return DefaultBackendName
+}
+
+// MultiBackend may be implemented by proxies which forward each request
+// to one of several backends, to list the backends by the names which
+// BackendName returns.
+type MultiBackend interface {
+	Backends() map[string]Proxy
+}
+
+// ProxyBackends returns the backends of p by name. Unless p implements
+// MultiBackend, p is its only backend, named DefaultBackendName.
+func ProxyBackends(p Proxy) map[string]Proxy {
+	if mb, ok := p.(MultiBackend); ok {
+		return mb.Backends()
+	}
+	return map[string]Proxy{DefaultBackendName: p}
+}
+
+// ProxyItem describes an item stored in a proxy backend.
+type ProxyItem struct {
+	Kind         EntryKind
+	Hash         string
+	SizeOnDisk   int64 // In the proxy backend's storage format.
+	LastModified time.Time
+}
+
+// Lister may be implemented by proxy backends which can list the items
+// they store, so that they can be reconciled with the local cache. List
+// calls fn for each item, and stops if fn returns an error.
+type Lister interface {
+	List(ctx context.Context, fn func(item ProxyItem) error) error
+}
+
+// The item requested by CheckProxyHealth, which is not expected to exist.
+const healthProbeHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// CheckProxyHealth returns an error if the proxy backend p can't be
+// reached. Unless p implements HealthChecker, this requests an item from
+// p, and it's considered healthy if it reports a hit or a miss.
+func CheckProxyHealth(ctx context.Context, p Proxy) error {
+	if hc, ok := p.(HealthChecker); ok {
+		return hc.CheckHealth(ctx)
+	}
+
+	rc, _, err := p.Get(ctx, RAW, healthProbeHash)
+	if rc != nil {
+		rc.Close()
+	}
+	return err
+}
+
 // TransformActionCacheKey takes an ActionCache key and an instance name
 // and returns a new ActionCache key to use instead. If the instance name
 // is empty, then the original key is returned unchanged.
@@ -104,6 +184,150 @@ func TransformActionCacheKey(key, instance string, logger Logger) string {
 	return newKey
 }
 
+type instanceCtxKey struct{}
+
+// WithInstanceName returns a copy of ctx which records the instance name
+// of a request, so that cache entries it adds can be attributed to the
+// instance.
+func WithInstanceName(ctx context.Context, instance string) context.Context {
+	return context.WithValue(ctx, instanceCtxKey{}, instance)
+}
+
+// InstanceName returns the instance name recorded in ctx by
+// WithInstanceName, or the empty (default) instance name if there is none.
+func InstanceName(ctx context.Context) string {
+	instance, _ := ctx.Value(instanceCtxKey{}).(string)
+	return instance
+}
+
+type invocationCtxKey struct{}
+
+// WithInvocationID returns a copy of ctx which records the ID of the
+// client tool invocation which made a request, eg a Bazel build, so that
+// cache statistics can be collected per invocation.
+func WithInvocationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, invocationCtxKey{}, id)
+}
+
+// InvocationID returns the invocation ID recorded in ctx by
+// WithInvocationID, or the empty string if there is none.
+func InvocationID(ctx context.Context) string {
+	id, _ := ctx.Value(invocationCtxKey{}).(string)
+	return id
+}
+
+type uploaderCtxKey struct{}
+
+// WithUploader returns a copy of ctx which records the identity of the
+// client which made a request, eg "user:alice", so that the provenance
+// of the cache entries it uploads can be recorded.
+func WithUploader(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, uploaderCtxKey{}, identity)
+}
+
+// Uploader returns the client identity recorded in ctx by WithUploader,
+// or the empty string if there is none.
+func Uploader(ctx context.Context) string {
+	identity, _ := ctx.Value(uploaderCtxKey{}).(string)
+	return identity
+}
+
+// Priority is the scheduling class of a request. When the disk is
+// saturated, the I/O of interactive requests is preferred to that of
+// batch requests.
+type Priority int
+
+const (
+	// Interactive requests, eg from developers' builds. This is the
+	// default.
+	PriorityInteractive Priority = iota
+
+	// Batch requests, eg from nightly CI builds.
+	PriorityBatch
+
+	NumPriorities = iota
+)
+
+func (p Priority) String() string {
+	if p == PriorityBatch {
+		return "batch"
+	}
+	return "interactive"
+}
+
+// ParsePriority returns the Priority named s, and false if there is none.
+func ParsePriority(s string) (Priority, bool) {
+	switch s {
+	case "interactive":
+		return PriorityInteractive, true
+	case "batch":
+		return PriorityBatch, true
+	}
+	return PriorityInteractive, false
+}
+
+type priorityCtxKey struct{}
+
+// WithPriority returns a copy of ctx which records the scheduling class
+// of a request.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityCtxKey{}, p)
+}
+
+// RequestPriority returns the Priority recorded in ctx by WithPriority,
+// or PriorityInteractive if there is none.
+func RequestPriority(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityCtxKey{}).(Priority)
+	return p
+}
+
+// The sources of the items served by cache lookups, see LookupResult.
+const (
+	SourceLocal = "local" // The local cache directory.
+	SourceProxy = "proxy" // The proxy backend.
+	SourcePeer  = "peer"  // The cluster member which owns the item.
+)
+
+// LookupResult describes how a cache lookup was served, so that it can be
+// reported to the client.
+type LookupResult struct {
+	// Where the item was found: SourceLocal, SourceProxy or SourcePeer.
+	// Empty if the item was not found.
+	Source string
+
+	// How the item is stored in the local cache: "zstd" or "identity".
+	// Empty if unknown, eg for items served by a peer.
+	StoredCompression string
+
+	// The uncompressed size of the data served, ie the size of the item
+	// minus the offset of the read.
+	ServedSize int64
+}
+
+type lookupResultCtxKey struct{}
+
+// WithLookupResult returns a copy of ctx in which the first successful
+// cache lookup made with it is recorded, and the LookupResult which it
+// is recorded in. Later lookups, eg of the blobs referenced by an action
+// result, are not recorded.
+func WithLookupResult(ctx context.Context) (context.Context, *LookupResult) {
+	lr := &LookupResult{}
+	return context.WithValue(ctx, lookupResultCtxKey{}, lr), lr
+}
+
+// RecordLookup records how a lookup was served in the LookupResult in
+// ctx, if there is one and no lookup has been recorded in it yet.
+func RecordLookup(ctx context.Context, source string, storedCompression string, servedSize int64) {
+	lr, ok := ctx.Value(lookupResultCtxKey{}).(*LookupResult)
+	if !ok || lr.Source != "" {
+		return
+	}
+
+	lr.Source = source
+	lr.StoredCompression = storedCompression
+	lr.ServedSize = servedSize
+}
+
 func LookupKey(kind EntryKind, hash string) string {
 	return kind.String() + "/" + hash
 }
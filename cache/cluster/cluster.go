@@ -0,0 +1,496 @@
+// Package cluster spreads cache entries across a group of bazel-remote
+// instances using a consistent hash ring. Requests for entries owned by
+// another member are forwarded to that member over its HTTP API.
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/discovery"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Header is set to the shared secret of the cluster on requests forwarded
+// from another cluster member. These requests are always served by the
+// receiving member, even if its view of the hash ring differs, to avoid
+// forwarding loops. Requests without the secret are served like any other
+// client request.
+const Header = "X-Bazel-Remote-Forwarded"
+
+var (
+	forwardedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bazel_remote_cluster_forwarded_requests_total",
+		Help: "The total number of requests forwarded to the owning cluster member, by method and result",
+	}, []string{"method", "result"})
+
+	memberCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "bazel_remote_cluster_members",
+		Help: "The number of members in the cluster hash ring",
+	})
+)
+
+type fwdCtxKey struct{}
+
+// Forwarded returns a copy of ctx which marks a request as having been
+// forwarded from another cluster member.
+func Forwarded(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fwdCtxKey{}, true)
+}
+
+// IsForwarded returns true if ctx was created by Forwarded.
+func IsForwarded(ctx context.Context) bool {
+	fwd, _ := ctx.Value(fwdCtxKey{}).(bool)
+	return fwd
+}
+
+// Cluster tracks the members of the hash ring, and decides which member
+// owns each cache entry.
+type Cluster struct {
+	self   string // Name of this member, see parseMember.
+	secret string // Sent in Header with forwarded requests.
+	client *http.Client
+	logger cache.Logger
+
+	mu        sync.RWMutex
+	ring      *ring
+	names     []string         // Sorted member names, including self.
+	nodes     map[string]*Node // Remote members, by name.
+	listeners []func()
+
+	discover         *url.URL // May be nil.
+	discoverInterval time.Duration
+	resolver         *discovery.Resolver
+
+	// Returns nil if the member at baseURL is healthy. Discovered members
+	// which are not healthy are left out of the ring. May be nil.
+	checkHealth func(ctx context.Context, baseURL string) error
+}
+
+// New returns a Cluster in which this instance is reachable at selfURL.
+// Requests are forwarded to the other members with secret, which must be
+// the same on all members.
+//
+// If discoverURL is empty, the cluster members are memberURLs, which
+// must include selfURL. Otherwise the hostname in discoverURL is resolved
+// with resolver every discoverInterval, and each instance which is found
+// and healthy becomes a cluster member.
+func New(selfURL string, memberURLs []string, discoverURL string,
+	discoverInterval time.Duration, resolver *discovery.Resolver,
+	secret string, client *http.Client, logger cache.Logger) (*Cluster, error) {
+
+	self, _, err := parseMember(selfURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cluster{
+		self:             self,
+		secret:           secret,
+		client:           client,
+		logger:           logger,
+		discoverInterval: discoverInterval,
+		resolver:         resolver,
+	}
+	c.checkHealth = func(ctx context.Context, baseURL string) error {
+		return discovery.CheckHealth(ctx, client, baseURL)
+	}
+
+	if discoverURL == "" {
+		_, err = c.setMembers(memberURLs)
+		if err != nil {
+			return nil, err
+		}
+
+		if !c.hasMember(self) {
+			return nil, fmt.Errorf("The cluster members must include this instance: %s", selfURL)
+		}
+
+		return c, nil
+	}
+
+	c.discover, err = url.Parse(discoverURL)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid cluster discovery URL %q: %w", discoverURL, err)
+	}
+	if c.discover.Scheme != "http" && c.discover.Scheme != "https" {
+		return nil, fmt.Errorf("Invalid cluster discovery URL %q: must use http or https", discoverURL)
+	}
+
+	// Start with an empty ring, in which this instance owns everything,
+	// if discovery fails now. We will retry later.
+	_, _ = c.setMembers(nil)
+	c.refresh(context.Background())
+
+	return c, nil
+}
+
+// parseMember returns the name used to identify a member on the hash
+// ring, which omits any credentials, and its base URL.
+func parseMember(memberURL string) (name string, baseURL string, err error) {
+	u, err := url.Parse(memberURL)
+	if err != nil {
+		return "", "", fmt.Errorf("Invalid cluster member URL %q: %w", memberURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", "", fmt.Errorf("Invalid cluster member URL %q: must use http or https", memberURL)
+	}
+	if u.Host == "" {
+		return "", "", fmt.Errorf("Invalid cluster member URL %q: missing host", memberURL)
+	}
+
+	return u.Host, strings.TrimRight(u.String(), "/"), nil
+}
+
+func (c *Cluster) hasMember(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	i := sort.SearchStrings(c.names, name)
+	return i < len(c.names) && c.names[i] == name
+}
+
+// Replace the cluster members, and return true if they changed.
+func (c *Cluster) setMembers(memberURLs []string) (bool, error) {
+	nodes := make(map[string]*Node, len(memberURLs))
+	names := make([]string, 0, len(memberURLs))
+
+	for _, mu := range memberURLs {
+		name, baseURL, err := parseMember(mu)
+		if err != nil {
+			return false, err
+		}
+		if contains(names, name) {
+			continue
+		}
+
+		names = append(names, name)
+		if name != c.self {
+			nodes[name] = &Node{
+				name:    name,
+				baseURL: baseURL,
+				secret:  c.secret,
+				client:  c.client,
+			}
+		}
+	}
+	sort.Strings(names)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ring != nil && equal(names, c.names) {
+		return false, nil
+	}
+
+	c.names = names
+	c.nodes = nodes
+	c.ring = newRing(names)
+	memberCount.Set(float64(len(names)))
+
+	return true, nil
+}
+
+func contains(s []string, x string) bool {
+	for _, v := range s {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Members returns the names of the current cluster members, including
+// this instance.
+func (c *Cluster) Members() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return append([]string(nil), c.names...)
+}
+
+// Owner returns the member which owns the entry with the given hash, or
+// nil if this instance owns it.
+func (c *Cluster) Owner(hash string) *Node {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	owner := c.ring.owner(hash)
+	if owner == "" || owner == c.self {
+		return nil
+	}
+
+	return c.nodes[owner]
+}
+
+// OnMembershipChange registers fn to be called in a new goroutine when
+// the cluster members change, and once when Start is called.
+func (c *Cluster) OnMembershipChange(fn func()) {
+	c.mu.Lock()
+	c.listeners = append(c.listeners, fn)
+	c.mu.Unlock()
+}
+
+func (c *Cluster) notify() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, fn := range c.listeners {
+		go fn()
+	}
+}
+
+// Start notifies the membership change listeners of the initial members,
+// so that they can move entries which this instance no longer owns, and
+// starts member discovery if it is enabled.
+func (c *Cluster) Start() {
+	c.notify()
+
+	if c.discover == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(c.discoverInterval)
+		for range ticker.C {
+			if c.refresh(context.Background()) {
+				c.logger.Printf("Cluster members changed: %s", strings.Join(c.Members(), ", "))
+				c.notify()
+			}
+		}
+	}()
+}
+
+// Resolve the discovery hostname, and update the members if they changed.
+func (c *Cluster) refresh(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, c.discoverInterval)
+	defer cancel()
+
+	memberURLs, err := c.resolver.Resolve(ctx, c.discover)
+	if err != nil {
+		c.logger.Printf("Cluster discovery failed: %s", err)
+		return false
+	}
+	if len(memberURLs) == 0 {
+		// Keep the existing members, rather than owning everything.
+		c.logger.Printf("Cluster discovery found no members for %s", c.discover.Hostname())
+		return false
+	}
+
+	changed, err := c.setMembers(c.healthyMembers(ctx, memberURLs))
+	if err != nil {
+		c.logger.Printf("Cluster discovery failed: %s", err)
+		return false
+	}
+
+	if changed && !c.hasMember(c.self) {
+		c.logger.Printf("Warning: this instance (%s) was not found among the discovered cluster members", c.self)
+	}
+
+	return changed
+}
+
+// Returns the members in memberURLs which are healthy, checking them in
+// parallel. This instance is always considered healthy.
+func (c *Cluster) healthyMembers(ctx context.Context, memberURLs []string) []string {
+	if c.checkHealth == nil {
+		return memberURLs
+	}
+
+	healthy := make([]bool, len(memberURLs))
+	var wg sync.WaitGroup
+	for i, mu := range memberURLs {
+		name, _, err := parseMember(mu)
+		if err != nil || name == c.self {
+			healthy[i] = true // Invalid URLs are reported by setMembers.
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, mu string, name string) {
+			defer wg.Done()
+			err := c.checkHealth(ctx, mu)
+			if err != nil {
+				c.logger.Printf("Leaving unhealthy cluster member %s out of the ring: %s", name, err)
+				return
+			}
+			healthy[i] = true
+		}(i, mu, name)
+	}
+	wg.Wait()
+
+	result := make([]string, 0, len(memberURLs))
+	for i, mu := range memberURLs {
+		if healthy[i] {
+			result = append(result, mu)
+		}
+	}
+	return result
+}
+
+// Node is a remote cluster member.
+type Node struct {
+	name    string // For metrics and logging, without credentials.
+	baseURL string
+	secret  string
+	client  *http.Client
+}
+
+func (n *Node) String() string {
+	return n.name
+}
+
+var errUnexpectedStatus = errors.New("unexpected status")
+
+func (n *Node) newRequest(ctx context.Context, method string, kind cache.EntryKind, hash string, body io.Reader) (*http.Request, error) {
+	// The instance name is passed on so that the owner can attribute
+	// the entry to it.
+	u := n.baseURL
+	if instance := cache.InstanceName(ctx); instance != "" {
+		for _, segment := range strings.Split(instance, "/") {
+			u += "/" + url.PathEscape(segment)
+		}
+	}
+
+	// RAW entries are AC entries stored without validation. All members
+	// are expected to use the same --disable_http_ac_validation setting,
+	// so the owner maps /ac/ requests to the same kind that we do.
+	if kind == cache.CAS {
+		u += "/cas/" + hash
+	} else {
+		u += "/ac/" + hash
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(Header, n.secret)
+
+	return req, nil
+}
+
+func (n *Node) failure(method string, err error) error {
+	forwardedRequests.WithLabelValues(method, "failure").Inc()
+	return fmt.Errorf("cluster member %s: %w", n.name, err)
+}
+
+// Get returns an io.ReadCloser with the content of the entry, which is
+// zstandard compressed if zstd is true, and its logical size if known
+// (or -1 if unknown). The io.ReadCloser is nil if the member does not
+// have the entry.
+func (n *Node) Get(ctx context.Context, kind cache.EntryKind, hash string, zstd bool) (io.ReadCloser, int64, error) {
+	req, err := n.newRequest(ctx, http.MethodGet, kind, hash, nil)
+	if err != nil {
+		return nil, -1, err
+	}
+	if zstd {
+		req.Header.Set("Accept-Encoding", "zstd")
+	}
+
+	rsp, err := n.client.Do(req)
+	if err != nil {
+		return nil, -1, n.failure("get", err)
+	}
+
+	if rsp.StatusCode == http.StatusNotFound {
+		rsp.Body.Close()
+		forwardedRequests.WithLabelValues("get", "miss").Inc()
+		return nil, -1, nil
+	}
+	if rsp.StatusCode != http.StatusOK {
+		rsp.Body.Close()
+		return nil, -1, n.failure("get", fmt.Errorf("%w: %d", errUnexpectedStatus, rsp.StatusCode))
+	}
+
+	compressed := rsp.Header.Get("Content-Encoding") == "zstd"
+	if compressed != zstd {
+		rsp.Body.Close()
+		return nil, -1, n.failure("get", fmt.Errorf("unexpected Content-Encoding: %q", rsp.Header.Get("Content-Encoding")))
+	}
+
+	size := int64(-1)
+	if !compressed {
+		size = rsp.ContentLength
+	}
+
+	forwardedRequests.WithLabelValues("get", "hit").Inc()
+	return rsp.Body, size, nil
+}
+
+// Contains returns true if the member has the entry, and its size if
+// known (or -1 if unknown).
+func (n *Node) Contains(ctx context.Context, kind cache.EntryKind, hash string) (bool, int64, error) {
+	req, err := n.newRequest(ctx, http.MethodHead, kind, hash, nil)
+	if err != nil {
+		return false, -1, err
+	}
+
+	rsp, err := n.client.Do(req)
+	if err != nil {
+		return false, -1, n.failure("head", err)
+	}
+	rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusOK:
+		forwardedRequests.WithLabelValues("head", "hit").Inc()
+		return true, rsp.ContentLength, nil
+	case http.StatusNotFound:
+		forwardedRequests.WithLabelValues("head", "miss").Inc()
+		return false, -1, nil
+	}
+
+	return false, -1, n.failure("head", fmt.Errorf("%w: %d", errUnexpectedStatus, rsp.StatusCode))
+}
+
+// Put uploads size bytes of uncompressed data from r to the member.
+func (n *Node) Put(ctx context.Context, kind cache.EntryKind, hash string, size int64, r io.Reader) error {
+	if size == 0 {
+		r = http.NoBody
+	}
+
+	req, err := n.newRequest(ctx, http.MethodPut, kind, hash, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.ContentLength = size
+
+	rsp, err := n.client.Do(req)
+	if err != nil {
+		return n.failure("put", err)
+	}
+	defer rsp.Body.Close()
+	_, _ = io.Copy(io.Discard, rsp.Body)
+
+	if rsp.StatusCode != http.StatusOK {
+		return n.failure("put", fmt.Errorf("%w: %d", errUnexpectedStatus, rsp.StatusCode))
+	}
+
+	forwardedRequests.WithLabelValues("put", "success").Inc()
+	return nil
+}
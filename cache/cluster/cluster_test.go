@@ -0,0 +1,297 @@
+package cluster
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/discovery"
+)
+
+func TestForwarded(t *testing.T) {
+	ctx := context.Background()
+	if IsForwarded(ctx) {
+		t.Error("Expected a plain context not to be forwarded")
+	}
+	if !IsForwarded(Forwarded(ctx)) {
+		t.Error("Expected the context to be forwarded")
+	}
+}
+
+func testHash(i int) string {
+	sum := sha256.Sum256([]byte(strconv.Itoa(i)))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestRing(t *testing.T) {
+	const numKeys = 10000
+
+	three := newRing([]string{"a:8080", "b:8080", "c:8080"})
+	four := newRing([]string{"a:8080", "b:8080", "c:8080", "d:8080"})
+
+	counts := make(map[string]int)
+	moved := 0
+	for i := 0; i < numKeys; i++ {
+		h := testHash(i)
+		before := three.owner(h)
+		after := four.owner(h)
+		counts[before]++
+
+		if before != after {
+			if after != "d:8080" {
+				t.Fatalf("Key %s moved from %s to %s, expected only moves to the new member",
+					h, before, after)
+			}
+			moved++
+		}
+	}
+
+	for m, n := range counts {
+		if n < numKeys/5 {
+			t.Errorf("Expected %s to own roughly a third of the keys, found %d/%d", m, n, numKeys)
+		}
+	}
+
+	if moved < numKeys/8 || moved > numKeys/3 {
+		t.Errorf("Expected roughly a quarter of the keys to move to the new member, found %d/%d",
+			moved, numKeys)
+	}
+
+	if newRing(nil).owner(testHash(0)) != "" {
+		t.Error("Expected an empty ring to have no owners")
+	}
+}
+
+func TestNewStatic(t *testing.T) {
+	logger := testutils.NewSilentLogger()
+
+	c, err := New("http://a:8080", []string{"http://b:8080/", "http://a:8080", "http://user:pass@c:8080"},
+		"", 0, nil, "secret", &http.Client{}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"a:8080", "b:8080", "c:8080"}
+	if !reflect.DeepEqual(c.Members(), expected) {
+		t.Errorf("Expected members %v, got %v", expected, c.Members())
+	}
+
+	local := 0
+	for i := 0; i < 100; i++ {
+		owner := c.Owner(testHash(i))
+		if owner == nil {
+			local++
+			continue
+		}
+		if owner.String() != "b:8080" && owner.String() != "c:8080" {
+			t.Errorf("Unexpected owner: %s", owner)
+		}
+		if owner.String() == "c:8080" && owner.baseURL != "http://user:pass@c:8080" {
+			t.Errorf("Expected the credentials to be kept in the base URL, got %s", owner.baseURL)
+		}
+	}
+	if local == 0 || local == 100 {
+		t.Errorf("Expected this member to own some but not all keys, found %d/100", local)
+	}
+
+	invalid := [][]string{
+		{"http://b:8080", "http://c:8080"}, // Missing self.
+		{"http://a:8080", "ftp://b:8080"},
+		{"http://a:8080", "http://"},
+	}
+	for _, members := range invalid {
+		_, err = New("http://a:8080", members, "", 0, nil, "secret", &http.Client{}, logger)
+		if err == nil {
+			t.Errorf("Expected an error for members %v", members)
+		}
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	addrs := []string{"10.0.0.2", "10.0.0.1"}
+	var lookupErr error
+
+	c := &Cluster{
+		self:             "10.0.0.1:8080",
+		client:           &http.Client{},
+		logger:           testutils.NewSilentLogger(),
+		discoverInterval: time.Second,
+		resolver: &discovery.Resolver{
+			LookupHost: func(ctx context.Context, host string) ([]string, error) {
+				if host != "bazel-remote" {
+					t.Errorf("Unexpected lookup: %s", host)
+				}
+				return addrs, lookupErr
+			},
+		},
+	}
+
+	var err error
+	c.discover, err = url.Parse("http://bazel-remote:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.refresh(context.Background()) {
+		t.Error("Expected the first refresh to change the members")
+	}
+	expected := []string{"10.0.0.1:8080", "10.0.0.2:8080"}
+	if !reflect.DeepEqual(c.Members(), expected) {
+		t.Errorf("Expected members %v, got %v", expected, c.Members())
+	}
+
+	addrs = []string{"10.0.0.1", "10.0.0.2"}
+	if c.refresh(context.Background()) {
+		t.Error("Expected no change when the addresses are reordered")
+	}
+
+	lookupErr = errors.New("lookup failed")
+	if c.refresh(context.Background()) {
+		t.Error("Expected no change when the lookup fails")
+	}
+
+	lookupErr = nil
+	addrs = []string{"10.0.0.1", "10.0.0.2", "fd00::3"}
+	if !c.refresh(context.Background()) {
+		t.Error("Expected a change when a member is added")
+	}
+	expected = []string{"10.0.0.1:8080", "10.0.0.2:8080", "[fd00::3]:8080"}
+	if !reflect.DeepEqual(c.Members(), expected) {
+		t.Errorf("Expected members %v, got %v", expected, c.Members())
+	}
+}
+
+func TestDiscoveryHealthChecks(t *testing.T) {
+	unhealthy := map[string]bool{"http://10.0.0.3:8080": true}
+
+	c := &Cluster{
+		self:             "10.0.0.1:8080",
+		client:           &http.Client{},
+		logger:           testutils.NewSilentLogger(),
+		discoverInterval: time.Second,
+		resolver: &discovery.Resolver{
+			LookupHost: func(ctx context.Context, host string) ([]string, error) {
+				return []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, nil
+			},
+		},
+		checkHealth: func(ctx context.Context, baseURL string) error {
+			if baseURL == "http://10.0.0.1:8080" {
+				t.Error("Expected this instance not to be checked")
+			}
+			if unhealthy[baseURL] {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	}
+
+	var err error
+	c.discover, err = url.Parse("http://bazel-remote:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.refresh(context.Background())
+	expected := []string{"10.0.0.1:8080", "10.0.0.2:8080"}
+	if !reflect.DeepEqual(c.Members(), expected) {
+		t.Errorf("Expected the unhealthy member to be left out, got %v", c.Members())
+	}
+
+	unhealthy = nil
+	if !c.refresh(context.Background()) {
+		t.Error("Expected a change when a member becomes healthy")
+	}
+	expected = append(expected, "10.0.0.3:8080")
+	if !reflect.DeepEqual(c.Members(), expected) {
+		t.Errorf("Expected members %v, got %v", expected, c.Members())
+	}
+}
+
+func TestNode(t *testing.T) {
+	const hash = "fe3a3a8a9c8ac5e6a0d1c2b52b8b0a8e4a3c9e3a8b6cbb3a1cf9b07e5d7a8d4f"
+	stored := make(map[string]string)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(Header) != "secret" {
+			t.Errorf("Expected the %s header to be set to the secret", Header)
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			stored[r.URL.Path] = string(body)
+		case http.MethodGet, http.MethodHead:
+			data, found := stored[r.URL.Path]
+			if !found {
+				http.Error(w, "Not found", http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(data))
+			}
+		}
+	}))
+	defer srv.Close()
+
+	n := &Node{name: "test", baseURL: srv.URL, secret: "secret", client: &http.Client{}}
+	ctx := context.Background()
+
+	found, _, err := n.Contains(ctx, cache.AC, hash)
+	if err != nil || found {
+		t.Fatalf("Expected a miss, got %v, %v", found, err)
+	}
+
+	rc, _, err := n.Get(ctx, cache.AC, hash, false)
+	if err != nil || rc != nil {
+		t.Fatalf("Expected a miss, got %v, %v", rc, err)
+	}
+
+	err = n.Put(ctx, cache.RAW, hash, 5, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored["/ac/"+hash] != "hello" {
+		t.Errorf("Expected RAW entries to be stored under /ac/, got %v", stored)
+	}
+
+	found, size, err := n.Contains(ctx, cache.AC, hash)
+	if err != nil || !found || size != 5 {
+		t.Fatalf("Expected a hit with size 5, got %v, %d, %v", found, size, err)
+	}
+
+	rc, size, err = n.Get(ctx, cache.AC, hash, false)
+	if err != nil || rc == nil {
+		t.Fatalf("Expected a hit, got %v, %v", rc, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello" || size != 5 {
+		t.Errorf("Expected \"hello\" with size 5, got %q with size %d", data, size)
+	}
+
+	// The instance name is passed on, so that the owner can attribute
+	// the entry to it.
+	err = n.Put(cache.WithInstanceName(ctx, "team/a"), cache.CAS, hash, 5, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := stored["/team/a/cas/"+hash]; !found {
+		t.Errorf("Expected the entry to be stored under /team/a/cas/, got %v", stored)
+	}
+}
@@ -0,0 +1,90 @@
+package cluster
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+	"strconv"
+)
+
+// The number of points that each member occupies on the hash ring. More
+// points give a more even distribution of keys between members.
+const virtualNodes = 128
+
+// ring is an immutable consistent hash ring. When a member joins or
+// leaves, only the keys on the points adjacent to that member's points
+// change owner.
+type ring struct {
+	points []uint64
+	owners []string // owners[i] owns the keys up to and including points[i].
+}
+
+func newRing(members []string) *ring {
+	r := &ring{}
+
+	type point struct {
+		pos   uint64
+		owner string
+	}
+	points := make([]point, 0, len(members)*virtualNodes)
+	for _, m := range members {
+		for i := 0; i < virtualNodes; i++ {
+			points = append(points, point{
+				pos:   hashString(m + "-" + strconv.Itoa(i)),
+				owner: m,
+			})
+		}
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].pos == points[j].pos {
+			// Break ties consistently on all nodes.
+			return points[i].owner < points[j].owner
+		}
+		return points[i].pos < points[j].pos
+	})
+
+	r.points = make([]uint64, len(points))
+	r.owners = make([]string, len(points))
+	for i, p := range points {
+		r.points[i] = p.pos
+		r.owners[i] = p.owner
+	}
+
+	return r
+}
+
+// Returns the member which owns hash, or "" if the ring is empty.
+func (r *ring) owner(hash string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	pos := keyPosition(hash)
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i] >= pos
+	})
+	if i == len(r.points) {
+		i = 0 // Wrap around.
+	}
+
+	return r.owners[i]
+}
+
+func hashString(s string) uint64 {
+	sum := sha256.Sum256([]byte(s))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// Cache keys are already sha256 hashes, so we can use them directly.
+func keyPosition(hash string) uint64 {
+	if len(hash) >= 16 {
+		b, err := hex.DecodeString(hash[:16])
+		if err == nil {
+			return binary.BigEndian.Uint64(b)
+		}
+	}
+
+	return hashString(hash)
+}
@@ -0,0 +1,401 @@
+package disk
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/utils/tempfile"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The entries found in the cache directory at startup are ordered by the
+// access times of their files, see atime.go, which are only as accurate
+// as the mount options allow: with relatime they are updated at most once
+// per day, and with noatime never. With WithAccessJournal the access
+// times which are tracked in the index are also appended to a journal,
+// which is replayed at startup, so the recency order survives restarts
+// regardless of the mount options.
+//
+// Accesses are collected in memory, where repeated accesses of an entry
+// are merged, and appended to the journal as a gzip member every
+// accessJournalFlushInterval, or sooner when maxPendingAccesses entries
+// were accessed. A member which was only partially written, eg after a
+// crash, is truncated at startup, so at most the accesses since the last
+// write are lost. When the journal has grown to twice its size after the
+// last compaction, and at least minAccessJournalCompactSize, it is
+// compacted by rewriting it with the access times of the entries in the
+// index.
+//
+// Each record consists of the entry's kind (1 byte), its digest (32
+// bytes) and the access time in Unix seconds (4 bytes, little endian).
+// The latest access time of each entry wins.
+
+const accessJournalDirName = "access_journal"
+
+const accessJournalFlushInterval = 10 * time.Second
+
+const maxPendingAccesses = 64 * 1024
+
+const minAccessJournalCompactSize = 64 * 1024 * 1024
+
+const accessRecordSize = 1 + sha256.Size + 4
+
+type accessRecord struct {
+	key        Key
+	lastAccess uint32
+}
+
+// It is safe to call the methods of a nil *accessJournal, which doesn't
+// record anything.
+type accessJournal struct {
+	path string
+
+	mu      sync.Mutex
+	pending map[Key]uint32 // Accesses which haven't been written yet.
+	full    chan struct{}  // Signalled when pending gets large.
+
+	// Only used at startup and by the goroutine which writes the journal.
+	f           *os.File // Opened for appending.
+	size        int64    // The size of the journal.
+	compactSize int64    // The size of the journal after the last compaction.
+
+	gaugeSize prometheus.Gauge
+}
+
+func newAccessJournal(cacheDir string) *accessJournal {
+	return &accessJournal{
+		path:    filepath.Join(cacheDir, accessJournalDirName, journalName),
+		pending: make(map[Key]uint32),
+		full:    make(chan struct{}, 1),
+		gaugeSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_access_journal_bytes",
+			Help: "The size of the journal of entry access times, with the access_journal setting",
+		}),
+	}
+}
+
+func (j *accessJournal) registerMetrics() {
+	if j == nil {
+		return
+	}
+
+	prometheus.MustRegister(j.gaugeSize)
+}
+
+// Record that key was accessed at lastAccess, in Unix seconds. This is
+// called by the index, with the cache's lock held.
+func (j *accessJournal) record(key Key, lastAccess uint32) {
+	j.mu.Lock()
+	j.pending[key] = lastAccess
+	n := len(j.pending)
+	j.mu.Unlock()
+
+	if n >= maxPendingAccesses {
+		select {
+		case j.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Returns the latest access time of each entry in the journal, after
+// truncating a partially written member at its end. Must be called
+// before open.
+func (j *accessJournal) replay() (map[Key]uint32, error) {
+	err := os.MkdirAll(filepath.Dir(j.path), os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	lastAccess := make(map[Key]uint32)
+
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return lastAccess, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	valid := readAccessRecords(f, func(r accessRecord) {
+		if r.lastAccess > lastAccess[r.key] {
+			lastAccess[r.key] = r.lastAccess
+		}
+	})
+
+	if valid < fi.Size() {
+		log.Printf("Truncating the access journal from %d to %d bytes, after an incomplete write",
+			fi.Size(), valid)
+		err = os.Truncate(j.path, valid)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	j.size = valid
+	j.compactSize = valid
+
+	return lastAccess, nil
+}
+
+// Open the journal for appending.
+func (j *accessJournal) open() error {
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	j.f = f
+	j.gaugeSize.Set(float64(j.size))
+
+	return nil
+}
+
+// Counts the bytes read through it. gzip.Reader uses the io.ByteReader
+// method without buffering, so the count ends exactly at the end of each
+// member.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// Calls fn for each record of the complete gzip members read from r, and
+// returns the number of bytes up to the end of the last complete member.
+// The records of an incomplete or corrupt member, and anything after it,
+// are ignored.
+func readAccessRecords(r io.Reader, fn func(accessRecord)) int64 {
+	cr := &countingReader{r: bufio.NewReader(r)}
+
+	var valid int64
+	var zr *gzip.Reader
+	for {
+		var err error
+		if zr == nil {
+			zr, err = gzip.NewReader(cr)
+		} else {
+			err = zr.Reset(cr)
+		}
+		if err != nil {
+			return valid
+		}
+		zr.Multistream(false)
+
+		data, err := io.ReadAll(zr)
+		if err != nil || len(data)%accessRecordSize != 0 {
+			return valid
+		}
+
+		for ; len(data) > 0; data = data[accessRecordSize:] {
+			var r accessRecord
+			r.key.kind = data[0]
+			copy(r.key.digest[:], data[1:1+sha256.Size])
+			r.lastAccess = binary.LittleEndian.Uint32(data[1+sha256.Size:])
+			fn(r)
+		}
+
+		valid = cr.n
+	}
+}
+
+// Write records to w as gzip members of at most maxPendingAccesses
+// records each, so that they can be read back without holding all of
+// them in memory.
+func writeAccessRecords(w io.Writer, records []accessRecord) error {
+	zw := gzip.NewWriter(w)
+	var buf [accessRecordSize]byte
+
+	for len(records) > 0 {
+		n := len(records)
+		if n > maxPendingAccesses {
+			n = maxPendingAccesses
+		}
+
+		for _, r := range records[:n] {
+			buf[0] = r.key.kind
+			copy(buf[1:], r.key.digest[:])
+			binary.LittleEndian.PutUint32(buf[1+sha256.Size:], r.lastAccess)
+
+			_, err := zw.Write(buf[:])
+			if err != nil {
+				return err
+			}
+		}
+
+		err := zw.Close()
+		if err != nil {
+			return err
+		}
+		zw.Reset(w)
+
+		records = records[n:]
+	}
+
+	return nil
+}
+
+// Replay the access journal, and use the access times in it for the
+// entries in result which were accessed later than their files say.
+func (c *diskCache) applyAccessJournal(result scanResult) error {
+	lastAccess, err := c.accessJournal.replay()
+	if err != nil {
+		return err
+	}
+
+	updated := 0
+	for _, m := range result.metadata {
+		t, found := lastAccess[m.lookupKey]
+		if found && int64(t) > m.ts.Unix() {
+			m.ts = time.Unix(int64(t), 0)
+			updated++
+		}
+	}
+
+	log.Printf("Using the access journal's access times for %d of %d entries", updated, len(result.metadata))
+
+	return nil
+}
+
+// Append the pending accesses to the journal every
+// accessJournalFlushInterval, or when there are many of them.
+func (c *diskCache) writeAccessJournal() {
+	ticker := time.NewTicker(accessJournalFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.accessJournal.full:
+		}
+
+		err := c.flushAccessJournal()
+		if err != nil {
+			log.Printf("Failed to write the access journal: %v", err)
+		}
+	}
+}
+
+// Append the pending accesses to the journal, and compact it if it has
+// grown too large.
+func (c *diskCache) flushAccessJournal() error {
+	j := c.accessJournal
+
+	j.mu.Lock()
+	pending := j.pending
+	j.pending = make(map[Key]uint32)
+	j.mu.Unlock()
+
+	if len(pending) > 0 {
+		records := make([]accessRecord, 0, len(pending))
+		for key, lastAccess := range pending {
+			records = append(records, accessRecord{key: key, lastAccess: lastAccess})
+		}
+
+		bw := bufio.NewWriter(j.f)
+		err := writeAccessRecords(bw, records)
+		if err == nil {
+			err = bw.Flush()
+		}
+		fi, statErr := j.f.Stat()
+		if statErr == nil {
+			j.size = fi.Size()
+			j.gaugeSize.Set(float64(j.size))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if j.size < minAccessJournalCompactSize || j.size < 2*j.compactSize {
+		return nil
+	}
+
+	return c.compactAccessJournal()
+}
+
+// Replace the journal with one which has a record for each entry in the
+// index. Accesses which happen meanwhile stay pending, and are appended
+// to the new journal.
+func (c *diskCache) compactAccessJournal() error {
+	j := c.accessJournal
+
+	c.mu.Lock()
+	records := make([]accessRecord, 0, c.lru.Len())
+	for ele := c.lru.ll.Back(); ele != nil; ele = ele.Prev() {
+		e := ele.Value.(*entry)
+		records = append(records, accessRecord{key: e.key, lastAccess: e.lastAccess})
+	}
+	c.mu.Unlock()
+
+	tmpName := j.path + tempfile.Suffix
+	f, err := os.Create(tmpName)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(f)
+	err = writeAccessRecords(bw, records)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpName, j.path)
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	j.f.Close()
+	err = j.open()
+	if err != nil {
+		return err
+	}
+
+	fi, err := j.f.Stat()
+	if err != nil {
+		return err
+	}
+	j.size = fi.Size()
+	j.compactSize = j.size
+	j.gaugeSize.Set(float64(j.size))
+
+	log.Printf("Compacted the access journal to %d bytes, with %d entries", j.size, len(records))
+
+	return nil
+}
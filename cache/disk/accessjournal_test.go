@@ -0,0 +1,132 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestAccessJournalRecords(t *testing.T) {
+	var a, b Key
+	a.kind = uint8(cache.CAS)
+	a.digest[0] = 0xaa
+	b.kind = uint8(cache.AC)
+	b.digest[0] = 0xbb
+
+	var buf bytes.Buffer
+	err := writeAccessRecords(&buf, []accessRecord{{a, 1}, {b, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	complete := int64(buf.Len())
+	err = writeAccessRecords(&buf, []accessRecord{{a, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cut the second member short, like a crash during a write would.
+	data := buf.Bytes()[:buf.Len()-3]
+
+	var records []accessRecord
+	valid := readAccessRecords(bytes.NewReader(data), func(r accessRecord) {
+		records = append(records, r)
+	})
+
+	if valid != complete {
+		t.Errorf("Expected %d valid bytes, got %d", complete, valid)
+	}
+	expected := []accessRecord{{a, 1}, {b, 2}}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected %v, got %v", expected, records)
+	}
+}
+
+func TestAccessJournalReplay(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	open := func() *diskCache {
+		c, err := New(cacheDir, BlockSize*10, WithAccessJournal(), WithAccessLogger(testutils.NewSilentLogger()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.(*diskCache)
+	}
+
+	c := open()
+
+	// Add the entries an hour apart, and then read the first one.
+	now := time.Now().Add(-10 * time.Hour)
+	c.lru.now = func() time.Time {
+		now = now.Add(time.Hour)
+		return now
+	}
+
+	var keys []Key
+	for i := 0; i < 3; i++ {
+		data, hash := testutils.RandomDataAndHash(100)
+		err := c.Put(context.Background(), cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, _ := newKey(cache.CAS, hash)
+		keys = append(keys, key)
+	}
+
+	rc, _, err := c.Get(context.Background(), cache.CAS, keys[0].Hash(), 100, 0)
+	if err != nil || rc == nil {
+		t.Fatal("Expected a cache hit", err)
+	}
+	_, _ = io.Copy(io.Discard, rc)
+	rc.Close()
+
+	// Make the files say that all the entries were last used a day ago,
+	// as if the filesystem didn't update access times.
+	for _, key := range keys {
+		item, _ := c.lru.peek(key)
+		ts := time.Now().Add(-24 * time.Hour)
+		err := os.Chtimes(c.getElementPath(key, item), ts, ts)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = c.flushAccessJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c = open()
+	if front := c.lru.ll.Front().Value.(*entry).key; front != keys[0] {
+		t.Errorf("Expected the entry which was read to be the most recently used, got %s", front)
+	}
+	if back := c.lru.ll.Back().Value.(*entry).key; back != keys[1] {
+		t.Errorf("Expected %s to be the least recently used, got %s", keys[1], back)
+	}
+
+	// Compaction keeps the access times of the entries in the index.
+	err = c.compactAccessJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastAccess, err := newAccessJournal(cacheDir).replay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lastAccess) != len(keys) {
+		t.Errorf("Expected %d entries in the compacted journal, got %d", len(keys), len(lastAccess))
+	}
+	for ele := c.lru.ll.Front(); ele != nil; ele = ele.Next() {
+		e := ele.Value.(*entry)
+		if lastAccess[e.key] != e.lastAccess {
+			t.Errorf("Expected %s to have access time %d, got %d", e.key, e.lastAccess, lastAccess[e.key])
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package disk
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// The admin UI shows the recent activity of the cache: its hit rate over
+// the last day, and the entries which were evicted most recently. This is
+// only tracked if enabled with WithActivityTracking.
+
+const (
+	hitRateInterval    = time.Minute
+	hitRateSamples     = 24 * 60 // One day.
+	maxRecentEvictions = 100
+)
+
+// HitRateSample counts the cache lookups in one interval.
+type HitRateSample struct {
+	Time   int64 `json:"time"` // Unix time of the start of the interval.
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// EntrySummary describes an entry in the cache.
+type EntrySummary struct {
+	Kind        string `json:"kind"`
+	Hash        string `json:"hash"`
+	LogicalSize int64  `json:"logical_size"`
+	SizeOnDisk  int64  `json:"size_on_disk"`
+	Instance    string `json:"instance,omitempty"`
+
+	// Unix time. For evicted entries, this is when they were evicted.
+	LastAccess int64 `json:"last_access"`
+}
+
+// Activity reports the recent activity of the cache.
+type Activity struct {
+	// From oldest to newest, one sample per minute.
+	HitRate []HitRateSample `json:"hit_rate"`
+
+	// From most to least recently evicted.
+	RecentEvictions []EntrySummary `json:"recent_evictions"`
+}
+
+// Tracks the recent activity of a cache. It is safe to call the methods
+// of a nil *activityTracker, which doesn't track anything.
+type activityTracker struct {
+	now func() time.Time
+
+	mu            sync.Mutex
+	samples       []HitRateSample // From oldest to newest.
+	evictions     []EntrySummary  // A ring buffer.
+	nextEviction  int
+	evictionsFull bool
+}
+
+func newActivityTracker() *activityTracker {
+	return &activityTracker{
+		now:       time.Now,
+		evictions: make([]EntrySummary, maxRecentEvictions),
+	}
+}
+
+// Return the sample for the current interval, adding samples for the
+// intervals without lookups since the last one. Must be called with t.mu
+// held.
+func (t *activityTracker) currentSample() *HitRateSample {
+	start := t.now().Truncate(hitRateInterval).Unix()
+	step := int64(hitRateInterval.Seconds())
+
+	next := start
+	if n := len(t.samples); n > 0 {
+		last := t.samples[n-1].Time
+		if last >= start {
+			return &t.samples[n-1]
+		}
+
+		next = last + step
+		if oldest := start - (hitRateSamples-1)*step; next < oldest {
+			next = oldest
+		}
+	}
+
+	for ; next <= start; next += step {
+		t.samples = append(t.samples, HitRateSample{Time: next})
+	}
+	if len(t.samples) > hitRateSamples {
+		t.samples = append(t.samples[:0], t.samples[len(t.samples)-hitRateSamples:]...)
+	}
+
+	return &t.samples[len(t.samples)-1]
+}
+
+func (t *activityTracker) recordLookup(hit bool) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := t.currentSample()
+	if hit {
+		s.Hits++
+	} else {
+		s.Misses++
+	}
+}
+
+func (t *activityTracker) recordEviction(key Key, item lruItem) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.evictions[t.nextEviction] = EntrySummary{
+		Kind:        key.Kind().String(),
+		Hash:        key.Hash(),
+		LogicalSize: item.size,
+		SizeOnDisk:  item.sizeOnDisk,
+		LastAccess:  t.now().Unix(),
+	}
+	t.nextEviction++
+	if t.nextEviction == len(t.evictions) {
+		t.nextEviction = 0
+		t.evictionsFull = true
+	}
+}
+
+func (t *activityTracker) activity() Activity {
+	if t == nil {
+		return Activity{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Include the intervals without lookups up to now.
+	t.currentSample()
+
+	a := Activity{
+		HitRate:         append([]HitRateSample(nil), t.samples...),
+		RecentEvictions: make([]EntrySummary, 0, maxRecentEvictions),
+	}
+
+	for i := t.nextEviction - 1; i >= 0; i-- {
+		a.RecentEvictions = append(a.RecentEvictions, t.evictions[i])
+	}
+	if t.evictionsFull {
+		for i := len(t.evictions) - 1; i >= t.nextEviction; i-- {
+			a.RecentEvictions = append(a.RecentEvictions, t.evictions[i])
+		}
+	}
+
+	return a
+}
+
+// Activity returns the recent activity of the cache, which is empty
+// unless it is tracked. See WithActivityTracking.
+func (c *diskCache) Activity() Activity {
+	return c.activity.activity()
+}
+
+// Describe returns a summary of the entry with the given kind and hash,
+// and false if it is not in the cache. This does not mark the entry as
+// recently used, or check the proxy backend.
+func (c *diskCache) Describe(kind cache.EntryKind, hash string) (EntrySummary, bool) {
+	key, ok := newKey(kind, hash)
+	if !ok {
+		return EntrySummary{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ele, found := c.lru.cache[key]
+	if !found {
+		return EntrySummary{}, false
+	}
+
+	return summarize(ele.Value.(*entry)), true
+}
+
+// LargestEntries returns summaries of the n largest entries in the cache,
+// by size on disk, from largest to smallest.
+func (c *diskCache) LargestEntries(n int) []EntrySummary {
+	if n <= 0 {
+		return nil
+	}
+
+	h := make(entryHeap, 0, n)
+
+	c.mu.Lock()
+	for ele := c.lru.ll.Front(); ele != nil; ele = ele.Next() {
+		e := ele.Value.(*entry)
+		if len(h) < n {
+			heap.Push(&h, e)
+		} else if e.value.sizeOnDisk > h[0].value.sizeOnDisk {
+			h[0] = e
+			heap.Fix(&h, 0)
+		}
+	}
+
+	summaries := make([]EntrySummary, len(h))
+	for i := len(h) - 1; i >= 0; i-- {
+		summaries[i] = summarize(heap.Pop(&h).(*entry))
+	}
+	c.mu.Unlock()
+
+	return summaries
+}
+
+// Must be called with c.mu held, if e is in the cache.
+func summarize(e *entry) EntrySummary {
+	s := EntrySummary{
+		Kind:        e.key.Kind().String(),
+		Hash:        e.key.Hash(),
+		LogicalSize: e.value.size,
+		SizeOnDisk:  e.value.sizeOnDisk,
+		LastAccess:  int64(e.lastAccess),
+	}
+	if e.usage != nil {
+		s.Instance = e.usage.name
+	}
+	return s
+}
+
+// A min-heap of entries, by size on disk.
+type entryHeap []*entry
+
+func (h entryHeap) Len() int           { return len(h) }
+func (h entryHeap) Less(i, j int) bool { return h[i].value.sizeOnDisk < h[j].value.sizeOnDisk }
+func (h entryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *entryHeap) Push(x interface{}) {
+	*h = append(*h, x.(*entry))
+}
+
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,130 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestActivityHitRate(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 30, 0, time.UTC)
+	tracker := newActivityTracker()
+	tracker.now = func() time.Time { return now }
+
+	tracker.recordLookup(true)
+	tracker.recordLookup(false)
+	now = now.Add(3 * time.Minute)
+	tracker.recordLookup(true)
+
+	samples := tracker.activity().HitRate
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC).Unix()
+	expected := []HitRateSample{
+		{Time: start, Hits: 1, Misses: 1},
+		{Time: start + 60},
+		{Time: start + 120},
+		{Time: start + 180, Hits: 1},
+	}
+	if len(samples) != len(expected) {
+		t.Fatalf("Expected %d samples, got %+v", len(expected), samples)
+	}
+	for i := range expected {
+		if samples[i] != expected[i] {
+			t.Errorf("Expected sample %d to be %+v, got %+v", i, expected[i], samples[i])
+		}
+	}
+
+	// Only the samples of the last day are kept.
+	now = now.Add(48 * time.Hour)
+	samples = tracker.activity().HitRate
+	if len(samples) != hitRateSamples {
+		t.Fatalf("Expected %d samples, got %d", hitRateSamples, len(samples))
+	}
+	if samples[len(samples)-1].Time != now.Truncate(time.Minute).Unix() {
+		t.Errorf("Expected the last sample to be for the current minute, got %+v", samples[len(samples)-1])
+	}
+	for _, s := range samples {
+		if s.Hits != 0 || s.Misses != 0 {
+			t.Fatalf("Expected no lookups in the last day, got %+v", s)
+		}
+	}
+}
+
+func TestActivityEvictions(t *testing.T) {
+	tracker := newActivityTracker()
+
+	hashes := make([]string, maxRecentEvictions+10)
+	for i := range hashes {
+		_, hashes[i] = testutils.RandomDataAndHash(1)
+		key, _ := newKey(cache.CAS, hashes[i])
+		tracker.recordEviction(key, lruItem{size: int64(i), sizeOnDisk: int64(i)})
+	}
+
+	evictions := tracker.activity().RecentEvictions
+	if len(evictions) != maxRecentEvictions {
+		t.Fatalf("Expected %d evictions, got %d", maxRecentEvictions, len(evictions))
+	}
+	for i, e := range evictions {
+		expected := hashes[len(hashes)-1-i]
+		if e.Hash != expected || e.Kind != "cas" {
+			t.Fatalf("Expected eviction %d to be cas/%s, got %+v", i, expected, e)
+		}
+	}
+}
+
+func TestActivityTracking(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := New(cacheDir, 8*BlockSize, WithActivityTracking(), WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	var hashes []string
+	for _, size := range []int64{1000, 3000, 2000, 4000, 2500} {
+		data, hash := testutils.RandomDataAndHash(size)
+		err = c.Put(ctx, cache.RAW, hash, size, bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	largest := c.LargestEntries(2)
+	if len(largest) != 2 || largest[0].Hash != hashes[3] || largest[1].Hash != hashes[1] {
+		t.Errorf("Expected the largest entries to be %s and %s, got %+v", hashes[3], hashes[1], largest)
+	}
+
+	summary, found := c.Describe(cache.RAW, hashes[4])
+	if !found || summary.SizeOnDisk != 2500 || summary.LastAccess == 0 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	_, found = c.Describe(cache.CAS, hashes[4])
+	if found {
+		t.Error("Expected no CAS entry")
+	}
+
+	// Fill the cache, so that the least recently used entries are
+	// evicted.
+	for i := 0; i < 4; i++ {
+		data, hash := testutils.RandomDataAndHash(4000)
+		err = c.Put(ctx, cache.RAW, hash, 4000, bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	evictions := c.Activity().RecentEvictions
+	if len(evictions) == 0 {
+		t.Fatal("Expected evictions")
+	}
+	if last := evictions[len(evictions)-1]; last.Hash != hashes[0] {
+		t.Errorf("Expected %s to be evicted first, got %+v", hashes[0], last)
+	}
+}
@@ -0,0 +1,155 @@
+package disk
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The distribution of the idle times (now - last access) of the entries
+// in the LRU index is exported as a histogram per kind of entry, so that
+// the cache size can be tuned against how long entries are actually
+// reused for. Unlike the bazel_remote_disk_cache_longest_item_idle_time_seconds
+// gauge, the access times are tracked in memory, to the second, so they
+// don't depend on filesystem mount options like relatime. At startup
+// they are initialized from the files' atimes.
+//
+// Computing the distribution requires visiting every entry with the lock
+// held, so it is only recomputed every ageDistributionInterval.
+
+const ageDistributionInterval = 5 * time.Minute
+
+// The upper bounds of the age histogram buckets, in seconds: from one
+// minute to 90 days.
+var idleTimeBuckets = []float64{
+	60, 5 * 60, 15 * 60,
+	3600, 3 * 3600, 6 * 3600, 12 * 3600,
+	86400, 2 * 86400, 4 * 86400, 7 * 86400, 14 * 86400, 30 * 86400, 60 * 86400, 90 * 86400,
+}
+
+var ageKinds = []cache.EntryKind{cache.AC, cache.CAS, cache.RAW}
+
+// Convert t to the Unix seconds stored in entry.lastAccess.
+func unixSeconds(t time.Time) uint32 {
+	s := t.Unix()
+	if s < 0 {
+		return 0
+	}
+	if s > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(s)
+}
+
+// The age distribution of the entries of one kind.
+type ageHistogram struct {
+	counts []uint64 // Per bucket, not cumulative. The last one is +Inf.
+	count  uint64
+	sum    float64
+}
+
+func newAgeHistogram() *ageHistogram {
+	return &ageHistogram{counts: make([]uint64, len(idleTimeBuckets)+1)}
+}
+
+func (h *ageHistogram) observe(age float64) {
+	i := 0
+	for i < len(idleTimeBuckets) && age > idleTimeBuckets[i] {
+		i++
+	}
+	h.counts[i]++
+	h.count++
+	h.sum += age
+}
+
+// Returns the cumulative bucket counts, as required by
+// prometheus.MustNewConstHistogram.
+func (h *ageHistogram) buckets() map[float64]uint64 {
+	buckets := make(map[float64]uint64, len(idleTimeBuckets))
+	var cumulative uint64
+	for i, upperBound := range idleTimeBuckets {
+		cumulative += h.counts[i]
+		buckets[upperBound] = cumulative
+	}
+	return buckets
+}
+
+// Get the age distribution of the entries of each kind at now.
+func (c *SizedLRU) ageDistribution(now time.Time) map[cache.EntryKind]*ageHistogram {
+	histograms := make(map[cache.EntryKind]*ageHistogram, len(ageKinds))
+	for _, kind := range ageKinds {
+		histograms[kind] = newAgeHistogram()
+	}
+
+	nowSeconds := int64(unixSeconds(now))
+	for ele := c.ll.Front(); ele != nil; ele = ele.Next() {
+		e := ele.Value.(*entry)
+		age := nowSeconds - int64(e.lastAccess)
+		if age < 0 {
+			age = 0
+		}
+		histograms[e.key.Kind()].observe(float64(age))
+	}
+
+	return histograms
+}
+
+// A prometheus.Collector which exports the most recently computed age
+// distribution.
+type ageCollector struct {
+	desc *prometheus.Desc
+
+	mu         sync.Mutex
+	histograms map[cache.EntryKind]*ageHistogram // nil until computed.
+}
+
+func newAgeCollector() *ageCollector {
+	return &ageCollector{
+		desc: prometheus.NewDesc("bazel_remote_disk_cache_entry_idle_time_seconds",
+			"The distribution of the idle times (now - last access) of the entries in the LRU index, by kind, recomputed every 5 minutes",
+			[]string{"kind"}, nil),
+	}
+}
+
+func (a *ageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- a.desc
+}
+
+func (a *ageCollector) Collect(ch chan<- prometheus.Metric) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, kind := range ageKinds {
+		h, ok := a.histograms[kind]
+		if !ok {
+			continue
+		}
+		ch <- prometheus.MustNewConstHistogram(a.desc, h.count, h.sum, h.buckets(), kind.String())
+	}
+}
+
+func (a *ageCollector) set(histograms map[cache.EntryKind]*ageHistogram) {
+	a.mu.Lock()
+	a.histograms = histograms
+	a.mu.Unlock()
+}
+
+// Recompute the age distribution every ageDistributionInterval.
+func (c *diskCache) pollAgeDistribution() {
+	ticker := time.NewTicker(ageDistributionInterval)
+	for ; true; <-ticker.C {
+		c.updateAgeDistribution()
+	}
+}
+
+func (c *diskCache) updateAgeDistribution() {
+	c.mu.Lock()
+	histograms := c.lru.ageDistribution(c.lru.now())
+	c.mu.Unlock()
+
+	c.ageCollector.set(histograms)
+}
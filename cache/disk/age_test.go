@@ -0,0 +1,72 @@
+package disk
+
+import (
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestAgeDistribution(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	lru := NewSizedLRU(10*BlockSize, nil, 0)
+	lru.now = func() time.Time { return now }
+
+	casKey := func(name string) Key {
+		return Key{kind: uint8(cache.CAS), digest: sha256.Sum256([]byte(name))}
+	}
+
+	item := lruItem{size: BlockSize, sizeOnDisk: BlockSize}
+	lru.addAt(casKey("old"), item, now.Add(-10*24*time.Hour))
+	lru.addAt(casKey("read"), item, now.Add(-10*24*time.Hour))
+	lru.Add(casKey("new"), item)
+	lru.addAt(testKey("raw"), item, now.Add(-2*time.Hour))
+
+	// Reading an entry resets its idle time.
+	now = now.Add(30 * time.Second)
+	lru.Get(casKey("read"))
+
+	now = now.Add(30 * time.Second)
+	histograms := lru.ageDistribution(now)
+
+	cas := histograms[cache.CAS]
+	if cas.count != 3 {
+		t.Fatalf("Expected 3 CAS entries, found %d", cas.count)
+	}
+	if cas.counts[0] != 2 {
+		t.Errorf("Expected 2 CAS entries idle for at most a minute, found %d", cas.counts[0])
+	}
+	// 10 days is in the (7d, 14d] bucket.
+	if cas.counts[11] != 1 {
+		t.Errorf("Expected 1 CAS entry idle for 10 days, found counts %v", cas.counts)
+	}
+	if expected := float64(30 + 60 + 10*86400 + 60); cas.sum != expected {
+		t.Errorf("Expected a sum of %v, found %v", expected, cas.sum)
+	}
+
+	// 2 hours is in the (1h, 3h] bucket.
+	if raw := histograms[cache.RAW]; raw.count != 1 || raw.counts[4] != 1 {
+		t.Errorf("Expected 1 RAW entry idle for 2 hours, found counts %v", raw.counts)
+	}
+	if ac := histograms[cache.AC]; ac.count != 0 {
+		t.Errorf("Expected no AC entries, found %d", ac.count)
+	}
+
+	buckets := cas.buckets()
+	if buckets[60] != 2 || buckets[idleTimeBuckets[len(idleTimeBuckets)-1]] != 3 {
+		t.Errorf("Expected cumulative bucket counts, found %v", buckets)
+	}
+
+	collector := newAgeCollector()
+	if n := testutil.CollectAndCount(collector); n != 0 {
+		t.Errorf("Expected no metrics before the distribution is computed, found %d", n)
+	}
+	collector.set(histograms)
+	if n := testutil.CollectAndCount(collector); n != 3 {
+		t.Errorf("Expected a histogram for each kind, found %d", n)
+	}
+}
@@ -0,0 +1,85 @@
+package disk
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/tempfile"
+)
+
+// At startup the entries found in the cache directory are ordered by the
+// access times of their files, and the
+// bazel_remote_disk_cache_longest_item_idle_time_seconds gauge is based on
+// the access time of the least recently used file. On filesystems which
+// don't update access times when files are read, eg mounted with noatime,
+// or NTFS by default, both would be misleading. So at startup a probe file
+// is read to find out whether access times are updated. If they are not,
+// entries are ordered by their files' modification times instead, and the
+// gauge reports the idle time which is tracked in memory, see age.go.
+
+// How far in the past the probe file's timestamps are set, so that even
+// relatime, which updates access times at most once per day, updates it.
+const atimeProbeAge = 48 * time.Hour
+
+// Returns true if reading a file in dir updates its access time. The
+// probe file has the suffix of incomplete writes, so that it is removed
+// by the next startup scan if it is left behind.
+func atimeUpdated(dir string) (bool, error) {
+	name := filepath.Join(dir, "atime-probe"+tempfile.Suffix)
+	err := os.WriteFile(name, []byte("probe"), 0644)
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(name)
+
+	old := time.Now().Add(-atimeProbeAge)
+	err = os.Chtimes(name, old, old)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.ReadFile(name)
+	if err != nil {
+		return false, err
+	}
+
+	at, err := statAccessTime(name)
+	if err != nil {
+		return false, err
+	}
+
+	return at.After(old.Add(atimeProbeAge / 2)), nil
+}
+
+// Find out whether access times are updated in the cache directory, and
+// log the result. Read-only caches are assumed to have access times.
+func (c *diskCache) detectAtime() {
+	if c.readOnly {
+		return
+	}
+
+	updated, err := atimeUpdated(filepath.Join(c.dir, cache.RAW.DirName(), "00"))
+	if err != nil {
+		log.Printf("Failed to find out whether file access times are updated, assuming they are: %v", err)
+		return
+	}
+
+	c.noAtime = !updated
+	if c.noAtime {
+		log.Printf("File access times are not updated in %s, eg because it is mounted with noatime: ordering existing entries by modification time, and reporting idle times from the index", c.dir)
+	} else {
+		log.Printf("File access times are updated in %s", c.dir)
+	}
+}
+
+// Returns the time which the file described by de is considered to have
+// been last accessed at.
+func (c *diskCache) lastAccessTime(de dirEntry) time.Time {
+	if c.noAtime {
+		return de.mtime
+	}
+	return de.atime
+}
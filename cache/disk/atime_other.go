@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package disk
+
+import (
+	"os"
+	"time"
+
+	"github.com/djherbis/atime"
+)
+
+// Returns the time that the file described by info was last accessed.
+func accessTime(info os.FileInfo) time.Time {
+	return atime.Get(info)
+}
+
+// Returns the time that the named file was last accessed.
+func statAccessTime(name string) (time.Time, error) {
+	return atime.Stat(name)
+}
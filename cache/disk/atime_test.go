@@ -0,0 +1,44 @@
+package disk
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAtimeProbe(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	// The result depends on how the test's temporary directory is
+	// mounted, but the probe must not fail or leave files behind.
+	_, err := atimeUpdated(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(des) != 0 {
+		t.Errorf("Expected the probe file to be removed, found %d files", len(des))
+	}
+}
+
+func TestLastAccessTime(t *testing.T) {
+	de := dirEntry{
+		atime: time.Unix(1000, 0),
+		mtime: time.Unix(2000, 0),
+	}
+
+	c := &diskCache{}
+	if at := c.lastAccessTime(de); !at.Equal(de.atime) {
+		t.Errorf("Expected the access time to be used, got %v", at)
+	}
+
+	c.noAtime = true
+	if at := c.lastAccessTime(de); !at.Equal(de.mtime) {
+		t.Errorf("Expected the modification time to be used without access times, got %v", at)
+	}
+}
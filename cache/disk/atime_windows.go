@@ -0,0 +1,34 @@
+//go:build windows
+// +build windows
+
+package disk
+
+import (
+	"os"
+	"time"
+
+	"github.com/djherbis/atime"
+)
+
+// Returns the time that the file described by info was last accessed.
+//
+// NTFS last access time updates are disabled by default on many windows
+// versions, and are otherwise only written once per hour. So we use the
+// modification time instead if it is more recent, which is at least
+// correct for blobs that were not read since they were written.
+func accessTime(info os.FileInfo) time.Time {
+	at := atime.Get(info)
+	if mt := info.ModTime(); mt.After(at) {
+		return mt
+	}
+	return at
+}
+
+// Returns the time that the named file was last accessed.
+func statAccessTime(name string) (time.Time, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return accessTime(info), nil
+}
@@ -2,9 +2,7 @@ package casblob
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/binary"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -12,6 +10,7 @@ import (
 	"os"
 
 	"github.com/buchgr/bazel-remote/v2/cache/disk/zstdimpl"
+	"github.com/buchgr/bazel-remote/v2/utils/bufpool"
 )
 
 type CompressionType uint8
@@ -21,7 +20,19 @@ const (
 	Zstandard CompressionType = 1
 )
 
-const defaultChunkSize = 1024 * 1024 * 1 // 1M
+func (t CompressionType) String() string {
+	switch t {
+	case Identity:
+		return "identity"
+	case Zstandard:
+		return "zstandard"
+	}
+	return fmt.Sprintf("unknown (%d)", uint8(t))
+}
+
+// ChunkSize is the amount of uncompressed data in each chunk of a
+// zstandard compressed blob, except the last one.
+const ChunkSize = 1024 * 1024 * 1 // 1M
 
 // 4 bytes, to be written to disk in little-endian format.
 // https://github.com/facebook/zstd/blob/dev/doc/zstd_compression_format.md#skippable-frames
@@ -78,11 +89,12 @@ type readCloserWrapper struct {
 
 var errWrongMagicNum = errors.New("expected magic number not found")
 
+// ErrChecksumMismatch is returned (wrapped) by Write when the data does
+// not match the expected hash.
+var ErrChecksumMismatch = errors.New("checksums don't match")
+
 // Read the header and leave f at the start of the data.
 func readHeader(f *os.File) (*header, error) {
-	var err error
-	var h header
-
 	fileInfo, err := f.Stat()
 	if err != nil {
 		return nil, err
@@ -94,8 +106,28 @@ func readHeader(f *os.File) (*header, error) {
 			foundFileSize, (chunkTableOffset + 16))
 	}
 
+	h, err := readHeaderFrom(f)
+	if err != nil {
+		return nil, err
+	}
+
+	finalOffset := h.chunkOffsets[len(h.chunkOffsets)-1]
+	if finalOffset != foundFileSize {
+		return nil,
+			fmt.Errorf("final offset in chunk table %d should be file size %d",
+				finalOffset, foundFileSize)
+	}
+
+	return h, nil
+}
+
+// Read the header from r, which is left at the start of the data.
+func readHeaderFrom(r io.Reader) (*header, error) {
+	var err error
+	var h header
+
 	var magicNumber uint32
-	err = binary.Read(f, binary.LittleEndian, &magicNumber)
+	err = binary.Read(r, binary.LittleEndian, &magicNumber)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read magic number: %w", err)
 	}
@@ -104,28 +136,28 @@ func readHeader(f *os.File) (*header, error) {
 	}
 
 	var frameSize uint32
-	err = binary.Read(f, binary.LittleEndian, &frameSize)
+	err = binary.Read(r, binary.LittleEndian, &frameSize)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read frameSize: %w", err)
 	}
 
-	err = binary.Read(f, binary.LittleEndian, &h.uncompressedSize)
+	err = binary.Read(r, binary.LittleEndian, &h.uncompressedSize)
 	if err != nil {
 		return nil, err
 	}
 
-	err = binary.Read(f, binary.LittleEndian, &h.compression)
+	err = binary.Read(r, binary.LittleEndian, &h.compression)
 	if err != nil {
 		return nil, err
 	}
 
-	err = binary.Read(f, binary.LittleEndian, &h.chunkSize)
+	err = binary.Read(r, binary.LittleEndian, &h.chunkSize)
 	if err != nil {
 		return nil, err
 	}
 
 	var numOffsets int64
-	err = binary.Read(f, binary.LittleEndian, &numOffsets)
+	err = binary.Read(r, binary.LittleEndian, &numOffsets)
 	if err != nil {
 		return nil, err
 	}
@@ -143,7 +175,7 @@ func readHeader(f *os.File) (*header, error) {
 	}
 
 	h.chunkOffsets = make([]int64, numOffsets)
-	err = binary.Read(f, binary.LittleEndian, h.chunkOffsets)
+	err = binary.Read(r, binary.LittleEndian, h.chunkOffsets)
 	if err != nil {
 		return nil, err
 	}
@@ -158,13 +190,42 @@ func readHeader(f *os.File) (*header, error) {
 		prevOffset = h.chunkOffsets[i]
 	}
 
-	if prevOffset != foundFileSize {
-		return nil,
-			fmt.Errorf("final offset in chunk table %d should be file size %d",
-				prevOffset, foundFileSize)
+	return &h, nil
+}
+
+// Info describes the header of a compressed CAS blob.
+type Info struct {
+	UncompressedSize int64
+	Compression      CompressionType
+	ChunkSize        uint32
+
+	// The size of the header, ie the offset of the first chunk.
+	HeaderSize int64
+
+	// The offset of each chunk in the file, followed by the file size.
+	ChunkOffsets []int64
+}
+
+// NumChunks returns the number of chunks in the blob.
+func (i *Info) NumChunks() int {
+	return len(i.ChunkOffsets) - 1
+}
+
+// ReadInfo reads and validates the header of the compressed CAS blob
+// in f, which is left positioned at the start of the first chunk.
+func ReadInfo(f *os.File) (*Info, error) {
+	h, err := readHeader(f)
+	if err != nil {
+		return nil, err
 	}
 
-	return &h, nil
+	return &Info{
+		UncompressedSize: h.uncompressedSize,
+		Compression:      h.compression,
+		ChunkSize:        h.chunkSize,
+		HeaderSize:       h.size(),
+		ChunkOffsets:     h.chunkOffsets,
+	}, nil
 }
 
 // Extract the logical size of a v2 cas blob from rc, and return that
@@ -275,14 +336,7 @@ func GetUncompressedReadCloser(zstd zstdimpl.ZstdImpl, f *os.File, expectedSize
 		}, nil
 	}
 
-	compressedFirstChunk := make([]byte, h.chunkOffsets[chunkNum+1]-h.chunkOffsets[chunkNum])
-	_, err = io.ReadFull(f, compressedFirstChunk)
-	if err != nil {
-		f.Close()
-		return nil, err
-	}
-
-	uncompressedFirstChunk, err := zstd.DecodeAll(compressedFirstChunk)
+	uncompressedFirstChunk, err := decodeChunk(zstd, f, h.chunkOffsets[chunkNum+1]-h.chunkOffsets[chunkNum])
 	if err != nil {
 		f.Close()
 		return nil, err
@@ -311,6 +365,20 @@ func GetUncompressedReadCloser(zstd zstdimpl.ZstdImpl, f *os.File, expectedSize
 	}, nil
 }
 
+// Read a compressed chunk of compressedSize bytes from the current
+// position of f, and return it decompressed.
+func decodeChunk(zstd zstdimpl.ZstdImpl, f *os.File, compressedSize int64) ([]byte, error) {
+	buf := bufpool.Get(int(compressedSize))
+	defer bufpool.Put(buf)
+
+	_, err := io.ReadFull(f, *buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return zstd.DecodeAll(*buf)
+}
+
 // Returns an io.ReadCloser that provides zstandard compressed data. The
 // caller must close the returned io.ReadCloser if it is non-nil. Doing so
 // will automatically close f. If there is an error f will be closed, the caller
@@ -367,14 +435,7 @@ func GetZstdReadCloser(zstd zstdimpl.ZstdImpl, f *os.File, expectedSize int64, o
 		return f, nil
 	}
 
-	compressedFirstChunk := make([]byte, h.chunkOffsets[chunkNum+1]-h.chunkOffsets[chunkNum])
-	_, err = io.ReadFull(f, compressedFirstChunk)
-	if err != nil {
-		f.Close()
-		return nil, err
-	}
-
-	uncompressedFirstChunk, err := zstd.DecodeAll(compressedFirstChunk)
+	uncompressedFirstChunk, err := decodeChunk(zstd, f, h.chunkOffsets[chunkNum+1]-h.chunkOffsets[chunkNum])
 	if err != nil {
 		f.Close()
 		return nil, err
@@ -396,6 +457,62 @@ func GetZstdReadCloser(zstd zstdimpl.ZstdImpl, f *os.File, expectedSize int64, o
 	}, nil
 }
 
+// GetUncompressedStreamReadCloser is like GetUncompressedReadCloser, but
+// reads the whole blob sequentially from r instead of from a file. The
+// caller must close the returned io.ReadCloser if it is non-nil, which
+// does not close r.
+func GetUncompressedStreamReadCloser(zstd zstdimpl.ZstdImpl, r io.Reader, expectedSize int64) (io.ReadCloser, error) {
+	h, err := readStreamHeader(r, expectedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.compression == Identity {
+		return io.NopCloser(r), nil
+	}
+
+	return zstd.GetDecoder(io.NopCloser(r))
+}
+
+// GetZstdStreamReadCloser is like GetZstdReadCloser, but reads the whole
+// blob sequentially from r instead of from a file. Only blobs stored
+// with zstandard compression are supported. The caller must close the
+// returned io.ReadCloser if it is non-nil, which does not close r.
+func GetZstdStreamReadCloser(zstd zstdimpl.ZstdImpl, r io.Reader, expectedSize int64) (io.ReadCloser, error) {
+	h, err := readStreamHeader(r, expectedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.compression != Zstandard {
+		return nil, fmt.Errorf("unable to stream a blob with %s compression in zstandard form",
+			h.compression)
+	}
+
+	// The chunks are independent zstandard frames, which together
+	// form a valid zstandard stream.
+	return io.NopCloser(r), nil
+}
+
+func readStreamHeader(r io.Reader, expectedSize int64) (*header, error) {
+	h, err := readHeaderFrom(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedSize != -1 && h.uncompressedSize != expectedSize {
+		return nil, fmt.Errorf("expected a blob of size %d, found %d",
+			expectedSize, h.uncompressedSize)
+	}
+
+	if h.compression != Identity && h.compression != Zstandard {
+		return nil, fmt.Errorf("unsupported compression type: %d",
+			h.compression)
+	}
+
+	return h, nil
+}
+
 // GetLegacyZstdReadCloser returns an io.ReadCloser that provides
 // zstandard-compressed data from an uncompressed file.
 func GetLegacyZstdReadCloser(zstd zstdimpl.ZstdImpl, f *os.File) (io.ReadCloser, error) {
@@ -487,17 +604,34 @@ func (b *readCloserWrapper) Close() error {
 	return f.Close()
 }
 
-// Read from r and write to f, using CompressionType t.
-// Return the size on disk or an error if something went wrong.
+// Read from r and write to f, using CompressionType t, then sync and
+// close f. Return the size on disk or an error if something went wrong.
 func WriteAndClose(zstd zstdimpl.ZstdImpl, r io.Reader, f *os.File, t CompressionType, hash string, size int64) (int64, error) {
-	var err error
 	defer f.Close()
 
+	n, err := Write(zstd, r, f, t, hash, size)
+	if err != nil {
+		return -1, err
+	}
+
+	err = f.Sync()
+	if err != nil {
+		return -1, err
+	}
+
+	return n, f.Close()
+}
+
+// Write is like WriteAndClose, but leaves syncing and closing f to the
+// caller.
+func Write(zstd zstdimpl.ZstdImpl, r io.Reader, f *os.File, t CompressionType, hash string, size int64) (int64, error) {
+	var err error
+
 	if size <= 0 {
 		return -1, fmt.Errorf("invalid file size: %d", size)
 	}
 
-	chunkSize := uint32(defaultChunkSize)
+	chunkSize := uint32(ChunkSize)
 
 	numChunks := int64(1)
 	remainder := int64(0)
@@ -528,26 +662,44 @@ func WriteAndClose(zstd zstdimpl.ZstdImpl, r io.Reader, f *os.File, t Compressio
 
 	var n int64
 
-	if t == Identity {
-		hasher := sha256.New()
+	// Hash the data in a separate goroutine, so that the hashing of large
+	// blobs overlaps with reading, compressing and writing them.
+	hasher := newPipelinedHasher(int(chunkSize))
+	defer hasher.close()
 
-		n, err = io.Copy(io.MultiWriter(f, hasher), r)
-		if err != nil {
-			return -1, err
+	if t == Identity {
+		for {
+			buf := hasher.buffer()
+			nr, err := io.ReadFull(r, *buf)
+			if nr > 0 {
+				*buf = (*buf)[:nr]
+				hasher.write(buf)
+
+				if _, err := f.Write(*buf); err != nil {
+					return -1, err
+				}
+				n += int64(nr)
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			if err != nil {
+				return -1, err
+			}
 		}
 		if n != size {
 			return -1, fmt.Errorf("expected to copy %d bytes, actually copied %d bytes",
 				size, n)
 		}
 
-		actualHash := hex.EncodeToString(hasher.Sum(nil))
+		actualHash := hasher.hexSum()
 		if actualHash != hash {
 			return -1,
-				fmt.Errorf("checksums don't match. Expected %s, found %s",
-					hash, actualHash)
+				fmt.Errorf("%w. Expected %s, found %s",
+					ErrChecksumMismatch, hash, actualHash)
 		}
 
-		return n + fileOffset, f.Close()
+		return n + fileOffset, nil
 	}
 
 	// Compress the data in chunks...
@@ -555,10 +707,6 @@ func WriteAndClose(zstd zstdimpl.ZstdImpl, r io.Reader, f *os.File, t Compressio
 	nextChunk := 0 // Index in h.chunkOffsets.
 	remainingRawData := size
 
-	uncompressedChunk := make([]byte, chunkSize)
-
-	hasher := sha256.New()
-
 	for nextChunk < len(h.chunkOffsets)-1 {
 		h.chunkOffsets[nextChunk] = fileOffset
 		nextChunk++
@@ -569,14 +717,17 @@ func WriteAndClose(zstd zstdimpl.ZstdImpl, r io.Reader, f *os.File, t Compressio
 		}
 		remainingRawData -= chunkEnd
 
-		_, err = io.ReadFull(r, uncompressedChunk[0:chunkEnd])
+		buf := hasher.buffer()
+		*buf = (*buf)[:chunkEnd]
+
+		_, err = io.ReadFull(r, *buf)
 		if err != nil {
 			return -1, err
 		}
 
-		compressedChunk := zstd.EncodeAll(uncompressedChunk[0:chunkEnd])
+		hasher.write(buf)
 
-		hasher.Write(uncompressedChunk[0:chunkEnd])
+		compressedChunk := zstd.EncodeAll(*buf)
 
 		written, err := f.Write(compressedChunk)
 		if err != nil {
@@ -588,17 +739,17 @@ func WriteAndClose(zstd zstdimpl.ZstdImpl, r io.Reader, f *os.File, t Compressio
 	h.chunkOffsets[nextChunk] = fileOffset
 
 	// Confirm that there is no data left to be read.
-	bytesAfter, err := io.ReadFull(r, uncompressedChunk)
+	bytesAfter, err := io.ReadFull(r, *hasher.buffer())
 	if err == nil {
 		return -1, fmt.Errorf("expected %d bytes but got at least %d more", size, bytesAfter)
 	} else if err != io.EOF {
 		return -1, err
 	}
 
-	actualHash := hex.EncodeToString(hasher.Sum(nil))
+	actualHash := hasher.hexSum()
 	if actualHash != hash {
-		return -1, fmt.Errorf("checksums don't match. Expected %s, found %s",
-			hash, actualHash)
+		return -1, fmt.Errorf("%w. Expected %s, found %s",
+			ErrChecksumMismatch, hash, actualHash)
 	}
 
 	// We know all the chunk offsets now, go back and fill those in.
@@ -612,10 +763,5 @@ func WriteAndClose(zstd zstdimpl.ZstdImpl, r io.Reader, f *os.File, t Compressio
 		return -1, err
 	}
 
-	err = f.Sync()
-	if err != nil {
-		return -1, err
-	}
-
-	return fileOffset, f.Close()
+	return fileOffset, nil
 }
@@ -1,8 +1,16 @@
 package casblob_test
 
 import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 	"unsafe"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+	"github.com/buchgr/bazel-remote/v2/cache/disk/zstdimpl"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
 )
 
 func TestLenSize(t *testing.T) {
@@ -17,3 +25,140 @@ func TestLenSize(t *testing.T) {
 		t.Errorf("This should silence linters that think slice is never used")
 	}
 }
+
+func TestReadInfo(t *testing.T) {
+	zstd, err := zstdimpl.Get("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	// A little over two chunks.
+	size := int64(2*1024*1024 + 100)
+	data, hash := testutils.RandomDataAndHash(size)
+
+	filename := filepath.Join(dir, hash)
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = casblob.WriteAndClose(zstd, bytes.NewReader(data), f, casblob.Zstandard, hash, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err = os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	info, err := casblob.ReadInfo(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.UncompressedSize != size {
+		t.Errorf("Expected uncompressed size %d, found %d", size, info.UncompressedSize)
+	}
+	if info.Compression != casblob.Zstandard {
+		t.Errorf("Expected zstandard compression, found %s", info.Compression)
+	}
+	if info.NumChunks() != 3 {
+		t.Errorf("Expected 3 chunks, found %d", info.NumChunks())
+	}
+	if info.ChunkOffsets[0] != info.HeaderSize {
+		t.Errorf("Expected the first chunk at offset %d, found %d",
+			info.HeaderSize, info.ChunkOffsets[0])
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ChunkOffsets[info.NumChunks()] != fi.Size() {
+		t.Errorf("Expected the final offset to be the file size %d, found %d",
+			fi.Size(), info.ChunkOffsets[info.NumChunks()])
+	}
+
+	// Uncompressed files have no header.
+	plain := filepath.Join(dir, "plain")
+	err = os.WriteFile(plain, data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pf, err := os.Open(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pf.Close()
+	_, err = casblob.ReadInfo(pf)
+	if err == nil {
+		t.Error("Expected an error reading a file without a casblob header")
+	}
+}
+
+func TestStreamReadClosers(t *testing.T) {
+	zstd, err := zstdimpl.Get("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	size := int64(2*1024*1024 + 100)
+	data, hash := testutils.RandomDataAndHash(size)
+
+	filename := filepath.Join(dir, hash)
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = casblob.WriteAndClose(zstd, bytes.NewReader(data), f, casblob.Zstandard, hash, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := casblob.GetUncompressedStreamReadCloser(zstd, bytes.NewReader(blob), size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(found, data) {
+		t.Error("Unexpected uncompressed data")
+	}
+
+	rc, err = casblob.GetZstdStreamReadCloser(zstd, bytes.NewReader(blob), size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err = zstd.DecodeAll(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(found, data) {
+		t.Error("Unexpected zstandard data")
+	}
+
+	_, err = casblob.GetUncompressedStreamReadCloser(zstd, bytes.NewReader(blob), size+1)
+	if err == nil {
+		t.Error("Expected an error for the wrong size")
+	}
+}
@@ -0,0 +1,109 @@
+package casblob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/buchgr/bazel-remote/v2/utils/bufpool"
+)
+
+// The maximum number of chunks which a pipelinedHasher has buffers for,
+// ie the number of chunks which can be read, compressed and written
+// while earlier chunks are still being hashed.
+const hasherDepth = 4
+
+// Computes the SHA256 hash of a blob's chunks in a separate goroutine,
+// so that hashing overlaps with reading, compressing and writing the
+// data of large blobs, rather than adding to the time taken to write them.
+//
+// Chunks are read into buffers from buffer, then passed to write, after
+// which they must not be modified until they are returned by a later
+// call to buffer.
+type pipelinedHasher struct {
+	chunkSize int
+	allocated int
+	held      *[]byte // The buffer from buffer which wasn't passed to write yet.
+
+	chunks chan *[]byte // Chunks to be hashed.
+	free   chan *[]byte // Buffers of chunks which have been hashed.
+	sum    chan []byte
+
+	closed bool
+	hash   string
+}
+
+func newPipelinedHasher(chunkSize int) *pipelinedHasher {
+	p := &pipelinedHasher{
+		chunkSize: chunkSize,
+		chunks:    make(chan *[]byte, hasherDepth),
+		free:      make(chan *[]byte, hasherDepth),
+		sum:       make(chan []byte, 1),
+	}
+
+	go func() {
+		h := sha256.New()
+		for chunk := range p.chunks {
+			h.Write(*chunk)
+			p.free <- chunk
+		}
+		p.sum <- h.Sum(nil)
+	}()
+
+	return p
+}
+
+// Returns a buffer of length chunkSize to read the next chunk into.
+// This blocks if all the buffers are waiting to be hashed.
+func (p *pipelinedHasher) buffer() *[]byte {
+	var buf *[]byte
+	select {
+	case buf = <-p.free:
+	default:
+		if p.allocated < hasherDepth {
+			p.allocated++
+			buf = bufpool.Get(p.chunkSize)
+		} else {
+			buf = <-p.free
+		}
+	}
+
+	*buf = (*buf)[:p.chunkSize]
+	p.held = buf
+	return buf
+}
+
+// Queue the chunk in buf, which is from buffer, to be hashed. The chunk
+// can still be read, but not modified.
+func (p *pipelinedHasher) write(buf *[]byte) {
+	p.held = nil
+	p.chunks <- buf
+}
+
+// Wait for the queued chunks to be hashed, and return the lowercase hex
+// hash of all the chunks.
+func (p *pipelinedHasher) hexSum() string {
+	p.close()
+	return p.hash
+}
+
+// Stop the hashing goroutine, and return the buffers to the pool. This
+// must be called, eg if the blob can't be written.
+func (p *pipelinedHasher) close() {
+	if p.closed {
+		return
+	}
+	p.closed = true
+
+	close(p.chunks)
+	p.hash = hex.EncodeToString(<-p.sum)
+
+	queued := p.allocated
+	if p.held != nil {
+		bufpool.Put(p.held)
+		p.held = nil
+		queued--
+	}
+	for i := 0; i < queued; i++ {
+		bufpool.Put(<-p.free)
+	}
+}
@@ -0,0 +1,62 @@
+package casblob
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestPipelinedHasher(t *testing.T) {
+	const chunkSize = 4096
+
+	data := make([]byte, chunkSize*10+123)
+	_, err := rand.Read(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	expected := hex.EncodeToString(sum[:])
+
+	p := newPipelinedHasher(chunkSize)
+	for remaining := data; len(remaining) > 0; {
+		buf := p.buffer()
+		if len(*buf) != chunkSize {
+			t.Fatalf("Expected a buffer of length %d, got %d", chunkSize, len(*buf))
+		}
+
+		n := copy(*buf, remaining)
+		remaining = remaining[n:]
+		*buf = (*buf)[:n]
+		p.write(buf)
+	}
+
+	if p.allocated > hasherDepth {
+		t.Errorf("Expected at most %d buffers, allocated %d", hasherDepth, p.allocated)
+	}
+
+	if h := p.hexSum(); h != expected {
+		t.Errorf("Expected hash %s, got %s", expected, h)
+	}
+
+	// Closing again is a no-op.
+	p.close()
+	if p.hash != expected {
+		t.Errorf("Expected hash %s after closing again, got %s", expected, p.hash)
+	}
+}
+
+func TestPipelinedHasherClose(t *testing.T) {
+	// A buffer which is not passed to write, eg after a read error,
+	// must not stop close from returning.
+	p := newPipelinedHasher(4096)
+	p.write(p.buffer())
+	p.buffer()
+	p.close()
+
+	empty := sha256.Sum256(nil)
+	p = newPipelinedHasher(4096)
+	if h := p.hexSum(); h != hex.EncodeToString(empty[:]) {
+		t.Errorf("Expected the empty hash, got %s", h)
+	}
+}
@@ -0,0 +1,194 @@
+package disk
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// To tell whether the cache is too small, or whether builds write much
+// more than they ever read back, the uncompressed sizes of the entries
+// written to the cache and of the data read from it are counted per kind
+// of entry, as well as how many of the entries written since startup
+// were evicted or removed before they were read.
+//
+// An entry counts as read when a lookup finds it, including existence
+// checks like FindMissingBlobs, since those save an upload too. Entries
+// found in the cache directory at startup are not counted when they are
+// evicted, since it is unknown whether they were read before.
+
+var churnKinds = []cache.EntryKind{cache.AC, cache.CAS, cache.RAW}
+
+// KindChurn reports the churn of the entries of one kind since startup.
+type KindChurn struct {
+	Kind string `json:"kind"`
+
+	EntriesWritten int64 `json:"entries_written"`
+	BytesWritten   int64 `json:"bytes_written"`
+	BytesRead      int64 `json:"bytes_read"`
+
+	// BytesWritten / BytesRead, or 0 if nothing was read.
+	WriteAmplification float64 `json:"write_amplification"`
+
+	// The entries written since startup which were evicted or removed,
+	// and those of them which were never read.
+	EntriesEvicted       int64 `json:"entries_evicted"`
+	BytesEvicted         int64 `json:"bytes_evicted"`
+	EntriesEvictedUnread int64 `json:"entries_evicted_unread"`
+	BytesEvictedUnread   int64 `json:"bytes_evicted_unread"`
+
+	// EntriesEvictedUnread / EntriesEvicted, or 0 if none were evicted.
+	UnreadEvictionFraction float64 `json:"unread_eviction_fraction"`
+}
+
+// ChurnReport reports how much data was written to the cache compared to
+// how much was read back, per kind of entry.
+type ChurnReport struct {
+	Since int64       `json:"since"` // Unix time, when counting started.
+	Kinds []KindChurn `json:"kinds"`
+}
+
+type kindChurnCounters struct {
+	entriesWritten atomic.Int64
+	bytesWritten   atomic.Int64
+	bytesRead      atomic.Int64
+
+	// Counted separately, so that each metric only ever increases.
+	entriesEvictedRead   atomic.Int64
+	bytesEvictedRead     atomic.Int64
+	entriesEvictedUnread atomic.Int64
+	bytesEvictedUnread   atomic.Int64
+}
+
+// Counts the churn of a cache, and exports it as prometheus metrics. It
+// is safe to call the methods of a nil *churnStats, which doesn't count
+// anything.
+type churnStats struct {
+	since time.Time
+	kinds [cache.RAW + 1]kindChurnCounters
+
+	writtenBytesDesc   *prometheus.Desc
+	readBytesDesc      *prometheus.Desc
+	evictedEntriesDesc *prometheus.Desc
+	evictedBytesDesc   *prometheus.Desc
+}
+
+func newChurnStats() *churnStats {
+	return &churnStats{
+		since: time.Now(),
+
+		writtenBytesDesc: prometheus.NewDesc("bazel_remote_disk_cache_written_bytes_total",
+			"The total uncompressed size of the entries written to the disk cache, by kind",
+			[]string{"kind"}, nil),
+		readBytesDesc: prometheus.NewDesc("bazel_remote_disk_cache_read_bytes_total",
+			"The total number of uncompressed bytes read by disk cache hits, by kind",
+			[]string{"kind"}, nil),
+		evictedEntriesDesc: prometheus.NewDesc("bazel_remote_disk_cache_written_entries_evicted_total",
+			"The total number of entries written since startup which were evicted or removed, by kind and by whether they were read before",
+			[]string{"kind", "read"}, nil),
+		evictedBytesDesc: prometheus.NewDesc("bazel_remote_disk_cache_written_bytes_evicted_total",
+			"The total uncompressed size of the entries written since startup which were evicted or removed, by kind and by whether they were read before",
+			[]string{"kind", "read"}, nil),
+	}
+}
+
+func (s *churnStats) recordWrite(kind cache.EntryKind, size int64) {
+	if s == nil {
+		return
+	}
+
+	s.kinds[kind].entriesWritten.Add(1)
+	s.kinds[kind].bytesWritten.Add(size)
+}
+
+func (s *churnStats) recordRead(kind cache.EntryKind, size int64) {
+	if s == nil || size <= 0 {
+		return
+	}
+
+	s.kinds[kind].bytesRead.Add(size)
+}
+
+// Record that e was evicted or removed, if it was written since startup.
+func (s *churnStats) recordEviction(e *entry) {
+	if s == nil || !e.written {
+		return
+	}
+
+	k := &s.kinds[e.key.kind]
+	if e.read {
+		k.entriesEvictedRead.Add(1)
+		k.bytesEvictedRead.Add(e.value.size)
+	} else {
+		k.entriesEvictedUnread.Add(1)
+		k.bytesEvictedUnread.Add(e.value.size)
+	}
+}
+
+func (s *churnStats) report() ChurnReport {
+	if s == nil {
+		return ChurnReport{Kinds: []KindChurn{}}
+	}
+
+	r := ChurnReport{
+		Since: s.since.Unix(),
+		Kinds: make([]KindChurn, 0, len(churnKinds)),
+	}
+
+	for _, kind := range churnKinds {
+		k := &s.kinds[kind]
+		kc := KindChurn{
+			Kind:                 kind.String(),
+			EntriesWritten:       k.entriesWritten.Load(),
+			BytesWritten:         k.bytesWritten.Load(),
+			BytesRead:            k.bytesRead.Load(),
+			EntriesEvictedUnread: k.entriesEvictedUnread.Load(),
+			BytesEvictedUnread:   k.bytesEvictedUnread.Load(),
+		}
+		kc.EntriesEvicted = k.entriesEvictedRead.Load() + kc.EntriesEvictedUnread
+		kc.BytesEvicted = k.bytesEvictedRead.Load() + kc.BytesEvictedUnread
+		if kc.BytesRead > 0 {
+			kc.WriteAmplification = float64(kc.BytesWritten) / float64(kc.BytesRead)
+		}
+		if kc.EntriesEvicted > 0 {
+			kc.UnreadEvictionFraction = float64(kc.EntriesEvictedUnread) / float64(kc.EntriesEvicted)
+		}
+		r.Kinds = append(r.Kinds, kc)
+	}
+
+	return r
+}
+
+func (s *churnStats) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.writtenBytesDesc
+	ch <- s.readBytesDesc
+	ch <- s.evictedEntriesDesc
+	ch <- s.evictedBytesDesc
+}
+
+func (s *churnStats) Collect(ch chan<- prometheus.Metric) {
+	counter := func(desc *prometheus.Desc, v *atomic.Int64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(v.Load()), labels...)
+	}
+
+	for _, kind := range churnKinds {
+		k := &s.kinds[kind]
+		name := kind.String()
+		counter(s.writtenBytesDesc, &k.bytesWritten, name)
+		counter(s.readBytesDesc, &k.bytesRead, name)
+		counter(s.evictedEntriesDesc, &k.entriesEvictedRead, name, "true")
+		counter(s.evictedBytesDesc, &k.bytesEvictedRead, name, "true")
+		counter(s.evictedEntriesDesc, &k.entriesEvictedUnread, name, "false")
+		counter(s.evictedBytesDesc, &k.bytesEvictedUnread, name, "false")
+	}
+}
+
+// Churn reports how much data was written to the cache since startup
+// compared to how much was read back, and how many of the entries which
+// were written were evicted without being read.
+func (c *diskCache) Churn() ChurnReport {
+	return c.churn.report()
+}
@@ -0,0 +1,73 @@
+package disk
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestChurn(t *testing.T) {
+	lru := NewSizedLRU(10*BlockSize, nil, 0)
+	lru.churn = newChurnStats()
+
+	casKey := func(name string) Key {
+		return Key{kind: uint8(cache.CAS), digest: sha256.Sum256([]byte(name))}
+	}
+
+	item := lruItem{size: 100, sizeOnDisk: 100}
+	lru.addAt(casKey("found"), item, lru.now())
+	lru.Add(casKey("read"), item)
+	lru.Add(casKey("unread"), item)
+	lru.Add(testKey("raw"), lruItem{size: 10, sizeOnDisk: 10})
+
+	lru.Get(casKey("read"))
+	lru.churn.recordRead(cache.CAS, 50)
+
+	// Overwriting an entry counts as a write, and it is unread again.
+	lru.Get(casKey("unread"))
+	lru.Add(casKey("unread"), item)
+
+	for _, name := range []string{"found", "read", "unread"} {
+		lru.Remove(casKey(name))
+	}
+
+	report := lru.churn.report()
+	if len(report.Kinds) != 3 {
+		t.Fatalf("Expected a report for each kind, got %+v", report)
+	}
+
+	expected := KindChurn{
+		Kind:                   "cas",
+		EntriesWritten:         3,
+		BytesWritten:           300,
+		BytesRead:              50,
+		WriteAmplification:     6,
+		EntriesEvicted:         2,
+		BytesEvicted:           200,
+		EntriesEvictedUnread:   1,
+		BytesEvictedUnread:     100,
+		UnreadEvictionFraction: 0.5,
+	}
+	if report.Kinds[1] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, report.Kinds[1])
+	}
+
+	raw := report.Kinds[2]
+	if raw.Kind != "raw" || raw.EntriesWritten != 1 || raw.BytesWritten != 10 || raw.EntriesEvicted != 0 {
+		t.Errorf("Expected one RAW entry written, got %+v", raw)
+	}
+
+	// Two counters per kind, and two per kind and read label.
+	if n := testutil.CollectAndCount(lru.churn); n != 3*6 {
+		t.Errorf("Expected %d metrics, got %d", 3*6, n)
+	}
+
+	var nilStats *churnStats
+	nilStats.recordWrite(cache.CAS, 1)
+	if r := nilStats.report(); len(r.Kinds) != 0 {
+		t.Errorf("Expected an empty report without churn stats, got %+v", r)
+	}
+}
@@ -0,0 +1,182 @@
+package disk
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync/atomic"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/cluster"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// The maximum number of concurrent requests to other cluster members,
+// when checking for blobs or moving entries after a membership change.
+const clusterConcurrency = 16
+
+// Returns the cluster member which owns hash, or nil if this instance
+// should handle the request itself.
+func (c *diskCache) remoteOwner(ctx context.Context, hash string) *cluster.Node {
+	if c.cluster == nil || cluster.IsForwarded(ctx) {
+		return nil
+	}
+
+	return c.cluster.Owner(hash)
+}
+
+// Returns a nil io.ReadCloser if the owner does not have the item, or
+// something went wrong. In that case we fall back to the local cache,
+// which might still have a copy from before a membership change.
+func (c *diskCache) getFromOwner(ctx context.Context, owner *cluster.Node, kind cache.EntryKind, hash string, size int64, offset int64, zstd bool) (io.ReadCloser, int64) {
+	rc, foundSize, err := owner.Get(ctx, kind, hash, zstd)
+	if err != nil {
+		log.Printf("Warning: failed to get %s from %v", cache.LookupKey(kind, hash), err)
+		return nil, -1
+	}
+	if rc == nil {
+		return nil, -1
+	}
+
+	if foundSize < 0 {
+		// The owner does not report the size of compressed blobs.
+		foundSize = size
+	}
+	if isSizeMismatch(size, foundSize) {
+		rc.Close()
+		return nil, -1
+	}
+
+	if offset > 0 {
+		// Only used for uncompressed data, see get.
+		_, err = io.CopyN(io.Discard, rc, offset)
+		if err != nil {
+			rc.Close()
+			return nil, -1
+		}
+	}
+
+	return rc, foundSize
+}
+
+// Set blobs that exist on the cluster member which owns them to nil, and
+// return the remaining blobs. Blobs owned by this instance are left for
+// the local lookup.
+func (c *diskCache) findMissingClusterCAS(ctx context.Context, blobs []*pb.Digest) []*pb.Digest {
+	var g errgroup.Group
+	g.SetLimit(clusterConcurrency)
+
+	for i := range blobs {
+		owner := c.cluster.Owner(blobs[i].Hash)
+		if owner == nil {
+			continue
+		}
+
+		digest := &blobs[i]
+		g.Go(func() error {
+			exists, foundSize, err := owner.Contains(ctx, cache.CAS, (*digest).Hash)
+			if err != nil {
+				log.Printf("Warning: failed to check for %s: %v", (*digest).Hash, err)
+				return nil
+			}
+			if exists && !isSizeMismatch((*digest).SizeBytes, foundSize) {
+				c.accessLogger.Printf("GRPC CAS HEAD %s OK", (*digest).Hash)
+				*digest = nil
+			}
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	missing := filterNonNil(blobs)
+	for i := len(missing); i < len(blobs); i++ {
+		blobs[i] = nil
+	}
+
+	return missing
+}
+
+// Move the entries which are now owned by other cluster members to
+// their owners, and remove the local copies. Entries which fail to move
+// are left in place, and will eventually be evicted.
+func (c *diskCache) rebalance() {
+	c.rebalanceMu.Lock()
+	defer c.rebalanceMu.Unlock()
+
+	c.mu.Lock()
+	keys := c.lru.keys()
+	c.mu.Unlock()
+
+	var moved, failed int64
+
+	var g errgroup.Group
+	g.SetLimit(clusterConcurrency)
+
+	for _, key := range keys {
+		key := key
+		kind, hash := key.Kind(), key.Hash()
+
+		owner := c.cluster.Owner(hash)
+		if owner == nil {
+			continue
+		}
+
+		g.Go(func() error {
+			err := c.moveEntry(owner, kind, hash, key)
+			if err != nil {
+				log.Printf("Warning: failed to move %s to %s: %v", key, owner, err)
+				atomic.AddInt64(&failed, 1)
+				return nil
+			}
+			atomic.AddInt64(&moved, 1)
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	if moved > 0 || failed > 0 {
+		log.Printf("Moved %d entries to other cluster members, %d failed", moved, failed)
+	}
+}
+
+func (c *diskCache) moveEntry(owner *cluster.Node, kind cache.EntryKind, hash string, key Key) error {
+	c.mu.Lock()
+	item, exists := c.lru.Get(key)
+	c.mu.Unlock()
+	if !exists {
+		return nil // Evicted in the meantime.
+	}
+
+	// Read the local copy, rather than asking the owner for it.
+	ctx := cluster.Forwarded(context.Background())
+
+	rc, size, err := c.Get(ctx, kind, hash, item.size, 0)
+	if err != nil {
+		return err
+	}
+	if rc == nil {
+		return nil // Evicted in the meantime.
+	}
+	defer rc.Close()
+
+	err = owner.Put(context.Background(), kind, hash, size, rc)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Don't remove the entry if it was replaced while we were moving it.
+	current, exists := c.lru.Get(key)
+	if exists && current.random == item.random {
+		c.lru.Remove(key)
+	}
+
+	return nil
+}
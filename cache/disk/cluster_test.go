@@ -0,0 +1,162 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/cluster"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+)
+
+// A fake cluster member, which stores entries by URL path.
+type fakeMember struct {
+	mu     sync.Mutex
+	stored map[string][]byte
+}
+
+func (m *fakeMember) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		m.stored[r.URL.Path] = data
+	case http.MethodGet, http.MethodHead:
+		data, found := m.stored[r.URL.Path]
+		if !found {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	}
+}
+
+func (m *fakeMember) get(path string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, found := m.stored[path]
+	return data, found
+}
+
+func TestCluster(t *testing.T) {
+	member := &fakeMember{stored: make(map[string][]byte)}
+	srv := httptest.NewServer(member)
+	defer srv.Close()
+
+	cl, err := cluster.New("http://localhost:1", []string{"http://localhost:1", srv.URL},
+		"", 0, nil, "secret", &http.Client{}, testutils.NewSilentLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, BlockSize*100,
+		WithAccessLogger(testutils.NewSilentLogger()),
+		WithCluster(cl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+
+	// Find some data owned by each member.
+	var localData, remoteData []byte
+	for i := 0; localData == nil || remoteData == nil; i++ {
+		data := []byte("data " + strconv.Itoa(i))
+		if cl.Owner(hashStr(string(data))) == nil {
+			localData = data
+		} else {
+			remoteData = data
+		}
+	}
+	localHash := hashStr(string(localData))
+	remoteHash := hashStr(string(remoteData))
+
+	ctx := context.Background()
+
+	err = testCache.Put(ctx, cache.CAS, localHash, int64(len(localData)), bytes.NewReader(localData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := member.get("/cas/" + localHash); found {
+		t.Error("Expected the locally owned blob not to be forwarded")
+	}
+
+	err = testCache.Put(ctx, cache.CAS, remoteHash, int64(len(remoteData)), bytes.NewReader(remoteData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := member.get("/cas/" + remoteHash); !bytes.Equal(data, remoteData) {
+		t.Errorf("Expected the remotely owned blob to be forwarded, found %q", data)
+	}
+	if _, _, numItems, _ := testCache.Stats(); numItems != 1 {
+		t.Errorf("Expected only the locally owned blob to be stored locally, found %d items", numItems)
+	}
+
+	found, size := testCache.Contains(ctx, cache.CAS, remoteHash, -1)
+	if !found || size != int64(len(remoteData)) {
+		t.Errorf("Expected to find the remotely owned blob, got %v, %d", found, size)
+	}
+
+	rc, size, err := testCache.Get(ctx, cache.CAS, remoteHash, int64(len(remoteData)), 2)
+	if err != nil || rc == nil {
+		t.Fatalf("Expected to get the remotely owned blob, got %v, %v", rc, err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, remoteData[2:]) || size != int64(len(remoteData)) {
+		t.Errorf("Expected %q with size %d, got %q with size %d",
+			remoteData[2:], len(remoteData), data, size)
+	}
+
+	missing, err := testCache.FindMissingCasBlobs(ctx, []*pb.Digest{
+		{Hash: localHash, SizeBytes: int64(len(localData))},
+		{Hash: remoteHash, SizeBytes: int64(len(remoteData))},
+		{Hash: hashStr("missing"), SizeBytes: 7},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 1 || missing[0].Hash != hashStr("missing") {
+		t.Errorf("Expected only the unknown blob to be missing, got %v", missing)
+	}
+
+	// Simulate an AC entry which was stored before a membership change,
+	// and check that it is moved to its new owner.
+	acData := []byte("action result")
+	err = testCache.Put(cluster.Forwarded(ctx), cache.AC, remoteHash, int64(len(acData)), bytes.NewReader(acData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache.rebalance()
+
+	if data, _ := member.get("/ac/" + remoteHash); !bytes.Equal(data, acData) {
+		t.Errorf("Expected the AC entry to be moved to its owner, found %q", data)
+	}
+	found, _ = testCache.Contains(cluster.Forwarded(ctx), cache.AC, remoteHash, -1)
+	if found {
+		t.Error("Expected the local copy of the moved AC entry to be removed")
+	}
+	found, _ = testCache.Contains(cluster.Forwarded(ctx), cache.CAS, localHash, -1)
+	if !found {
+		t.Error("Expected the locally owned blob to be kept")
+	}
+}
@@ -0,0 +1,75 @@
+package disk
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The default time that the FsyncBatch policy waits to group directory
+// syncs.
+const defaultFsyncBatchInterval = 10 * time.Millisecond
+
+// Groups the directory syncs of the FsyncBatch policy. The first write
+// to wait for a sync starts a timer, and when it fires each directory
+// with waiting writes is synced once. So with many small writes, most
+// directories are synced once per interval rather than once per write.
+type dirSyncBatcher struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[string][]chan error // Waiting writes, by directory.
+	timer   *time.Timer             // Set while a batch is scheduled.
+
+	counterSyncs prometheus.Counter
+}
+
+func newDirSyncBatcher() *dirSyncBatcher {
+	return &dirSyncBatcher{
+		interval: defaultFsyncBatchInterval,
+		pending:  make(map[string][]chan error),
+		counterSyncs: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_batched_dir_syncs_total",
+			Help: "The total number of directory syncs done for batches of writes with the \"batch\" fsync policy. Compare with the number of dir observations of bazel_remote_disk_cache_fsync_duration_seconds, which counts the writes",
+		}),
+	}
+}
+
+// Wait until dir is synced by the next batch, and return the result.
+func (b *dirSyncBatcher) sync(dir string) error {
+	done := make(chan error, 1)
+
+	b.mu.Lock()
+	b.pending[dir] = append(b.pending[dir], done)
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, b.flush)
+	}
+	b.mu.Unlock()
+
+	return <-done
+}
+
+// Sync the directories with waiting writes, and wake them up.
+func (b *dirSyncBatcher) flush() {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[string][]chan error)
+	b.timer = nil
+	b.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for dir, waiting := range pending {
+		wg.Add(1)
+		go func(dir string, waiting []chan error) {
+			defer wg.Done()
+
+			err := syncDir(dir)
+			b.counterSyncs.Inc()
+			for _, done := range waiting {
+				done <- err
+			}
+		}(dir, waiting)
+	}
+	wg.Wait()
+}
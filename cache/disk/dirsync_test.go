@@ -0,0 +1,65 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestDirSyncBatcher(t *testing.T) {
+	dir := t.TempDir()
+	dirs := []string{filepath.Join(dir, "a"), filepath.Join(dir, "b")}
+	for _, d := range dirs {
+		err := os.Mkdir(d, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b := newDirSyncBatcher()
+	b.interval = 500 * time.Millisecond
+
+	// Writes which wait for the same batch share one sync per directory.
+	const numWrites = 20
+	errs := make(chan error, numWrites)
+	var wg sync.WaitGroup
+	for i := 0; i < numWrites; i++ {
+		wg.Add(1)
+		go func(d string) {
+			defer wg.Done()
+			errs <- b.sync(d)
+		}(dirs[i%len(dirs)])
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	if n := testutil.ToFloat64(b.counterSyncs); n != float64(len(dirs)) {
+		t.Errorf("Expected %d directory syncs, found %f", len(dirs), n)
+	}
+
+	// The next write starts a new batch.
+	b.interval = 10 * time.Millisecond
+	err := b.sync(dirs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.ToFloat64(b.counterSyncs); n != float64(len(dirs)+1) {
+		t.Errorf("Expected %d directory syncs, found %f", len(dirs)+1, n)
+	}
+
+	// Errors are returned to each waiting write.
+	err = b.sync(filepath.Join(dir, "missing"))
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected a not exist error for a missing directory, got %v", err)
+	}
+}
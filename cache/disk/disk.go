@@ -2,6 +2,7 @@ package disk
 
 import (
 	"bytes"
+	"container/list"
 	"context"
 	"crypto/sha256"
 	"errors"
@@ -12,18 +13,24 @@ import (
 	"os"
 	"path"
 	"path/filepath"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/cluster"
 	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
 	"github.com/buchgr/bazel-remote/v2/cache/disk/zstdimpl"
+	"github.com/buchgr/bazel-remote/v2/cache/eventstream"
+	"github.com/buchgr/bazel-remote/v2/cache/notify"
+	"github.com/buchgr/bazel-remote/v2/cache/replication"
+	"github.com/buchgr/bazel-remote/v2/utils/bufpool"
+	"github.com/buchgr/bazel-remote/v2/utils/limiter"
+	"github.com/buchgr/bazel-remote/v2/utils/maintenance"
+	"github.com/buchgr/bazel-remote/v2/utils/sharedfile"
 	"github.com/buchgr/bazel-remote/v2/utils/tempfile"
 	"github.com/buchgr/bazel-remote/v2/utils/validate"
 
-	"github.com/djherbis/atime"
-
 	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
 	"google.golang.org/protobuf/proto"
 
@@ -41,11 +48,35 @@ type Cache interface {
 	GetValidatedActionResult(ctx context.Context, hash string) (*pb.ActionResult, []byte, error)
 	GetZstd(ctx context.Context, hash string, size int64, offset int64) (io.ReadCloser, int64, error)
 	Put(ctx context.Context, kind cache.EntryKind, hash string, size int64, r io.Reader) error
+	ResumablePut(ctx context.Context, hash string, size int64, offset int64, r io.Reader) error
+	CommittedSize(hash string, size int64) int64
 	Contains(ctx context.Context, kind cache.EntryKind, hash string, size int64) (bool, int64)
 	FindMissingCasBlobs(ctx context.Context, blobs []*pb.Digest) ([]*pb.Digest, error)
 
 	MaxSize() int64
+	SetMaxSize(maxSize int64)
 	Stats() (totalSize int64, reservedSize int64, numItems int, uncompressedSize int64)
+	SimulateEviction(targetSize int64) EvictionReport
+	InstanceUsage() map[string]InstanceUsage
+	DirectoryUsage(depth int) []DirectoryUsage
+	InvocationStats() []InvocationStats
+	InvocationTranscript(id string) (*InvocationTranscript, error)
+	Provenance(kind cache.EntryKind, hash string) ([]ProvenanceRecord, error)
+	Standby() bool
+	Loading() bool
+	Promote() bool
+	ProxyBackends() []ProxyBackendStatus
+	SetProxyBackendEnabled(name string, enabled bool) error
+	Snapshot() *Snapshot
+	NewImporter(entries []EntryInfo) *Importer
+	Purge(before time.Time, kinds []cache.EntryKind, progress func(PurgeStats)) PurgeStats
+	Reconcile(ctx context.Context, opts ReconcileOptions) (ReconcileReport, error)
+	Prewarm(ctx context.Context, acHashes []string) (PrewarmReport, error)
+	Rescan(kinds []cache.EntryKind, shard string) (RescanStats, error)
+	Activity() Activity
+	Churn() ChurnReport
+	Describe(kind cache.EntryKind, hash string) (EntrySummary, bool)
+	LargestEntries(n int) []EntrySummary
 	RegisterMetrics()
 }
 
@@ -58,7 +89,12 @@ type lruItem struct {
 	sizeOnDisk int64
 
 	// A random string (of digits, for now) that is included in the filename.
-	random string
+	random randomSuffix
+
+	// The instance name of the request which added the blob. Blobs
+	// found in the cache directory at startup are not attributed to
+	// any instance.
+	instance instanceID
 
 	// If true, the blob is a raw CAS file (no header, uncompressed)
 	// with a ".v1" filename suffix.
@@ -76,19 +112,220 @@ type diskCache struct {
 	maxProxyBlobSize int64
 	accessLogger     *log.Logger
 	containsQueue    chan proxyCheck
-
-	// Limit the number of simultaneous file removals.
-	fileRemovalSem *semaphore.Weighted
+	instanceQuotas   map[string]int64
+	maxEntries       map[cache.EntryKind]int
+	replicator       *replication.Replicator // May be nil.
+	cluster          *cluster.Cluster        // May be nil.
+	maintenance      *maintenance.Window     // May be nil.
+	notifier         *notify.Notifier        // May be nil.
+	events           *eventstream.Exporter   // May be nil.
+	fsyncPolicies    map[cache.EntryKind]string
+	dirSyncer        *dirSyncBatcher
+	mmapReads        bool
+	leaseDuration    time.Duration
+	uploadWait       time.Duration
+	invocations      *invocationTracker // May be nil.
+	activity         *activityTracker   // May be nil.
+	io               *ioScheduler       // May be nil.
+	uploads          *uploadJournal     // May be nil.
+	accessJournal    *accessJournal     // May be nil.
+	provenance       *provenanceStore   // May be nil.
+	churn            *churnStats
+
+	// A soft limit on the total size of the writes in progress, ie the
+	// space reserved in lru, or 0 for no limit.
+	maxInflightUploadSize int64
+
+	// The fraction of whole, uncompressed reads of legacy CAS blobs to
+	// verify, between 0 and 1. See verify.go.
+	legacyVerifyRatio float64
+
+	// Whether to check the shard directory of an entry whose file was
+	// deleted behind our back for other missing files, and the shards
+	// which are being checked. Protected by mu, see zombie.go.
+	zombieRescan  bool
+	zombieRescans map[string]bool
+
+	// The content of entries which are no larger than inlineBlobSize, if
+	// it is > 0, is kept in inline after they are first read, up to
+	// inlineMaxBytes in total. The elements of inline are in inlineLRU,
+	// from most to least recently read. Protected by mu, see inline.go.
+	inlineBlobSize int64
+	inlineMaxBytes int64
+	inlineBytes    int64
+	inline         map[Key]*list.Element
+	inlineLRU      *list.List
+
+	// The number of goroutines which scan the cache directory at
+	// startup, or 0 to choose a number based on the number of CPUs.
+	scanWorkers int
+
+	// Set if reading files doesn't update their access times. See
+	// atime.go.
+	noAtime bool
+
+	// Whether to verify CAS blobs fetched from the proxy backend before
+	// using them. See proxyverify.go.
+	verifyProxyReads bool
+
+	// Serializes cluster rebalancing.
+	rebalanceMu sync.Mutex
+
+	// Reconcile with the proxy backend this often, if > 0, and serialize
+	// reconciliations. See reconcile.go.
+	reconcileInterval       time.Duration
+	reconcileDownloadWindow time.Duration
+	reconcileMu             sync.Mutex
+
+	// Writes are rejected if readOnly is set, or while degraded is set
+	// after persistent write errors. See readonly.go.
+	readOnly               bool
+	degraded               atomic.Bool
+	consecutiveWriteErrors atomic.Int32
+	writeProbeInterval     time.Duration
+
+	// Writes are refused while proxyRequired is set and the proxy
+	// backend is unavailable. See proxyhealth.go.
+	proxyRequired       bool
+	proxyHealthy        atomic.Bool
+	proxyProbeInterval  time.Duration
+	proxyBackendsMu     sync.Mutex
+	proxyBackends       map[string]*proxyBackend
+	numDisabledBackends atomic.Int32
+
+	// Writes from clients are refused while standby is set. See
+	// standby.go.
+	standby atomic.Bool
+
+	// With proxyReadsWhileLoading, reads are served from the proxy
+	// backend and writes are refused while loading is set, and the keys
+	// read are collected in backfill with loadingWriteThrough. See
+	// loading.go.
+	proxyReadsWhileLoading bool
+	loadingWriteThrough    bool
+	loading                atomic.Bool
+	backfillMu             sync.Mutex
+	backfill               map[Key]struct{}
+
+	// Entries are evicted to keep headroom free on the cache directory's
+	// filesystem if it is not nil, and writes are refused while
+	// headroomExhausted is set. See headroom.go.
+	headroom          *diskHeadroom
+	headroomExhausted atomic.Bool
+	statFilesystem    func(dir string) (filesystemStats, error)
+
+	// Uploads which don't fit in the free space of the cache directory's
+	// filesystem are refused if freeSpace is not nil. See freespace.go.
+	freeSpace *freeSpaceSample
+
+	// The number of files of evicted entries which were not removed yet.
+	pendingRemovals atomic.Int64
+
+	// Limit the number of simultaneous file removals to
+	// fileRemovalLimit, or to defaultFileRemovalLimit if it is 0.
+	fileRemovalLimit int
+	fileRemovalSem   *semaphore.Weighted
+
+	// Limit file removals to fileRemovalRate per second if it is > 0,
+	// and remove files in batches if batchFileRemovals is set. See
+	// remover.go.
+	fileRemovalRate   int
+	removalPacer      *removalPacer // May be nil.
+	batchFileRemovals bool
+	removals          *fileRemovalQueue // May be nil.
+
+	// The number of open snapshots, and the files of the entries which
+	// were removed while they were open. Protected by mu, see
+	// snapshot.go.
+	openSnapshots    int
+	deferredRemovals []string
+
+	// The writes which are in progress, by key. Protected by mu, see
+	// inflight.go.
+	inflight map[Key]*inflightWrite
+
+	// The proxy fetches which are in progress, by key. Protected by mu,
+	// see inflight.go.
+	fetches map[Key]*inflightFetch
 
 	mu  sync.Mutex
 	lru SizedLRU
 
-	gaugeCacheAge prometheus.Gauge
+	gaugeCacheAge        prometheus.Gauge
+	ageCollector         *ageCollector
+	gaugeReadOnly        prometheus.Gauge
+	gaugeStandby         prometheus.Gauge
+	gaugeLoading         prometheus.Gauge
+	counterWriteErrors   prometheus.Counter
+	counterScrubbedBlobs prometheus.Counter
+	counterCorruptBlobs  prometheus.Counter
+	counterVerifiedReads prometheus.Counter
+	counterCorruptReads  prometheus.Counter
+	counterInlineHits    prometheus.Counter
+	gaugeInlineBytes     prometheus.Gauge
+	counterSkippedWrites prometheus.Counter
+	counterSharedFetches prometheus.Counter
+	counterUploadWaits   prometheus.Counter
+	gaugeProxyHealthy    prometheus.Gauge
+	counterRefusedWrites prometheus.Counter
+
+	gaugeProxyBackendEnabled *prometheus.GaugeVec
+
+	counterThrottledWrites prometheus.Counter
+	counterZombieEntries   prometheus.Counter
+
+	gaugeReconcileLocalOnly   prometheus.Gauge
+	gaugeReconcileRemoteOnly  prometheus.Gauge
+	counterReconcileUploads   prometheus.Counter
+	counterReconcileDownloads prometheus.Counter
+
+	counterPrewarmDownloads prometheus.Counter
+
+	counterProxyVerifiedBlobs *prometheus.CounterVec
+	counterProxyCorruptBlobs  *prometheus.CounterVec
+
+	histogramFsyncDuration *prometheus.HistogramVec
+	counterMmapFallbacks   prometheus.Counter
+
+	gaugeFileRemovalLimit       prometheus.Gauge
+	gaugeFileRemovalsQueued     prometheus.Gauge
+	gaugeFileRemovalsInProgress prometheus.Gauge
+	counterFileRemovalErrors    prometheus.Counter
+
+	gaugeFilesystemAvailableBytes prometheus.Gauge
+	counterHeadroomEvictedBytes   prometheus.Counter
+	counterFreeSpaceRefusedWrites prometheus.Counter
 }
 
 const sha256HashStrSize = sha256.Size * 2 // Two hex characters per byte.
 const emptySha256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
 
+// Returns an error if a write of size bytes would take the total size of
+// the writes in progress over maxInflightUploadSize. This is a soft
+// limit: a write is always allowed if no others are in progress, so that
+// blobs larger than the limit can be uploaded. Must be called with mu
+// held.
+func (c *diskCache) checkInflightUploadSize(size int64) error {
+	if c.maxInflightUploadSize <= 0 {
+		return nil
+	}
+
+	reserved := c.lru.ReservedSize()
+	if reserved == 0 || reserved+size <= c.maxInflightUploadSize {
+		return nil
+	}
+
+	c.counterThrottledWrites.Inc()
+	return &cache.Error{
+		Code: http.StatusTooManyRequests,
+		Text: fmt.Sprintf("Too much data is being uploaded (%d bytes, the limit is %d), try again later",
+			reserved, c.maxInflightUploadSize),
+		QuotaSubject: "max_inflight_upload_size",
+		// Wait longer the further over the limit the uploads are.
+		RetryAfter: limiter.Backoff(limiter.RetryAfter, reserved+size-c.maxInflightUploadSize, c.maxInflightUploadSize),
+	}
+}
+
 func internalErr(err error) *cache.Error {
 	return &cache.Error{
 		Code: http.StatusInternalServerError,
@@ -108,12 +345,53 @@ func (c *diskCache) RegisterMetrics() {
 	c.lru.RegisterMetrics()
 
 	prometheus.MustRegister(c.gaugeCacheAge)
+	prometheus.MustRegister(c.ageCollector)
+	prometheus.MustRegister(c.churn)
+	prometheus.MustRegister(c.gaugeReadOnly)
+	prometheus.MustRegister(c.gaugeStandby)
+	prometheus.MustRegister(c.gaugeLoading)
+	prometheus.MustRegister(c.counterWriteErrors)
+	prometheus.MustRegister(c.counterScrubbedBlobs)
+	prometheus.MustRegister(c.counterCorruptBlobs)
+	prometheus.MustRegister(c.counterVerifiedReads)
+	prometheus.MustRegister(c.counterCorruptReads)
+	prometheus.MustRegister(c.counterInlineHits)
+	prometheus.MustRegister(c.gaugeInlineBytes)
+	prometheus.MustRegister(c.counterSkippedWrites)
+	prometheus.MustRegister(c.counterSharedFetches)
+	prometheus.MustRegister(c.counterUploadWaits)
+	prometheus.MustRegister(c.gaugeProxyHealthy)
+	prometheus.MustRegister(c.gaugeProxyBackendEnabled)
+	prometheus.MustRegister(c.counterRefusedWrites)
+	prometheus.MustRegister(c.counterThrottledWrites)
+	prometheus.MustRegister(c.counterZombieEntries)
+	prometheus.MustRegister(c.gaugeReconcileLocalOnly)
+	prometheus.MustRegister(c.gaugeReconcileRemoteOnly)
+	prometheus.MustRegister(c.counterReconcileUploads)
+	prometheus.MustRegister(c.counterReconcileDownloads)
+	prometheus.MustRegister(c.counterPrewarmDownloads)
+	prometheus.MustRegister(c.counterProxyVerifiedBlobs)
+	prometheus.MustRegister(c.counterProxyCorruptBlobs)
+	prometheus.MustRegister(c.histogramFsyncDuration)
+	prometheus.MustRegister(c.dirSyncer.counterSyncs)
+	prometheus.MustRegister(c.counterMmapFallbacks)
+	prometheus.MustRegister(c.gaugeFileRemovalLimit)
+	prometheus.MustRegister(c.gaugeFileRemovalsQueued)
+	prometheus.MustRegister(c.gaugeFileRemovalsInProgress)
+	prometheus.MustRegister(c.counterFileRemovalErrors)
+	prometheus.MustRegister(c.gaugeFilesystemAvailableBytes)
+	prometheus.MustRegister(c.counterHeadroomEvictedBytes)
+	prometheus.MustRegister(c.counterFreeSpaceRefusedWrites)
+	c.io.registerMetrics()
+	c.uploads.registerMetrics()
+	c.accessJournal.registerMetrics()
 
 	// Update the cache age metric on a static interval
 	// Note: this could be modeled as a GuageFunc that updates as needed
 	// but since the updater func must lock the cache mu, it was deemed
 	// necessary to have greater control of when to get the cache age
 	go c.pollCacheAge()
+	go c.pollAgeDistribution()
 }
 
 // Update metric every minute with the idle time of the least recently used item in the cache
@@ -128,13 +406,15 @@ func (c *diskCache) pollCacheAge() {
 func (c *diskCache) updateCacheAgeMetric() {
 	c.mu.Lock()
 
-	key, value := c.lru.getTailItem()
+	key, value, ok := c.lru.getTailItem()
 	age := 0.0
 	validAge := true
 
-	if key != nil {
+	if ok && c.noAtime {
+		age = time.Since(time.Unix(int64(c.lru.ll.Back().Value.(*entry).lastAccess), 0)).Seconds()
+	} else if ok {
 		f := c.getElementPath(key, value)
-		ts, err := atime.Stat(f)
+		ts, err := statAccessTime(f)
 
 		if err != nil {
 			log.Printf("ERROR: failed to determine time of least recently used cache item: %v, unable to stat %s", err, f)
@@ -152,29 +432,29 @@ func (c *diskCache) updateCacheAgeMetric() {
 }
 
 func (c *diskCache) getElementPath(key Key, value lruItem) string {
-	ks := key.(string)
-	hash := ks[len(ks)-sha256.Size*2:]
-	var kind cache.EntryKind = cache.AC
-	if strings.HasPrefix(ks, "cas") {
-		kind = cache.CAS
-	} else if strings.HasPrefix(ks, "ac") {
-		kind = cache.AC
-	} else if strings.HasPrefix(ks, "raw") {
-		kind = cache.RAW
-	}
-
-	return filepath.Join(c.dir, c.FileLocation(kind, value.legacy, hash, value.size, value.random))
+	return filepath.Join(c.dir, c.FileLocation(key.Kind(), value.legacy, key.Hash(), value.size, value.random.String()))
 }
 
 func (c *diskCache) removeFile(f string) {
-	if err := c.fileRemovalSem.Acquire(context.Background(), 1); err != nil {
+	defer c.pendingRemovals.Add(-1)
+
+	c.gaugeFileRemovalsQueued.Inc()
+	c.removalPacer.wait(1)
+	err := c.fileRemovalSem.Acquire(context.Background(), 1)
+	c.gaugeFileRemovalsQueued.Dec()
+	if err != nil {
+		c.counterFileRemovalErrors.Inc()
 		log.Printf("ERROR: failed to aquire semaphore: %v, unable to remove %s", err, f)
 		return
 	}
 	defer c.fileRemovalSem.Release(1)
 
-	err := os.Remove(f)
-	if err != nil {
+	c.gaugeFileRemovalsInProgress.Inc()
+	defer c.gaugeFileRemovalsInProgress.Dec()
+
+	err = os.Remove(f)
+	if err != nil && !os.IsNotExist(err) {
+		c.counterFileRemovalErrors.Inc()
 		log.Printf("ERROR: failed to remove evicted cache file: %s", f)
 	}
 }
@@ -220,6 +500,10 @@ func (c *diskCache) Put(ctx context.Context, kind cache.EntryKind, hash string,
 		if r != nil {
 			_, _ = io.Copy(io.Discard, r)
 		}
+		if rErr == nil {
+			c.invocations.recordPut(ctx, kind, hash, size)
+			c.recordProvenance(ctx, kind, hash, size)
+		}
 	}()
 
 	if size < 0 {
@@ -230,17 +514,89 @@ func (c *diskCache) Put(ctx context.Context, kind cache.EntryKind, hash string,
 		return badReqErr("Blob size %d too large, max blob size is %d", size, c.maxBlobSize)
 	}
 
+	instance := cache.InstanceName(ctx)
+	if quota, found := c.instanceQuotas[instance]; found && size > quota {
+		return &cache.Error{
+			Code:         http.StatusInsufficientStorage,
+			Text:         fmt.Sprintf("Blob size %d is larger than the quota of instance %q (%d)", size, instance, quota),
+			QuotaSubject: "instance:" + instance,
+		}
+	}
+
 	// The hash format is checked properly in the http/grpc code.
 	// Just perform a simple/fast check here, to catch bad tests.
 	if len(hash) != sha256HashStrSize {
 		return badReqErr("Invalid hash size: %d, expected: %d", len(hash), sha256.Size)
 	}
+	key, ok := newKey(kind, hash)
+	if !ok {
+		return badReqErr("Invalid hash: %q", hash)
+	}
 
 	if kind == cache.CAS && size == 0 && hash == emptySha256 {
 		return nil
 	}
 
-	key := cache.LookupKey(kind, hash)
+	if owner := c.remoteOwner(ctx, hash); owner != nil {
+		// Leave any unread data in r to be discarded by the deferred
+		// function above.
+		err := owner.Put(ctx, kind, hash, size, r)
+		if err != nil {
+			return &cache.Error{
+				Code: http.StatusBadGateway,
+				Text: err.Error(),
+			}
+		}
+		return nil
+	}
+
+	if c.isReadOnly() {
+		return errReadOnly
+	}
+
+	if c.loading.Load() {
+		return errLoading
+	}
+
+	if c.refuseWrites() {
+		return errProxyUnavailable
+	}
+
+	if c.headroomExhausted.Load() {
+		return errNoHeadroom
+	}
+
+	if c.refuseStandbyWrite(ctx) {
+		return errStandby
+	}
+
+	finish, skip, err := c.beginWrite(ctx, key)
+	if err != nil {
+		return &cache.Error{
+			Code: http.StatusServiceUnavailable,
+			Text: err.Error(),
+		}
+	}
+	if skip {
+		return nil
+	}
+	defer func() {
+		finish(rErr)
+	}()
+
+	fromPeer := replication.IsFromPeer(ctx)
+	if fromPeer && kind != cache.CAS && c.replicator != nil {
+		var err error
+		var release func()
+		r, release, err = c.resolveReplicaConflict(key, kind, hash, size, r)
+		if err != nil {
+			return internalErr(err)
+		}
+		defer release()
+		if r == nil {
+			return nil
+		}
+	}
 
 	var tf *os.File // Tempfile.
 	var blobFile string
@@ -253,12 +609,6 @@ func (c *diskCache) Put(ctx context.Context, kind cache.EntryKind, hash string,
 		// No lock required to remove stray tempfiles.
 		if removeTempfile {
 			os.Remove(blobFile)
-		} else if blobFile != "" {
-			// Mark the file as "complete".
-			err := os.Chmod(blobFile, tempfile.FinalMode)
-			if err != nil {
-				log.Println("Failed to mark", blobFile, "as complete:", err)
-			}
 		}
 
 		if unreserve {
@@ -274,13 +624,28 @@ func (c *diskCache) Put(ctx context.Context, kind cache.EntryKind, hash string,
 	}()
 
 	if size > 0 {
+		available, checkFreeSpace := c.freeSpaceForWrites()
+
 		c.mu.Lock()
+		err := c.checkInflightUploadSize(size)
+		if err != nil {
+			c.mu.Unlock()
+			return err
+		}
+		if checkFreeSpace {
+			err = c.checkFreeSpace(size, available)
+			if err != nil {
+				c.mu.Unlock()
+				return err
+			}
+		}
 		ok, err := c.lru.Reserve(size)
 		if err != nil {
 			c.mu.Unlock()
 			return &cache.Error{
-				Code: http.StatusInsufficientStorage,
-				Text: err.Error(),
+				Code:         http.StatusInsufficientStorage,
+				Text:         err.Error(),
+				QuotaSubject: "max_size",
 			}
 		}
 		if !ok {
@@ -289,6 +654,7 @@ func (c *diskCache) Put(ctx context.Context, kind cache.EntryKind, hash string,
 				Code: http.StatusInsufficientStorage,
 				Text: fmt.Sprintf("The item (%d) + reserved space is larger than the cache's maximum size (%d).",
 					size, c.lru.MaxSize()),
+				QuotaSubject: "max_size",
 			}
 		}
 		c.mu.Unlock()
@@ -298,11 +664,13 @@ func (c *diskCache) Put(ctx context.Context, kind cache.EntryKind, hash string,
 	legacy := kind == cache.CAS && c.storageMode == casblob.Identity
 
 	// Final destination, if all goes well.
-	filePath := path.Join(c.dir, c.FileLocationBase(kind, legacy, hash, size))
+	filePath := filepath.Join(c.dir, c.FileLocationBase(kind, legacy, hash, size))
 
-	// We will download to this temporary file.
-	tf, random, err := tfc.Create(filePath, legacy)
+	// We will download to this temporary file, and rename it to its
+	// final name once it is complete.
+	tf, random, err := tfc.CreateTemp(filePath, legacy)
 	if err != nil {
+		c.recordWrite(err)
 		return internalErr(err)
 	}
 	if tf == nil {
@@ -315,15 +683,30 @@ func (c *diskCache) Put(ctx context.Context, kind cache.EntryKind, hash string,
 	removeTempfile = true
 
 	var sizeOnDisk int64
-	sizeOnDisk, err = c.writeAndCloseFile(r, kind, hash, size, tf)
+	src, releaseIO := c.io.writeSource(ctx, r)
+	sizeOnDisk, err = c.writeAndCloseFile(src, kind, hash, size, tf)
+	releaseIO()
 	if err != nil {
+		c.recordWrite(err)
+		if errors.Is(err, casblob.ErrChecksumMismatch) {
+			c.notifier.Notify(notify.NewEvent(ctx, notify.EventHashMismatch, kind, hash, size))
+
+			// The client sent the wrong data.
+			return badReqErr("%v", err)
+		}
 		return internalErr(err)
 	}
 
 	r = nil // We read all the data from r.
 
-	if c.proxy != nil {
-		rc, err := os.Open(blobFile)
+	blobFile, err = c.renameTempfile(kind, blobFile)
+	if err != nil {
+		c.recordWrite(err)
+		return internalErr(err)
+	}
+
+	if c.proxy != nil && !c.proxyDisabled(ctx) {
+		rc, err := sharedfile.Open(blobFile)
 		if err != nil {
 			log.Println("Failed to proxy Put:", err)
 		} else {
@@ -332,14 +715,96 @@ func (c *diskCache) Put(ctx context.Context, kind cache.EntryKind, hash string,
 		}
 	}
 
-	unreserve, removeTempfile, err = c.commit(key, legacy, blobFile, size, size, sizeOnDisk, random)
+	unreserve, removeTempfile, err = c.commit(ctx, key, legacy, blobFile, size, size, sizeOnDisk, random)
 	if err != nil {
 		return internalErr(err)
 	}
 
+	// Only entries which were added to the cache are sent to the peers.
+	if c.replicator != nil && !fromPeer {
+		// Doesn't block, should be fast.
+		c.replicator.Replicate(kind, hash, size, sizeOnDisk, legacy, blobFile)
+	}
+
+	c.recordWrite(nil)
+	c.notifier.Notify(notify.NewEvent(ctx, notify.EventUpload, kind, hash, size))
+	c.events.Write(ctx, kind, hash, size)
+
 	return nil
 }
 
+// The amount of data that resolveReplicaConflict compares at a time.
+const replicaCompareChunkSize = 32 * 1024
+
+// Compare an AC or RAW entry received from a replication peer with any
+// existing entry, a chunk at a time so that large entries are not held
+// in memory. Returns a reader with the data to store, or nil if the
+// write should be skipped, and a function which must be called when the
+// reader is no longer needed.
+func (c *diskCache) resolveReplicaConflict(key Key, kind cache.EntryKind, hash string, size int64, r io.Reader) (io.Reader, func(), error) {
+	noop := func() {}
+
+	c.mu.Lock()
+	item, found := c.lru.Get(key)
+	c.mu.Unlock()
+	if !found {
+		return r, noop, nil
+	}
+
+	f, err := os.Open(filepath.Join(c.dir, c.FileLocation(kind, false, hash, item.size, item.random.String())))
+	if err != nil {
+		// The existing entry may have been evicted, store the new one.
+		return r, noop, nil
+	}
+
+	conflict := func() (io.Reader, func(), error) {
+		c.replicator.RecordConflict(kind, hash)
+		if c.replicator.KeepExisting() {
+			f.Close()
+			return nil, noop, nil
+		}
+		return r, func() { f.Close() }, nil
+	}
+
+	if item.size != size {
+		return conflict()
+	}
+
+	incoming := make([]byte, replicaCompareChunkSize)
+	existing := make([]byte, replicaCompareChunkSize)
+	var offset int64
+	for offset < size {
+		n := int64(len(incoming))
+		if size-offset < n {
+			n = size - offset
+		}
+
+		_, err = io.ReadFull(r, incoming[:n])
+		if err != nil {
+			f.Close()
+			return nil, noop, err
+		}
+
+		_, err = io.ReadFull(f, existing[:n])
+		if err != nil || !bytes.Equal(incoming[:n], existing[:n]) {
+			// The incoming data before offset matches the existing
+			// entry, so it can be read back from there.
+			r = io.MultiReader(io.NewSectionReader(f, 0, offset),
+				bytes.NewReader(incoming[:n]), r)
+			if err != nil {
+				// The existing entry can't be read, store the new one.
+				return r, func() { f.Close() }, nil
+			}
+			return conflict()
+		}
+
+		offset += n
+	}
+
+	f.Close()
+	return nil, noop, nil
+}
+
 func (c *diskCache) writeAndCloseFile(r io.Reader, kind cache.EntryKind, hash string, size int64, f *os.File) (int64, error) {
 	closeFile := true
 	defer func() {
@@ -352,24 +817,22 @@ func (c *diskCache) writeAndCloseFile(r io.Reader, kind cache.EntryKind, hash st
 	var sizeOnDisk int64
 
 	if kind == cache.CAS && c.storageMode != casblob.Identity {
-		sizeOnDisk, err = casblob.WriteAndClose(c.zstd, r, f, c.storageMode, hash, size)
+		sizeOnDisk, err = casblob.Write(c.zstd, r, f, c.storageMode, hash, size)
 		if err != nil {
 			return -1, err
 		}
-		closeFile = false
-		return sizeOnDisk, nil
-	}
-
-	if sizeOnDisk, err = io.Copy(f, r); err != nil {
-		return -1, err
-	}
+	} else {
+		if sizeOnDisk, err = bufpool.Copy(f, r); err != nil {
+			return -1, err
+		}
 
-	if isSizeMismatch(sizeOnDisk, size) {
-		return -1, fmt.Errorf(
-			"sizes don't match. Expected %d, found %d", size, sizeOnDisk)
+		if isSizeMismatch(sizeOnDisk, size) {
+			return -1, fmt.Errorf(
+				"sizes don't match. Expected %d, found %d", size, sizeOnDisk)
+		}
 	}
 
-	if err = f.Sync(); err != nil {
+	if err = c.syncFile(kind, f); err != nil {
 		return -1, err
 	}
 
@@ -381,8 +844,28 @@ func (c *diskCache) writeAndCloseFile(r io.Reader, kind cache.EntryKind, hash st
 	return sizeOnDisk, nil
 }
 
+// Rename a complete tempfile created by tempfile.CreateTemp to its final
+// name, and return that name. Files are only renamed once their data has
+// been written (and synced if required by the fsync policy), so files
+// with their final names are never incomplete after a crash, and
+// tempfiles which are left behind are removed by scanDir.
+func (c *diskCache) renameTempfile(kind cache.EntryKind, name string) (string, error) {
+	finalName := tempfile.FinalName(name)
+	err := os.Rename(name, finalName)
+	if err != nil {
+		return name, err
+	}
+
+	err = c.syncParentDir(kind, finalName)
+	if err != nil {
+		return finalName, err
+	}
+
+	return finalName, nil
+}
+
 // This must be called when the lock is not held.
-func (c *diskCache) commit(key string, legacy bool, tempfile string, reservedSize int64, logicalSize int64, sizeOnDisk int64, random string) (unreserve bool, removeTempfile bool, err error) {
+func (c *diskCache) commit(ctx context.Context, key Key, legacy bool, tempfile string, reservedSize int64, logicalSize int64, sizeOnDisk int64, random string) (unreserve bool, removeTempfile bool, err error) {
 	unreserve = reservedSize > 0
 	removeTempfile = true
 
@@ -402,7 +885,8 @@ func (c *diskCache) commit(key string, legacy bool, tempfile string, reservedSiz
 		size:       logicalSize,
 		sizeOnDisk: sizeOnDisk,
 		legacy:     legacy,
-		random:     random,
+		random:     newRandomSuffix(random),
+		instance:   newInstanceID(cache.InstanceName(ctx)),
 	}
 
 	if !c.lru.Add(key, newItem) {
@@ -418,27 +902,54 @@ func (c *diskCache) commit(key string, legacy bool, tempfile string, reservedSiz
 	return unreserve, removeTempfile, nil
 }
 
+// Describe how an item is stored, for cache.LookupResult.
+func storedCompression(kind cache.EntryKind, legacy bool) string {
+	if kind == cache.CAS && !legacy {
+		return "zstd"
+	}
+	return "identity"
+}
+
+// Release space which was reserved for an item that won't be added.
+func (c *diskCache) unreserve(size int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.lru.Unreserve(size)
+	if err != nil {
+		log.Println(err.Error())
+	}
+	return err
+}
+
 // Return a non-nil io.ReadCloser and non-negative size if the item is available
 // locally, and a boolean that indicates if the item is not available locally
 // but that we can try the proxy backend.
 //
 // This function assumes that only CAS blobs are requested in zstd form.
-func (c *diskCache) availableOrTryProxy(kind cache.EntryKind, hash string, size int64, offset int64, zstd bool) (io.ReadCloser, int64, bool, error) {
+func (c *diskCache) availableOrTryProxy(ctx context.Context, key Key, kind cache.EntryKind, hash string, size int64, offset int64, zstd bool) (io.ReadCloser, int64, bool, error) {
 	locked := true
 	var err error
 	c.mu.Lock()
 
-	key := cache.LookupKey(kind, hash)
 	item, available := c.lru.Get(key)
 	if available {
 		c.mu.Unlock() // We expect a cache hit below.
 		locked = false
 
-		blobPath := path.Join(c.dir, c.FileLocation(kind, item.legacy, hash, item.size, item.random))
+		blobPath := filepath.Join(c.dir, c.FileLocation(kind, item.legacy, hash, item.size, item.random.String()))
+
+		if !isSizeMismatch(size, item.size) && c.isInlineSize(item.size) {
+			rc, ok := c.inlineReader(key, kind, item, blobPath, offset, zstd)
+			if ok {
+				cache.RecordLookup(ctx, cache.SourceLocal, storedCompression(kind, item.legacy), item.size-offset)
+				return rc, item.size, false, nil
+			}
+		}
 
 		if !isSizeMismatch(size, item.size) {
 			var f *os.File
-			f, err = os.Open(blobPath)
+			f, err = sharedfile.Open(blobPath)
 			if err != nil && os.IsNotExist(err) {
 				// Another request replaced the file before we could open it?
 				// Enter slow path.
@@ -446,24 +957,32 @@ func (c *diskCache) availableOrTryProxy(kind cache.EntryKind, hash string, size
 				c.mu.Lock()
 				item, available = c.lru.Get(key)
 				if available {
-					blobPath = path.Join(c.dir, c.FileLocation(kind, item.legacy, hash, item.size, item.random))
-					f, err = os.Open(blobPath)
+					blobPath = filepath.Join(c.dir, c.FileLocation(kind, item.legacy, hash, item.size, item.random.String()))
+					f, err = sharedfile.Open(blobPath)
 				}
 				c.mu.Unlock()
 			}
 
 			if err != nil {
-				// Race condition, was the item purged after we released the lock?
-				log.Printf("Warning: expected %q to exist on disk, undersized cache?", blobPath)
+				// Was the file deleted behind our back? See zombie.go.
+				if !os.IsNotExist(err) || !c.dropZombie(key, item) {
+					// Race condition, was the item purged after we released the lock?
+					log.Printf("Warning: expected %q to exist on disk, undersized cache?", blobPath)
+				}
 			} else if kind == cache.CAS {
 				var rc io.ReadCloser
 				if item.legacy {
 					// The file is uncompressed, without a casblob header.
-					_, err = f.Seek(offset, io.SeekStart)
-					if zstd && err == nil {
-						rc, err = casblob.GetLegacyZstdReadCloser(c.zstd, f)
-					} else if err == nil {
-						rc = f
+					if zstd {
+						_, err = f.Seek(offset, io.SeekStart)
+						if err == nil {
+							rc, err = casblob.GetLegacyZstdReadCloser(c.zstd, f)
+						}
+					} else {
+						rc, err = c.blobReader(f, item.size, offset)
+						if err == nil && c.shouldVerifyRead(item, offset) {
+							rc = c.newVerifyingReader(rc, key, item)
+						}
 					}
 				} else {
 					// The file is compressed.
@@ -478,6 +997,7 @@ func (c *diskCache) availableOrTryProxy(kind cache.EntryKind, hash string, size
 					log.Printf("Warning: expected item to be on disk, but something happened: %v", err)
 					f.Close()
 				} else {
+					cache.RecordLookup(ctx, cache.SourceLocal, storedCompression(kind, item.legacy), item.size-offset)
 					return rc, item.size, false, nil
 				}
 			} else {
@@ -493,8 +1013,11 @@ func (c *diskCache) availableOrTryProxy(kind cache.EntryKind, hash string, size
 					log.Printf("Warning: expected %s to on disk to have size %d, found %d",
 						blobPath, size, foundSize)
 				} else {
-					_, err = f.Seek(offset, io.SeekStart)
-					return f, foundSize, false, err
+					rc, err := c.blobReader(f, foundSize, offset)
+					if err == nil {
+						cache.RecordLookup(ctx, cache.SourceLocal, storedCompression(kind, item.legacy), foundSize-offset)
+					}
+					return rc, foundSize, false, err
 				}
 			}
 		}
@@ -503,7 +1026,9 @@ func (c *diskCache) availableOrTryProxy(kind cache.EntryKind, hash string, size
 
 	var tryProxy bool
 
-	if c.proxy != nil && size <= c.maxProxyBlobSize {
+	// Blobs from the proxy backend are written to disk before being
+	// served, so we can't use it in read-only mode.
+	if c.proxy != nil && size <= c.maxProxyBlobSize && !c.isReadOnly() && !c.proxyDisabled(ctx) {
 		if size > 0 {
 			// If we know the size, attempt to reserve that much space.
 			if !locked {
@@ -537,14 +1062,33 @@ var errOnlyCompressedCAS = &cache.Error{
 // when processing the request, then it is returned. Callers should provide
 // the `size` of the item to be retrieved, or -1 if unknown.
 func (c *diskCache) Get(ctx context.Context, kind cache.EntryKind, hash string, size int64, offset int64) (rc io.ReadCloser, s int64, rErr error) {
-	return c.get(ctx, kind, hash, size, offset, false)
+	rc, s, rErr = c.get(ctx, kind, hash, size, offset, false)
+	c.recordGet(ctx, kind, hash, rc, s-offset, rErr)
+	return rc, s, rErr
 }
 
 // GetZstd is just like Get, except the data available from rc is zstandard
 // compressed. Note that the returned `s` value still refers to the amount
 // of data once it has been decompressed.
 func (c *diskCache) GetZstd(ctx context.Context, hash string, size int64, offset int64) (rc io.ReadCloser, s int64, rErr error) {
-	return c.get(ctx, cache.CAS, hash, size, offset, true)
+	rc, s, rErr = c.get(ctx, cache.CAS, hash, size, offset, true)
+	c.recordGet(ctx, cache.CAS, hash, rc, s-offset, rErr)
+	return rc, s, rErr
+}
+
+// Update the stats of the invocation which made a Get request, if any,
+// and export a record of the request.
+func (c *diskCache) recordGet(ctx context.Context, kind cache.EntryKind, hash string, rc io.ReadCloser, bytesRead int64, err error) {
+	if err != nil {
+		return
+	}
+	if rc == nil {
+		bytesRead = 0
+	}
+	c.invocations.recordLookup(ctx, kind, hash, rc != nil, bytesRead)
+	c.activity.recordLookup(rc != nil)
+	c.churn.recordRead(kind, bytesRead)
+	c.events.Read(ctx, kind, hash, bytesRead, rc != nil)
 }
 
 func (c *diskCache) get(ctx context.Context, kind cache.EntryKind, hash string, size int64, offset int64, zstd bool) (rc io.ReadCloser, s int64, rErr error) {
@@ -553,8 +1097,13 @@ func (c *diskCache) get(ctx context.Context, kind cache.EntryKind, hash string,
 	if len(hash) != sha256HashStrSize {
 		return nil, -1, badReqErr("Invalid hash size: %d, expected: %d", len(hash), sha256.Size)
 	}
+	key, ok := newKey(kind, hash)
+	if !ok {
+		return nil, -1, badReqErr("Invalid hash: %q", hash)
+	}
 
 	if kind == cache.CAS && size <= 0 && hash == emptySha256 {
+		cache.RecordLookup(ctx, cache.SourceLocal, "", 0)
 		if zstd {
 			return io.NopCloser(bytes.NewReader(emptyZstdBlob)), 0, nil
 		}
@@ -573,142 +1122,107 @@ func (c *diskCache) get(ctx context.Context, kind cache.EntryKind, hash string,
 		return nil, -1, badReqErr("Invalid offset: %d for size %d", offset, size)
 	}
 
-	var err error
-	key := cache.LookupKey(kind, hash)
-
-	var tf *os.File // Tempfile we will write to.
-	var blobFile string
-
-	// Cleanup intermediate state if something went wrong and we
-	// did not successfully commit.
-	unreserve := false
-	removeTempfile := false
-	defer func() {
-		// No lock required to remove stray tempfiles.
-		if removeTempfile {
-			os.Remove(blobFile)
-		} else if blobFile != "" {
-			// Mark the file as "complete".
-			err := os.Chmod(blobFile, tempfile.FinalMode)
-			if err != nil {
-				log.Println("Failed to mark", blobFile, "as complete:", err)
-			}
-		}
-
-		if unreserve {
-			c.mu.Lock()
-			err := c.lru.Unreserve(size)
-			if err != nil {
-				// Set named return value.
-				rErr = internalErr(err)
-				log.Println(rErr.Error())
-			}
-			c.mu.Unlock()
+	// Compressed reads with an offset can't be forwarded, since the owner
+	// only returns whole zstd streams.
+	if owner := c.remoteOwner(ctx, hash); owner != nil && (offset == 0 || !zstd) {
+		rc, foundSize := c.getFromOwner(ctx, owner, kind, hash, size, offset, zstd)
+		if rc != nil {
+			cache.RecordLookup(ctx, cache.SourcePeer, "", foundSize-offset)
+			return rc, foundSize, nil
 		}
-	}()
-
-	f, foundSize, tryProxy, err := c.availableOrTryProxy(kind, hash, size, offset, zstd)
-	if err != nil {
-		return nil, -1, internalErr(err)
-	}
-	if tryProxy && size > 0 {
-		unreserve = true
-	}
-	if f != nil {
-		return f, foundSize, nil
 	}
 
-	if !tryProxy {
-		return nil, -1, nil
+	if c.loading.Load() {
+		return c.getWhileLoading(ctx, key, kind, hash, size, offset, zstd)
 	}
 
-	r, foundSize, err := c.proxy.Get(ctx, kind, hash)
-	if r != nil {
-		defer r.Close()
-	}
-	if err != nil {
-		return nil, -1, internalErr(err)
-	}
-	if r == nil {
-		return nil, -1, nil
-	}
-	if foundSize > c.maxProxyBlobSize {
-		r.Close()
-		return nil, -1, nil
-	}
-
-	if isSizeMismatch(size, foundSize) || foundSize < 0 {
-		return nil, -1, nil
-	}
-
-	legacy := kind == cache.CAS && c.storageMode == casblob.Identity
-
-	blobPathBase := path.Join(c.dir, c.FileLocationBase(kind, legacy, hash, foundSize))
-	tf, random, err := tfc.Create(blobPathBase, legacy)
-	if err != nil {
-		return nil, -1, internalErr(err)
-	}
-	removeTempfile = true
+	waitedForUpload := false
+	for {
+		f, foundSize, tryProxy, err := c.availableOrTryProxy(ctx, key, kind, hash, size, offset, zstd)
+		if err != nil {
+			return nil, -1, internalErr(err)
+		}
+		if f != nil {
+			return c.io.readCloser(ctx, f), foundSize, nil
+		}
 
-	blobFile = tf.Name()
+		// If the item is being uploaded, give the upload a chance to
+		// finish instead of reporting a miss.
+		if !waitedForUpload && c.uploadWait > 0 {
+			if w := c.pendingWrite(key); w != nil {
+				waitedForUpload = true
+				if tryProxy && size > 0 {
+					err = c.unreserve(size)
+					if err != nil {
+						return nil, -1, internalErr(err)
+					}
+				}
+				c.waitForUpload(ctx, w)
+				continue
+			}
+		}
 
-	var sizeOnDisk int64
-	sizeOnDisk, err = io.Copy(tf, r)
-	tf.Close()
-	if err != nil {
-		return nil, -1, internalErr(err)
-	}
+		if !tryProxy {
+			return nil, -1, nil
+		}
 
-	rcf, err := os.Open(blobFile)
-	if err != nil {
-		return nil, -1, internalErr(err)
-	}
+		// If size > 0 then that much space was reserved, and is now
+		// owned by the fetch.
+		finish, other := c.beginFetch(key)
+		if finish != nil {
+			return c.fetchFromProxy(ctx, key, kind, hash, size, offset, zstd, finish)
+		}
 
-	uncompressedOnDisk := (kind != cache.CAS) || (c.storageMode == casblob.Identity)
-	if uncompressedOnDisk {
-		if offset > 0 {
-			_, err = rcf.Seek(offset, io.SeekStart)
+		// Another request is fetching this item, wait for it instead
+		// of downloading it again. Our reservation isn't needed.
+		if size > 0 {
+			err = c.unreserve(size)
 			if err != nil {
 				return nil, -1, internalErr(err)
 			}
 		}
 
-		if zstd {
-			rc, err = casblob.GetLegacyZstdReadCloser(c.zstd, rcf)
-		} else {
-			rc = rcf
+		select {
+		case <-other.done:
+		case <-ctx.Done():
+			return nil, -1, &cache.Error{
+				Code: http.StatusServiceUnavailable,
+				Text: ctx.Err().Error(),
+			}
 		}
-	} else { // Compressed CAS blob.
-		if zstd {
-			rc, err = casblob.GetZstdReadCloser(c.zstd, rcf, foundSize, offset)
-		} else {
-			rc, err = casblob.GetUncompressedReadCloser(c.zstd, rcf, foundSize, offset)
+
+		if other.found || other.missing {
+			c.counterSharedFetches.Inc()
+		}
+		if other.missing {
+			return nil, -1, nil
 		}
-	}
-	if err != nil {
-		return nil, -1, internalErr(err)
-	}
 
-	unreserve, removeTempfile, err = c.commit(key, legacy, blobFile, size, foundSize, sizeOnDisk, random)
-	if err != nil {
-		rc.Close()
-		return nil, -1, internalErr(err)
+		// Check the cache again, and fetch the item ourselves if the
+		// other fetch failed.
 	}
-
-	return rc, foundSize, nil
 }
 
 // Contains returns true if the `hash` key exists in the cache, and
 // the size if known (or -1 if unknown).
 //
-// If there is a local cache miss, the proxy backend (if there is
-// one) will be checked.
+// In cluster mode, the member which owns the item is checked first. If
+// there is a local cache miss, the proxy backend (if there is one) will
+// be checked.
 //
 // Callers should provide the `size` of the item, or -1 if unknown.
 func (c *diskCache) Contains(ctx context.Context, kind cache.EntryKind, hash string, size int64) (bool, int64) {
+	found, foundSize := c.contains(ctx, kind, hash, size)
+	c.invocations.recordLookup(ctx, kind, hash, found, 0)
+	c.activity.recordLookup(found)
+	return found, foundSize
+}
+
+func (c *diskCache) contains(ctx context.Context, kind cache.EntryKind, hash string, size int64) (bool, int64) {
 	// The hash format is checked properly in the http/grpc code.
 	// Just perform a simple/fast check here, to catch bad tests.
-	if len(hash) != sha256HashStrSize {
+	key, ok := newKey(kind, hash)
+	if !ok {
 		return false, -1
 	}
 
@@ -716,22 +1230,36 @@ func (c *diskCache) Contains(ctx context.Context, kind cache.EntryKind, hash str
 		return true, 0
 	}
 
+	if owner := c.remoteOwner(ctx, hash); owner != nil {
+		exists, foundSize, err := owner.Contains(ctx, kind, hash)
+		if err != nil {
+			log.Printf("Warning: failed to check for %s: %v", cache.LookupKey(kind, hash), err)
+		}
+		if exists && !isSizeMismatch(size, foundSize) {
+			return true, foundSize
+		}
+	}
+
 	foundSize := int64(-1)
-	key := cache.LookupKey(kind, hash)
+	exists := false
 
-	c.mu.Lock()
-	item, exists := c.lru.Get(key)
-	if exists {
-		foundSize = item.size
+	// The index is incomplete while loading, see loading.go.
+	if !c.loading.Load() {
+		var item lruItem
+		c.mu.Lock()
+		item, exists = c.lru.Get(key)
+		if exists {
+			foundSize = item.size
+		}
+		c.mu.Unlock()
 	}
-	c.mu.Unlock()
 
 	if exists && !isSizeMismatch(size, foundSize) {
 		return true, foundSize
 	}
 
 	if c.proxy != nil && size <= c.maxProxyBlobSize {
-		exists, foundSize = c.proxy.Contains(ctx, kind, hash)
+		exists, foundSize = c.proxyContains(ctx, kind, hash)
 		if exists && foundSize <= c.maxProxyBlobSize && !isSizeMismatch(size, foundSize) {
 			return true, foundSize
 		}
@@ -742,10 +1270,21 @@ func (c *diskCache) Contains(ctx context.Context, kind cache.EntryKind, hash str
 
 // MaxSize returns the maximum cache size in bytes.
 func (c *diskCache) MaxSize() int64 {
-	// The underlying value is never modified, no need to lock.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	return c.lru.MaxSize()
 }
 
+// SetMaxSize changes the maximum cache size in bytes, and evicts the
+// least recently used entries if the cache is larger than that.
+func (c *diskCache) SetMaxSize(maxSize int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.setMaxSize(maxSize)
+}
+
 // Stats returns the current size of the cache in bytes, and the number of
 // items stored in the cache.
 func (c *diskCache) Stats() (totalSize int64, reservedSize int64, numItems int, uncompressedSize int64) {
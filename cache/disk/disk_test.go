@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -13,6 +14,8 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
@@ -21,8 +24,12 @@ import (
 	"github.com/buchgr/bazel-remote/v2/cache"
 	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
 	"github.com/buchgr/bazel-remote/v2/cache/disk/zstdimpl"
+	"github.com/buchgr/bazel-remote/v2/cache/eventstream"
 	"github.com/buchgr/bazel-remote/v2/cache/httpproxy"
+	"github.com/buchgr/bazel-remote/v2/cache/notify"
+	"github.com/buchgr/bazel-remote/v2/cache/replication"
 	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/limiter"
 
 	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
 	"google.golang.org/protobuf/proto"
@@ -219,6 +226,9 @@ func TestCacheGetContainsWrongSizeWithProxy(t *testing.T) {
 	if rdr == nil {
 		t.Fatal("Expected found, when unknown size")
 	}
+	// Blobs are streamed from the proxy, and added to the cache once
+	// the reader is closed.
+	rdr.Close()
 
 	if testCache.lru.Len() != 1 {
 		t.Fatalf("Expected one item to be in the cache at this point, found %d items",
@@ -456,7 +466,7 @@ func TestCacheExistingFiles(t *testing.T) {
 	evicted := []Key{}
 	origOnEvict := testCache.lru.onEvict
 	testCache.lru.onEvict = func(key Key, value lruItem) {
-		evicted = append(evicted, key.(string))
+		evicted = append(evicted, key)
 		origOnEvict(key, value)
 	}
 
@@ -484,7 +494,7 @@ func TestCacheExistingFiles(t *testing.T) {
 			continue
 		}
 
-		if evicted[0] != items[0].key {
+		if evicted[0].String() != items[0].key {
 			t.Fatalf("Expected first evicted item to be %s, was %s",
 				items[0].key, evicted[0])
 		}
@@ -1444,6 +1454,66 @@ func TestMetricsValidatedAC(t *testing.T) {
 	}
 }
 
+func TestMetricsInstanceLabels(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, 100000,
+		WithAccessLogger(testutils.NewSilentLogger()),
+		WithMetricsInstanceNames([]string{"team-a"}),
+		WithEndpointMetrics())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*metricsDecorator)
+
+	teamA := cache.WithInstanceName(context.Background(), "team-a")
+	teamB := cache.WithInstanceName(context.Background(), "team-b")
+
+	data, hash := testutils.RandomDataAndHash(100)
+	err = testCache.Put(teamA, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ctx := range []context.Context{teamA, teamB} {
+		rc, _, err := testCache.Get(ctx, cache.CAS, hash, int64(len(data)), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc.Close()
+	}
+	found, _ := testCache.Contains(teamB, cache.CAS, "0000000000000000000000000000000000000000000000000000000000000000", -1)
+	if found {
+		t.Fatal("Expected a cache miss")
+	}
+
+	requests := func(instance string, method string, status string) float64 {
+		return testutil.ToFloat64(testCache.counter.With(prometheus.Labels{
+			"method": method, "kind": casKind, "instance": instance, "status": status}))
+	}
+	if n := requests("team-a", getMethod, hitStatus); n != 1 {
+		t.Errorf("Expected 1 hit for team-a, found %f", n)
+	}
+	if n := requests(otherInstance, getMethod, hitStatus); n != 1 {
+		t.Errorf("Expected 1 hit for other instances, found %f", n)
+	}
+	if n := requests(otherInstance, containsMethod, missStatus); n != 1 {
+		t.Errorf("Expected 1 miss for other instances, found %f", n)
+	}
+
+	bytesCount := func(instance string, method string) float64 {
+		return testutil.ToFloat64(testCache.bytesCounter.With(prometheus.Labels{
+			"method": method, "kind": casKind, "instance": instance}))
+	}
+	if n := bytesCount("team-a", putMethod); n != float64(len(data)) {
+		t.Errorf("Expected %d bytes written by team-a, found %f", len(data), n)
+	}
+	if n := bytesCount(otherInstance, getMethod); n != float64(len(data)) {
+		t.Errorf("Expected %d bytes read by other instances, found %f", len(data), n)
+	}
+}
+
 func TestCacheDirLostAndFound(t *testing.T) {
 	cacheDir := tempDir(t)
 	defer os.RemoveAll(cacheDir)
@@ -1477,3 +1547,565 @@ func TestCacheDirLostAndFound(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestListDir(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	err := os.Mkdir(path.Join(dir, lostAndFound), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := map[string]int{"empty": 0, "small": 5, "large": 100000}
+	for name, size := range sizes {
+		err = os.WriteFile(path.Join(dir, name), make([]byte, size), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := listDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != len(sizes)+1 {
+		t.Fatalf("Expected %d entries, found %d", len(sizes)+1, len(entries))
+	}
+
+	for _, e := range entries {
+		if e.name == lostAndFound {
+			if !e.isDir {
+				t.Errorf("Expected %q to be a directory", e.name)
+			}
+			continue
+		}
+
+		if e.isDir {
+			t.Errorf("Expected %q to be a file", e.name)
+		}
+		if e.size != int64(sizes[e.name]) {
+			t.Errorf("Expected %q to have size %d, found %d", e.name, sizes[e.name], e.size)
+		}
+
+		info, err := os.Stat(path.Join(dir, e.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if at := accessTime(info); !e.atime.Equal(at) {
+			t.Errorf("Expected %q to have access time %s, found %s", e.name, at, e.atime)
+		}
+	}
+
+	_, err = listDir(path.Join(dir, "nonexistent"))
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected a not exist error for a missing directory, got %v", err)
+	}
+}
+
+func TestScanWorkers(t *testing.T) {
+	ctx := context.Background()
+
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	_, err := New(cacheDir, BlockSize, WithScanWorkers(-1),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err == nil {
+		t.Fatal("Expected an error for a negative number of scan workers")
+	}
+
+	numBlobs := 50
+	blobSize := int64(1024)
+	cacheSize := (blobSize + BlockSize) * int64(numBlobs) * 2
+
+	testCache, err := New(cacheDir, cacheSize, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashes := make([]string, numBlobs)
+	for i := range hashes {
+		var data []byte
+		data, hashes[i] = testutils.RandomDataAndHash(blobSize)
+		err = testCache.Put(ctx, cache.CAS, hashes[i], blobSize, bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, workers := range []int{1, 64} {
+		testCache, err = New(cacheDir, cacheSize, WithScanWorkers(workers),
+			WithAccessLogger(testutils.NewSilentLogger()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, numItems, _ := testCache.Stats()
+		if numItems != numBlobs {
+			t.Errorf("Expected %d items to be loaded with %d scan workers, found %d",
+				numBlobs, workers, numItems)
+		}
+
+		for _, hash := range hashes {
+			found, _ := testCache.Contains(ctx, cache.CAS, hash, blobSize)
+			if !found {
+				t.Errorf("Expected CAS blob %s to be loaded with %d scan workers", hash, workers)
+			}
+		}
+	}
+}
+
+func TestFsyncPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	invalid := []map[string]string{
+		{"cas": "sometimes"},
+		{"blob": FsyncNever},
+	}
+	for _, policies := range invalid {
+		cacheDir := tempDir(t)
+		_, err := New(cacheDir, BlockSize, WithFsyncPolicies(policies),
+			WithAccessLogger(testutils.NewSilentLogger()))
+		os.RemoveAll(cacheDir)
+		if err == nil {
+			t.Errorf("Expected an error for fsync policies %v", policies)
+		}
+	}
+
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	policies := map[string]string{"ac": FsyncAlways, "cas": FsyncNever, "raw": FsyncBatch}
+
+	testCacheI, err := New(cacheDir, BlockSize*10, WithFsyncPolicies(policies),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+
+	for _, kind := range []cache.EntryKind{cache.AC, cache.CAS, cache.RAW} {
+		data, hash := testutils.RandomDataAndHash(100)
+		err = testCache.Put(ctx, kind, hash, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rc, _, err := testCache.Get(ctx, kind, hash, int64(len(data)), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rc == nil {
+			t.Fatalf("Expected to find the %s entry", kind)
+		}
+		rc.Close()
+	}
+
+	if n := testutil.CollectAndCount(testCache.histogramFsyncDuration); n != 4 {
+		t.Errorf("Expected 4 fsync duration series, found %d", n)
+	}
+	if n := testutil.ToFloat64(testCache.dirSyncer.counterSyncs); n != 1 {
+		t.Errorf("Expected 1 batched directory sync, found %f", n)
+	}
+
+	// Series are only created by a sync.
+	expected := []struct {
+		kind   string
+		target string
+		synced bool
+	}{
+		{"ac", fsyncTargetFile, true},
+		{"ac", fsyncTargetDir, true},
+		{"cas", fsyncTargetFile, false},
+		{"cas", fsyncTargetDir, false},
+		{"raw", fsyncTargetFile, true},
+		{"raw", fsyncTargetDir, true},
+	}
+	for _, e := range expected {
+		synced := testCache.histogramFsyncDuration.DeleteLabelValues(e.kind, e.target)
+		if synced != e.synced {
+			t.Errorf("Expected %s sync of %s entries: %v, found %v", e.target, e.kind, e.synced, synced)
+		}
+	}
+}
+
+func TestMaxInflightUploadSize(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCache, err := New(cacheDir, BlockSize*100,
+		WithMaxInflightUploadSize(2*BlockSize),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := testCache.(*diskCache)
+	ctx := context.Background()
+
+	// Start an upload which doesn't finish until we close pw.
+	slow, slowHash := testutils.RandomDataAndHash(BlockSize)
+	pr, pw := io.Pipe()
+	slowErr := make(chan error)
+	go func() {
+		slowErr <- c.Put(ctx, cache.CAS, slowHash, int64(len(slow)), pr)
+	}()
+	for i := 0; ; i++ {
+		c.mu.Lock()
+		reserved := c.lru.ReservedSize()
+		c.mu.Unlock()
+		if reserved > 0 {
+			break
+		}
+		if i == 1000 {
+			t.Fatal("Timed out waiting for the upload to start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// An upload which fits under the limit.
+	data, hash := testutils.RandomDataAndHash(BlockSize / 2)
+	err = c.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An upload which doesn't.
+	data, hash = testutils.RandomDataAndHash(BlockSize + 1)
+	err = c.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	cerr, ok := err.(*cache.Error)
+	if !ok || cerr.Code != http.StatusTooManyRequests {
+		t.Fatal("Expected a cache.Error with code 429, got", err)
+	}
+	if cerr.RetryAfter < limiter.RetryAfter || cerr.QuotaSubject != "max_inflight_upload_size" {
+		t.Errorf("Expected a retry delay and the quota subject, got %+v", cerr)
+	}
+	if n := testutil.ToFloat64(c.counterThrottledWrites); n != 1 {
+		t.Error("Expected 1 throttled write, got", n)
+	}
+
+	_, err = pw.Write(slow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw.Close()
+	err = <-slowErr
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A single upload larger than the limit is allowed when no others
+	// are in progress.
+	data, hash = testutils.RandomDataAndHash(3 * BlockSize)
+	err = c.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplicaConflicts(t *testing.T) {
+	for _, policy := range replication.GetConflictPolicies() {
+		t.Run(policy, func(t *testing.T) {
+			cacheDir := tempDir(t)
+			defer os.RemoveAll(cacheDir)
+
+			r, err := replication.New(nil, false, policy, "s3cret", &http.Client{},
+				testutils.NewSilentLogger(), 1, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testCache, err := New(cacheDir, BlockSize*100,
+				WithAccessLogger(testutils.NewSilentLogger()),
+				WithReplicator(r))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+			peerCtx := replication.FromPeer(ctx)
+
+			original := []byte("original")
+			err = testCache.Put(ctx, cache.RAW, contentsHash, int64(len(original)), bytes.NewReader(original))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// An identical entry from a peer is not a conflict.
+			err = testCache.Put(peerCtx, cache.RAW, contentsHash, int64(len(original)), bytes.NewReader(original))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			conflicting := []byte("conflicting")
+			err = testCache.Put(peerCtx, cache.RAW, contentsHash, int64(len(conflicting)), bytes.NewReader(conflicting))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rc, _, err := testCache.Get(ctx, cache.RAW, contentsHash, -1, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			data, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := conflicting
+			if policy == replication.ConflictKeepExisting {
+				expected = original
+			}
+			if !bytes.Equal(data, expected) {
+				t.Errorf("Expected %q, got %q", expected, data)
+			}
+
+			// Entries of the same size, which differ after the first
+			// chunk that is compared.
+			original, largeHash := testutils.RandomDataAndHash(3 * replicaCompareChunkSize)
+			err = testCache.Put(ctx, cache.RAW, largeHash, int64(len(original)), bytes.NewReader(original))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			conflicting = append([]byte{}, original...)
+			conflicting[len(conflicting)-1]++
+			err = testCache.Put(peerCtx, cache.RAW, largeHash, int64(len(conflicting)), bytes.NewReader(conflicting))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rc, _, err = testCache.Get(ctx, cache.RAW, largeHash, -1, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			data, err = io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected = conflicting
+			if policy == replication.ConflictKeepExisting {
+				expected = original
+			}
+			if !bytes.Equal(data, expected) {
+				t.Error("Unexpected data for the large entry")
+			}
+		})
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	var mu sync.Mutex
+	var events []notify.Event
+
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e notify.Event
+		err := json.NewDecoder(r.Body).Decode(&e)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	n, err := notify.NewWebhook(webhook.URL, &http.Client{},
+		notify.Filter{Kinds: []string{"cas"}}, 10, 0, testutils.NewSilentLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+	testCache, err := New(cacheDir, 10*BlockSize,
+		WithNotifier(n),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	err = testCache.Put(ctx, cache.CAS, contentsHash, contentsLength, strings.NewReader(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testCache.Put(ctx, cache.CAS, hashStr("foo"), contentsLength, strings.NewReader(contents))
+	if err == nil {
+		t.Fatal("Expected a hash mismatch error")
+	}
+
+	// Filtered out.
+	err = testCache.Put(ctx, cache.AC, contentsHash, contentsLength, strings.NewReader(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = n.Flush(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %+v", events)
+	}
+	if events[0].Type != notify.EventUpload || events[0].Hash != contentsHash {
+		t.Errorf("Expected an upload event for %s, got %+v", contentsHash, events[0])
+	}
+	if events[1].Type != notify.EventHashMismatch || events[1].Hash != hashStr("foo") {
+		t.Errorf("Expected a hash mismatch event for %s, got %+v", hashStr("foo"), events[1])
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	var mu sync.Mutex
+	var ops []string
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Records []struct {
+				Value eventstream.Record `json:"value"`
+			} `json:"records"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		for _, rec := range req.Records {
+			ops = append(ops, rec.Value.Op+" "+rec.Value.Result)
+		}
+		mu.Unlock()
+	}))
+	defer proxy.Close()
+
+	e, err := eventstream.NewKafkaREST(proxy.URL, "events", &http.Client{},
+		eventstream.Options{BatchSize: 100, FlushInterval: 10 * time.Millisecond, QueueSize: 100},
+		testutils.NewSilentLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+	testCache, err := New(cacheDir, 3*BlockSize,
+		WithStorageMode("uncompressed"),
+		WithEventStream(e),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	err = testCache.Put(ctx, cache.CAS, contentsHash, contentsLength, strings.NewReader(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rc, _, err := testCache.Get(ctx, cache.CAS, contentsHash, contentsLength, 0)
+	if err != nil || rc == nil {
+		t.Fatalf("Expected a cache hit, got %v", err)
+	}
+	rc.Close()
+
+	// Overwriting an entry is not an eviction, but the last write
+	// doesn't fit and evicts the least recently used entry.
+	for _, h := range []string{contentsHash, contentsHash, hashStr("a"), hashStr("b")} {
+		err = testCache.Put(ctx, cache.RAW, h, contentsLength, strings.NewReader(contents))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rc, _, err = testCache.Get(ctx, cache.CAS, contentsHash, contentsLength, 0)
+	if err != nil || rc != nil {
+		t.Fatalf("Expected a cache miss, got %v", err)
+	}
+
+	err = e.Flush(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	expected := []string{"write ", "read hit", "write ", "write ", "write ", "evict ", "write ", "read miss"}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Errorf("Expected %q, got %q", expected, ops)
+	}
+}
+
+func TestFileRemovalLimits(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCache, err := New(cacheDir, BlockSize*100,
+		WithMaxConcurrentFileRemovals(1),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := testCache.(*diskCache)
+
+	if n := testutil.ToFloat64(c.gaugeFileRemovalLimit); n != 1 {
+		t.Error("Expected a limit of 1 concurrent file removal, got", n)
+	}
+
+	// Hold the only slot, so the next removal is queued.
+	err = c.fileRemovalSem.Acquire(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A non-empty directory can't be removed.
+	dir := filepath.Join(cacheDir, "undeletable")
+	err = os.MkdirAll(filepath.Join(dir, "child"), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.removeFile(dir)
+		close(done)
+	}()
+
+	for i := 0; testutil.ToFloat64(c.gaugeFileRemovalsQueued) != 1; i++ {
+		if i == 1000 {
+			t.Fatal("Timed out waiting for the removal to be queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	c.fileRemovalSem.Release(1)
+	<-done
+
+	if n := testutil.ToFloat64(c.gaugeFileRemovalsQueued); n != 0 {
+		t.Error("Expected no queued removals, got", n)
+	}
+	if n := testutil.ToFloat64(c.gaugeFileRemovalsInProgress); n != 0 {
+		t.Error("Expected no removals in progress, got", n)
+	}
+	if n := testutil.ToFloat64(c.counterFileRemovalErrors); n != 1 {
+		t.Error("Expected 1 removal error, got", n)
+	}
+
+	// Files which were already removed are not errors.
+	c.removeFile(filepath.Join(cacheDir, "missing"))
+	if n := testutil.ToFloat64(c.counterFileRemovalErrors); n != 1 {
+		t.Error("Expected 1 removal error, got", n)
+	}
+}
@@ -0,0 +1,64 @@
+package disk
+
+import (
+	"container/list"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// Filesystems can run out of inodes before the cache reaches its maximum
+// size, eg when it holds many small CAS blobs, since every entry is a
+// file. With WithMaxEntries, the number of entries of each kind can be
+// limited as well. When a kind would exceed its limit, its own least
+// recently used entries are evicted, like for instance quotas.
+
+// The entries of a kind with an entry limit.
+type kindEntries struct {
+	limit int
+
+	// The kind's entries, from most to least recently used. The values
+	// are the entries' elements in the main LRU list.
+	ll *list.List
+}
+
+// Set the maximum number of entries of the given kinds. This must be
+// called before any entries are added.
+func (c *SizedLRU) setMaxEntries(limits map[cache.EntryKind]int) {
+	for kind, limit := range limits {
+		c.kindEntries[kind] = &kindEntries{limit: limit, ll: list.New()}
+	}
+}
+
+// Start counting the entry in ele against the limit of its kind, if it
+// has one.
+func (c *SizedLRU) attachKind(ele *list.Element) {
+	e := ele.Value.(*entry)
+	k := c.kindEntries[e.key.kind]
+	if k != nil {
+		e.kindEle = k.ll.PushFront(ele)
+	}
+}
+
+// Stop counting e against the limit of its kind.
+func (c *SizedLRU) detachKind(e *entry) {
+	if e.kindEle == nil {
+		return
+	}
+
+	c.kindEntries[e.key.kind].ll.Remove(e.kindEle)
+	e.kindEle = nil
+}
+
+// Evict the least recently used entries of kind until it is within its
+// entry limit, if it has one.
+func (c *SizedLRU) enforceMaxEntries(kind uint8) {
+	k := c.kindEntries[kind]
+	if k == nil {
+		return
+	}
+
+	for k.ll.Len() > k.limit {
+		c.removeElement(k.ll.Back().Value.(*list.Element))
+		c.counterEntryLimitEvictions.WithLabelValues(cache.EntryKind(kind).String()).Inc()
+	}
+}
@@ -0,0 +1,54 @@
+package disk
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func kindKey(kind cache.EntryKind, name string) Key {
+	return Key{kind: uint8(kind), digest: sha256.Sum256([]byte(name))}
+}
+
+func TestMaxEntries(t *testing.T) {
+	var evicted []Key
+	lru := NewSizedLRU(100*BlockSize, func(key Key, value lruItem) {
+		evicted = append(evicted, key)
+	}, 0)
+	lru.setMaxEntries(map[cache.EntryKind]int{cache.CAS: 2})
+
+	item := lruItem{size: 10, sizeOnDisk: 10}
+	lru.Add(kindKey(cache.CAS, "1"), item)
+	lru.Add(kindKey(cache.AC, "1"), item)
+	lru.Add(kindKey(cache.CAS, "2"), item)
+	lru.Add(kindKey(cache.AC, "2"), item)
+
+	// Mark cas 1 as more recently used than cas 2.
+	lru.Get(kindKey(cache.CAS, "1"))
+
+	lru.Add(kindKey(cache.CAS, "3"), item)
+
+	if len(evicted) != 1 || evicted[0] != kindKey(cache.CAS, "2") {
+		t.Errorf("Expected only cas 2 to be evicted, got %v", evicted)
+	}
+	checkSizeAndNumItems(t, lru, 4*BlockSize, 4)
+
+	// Overwriting an entry doesn't count it twice.
+	lru.Add(kindKey(cache.CAS, "3"), item)
+	if _, found := lru.peek(kindKey(cache.CAS, "1")); !found {
+		t.Error("Expected cas 1 to remain in the cache")
+	}
+	checkSizeAndNumItems(t, lru, 4*BlockSize, 4)
+
+	// Removed entries no longer count against the limit.
+	lru.Remove(kindKey(cache.CAS, "1"))
+	lru.Add(kindKey(cache.CAS, "4"), item)
+	checkSizeAndNumItems(t, lru, 4*BlockSize, 4)
+
+	if n := testutil.ToFloat64(lru.counterEntryLimitEvictions.WithLabelValues("cas")); n != 1 {
+		t.Errorf("Expected 1 eviction due to the entry limit, got %v", n)
+	}
+}
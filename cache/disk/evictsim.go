@@ -0,0 +1,95 @@
+package disk
+
+import (
+	"time"
+)
+
+// EvictionReport describes the entries which would be evicted to shrink
+// the cache to a target size. See SimulateEviction.
+type EvictionReport struct {
+	CurrentSize int64
+	TargetSize  int64
+
+	// The entries which would be evicted.
+	NumEntries       int
+	SizeOnDisk       int64
+	LogicalSize      int64
+	NumEntriesByKind map[string]int
+
+	// The time since the entries were last accessed, according to the
+	// filesystem's atime.
+	AgeDistribution []AgeBucket
+}
+
+// AgeBucket counts the entries last accessed within an age range.
+type AgeBucket struct {
+	Age        string
+	NumEntries int
+	SizeOnDisk int64
+}
+
+var ageBuckets = []struct {
+	label  string
+	maxAge time.Duration
+}{
+	{"<1h", time.Hour},
+	{"<1d", 24 * time.Hour},
+	{"<7d", 7 * 24 * time.Hour},
+	{"<30d", 30 * 24 * time.Hour},
+	{">=30d", -1},
+}
+
+// SimulateEviction reports which entries would be evicted if the cache
+// was shrunk to targetSize bytes, without evicting anything.
+func (c *diskCache) SimulateEviction(targetSize int64) EvictionReport {
+	c.mu.Lock()
+	currentSize := c.lru.TotalSize()
+	candidates := c.lru.evictionCandidates(targetSize)
+	c.mu.Unlock()
+
+	r := EvictionReport{
+		CurrentSize:      currentSize,
+		TargetSize:       targetSize,
+		NumEntries:       len(candidates),
+		NumEntriesByKind: make(map[string]int),
+		AgeDistribution:  make([]AgeBucket, len(ageBuckets)),
+	}
+	for i, b := range ageBuckets {
+		r.AgeDistribution[i].Age = b.label
+	}
+
+	var unknownAge AgeBucket
+
+	now := time.Now()
+	for _, e := range candidates {
+		sizeOnDisk := roundUp4k(e.value.sizeOnDisk)
+		r.SizeOnDisk += sizeOnDisk
+		r.LogicalSize += e.value.size
+
+		r.NumEntriesByKind[e.key.Kind().String()]++
+
+		ts, err := statAccessTime(c.getElementPath(e.key, e.value))
+		if err != nil {
+			// Probably replaced or evicted in the meantime.
+			unknownAge.NumEntries++
+			unknownAge.SizeOnDisk += sizeOnDisk
+			continue
+		}
+
+		age := now.Sub(ts)
+		for i, b := range ageBuckets {
+			if age < b.maxAge || b.maxAge < 0 {
+				r.AgeDistribution[i].NumEntries++
+				r.AgeDistribution[i].SizeOnDisk += sizeOnDisk
+				break
+			}
+		}
+	}
+
+	if unknownAge.NumEntries > 0 {
+		unknownAge.Age = "unknown"
+		r.AgeDistribution = append(r.AgeDistribution, unknownAge)
+	}
+
+	return r
+}
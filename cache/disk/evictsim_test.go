@@ -0,0 +1,67 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestSimulateEviction(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCache, err := New(cacheDir, BlockSize*10,
+		WithAccessLogger(testutils.NewSilentLogger()),
+		WithStorageMode("uncompressed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	entries := []struct {
+		kind cache.EntryKind
+		data []byte
+	}{
+		{cache.CAS, []byte("oldest")},
+		{cache.AC, []byte("older")},
+		{cache.CAS, []byte("newest")},
+	}
+	for _, e := range entries {
+		err = testCache.Put(ctx, e.kind, hashStr(string(e.data)), int64(len(e.data)), bytes.NewReader(e.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := testCache.SimulateEviction(BlockSize)
+	if r.CurrentSize != 3*BlockSize || r.TargetSize != BlockSize {
+		t.Errorf("Unexpected sizes in report: %+v", r)
+	}
+	if r.NumEntries != 2 || r.SizeOnDisk != 2*BlockSize {
+		t.Errorf("Expected the two least recently used entries to be evicted, got %+v", r)
+	}
+	if r.NumEntriesByKind["cas"] != 1 || r.NumEntriesByKind["ac"] != 1 {
+		t.Errorf("Expected one CAS and one AC entry to be evicted, got %v", r.NumEntriesByKind)
+	}
+	if r.AgeDistribution[0].Age != "<1h" || r.AgeDistribution[0].NumEntries != 2 {
+		t.Errorf("Expected both entries to be less than an hour old, got %+v", r.AgeDistribution)
+	}
+
+	if r = testCache.SimulateEviction(10 * BlockSize); r.NumEntries != 0 {
+		t.Errorf("Expected nothing to be evicted, got %+v", r)
+	}
+
+	// Nothing is actually evicted.
+	_, _, numItems, _ := testCache.Stats()
+	if numItems != len(entries) {
+		t.Errorf("Expected %d items to remain in the cache, found %d", len(entries), numItems)
+	}
+	found, _ := testCache.Contains(ctx, cache.CAS, hashStr("oldest"), -1)
+	if !found {
+		t.Error("Expected the oldest entry to remain in the cache")
+	}
+}
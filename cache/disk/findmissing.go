@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/cluster"
 
 	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
 
@@ -30,11 +31,20 @@ var errRequestCancelled = status.Error(codes.Canceled, "Request was cancelled")
 //
 // Note that this modifies the input slice and returns a subset of it.
 func (c *diskCache) FindMissingCasBlobs(ctx context.Context, blobs []*pb.Digest) ([]*pb.Digest, error) {
+	var requested []*pb.Digest
+	if c.invocations != nil {
+		// Keep the requested digests for the invocation stats, since
+		// the found ones are replaced with nil below.
+		requested = append(requested, blobs...)
+	}
+
 	err := c.findMissingCasBlobsInternal(ctx, blobs, false)
 	if err != nil {
 		return nil, err
 	}
-	return filterNonNil(blobs), nil
+	missing := filterNonNil(blobs)
+	c.invocations.recordFindMissing(ctx, requested, missing)
+	return missing, nil
 }
 
 // Identifies local and proxy cache misses for blobs. Modifies the input `blobs` slice such that found
@@ -47,6 +57,12 @@ func (c *diskCache) findMissingCasBlobsInternal(ctx context.Context, blobs []*pb
 	// batchSize moderates how long the cache lock is held by findMissingLocalCAS.
 	const batchSize = 20
 
+	blobs = c.skipEmptyBlobs(blobs)
+
+	if c.cluster != nil && !cluster.IsForwarded(ctx) {
+		blobs = c.findMissingClusterCAS(ctx, blobs)
+	}
+
 	var cancelContextForFailFast context.CancelFunc = nil
 	cancelledDueToFailFast := false
 
@@ -169,31 +185,57 @@ func filterNonNil(blobs []*pb.Digest) []*pb.Digest {
 	return blobs[:count]
 }
 
+// Set the digests of the empty blob, which is always present, to nil and
+// return the remaining blobs, so that the empty blob is not looked up on
+// other cluster members or in the index.
+func (c *diskCache) skipEmptyBlobs(blobs []*pb.Digest) []*pb.Digest {
+	found := false
+	for i := range blobs {
+		if blobs[i].SizeBytes == 0 && blobs[i].Hash == emptySha256 {
+			c.accessLogger.Printf("GRPC CAS HEAD %s OK", blobs[i].Hash)
+			blobs[i] = nil
+			found = true
+		}
+	}
+	if !found {
+		return blobs
+	}
+
+	remaining := filterNonNil(blobs)
+	for i := len(remaining); i < len(blobs); i++ {
+		blobs[i] = nil
+	}
+
+	return remaining
+}
+
 // Set blobs that exist in the disk cache to nil, and return the number
 // of missing blobs.
 func (c *diskCache) findMissingLocalCAS(blobs []*pb.Digest) int {
 	var exists bool
 	var item lruItem
-	var key string
+	var key Key
 	missing := 0
 
+	if c.loading.Load() {
+		// The index is incomplete, see loading.go.
+		return len(blobs)
+	}
+
 	c.mu.Lock()
 
 	for i := range blobs {
-		if blobs[i].SizeBytes == 0 && blobs[i].Hash == emptySha256 {
-			c.accessLogger.Printf("GRPC CAS HEAD %s OK", blobs[i].Hash)
-			blobs[i] = nil
-			continue
-		}
-
 		foundSize := int64(-1)
-		key = cache.LookupKey(cache.CAS, blobs[i].Hash)
-		item, exists = c.lru.Get(key)
+		key, exists = newKey(cache.CAS, blobs[i].Hash)
+		if exists {
+			item, exists = c.lru.Get(key)
+		}
 		if exists {
 			foundSize = item.size
 		}
 
 		if exists && !isSizeMismatch(blobs[i].SizeBytes, foundSize) {
+			c.lru.lease(key)
 			c.accessLogger.Printf("GRPC CAS HEAD %s OK", blobs[i].Hash)
 			blobs[i] = nil
 		} else {
@@ -220,7 +262,7 @@ func (c *diskCache) containsWorker() {
 			}
 		}
 
-		ok, _ = c.proxy.Contains(req.ctx, cache.CAS, (*req.digest).Hash)
+		ok, _ = c.proxyContains(req.ctx, cache.CAS, (*req.digest).Hash)
 		if ok {
 			c.accessLogger.Printf("GRPC CAS HEAD %s OK", (*req.digest).Hash)
 			// The blob exists on the proxy, remove it from the
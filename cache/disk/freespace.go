@@ -0,0 +1,109 @@
+package disk
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// Space is reserved in the index for each upload before it is written,
+// but reservations are only checked against the maximum size of the
+// cache, so if that is larger than the free space on the cache
+// directory's filesystem, eg because other files use it, many large
+// uploads in parallel can fill the filesystem, and fail half way with
+// write errors. With WithFreeSpaceCheck, an upload is refused before
+// anything is written if its size, plus the space reserved by the uploads
+// in progress, is more than the free space on the filesystem, less the
+// headroom if there is one. The free space is measured with statfs at
+// most every freeSpaceSampleInterval. The check is conservative: the
+// parts of uploads in progress which were already written are counted
+// twice, since they use free space and are still reserved. This is only
+// supported on Linux.
+
+const freeSpaceSampleInterval = time.Second
+
+// A sample of the free space on the cache directory's filesystem.
+type freeSpaceSample struct {
+	mu      sync.Mutex
+	sampled time.Time
+	stats   filesystemStats
+	err     error
+}
+
+// Returns the number of bytes on the cache directory's filesystem which
+// are available for the cache, ie the free space less the headroom.
+func (c *diskCache) availableForWrites() (int64, error) {
+	s := c.freeSpace
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.sampled) >= freeSpaceSampleInterval {
+		s.stats, s.err = c.statFilesystem(c.dir)
+		s.sampled = now
+		if s.err != nil {
+			log.Printf("Warning: failed to check the free space for check_free_space: %v", s.err)
+		} else {
+			c.gaugeFilesystemAvailableBytes.Set(float64(s.stats.available))
+		}
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	available := s.stats.available
+	if c.headroom != nil {
+		available -= c.headroom.required(s.stats.size)
+	}
+	return available, nil
+}
+
+// Returns the number of bytes available for an upload, and false if the
+// free space isn't checked or couldn't be measured, in which case the
+// upload is allowed.
+func (c *diskCache) freeSpaceForWrites() (int64, bool) {
+	if c.freeSpace == nil {
+		return 0, false
+	}
+
+	available, err := c.availableForWrites()
+	if err != nil {
+		return 0, false
+	}
+	return available, true
+}
+
+// Returns an error if an upload of size bytes, and the uploads which
+// reserved space already, need more than available bytes. Must be called
+// with mu held.
+func (c *diskCache) checkFreeSpace(size int64, available int64) error {
+	reserved := c.lru.ReservedSize()
+	if size+reserved <= available {
+		return nil
+	}
+
+	c.counterFreeSpaceRefusedWrites.Inc()
+	return &cache.Error{
+		Code: http.StatusInsufficientStorage,
+		Text: fmt.Sprintf("Not enough free space on the cache directory's filesystem for %d bytes: %d bytes are available, and %d are reserved by uploads in progress",
+			size, available, reserved),
+		QuotaSubject: "free_space",
+	}
+}
+
+// Check that the free space of the cache directory's filesystem can be
+// found, before serving requests.
+func (c *diskCache) initFreeSpaceCheck() error {
+	available, err := c.availableForWrites()
+	if err != nil {
+		return fmt.Errorf("Unable to check the free space for check_free_space: %w", err)
+	}
+
+	log.Printf("Refusing uploads which don't fit in the free space on the cache directory's filesystem, currently %d bytes", available)
+	return nil
+}
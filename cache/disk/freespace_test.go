@@ -0,0 +1,107 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestFreeSpaceCheck(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, 100*BlockSize, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := testCacheI.(*diskCache)
+
+	available := int64(10 * BlockSize)
+	statErr := error(nil)
+	c.freeSpace = &freeSpaceSample{}
+	c.statFilesystem = func(dir string) (filesystemStats, error) {
+		return filesystemStats{size: 1000 * BlockSize, available: available}, statErr
+	}
+	resample := func() {
+		c.freeSpace.sampled = time.Time{}
+	}
+
+	ctx := context.Background()
+	put := func(size int) error {
+		data, hash := testutils.RandomDataAndHash(int64(size))
+		return c.Put(ctx, cache.RAW, hash, int64(len(data)), bytes.NewReader(data))
+	}
+	expectRefused := func(err error, msg string) {
+		t.Helper()
+		var cerr *cache.Error
+		if !errors.As(err, &cerr) || cerr.Code != http.StatusInsufficientStorage || cerr.QuotaSubject != "free_space" {
+			t.Fatalf("Expected the upload to be refused %s, got %v", msg, err)
+		}
+	}
+
+	// Uploads which fit in the free space are accepted.
+	err = put(4 * BlockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The free space is only measured once per interval.
+	available = 2 * BlockSize
+	err = put(4 * BlockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resample()
+	err = put(4 * BlockSize)
+	expectRefused(err, "when it doesn't fit in the free space")
+	if !strings.Contains(err.Error(), "8192 bytes are available") {
+		t.Errorf("Expected the error to report the available space, got %q", err)
+	}
+
+	// Space reserved by uploads in progress is not available.
+	available = 10 * BlockSize
+	resample()
+	c.mu.Lock()
+	_, err = c.lru.Reserve(8 * BlockSize)
+	c.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = put(4 * BlockSize)
+	expectRefused(err, "when other uploads reserved the free space")
+	err = put(BlockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	err = c.lru.Unreserve(8 * BlockSize)
+	c.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The headroom is not available.
+	c.headroom = &diskHeadroom{bytes: 8 * BlockSize}
+	resample()
+	err = put(4 * BlockSize)
+	expectRefused(err, "when it doesn't fit in the free space less the headroom")
+	c.headroom = nil
+
+	// Uploads are allowed if the free space can't be measured.
+	statErr = errors.New("statfs failed")
+	available = 0
+	resample()
+	err = put(4 * BlockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,120 @@
+package disk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Durability policies for the files written to the cache directory,
+// which trade the cost of fsync(2) calls for the chance of losing or
+// corrupting entries if the machine crashes. The cache directory is
+// only missing entries after a crash if files or directory entries are
+// not synced, which clients recover from by rebuilding them. But a file
+// which is not synced may be found with missing data after a crash.
+const (
+	// Sync each file before it is added to the index. This is the
+	// default.
+	FsyncFile = "file"
+
+	// Sync each file, and the directory which contains it.
+	FsyncAlways = "always"
+
+	// Sync each file, and sync the directories which contain them in
+	// batches. Writes wait for the batched sync, so this is as durable
+	// as FsyncAlways, but adds latency. With many small writes, eg of
+	// AC entries, there are fewer directory syncs. See dirsync.go.
+	FsyncBatch = "batch"
+
+	// Only sync the directory which contains each file.
+	FsyncDir = "dir"
+
+	// Leave syncing to the operating system.
+	FsyncNever = "never"
+)
+
+func GetFsyncPolicies() []string {
+	return []string{
+		FsyncFile,
+		FsyncAlways,
+		FsyncBatch,
+		FsyncDir,
+		FsyncNever,
+	}
+}
+
+func IsValidFsyncPolicy(policy string) bool {
+	for _, p := range GetFsyncPolicies() {
+		if policy == p {
+			return true
+		}
+	}
+	return false
+}
+
+// The fsync duration histogram labels for what was synced.
+const (
+	fsyncTargetFile = "file"
+	fsyncTargetDir  = "dir"
+)
+
+func newFsyncDurationHistogram() *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bazel_remote_disk_cache_fsync_duration_seconds",
+		Help:    "The time taken to sync files written to the cache directory, and the directories which contain them, by kind and target (file or dir). With the batch policy, the dir times include the wait for the batch",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 4, 9),
+	}, []string{"kind", "target"})
+}
+
+// Returns the fsync policy for entries of the given kind.
+func (c *diskCache) fsyncPolicy(kind cache.EntryKind) string {
+	if p, ok := c.fsyncPolicies[kind]; ok {
+		return p
+	}
+	return FsyncFile
+}
+
+// Sync f before it is closed, if required by the fsync policy for kind.
+func (c *diskCache) syncFile(kind cache.EntryKind, f *os.File) error {
+	p := c.fsyncPolicy(kind)
+	if p != FsyncFile && p != FsyncAlways && p != FsyncBatch {
+		return nil
+	}
+
+	start := time.Now()
+	err := f.Sync()
+	c.histogramFsyncDuration.WithLabelValues(kind.String(), fsyncTargetFile).
+		Observe(time.Since(start).Seconds())
+
+	return err
+}
+
+// Sync the directory which contains the named file, if required by the
+// fsync policy for kind.
+func (c *diskCache) syncParentDir(kind cache.EntryKind, name string) error {
+	p := c.fsyncPolicy(kind)
+	if p != FsyncDir && p != FsyncAlways && p != FsyncBatch {
+		return nil
+	}
+
+	start := time.Now()
+	var err error
+	if p == FsyncBatch {
+		err = c.dirSyncer.sync(filepath.Dir(name))
+	} else {
+		err = syncDir(filepath.Dir(name))
+	}
+	c.histogramFsyncDuration.WithLabelValues(kind.String(), fsyncTargetDir).
+		Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		return fmt.Errorf("Failed to sync the directory of %q: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package disk
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// With WithDiskHeadroom, some of the space on the cache directory's
+// filesystem is kept free for other users, eg the system journal and
+// logs, independent of the maximum size of the cache. The filesystem is
+// checked with statfs every headroomCheckInterval, and if less than the
+// headroom is available, the least recently used entries are evicted to
+// free the difference. The files of evicted entries are removed in the
+// background, so no more entries are evicted while files are waiting to
+// be removed. If the headroom can't be freed by evicting entries, eg
+// because other files fill the filesystem, writes are refused until it
+// is available again.
+
+const headroomCheckInterval = 10 * time.Second
+
+var errNoHeadroom = &cache.Error{
+	Code:         http.StatusInsufficientStorage,
+	Text:         "Refusing writes while the cache directory's filesystem is low on free space (disk_headroom is set)",
+	QuotaSubject: "disk_headroom",
+}
+
+// The size and free space of a filesystem, in bytes.
+type filesystemStats struct {
+	size      int64
+	available int64 // To unprivileged users.
+}
+
+// The space to keep free on the cache directory's filesystem: a number
+// of bytes, or a percentage of the filesystem's size.
+type diskHeadroom struct {
+	bytes   int64
+	percent float64
+}
+
+// Returns the number of bytes to keep free on a filesystem of the given
+// size.
+func (h *diskHeadroom) required(fsSize int64) int64 {
+	if h.percent > 0 {
+		return int64(float64(fsSize) * h.percent / 100)
+	}
+	return h.bytes
+}
+
+// FilesystemSize returns the size in bytes of the filesystem which
+// contains dir.
+func FilesystemSize(dir string) (int64, error) {
+	st, err := statFilesystem(dir)
+	if err != nil {
+		return 0, err
+	}
+	return st.size, nil
+}
+
+// Evict entries until at least n bytes, rounded up to BlockSize like the
+// cache size, were freed, or no entries are left. Returns the number of
+// bytes freed.
+func (c *SizedLRU) evictBytes(n int64) int64 {
+	var freed int64
+	for freed < n {
+		ele := c.evictionVictim(nil)
+		if ele == nil {
+			break
+		}
+		freed += roundUp4k(ele.Value.(*entry).value.sizeOnDisk)
+		c.removeElement(ele)
+	}
+
+	c.gaugeCacheSizeBytes.Set(float64(c.currentSize))
+	c.gaugeCacheLogicalBytes.Set(float64(c.uncompressedSize))
+
+	return freed
+}
+
+func (c *diskCache) maintainHeadroom() {
+	ticker := time.NewTicker(headroomCheckInterval)
+	for range ticker.C {
+		err := c.checkHeadroom()
+		if err != nil {
+			log.Printf("Failed to check the free space for disk_headroom: %v", err)
+		}
+	}
+}
+
+// Evict entries if less than the headroom is available on the cache
+// directory's filesystem, and refuse writes if that doesn't free enough
+// space.
+func (c *diskCache) checkHeadroom() error {
+	st, err := c.statFilesystem(c.dir)
+	if err != nil {
+		return err
+	}
+	c.gaugeFilesystemAvailableBytes.Set(float64(st.available))
+
+	required := c.headroom.required(st.size)
+	deficit := required - st.available
+	if deficit <= 0 {
+		if c.headroomExhausted.Swap(false) {
+			log.Printf("%d bytes are available on the cache directory's filesystem, accepting writes again", st.available)
+		}
+		return nil
+	}
+
+	if c.pendingRemovals.Load() > 0 {
+		// The files of entries which were evicted already are still
+		// being removed.
+		return nil
+	}
+
+	c.mu.Lock()
+	freed := c.lru.evictBytes(deficit)
+	c.mu.Unlock()
+	c.counterHeadroomEvictedBytes.Add(float64(freed))
+
+	exhausted := freed < deficit
+	if exhausted && !c.headroomExhausted.Swap(true) {
+		log.Printf("Only %d bytes are available on the cache directory's filesystem, and %d are required by disk_headroom, refusing writes",
+			st.available, required)
+	}
+
+	return nil
+}
+
+// Check that the free space of the cache directory's filesystem can be
+// found, before serving requests.
+func (c *diskCache) initHeadroom() error {
+	err := c.checkHeadroom()
+	if err != nil {
+		return fmt.Errorf("Unable to check the free space for disk_headroom: %w", err)
+	}
+
+	go c.maintainHeadroom()
+	return nil
+}
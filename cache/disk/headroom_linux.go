@@ -0,0 +1,29 @@
+//go:build linux
+// +build linux
+
+package disk
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func statFilesystem(dir string) (filesystemStats, error) {
+	var st unix.Statfs_t
+	err := unix.Statfs(dir, &st)
+	if err != nil {
+		return filesystemStats{}, &os.PathError{Op: "statfs", Path: dir, Err: err}
+	}
+
+	// The block counts are in units of the fragment size.
+	blockSize := int64(st.Frsize)
+	if blockSize <= 0 {
+		blockSize = int64(st.Bsize)
+	}
+
+	return filesystemStats{
+		size:      int64(st.Blocks) * blockSize,
+		available: int64(st.Bavail) * blockSize,
+	}, nil
+}
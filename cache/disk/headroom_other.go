@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package disk
+
+import (
+	"errors"
+)
+
+func statFilesystem(dir string) (filesystemStats, error) {
+	return filesystemStats{}, errors.New("Checking the free space of filesystems is only supported on Linux")
+}
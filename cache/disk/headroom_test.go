@@ -0,0 +1,110 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestDiskHeadroom(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, 100*BlockSize, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := testCacheI.(*diskCache)
+
+	var available int64
+	c.headroom = &diskHeadroom{bytes: 10 * BlockSize}
+	c.statFilesystem = func(dir string) (filesystemStats, error) {
+		return filesystemStats{size: 1000 * BlockSize, available: available}, nil
+	}
+
+	put := func() error {
+		data, hash := testutils.RandomDataAndHash(BlockSize)
+		return c.Put(context.Background(), cache.RAW, hash, int64(len(data)), bytes.NewReader(data))
+	}
+	for i := 0; i < 3; i++ {
+		err = put()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func() {
+		err := c.checkHeadroom()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Wait for the files of the evicted entries to be removed.
+		for i := 0; c.pendingRemovals.Load() > 0; i++ {
+			if i == 1000 {
+				t.Fatal("Timed out waiting for evicted files to be removed")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	numItems := func() int {
+		_, _, n, _ := c.Stats()
+		return n
+	}
+
+	// Enough space is available.
+	available = 10 * BlockSize
+	check()
+	if n := numItems(); n != 3 {
+		t.Fatalf("Expected 3 entries, found %d", n)
+	}
+
+	// Entries are evicted to free the difference.
+	available = 8*BlockSize + 1
+	check()
+	if n := numItems(); n != 1 {
+		t.Fatalf("Expected 1 entry after evicting 2 for the headroom, found %d", n)
+	}
+	if c.headroomExhausted.Load() {
+		t.Error("Expected writes to be accepted after evicting enough entries")
+	}
+
+	// Writes are refused if evicting every entry isn't enough.
+	available = 0
+	check()
+	if n := numItems(); n != 0 {
+		t.Fatalf("Expected no entries, found %d", n)
+	}
+	err = put()
+	if cerr, ok := err.(*cache.Error); !ok || cerr.Code != 507 {
+		t.Fatalf("Expected writes to be refused with status 507, got %v", err)
+	}
+
+	// And accepted again once enough space is available.
+	available = 20 * BlockSize
+	check()
+	err = put()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := diskHeadroom{percent: 5}
+	if required := h.required(1000); required != 50 {
+		t.Errorf("Expected 5%% of 1000 bytes to be 50, got %d", required)
+	}
+
+	for _, invalid := range []struct {
+		bytes   int64
+		percent float64
+	}{{0, 0}, {-1, 0}, {0, 100}, {1, 5}} {
+		_, err := New(t.TempDir(), 100*BlockSize, WithDiskHeadroom(invalid.bytes, invalid.percent))
+		if err == nil {
+			t.Errorf("Expected an error for a headroom of %d bytes, %v%%", invalid.bytes, invalid.percent)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package disk
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+)
+
+// Entries can be imported from another cache directory or snapshot into
+// a running cache. Imported entries never replace or evict entries which
+// are already in the cache: duplicates are skipped, only as many entries
+// as fit in the free space are imported, preferring the most recently
+// used ones, and they are added behind the existing entries in the LRU,
+// in their original order. Compressed CAS blobs are decoded while they
+// are read, so nothing is written outside the cache directory.
+
+// ImportStats counts the entries passed to an Importer.
+type ImportStats struct {
+	Imported      int   `json:"imported"`
+	ImportedBytes int64 `json:"imported_bytes"`
+
+	// Entries which were already in the cache.
+	Duplicates int `json:"duplicates"`
+
+	// Entries which did not fit in the free space of the cache.
+	NoSpace int `json:"no_space"`
+
+	// Entries which could not be stored, eg because they were corrupt.
+	Failed int `json:"failed"`
+}
+
+// Importer merges entries from another cache into a running cache. It is
+// not safe for concurrent use.
+type Importer struct {
+	Stats ImportStats
+
+	c *diskCache
+
+	// The entries which fit in the free space of the cache.
+	selected map[Key]struct{}
+
+	// The most recently imported entry, if any.
+	previous *Key
+}
+
+// NewImporter returns an Importer for entries, which should be listed
+// from the least to the most recently used, like the entries of a
+// Snapshot. They must then be passed to Import in the same order.
+func (c *diskCache) NewImporter(entries []EntryInfo) *Importer {
+	i := &Importer{
+		c:        c,
+		selected: make(map[Key]struct{}),
+	}
+
+	available, checkFreeSpace := c.freeSpaceForWrites()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	free := c.importSpace(available, checkFreeSpace)
+	for n := len(entries) - 1; n >= 0; n-- {
+		key, ok := newKey(entries[n].Kind, entries[n].Hash)
+		if !ok {
+			continue
+		}
+		if _, found := c.lru.peek(key); found {
+			continue
+		}
+		if _, found := i.selected[key]; found {
+			continue
+		}
+
+		size := c.importSize(entries[n])
+		if size > free {
+			continue
+		}
+		free -= size
+		i.selected[key] = struct{}{}
+	}
+
+	return i
+}
+
+// Returns the number of bytes which imported entries can use: the space
+// in the cache which is neither used nor reserved by uploads in progress,
+// and with WithFreeSpaceCheck, at most the free space on the cache
+// directory's filesystem less the headroom and the reservations, like Put
+// checks. Must be called with mu held.
+func (c *diskCache) importSpace(available int64, checkFreeSpace bool) int64 {
+	if c.headroomExhausted.Load() {
+		return 0
+	}
+
+	reserved := c.lru.ReservedSize()
+	free := c.lru.MaxSize() - c.lru.TotalSize() - reserved
+	if checkFreeSpace && available-reserved < free {
+		free = available - reserved
+	}
+	return free
+}
+
+// The space needed to import e, which might be stored in a different
+// format in this cache.
+func (c *diskCache) importSize(e EntryInfo) int64 {
+	size := e.LogicalSize
+	if e.SizeOnDisk > size {
+		size = e.SizeOnDisk
+	}
+	size = roundUp4k(size)
+
+	if e.Kind == cache.CAS && c.storageMode != casblob.Identity {
+		// Leave room for the header of a compressed blob.
+		size += BlockSize
+	}
+
+	return size
+}
+
+// Import stores the entry e, reading the contents of its file in the
+// other cache from r, unless it is a duplicate or does not fit in the
+// cache.
+func (i *Importer) Import(ctx context.Context, e EntryInfo, r io.Reader) error {
+	c := i.c
+
+	key, ok := newKey(e.Kind, e.Hash)
+	if !ok {
+		i.Stats.Failed++
+		return fmt.Errorf("Invalid hash: %q", e.Hash)
+	}
+
+	available, checkFreeSpace := c.freeSpaceForWrites()
+
+	c.mu.Lock()
+	_, found := c.lru.peek(key)
+	free := c.importSpace(available, checkFreeSpace)
+	c.mu.Unlock()
+
+	if found {
+		i.Stats.Duplicates++
+		return nil
+	}
+	if _, selected := i.selected[key]; !selected || c.importSize(e) > free {
+		// Other entries might have been added since the importer
+		// was created.
+		i.Stats.NoSpace++
+		return nil
+	}
+	delete(i.selected, key)
+
+	err := c.importEntry(ctx, e, r)
+	if err != nil {
+		i.Stats.Failed++
+		return fmt.Errorf("Failed to import %s: %w", cache.LookupKey(e.Kind, e.Hash), err)
+	}
+
+	c.mu.Lock()
+	c.lru.demote(key, i.previous)
+	c.mu.Unlock()
+	i.previous = &key
+
+	i.Stats.Imported++
+	i.Stats.ImportedBytes += e.LogicalSize
+
+	return nil
+}
+
+func (c *diskCache) importEntry(ctx context.Context, e EntryInfo, r io.Reader) error {
+	if e.Kind != cache.CAS || e.Legacy {
+		// AC, RAW and uncompressed CAS files contain the data as-is.
+		return c.Put(ctx, e.Kind, e.Hash, e.LogicalSize, r)
+	}
+
+	// Compressed CAS blobs are decoded while they are read, and
+	// verified by Put.
+	rc, err := casblob.GetUncompressedStreamReadCloser(c.zstd, r, e.LogicalSize)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return c.Put(ctx, e.Kind, e.Hash, e.LogicalSize, rc)
+}
@@ -0,0 +1,143 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+
+	newCache := func(maxSize int64) *diskCache {
+		dir := tempDir(t)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+
+		c, err := New(dir, maxSize, WithAccessLogger(testutils.NewSilentLogger()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.(*diskCache)
+	}
+
+	put := func(c *diskCache, kind cache.EntryKind, data []byte) string {
+		hash := hashStr(string(data))
+		err := c.Put(ctx, kind, hash, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+
+	source := newCache(BlockSize * 10)
+	target := newCache(BlockSize * 5)
+
+	dup := put(target, cache.RAW, []byte("duplicate"))
+
+	// From the least to the most recently used. The compressed CAS
+	// blob needs two blocks, so there is no space left for d.
+	d := put(source, cache.RAW, []byte("d"))
+	b := put(source, cache.RAW, []byte("b"))
+	a := put(source, cache.CAS, []byte("a"))
+	put(source, cache.RAW, []byte("duplicate"))
+	c := put(source, cache.RAW, []byte("c"))
+
+	s := source.Snapshot()
+	defer s.Close()
+
+	importer := target.NewImporter(s.Entries)
+	for _, e := range s.Entries {
+		f, err := s.Open(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = importer.Import(ctx, e, f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectedStats := ImportStats{Imported: 3, ImportedBytes: 3, Duplicates: 1, NoSpace: 1}
+	if importer.Stats != expectedStats {
+		t.Errorf("Expected %+v, got %+v", expectedStats, importer.Stats)
+	}
+
+	// The imported entries are behind the existing entry, in their
+	// original order.
+	expectedKeys := []string{dup, c, a, b}
+	keys := target.lru.keys()
+	if len(keys) != len(expectedKeys) {
+		t.Fatalf("Expected %d entries, got %d", len(expectedKeys), len(keys))
+	}
+	for i, key := range keys {
+		if key.Hash() != expectedKeys[i] {
+			t.Errorf("Expected entry %d to be %s, got %s", i, expectedKeys[i], key.Hash())
+		}
+	}
+
+	if found, _ := target.Contains(ctx, cache.RAW, d, -1); found {
+		t.Error("Expected the least recently used entry not to be imported")
+	}
+
+	rc, _, err := target.Get(ctx, cache.CAS, a, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a" {
+		t.Errorf("Expected to read the imported CAS blob, got %q", data)
+	}
+}
+
+func TestImportSpace(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	ci, err := New(dir, 100*BlockSize, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := ci.(*diskCache)
+
+	c.freeSpace = &freeSpaceSample{}
+	c.statFilesystem = func(dir string) (filesystemStats, error) {
+		return filesystemStats{size: 1000 * BlockSize, available: 4 * BlockSize}, nil
+	}
+
+	var entries []EntryInfo
+	for i := 0; i < 5; i++ {
+		_, hash := testutils.RandomDataAndHash(1)
+		entries = append(entries, EntryInfo{Kind: cache.RAW, Hash: hash, LogicalSize: 1, SizeOnDisk: 1})
+	}
+
+	// Space reserved by uploads in progress is not available, and the
+	// entries need a block each.
+	c.mu.Lock()
+	ok, err := c.lru.Reserve(BlockSize)
+	c.mu.Unlock()
+	if err != nil || !ok {
+		t.Fatalf("Failed to reserve space: %v", err)
+	}
+
+	importer := c.NewImporter(entries)
+	if len(importer.selected) != 3 {
+		t.Errorf("Expected 3 entries to fit in the free space, got %d", len(importer.selected))
+	}
+
+	// Nothing is imported while writes are refused for the headroom.
+	c.headroomExhausted.Store(true)
+	importer = c.NewImporter(entries)
+	if len(importer.selected) != 0 {
+		t.Errorf("Expected no entries to be imported without headroom, got %d", len(importer.selected))
+	}
+}
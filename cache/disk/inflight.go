@@ -0,0 +1,133 @@
+package disk
+
+import (
+	"context"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// Concurrent uploads of the same key are serialized, so only one of them
+// writes to the cache directory at a time. Since a CAS blob's contents
+// are determined by its hash, uploads of a CAS blob which is already
+// being written wait for that write, and are skipped if it succeeds.
+// Uploads of AC and RAW entries, which might have different contents,
+// wait and then write their own contents, so the last upload wins.
+
+// A write of a key which is in progress.
+type inflightWrite struct {
+	done chan struct{} // Closed when the write finishes.
+	err  error         // The result of the write, set before done is closed.
+}
+
+// Wait until there is no other write of key in progress, and register
+// a new one. If skip is true, a concurrent write of the same CAS blob
+// succeeded and there is nothing to do. Otherwise finish must be called
+// with the result of the write.
+func (c *diskCache) beginWrite(ctx context.Context, key Key) (finish func(error), skip bool, err error) {
+	for {
+		c.mu.Lock()
+		w, found := c.inflight[key]
+		if !found {
+			w = &inflightWrite{done: make(chan struct{})}
+			c.inflight[key] = w
+			c.mu.Unlock()
+
+			finish = func(err error) {
+				c.mu.Lock()
+				delete(c.inflight, key)
+				c.mu.Unlock()
+
+				w.err = err
+				close(w.done)
+			}
+			return finish, false, nil
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+
+		if w.err == nil && key.Kind() == cache.CAS {
+			c.counterSkippedWrites.Inc()
+			return nil, true, nil
+		}
+
+		// Try again, another waiting write might have started first.
+	}
+}
+
+// Concurrent proxy fetches of the same key are deduplicated, so a cold
+// blob which many clients request at once is downloaded from the proxy
+// backend and written to disk once. The other requests wait for that
+// fetch, then read the committed file.
+
+// A proxy fetch of a key which is in progress.
+type inflightFetch struct {
+	done chan struct{} // Closed when the fetch finishes.
+
+	// The result of the fetch, set before done is closed: whether the
+	// item was added to the cache, or the proxy backend doesn't have it.
+	found   bool
+	missing bool
+}
+
+// Register a proxy fetch of key, unless one is already in progress. If
+// there is one, it is returned and finish is nil. Otherwise finish must
+// be called with the result of the fetch.
+func (c *diskCache) beginFetch(key Key) (finish func(found bool, missing bool), other *inflightFetch) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	other, found := c.fetches[key]
+	if found {
+		return nil, other
+	}
+
+	f := &inflightFetch{done: make(chan struct{})}
+	c.fetches[key] = f
+
+	finish = func(found bool, missing bool) {
+		c.mu.Lock()
+		delete(c.fetches, key)
+		c.mu.Unlock()
+
+		f.found = found
+		f.missing = missing
+		close(f.done)
+	}
+	return finish, nil
+}
+
+// Reads of items which are missing from the cache can wait for a
+// concurrent upload of the item to finish, with the upload_wait setting.
+// This helps when one client requests a large blob which another client
+// is still uploading. Data isn't served before the upload finishes,
+// since CAS blobs are only known to be valid once their whole contents
+// have been hashed.
+
+// Return the write of key which is in progress, if any.
+func (c *diskCache) pendingWrite(key Key) *inflightWrite {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.inflight[key]
+}
+
+// Wait up to c.uploadWait for the write w to finish.
+func (c *diskCache) waitForUpload(ctx context.Context, w *inflightWrite) {
+	timer := time.NewTimer(c.uploadWait)
+	defer timer.Stop()
+
+	select {
+	case <-w.done:
+		if w.err == nil {
+			c.counterUploadWaits.Inc()
+		}
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,384 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Start a Put of data whose contents are sent through the returned
+// writer, and wait until it is in progress.
+func startPut(t *testing.T, c *diskCache, kind cache.EntryKind, hash string, size int64) (*io.PipeWriter, chan error) {
+	pr, pw := io.Pipe()
+	result := make(chan error, 1)
+	go func() {
+		result <- c.Put(context.Background(), kind, hash, size, pr)
+	}()
+
+	key, _ := newKey(kind, hash)
+	for i := 0; ; i++ {
+		c.mu.Lock()
+		_, found := c.inflight[key]
+		c.mu.Unlock()
+		if found {
+			break
+		}
+		if i == 100 {
+			t.Fatal("Expected the write to start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return pw, result
+}
+
+// Start a Put which is expected to wait for another write of the same key.
+func startWaitingPut(t *testing.T, ctx context.Context, c *diskCache, kind cache.EntryKind, hash string, data []byte) chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- c.Put(ctx, kind, hash, int64(len(data)), bytes.NewReader(data))
+	}()
+
+	select {
+	case err := <-result:
+		t.Fatalf("Expected the concurrent write to wait, it returned %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	return result
+}
+
+func newInflightTestCache(t *testing.T) *diskCache {
+	cacheDir := tempDir(t)
+	t.Cleanup(func() { os.RemoveAll(cacheDir) })
+
+	c, err := New(cacheDir, BlockSize*10, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.(*diskCache)
+}
+
+func TestConcurrentCASWrites(t *testing.T) {
+	c := newInflightTestCache(t)
+
+	data, hash := testutils.RandomDataAndHash(100)
+
+	pw, first := startPut(t, c, cache.CAS, hash, int64(len(data)))
+	second := startWaitingPut(t, context.Background(), c, cache.CAS, hash, data)
+
+	_, err := pw.Write(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw.Close()
+
+	for _, result := range []chan error{first, second} {
+		if err := <-result; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The second upload was skipped.
+	if n := testutil.ToFloat64(c.counterSkippedWrites); n != 1 {
+		t.Errorf("Expected 1 skipped write, got %v", n)
+	}
+	if len(c.inflight) != 0 {
+		t.Errorf("Expected no writes in progress, found %d", len(c.inflight))
+	}
+}
+
+func TestConcurrentCASWriteAfterFailure(t *testing.T) {
+	c := newInflightTestCache(t)
+
+	data, hash := testutils.RandomDataAndHash(100)
+
+	pw, first := startPut(t, c, cache.CAS, hash, int64(len(data)))
+	second := startWaitingPut(t, context.Background(), c, cache.CAS, hash, data)
+
+	// The first upload is corrupt, so the second one is written.
+	_, err := pw.Write(bytes.Repeat([]byte{0}, len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw.Close()
+
+	if err := <-first; err == nil {
+		t.Error("Expected the corrupt upload to fail")
+	}
+	if err := <-second; err != nil {
+		t.Fatal(err)
+	}
+
+	if found, _ := c.Contains(context.Background(), cache.CAS, hash, int64(len(data))); !found {
+		t.Error("Expected the second upload to be written")
+	}
+	if n := testutil.ToFloat64(c.counterSkippedWrites); n != 0 {
+		t.Errorf("Expected no skipped writes, got %v", n)
+	}
+}
+
+func TestConcurrentACWrites(t *testing.T) {
+	c := newInflightTestCache(t)
+
+	hash := hashStr("action")
+	firstData := []byte("first")
+	secondData := []byte("second")
+
+	pw, first := startPut(t, c, cache.AC, hash, int64(len(firstData)))
+	second := startWaitingPut(t, context.Background(), c, cache.AC, hash, secondData)
+
+	// A write which gives up waiting fails.
+	ctx, cancel := context.WithCancel(context.Background())
+	third := startWaitingPut(t, ctx, c, cache.AC, hash, []byte("third"))
+	cancel()
+	if err := <-third; err == nil {
+		t.Error("Expected the cancelled write to fail")
+	}
+
+	_, err := pw.Write(firstData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw.Close()
+
+	for _, result := range []chan error{first, second} {
+		if err := <-result; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The AC entry is written by each upload in turn.
+	rc, _, err := c.Get(context.Background(), cache.AC, hash, -1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	found, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(found, secondData) {
+		t.Errorf("Expected the AC entry to contain %q, got %q", secondData, found)
+	}
+}
+
+// blockingProxy is a cache.Proxy which serves RAW entries from a map,
+// once release is closed.
+type blockingProxy struct {
+	blobs   map[string][]byte
+	release chan struct{}
+	gets    atomic.Int32
+}
+
+func (p *blockingProxy) Put(ctx context.Context, kind cache.EntryKind, hash string, logicalSize int64, sizeOnDisk int64, rc io.ReadCloser) {
+	rc.Close()
+}
+
+func (p *blockingProxy) Get(ctx context.Context, kind cache.EntryKind, hash string) (io.ReadCloser, int64, error) {
+	p.gets.Add(1)
+	<-p.release
+
+	data, found := p.blobs[hash]
+	if !found {
+		return nil, -1, nil
+	}
+	return io.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+}
+
+func (p *blockingProxy) Contains(ctx context.Context, kind cache.EntryKind, hash string) (bool, int64) {
+	data, found := p.blobs[hash]
+	return found, int64(len(data))
+}
+
+// Start n concurrent Gets of the same RAW entry, and wait until one of
+// them is fetching it from the proxy backend.
+func startProxyGets(t *testing.T, c *diskCache, p *blockingProxy, hash string, n int) chan []byte {
+	results := make(chan []byte, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			rc, _, err := c.Get(context.Background(), cache.RAW, hash, -1, 0)
+			if err != nil {
+				t.Error(err)
+				results <- nil
+				return
+			}
+			if rc == nil {
+				results <- nil
+				return
+			}
+			defer rc.Close()
+			data, err := io.ReadAll(rc)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- data
+		}()
+	}
+
+	for i := 0; p.gets.Load() == 0; i++ {
+		if i == 100 {
+			t.Fatal("Expected a proxy fetch to start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Give the other requests time to start waiting.
+	time.Sleep(50 * time.Millisecond)
+
+	return results
+}
+
+func newFetchTestCache(t *testing.T, p *blockingProxy) *diskCache {
+	cacheDir := tempDir(t)
+	t.Cleanup(func() { os.RemoveAll(cacheDir) })
+
+	c, err := New(cacheDir, BlockSize*10, WithProxyBackend(p), WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.(*diskCache)
+}
+
+func TestConcurrentProxyFetches(t *testing.T) {
+	data := []byte("popular blob")
+	hash := hashStr(string(data))
+	p := &blockingProxy{
+		blobs:   map[string][]byte{hash: data},
+		release: make(chan struct{}),
+	}
+	c := newFetchTestCache(t, p)
+
+	const n = 5
+	results := startProxyGets(t, c, p, hash, n)
+	close(p.release)
+
+	for i := 0; i < n; i++ {
+		found := <-results
+		if !bytes.Equal(found, data) {
+			t.Errorf("Expected %q, got %q", data, found)
+		}
+	}
+
+	if gets := p.gets.Load(); gets != 1 {
+		t.Errorf("Expected a single proxy fetch, got %d", gets)
+	}
+	if shared := testutil.ToFloat64(c.counterSharedFetches); shared != n-1 {
+		t.Errorf("Expected %d shared fetches, got %v", n-1, shared)
+	}
+	if c.lru.Len() != 1 {
+		t.Errorf("Expected one cache entry, found %d", c.lru.Len())
+	}
+	if len(c.fetches) != 0 {
+		t.Errorf("Expected no fetches in progress, found %d", len(c.fetches))
+	}
+}
+
+func TestConcurrentProxyFetchesMissing(t *testing.T) {
+	p := &blockingProxy{release: make(chan struct{})}
+	c := newFetchTestCache(t, p)
+
+	const n = 5
+	results := startProxyGets(t, c, p, hashStr("missing"), n)
+	close(p.release)
+
+	for i := 0; i < n; i++ {
+		if found := <-results; found != nil {
+			t.Errorf("Expected a cache miss, got %q", found)
+		}
+	}
+
+	// The other requests don't ask the proxy backend again.
+	if gets := p.gets.Load(); gets != 1 {
+		t.Errorf("Expected a single proxy fetch, got %d", gets)
+	}
+}
+
+func TestUploadWait(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	cI, err := New(cacheDir, BlockSize*10, WithUploadWait(time.Minute), WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cI.(*diskCache)
+
+	data, hash := testutils.RandomDataAndHash(100)
+	pw, upload := startPut(t, c, cache.CAS, hash, int64(len(data)))
+
+	// The read waits for the upload.
+	result := make(chan []byte, 1)
+	go func() {
+		rc, _, err := c.Get(context.Background(), cache.CAS, hash, int64(len(data)), 0)
+		if err != nil || rc == nil {
+			t.Errorf("Expected a cache hit, got %v", err)
+			result <- nil
+			return
+		}
+		defer rc.Close()
+		found, err := io.ReadAll(rc)
+		if err != nil {
+			t.Error(err)
+		}
+		result <- found
+	}()
+
+	select {
+	case <-result:
+		t.Fatal("Expected the read to wait for the upload")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, err = pw.Write(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw.Close()
+	if err := <-upload; err != nil {
+		t.Fatal(err)
+	}
+
+	if found := <-result; !bytes.Equal(found, data) {
+		t.Errorf("Expected %q, got %q", data, found)
+	}
+	if n := testutil.ToFloat64(c.counterUploadWaits); n != 1 {
+		t.Errorf("Expected 1 upload wait, got %v", n)
+	}
+}
+
+func TestUploadWaitTimeout(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	cI, err := New(cacheDir, BlockSize*10, WithUploadWait(50*time.Millisecond), WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cI.(*diskCache)
+
+	data, hash := testutils.RandomDataAndHash(100)
+	pw, upload := startPut(t, c, cache.CAS, hash, int64(len(data)))
+	defer func() {
+		pw.CloseWithError(io.ErrUnexpectedEOF)
+		<-upload
+	}()
+
+	// The upload doesn't finish in time, so the read misses.
+	rc, _, err := c.Get(context.Background(), cache.CAS, hash, int64(len(data)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc != nil {
+		rc.Close()
+		t.Error("Expected a cache miss")
+	}
+}
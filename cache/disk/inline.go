@@ -0,0 +1,155 @@
+package disk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+	"github.com/buchgr/bazel-remote/v2/utils/sharedfile"
+)
+
+// Small blobs, like empty files and tiny action outputs, cost an open,
+// a read and a close of their file on every access, which is most of the
+// cost of serving them. With WithInlineBlobSize, the contents of entries
+// which are no larger than inlineBlobSize are kept in memory alongside
+// the index after they are first read, and later reads are served from
+// memory. Their files are still written, since the index is rebuilt from
+// the cache directory at startup. The inlined content is limited to
+// inlineMaxBytes in total, and the content of the least recently read
+// entries is dropped from memory to stay within the limit.
+
+// The largest supported inline blob size.
+const maxInlineBlobSize = 64 * 1024
+
+// The default limit of the total size of inlined content.
+const defaultInlineMaxBytes = 64 * 1024 * 1024
+
+// The content of an inlined entry, with the random suffix of the file it
+// was read from.
+type inlineBlob struct {
+	key    Key
+	random randomSuffix
+	data   []byte
+}
+
+// Returns true if the content of entries of the given size is kept in
+// memory.
+func (c *diskCache) isInlineSize(size int64) bool {
+	return c.inlineBlobSize > 0 && size <= c.inlineBlobSize
+}
+
+// Returns a reader for the content of the entry for key, which is item,
+// from offset, served from memory. The content is read from blobPath if it
+// is not in memory yet. Returns false if the content could not be read,
+// in which case the caller should read the file as usual. Must be called
+// without holding mu.
+func (c *diskCache) inlineReader(key Key, kind cache.EntryKind, item lruItem, blobPath string, offset int64, zstd bool) (io.ReadCloser, bool) {
+	c.mu.Lock()
+	var b inlineBlob
+	ele, found := c.inline[key]
+	if found {
+		c.inlineLRU.MoveToFront(ele)
+		b = *ele.Value.(*inlineBlob)
+	}
+	c.mu.Unlock()
+
+	if found && b.random == item.random {
+		c.counterInlineHits.Inc()
+	} else {
+		data, err := c.readInlineBlob(key, kind, item, blobPath)
+		if err != nil {
+			return nil, false
+		}
+		b = inlineBlob{key: key, random: item.random, data: data}
+		c.storeInline(key, item, b)
+	}
+
+	if offset > int64(len(b.data)) {
+		return nil, false
+	}
+
+	if zstd {
+		return io.NopCloser(bytes.NewReader(c.zstd.EncodeAll(b.data[offset:]))), true
+	}
+	return io.NopCloser(bytes.NewReader(b.data[offset:])), true
+}
+
+// Read the uncompressed content of the entry for key, which is item, from
+// blobPath. The content of CAS blobs is verified, so that a corrupt file
+// is not served from memory for as long as the entry is in the index.
+func (c *diskCache) readInlineBlob(key Key, kind cache.EntryKind, item lruItem, blobPath string) ([]byte, error) {
+	f, err := sharedfile.Open(blobPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var rc io.ReadCloser = f
+	if kind == cache.CAS && !item.legacy {
+		rc, err = casblob.GetUncompressedReadCloser(c.zstd, f, item.size, 0)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, item.size+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != item.size {
+		return nil, fmt.Errorf("expected %q to have %d bytes, found %d",
+			blobPath, item.size, len(data))
+	}
+
+	if kind == cache.CAS {
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != key.Hash() {
+			return nil, fmt.Errorf("the content of %q does not match its hash", blobPath)
+		}
+	}
+
+	return data, nil
+}
+
+// Keep b in memory, if the entry for key still refers to item, and drop
+// the least recently read content if the limit is exceeded.
+func (c *diskCache) storeInline(key Key, item lruItem, b inlineBlob) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, exists := c.lru.peek(key)
+	if !exists || current.random != item.random || current.legacy != item.legacy {
+		return
+	}
+
+	c.dropInline(key)
+	if int64(len(b.data)) > c.inlineMaxBytes {
+		return
+	}
+
+	c.inline[key] = c.inlineLRU.PushFront(&b)
+	c.inlineBytes += int64(len(b.data))
+	c.gaugeInlineBytes.Add(float64(len(b.data)))
+
+	for c.inlineBytes > c.inlineMaxBytes {
+		c.dropInline(c.inlineLRU.Back().Value.(*inlineBlob).key)
+	}
+}
+
+// Forget the inlined content of the entry for key, if there is any. Must
+// be called with mu held.
+func (c *diskCache) dropInline(key Key) {
+	ele, found := c.inline[key]
+	if !found {
+		return
+	}
+	b := c.inlineLRU.Remove(ele).(*inlineBlob)
+	delete(c.inline, key)
+	c.inlineBytes -= int64(len(b.data))
+	c.gaugeInlineBytes.Sub(float64(len(b.data)))
+}
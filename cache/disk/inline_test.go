@@ -0,0 +1,201 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestInlineBlobs(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, BlockSize*100,
+		WithInlineBlobSize(256),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+
+	ctx := context.Background()
+	small, smallHash := testutils.RandomDataAndHash(100)
+	large, largeHash := testutils.RandomDataAndHash(1000)
+	err = testCache.Put(ctx, cache.CAS, smallHash, int64(len(small)), bytes.NewReader(small))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = testCache.Put(ctx, cache.CAS, largeHash, int64(len(large)), bytes.NewReader(large))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(hash string, size int64, offset int64) []byte {
+		rc, _, err := testCache.Get(ctx, cache.CAS, hash, size, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rc == nil {
+			t.Fatalf("Expected %s to be found", hash)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	// The first read of the small blob keeps it in memory.
+	if !bytes.Equal(read(smallHash, 100, 0), small) {
+		t.Fatal("Unexpected content of the small blob")
+	}
+	if !bytes.Equal(read(largeHash, 1000, 0), large) {
+		t.Fatal("Unexpected content of the large blob")
+	}
+	if v := testutil.ToFloat64(testCache.gaugeInlineBytes); v != 100 {
+		t.Fatalf("Expected 100 inline bytes, got %g", v)
+	}
+	if v := testutil.ToFloat64(testCache.counterInlineHits); v != 0 {
+		t.Fatalf("Expected no inline hits, got %g", v)
+	}
+
+	// Later reads are served from memory, even if the file is gone.
+	key, _ := newKey(cache.CAS, smallHash)
+	item, _ := testCache.lru.peek(key)
+	err = os.Remove(testCache.getElementPath(key, item))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read(smallHash, 100, 10), small[10:]) {
+		t.Fatal("Unexpected content of the small blob, read from an offset")
+	}
+
+	rc, _, err := testCache.GetZstd(ctx, smallHash, 100, 0)
+	if err != nil || rc == nil {
+		t.Fatal("Expected a zstd read of the small blob to succeed, got", err)
+	}
+	compressed, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := testCache.zstd.DecodeAll(compressed)
+	if err != nil || !bytes.Equal(decompressed, small) {
+		t.Fatal("Unexpected zstd content of the small blob, got", err)
+	}
+
+	if v := testutil.ToFloat64(testCache.counterInlineHits); v != 2 {
+		t.Fatalf("Expected 2 inline hits, got %g", v)
+	}
+
+	// Removing the entry drops it from memory.
+	testCache.mu.Lock()
+	testCache.lru.Remove(key)
+	testCache.mu.Unlock()
+	if v := testutil.ToFloat64(testCache.gaugeInlineBytes); v != 0 {
+		t.Fatalf("Expected no inline bytes, got %g", v)
+	}
+}
+
+func TestInlineBlobsReplaced(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, BlockSize*100,
+		WithInlineBlobSize(256),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+
+	ctx := context.Background()
+	_, hash := testutils.RandomDataAndHash(1)
+	for _, data := range [][]byte{[]byte("first"), []byte("second")} {
+		err = testCache.Put(ctx, cache.RAW, hash, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rc, _, err := testCache.Get(ctx, cache.RAW, hash, -1, 0)
+		if err != nil || rc == nil {
+			t.Fatal("Expected the entry to be found, got", err)
+		}
+		found, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(found, data) {
+			t.Fatalf("Expected %q, got %q", data, found)
+		}
+		if v := testutil.ToFloat64(testCache.gaugeInlineBytes); v != float64(len(data)) {
+			t.Fatalf("Expected %d inline bytes, got %g", len(data), v)
+		}
+	}
+}
+
+func TestInlineBlobsMaxBytes(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, BlockSize*100,
+		WithInlineBlobSize(256),
+		WithInlineMaxBytes(250),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+
+	ctx := context.Background()
+	var hashes []string
+	for i := 0; i < 3; i++ {
+		data, hash := testutils.RandomDataAndHash(100)
+		err = testCache.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	read := func(hash string) {
+		rc, _, err := testCache.Get(ctx, cache.CAS, hash, 100, 0)
+		if err != nil || rc == nil {
+			t.Fatal("Expected the blob to be found, got", err)
+		}
+		_, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only two of the blobs fit, so reading the third one drops the
+	// least recently read one, which is the first.
+	read(hashes[0])
+	read(hashes[1])
+	read(hashes[0])
+	read(hashes[2])
+
+	if v := testutil.ToFloat64(testCache.gaugeInlineBytes); v != 200 {
+		t.Fatalf("Expected 200 inline bytes, got %g", v)
+	}
+
+	testCache.mu.Lock()
+	for i, expected := range []bool{true, false, true} {
+		key, _ := newKey(cache.CAS, hashes[i])
+		if _, found := testCache.inline[key]; found != expected {
+			t.Errorf("Expected blob %d to be inlined: %v", i, expected)
+		}
+	}
+	testCache.mu.Unlock()
+}
@@ -0,0 +1,179 @@
+package disk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/tempfile"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+)
+
+// EntryInfo describes a single file in a cache directory, as found by
+// Walk or FindEntries. This is intended for offline inspection of a
+// cache directory, while bazel-remote is not running.
+type EntryInfo struct {
+	Kind cache.EntryKind
+	Hash string
+	Path string
+
+	// The uncompressed size of the blob, for CAS entries. For AC and
+	// RAW entries this is the same as SizeOnDisk.
+	LogicalSize int64
+	SizeOnDisk  int64
+
+	Random string
+
+	// True for uncompressed CAS blobs.
+	Legacy bool
+
+	// True if the file has not been committed to the cache yet,
+	// ie it is still being written or was abandoned mid-write.
+	Incomplete bool
+
+	Atime time.Time
+	Mtime time.Time
+}
+
+var entryKinds = []cache.EntryKind{cache.AC, cache.CAS, cache.RAW}
+
+// Walk calls fn for each entry in the cache directory dir, in no
+// particular order. Walking stops at the first error returned by fn.
+func Walk(dir string, fn func(EntryInfo) error) error {
+	_, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, kind := range entryKinds {
+		kindDir := filepath.Join(dir, kind.DirName())
+
+		des, err := os.ReadDir(kindDir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, de := range des {
+			if !de.IsDir() || de.Name() == lostAndFound {
+				continue
+			}
+			if !shardDirRegex.MatchString(de.Name()) {
+				return fmt.Errorf("Unexpected dir: %s", filepath.Join(kindDir, de.Name()))
+			}
+
+			shardDir := filepath.Join(kindDir, de.Name())
+			files, err := os.ReadDir(shardDir)
+			if err != nil {
+				return err
+			}
+
+			for _, f := range files {
+				if f.IsDir() {
+					if f.Name() == lostAndFound {
+						continue
+					}
+					return fmt.Errorf("Unexpected directory: %q", filepath.Join(shardDir, f.Name()))
+				}
+
+				info, err := f.Info()
+				if err != nil {
+					return fmt.Errorf("Failed to get file info for %q: %w",
+						filepath.Join(shardDir, f.Name()), err)
+				}
+
+				e, err := newEntryInfo(kind, filepath.Join(shardDir, f.Name()), info)
+				if err != nil {
+					return err
+				}
+
+				err = fn(e)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// FindEntries returns all the entries in the cache directory dir
+// for the given hash, of any kind.
+func FindEntries(dir string, hash string) ([]EntryInfo, error) {
+	if !validate.HashKeyRegex.MatchString(hash) {
+		return nil, fmt.Errorf("Invalid hash: %q", hash)
+	}
+
+	var entries []EntryInfo
+
+	for _, kind := range entryKinds {
+		pattern := filepath.Join(dir, kind.DirName(), hash[:2], hash+"-*")
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil {
+				return nil, err
+			}
+
+			e, err := newEntryInfo(kind, m, info)
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+func newEntryInfo(kind cache.EntryKind, filePath string, info os.FileInfo) (EntryInfo, error) {
+	// Files which are still being written have a tempfile suffix, or
+	// the setgid bit if they were written by older versions.
+	name := info.Name()
+	incomplete := info.Mode()&os.ModeSetgid != 0
+	if strings.HasSuffix(name, tempfile.Suffix) {
+		name = tempfile.FinalName(name)
+		incomplete = true
+	}
+
+	sm := cacheFileRegex.FindStringSubmatch(name)
+	if len(sm) != 5 {
+		return EntryInfo{}, fmt.Errorf("Unrecognized file: %q", filePath)
+	}
+
+	e := EntryInfo{
+		Kind:        kind,
+		Hash:        sm[1],
+		Path:        filePath,
+		SizeOnDisk:  info.Size(),
+		LogicalSize: info.Size(),
+		Random:      sm[3],
+		Legacy:      sm[4] == ".v1",
+		Incomplete:  incomplete,
+		Atime:       accessTime(info),
+		Mtime:       info.ModTime(),
+	}
+
+	if len(sm[2]) > 0 {
+		var err error
+		e.LogicalSize, err = strconv.ParseInt(sm[2], 10, 64)
+		if err != nil {
+			return EntryInfo{}, fmt.Errorf("Failed to parse int from %q in file %q: %w",
+				sm[2], filePath, err)
+		}
+	}
+
+	return e, nil
+}
@@ -0,0 +1,102 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestWalkAndFindEntries(t *testing.T) {
+	ctx := context.Background()
+
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := New(cacheDir, 10*1024*1024, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	casData, casHash := testutils.RandomDataAndHash(1024)
+	err = c.Put(ctx, cache.CAS, casHash, int64(len(casData)), bytes.NewReader(casData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawData, rawHash := testutils.RandomDataAndHash(100)
+	err = c.Put(ctx, cache.RAW, rawHash, int64(len(rawData)), bytes.NewReader(rawData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := make(map[cache.EntryKind]EntryInfo)
+	err = Walk(cacheDir, func(e EntryInfo) error {
+		if _, exists := found[e.Kind]; exists {
+			t.Errorf("Found more than one %s entry", e.Kind)
+		}
+		found[e.Kind] = e
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(found) != 2 {
+		t.Fatalf("Expected 2 entries, found %d", len(found))
+	}
+
+	cas := found[cache.CAS]
+	if cas.Hash != casHash || cas.LogicalSize != int64(len(casData)) {
+		t.Errorf("Unexpected CAS entry: %+v", cas)
+	}
+	if cas.Legacy || cas.Incomplete {
+		t.Errorf("Unexpected CAS entry: %+v", cas)
+	}
+
+	raw := found[cache.RAW]
+	if raw.Hash != rawHash || raw.LogicalSize != raw.SizeOnDisk {
+		t.Errorf("Unexpected RAW entry: %+v", raw)
+	}
+
+	entries, err := FindEntries(cacheDir, casHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0] != cas {
+		t.Errorf("Expected to find %+v, found %+v", cas, entries)
+	}
+
+	_, missingHash := testutils.RandomDataAndHash(1)
+	entries, err = FindEntries(cacheDir, missingHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no entries, found %+v", entries)
+	}
+
+	_, err = FindEntries(cacheDir, "invalid")
+	if err == nil {
+		t.Error("Expected an error for an invalid hash")
+	}
+
+	// Tempfiles are incomplete entries.
+	tmp := filepath.Join(cacheDir, "cas.v2", missingHash[:2], missingHash+"-1-42.tmp")
+	err = os.WriteFile(tmp, []byte("x"), 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err = FindEntries(cacheDir, missingHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].Incomplete || entries[0].Random != "42" || entries[0].Path != tmp {
+		t.Errorf("Expected an incomplete entry, found %+v", entries)
+	}
+}
@@ -0,0 +1,298 @@
+package disk
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+)
+
+// The maximum number of invocations to keep statistics for. When there
+// are more, the ones which were seen least recently are dropped, so that
+// clients which send many invocation IDs can't use unbounded memory.
+const maxTrackedInvocations = 10000
+
+// InvocationStats summarizes the cache requests made by a client tool
+// invocation, eg a Bazel build, identified by the tool_invocation_id in
+// the gRPC RequestMetadata of its requests.
+type InvocationStats struct {
+	InvocationID string `json:"invocation_id"`
+	FirstSeen    int64  `json:"first_seen"` // Unix time.
+	LastSeen     int64  `json:"last_seen"`  // Unix time.
+
+	ACHits    int64 `json:"ac_hits"`
+	ACMisses  int64 `json:"ac_misses"`
+	CASHits   int64 `json:"cas_hits"`
+	CASMisses int64 `json:"cas_misses"`
+
+	// The number of bytes read by cache hits, and written by uploads.
+	BytesRead    int64 `json:"bytes_read"`
+	BytesWritten int64 `json:"bytes_written"`
+}
+
+// TranscriptEntry records a cache request made by a client tool
+// invocation.
+type TranscriptEntry struct {
+	Time   int64  `json:"time"` // Unix time.
+	Op     string `json:"op"`   // "read", "write" or "find_missing".
+	Kind   string `json:"kind"` // "ac", "cas" or "raw".
+	Hash   string `json:"hash"`
+	Size   int64  `json:"size"`
+	Result string `json:"result,omitempty"` // "hit" or "miss", for reads and find_missing.
+}
+
+// InvocationTranscript lists the cache requests made by a client tool
+// invocation, in the order they were made.
+type InvocationTranscript struct {
+	InvocationID string            `json:"invocation_id"`
+	Entries      []TranscriptEntry `json:"entries"`
+
+	// True if entries were dropped, because the transcripts of all the
+	// invocations reached the limit.
+	Truncated bool `json:"truncated"`
+}
+
+type trackedInvocation struct {
+	stats      InvocationStats
+	transcript []TranscriptEntry
+	truncated  bool
+}
+
+// Collects InvocationStats, and optionally transcripts, for the
+// invocations seen within a retention window. It is safe to call the
+// methods of a nil *invocationTracker, which doesn't collect anything.
+type invocationTracker struct {
+	retention time.Duration
+	now       func() time.Time
+
+	// The maximum number of transcript entries of all the invocations,
+	// or zero if transcripts are not collected.
+	transcriptLimit int
+
+	mu                sync.Mutex
+	ll                *list.List // *trackedInvocation, from most to least recently seen.
+	invocations       map[string]*list.Element
+	transcriptEntries int
+}
+
+func newInvocationTracker(retention time.Duration) *invocationTracker {
+	return &invocationTracker{
+		retention:   retention,
+		now:         time.Now,
+		ll:          list.New(),
+		invocations: make(map[string]*list.Element),
+	}
+}
+
+// Update the stats and transcript of the invocation which made the
+// request with ctx, if any, with f.
+func (t *invocationTracker) record(ctx context.Context, f func(inv *trackedInvocation, now int64)) {
+	if t == nil {
+		return
+	}
+
+	id := cache.InvocationID(ctx)
+	if id == "" {
+		return
+	}
+
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ele, found := t.invocations[id]
+	if found {
+		t.ll.MoveToFront(ele)
+	} else {
+		ele = t.ll.PushFront(&trackedInvocation{
+			stats: InvocationStats{InvocationID: id, FirstSeen: now.Unix()},
+		})
+		t.invocations[id] = ele
+	}
+
+	inv := ele.Value.(*trackedInvocation)
+	inv.stats.LastSeen = now.Unix()
+	f(inv, now.Unix())
+
+	t.prune(now)
+}
+
+// Add an entry to the transcript of inv, if transcripts are collected.
+// When the transcripts of all the invocations reach the limit, the
+// transcripts of the least recently seen invocations are dropped, or the
+// entry if inv is the only invocation with a transcript. Must be called
+// with t.mu held.
+func (t *invocationTracker) transcribe(inv *trackedInvocation, e TranscriptEntry) {
+	if t.transcriptLimit == 0 || inv.truncated {
+		return
+	}
+
+	for ele := t.ll.Back(); t.transcriptEntries >= t.transcriptLimit; ele = ele.Prev() {
+		if ele == nil {
+			inv.truncated = true
+			return
+		}
+		victim := ele.Value.(*trackedInvocation)
+		if victim == inv || len(victim.transcript) == 0 {
+			continue
+		}
+		t.transcriptEntries -= len(victim.transcript)
+		victim.transcript = nil
+		victim.truncated = true
+	}
+
+	inv.transcript = append(inv.transcript, e)
+	t.transcriptEntries++
+}
+
+// Drop the invocations which were last seen before the retention
+// window, or which don't fit in maxTrackedInvocations. Must be called
+// with t.mu held.
+func (t *invocationTracker) prune(now time.Time) {
+	cutoff := now.Add(-t.retention).Unix()
+	for ele := t.ll.Back(); ele != nil; ele = t.ll.Back() {
+		inv := ele.Value.(*trackedInvocation)
+		if inv.stats.LastSeen >= cutoff && t.ll.Len() <= maxTrackedInvocations {
+			break
+		}
+
+		t.ll.Remove(ele)
+		delete(t.invocations, inv.stats.InvocationID)
+		t.transcriptEntries -= len(inv.transcript)
+	}
+}
+
+// Returns "hit" or "miss".
+func lookupResult(hit bool) string {
+	if hit {
+		return "hit"
+	}
+	return "miss"
+}
+
+func (t *invocationTracker) recordLookup(ctx context.Context, kind cache.EntryKind, hash string, hit bool, bytesRead int64) {
+	t.record(ctx, func(inv *trackedInvocation, now int64) {
+		s := &inv.stats
+		switch {
+		case kind == cache.AC && hit:
+			s.ACHits++
+		case kind == cache.AC:
+			s.ACMisses++
+		case hit:
+			s.CASHits++
+		default:
+			s.CASMisses++
+		}
+		s.BytesRead += bytesRead
+
+		t.transcribe(inv, TranscriptEntry{Time: now, Op: "read", Kind: kind.String(),
+			Hash: hash, Size: bytesRead, Result: lookupResult(hit)})
+	})
+}
+
+// Record a FindMissingBlobs request for the given digests, of which the
+// missing ones are in missing.
+func (t *invocationTracker) recordFindMissing(ctx context.Context, digests []*pb.Digest, missing []*pb.Digest) {
+	t.record(ctx, func(inv *trackedInvocation, now int64) {
+		inv.stats.CASHits += int64(len(digests) - len(missing))
+		inv.stats.CASMisses += int64(len(missing))
+
+		if t.transcriptLimit == 0 {
+			return
+		}
+		isMissing := make(map[string]bool, len(missing))
+		for _, d := range missing {
+			isMissing[d.Hash] = true
+		}
+		for _, d := range digests {
+			t.transcribe(inv, TranscriptEntry{Time: now, Op: "find_missing", Kind: cache.CAS.String(),
+				Hash: d.Hash, Size: d.SizeBytes, Result: lookupResult(!isMissing[d.Hash])})
+		}
+	})
+}
+
+func (t *invocationTracker) recordPut(ctx context.Context, kind cache.EntryKind, hash string, size int64) {
+	t.record(ctx, func(inv *trackedInvocation, now int64) {
+		inv.stats.BytesWritten += size
+
+		t.transcribe(inv, TranscriptEntry{Time: now, Op: "write", Kind: kind.String(),
+			Hash: hash, Size: size})
+	})
+}
+
+// Returns the stats of the invocations seen within the retention window,
+// from most to least recently seen.
+func (t *invocationTracker) stats() []InvocationStats {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(t.now())
+
+	stats := make([]InvocationStats, 0, t.ll.Len())
+	for ele := t.ll.Front(); ele != nil; ele = ele.Next() {
+		stats = append(stats, ele.Value.(*trackedInvocation).stats)
+	}
+
+	return stats
+}
+
+// Returns the transcript of the invocation with the given ID, or false if
+// it was not seen within the retention window.
+func (t *invocationTracker) transcript(id string) (InvocationTranscript, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(t.now())
+
+	ele, found := t.invocations[id]
+	if !found {
+		return InvocationTranscript{}, false
+	}
+
+	inv := ele.Value.(*trackedInvocation)
+	return InvocationTranscript{
+		InvocationID: id,
+		Entries:      append([]TranscriptEntry{}, inv.transcript...),
+		Truncated:    inv.truncated,
+	}, true
+}
+
+// InvocationStats returns the stats of the client tool invocations seen
+// within the retention window, from most to least recently seen, or nil
+// if invocation stats are not collected. See WithInvocationStats.
+func (c *diskCache) InvocationStats() []InvocationStats {
+	return c.invocations.stats()
+}
+
+// InvocationTranscript returns the transcript of the client tool
+// invocation with the given ID. Returns a *cache.Error with code 404 if
+// invocation transcripts are not collected, see WithInvocationTranscripts,
+// or the invocation was not seen within the retention window.
+func (c *diskCache) InvocationTranscript(id string) (*InvocationTranscript, error) {
+	if c.invocations == nil || c.invocations.transcriptLimit == 0 {
+		return nil, &cache.Error{
+			Code: http.StatusNotFound,
+			Text: "Invocation transcripts are not collected",
+		}
+	}
+
+	tr, found := c.invocations.transcript(id)
+	if !found {
+		return nil, &cache.Error{
+			Code: http.StatusNotFound,
+			Text: fmt.Sprintf("Invocation %q not found", id),
+		}
+	}
+	return &tr, nil
+}
@@ -0,0 +1,135 @@
+package disk
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+)
+
+func TestInvocationTracker(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tracker := newInvocationTracker(time.Hour)
+	tracker.now = func() time.Time { return now }
+
+	ctx1 := cache.WithInvocationID(context.Background(), "build-1")
+	ctx2 := cache.WithInvocationID(context.Background(), "build-2")
+
+	digests := []*pb.Digest{{Hash: "a"}, {Hash: "b"}, {Hash: "c"}, {Hash: "d"}, {Hash: "e"}}
+
+	tracker.recordLookup(ctx1, cache.AC, "x", true, 10)
+	tracker.recordLookup(ctx1, cache.AC, "y", false, 0)
+	tracker.recordFindMissing(ctx1, digests, digests[3:])
+	tracker.recordPut(ctx1, cache.CAS, "z", 100)
+
+	// Requests without an invocation ID are not tracked.
+	tracker.recordPut(context.Background(), cache.CAS, "z", 100)
+
+	now = now.Add(30 * time.Minute)
+	tracker.recordLookup(ctx2, cache.CAS, "z", true, 20)
+
+	stats := tracker.stats()
+	expected := []InvocationStats{
+		{InvocationID: "build-2", FirstSeen: 2800, LastSeen: 2800, CASHits: 1, BytesRead: 20},
+		{InvocationID: "build-1", FirstSeen: 1000, LastSeen: 1000, ACHits: 1, ACMisses: 1,
+			CASHits: 3, CASMisses: 2, BytesRead: 10, BytesWritten: 100},
+	}
+	if fmt.Sprint(stats) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, stats)
+	}
+
+	// build-1 was last seen more than an hour ago.
+	now = now.Add(45 * time.Minute)
+	stats = tracker.stats()
+	if len(stats) != 1 || stats[0].InvocationID != "build-2" {
+		t.Fatalf("Expected only build-2 within the retention window, got %+v", stats)
+	}
+
+	for i := 0; i < maxTrackedInvocations; i++ {
+		tracker.recordPut(cache.WithInvocationID(context.Background(), fmt.Sprint(i)), cache.CAS, "z", 1)
+	}
+	stats = tracker.stats()
+	if len(stats) != maxTrackedInvocations {
+		t.Fatalf("Expected %d invocations, got %d", maxTrackedInvocations, len(stats))
+	}
+	if stats[len(stats)-1].InvocationID != "0" {
+		t.Errorf("Expected build-2 to be dropped first, got %+v", stats[len(stats)-1])
+	}
+
+	var nilTracker *invocationTracker
+	nilTracker.recordPut(ctx1, cache.CAS, "z", 1)
+	if nilTracker.stats() != nil {
+		t.Error("Expected no stats from a nil tracker")
+	}
+}
+
+func TestInvocationTranscripts(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tracker := newInvocationTracker(time.Hour)
+	tracker.now = func() time.Time { return now }
+	tracker.transcriptLimit = 4
+
+	ctx1 := cache.WithInvocationID(context.Background(), "build-1")
+	ctx2 := cache.WithInvocationID(context.Background(), "build-2")
+
+	tracker.recordPut(ctx1, cache.AC, "a", 10)
+	tracker.recordLookup(ctx1, cache.CAS, "b", false, 0)
+	tracker.recordFindMissing(ctx1, []*pb.Digest{{Hash: "c", SizeBytes: 3}}, nil)
+
+	tr, found := tracker.transcript("build-1")
+	if !found {
+		t.Fatal("Expected a transcript for build-1")
+	}
+	expected := InvocationTranscript{
+		InvocationID: "build-1",
+		Entries: []TranscriptEntry{
+			{Time: 1000, Op: "write", Kind: "ac", Hash: "a", Size: 10},
+			{Time: 1000, Op: "read", Kind: "cas", Hash: "b", Result: "miss"},
+			{Time: 1000, Op: "find_missing", Kind: "cas", Hash: "c", Size: 3, Result: "hit"},
+		},
+	}
+	if fmt.Sprint(tr) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, tr)
+	}
+
+	_, found = tracker.transcript("build-2")
+	if found {
+		t.Fatal("Expected no transcript for build-2")
+	}
+
+	// When the limit is reached, the transcript of the least recently
+	// seen invocation is dropped.
+	now = now.Add(time.Minute)
+	tracker.recordLookup(ctx2, cache.CAS, "d", true, 5)
+	tracker.recordLookup(ctx2, cache.CAS, "e", true, 5)
+
+	tr, _ = tracker.transcript("build-1")
+	if !tr.Truncated || len(tr.Entries) != 0 {
+		t.Errorf("Expected the transcript of build-1 to be dropped, got %+v", tr)
+	}
+	if tracker.transcriptEntries != 2 {
+		t.Errorf("Expected 2 transcript entries, got %d", tracker.transcriptEntries)
+	}
+
+	// Once it has no room left, the entries of the only invocation with
+	// a transcript are dropped instead.
+	for i := 0; i < 3; i++ {
+		tracker.recordPut(ctx2, cache.CAS, fmt.Sprint(i), 1)
+	}
+	tr, _ = tracker.transcript("build-2")
+	if !tr.Truncated || len(tr.Entries) != 4 || tr.Entries[3].Hash != "1" {
+		t.Errorf("Expected the first 4 entries of build-2, got %+v", tr)
+	}
+
+	// Pruned invocations release their transcript entries.
+	now = now.Add(2 * time.Hour)
+	_, found = tracker.transcript("build-2")
+	if found || tracker.transcriptEntries != 0 {
+		t.Errorf("Expected build-2 to be pruned, with no transcript entries left, got %d",
+			tracker.transcriptEntries)
+	}
+}
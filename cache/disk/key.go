@@ -0,0 +1,182 @@
+package disk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"sync"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// The in-memory index can hold hundreds of millions of entries, so the
+// types in this file store each entry's identity compactly: the keys
+// hold binary digests rather than hex strings, and the strings which
+// are repeated or mostly numeric are stored as small integers.
+
+// Key identifies an entry in the index, by its kind and the SHA256
+// digest of its hash.
+type Key struct {
+	kind   uint8 // A cache.EntryKind.
+	digest [sha256.Size]byte
+}
+
+// Returns the key for the entry of the given kind and lowercase hex
+// SHA256 hash, or false if the hash is not valid. Uppercase hashes are
+// rejected, since they would refer to different files.
+func newKey(kind cache.EntryKind, hash string) (Key, bool) {
+	k := Key{kind: uint8(kind)}
+	if len(hash) != sha256HashStrSize {
+		return k, false
+	}
+
+	for i := 0; i < len(hash); i++ {
+		c := hash[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return k, false
+		}
+	}
+
+	_, err := hex.Decode(k.digest[:], []byte(hash))
+	return k, err == nil
+}
+
+// Kind returns the kind of the entry.
+func (k Key) Kind() cache.EntryKind {
+	return cache.EntryKind(k.kind)
+}
+
+// Hash returns the lowercase hex hash of the entry.
+func (k Key) Hash() string {
+	return hex.EncodeToString(k.digest[:])
+}
+
+// String returns the key in the form "<kind>/<hash>", as returned by
+// cache.LookupKey.
+func (k Key) String() string {
+	return cache.LookupKey(k.Kind(), k.Hash())
+}
+
+// The random suffix of a file in the cache directory. Suffixes of up to
+// maxNumericSuffixLen digits, as created by utils/tempfile, are stored
+// as their number and length, so that leading zeros are kept. Other
+// suffixes, eg from files created by other tools, are interned.
+type randomSuffix uint64
+
+const (
+	maxNumericSuffixLen = 16 // 10^16 < 2^56.
+	suffixLenShift      = 56
+	suffixInterned      = randomSuffix(1) << 63
+)
+
+var internedSuffixes stringTable
+
+func newRandomSuffix(s string) randomSuffix {
+	if len(s) > 0 && len(s) <= maxNumericSuffixLen {
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err == nil {
+			return randomSuffix(len(s))<<suffixLenShift | randomSuffix(n)
+		}
+	}
+
+	i, _ := internedSuffixes.intern(s) // The table has no limit.
+	return suffixInterned | randomSuffix(i)
+}
+
+func (r randomSuffix) String() string {
+	if r&suffixInterned != 0 {
+		return internedSuffixes.lookup(uint32(r &^ suffixInterned))
+	}
+
+	n := int(r >> suffixLenShift)
+	s := strconv.FormatUint(uint64(r&(1<<suffixLenShift-1)), 10)
+	for len(s) < n {
+		s = "0" + s
+	}
+	return s
+}
+
+// The instance name which an entry is attributed to, see quota.go.
+// There are usually few distinct instance names, so they are interned.
+// Clients choose the instance names though, so once maxInstanceNames
+// names have been interned, entries with further names are attributed
+// to otherInstances, rather than growing the table without bound.
+type instanceID uint32
+
+const (
+	// The instanceID of entries which are not attributed to any
+	// instance, eg those found in the cache directory at startup. This
+	// is distinct from the empty instance name.
+	noInstance instanceID = 0
+
+	// The instanceID of entries whose instance names didn't fit in the
+	// table, reported as otherInstancesName.
+	otherInstances instanceID = 1
+
+	firstInternedInstance instanceID = 2
+)
+
+const (
+	maxInstanceNames   = 10000
+	otherInstancesName = "<other>"
+)
+
+var internedInstances = stringTable{max: maxInstanceNames}
+
+func newInstanceID(name string) instanceID {
+	i, ok := internedInstances.intern(name)
+	if !ok {
+		return otherInstances
+	}
+	return instanceID(i) + firstInternedInstance
+}
+
+func (id instanceID) name() string {
+	switch id {
+	case noInstance:
+		return ""
+	case otherInstances:
+		return otherInstancesName
+	}
+	return internedInstances.lookup(uint32(id - firstInternedInstance))
+}
+
+// A table of interned strings, indexed by the order in which they were
+// added. Strings are never removed.
+type stringTable struct {
+	mu      sync.Mutex
+	indices map[string]uint32
+	strings []string
+
+	max int // The maximum number of strings, or 0 for no limit.
+}
+
+// Returns the index of s, or false if s is not in the table and the
+// table is full.
+func (t *stringTable) intern(s string) (uint32, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if i, ok := t.indices[s]; ok {
+		return i, true
+	}
+
+	if t.max > 0 && len(t.strings) >= t.max {
+		return 0, false
+	}
+
+	if t.indices == nil {
+		t.indices = make(map[string]uint32)
+	}
+	i := uint32(len(t.strings))
+	t.indices[s] = i
+	t.strings = append(t.strings, s)
+	return i, true
+}
+
+func (t *stringTable) lookup(i uint32) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.strings[i]
+}
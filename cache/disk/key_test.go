@@ -0,0 +1,117 @@
+package disk
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+func TestKey(t *testing.T) {
+	key, ok := newKey(cache.CAS, emptySha256)
+	if !ok {
+		t.Fatal("Expected a valid key for", emptySha256)
+	}
+	if key.Kind() != cache.CAS || key.Hash() != emptySha256 {
+		t.Fatalf("Expected kind %s and hash %s, got %s and %s",
+			cache.CAS, emptySha256, key.Kind(), key.Hash())
+	}
+	if key.String() != cache.LookupKey(cache.CAS, emptySha256) {
+		t.Fatal("Unexpected key string:", key.String())
+	}
+
+	if acKey, _ := newKey(cache.AC, emptySha256); acKey == key {
+		t.Fatal("Expected keys of different kinds to differ")
+	}
+
+	invalid := []string{
+		"",
+		emptySha256[1:],
+		emptySha256 + "0",
+		"E" + emptySha256[1:],
+		"g" + emptySha256[1:],
+	}
+	for _, hash := range invalid {
+		if _, ok := newKey(cache.CAS, hash); ok {
+			t.Errorf("Expected %q to be rejected", hash)
+		}
+	}
+}
+
+func TestRandomSuffix(t *testing.T) {
+	suffixes := []string{
+		"0",
+		"000000001",
+		"222444666",
+		"999999999",
+		"1234567890123456",
+		"12345678901234567",
+		"abcXYZ123",
+	}
+	for _, s := range suffixes {
+		r := newRandomSuffix(s)
+		if r.String() != s {
+			t.Errorf("Expected suffix %q, got %q", s, r.String())
+		}
+		if newRandomSuffix(s) != r {
+			t.Errorf("Expected equal suffixes for %q", s)
+		}
+	}
+
+	if newRandomSuffix("01") == newRandomSuffix("1") {
+		t.Error("Expected leading zeros to be significant")
+	}
+}
+
+func TestInstanceID(t *testing.T) {
+	empty := newInstanceID("")
+	if empty == noInstance {
+		t.Fatal("Expected the empty instance name to be distinct from noInstance")
+	}
+	if empty.name() != "" {
+		t.Fatalf("Expected the empty instance name, got %q", empty.name())
+	}
+
+	foo := newInstanceID("foo")
+	if foo.name() != "foo" || newInstanceID("foo") != foo {
+		t.Fatalf("Expected instance foo, got %q", foo.name())
+	}
+
+	if otherInstances.name() != otherInstancesName {
+		t.Fatalf("Expected instance %s, got %q", otherInstancesName, otherInstances.name())
+	}
+}
+
+func TestStringTableLimit(t *testing.T) {
+	table := stringTable{max: 2}
+
+	for _, s := range []string{"a", "b", "a"} {
+		if _, ok := table.intern(s); !ok {
+			t.Fatalf("Expected %q to be interned", s)
+		}
+	}
+
+	if _, ok := table.intern("c"); ok {
+		t.Fatal("Expected the full table to refuse a new string")
+	}
+	if i, ok := table.intern("b"); !ok || table.lookup(i) != "b" {
+		t.Fatal("Expected the full table to return existing strings")
+	}
+}
+
+func TestIndexEntrySizes(t *testing.T) {
+	// These are stored for each entry in the index, so changes to
+	// their sizes can affect memory usage significantly.
+	if size := unsafe.Sizeof(Key{}); size != 33 {
+		t.Errorf("Expected Key to be 33 bytes, found %d", size)
+	}
+	if size := unsafe.Sizeof(lruItem{}); size != 32 {
+		t.Errorf("Expected lruItem to be 32 bytes, found %d", size)
+	}
+
+	// entry.lastAccess should fit in the padding after the key.
+	var e entry
+	if offset := unsafe.Offsetof(e.value); offset != 40 {
+		t.Errorf("Expected entry.value at offset 40, found %d", offset)
+	}
+}
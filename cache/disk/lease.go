@@ -0,0 +1,72 @@
+package disk
+
+import (
+	"container/list"
+	"time"
+)
+
+// Clients which build without the bytes, eg Bazel with
+// --remote_download_minimal, check that blobs exist with
+// FindMissingBlobs and then refer to them for the rest of the build,
+// without downloading them. If such a blob is evicted in the meantime,
+// the build fails.
+//
+// If a lease duration is set, each CAS blob which FindMissingBlobs
+// reports as present (including the outputs of action results which are
+// checked when they are served) is leased for that duration, and the
+// lease is extended by each later hit. Leased entries are skipped when
+// the least recently used entries are evicted to make room, and are
+// treated as recently used instead. Only if every entry in the cache is
+// leased is a leased entry evicted, which is counted by the
+// bazel_remote_disk_cache_leased_evictions_total metric.
+//
+// Instance quotas take precedence over leases: an instance which exceeds
+// its quota has its least recently used entries evicted even if they are
+// leased.
+
+// Set the duration of the leases given by lease, or 0 to disable
+// leases. This must be called before any entries are added.
+func (c *SizedLRU) setLeaseDuration(d time.Duration) {
+	c.leaseDuration = d
+}
+
+// Lease the entry for key, if it exists, for the lease duration from now.
+func (c *SizedLRU) lease(key Key) {
+	if c.leaseDuration <= 0 {
+		return
+	}
+
+	if ele, hit := c.cache[key]; hit {
+		ele.Value.(*entry).leaseExpiry = c.now().Add(c.leaseDuration).UnixNano()
+	}
+}
+
+// Returns true if e is leased at now, in Unix nanoseconds.
+func (e *entry) leased(now int64) bool {
+	return e.leaseExpiry > now
+}
+
+// Returns the least recently used element which is not leased, avoiding
+// keep, and moves the elements which are skipped to the front of the
+// list. If every other element is leased, the least recently used one is
+// returned.
+func (c *SizedLRU) unleasedBack(keep *list.Element) *list.Element {
+	if c.leaseDuration <= 0 {
+		return c.ll.Back()
+	}
+
+	now := c.now().UnixNano()
+	for i, n := 0, c.ll.Len(); i < n; i++ {
+		ele := c.ll.Back()
+		if ele != keep && !ele.Value.(*entry).leased(now) {
+			return ele
+		}
+		c.ll.MoveToFront(ele)
+	}
+
+	ele := c.ll.Back()
+	if ele != nil && ele.Value.(*entry).leased(now) {
+		c.counterLeasedEvictions.Inc()
+	}
+	return ele
+}
@@ -0,0 +1,130 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLeasedEviction(t *testing.T) {
+	now := time.Now()
+
+	lru := NewSizedLRU(3*BlockSize, nil, 0)
+	lru.setLeaseDuration(time.Hour)
+	lru.now = func() time.Time { return now }
+
+	item := lruItem{size: BlockSize, sizeOnDisk: BlockSize}
+	lru.Add(testKey("a"), item)
+	lru.Add(testKey("b"), item)
+	lru.Add(testKey("c"), item)
+
+	// a is the least recently used entry, but it is leased.
+	lru.lease(testKey("a"))
+	lru.Add(testKey("d"), item)
+
+	if _, found := lru.peek(testKey("a")); !found {
+		t.Error("Expected leased entry a to remain in the cache")
+	}
+	if _, found := lru.peek(testKey("b")); found {
+		t.Error("Expected b to be evicted")
+	}
+
+	// a was moved to the front when it was skipped, so it is evicted
+	// after c and d, even though its lease has expired.
+	now = now.Add(2 * time.Hour)
+	lru.Add(testKey("e"), item)
+	lru.Add(testKey("f"), item)
+	if _, found := lru.peek(testKey("a")); !found {
+		t.Error("Expected a to remain in the cache")
+	}
+	lru.Add(testKey("g"), item)
+	if _, found := lru.peek(testKey("a")); found {
+		t.Error("Expected a to be evicted after its lease expired")
+	}
+
+	if n := testutil.ToFloat64(lru.counterLeasedEvictions); n != 0 {
+		t.Errorf("Expected no leased evictions, found %v", n)
+	}
+
+	// If every entry is leased, the least recently used one is evicted
+	// anyway.
+	for _, k := range []string{"e", "f", "g"} {
+		lru.lease(testKey(k))
+	}
+	lru.Add(testKey("h"), item)
+	if _, found := lru.peek(testKey("e")); found {
+		t.Error("Expected e to be evicted when every entry was leased")
+	}
+	checkSizeAndNumItems(t, lru, 3*BlockSize, 3)
+
+	if n := testutil.ToFloat64(lru.counterLeasedEvictions); n != 1 {
+		t.Errorf("Expected one leased eviction, found %v", n)
+	}
+}
+
+func TestLeasesDisabled(t *testing.T) {
+	lru := NewSizedLRU(2*BlockSize, nil, 0)
+
+	item := lruItem{size: BlockSize, sizeOnDisk: BlockSize}
+	lru.Add(testKey("a"), item)
+	lru.Add(testKey("b"), item)
+
+	lru.lease(testKey("a"))
+	lru.Add(testKey("c"), item)
+
+	if _, found := lru.peek(testKey("a")); found {
+		t.Error("Expected a to be evicted when leases are disabled")
+	}
+}
+
+func TestFindMissingLeases(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, 10*BlockSize,
+		WithCASLeaseDuration(time.Hour),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+
+	data, hash := testutils.RandomDataAndHash(32)
+	err = testCache.Put(context.Background(), cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaseExpiry := func() int64 {
+		key, _ := newKey(cache.CAS, hash)
+		testCache.mu.Lock()
+		defer testCache.mu.Unlock()
+		return testCache.lru.cache[key].Value.(*entry).leaseExpiry
+	}
+
+	if leaseExpiry() != 0 {
+		t.Fatal("Expected the blob not to be leased after it was written")
+	}
+
+	missing, err := testCache.FindMissingCasBlobs(context.Background(),
+		[]*pb.Digest{{Hash: hash, SizeBytes: int64(len(data))}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 0 {
+		t.Fatalf("Expected no missing blobs, found %v", missing)
+	}
+
+	if leaseExpiry() < time.Now().Add(59*time.Minute).UnixNano() {
+		t.Error("Expected the blob to be leased for an hour")
+	}
+}
@@ -1,6 +1,7 @@
 package disk
 
 import (
+	"container/list"
 	"fmt"
 	"log"
 	"math"
@@ -13,15 +14,15 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/buchgr/bazel-remote/v2/cache"
 	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
 	"github.com/buchgr/bazel-remote/v2/cache/disk/zstdimpl"
+	"github.com/buchgr/bazel-remote/v2/utils/tempfile"
 	"github.com/buchgr/bazel-remote/v2/utils/validate"
 
-	"github.com/djherbis/atime"
-
 	"github.com/prometheus/client_golang/prometheus"
 
 	"golang.org/x/sync/errgroup"
@@ -44,20 +45,6 @@ func New(dir string, maxSizeBytes int64, opts ...Option) (Cache, error) {
 		return nil, err
 	}
 
-	// Go defaults to a limit of 10,000 operating system threads.
-	// We probably don't need half of those for file removals at
-	// any given point in time, unless the disk/fs can't keep up.
-	// I suppose it's better to slow down processing than to crash
-	// when hitting the 10k limit or to run out of disk space.
-	semaphoreWeight := int64(5000)
-
-	if strings.HasPrefix(runtime.GOOS, "darwin") {
-		// Mac seems to fail to create os threads when removing
-		// lots of files, so allow fewer than linux.
-		semaphoreWeight = 3000
-	}
-	log.Printf("Limiting concurrent file removals to %d\n", semaphoreWeight)
-
 	zi, err := zstdimpl.Get("go")
 	if err != nil {
 		return nil, err
@@ -72,12 +59,157 @@ func New(dir string, maxSizeBytes int64, opts ...Option) (Cache, error) {
 		maxBlobSize:      math.MaxInt64,
 		maxProxyBlobSize: math.MaxInt64,
 
-		fileRemovalSem: semaphore.NewWeighted(semaphoreWeight),
+		inflight:       make(map[Key]*inflightWrite),
+		fetches:        make(map[Key]*inflightFetch),
+		zombieRescans:  make(map[string]bool),
+		inline:         make(map[Key]*list.Element),
+		inlineLRU:      list.New(),
+		inlineMaxBytes: defaultInlineMaxBytes,
+
+		writeProbeInterval: defaultWriteProbeInterval,
+		proxyProbeInterval: defaultProxyProbeInterval,
 
 		gaugeCacheAge: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "bazel_remote_disk_cache_longest_item_idle_time_seconds",
-			Help: "The idle time (now - atime) of the last item in the LRU cache, updated once per minute. Depending on filesystem mount options (e.g. relatime), the resolution may be measured in 'days' and not accurate to the second. If using noatime this will be 0.",
+			Help: "The idle time (now - atime) of the last item in the LRU cache, updated once per minute. Depending on filesystem mount options (e.g. relatime), the resolution may be measured in 'days' and not accurate to the second. If access times are not updated, eg with noatime, the idle time tracked in memory is reported instead.",
+		}),
+		ageCollector: newAgeCollector(),
+		churn:        newChurnStats(),
+		gaugeReadOnly: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_read_only",
+			Help: "1 if the disk cache is in read-only mode, either because of the read_only flag or after persistent write errors, otherwise 0",
+		}),
+		gaugeStandby: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_standby",
+			Help: "1 if the cache is a standby which only accepts writes replicated from its primary, otherwise 0",
+		}),
+		gaugeLoading: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_loading",
+			Help: "1 while the existing files are loaded and reads are served from the proxy backend, with the proxy_reads_while_loading setting, otherwise 0",
+		}),
+		counterWriteErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_write_errors_total",
+			Help: "The total number of failed writes to the cache directory",
+		}),
+		counterScrubbedBlobs: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_scrubbed_blobs_total",
+			Help: "The total number of CAS blobs verified during maintenance windows",
+		}),
+		counterCorruptBlobs: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_corrupt_blobs_total",
+			Help: "The total number of corrupt CAS blobs found and removed during maintenance windows",
+		}),
+		counterVerifiedReads: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_verified_reads_total",
+			Help: "The total number of reads of legacy uncompressed CAS blobs whose content was verified, with the verify_legacy_reads setting",
+		}),
+		counterCorruptReads: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_corrupt_reads_total",
+			Help: "The total number of reads of legacy uncompressed CAS blobs which found that the blob was corrupt, and removed it",
+		}),
+		counterInlineHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_inline_hits_total",
+			Help: "The total number of reads of small blobs which were served from memory, with the inline_blob_size setting",
+		}),
+		gaugeInlineBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_inline_bytes",
+			Help: "The total size of the small blobs which are kept in memory, with the inline_blob_size setting",
 		}),
+		counterSkippedWrites: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_skipped_concurrent_writes_total",
+			Help: "The total number of CAS uploads which were skipped because a concurrent upload of the same blob succeeded",
+		}),
+		counterSharedFetches: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_shared_proxy_fetches_total",
+			Help: "The total number of cache misses which used the result of a concurrent proxy backend fetch of the same item, instead of fetching it again",
+		}),
+		counterUploadWaits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_upload_waits_total",
+			Help: "The total number of reads of missing items which waited for an upload of the item in progress, and found it once the upload succeeded",
+		}),
+		gaugeProxyHealthy: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_proxy_backend_healthy",
+			Help: "1 if the last checks of all the proxy backends succeeded and none of them are disabled, otherwise 0",
+		}),
+		gaugeProxyBackendEnabled: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_proxy_backend_enabled",
+			Help: "1 if the proxy backend is enabled, or 0 if it was disabled through the admin API, by backend",
+		}, []string{"backend"}),
+		counterRefusedWrites: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_refused_writes_total",
+			Help: "The total number of writes which were refused because the proxy backend was unavailable, with the proxy_required setting",
+		}),
+		counterThrottledWrites: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_throttled_writes_total",
+			Help: "The total number of writes which were rejected because too much data was being uploaded, with the max_inflight_upload_size setting",
+		}),
+		counterZombieEntries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_zombie_entries_total",
+			Help: "The total number of cache entries which were removed from the index because their files were deleted by something other than bazel-remote",
+		}),
+		gaugeReconcileLocalOnly: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_reconcile_local_only_items",
+			Help: "The number of cache entries which were missing from the proxy backend at the last reconciliation",
+		}),
+		gaugeReconcileRemoteOnly: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_reconcile_remote_only_items",
+			Help: "The number of proxy backend items which were missing from the cache at the last reconciliation",
+		}),
+		counterReconcileUploads: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_reconcile_uploads_total",
+			Help: "The total number of cache entries queued for upload to the proxy backend by reconciliations",
+		}),
+		counterReconcileDownloads: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_reconcile_downloads_total",
+			Help: "The total number of proxy backend items added to the cache by reconciliations",
+		}),
+		counterPrewarmDownloads: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_prewarm_downloads_total",
+			Help: "The total number of proxy backend items added to the cache by prewarms",
+		}),
+		counterProxyVerifiedBlobs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_proxy_verified_blobs_total",
+			Help: "The total number of CAS blobs fetched from each proxy backend whose content was verified, with the verify_proxy_reads setting",
+		}, []string{"backend"}),
+		counterProxyCorruptBlobs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_proxy_corrupt_blobs_total",
+			Help: "The total number of CAS blobs fetched from each proxy backend which did not match their digest and were discarded, with the verify_proxy_reads setting",
+		}, []string{"backend"}),
+		histogramFsyncDuration: newFsyncDurationHistogram(),
+		dirSyncer:              newDirSyncBatcher(),
+		counterMmapFallbacks: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_mmap_fallbacks_total",
+			Help: "The total number of blob reads which could not use a memory mapping with the mmap_reads setting, and read the file instead",
+		}),
+		gaugeFileRemovalLimit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_file_removals_limit",
+			Help: "The maximum number of files which can be removed concurrently, after their entries were evicted",
+		}),
+		gaugeFileRemovalsQueued: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_file_removals_queued",
+			Help: "The number of files of evicted entries which are waiting to be removed",
+		}),
+		gaugeFileRemovalsInProgress: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_file_removals_in_progress",
+			Help: "The number of files of evicted entries which are being removed",
+		}),
+		counterFileRemovalErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_file_removal_errors_total",
+			Help: "The total number of files of evicted entries which could not be removed",
+		}),
+		gaugeFilesystemAvailableBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_filesystem_available_bytes",
+			Help: "The free space on the cache directory's filesystem, updated every 10 seconds with the disk_headroom setting, and with the check_free_space setting when it is checked",
+		}),
+		counterHeadroomEvictedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_headroom_evicted_bytes_total",
+			Help: "The total number of bytes evicted from the disk cache to keep free space on its filesystem, with the disk_headroom setting",
+		}),
+		counterFreeSpaceRefusedWrites: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_free_space_refused_writes_total",
+			Help: "The total number of uploads which were refused because they didn't fit in the free space of the cache directory's filesystem, with the check_free_space setting",
+		}),
+		statFilesystem: statFilesystem,
 	}
 
 	cc := CacheConfig{diskCache: &c}
@@ -90,6 +222,19 @@ func New(dir string, maxSizeBytes int64, opts ...Option) (Cache, error) {
 		}
 	}
 
+	if c.fileRemovalLimit <= 0 {
+		c.fileRemovalLimit = defaultFileRemovalLimit()
+	}
+	c.fileRemovalSem = semaphore.NewWeighted(int64(c.fileRemovalLimit))
+	c.gaugeFileRemovalLimit.Set(float64(c.fileRemovalLimit))
+	log.Printf("Limiting concurrent file removals to %d\n", c.fileRemovalLimit)
+	if c.fileRemovalRate > 0 {
+		c.removalPacer = newRemovalPacer(c.fileRemovalRate)
+	}
+	if c.batchFileRemovals {
+		c.removals = newFileRemovalQueue(&c)
+	}
+
 	// Create the directory structure.
 	hexLetters := []byte("0123456789abcdef")
 	for _, c1 := range hexLetters {
@@ -114,9 +259,43 @@ func New(dir string, maxSizeBytes int64, opts ...Option) (Cache, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Attempting to migrate the old directory structure failed: %w", err)
 	}
-	err = c.loadExistingFiles(maxSizeBytes)
-	if err != nil {
-		return nil, fmt.Errorf("Loading of existing cache entries failed due to error: %w", err)
+	if c.readOnly {
+		c.gaugeReadOnly.Set(1)
+	}
+	if c.standby.Load() {
+		c.gaugeStandby.Set(1)
+	}
+
+	c.detectAtime()
+
+	if c.proxyRequired && c.proxy == nil {
+		return nil, fmt.Errorf("A proxy backend is required, but none is configured")
+	}
+
+	if c.reconcileInterval > 0 {
+		if _, ok := c.proxy.(cache.Lister); !ok {
+			return nil, errNoLister
+		}
+	}
+
+	if c.proxyReadsWhileLoading {
+		if c.proxy == nil {
+			return nil, errLoadingNoProxy
+		}
+
+		// Serve reads from the proxy backend until the existing files
+		// have been loaded, see loading.go.
+		c.lru = c.newLRU(maxSizeBytes, 0)
+		c.loading.Store(true)
+		c.gaugeLoading.Set(1)
+		c.startProxy()
+		go c.loadInBackground(maxSizeBytes)
+	} else {
+		err = c.load(maxSizeBytes)
+		if err != nil {
+			return nil, err
+		}
+		c.startProxy()
 	}
 
 	if cc.metrics == nil {
@@ -124,10 +303,101 @@ func New(dir string, maxSizeBytes int64, opts ...Option) (Cache, error) {
 	}
 
 	cc.metrics.diskCache = &c
+	cc.metrics.createCounters(cc.metricsInstances)
 
 	return cc.metrics, nil
 }
 
+// Load the existing files in the cache directory, and start the
+// background work which needs the index.
+func (c *diskCache) load(maxSizeBytes int64) error {
+	err := c.loadExistingFiles(maxSizeBytes)
+	if err != nil {
+		return fmt.Errorf("Loading of existing cache entries failed due to error: %w", err)
+	}
+
+	// The cleanup of partial uploads here and of tempfiles in
+	// loadExistingFiles assumes that no other process writes to the cache
+	// directory, so on a handover the new process only loads it once the
+	// previous one has exited, see handoff.WaitForPrevious.
+	if c.uploads != nil {
+		err = c.uploads.recover()
+		if err != nil {
+			return fmt.Errorf("Failed to recover resumable uploads: %w", err)
+		}
+	} else {
+		// Remove any partial uploads from when resumable uploads were
+		// enabled.
+		err = os.RemoveAll(filepath.Join(c.dir, uploadsDirName))
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.accessJournal != nil && !c.readOnly {
+		err = c.accessJournal.open()
+		if err != nil {
+			return fmt.Errorf("Failed to open the access journal: %w", err)
+		}
+		c.mu.Lock()
+		c.lru.onAccess = c.accessJournal.record
+		c.mu.Unlock()
+		go c.writeAccessJournal()
+	}
+
+	if c.provenance != nil {
+		err = c.provenance.open(c.readOnly)
+		if err != nil {
+			return fmt.Errorf("Failed to open the provenance journal: %w", err)
+		}
+	}
+
+	if c.headroom != nil && !c.readOnly {
+		err = c.initHeadroom()
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.freeSpace != nil && !c.readOnly {
+		err = c.initFreeSpaceCheck()
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.cluster != nil {
+		c.cluster.OnMembershipChange(c.rebalance)
+	}
+
+	if c.maintenance != nil {
+		go c.scrub()
+	}
+
+	if c.reconcileInterval > 0 {
+		go c.reconcilePeriodically()
+	}
+
+	return nil
+}
+
+// Start monitoring the proxy backend, if there is one.
+func (c *diskCache) startProxy() {
+	if c.proxy == nil {
+		return
+	}
+
+	c.initProxyBackends()
+	c.proxyHealthy.Store(true)
+	c.gaugeProxyHealthy.Set(1)
+
+	if c.proxyRequired {
+		// Log if the proxy backend is unavailable at startup.
+		c.checkProxyHealth()
+	}
+	go c.monitorProxyHealth()
+}
+
 func (c *diskCache) migrateDirectories() error {
 	err := migrateDirectory(c.dir, cache.AC)
 	if err != nil {
@@ -145,7 +415,7 @@ func (c *diskCache) migrateDirectories() error {
 }
 
 func migrateDirectory(baseDir string, kind cache.EntryKind) error {
-	sourceDir := path.Join(baseDir, kind.String())
+	sourceDir := filepath.Join(baseDir, kind.String())
 
 	_, err := os.Stat(sourceDir)
 	if os.IsNotExist(err) {
@@ -166,7 +436,7 @@ func migrateDirectory(baseDir string, kind cache.EntryKind) error {
 	// hex character pairs.
 	v1DirRegex := regexp.MustCompile("^[a-f0-9]{2}$")
 
-	targetDir := path.Join(baseDir, kind.DirName())
+	targetDir := filepath.Join(baseDir, kind.DirName())
 
 	itemChan := make(chan os.DirEntry)
 	errChan := make(chan error)
@@ -263,7 +533,7 @@ func migrateV1Subdir(oldDir string, destDir string, kind cache.EntryKind) error
 
 			name := item.Name()
 
-			oldPath := path.Join(oldDir, name)
+			oldPath := filepath.Join(oldDir, name)
 
 			if !validate.HashKeyRegex.MatchString(name) {
 				if strings.ToLower(name) == lowercaseDSStoreFile {
@@ -274,7 +544,7 @@ func migrateV1Subdir(oldDir string, destDir string, kind cache.EntryKind) error
 				return fmt.Errorf("Unexpected file: %s", oldPath)
 			}
 
-			destPath := path.Join(destDir, name) + "-556677.v1"
+			destPath := filepath.Join(destDir, name) + "-556677.v1"
 			err = os.Rename(oldPath, destPath)
 			if err != nil {
 				return fmt.Errorf("Failed to migrate CAS blob %s: %w",
@@ -288,7 +558,7 @@ func migrateV1Subdir(oldDir string, destDir string, kind cache.EntryKind) error
 	for _, item := range listing {
 		name := item.Name()
 
-		oldPath := path.Join(oldDir, name)
+		oldPath := filepath.Join(oldDir, name)
 
 		if !validate.HashKeyRegex.MatchString(name) {
 			if strings.ToLower(name) == lowercaseDSStoreFile {
@@ -299,7 +569,7 @@ func migrateV1Subdir(oldDir string, destDir string, kind cache.EntryKind) error
 			return fmt.Errorf("Unexpected file: %s %s", oldPath, name)
 		}
 
-		destPath := path.Join(destDir, name) + "-112233"
+		destPath := filepath.Join(destDir, name) + "-112233"
 
 		// TODO: support cross-filesystem migration.
 		err = os.Rename(oldPath, destPath)
@@ -313,7 +583,7 @@ func migrateV1Subdir(oldDir string, destDir string, kind cache.EntryKind) error
 
 // Metadata for an lruItem.
 type keyAndAtime struct {
-	lookupKey string
+	lookupKey Key
 
 	// atime of the file.
 	ts time.Time
@@ -344,13 +614,88 @@ func (r scanResult) Swap(i, j int) {
 	r.metadata[i], r.metadata[j] = r.metadata[j], r.metadata[i]
 }
 
+// compressed CAS items: <hash>-<logical size>-<random digits/ascii letters>
+// uncompressed CAS items: <hash>-<logical size>-<random digits/ascii letters>.v1
+// AC and RAW items: <hash>-<random digits/ascii letters>
+var cacheFileRegex = regexp.MustCompile(`^([a-f0-9]{64})(?:-([1-9][0-9]*))?-([0-9a-zA-Z]+)(\.v1)?$`)
+
+// The two hex character subdirectories of ac.v2, cas.v2 and raw.v2.
+var shardDirRegex = regexp.MustCompile(`^[a-f0-9]{2}$`)
+
+// Ignore lost+found dirs, which are automatically created in the
+// root dir of some unix style filesystems.
+const lostAndFound = "lost+found"
+
+// An entry in a shard directory, as returned by listDir. The size,
+// access time and modification time are only set for files.
+type dirEntry struct {
+	name  string
+	isDir bool
+	size  int64
+	atime time.Time
+	mtime time.Time
+}
+
+// Returns the default maximum number of concurrent file removals.
+func defaultFileRemovalLimit() int {
+	// Go defaults to a limit of 10,000 operating system threads.
+	// We probably don't need half of those for file removals at
+	// any given point in time, unless the disk/fs can't keep up.
+	// I suppose it's better to slow down processing than to crash
+	// when hitting the 10k limit or to run out of disk space.
+	if strings.HasPrefix(runtime.GOOS, "darwin") {
+		// Mac seems to fail to create os threads when removing
+		// lots of files, so allow fewer than linux.
+		return 3000
+	}
+	return 5000
+}
+
+// Parse the name of a file in the shard directory dirName of the given
+// kind, and return its key and the index item for it. sizeOnDisk is the
+// size of the file.
+func parseCacheFile(kind cache.EntryKind, dirName string, file string, sizeOnDisk int64) (Key, lruItem, error) {
+	sm := cacheFileRegex.FindStringSubmatch(file)
+	if len(sm) != 5 {
+		return Key{}, lruItem{}, fmt.Errorf("Unrecognized file: %q", filepath.Join(dirName, file))
+	}
+
+	// The regex only matches valid hashes.
+	key, _ := newKey(kind, sm[1])
+
+	item := lruItem{
+		size:       sizeOnDisk,
+		sizeOnDisk: sizeOnDisk,
+	}
+	if len(sm[2]) > 0 {
+		var err error
+		item.size, err = strconv.ParseInt(sm[2], 10, 64)
+		if err != nil {
+			return Key{}, lruItem{}, fmt.Errorf("Failed to parse int from %q in file %q: %w",
+				sm[2], filepath.Join(dirName, file), err)
+		}
+	}
+
+	if len(sm[3]) == 0 {
+		return Key{}, lruItem{}, fmt.Errorf("Unrecognized file (no random string): %q", filepath.Join(dirName, file))
+	}
+	item.random = newRandomSuffix(sm[3])
+
+	item.legacy = sm[4] == ".v1"
+
+	return key, item, nil
+}
+
 func (c *diskCache) scanDir() (scanResult, error) {
 
-	numWorkers := runtime.NumCPU()
-	if numWorkers < 4 {
-		numWorkers = 4
-	} else if numWorkers > 16 {
-		numWorkers = 16 // Consider increasing the upper limit after more testing.
+	numWorkers := c.scanWorkers
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+		if numWorkers < 4 {
+			numWorkers = 4
+		} else if numWorkers > 16 {
+			numWorkers = 16 // Use WithScanWorkers for more.
+		}
 	}
 	log.Println("Scanning cache directory with", numWorkers, "goroutines")
 
@@ -370,6 +715,8 @@ func (c *diskCache) scanDir() (scanResult, error) {
 		}
 	}()
 
+	var numTempfiles atomic.Int64 // The number of tempfiles removed.
+
 	finalScanResult := scanResult{
 		item:     []*lruItem{},
 		metadata: []*keyAndAtime{},
@@ -387,32 +734,23 @@ func (c *diskCache) scanDir() (scanResult, error) {
 
 	dirListers := new(errgroup.Group)
 
-	// compressed CAS items: <hash>-<logical size>-<random digits/ascii letters>
-	// uncompressed CAS items: <hash>-<logical size>-<random digits/ascii letters>.v1
-	// AC and RAW items: <hash>-<random digits/ascii letters>
-	re := regexp.MustCompile(`^([a-f0-9]{64})(?:-([1-9][0-9]*))?-([0-9a-zA-Z]+)(\.v1)?$`)
-
-	// Ignore lost+found dirs, which are automatically created in the
-	// root dir of some unix style filesystems.
-	const lostAndFound = "lost+found"
-
 	for i := 0; i < numWorkers; i++ {
 		dirListers.Go(func() error {
 			for d := range dc {
-				dirName := path.Join(c.dir, d)
+				dirName := filepath.Join(c.dir, d)
 
-				var lookupKeyPrefix string
+				var kind cache.EntryKind
 				if strings.HasPrefix(d, "cas.v2/") {
-					lookupKeyPrefix = "cas/"
+					kind = cache.CAS
 				} else if strings.HasPrefix(d, "ac.v2/") {
-					lookupKeyPrefix = "ac/"
+					kind = cache.AC
 				} else if strings.HasPrefix(d, "raw.v2/") {
-					lookupKeyPrefix = "raw/"
+					kind = cache.RAW
 				} else {
 					return fmt.Errorf("Unrecognised directory in cache dir: %q", dirName)
 				}
 
-				des, err := os.ReadDir(dirName)
+				des, err := listDir(dirName)
 				if err != nil {
 					return err
 				}
@@ -424,54 +762,40 @@ func (c *diskCache) scanDir() (scanResult, error) {
 
 				n := 0 // The number of items to return for this dir.
 				for _, de := range des {
-					name := de.Name()
+					name := de.name
 
-					if de.IsDir() {
-						if de.Name() == lostAndFound {
+					if de.isDir {
+						if name == lostAndFound {
 							continue
 						}
 
-						return fmt.Errorf("Unexpected directory: %q", path.Join(dirName, name))
-					}
-
-					info, err := de.Info()
-					if err != nil {
-						return fmt.Errorf("Failed to get file info for %q: %w", path.Join(dirName, name), err)
+						return fmt.Errorf("Unexpected directory: %q", filepath.Join(dirName, name))
 					}
 
 					fields := strings.Split(name, "/")
 					file := fields[len(fields)-1]
 
-					sm := re.FindStringSubmatch(file)
-					if len(sm) != 5 {
-						return fmt.Errorf("Unrecognized file: %q", path.Join(dirName, name))
+					if strings.HasSuffix(file, tempfile.Suffix) {
+						// An incomplete write, which was interrupted
+						// by a crash or restart. No other process
+						// writes to the cache directory, see load.
+						err = os.Remove(filepath.Join(dirName, name))
+						if err != nil && !os.IsNotExist(err) {
+							return err
+						}
+						numTempfiles.Add(1)
+						continue
 					}
 
-					hash := sm[1]
-
 					item[n] = &item_values[n]
 					metadata[n] = &metadata_values[n]
 
-					metadata[n].lookupKey = lookupKeyPrefix + hash
-
-					item[n].sizeOnDisk = info.Size()
-					item[n].size = item[n].sizeOnDisk
-					if len(sm[2]) > 0 {
-						item[n].size, err = strconv.ParseInt(sm[2], 10, 64)
-						if err != nil {
-							return fmt.Errorf("Failed to parse int from %q in file %q: %w",
-								sm[2], path.Join(dirName, name), err)
-						}
-					}
-
-					item[n].random = sm[3]
-					if len(item[n].random) == 0 {
-						return fmt.Errorf("Unrecognized file (no random string): %q", path.Join(dirName, name))
+					metadata[n].lookupKey, item_values[n], err = parseCacheFile(kind, dirName, file, de.size)
+					if err != nil {
+						return err
 					}
 
-					item[n].legacy = sm[4] == ".v1"
-
-					metadata[n].ts = atime.Get(info)
+					metadata[n].ts = c.lastAccessTime(de)
 
 					n++
 				}
@@ -491,8 +815,6 @@ func (c *diskCache) scanDir() (scanResult, error) {
 		return scanResult{}, fmt.Errorf("Failed to read cache dir %q: %w", c.dir, err)
 	}
 
-	dre := regexp.MustCompile(`^[a-f0-9]{2}$`)
-
 	for _, de := range des {
 		name := de.Name()
 
@@ -508,11 +830,26 @@ func (c *diskCache) scanDir() (scanResult, error) {
 			continue
 		}
 
+		if name == uploadsDirName {
+			// See resume.go.
+			continue
+		}
+
+		if name == accessJournalDirName {
+			// See accessjournal.go.
+			continue
+		}
+
+		if name == provenanceDirName {
+			// See provenance.go.
+			continue
+		}
+
 		if name != "ac.v2" && name != "cas.v2" && name != "raw.v2" {
 			return scanResult{}, fmt.Errorf("Unexpected dir: %s", name)
 		}
 
-		dir := path.Join(c.dir, name)
+		dir := filepath.Join(c.dir, name)
 		des2, err := os.ReadDir(dir)
 		if err != nil {
 			return scanResult{}, err
@@ -535,7 +872,7 @@ func (c *diskCache) scanDir() (scanResult, error) {
 				continue
 			}
 
-			if !dre.MatchString(name2) {
+			if !shardDirRegex.MatchString(name2) {
 				return scanResult{}, fmt.Errorf("Unexpected dir: %s", dirPath)
 			}
 
@@ -555,6 +892,10 @@ func (c *diskCache) scanDir() (scanResult, error) {
 
 	<-received
 
+	if n := numTempfiles.Load(); n > 0 {
+		log.Printf("Removed %d incomplete files", n)
+	}
+
 	return finalScanResult, nil
 }
 
@@ -570,26 +911,36 @@ func (c *diskCache) loadExistingFiles(maxSizeBytes int64) error {
 		return err
 	}
 
-	log.Println("Sorting cache files by atime.")
-	sort.Sort(result)
+	if c.accessJournal != nil {
+		err = c.applyAccessJournal(result)
+		if err != nil {
+			return fmt.Errorf("Failed to replay the access journal: %w", err)
+		}
+	}
 
-	// The eviction callback deletes the file from disk.
-	// This function is only called while the lock is held
-	// by the current goroutine.
-	onEvict := func(key Key, value lruItem) {
-		f := c.getElementPath(key, value)
-		// Run in a goroutine so we can release the lock sooner.
-		go c.removeFile(f)
+	if c.noAtime {
+		log.Println("Sorting cache files by mtime.")
+	} else {
+		log.Println("Sorting cache files by atime.")
 	}
+	sort.Sort(result)
 
 	log.Println("Building LRU index.")
 
-	c.lru = NewSizedLRU(maxSizeBytes, onEvict, len(result.item))
+	if !c.loading.Load() {
+		c.lru = c.newLRU(maxSizeBytes, len(result.item))
+	}
+
+	// While loading in the background, the index is only used by
+	// requests after loading has finished, but eg Stats still needs the
+	// lock.
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	for i := 0; i < len(result.item); i++ {
-		ok := c.lru.Add(result.metadata[i].lookupKey, *result.item[i])
+		ok := c.lru.addAt(result.metadata[i].lookupKey, *result.item[i], result.metadata[i].ts)
 		if !ok {
-			err = os.Remove(filepath.Join(c.dir, result.metadata[i].lookupKey))
+			err = os.Remove(c.getElementPath(result.metadata[i].lookupKey, *result.item[i]))
 			if err != nil {
 				return err
 			}
@@ -600,3 +951,28 @@ func (c *diskCache) loadExistingFiles(maxSizeBytes int64) error {
 
 	return nil
 }
+
+// Returns a new, empty index with the cache's limits.
+func (c *diskCache) newLRU(maxSizeBytes int64, initialCapacity int) SizedLRU {
+	// The eviction callback deletes the file from disk.
+	// This function is only called while the lock is held
+	// by the current goroutine.
+	onEvict := func(key Key, value lruItem) {
+		// This is also called for the previous value of an entry which
+		// is overwritten, which is still in the index.
+		if _, overwritten := c.lru.cache[key]; !overwritten {
+			c.events.Evict(key.Kind(), key.Hash(), value.size)
+			c.activity.recordEviction(key, value)
+		}
+
+		c.dropInline(key)
+		c.removeEvictedFile(c.getElementPath(key, value))
+	}
+
+	lru := NewSizedLRU(maxSizeBytes, onEvict, initialCapacity)
+	lru.setInstanceQuotas(c.instanceQuotas)
+	lru.setMaxEntries(c.maxEntries)
+	lru.setLeaseDuration(c.leaseDuration)
+	lru.churn = c.churn
+	return lru
+}
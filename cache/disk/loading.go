@@ -0,0 +1,263 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Loading the existing files of a large cache directory can take a long
+// time after a restart, during which the cache can't serve anything.
+// With WithProxyReadsWhileLoading, New returns before the files have been
+// loaded, and until then reads are served from the proxy backend, so that
+// a restart doesn't leave the clients without a cache.
+//
+// While loading, the index is incomplete, so it isn't used: Get and
+// Contains go straight to the proxy backend, and blobs are streamed from
+// it without being written to disk, since files written during the scan
+// of the cache directory might be mistaken for incomplete ones. Writes
+// are refused, and compressed reads of items which are stored
+// uncompressed, and compressed reads from an offset, are reported as
+// misses. With write-through, the items which were served while loading
+// are added to the local cache from the proxy backend once loading has
+// finished.
+
+// The maximum number of items served while loading which are added to the
+// local cache afterwards, with write-through.
+const maxLoadingBackfill = 100000
+
+var errLoading = &cache.Error{
+	Code: http.StatusServiceUnavailable,
+	Text: "The cache is still loading its index, and doesn't accept writes yet",
+}
+
+var errLoadingNoProxy = errors.New("Serving reads from the proxy backend while loading requires a proxy backend")
+
+// Loading returns true if the existing files in the cache directory are
+// still being loaded, and reads are served from the proxy backend.
+func (c *diskCache) Loading() bool {
+	return c.loading.Load()
+}
+
+// Load the existing files, and then serve requests from the local cache.
+func (c *diskCache) loadInBackground(maxSizeBytes int64) {
+	log.Println("Serving reads from the proxy backend until the existing files have been loaded")
+
+	err := c.load(maxSizeBytes)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c.finishLoading()
+}
+
+// Serve requests from the local cache, and add the items which were read
+// while loading to it, with write-through.
+func (c *diskCache) finishLoading() {
+	c.backfillMu.Lock()
+	c.loading.Store(false)
+	keys := c.backfill
+	c.backfill = nil
+	c.backfillMu.Unlock()
+
+	c.gaugeLoading.Set(0)
+	log.Println("Loaded the existing files, serving requests from the local cache")
+
+	if len(keys) > 0 {
+		c.backfillLoadingReads(keys)
+	}
+}
+
+// Remember that key was served from the proxy backend while loading, to
+// add it to the local cache afterwards.
+func (c *diskCache) recordLoadingRead(key Key) {
+	if !c.loadingWriteThrough {
+		return
+	}
+
+	c.backfillMu.Lock()
+	defer c.backfillMu.Unlock()
+
+	if !c.loading.Load() || len(c.backfill) >= maxLoadingBackfill {
+		return
+	}
+	if c.backfill == nil {
+		c.backfill = make(map[Key]struct{})
+	}
+	c.backfill[key] = struct{}{}
+}
+
+// Add the items which were served while loading to the local cache.
+func (c *diskCache) backfillLoadingReads(keys map[Key]struct{}) {
+	var downloaded, failed int64
+
+	var g errgroup.Group
+	g.SetLimit(reconcileConcurrency)
+
+	for key := range keys {
+		key := key
+		g.Go(func() error {
+			c.mu.Lock()
+			_, found := c.lru.peek(key)
+			c.mu.Unlock()
+			if found {
+				return nil
+			}
+
+			found, err := c.downloadFromProxy(context.Background(), key)
+			if found {
+				atomic.AddInt64(&downloaded, 1)
+			}
+			if err != nil {
+				log.Printf("Warning: failed to add %s to the local cache: %v", key, err)
+				atomic.AddInt64(&failed, 1)
+			}
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	log.Printf("Added %d of the %d items served while loading to the local cache, %d failed",
+		downloaded, len(keys), failed)
+}
+
+// Like get, but streams the item from the proxy backend without using the
+// index or writing it to disk, for reads while loading.
+func (c *diskCache) getWhileLoading(ctx context.Context, key Key, kind cache.EntryKind, hash string, size int64, offset int64, zstd bool) (io.ReadCloser, int64, error) {
+	if size > c.maxProxyBlobSize || c.proxyDisabled(ctx) {
+		return nil, -1, nil
+	}
+
+	uncompressed := kind != cache.CAS || c.storageMode == casblob.Identity
+	if zstd && (uncompressed || offset > 0) {
+		return nil, -1, nil
+	}
+
+	err := c.io.acquire(ctx)
+	if err != nil {
+		return nil, -1, internalErr(err)
+	}
+	r, foundSize, err := c.proxy.Get(ctx, kind, hash)
+	c.io.release()
+	if err != nil {
+		if r != nil {
+			r.Close()
+		}
+		return nil, -1, internalErr(err)
+	}
+	if r == nil {
+		return nil, -1, nil
+	}
+	if foundSize > c.maxProxyBlobSize || isSizeMismatch(size, foundSize) || foundSize < 0 {
+		r.Close()
+		return nil, -1, nil
+	}
+
+	var rc io.ReadCloser
+	if uncompressed {
+		rc = io.NopCloser(r)
+	} else if zstd {
+		rc, err = casblob.GetZstdStreamReadCloser(c.zstd, r, foundSize)
+	} else {
+		rc, err = casblob.GetUncompressedStreamReadCloser(c.zstd, r, foundSize)
+	}
+	if err != nil {
+		r.Close()
+		return nil, -1, internalErr(err)
+	}
+
+	if !zstd && c.verifyProxyRead(kind) {
+		rc = &loadingVerifier{
+			ReadCloser: rc,
+			c:          c,
+			ctx:        ctx,
+			key:        key,
+			size:       foundSize,
+			h:          sha256.New(),
+		}
+	}
+
+	if offset > 0 {
+		_, err = io.CopyN(io.Discard, rc, offset)
+		if err != nil {
+			rc.Close()
+			r.Close()
+			return nil, -1, internalErr(err)
+		}
+	}
+
+	c.recordLoadingRead(key)
+	cache.RecordLookup(ctx, cache.SourceProxy, storedCompression(kind, uncompressed), foundSize-offset)
+
+	return &multiCloser{ReadCloser: rc, backend: r}, foundSize, nil
+}
+
+// Closes the reader of a blob, and then the proxy backend's reader which
+// it reads from.
+type multiCloser struct {
+	io.ReadCloser
+	backend io.ReadCloser
+}
+
+func (m *multiCloser) Close() error {
+	err := m.ReadCloser.Close()
+	backendErr := m.backend.Close()
+	if err == nil {
+		err = backendErr
+	}
+	return err
+}
+
+// Hashes a CAS blob from the proxy backend while it is read, and returns
+// errCorruptProxyBlob instead of io.EOF if it doesn't match its digest,
+// since it can't be downloaded and verified before it is served while
+// loading.
+type loadingVerifier struct {
+	io.ReadCloser
+	c    *diskCache
+	ctx  context.Context
+	key  Key
+	size int64
+	h    hash.Hash
+	n    int64
+	err  error // The result of the verification, once it is done.
+}
+
+func (v *loadingVerifier) Read(p []byte) (int, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+
+	n, err := v.ReadCloser.Read(p)
+	v.h.Write(p[:n])
+	v.n += int64(n)
+
+	if err != io.EOF {
+		return n, err
+	}
+
+	backend := cache.ProxyBackendName(v.ctx, v.c.proxy)
+	v.c.counterProxyVerifiedBlobs.WithLabelValues(backend).Inc()
+	if v.n == v.size && bytes.Equal(v.h.Sum(nil), v.key.digest[:]) {
+		v.err = io.EOF
+		return n, io.EOF
+	}
+
+	v.c.counterProxyCorruptBlobs.WithLabelValues(backend).Inc()
+	log.Printf("The %s proxy backend returned %s, which does not match its digest", backend, v.key)
+
+	v.err = errCorruptProxyBlob
+	return n, v.err
+}
@@ -0,0 +1,188 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func newLoadingProxy() *prewarmProxy {
+	return &prewarmProxy{
+		listingProxy: listingProxy{
+			items: make(map[string]cache.ProxyItem),
+			blobs: make(map[string][]byte),
+		},
+		sizes: make(map[string]int64),
+	}
+}
+
+func TestProxyReadsWhileLoading(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	p := newLoadingProxy()
+
+	cI, err := New(cacheDir, BlockSize*10, WithProxyBackend(p), WithProxyReadsWhileLoading(true),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cI.(*diskCache)
+	waitForLoading(t, c)
+
+	ctx := context.Background()
+
+	local, localHash := testutils.RandomDataAndHash(100)
+	err = c.Put(ctx, cache.CAS, localHash, int64(len(local)), bytes.NewReader(local))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote, remoteHash := testutils.RandomDataAndHash(200)
+	p.add(compressedBlob(t, c, remote, remoteHash), remoteHash, int64(len(remote)))
+
+	// Pretend that the existing files are still being loaded.
+	c.loading.Store(true)
+	if !c.Loading() {
+		t.Fatal("Expected the cache to report that it is loading")
+	}
+
+	// The incomplete index isn't used.
+	found, _ := c.Contains(ctx, cache.CAS, localHash, int64(len(local)))
+	if found {
+		t.Error("Expected the local blob not to be found while loading")
+	}
+	missing, err := c.FindMissingCasBlobs(ctx, []*pb.Digest{
+		{Hash: localHash, SizeBytes: int64(len(local))},
+		{Hash: remoteHash, SizeBytes: int64(len(remote))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 1 || missing[0].Hash != localHash {
+		t.Errorf("Expected only the local blob to be missing while loading, got %v", missing)
+	}
+
+	// Blobs are streamed from the proxy backend, without being added to
+	// the cache.
+	rc, size, err := c.Get(ctx, cache.CAS, remoteHash, int64(len(remote)), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc == nil || size != int64(len(remote)) {
+		t.Fatalf("Expected the blob from the proxy backend, got size %d", size)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, remote[10:]) {
+		t.Error("Unexpected data from the proxy backend")
+	}
+
+	c.mu.Lock()
+	_, found = c.lru.peek(casKey(t, remoteHash))
+	c.mu.Unlock()
+	if found {
+		t.Error("Expected the blob not to be added to the cache while loading")
+	}
+
+	// Writes are refused.
+	err = c.Put(ctx, cache.CAS, remoteHash, int64(len(remote)), bytes.NewReader(remote))
+	var cerr *cache.Error
+	if !errors.As(err, &cerr) || cerr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the write to be refused while loading, got %v", err)
+	}
+
+	// With write-through, the blob is added to the cache once loading
+	// has finished.
+	c.finishLoading()
+	if c.Loading() {
+		t.Fatal("Expected the cache to have finished loading")
+	}
+
+	c.mu.Lock()
+	_, found = c.lru.peek(casKey(t, remoteHash))
+	c.mu.Unlock()
+	if !found {
+		t.Error("Expected the blob which was read while loading to be added to the cache")
+	}
+
+	found, _ = c.Contains(ctx, cache.CAS, localHash, int64(len(local)))
+	if !found {
+		t.Error("Expected the local blob to be found after loading")
+	}
+}
+
+func TestProxyReadsWhileLoadingExistingFiles(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	ctx := context.Background()
+	data, hash := testutils.RandomDataAndHash(100)
+
+	c, err := New(cacheDir, BlockSize*10, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The existing files are loaded in the background.
+	cI, err := New(cacheDir, BlockSize*10, WithProxyBackend(newLoadingProxy()), WithProxyReadsWhileLoading(false),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForLoading(t, cI.(*diskCache))
+
+	found, _ := cI.Contains(ctx, cache.CAS, hash, int64(len(data)))
+	if !found {
+		t.Error("Expected the existing blob to be found after loading")
+	}
+}
+
+func TestProxyReadsWhileLoadingRequiresProxy(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	_, err := New(cacheDir, BlockSize*10, WithProxyReadsWhileLoading(false),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != errLoadingNoProxy {
+		t.Fatalf("Expected %v, got %v", errLoadingNoProxy, err)
+	}
+}
+
+func waitForLoading(t *testing.T, c *diskCache) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for c.Loading() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the existing files to be loaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func casKey(t *testing.T, hash string) Key {
+	t.Helper()
+
+	key, ok := newKey(cache.CAS, hash)
+	if !ok {
+		t.Fatalf("Invalid hash: %q", hash)
+	}
+	return key
+}
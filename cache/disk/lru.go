@@ -4,18 +4,13 @@ import (
 	"container/list"
 	"errors"
 	"fmt"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-// Key is the type used for identifying cache items. For non-test code,
-// this is a string of the form "<keyspace>/<hash>". Some test code uses
-// ints for simplicity.
-//
-// TODO: update the test code to use strings too, then drop all the
-// type assertions.
-type Key interface{}
-
 // EvictCallback is the type of callbacks that are invoked when items are evicted.
 type EvictCallback func(key Key, value lruItem)
 
@@ -26,7 +21,7 @@ type SizedLRU struct {
 	// Eviction double-linked list. Most recently accessed elements are at the front.
 	ll *list.List
 	// Map to access the items in O(1) time
-	cache map[interface{}]*list.Element
+	cache map[Key]*list.Element
 
 	// Total cache size including reserved bytes and estimated filesystem overhead.
 	currentSize int64
@@ -44,15 +39,65 @@ type SizedLRU struct {
 
 	onEvict EvictCallback
 
+	// Per-instance accounting, see quota.go.
+	instances   map[string]*instanceUsage
+	quotaUsages []*instanceUsage // The instances with quotas, by name.
+	totalQuota  int64
+
+	// The entries of the kinds with entry limits, or nil, by kind. See
+	// entrylimit.go.
+	kindEntries [cache.RAW + 1]*kindEntries
+
+	// See lease.go.
+	leaseDuration time.Duration
+
+	// Used for leases and entry access times.
+	now func() time.Time
+
+	// Called with the access time of each entry which is added or read,
+	// if not nil. See accessjournal.go.
+	onAccess func(key Key, lastAccess uint32)
+
+	// Counts the entries which are written, and evicted. May be nil. See
+	// churn.go.
+	churn *churnStats
+
 	gaugeCacheSizeBytes     prometheus.Gauge
 	gaugeCacheLogicalBytes  prometheus.Gauge
+	gaugeInstanceSizeBytes  *prometheus.GaugeVec
 	counterEvictedBytes     prometheus.Counter
 	counterOverwrittenBytes prometheus.Counter
+	counterLeasedEvictions  prometheus.Counter
+
+	counterEntryLimitEvictions *prometheus.CounterVec
 }
 
 type entry struct {
-	key   Key
+	key Key
+
+	// Whether the entry was added by Add, ie written since startup, and
+	// whether it was read since, for churn.go. These fit in the padding
+	// after key.
+	written bool
+	read    bool
+
+	// When the entry was last added or read, in Unix seconds, for the
+	// age metrics in age.go. This fits in the padding after key.
+	lastAccess uint32
+
 	value lruItem
+
+	// The instance which the entry is attributed to, or nil.
+	usage *instanceUsage
+	// The entry's element in usage.ll, if the instance has a quota.
+	instanceEle *list.Element
+	// The entry's element in the list of its kind, if the kind has an
+	// entry limit.
+	kindEle *list.Element
+
+	// When the entry's lease expires, in Unix nanoseconds, or 0 if it
+	// was never leased. See lease.go.
+	leaseExpiry int64
 }
 
 // Actual disk usage will be estimated by rounding file sizes up to the
@@ -64,9 +109,12 @@ func NewSizedLRU(maxSize int64, onEvict EvictCallback, initialCapacity int) Size
 	return SizedLRU{
 		maxSize: maxSize,
 		ll:      list.New(),
-		cache:   make(map[interface{}]*list.Element, initialCapacity),
+		cache:   make(map[Key]*list.Element, initialCapacity),
 		onEvict: onEvict,
 
+		instances: make(map[string]*instanceUsage),
+		now:       time.Now,
+
 		gaugeCacheSizeBytes: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "bazel_remote_disk_cache_size_bytes",
 			Help: "The current number of bytes in the disk backend",
@@ -75,6 +123,10 @@ func NewSizedLRU(maxSize int64, onEvict EvictCallback, initialCapacity int) Size
 			Name: "bazel_remote_disk_cache_logical_bytes",
 			Help: "The current number of bytes in the disk backend if they were uncompressed",
 		}),
+		gaugeInstanceSizeBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "bazel_remote_disk_cache_instance_size_bytes",
+			Help: "The current number of bytes in the disk backend attributed to each instance name which has a quota",
+		}, []string{"instance"}),
 		counterEvictedBytes: prometheus.NewCounter(prometheus.CounterOpts{
 			Name: "bazel_remote_disk_cache_evicted_bytes_total",
 			Help: "The total number of bytes evicted from disk backend, due to full cache",
@@ -83,25 +135,54 @@ func NewSizedLRU(maxSize int64, onEvict EvictCallback, initialCapacity int) Size
 			Name: "bazel_remote_disk_cache_overwritten_bytes_total",
 			Help: "The total number of bytes removed from disk backend, due to put of already existing key",
 		}),
+		counterLeasedEvictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_leased_evictions_total",
+			Help: "The total number of entries evicted from disk backend while leased, because every entry was leased",
+		}),
+		counterEntryLimitEvictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_entry_limit_evictions_total",
+			Help: "The total number of entries evicted from disk backend because their kind reached its maximum number of entries",
+		}, []string{"kind"}),
 	}
 }
 
 func (c *SizedLRU) RegisterMetrics() {
 	prometheus.MustRegister(c.gaugeCacheSizeBytes)
 	prometheus.MustRegister(c.gaugeCacheLogicalBytes)
+	prometheus.MustRegister(c.gaugeInstanceSizeBytes)
 	prometheus.MustRegister(c.counterEvictedBytes)
 	prometheus.MustRegister(c.counterOverwrittenBytes)
+	prometheus.MustRegister(c.counterLeasedEvictions)
+	prometheus.MustRegister(c.counterEntryLimitEvictions)
 }
 
 // Add adds a (key, value) to the cache, evicting items as necessary.
 // Add returns false and does not add the item if the item size is
-// larger than the maximum size of the cache, or if the item cannot
-// be added to the cache because too much space is reserved.
+// larger than the maximum size of the cache or the quota of its
+// instance, or if the item cannot be added to the cache because too
+// much space is reserved.
 //
 // Note that this function rounds file sizes up to the nearest
 // BlockSize (4096) bytes, as an estimate of actual disk usage since
 // most linux filesystems default to 4kb blocks.
 func (c *SizedLRU) Add(key Key, value lruItem) (ok bool) {
+	if !c.addAt(key, value, c.now()) {
+		return false
+	}
+
+	if ele, found := c.cache[key]; found {
+		e := ele.Value.(*entry)
+		e.written = true
+		e.read = false
+	}
+	c.churn.recordWrite(key.Kind(), value.size)
+
+	return true
+}
+
+// Like Add, but records accessTime as the time when the entry was last
+// accessed, eg the atime of a file found at startup.
+func (c *SizedLRU) addAt(key Key, value lruItem, accessTime time.Time) (ok bool) {
 
 	roundedUpSizeOnDisk := roundUp4k(value.sizeOnDisk)
 
@@ -109,8 +190,13 @@ func (c *SizedLRU) Add(key Key, value lruItem) (ok bool) {
 		return false
 	}
 
+	if quota := c.instanceQuota(value); quota > 0 && roundedUpSizeOnDisk > quota {
+		return false
+	}
+
 	var sizeDelta, uncompressedSizeDelta int64
-	if ee, ok := c.cache[key]; ok {
+	ee, ok := c.cache[key]
+	if ok {
 		sizeDelta = roundedUpSizeOnDisk - roundUp4k(ee.Value.(*entry).value.sizeOnDisk)
 		if c.reservedSize+sizeDelta > c.maxSize {
 			return false
@@ -124,21 +210,24 @@ func (c *SizedLRU) Add(key Key, value lruItem) (ok bool) {
 			c.onEvict(key, prevValue)
 		}
 
+		c.detach(ee.Value.(*entry))
 		ee.Value.(*entry).value = value
+		ee.Value.(*entry).lastAccess = unixSeconds(accessTime)
 	} else {
 		sizeDelta = roundedUpSizeOnDisk
 		if c.reservedSize+sizeDelta > c.maxSize {
 			return false
 		}
 		uncompressedSizeDelta = roundUp4k(value.size)
-		ele := c.ll.PushFront(&entry{key, value})
-		c.cache[key] = ele
+		ee = c.ll.PushFront(&entry{key: key, lastAccess: unixSeconds(accessTime), value: value})
+		c.cache[key] = ee
 	}
+	c.attach(ee)
 
 	// Eviction. This is needed even if the key was already present, since the size of the
 	// value might have changed, pushing the total size over maxSize.
 	for c.currentSize+sizeDelta > c.maxSize {
-		ele := c.ll.Back()
+		ele := c.evictionVictim(ee)
 		if ele != nil {
 			c.removeElement(ele)
 		}
@@ -147,9 +236,16 @@ func (c *SizedLRU) Add(key Key, value lruItem) (ok bool) {
 	c.currentSize += sizeDelta
 	c.uncompressedSize += uncompressedSizeDelta
 
+	c.enforceQuota(ee.Value.(*entry).usage)
+	c.enforceMaxEntries(key.kind)
+
 	c.gaugeCacheSizeBytes.Set(float64(c.currentSize))
 	c.gaugeCacheLogicalBytes.Set(float64(c.uncompressedSize))
 
+	if c.onAccess != nil {
+		c.onAccess(key, ee.Value.(*entry).lastAccess)
+	}
+
 	return true
 }
 
@@ -157,12 +253,55 @@ func (c *SizedLRU) Add(key Key, value lruItem) (ok bool) {
 func (c *SizedLRU) Get(key Key) (value lruItem, ok bool) {
 	if ele, hit := c.cache[key]; hit {
 		c.ll.MoveToFront(ele)
-		return ele.Value.(*entry).value, true
+		e := ele.Value.(*entry)
+		if e.instanceEle != nil {
+			e.usage.ll.MoveToFront(e.instanceEle)
+		}
+		if e.kindEle != nil {
+			c.kindEntries[key.kind].ll.MoveToFront(e.kindEle)
+		}
+		e.lastAccess = unixSeconds(c.now())
+		e.read = true
+		if c.onAccess != nil {
+			c.onAccess(key, e.lastAccess)
+		}
+		return e.value, true
 	}
 
 	return
 }
 
+// Look up a key without marking it as recently used.
+func (c *SizedLRU) peek(key Key) (lruItem, bool) {
+	if ele, hit := c.cache[key]; hit {
+		return ele.Value.(*entry).value, true
+	}
+
+	return lruItem{}, false
+}
+
+// Move the entry with key to just in front of the entry with key older,
+// or to the back of the list if older is nil or no longer in the cache,
+// so that it is evicted before the entries which were already in the
+// cache. Used for imported entries, see import.go.
+func (c *SizedLRU) demote(key Key, older *Key) {
+	ele, found := c.cache[key]
+	if !found {
+		// The entry might have been forwarded to another cluster
+		// member, or evicted already.
+		return
+	}
+
+	if older != nil {
+		if olderEle, found := c.cache[*older]; found && olderEle != ele {
+			c.ll.MoveBefore(ele, olderEle)
+			return
+		}
+	}
+
+	c.ll.MoveToBack(ele)
+}
+
 // Remove removes a (key, value) from the cache
 func (c *SizedLRU) Remove(key Key) {
 	if ele, hit := c.cache[key]; hit {
@@ -193,6 +332,15 @@ func (c *SizedLRU) MaxSize() int64 {
 	return c.maxSize
 }
 
+// Change the maximum size, and evict entries until the total size is at
+// most the new maximum size.
+func (c *SizedLRU) setMaxSize(maxSize int64) {
+	c.maxSize = maxSize
+	if c.currentSize > maxSize {
+		c.evictBytes(c.currentSize - maxSize)
+	}
+}
+
 // This assumes that a is positive, b is non-negative, and c is positive.
 func sumLargerThan(a, b, c int64) bool {
 	sum := a + b
@@ -232,7 +380,7 @@ func (c *SizedLRU) Reserve(size int64) (bool, error) {
 
 	// Evict elements until we are able to reserve enough space.
 	for sumLargerThan(size, c.currentSize, c.maxSize) {
-		ele := c.ll.Back()
+		ele := c.evictionVictim(nil)
 		if ele != nil {
 			c.removeElement(ele)
 		} else {
@@ -274,6 +422,8 @@ func (c *SizedLRU) removeElement(e *list.Element) {
 	c.currentSize -= roundUp4k(kv.value.sizeOnDisk)
 	c.uncompressedSize -= roundUp4k(kv.value.size)
 	c.counterEvictedBytes.Add(float64(kv.value.sizeOnDisk))
+	c.churn.recordEviction(kv)
+	c.detach(kv)
 
 	if c.onEvict != nil {
 		c.onEvict(kv.key, kv.value)
@@ -285,12 +435,34 @@ func roundUp4k(n int64) int64 {
 	return (n + BlockSize - 1) & -BlockSize
 }
 
-// Get the back item of the LRU cache.
-func (c *SizedLRU) getTailItem() (Key, lruItem) {
+// Get the back item of the LRU cache, or false if the cache is empty.
+func (c *SizedLRU) getTailItem() (Key, lruItem, bool) {
 	ele := c.ll.Back()
 	if ele != nil {
 		kv := ele.Value.(*entry)
-		return kv.key, kv.value
+		return kv.key, kv.value, true
+	}
+	return Key{}, lruItem{}, false
+}
+
+// Get the entries which would be evicted to reduce the total size to
+// targetSize, from least to most recently used, without evicting them.
+func (c *SizedLRU) evictionCandidates(targetSize int64) []entry {
+	var entries []entry
+	size := c.currentSize
+	for ele := c.ll.Back(); ele != nil && size > targetSize; ele = ele.Prev() {
+		kv := ele.Value.(*entry)
+		entries = append(entries, *kv)
+		size -= roundUp4k(kv.value.sizeOnDisk)
+	}
+	return entries
+}
+
+// Get the keys of all items, from most to least recently used.
+func (c *SizedLRU) keys() []Key {
+	keys := make([]Key, 0, c.ll.Len())
+	for ele := c.ll.Front(); ele != nil; ele = ele.Next() {
+		keys = append(keys, ele.Value.(*entry).key)
 	}
-	return nil, lruItem{}
+	return keys
 }
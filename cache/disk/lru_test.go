@@ -1,11 +1,20 @@
 package disk
 
 import (
+	"crypto/sha256"
 	"math"
 	"reflect"
+	"strconv"
 	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
 )
 
+// Returns a key for name, for tests which don't need real hashes.
+func testKey(name string) Key {
+	return Key{kind: uint8(cache.RAW), digest: sha256.Sum256([]byte(name))}
+}
+
 func checkSizeAndNumItems(t *testing.T, lru SizedLRU, expSize int64, expNum int) {
 	currentSize := lru.TotalSize()
 	if currentSize != expSize {
@@ -27,7 +36,7 @@ func TestBasics(t *testing.T) {
 		t.Fatalf("MaxSize: expected %d, got %d", maxSize, lru.MaxSize())
 	}
 
-	_, ok := lru.Get("1")
+	_, ok := lru.Get(testKey("1"))
 	if ok {
 		t.Fatalf("Get: unexpected element found")
 	}
@@ -35,7 +44,7 @@ func TestBasics(t *testing.T) {
 	checkSizeAndNumItems(t, lru, 0, 0)
 
 	// Add an item
-	aKey := "akey"
+	aKey := testKey("akey")
 	anItem := lruItem{size: 5, sizeOnDisk: 5}
 	ok = lru.Add(aKey, anItem)
 	if !ok {
@@ -59,9 +68,9 @@ func TestBasics(t *testing.T) {
 
 func TestEviction(t *testing.T) {
 	// Keep track of evictions using the callback
-	var evictions []int
+	var evictions []Key
 	onEvict := func(key Key, value lruItem) {
-		evictions = append(evictions, key.(int))
+		evictions = append(evictions, key)
 	}
 
 	lru := NewSizedLRU(10*BlockSize, onEvict, 0)
@@ -81,29 +90,68 @@ func TestEviction(t *testing.T) {
 		{7, 1, []int{6}},          // 7
 	}
 
-	var expectedEvictions []int
+	var expectedEvictions []Key
 
 	for i, thisExpected := range expectedSizesNumItems {
 		item := lruItem{size: int64(i) * BlockSize, sizeOnDisk: int64(i) * BlockSize}
-		ok := lru.Add(i, item)
+		ok := lru.Add(testKey(strconv.Itoa(i)), item)
 		if !ok {
 			t.Fatalf("Add: failed adding %d", i)
 		}
 
 		checkSizeAndNumItems(t, lru, thisExpected.expBlocks*BlockSize, thisExpected.expNumItems)
 
-		expectedEvictions = append(expectedEvictions, thisExpected.expEvicted...)
+		for _, e := range thisExpected.expEvicted {
+			expectedEvictions = append(expectedEvictions, testKey(strconv.Itoa(e)))
+		}
 		if !reflect.DeepEqual(expectedEvictions, evictions) {
 			t.Fatalf("Expecting evictions %v, found %v", expectedEvictions, evictions)
 		}
 	}
 }
 
+func TestSetMaxSize(t *testing.T) {
+	var evictions []Key
+	onEvict := func(key Key, value lruItem) {
+		evictions = append(evictions, key)
+	}
+
+	lru := NewSizedLRU(10*BlockSize, onEvict, 0)
+	for i := 0; i < 4; i++ {
+		ok := lru.Add(testKey(strconv.Itoa(i)), lruItem{size: 2 * BlockSize, sizeOnDisk: 2 * BlockSize})
+		if !ok {
+			t.Fatalf("Add: failed adding %d", i)
+		}
+	}
+
+	// Growing the cache doesn't evict anything.
+	lru.setMaxSize(20 * BlockSize)
+	checkSizeAndNumItems(t, lru, 8*BlockSize, 4)
+	if lru.MaxSize() != 20*BlockSize {
+		t.Fatalf("MaxSize: expected %d, got %d", 20*BlockSize, lru.MaxSize())
+	}
+
+	// Shrinking it evicts the least recently used items.
+	lru.setMaxSize(5 * BlockSize)
+	checkSizeAndNumItems(t, lru, 4*BlockSize, 2)
+	expected := []Key{testKey("0"), testKey("1")}
+	if !reflect.DeepEqual(evictions, expected) {
+		t.Fatalf("Expecting evictions %v, found %v", expected, evictions)
+	}
+
+	// And later items are evicted to stay below the new size.
+	ok := lru.Add(testKey("4"), lruItem{size: 2 * BlockSize, sizeOnDisk: 2 * BlockSize})
+	if !ok {
+		t.Fatal("Add: failed adding 4")
+	}
+	checkSizeAndNumItems(t, lru, 4*BlockSize, 2)
+}
+
 func TestRejectBigItem(t *testing.T) {
 	// Bounded caches should reject big items
 	lru := NewSizedLRU(10, nil, 0)
 
-	ok := lru.Add("hello", lruItem{size: 11, sizeOnDisk: 11})
+	ok := lru.Add(testKey("hello"), lruItem{size: 11, sizeOnDisk: 11})
 	if ok {
 		t.Fatalf("Add succeeded, expected it to fail")
 	}
@@ -115,7 +163,7 @@ func TestReserveZeroAlwaysPossible(t *testing.T) {
 	largeItem := lruItem{size: math.MaxInt64, sizeOnDisk: math.MaxInt64}
 
 	lru := NewSizedLRU(math.MaxInt64, nil, 0)
-	lru.Add("foo", largeItem)
+	lru.Add(testKey("foo"), largeItem)
 	ok, err := lru.Reserve(0)
 	if err != nil {
 		t.Fatal(err)
@@ -246,7 +294,7 @@ func TestAddWithSpaceReserved(t *testing.T) {
 		t.Fatalf("Expected to be able to reserve 1")
 	}
 
-	ok = lru.Add("hello", lruItem{size: 2, sizeOnDisk: 2})
+	ok = lru.Add(testKey("hello"), lruItem{size: 2, sizeOnDisk: 2})
 	if ok {
 		t.Fatal("Expected to not be able to add item with size 2")
 	}
@@ -256,7 +304,7 @@ func TestAddWithSpaceReserved(t *testing.T) {
 		t.Fatal("Expected to be able to unreserve 1:", err)
 	}
 
-	ok = lru.Add("hello", lruItem{size: 2, sizeOnDisk: 2})
+	ok = lru.Add(testKey("hello"), lruItem{size: 2, sizeOnDisk: 2})
 	if !ok {
 		t.Fatal("Expected to be able to add item with size 2")
 	}
@@ -12,7 +12,13 @@ import (
 )
 
 type metricsDecorator struct {
-	counter *prometheus.CounterVec
+	counter      *prometheus.CounterVec
+	bytesCounter *prometheus.CounterVec
+
+	// The instance names which are used as instance label values, or nil
+	// if the metrics don't have an instance label.
+	instances map[string]bool
+
 	*diskCache
 }
 
@@ -22,31 +28,105 @@ const (
 
 	containsMethod = "contains"
 	getMethod      = "get"
-	//putMethod      = "put"
+	putMethod      = "put"
 
 	acKind  = "ac" // This must be lowercase to match cache.EntryKind.String()
 	casKind = "cas"
 	rawKind = "raw"
+
+	// The instance label value for instance names which are not listed.
+	otherInstance = "other"
 )
 
+// Create the counters, with an instance label if instances is not empty.
+func (m *metricsDecorator) createCounters(instances []string) {
+	labels := []string{"method", "kind"}
+	if len(instances) > 0 {
+		m.instances = make(map[string]bool, len(instances))
+		for _, name := range instances {
+			m.instances[name] = true
+		}
+		labels = append(labels, "instance")
+	}
+
+	m.counter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bazel_remote_incoming_requests_total",
+		Help: "The number of incoming cache requests",
+	},
+		append(labels, "status"))
+
+	m.bytesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bazel_remote_incoming_bytes_total",
+		Help: "The number of bytes read by cache hits and written by incoming cache requests",
+	},
+		labels)
+}
+
+// Returns the labels for a request, including the instance label if
+// there is one.
+func (m *metricsDecorator) labels(ctx context.Context, method string, kind string) prometheus.Labels {
+	lbls := prometheus.Labels{"method": method, "kind": kind}
+
+	if m.instances != nil {
+		instance := cache.InstanceName(ctx)
+		if !m.instances[instance] {
+			instance = otherInstance
+		}
+		lbls["instance"] = instance
+	}
+
+	return lbls
+}
+
+// Count a request with the given labels, which are modified.
+func (m *metricsDecorator) count(lbls prometheus.Labels, hit bool) {
+	if hit {
+		lbls["status"] = hitStatus
+	} else {
+		lbls["status"] = missStatus
+	}
+	m.counter.With(lbls).Inc()
+}
+
 func (m *metricsDecorator) RegisterMetrics() {
 	prometheus.MustRegister(m.counter)
+	prometheus.MustRegister(m.bytesCounter)
 	m.diskCache.RegisterMetrics()
 }
 
+func (m *metricsDecorator) Put(ctx context.Context, kind cache.EntryKind, hash string, size int64, r io.Reader) error {
+	err := m.diskCache.Put(ctx, kind, hash, size, r)
+	if err != nil {
+		return err
+	}
+
+	m.bytesCounter.With(m.labels(ctx, putMethod, kind.String())).Add(float64(size))
+
+	return nil
+}
+
+func (m *metricsDecorator) ResumablePut(ctx context.Context, hash string, size int64, offset int64, r io.Reader) error {
+	err := m.diskCache.ResumablePut(ctx, hash, size, offset, r)
+	if err != nil {
+		return err
+	}
+
+	m.bytesCounter.With(m.labels(ctx, putMethod, cache.CAS.String())).Add(float64(size - offset))
+
+	return nil
+}
+
 func (m *metricsDecorator) Get(ctx context.Context, kind cache.EntryKind, hash string, size int64, offset int64) (io.ReadCloser, int64, error) {
 	rc, size, err := m.diskCache.Get(ctx, kind, hash, size, offset)
 	if err != nil {
 		return rc, size, err
 	}
 
-	lbls := prometheus.Labels{"method": getMethod, "kind": kind.String()}
+	lbls := m.labels(ctx, getMethod, kind.String())
 	if rc != nil {
-		lbls["status"] = hitStatus
-	} else {
-		lbls["status"] = missStatus
+		m.bytesCounter.With(lbls).Add(float64(size - offset))
 	}
-	m.counter.With(lbls).Inc()
+	m.count(lbls, rc != nil)
 
 	return rc, size, nil
 }
@@ -57,13 +137,11 @@ func (m *metricsDecorator) GetValidatedActionResult(ctx context.Context, hash st
 		return ar, data, err
 	}
 
-	lbls := prometheus.Labels{"method": getMethod, "kind": acKind}
+	lbls := m.labels(ctx, getMethod, acKind)
 	if ar != nil {
-		lbls["status"] = hitStatus
-	} else {
-		lbls["status"] = missStatus
+		m.bytesCounter.With(lbls).Add(float64(len(data)))
 	}
-	m.counter.With(lbls).Inc()
+	m.count(lbls, ar != nil)
 
 	return ar, data, err
 }
@@ -74,16 +152,11 @@ func (m *metricsDecorator) GetZstd(ctx context.Context, hash string, size int64,
 		return rc, size, err
 	}
 
-	lbls := prometheus.Labels{
-		"method": getMethod,
-		"kind":   "cas",
-	}
+	lbls := m.labels(ctx, getMethod, casKind)
 	if rc != nil {
-		lbls["status"] = hitStatus
-	} else {
-		lbls["status"] = missStatus
+		m.bytesCounter.With(lbls).Add(float64(size - offset))
 	}
-	m.counter.With(lbls).Inc()
+	m.count(lbls, rc != nil)
 
 	return rc, size, nil
 }
@@ -91,13 +164,7 @@ func (m *metricsDecorator) GetZstd(ctx context.Context, hash string, size int64,
 func (m *metricsDecorator) Contains(ctx context.Context, kind cache.EntryKind, hash string, size int64) (bool, int64) {
 	ok, size := m.diskCache.Contains(ctx, kind, hash, size)
 
-	lbls := prometheus.Labels{"method": containsMethod, "kind": kind.String()}
-	if ok {
-		lbls["status"] = hitStatus
-	} else {
-		lbls["status"] = missStatus
-	}
-	m.counter.With(lbls).Inc()
+	m.count(m.labels(ctx, containsMethod, kind.String()), ok)
 
 	return ok, size
 }
@@ -113,18 +180,12 @@ func (m *metricsDecorator) FindMissingCasBlobs(ctx context.Context, blobs []*pb.
 
 	numFound := numLooking - numMissing
 
-	hitLabels := prometheus.Labels{
-		"method": containsMethod,
-		"kind":   "cas",
-		"status": hitStatus,
-	}
+	hitLabels := m.labels(ctx, containsMethod, casKind)
+	hitLabels["status"] = hitStatus
 	hits := m.counter.With(hitLabels)
 
-	missLabels := prometheus.Labels{
-		"method": containsMethod,
-		"kind":   "cas",
-		"status": missStatus,
-	}
+	missLabels := m.labels(ctx, containsMethod, casKind)
+	missLabels["status"] = missStatus
 	misses := m.counter.With(missLabels)
 
 	hits.Add(float64(numFound))
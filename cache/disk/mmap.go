@@ -0,0 +1,109 @@
+package disk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+)
+
+// The sizes of the blobs which are read through a memory mapping, if
+// WithMmapReads is used. Smaller blobs are cheaper to read with read(2),
+// and larger blobs would use a lot of address space per reader.
+const (
+	minMmapSize = 1024 * 1024
+	maxMmapSize = 50 * 1024 * 1024
+)
+
+var errMmapUnsupported = errors.New("memory mapped reads are not supported on this platform")
+
+// Returns a reader for the uncompressed blob of the given size in f,
+// starting at offset. If mmap reads are enabled and the size is in range,
+// the blob is read through a memory mapping, which is shared by the
+// concurrent readers of hot blobs via the page cache without copying
+// the data in each read(2). If the file can't be mapped, it is read
+// normally.
+func (c *diskCache) blobReader(f *os.File, size int64, offset int64) (io.ReadCloser, error) {
+	if c.mmapReads && size >= minMmapSize && size <= maxMmapSize {
+		data, err := mmapFile(f, size)
+		if err == nil {
+			f.Close() // The mapping remains valid.
+			return &mmapReader{data: data, off: offset}, nil
+		}
+		c.counterMmapFallbacks.Inc()
+	}
+
+	_, err := f.Seek(offset, io.SeekStart)
+	return f, err
+}
+
+// An io.ReadCloser for a memory mapped blob.
+//
+// Reads from a mapping fault if the underlying file becomes unavailable,
+// eg because of I/O errors. These faults are recovered from and returned
+// as errors, rather than crashing the process.
+type mmapReader struct {
+	data []byte
+	off  int64
+}
+
+func (r *mmapReader) Read(p []byte) (n int, err error) {
+	if r.data == nil {
+		return 0, os.ErrClosed
+	}
+	if r.off >= int64(len(r.data)) {
+		return 0, io.EOF
+	}
+
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer recoverFault(&err)
+
+	n = copy(p, r.data[r.off:])
+	r.off += int64(n)
+	return n, nil
+}
+
+// WriteTo writes the rest of the blob to w in one call, so that io.Copy
+// doesn't need a buffer.
+func (r *mmapReader) WriteTo(w io.Writer) (n int64, err error) {
+	if r.data == nil {
+		return 0, os.ErrClosed
+	}
+	if r.off >= int64(len(r.data)) {
+		return 0, nil
+	}
+
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer recoverFault(&err)
+
+	written, err := w.Write(r.data[r.off:])
+	r.off += int64(written)
+	return int64(written), err
+}
+
+func (r *mmapReader) Close() error {
+	if r.data == nil {
+		return nil
+	}
+
+	err := munmap(r.data)
+	r.data = nil
+	return err
+}
+
+// Turn a panic for a memory fault into an error. Other panics are
+// propagated.
+func recoverFault(err *error) {
+	p := recover()
+	if p == nil {
+		return
+	}
+
+	if faultErr, ok := p.(interface{ Addr() uintptr }); ok {
+		*err = fmt.Errorf("Failed to read memory mapped blob: %v", faultErr)
+		return
+	}
+
+	panic(p)
+}
@@ -0,0 +1,46 @@
+//go:build linux
+// +build linux
+
+package disk
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+const mmapSupported = true
+
+// Filesystem types on which mmap reads are disabled, because reads from
+// a mapping fault when the server is unavailable, rather than failing
+// or waiting like read(2).
+var networkFilesystems = map[int64]string{
+	unix.NFS_SUPER_MAGIC:  "NFS",
+	unix.SMB_SUPER_MAGIC:  "SMB",
+	unix.CIFS_SUPER_MAGIC: "CIFS",
+	unix.FUSE_SUPER_MAGIC: "FUSE",
+}
+
+// Returns the name of the network filesystem type of dir, or "" if it
+// is not on a network filesystem.
+func networkFilesystem(dir string) string {
+	var st unix.Statfs_t
+	err := unix.Statfs(dir, &st)
+	if err != nil {
+		return ""
+	}
+	// The type is signed on some platforms.
+	return networkFilesystems[int64(uint32(st.Type))]
+}
+
+func mmapFile(f *os.File, size int64) ([]byte, error) {
+	data, err := unix.Mmap(int(f.Fd()), 0, int(size), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, &os.PathError{Op: "mmap", Path: f.Name(), Err: err}
+	}
+	return data, nil
+}
+
+func munmap(data []byte) error {
+	return unix.Munmap(data)
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+// +build !linux
+
+package disk
+
+import (
+	"os"
+)
+
+const mmapSupported = false
+
+func networkFilesystem(dir string) string {
+	return ""
+}
+
+func mmapFile(f *os.File, size int64) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+func munmap(data []byte) error {
+	return errMmapUnsupported
+}
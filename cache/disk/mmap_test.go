@@ -0,0 +1,114 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestMmapReads(t *testing.T) {
+	if !mmapSupported {
+		t.Skip("mmap reads are not supported on this platform")
+	}
+
+	ctx := context.Background()
+
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, 100*1024*1024, WithMmapReads(),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+	if !testCache.mmapReads {
+		t.Skip("mmap reads are disabled for the filesystem of", cacheDir)
+	}
+
+	for _, blobSize := range []int64{1024, 2 * minMmapSize} {
+		data, hash := testutils.RandomDataAndHash(blobSize)
+
+		err = testCache.Put(ctx, cache.RAW, hash, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		offset := int64(len(data) / 3)
+		rc, size, err := testCache.Get(ctx, cache.RAW, hash, int64(len(data)), offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rc == nil {
+			t.Fatalf("Expected to find the %d byte blob", len(data))
+		}
+
+		_, mapped := rc.(*mmapReader)
+		if mapped != (len(data) >= minMmapSize) {
+			t.Errorf("Expected the %d byte blob to be memory mapped: %v, found %v",
+				len(data), len(data) >= minMmapSize, mapped)
+		}
+
+		read, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != int64(len(data)) || !bytes.Equal(read, data[offset:]) {
+			t.Errorf("Read the wrong data for the %d byte blob", len(data))
+		}
+
+		err = rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMmapReaderFault(t *testing.T) {
+	if !mmapSupported {
+		t.Skip("mmap reads are not supported on this platform")
+	}
+
+	name := filepath.Join(t.TempDir(), "blob")
+	err := os.WriteFile(name, make([]byte, 2*minMmapSize), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	data, err := mmapFile(f, 2*minMmapSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &mmapReader{data: data}
+	defer r.Close()
+
+	// Reading the mapping past the end of the truncated file faults,
+	// which should be returned as an error.
+	err = os.Truncate(name, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.Read(make([]byte, 1024))
+	if err == nil {
+		t.Error("Expected an error when reading a truncated file")
+	}
+
+	var buf bytes.Buffer
+	_, err = r.WriteTo(&buf)
+	if err == nil {
+		t.Error("Expected an error when writing a truncated file")
+	}
+}
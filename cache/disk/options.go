@@ -3,12 +3,16 @@ package disk
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/cluster"
 	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
 	"github.com/buchgr/bazel-remote/v2/cache/disk/zstdimpl"
-
-	"github.com/prometheus/client_golang/prometheus"
+	"github.com/buchgr/bazel-remote/v2/cache/eventstream"
+	"github.com/buchgr/bazel-remote/v2/cache/notify"
+	"github.com/buchgr/bazel-remote/v2/cache/replication"
+	"github.com/buchgr/bazel-remote/v2/utils/maintenance"
 )
 
 type Option func(*CacheConfig) error
@@ -16,6 +20,9 @@ type Option func(*CacheConfig) error
 type CacheConfig struct {
 	diskCache *diskCache        // Assumed to be non-nil.
 	metrics   *metricsDecorator // May be nil.
+
+	// The instance names to label endpoint metrics with, if any.
+	metricsInstances []string
 }
 
 func WithStorageMode(mode string) Option {
@@ -77,6 +84,480 @@ func WithProxyMaxBlobSize(maxProxyBlobSize int64) Option {
 	}
 }
 
+// WithInstanceQuotas sets the maximum disk space in bytes which the
+// entries added by requests with each of the given instance names can
+// use. See quota.go.
+func WithInstanceQuotas(quotas map[string]int64) Option {
+	return func(c *CacheConfig) error {
+		for instance, quota := range quotas {
+			if quota <= 0 {
+				return fmt.Errorf("Invalid quota for instance %q: %d", instance, quota)
+			}
+		}
+		c.diskCache.instanceQuotas = quotas
+		return nil
+	}
+}
+
+// WithMaxEntries sets the maximum number of entries of each of the given
+// kinds, by kind name ("ac", "cas" or "raw"). See entrylimit.go.
+func WithMaxEntries(limits map[string]int) Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.maxEntries = make(map[cache.EntryKind]int, len(limits))
+		for name, limit := range limits {
+			if limit <= 0 {
+				return fmt.Errorf("Invalid maximum number of %q entries: %d", name, limit)
+			}
+
+			switch name {
+			case cache.AC.String():
+				c.diskCache.maxEntries[cache.AC] = limit
+			case cache.CAS.String():
+				c.diskCache.maxEntries[cache.CAS] = limit
+			case cache.RAW.String():
+				c.diskCache.maxEntries[cache.RAW] = limit
+			default:
+				return fmt.Errorf("Invalid kind of entry for maximum number of entries: %q", name)
+			}
+		}
+		return nil
+	}
+}
+
+// WithFsyncPolicies sets the durability policy for the files written
+// for each kind of entry, by kind name ("ac", "cas" or "raw"). Kinds
+// which are not listed use FsyncFile. See fsync.go.
+func WithFsyncPolicies(policies map[string]string) Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.fsyncPolicies = make(map[cache.EntryKind]string, len(policies))
+		for name, policy := range policies {
+			if !IsValidFsyncPolicy(policy) {
+				return fmt.Errorf("Invalid fsync policy for %q entries: %q", name, policy)
+			}
+
+			switch name {
+			case cache.AC.String():
+				c.diskCache.fsyncPolicies[cache.AC] = policy
+			case cache.CAS.String():
+				c.diskCache.fsyncPolicies[cache.CAS] = policy
+			case cache.RAW.String():
+				c.diskCache.fsyncPolicies[cache.RAW] = policy
+			default:
+				return fmt.Errorf("Invalid kind of entry for fsync policy: %q", name)
+			}
+		}
+		return nil
+	}
+}
+
+// WithFsyncBatchInterval sets how long the FsyncBatch policy waits to
+// group directory syncs. If d is 0, the default of 10ms is used.
+func WithFsyncBatchInterval(d time.Duration) Option {
+	return func(c *CacheConfig) error {
+		if d < 0 {
+			return fmt.Errorf("Invalid fsync batch interval: %s", d)
+		}
+
+		if d > 0 {
+			c.diskCache.dirSyncer.interval = d
+		}
+		return nil
+	}
+}
+
+// WithMmapReads reads uncompressed blobs between 1 MiB and 50 MiB in size
+// through memory mappings. This is ignored if mmap is not supported on
+// this platform, or if the cache directory is on a network filesystem.
+// See mmap.go.
+func WithMmapReads() Option {
+	return func(c *CacheConfig) error {
+		if !mmapSupported {
+			log.Println("Memory mapped reads are not supported on this platform, ignoring mmap_reads")
+			return nil
+		}
+
+		if fs := networkFilesystem(c.diskCache.dir); fs != "" {
+			log.Printf("The cache directory is on a %s filesystem, ignoring mmap_reads", fs)
+			return nil
+		}
+
+		c.diskCache.mmapReads = true
+		return nil
+	}
+}
+
+// WithZombieRescan rescans the shard directory of an entry whose file
+// was found to be missing, see Rescan. See zombie.go.
+func WithZombieRescan() Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.zombieRescan = true
+		return nil
+	}
+}
+
+// WithLegacyReadVerification verifies the content of the given fraction
+// of the uncompressed reads of whole legacy CAS blobs, between 0 and 1,
+// and removes the blobs which are corrupt. See verify.go.
+func WithLegacyReadVerification(ratio float64) Option {
+	return func(c *CacheConfig) error {
+		if ratio < 0 || ratio > 1 {
+			return fmt.Errorf("Invalid legacy read verification ratio: %g", ratio)
+		}
+
+		c.diskCache.legacyVerifyRatio = ratio
+		return nil
+	}
+}
+
+// WithCASLeaseDuration protects the CAS blobs which FindMissingBlobs
+// reports as present from eviction for d after each hit, for clients
+// which build without the bytes. If d is 0, blobs are not leased. See
+// lease.go.
+func WithCASLeaseDuration(d time.Duration) Option {
+	return func(c *CacheConfig) error {
+		if d < 0 {
+			return fmt.Errorf("Invalid CAS lease duration: %s", d)
+		}
+
+		c.diskCache.leaseDuration = d
+		return nil
+	}
+}
+
+// WithUploadWait makes reads of items which are missing from the cache,
+// but are being uploaded, wait up to d for the upload to finish. If d is
+// 0, reads don't wait. See inflight.go.
+func WithUploadWait(d time.Duration) Option {
+	return func(c *CacheConfig) error {
+		if d < 0 {
+			return fmt.Errorf("Invalid upload wait: %s", d)
+		}
+
+		c.diskCache.uploadWait = d
+		return nil
+	}
+}
+
+// WithInvocationStats collects InvocationStats for the client tool
+// invocations which made requests within the given retention window,
+// identified by cache.InvocationID. See invocations.go.
+func WithInvocationStats(retention time.Duration) Option {
+	return func(c *CacheConfig) error {
+		if retention <= 0 {
+			return fmt.Errorf("Invalid invocation stats retention: %s", retention)
+		}
+
+		c.diskCache.invocations = newInvocationTracker(retention)
+		return nil
+	}
+}
+
+// WithInvocationTranscripts also collects InvocationTranscripts for the
+// client tool invocations, keeping at most maxEntries transcript entries
+// of all the invocations. It must follow WithInvocationStats.
+func WithInvocationTranscripts(maxEntries int) Option {
+	return func(c *CacheConfig) error {
+		if maxEntries <= 0 {
+			return fmt.Errorf("Invalid invocation transcript max entries: %d", maxEntries)
+		}
+		if c.diskCache.invocations == nil {
+			return fmt.Errorf("Invocation transcripts require invocation stats")
+		}
+
+		c.diskCache.invocations.transcriptLimit = maxEntries
+		return nil
+	}
+}
+
+// WithActivityTracking tracks the recent activity of the cache, which
+// is reported by Activity. See activity.go.
+func WithActivityTracking() Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.activity = newActivityTracker()
+		return nil
+	}
+}
+
+// WithIOScheduler limits the number of concurrent disk reads and writes
+// and proxy requests to slots. When operations are waiting for a slot,
+// interactiveWeight interactive operations are served for each batch
+// operation, see cache.Priority. An interactiveWeight of 0 means the
+// default, 4.
+func WithIOScheduler(slots int, interactiveWeight int) Option {
+	return func(c *CacheConfig) error {
+		if slots <= 0 {
+			return fmt.Errorf("Invalid number of I/O scheduler slots: %d", slots)
+		}
+		if interactiveWeight == 0 {
+			interactiveWeight = defaultInteractiveWeight
+		}
+		if interactiveWeight < 0 {
+			return fmt.Errorf("Invalid I/O scheduler interactive weight: %d", interactiveWeight)
+		}
+
+		c.diskCache.io = newIOScheduler(slots, interactiveWeight)
+		return nil
+	}
+}
+
+// WithResumableUploads keeps the data received by ResumablePut for CAS
+// blobs of at least minSize bytes when the upload is interrupted, so it
+// can be resumed. See resume.go.
+func WithResumableUploads(minSize int64) Option {
+	return func(c *CacheConfig) error {
+		if minSize <= 0 {
+			return fmt.Errorf("Invalid minimum resumable upload size: %d", minSize)
+		}
+
+		c.diskCache.uploads = newUploadJournal(c.diskCache.dir, minSize)
+		return nil
+	}
+}
+
+// WithProxyReadVerification checks that CAS blobs fetched from the proxy
+// backend match their digest before they are added to the cache or
+// served. See proxyverify.go.
+func WithProxyReadVerification() Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.verifyProxyReads = true
+		return nil
+	}
+}
+
+// WithAccessJournal records the access times of entries in a journal in
+// the cache directory, which is replayed at startup to order the entries
+// found there, rather than relying on the access times of their files.
+// See accessjournal.go.
+func WithAccessJournal() Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.accessJournal = newAccessJournal(c.diskCache.dir)
+		return nil
+	}
+}
+
+// WithProvenance records who uploaded each entry, and when, in a journal
+// in the cache directory, which can be queried with Provenance. See
+// provenance.go.
+func WithProvenance() Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.provenance = newProvenanceStore(c.diskCache.dir)
+		return nil
+	}
+}
+
+// WithMaxInflightUploadSize rejects writes which would take the total
+// size of the writes in progress over size bytes, unless no other writes
+// are in progress. Rejected writes return a cache.Error with code 429,
+// so that clients retry later.
+func WithMaxInflightUploadSize(size int64) Option {
+	return func(c *CacheConfig) error {
+		if size <= 0 {
+			return fmt.Errorf("Invalid maximum in-flight upload size: %d", size)
+		}
+
+		c.diskCache.maxInflightUploadSize = size
+		return nil
+	}
+}
+
+// WithDiskHeadroom evicts entries to keep the given number of bytes, or
+// percentage of the filesystem's size if percent is not 0, free on the
+// cache directory's filesystem, and refuses writes if that isn't enough.
+// This is only supported on Linux. See headroom.go.
+func WithDiskHeadroom(bytes int64, percent float64) Option {
+	return func(c *CacheConfig) error {
+		if bytes < 0 || percent < 0 || percent >= 100 || (bytes == 0) == (percent == 0) {
+			return fmt.Errorf("Invalid disk headroom: %d bytes, %v%%", bytes, percent)
+		}
+
+		c.diskCache.headroom = &diskHeadroom{bytes: bytes, percent: percent}
+		return nil
+	}
+}
+
+// WithFreeSpaceCheck refuses uploads which, with the uploads in progress,
+// don't fit in the free space of the cache directory's filesystem, less
+// the headroom if there is one. This is only supported on Linux. See
+// freespace.go.
+func WithFreeSpaceCheck() Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.freeSpace = &freeSpaceSample{}
+		return nil
+	}
+}
+
+// WithScanWorkers sets the number of goroutines which list the cache
+// directory and stat the files in it at startup. If n is 0, a number
+// between 4 and 16 is chosen based on the number of CPUs. Filesystems
+// with high latency, eg network filesystems, can benefit from more.
+func WithScanWorkers(n int) Option {
+	return func(c *CacheConfig) error {
+		if n < 0 {
+			return fmt.Errorf("Invalid number of scan workers: %d", n)
+		}
+
+		c.diskCache.scanWorkers = n
+		return nil
+	}
+}
+
+// WithMaxConcurrentFileRemovals limits the number of files of evicted
+// entries which are removed concurrently to n. If n is 0, the limit is
+// 5000, or 3000 on macOS.
+func WithMaxConcurrentFileRemovals(n int) Option {
+	return func(c *CacheConfig) error {
+		if n < 0 {
+			return fmt.Errorf("Invalid maximum number of concurrent file removals: %d", n)
+		}
+
+		c.diskCache.fileRemovalLimit = n
+		return nil
+	}
+}
+
+// WithBatchFileRemovals queues the files of evicted entries, and removes
+// them in batches grouped by directory. See remover.go.
+func WithBatchFileRemovals() Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.batchFileRemovals = true
+		return nil
+	}
+}
+
+// WithFileRemovalRate limits the removal of the files of evicted entries
+// to n per second. See remover.go.
+func WithFileRemovalRate(n int) Option {
+	return func(c *CacheConfig) error {
+		if n <= 0 {
+			return fmt.Errorf("Invalid file removal rate: %d", n)
+		}
+
+		c.diskCache.fileRemovalRate = n
+		return nil
+	}
+}
+
+// WithInlineBlobSize keeps the content of entries which are no larger
+// than n bytes in memory after they are first read, and serves later
+// reads from memory. See inline.go.
+func WithInlineBlobSize(n int64) Option {
+	return func(c *CacheConfig) error {
+		if n <= 0 || n > maxInlineBlobSize {
+			return fmt.Errorf("Invalid inline blob size: %d, expected a value between 1 and %d", n, maxInlineBlobSize)
+		}
+
+		c.diskCache.inlineBlobSize = n
+		return nil
+	}
+}
+
+// WithInlineMaxBytes limits the total size of the content which is kept
+// in memory with WithInlineBlobSize to n bytes. See inline.go.
+func WithInlineMaxBytes(n int64) Option {
+	return func(c *CacheConfig) error {
+		if n <= 0 {
+			return fmt.Errorf("Invalid inline max bytes: %d, expected a positive value", n)
+		}
+
+		c.diskCache.inlineMaxBytes = n
+		return nil
+	}
+}
+
+func WithReplicator(r *replication.Replicator) Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.replicator = r
+		return nil
+	}
+}
+
+// WithNotifier sends notifications about uploads, and blobs whose
+// contents don't match their digest, to n.
+func WithNotifier(n *notify.Notifier) Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.notifier = n
+		return nil
+	}
+}
+
+// WithEventStream exports records of reads, writes and evictions to e.
+func WithEventStream(e *eventstream.Exporter) Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.events = e
+		return nil
+	}
+}
+
+func WithCluster(cl *cluster.Cluster) Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.cluster = cl
+		return nil
+	}
+}
+
+func WithMaintenance(w *maintenance.Window) Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.maintenance = w
+		return nil
+	}
+}
+
+func WithReadOnly() Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.readOnly = true
+		return nil
+	}
+}
+
+// WithStandby starts the cache as a standby, which refuses writes from
+// clients until it is promoted. See standby.go.
+func WithStandby() Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.standby.Store(true)
+		return nil
+	}
+}
+
+// WithProxyRequired makes the cache refuse writes while the proxy backend
+// is unavailable, instead of accepting items which might never be
+// uploaded to it. See proxyhealth.go.
+func WithProxyRequired() Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.proxyRequired = true
+		return nil
+	}
+}
+
+// WithProxyReadsWhileLoading makes New return before the existing files
+// have been loaded, and serve reads from the proxy backend until then.
+// With writeThrough, the items which were read while loading are added to
+// the local cache afterwards. See loading.go.
+func WithProxyReadsWhileLoading(writeThrough bool) Option {
+	return func(c *CacheConfig) error {
+		c.diskCache.proxyReadsWhileLoading = true
+		c.diskCache.loadingWriteThrough = writeThrough
+		return nil
+	}
+}
+
+// WithReconciliation reconciles the cache with the proxy backend every
+// interval, downloading the items which were modified in the proxy
+// backend within downloadWindow if it is > 0. See reconcile.go.
+func WithReconciliation(interval time.Duration, downloadWindow time.Duration) Option {
+	return func(c *CacheConfig) error {
+		if interval < 0 {
+			return fmt.Errorf("Invalid reconciliation interval: %s", interval)
+		}
+		if downloadWindow < 0 {
+			return fmt.Errorf("Invalid reconciliation download window: %s", downloadWindow)
+		}
+
+		c.diskCache.reconcileInterval = interval
+		c.diskCache.reconcileDownloadWindow = downloadWindow
+		return nil
+	}
+}
+
 func WithAccessLogger(logger *log.Logger) Option {
 	return func(c *CacheConfig) error {
 		c.diskCache.accessLogger = logger
@@ -90,14 +571,19 @@ func WithEndpointMetrics() Option {
 			return fmt.Errorf("WithEndpointMetrics specified multiple times")
 		}
 
-		c.metrics = &metricsDecorator{
-			counter: prometheus.NewCounterVec(prometheus.CounterOpts{
-				Name: "bazel_remote_incoming_requests_total",
-				Help: "The number of incoming cache requests",
-			},
-				[]string{"method", "kind", "status"}),
-		}
+		c.metrics = &metricsDecorator{}
 
 		return nil
 	}
 }
+
+// WithMetricsInstanceNames adds an instance label to the endpoint
+// metrics, if WithEndpointMetrics is also specified. Requests with
+// instance names which are not listed are counted with the instance
+// label "other", to keep the number of time series bounded.
+func WithMetricsInstanceNames(names []string) Option {
+	return func(c *CacheConfig) error {
+		c.metricsInstances = names
+		return nil
+	}
+}
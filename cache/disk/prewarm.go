@@ -0,0 +1,211 @@
+package disk
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+)
+
+// Prewarming fills the local cache before the builds which need it, eg
+// after a merge to the main branch, with the action cache entries of a
+// "seed" build from the proxy backend, and the CAS blobs which they refer
+// to: output files, the trees of output directories and the files in
+// them, and stdout and stderr.
+
+// PrewarmReport reports the result of a prewarm.
+type PrewarmReport struct {
+	// The number of action cache entries which were found, locally or in
+	// the proxy backend, and the number which were not found.
+	ActionResults int `json:"action_results"`
+	Missing       int `json:"missing"`
+
+	// The number of distinct CAS blobs which the action cache entries
+	// refer to.
+	Blobs int `json:"blobs"`
+
+	// The number of entries and blobs which were missing from the local
+	// cache and downloaded from the proxy backend.
+	Downloaded int `json:"downloaded"`
+
+	// The number of downloads which failed.
+	Failed int `json:"failed"`
+}
+
+var errNoProxy = &cache.Error{
+	Code: http.StatusNotImplemented,
+	Text: "Prewarming requires a proxy backend",
+}
+
+// Prewarm adds the action cache entries with the given hashes, and the CAS
+// blobs which they refer to, from the proxy backend to the local cache.
+// Entries and blobs which are already in the local cache are not
+// downloaded again.
+func (c *diskCache) Prewarm(ctx context.Context, acHashes []string) (PrewarmReport, error) {
+	var report PrewarmReport
+
+	if c.proxy == nil {
+		return report, errNoProxy
+	}
+
+	var results, missing, downloaded, failed int64
+
+	var blobsMu sync.Mutex
+	blobs := make(map[Key]struct{})
+	addBlob := func(d *pb.Digest) {
+		key, ok := newKey(cache.CAS, d.GetHash())
+		if !ok {
+			return
+		}
+		blobsMu.Lock()
+		blobs[key] = struct{}{}
+		blobsMu.Unlock()
+	}
+
+	var g errgroup.Group
+	g.SetLimit(reconcileConcurrency)
+
+	for _, hash := range acHashes {
+		key, ok := newKey(cache.AC, hash)
+		if !ok {
+			log.Printf("Warning: invalid action cache key to prewarm: %q", hash)
+			atomic.AddInt64(&failed, 1)
+			continue
+		}
+
+		g.Go(func() error {
+			result := &pb.ActionResult{}
+			found, err := c.prewarmProto(ctx, key, &downloaded, result)
+			if err != nil {
+				log.Printf("Warning: failed to prewarm %s: %v", key, err)
+				atomic.AddInt64(&failed, 1)
+				return nil
+			}
+			if !found {
+				atomic.AddInt64(&missing, 1)
+				return nil
+			}
+			atomic.AddInt64(&results, 1)
+
+			for _, f := range result.OutputFiles {
+				if len(f.Contents) == 0 {
+					addBlob(f.Digest)
+				}
+			}
+			if result.StdoutDigest != nil && len(result.StdoutRaw) == 0 {
+				addBlob(result.StdoutDigest)
+			}
+			if result.StderrDigest != nil && len(result.StderrRaw) == 0 {
+				addBlob(result.StderrDigest)
+			}
+
+			for _, d := range result.OutputDirectories {
+				treeKey, ok := newKey(cache.CAS, d.GetTreeDigest().GetHash())
+				if !ok {
+					continue
+				}
+				addBlob(d.TreeDigest)
+
+				tree := &pb.Tree{}
+				found, err := c.prewarmProto(ctx, treeKey, &downloaded, tree)
+				if err != nil {
+					log.Printf("Warning: failed to prewarm %s: %v", treeKey, err)
+					atomic.AddInt64(&failed, 1)
+					continue
+				}
+				if !found {
+					continue
+				}
+
+				for _, f := range tree.Root.GetFiles() {
+					addBlob(f.Digest)
+				}
+				for _, child := range tree.Children {
+					for _, f := range child.GetFiles() {
+						addBlob(f.Digest)
+					}
+				}
+			}
+
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	var blobGroup errgroup.Group
+	blobGroup.SetLimit(reconcileConcurrency)
+
+	for key := range blobs {
+		key := key
+		blobGroup.Go(func() error {
+			_, err := c.prewarmEntry(ctx, key, &downloaded)
+			if err != nil {
+				log.Printf("Warning: failed to prewarm %s: %v", key, err)
+				atomic.AddInt64(&failed, 1)
+			}
+			return nil
+		})
+	}
+
+	_ = blobGroup.Wait()
+
+	report.ActionResults = int(results)
+	report.Missing = int(missing)
+	report.Blobs = len(blobs)
+	report.Downloaded = int(downloaded)
+	report.Failed = int(failed)
+
+	return report, ctx.Err()
+}
+
+// Add the entry with key to the local cache from the proxy backend, unless
+// it is already present, and increment *downloaded if it was downloaded.
+// Returns false if neither has it.
+func (c *diskCache) prewarmEntry(ctx context.Context, key Key, downloaded *int64) (bool, error) {
+	c.mu.Lock()
+	_, found := c.lru.peek(key)
+	c.mu.Unlock()
+	if found {
+		return true, nil
+	}
+
+	found, err := c.downloadFromProxy(ctx, key)
+	if found {
+		atomic.AddInt64(downloaded, 1)
+		c.counterPrewarmDownloads.Inc()
+	}
+	return found, err
+}
+
+// Like prewarmEntry, and unmarshal the entry into m.
+func (c *diskCache) prewarmProto(ctx context.Context, key Key, downloaded *int64, m proto.Message) (bool, error) {
+	found, err := c.prewarmEntry(ctx, key, downloaded)
+	if err != nil || !found {
+		return false, err
+	}
+
+	rc, _, err := c.Get(ctx, key.Kind(), key.Hash(), -1, 0)
+	if err != nil {
+		return false, err
+	}
+	if rc == nil {
+		return false, nil // Evicted in the meantime.
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return false, err
+	}
+
+	return true, proto.Unmarshal(data, m)
+}
@@ -0,0 +1,151 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/protobuf/proto"
+)
+
+// prewarmProxy is a listingProxy which reports the logical sizes of the
+// items which it serves, rather than their sizes on disk.
+type prewarmProxy struct {
+	listingProxy
+	sizes map[string]int64
+}
+
+func (p *prewarmProxy) add(data []byte, hash string, size int64) {
+	p.listingProxy.add(data, hash, time.Now())
+	p.sizes[hash] = size
+}
+
+func (p *prewarmProxy) Get(ctx context.Context, kind cache.EntryKind, hash string) (io.ReadCloser, int64, error) {
+	rc, _, err := p.listingProxy.Get(ctx, kind, hash)
+	if rc == nil {
+		return nil, -1, err
+	}
+	return rc, p.sizes[hash], err
+}
+
+func (p *prewarmProxy) Contains(ctx context.Context, kind cache.EntryKind, hash string) (bool, int64) {
+	size, found := p.sizes[hash]
+	return found, size
+}
+
+func TestPrewarm(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	p := &prewarmProxy{
+		listingProxy: listingProxy{
+			items: make(map[string]cache.ProxyItem),
+			blobs: make(map[string][]byte),
+		},
+		sizes: make(map[string]int64),
+	}
+
+	cI, err := New(cacheDir, BlockSize*10, WithProxyBackend(p), WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cI.(*diskCache)
+
+	// CAS blobs are stored in the proxy backend in the same format as in
+	// the cache, AC entries as they are.
+	add := func(data []byte, hash string) *pb.Digest {
+		p.add(compressedBlob(t, c, data, hash), hash, int64(len(data)))
+		return &pb.Digest{Hash: hash, SizeBytes: int64(len(data))}
+	}
+	marshal := func(m proto.Message) ([]byte, string) {
+		data, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash := sha256.Sum256(data)
+		return data, hex.EncodeToString(hash[:])
+	}
+
+	// An output file which is already in the local cache.
+	local, localHash := testutils.RandomDataAndHash(100)
+	err = c.Put(context.Background(), cache.CAS, localHash, int64(len(local)), bytes.NewReader(local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	localDigest := &pb.Digest{Hash: localHash, SizeBytes: int64(len(local))}
+
+	outputFile := add(testutils.RandomDataAndHash(100))
+	stdout := add(testutils.RandomDataAndHash(100))
+	treeFile := add(testutils.RandomDataAndHash(100))
+
+	tree := add(marshal(&pb.Tree{
+		Root: &pb.Directory{Files: []*pb.FileNode{{Name: "a", Digest: treeFile}}},
+	}))
+
+	ar, arHash := marshal(&pb.ActionResult{
+		OutputFiles: []*pb.OutputFile{
+			{Path: "out", Digest: outputFile},
+			{Path: "local", Digest: localDigest},
+		},
+		OutputDirectories: []*pb.OutputDirectory{{Path: "dir", TreeDigest: tree}},
+		StdoutDigest:      stdout,
+	})
+	p.add(ar, arHash, int64(len(ar)))
+
+	_, missingHash := testutils.RandomDataAndHash(100)
+
+	report, err := c.Prewarm(context.Background(), []string{arHash, missingHash, "invalid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := PrewarmReport{ActionResults: 1, Missing: 1, Blobs: 5, Downloaded: 5, Failed: 1}
+	if report != expected {
+		t.Errorf("Expected %+v, got %+v", expected, report)
+	}
+
+	for _, d := range []*pb.Digest{outputFile, stdout, treeFile, tree} {
+		key, _ := newKey(cache.CAS, d.Hash)
+		if _, found := c.lru.peek(key); !found {
+			t.Errorf("Expected %s to be downloaded", key)
+		}
+	}
+	if n := testutil.ToFloat64(c.counterPrewarmDownloads); n != 5 {
+		t.Errorf("Expected 5 downloads, got %v", n)
+	}
+
+	// Everything is in the local cache now.
+	report, err = c.Prewarm(context.Background(), []string{arHash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = PrewarmReport{ActionResults: 1, Blobs: 5}
+	if report != expected {
+		t.Errorf("Expected %+v, got %+v", expected, report)
+	}
+}
+
+func TestPrewarmWithoutProxy(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := New(cacheDir, BlockSize*10, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Prewarm(context.Background(), nil)
+	if err != errNoProxy {
+		t.Errorf("Expected errNoProxy, got %v", err)
+	}
+}
@@ -0,0 +1,398 @@
+package disk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/tempfile"
+)
+
+// With WithProvenance, a record of who uploaded each entry, and when, is
+// appended to a journal in the cache directory, so that the uploaders of
+// an entry can be looked up later, eg in supply-chain investigations.
+//
+// Each record is a line of JSON. The offsets of the records of each
+// entry are kept in memory: the first one, and the latest
+// maxProvenanceRecords-1 ones. A line which was only partially written,
+// eg after a crash, is truncated at startup. When the journal has grown
+// to twice its size after the last compaction, and at least
+// minProvenanceCompactSize, it is compacted by dropping the records of
+// the entries which are no longer in the cache, and the records which
+// are no longer kept in memory.
+
+const provenanceDirName = "provenance"
+
+const maxProvenanceRecords = 16
+
+const minProvenanceCompactSize = 64 * 1024 * 1024
+
+// ProvenanceRecord describes an upload of a cache entry.
+type ProvenanceRecord struct {
+	Kind string `json:"kind"` // "ac", "cas" or "raw".
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+
+	// The identity of the client, see cache.WithUploader.
+	Uploader string `json:"uploader,omitempty"`
+
+	// The client tool invocation, see cache.WithInvocationID.
+	InvocationID string `json:"invocation_id,omitempty"`
+
+	Instance string `json:"instance,omitempty"`
+	Time     int64  `json:"time"` // Unix time.
+}
+
+type provenanceStore struct {
+	path string
+
+	mu          sync.Mutex
+	f           *os.File // Opened for reading and appending.
+	size        int64    // The size of the journal.
+	compactSize int64    // The size of the journal after the last compaction.
+	compacting  bool
+	offsets     map[Key][]int64
+}
+
+func newProvenanceStore(cacheDir string) *provenanceStore {
+	return &provenanceStore{
+		path:    filepath.Join(cacheDir, provenanceDirName, journalName),
+		offsets: make(map[Key][]int64),
+	}
+}
+
+// Add the record at offset to the records of key in index, dropping the
+// second oldest one if there are too many.
+func indexProvenanceRecord(index map[Key][]int64, key Key, offset int64) {
+	offsets := index[key]
+	if len(offsets) == maxProvenanceRecords {
+		copy(offsets[1:], offsets[2:])
+		offsets = offsets[:len(offsets)-1]
+	}
+	index[key] = append(offsets, offset)
+}
+
+// Calls fn with the offset, contents and key of each complete record
+// read from r, which starts at offset base in the journal, and returns
+// the offset of the end of the last one. A record which is incomplete
+// or invalid, and anything after it, is ignored.
+func readProvenanceRecords(r io.Reader, base int64, fn func(offset int64, line []byte, key Key)) int64 {
+	br := bufio.NewReader(r)
+	offset := base
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			return offset
+		}
+
+		var rec ProvenanceRecord
+		err = json.Unmarshal(line, &rec)
+		if err != nil {
+			return offset
+		}
+		key, ok := newKey(kindFromString(rec.Kind), rec.Hash)
+		if !ok {
+			return offset
+		}
+
+		fn(offset, line, key)
+		offset += int64(len(line))
+	}
+}
+
+// Returns the cache.EntryKind whose String method returns s.
+func kindFromString(s string) cache.EntryKind {
+	switch s {
+	case "ac":
+		return cache.AC
+	case "cas":
+		return cache.CAS
+	}
+	return cache.RAW
+}
+
+// Open the journal and index its records, after truncating a partially
+// written record at its end. If readOnly is true, the journal is only
+// opened for reading.
+func (p *provenanceStore) open(readOnly bool) error {
+	err := os.MkdirAll(filepath.Dir(p.path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	flag := os.O_CREATE | os.O_RDWR | os.O_APPEND
+	if readOnly {
+		flag = os.O_CREATE | os.O_RDONLY
+	}
+	f, err := os.OpenFile(p.path, flag, 0644)
+	if err != nil {
+		return err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	valid := readProvenanceRecords(f, 0, func(offset int64, line []byte, key Key) {
+		indexProvenanceRecord(p.offsets, key, offset)
+	})
+
+	if valid < fi.Size() && !readOnly {
+		log.Printf("Truncating the provenance journal from %d to %d bytes, after an incomplete write",
+			fi.Size(), valid)
+		err = f.Truncate(valid)
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	p.f = f
+	p.size = valid
+	p.compactSize = valid
+
+	log.Printf("Loaded the provenance records of %d entries", len(p.offsets))
+
+	return nil
+}
+
+// Append rec to the journal, and return true if it should be compacted.
+func (p *provenanceStore) record(key Key, rec ProvenanceRecord) (bool, error) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return false, err
+	}
+	line = append(line, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err = p.f.Write(line)
+	if err != nil {
+		// Don't leave a partial record, which would hide the records
+		// after it at startup.
+		_ = p.f.Truncate(p.size)
+		return false, err
+	}
+
+	indexProvenanceRecord(p.offsets, key, p.size)
+	p.size += int64(len(line))
+
+	if p.compacting || p.size < minProvenanceCompactSize || p.size < 2*p.compactSize {
+		return false, nil
+	}
+	p.compacting = true
+	return true, nil
+}
+
+// Returns the records of key, oldest first.
+func (p *provenanceStore) lookup(key Key) ([]ProvenanceRecord, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	records := []ProvenanceRecord{}
+	for _, offset := range p.offsets[key] {
+		br := bufio.NewReader(io.NewSectionReader(p.f, offset, p.size-offset))
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		var rec ProvenanceRecord
+		err = json.Unmarshal(line, &rec)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// Record the upload of an entry by the client which made the request
+// with ctx, if provenance is recorded.
+func (c *diskCache) recordProvenance(ctx context.Context, kind cache.EntryKind, hash string, size int64) {
+	if c.provenance == nil {
+		return
+	}
+
+	key, ok := newKey(kind, hash)
+	if !ok {
+		return
+	}
+
+	compact, err := c.provenance.record(key, ProvenanceRecord{
+		Kind:         kind.String(),
+		Hash:         hash,
+		Size:         size,
+		Uploader:     cache.Uploader(ctx),
+		InvocationID: cache.InvocationID(ctx),
+		Instance:     cache.InstanceName(ctx),
+		Time:         time.Now().Unix(),
+	})
+	if err != nil {
+		log.Printf("Failed to record the provenance of %s: %v", key, err)
+		return
+	}
+
+	if compact {
+		go func() {
+			err := c.compactProvenance()
+			if err != nil {
+				log.Printf("Failed to compact the provenance journal: %v", err)
+			}
+		}()
+	}
+}
+
+// Replace the journal with one which only has the records of the entries
+// in the index which are kept in memory. Records which are appended
+// meanwhile are copied to the new journal at the end.
+func (c *diskCache) compactProvenance() error {
+	p := c.provenance
+
+	p.mu.Lock()
+	end := p.size
+	keys := make([]Key, 0, len(p.offsets))
+	for key := range p.offsets {
+		keys = append(keys, key)
+	}
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		p.compacting = false
+		p.mu.Unlock()
+	}()
+
+	c.mu.Lock()
+	live := keys[:0]
+	for _, key := range keys {
+		if _, found := c.lru.peek(key); found {
+			live = append(live, key)
+		}
+	}
+	c.mu.Unlock()
+
+	// Only p.f is replaced while compacting, and the offsets of these
+	// records, which are before end, don't change.
+	p.mu.Lock()
+	var keep []int64
+	for _, key := range live {
+		keep = append(keep, p.offsets[key]...)
+	}
+	f := p.f
+	p.mu.Unlock()
+	sort.Slice(keep, func(i, j int) bool { return keep[i] < keep[j] })
+
+	tmpName := p.path + tempfile.Suffix
+	tmp, err := os.Create(tmpName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tmp != nil {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	offsets := make(map[Key][]int64, len(live))
+	bw := bufio.NewWriter(tmp)
+	var size int64
+	var writeErr error
+	copyRecord := func(offset int64, line []byte, key Key) {
+		if writeErr != nil {
+			return
+		}
+		_, writeErr = bw.Write(line)
+		indexProvenanceRecord(offsets, key, size)
+		size += int64(len(line))
+	}
+
+	readProvenanceRecords(io.NewSectionReader(f, 0, end), 0, func(offset int64, line []byte, key Key) {
+		for len(keep) > 0 && keep[0] < offset {
+			keep = keep[1:]
+		}
+		if len(keep) > 0 && keep[0] == offset {
+			copyRecord(offset, line, key)
+		}
+	})
+	if writeErr != nil {
+		return writeErr
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	readProvenanceRecords(io.NewSectionReader(p.f, end, p.size-end), end, copyRecord)
+	if writeErr == nil {
+		writeErr = bw.Flush()
+	}
+	if writeErr == nil {
+		writeErr = tmp.Sync()
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+
+	err = os.Rename(tmpName, p.path)
+	if err != nil {
+		return err
+	}
+
+	// The new journal is open for writing, and must be reopened for
+	// appending.
+	tmp.Close()
+	tmp = nil
+	newF, err := os.OpenFile(p.path, os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	p.f.Close()
+	p.f = newF
+	p.offsets = offsets
+	p.size = size
+	p.compactSize = size
+
+	log.Printf("Compacted the provenance journal to %d bytes, with the records of %d entries",
+		size, len(offsets))
+
+	return nil
+}
+
+// Provenance returns the recorded uploads of the entry of the given
+// kind and hash, oldest first: the first one, and the latest ones. See
+// WithProvenance. Returns a *cache.Error with code 404 if provenance is
+// not recorded.
+func (c *diskCache) Provenance(kind cache.EntryKind, hash string) ([]ProvenanceRecord, error) {
+	if c.provenance == nil {
+		return nil, &cache.Error{
+			Code: http.StatusNotFound,
+			Text: "Provenance is not recorded",
+		}
+	}
+
+	key, ok := newKey(kind, hash)
+	if !ok {
+		return nil, &cache.Error{
+			Code: http.StatusBadRequest,
+			Text: fmt.Sprintf("Invalid hash: %q", hash),
+		}
+	}
+
+	return c.provenance.lookup(key)
+}
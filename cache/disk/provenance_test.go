@@ -0,0 +1,142 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestProvenance(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	open := func() *diskCache {
+		c, err := New(cacheDir, BlockSize*10, WithProvenance(), WithAccessLogger(testutils.NewSilentLogger()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.(*diskCache)
+	}
+
+	c := open()
+
+	data, hash := testutils.RandomDataAndHash(100)
+	put := func(uploader string) {
+		ctx := cache.WithUploader(context.Background(), uploader)
+		ctx = cache.WithInvocationID(ctx, "build-"+uploader)
+		err := c.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	put("user:alice")
+	put("user:bob")
+
+	records, err := c.Provenance(cache.CAS, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Uploader != "user:alice" || records[1].Uploader != "user:bob" ||
+		records[1].InvocationID != "build-user:bob" || records[1].Size != int64(len(data)) {
+		t.Fatalf("Expected uploads by alice and bob, got %+v", records)
+	}
+
+	records, err = c.Provenance(cache.AC, hash)
+	if err != nil || len(records) != 0 {
+		t.Errorf("Expected no AC records, got %+v, %v", records, err)
+	}
+
+	_, err = c.Provenance(cache.CAS, "invalid")
+	if cerr, ok := err.(*cache.Error); !ok || cerr.Code != 400 {
+		t.Errorf("Expected a bad request error for an invalid hash, got %v", err)
+	}
+
+	// Only the first and the latest records are kept.
+	for i := 0; i < maxProvenanceRecords; i++ {
+		put(fmt.Sprint(i))
+	}
+	records, _ = c.Provenance(cache.CAS, hash)
+	if len(records) != maxProvenanceRecords || records[0].Uploader != "user:alice" ||
+		records[1].Uploader != "1" || records[len(records)-1].Uploader != fmt.Sprint(maxProvenanceRecords-1) {
+		t.Fatalf("Expected the first and the latest records, got %+v", records)
+	}
+
+	// A record which was partially written is dropped at startup.
+	f, err := os.OpenFile(c.provenance.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteString(`{"kind":"cas","hash":"`)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := c.provenance.size
+
+	c = open()
+	if c.provenance.size != size {
+		t.Errorf("Expected the journal to be truncated to %d bytes, got %d", size, c.provenance.size)
+	}
+	reopened, err := c.Provenance(cache.CAS, hash)
+	if err != nil || fmt.Sprint(reopened) != fmt.Sprint(records) {
+		t.Errorf("Expected %+v after reopening, got %+v, %v", records, reopened, err)
+	}
+
+	// Compaction drops the records which are not kept in memory, and
+	// the records of entries which are no longer in the cache.
+	otherData, otherHash := testutils.RandomDataAndHash(100)
+	err = c.Put(context.Background(), cache.CAS, otherHash, int64(len(otherData)), bytes.NewReader(otherData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _ := newKey(cache.CAS, otherHash)
+	c.mu.Lock()
+	c.lru.Remove(otherKey)
+	c.mu.Unlock()
+
+	err = c.compactProvenance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.provenance.size >= size {
+		t.Errorf("Expected the journal to be smaller than %d bytes, got %d", size, c.provenance.size)
+	}
+	compacted, err := c.Provenance(cache.CAS, hash)
+	if err != nil || fmt.Sprint(compacted) != fmt.Sprint(records) {
+		t.Errorf("Expected %+v after compacting, got %+v, %v", records, compacted, err)
+	}
+	otherRecords, _ := c.Provenance(cache.CAS, otherHash)
+	if len(otherRecords) != 0 {
+		t.Errorf("Expected no records of the removed entry, got %+v", otherRecords)
+	}
+
+	// Records are still appended after compacting.
+	put("user:carol")
+	records, _ = c.Provenance(cache.CAS, hash)
+	if records[len(records)-1].Uploader != "user:carol" {
+		t.Errorf("Expected the latest record to be carol's, got %+v", records[len(records)-1])
+	}
+
+	c = open()
+	records, _ = c.Provenance(cache.CAS, hash)
+	if len(records) != maxProvenanceRecords || records[len(records)-1].Uploader != "user:carol" {
+		t.Errorf("Expected carol's record to be kept after reopening, got %+v", records)
+	}
+
+	// Without WithProvenance, no records are kept.
+	withoutDir := tempDir(t)
+	defer os.RemoveAll(withoutDir)
+	without, err := New(withoutDir, BlockSize*10, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = without.Provenance(cache.CAS, hash)
+	if cerr, ok := err.(*cache.Error); !ok || cerr.Code != 404 {
+		t.Errorf("Expected a not found error without provenance, got %v", err)
+	}
+}
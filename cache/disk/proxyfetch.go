@@ -0,0 +1,281 @@
+package disk
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+	"github.com/buchgr/bazel-remote/v2/utils/bufpool"
+	"github.com/buchgr/bazel-remote/v2/utils/sharedfile"
+)
+
+// Items which are missing from the cache are fetched from the proxy
+// backend and written to a tempfile, which is committed to the cache
+// once the whole item has been downloaded. When possible the item is
+// streamed to the client while it is being downloaded, otherwise it is
+// served from the tempfile after the download.
+
+// The state of an item which is being fetched from the proxy backend.
+type proxyFetch struct {
+	c      *diskCache
+	ctx    context.Context
+	key    Key
+	finish func(found bool, missing bool) // See beginFetch.
+
+	// The number of bytes reserved in the LRU for the item, if > 0.
+	reservedSize int64
+
+	size    int64 // The logical size of the item.
+	legacy  bool
+	random  string
+	backend io.ReadCloser
+	tf      *os.File  // The tempfile we write to.
+	src     io.Reader // Reads from backend, and writes to tf.
+}
+
+// Fetch the item identified by key from the proxy backend, and return a
+// reader for it from offset. If size > 0 then that many bytes have been
+// reserved in the LRU. finish is called when the fetch is complete.
+func (c *diskCache) fetchFromProxy(ctx context.Context, key Key, kind cache.EntryKind, hash string, size int64, offset int64, zstd bool, finish func(found bool, missing bool)) (io.ReadCloser, int64, error) {
+	f := &proxyFetch{
+		c:            c,
+		ctx:          ctx,
+		key:          key,
+		finish:       finish,
+		reservedSize: size,
+	}
+
+	err := c.io.acquire(ctx)
+	if err != nil {
+		f.abort(false)
+		return nil, -1, internalErr(err)
+	}
+	r, foundSize, err := c.proxy.Get(ctx, kind, hash)
+	c.io.release()
+	if err != nil {
+		if r != nil {
+			r.Close()
+		}
+		f.abort(false)
+		return nil, -1, internalErr(err)
+	}
+	if r == nil {
+		f.abort(true)
+		return nil, -1, nil
+	}
+	if foundSize > c.maxProxyBlobSize || isSizeMismatch(size, foundSize) || foundSize < 0 {
+		r.Close()
+		f.abort(false)
+		return nil, -1, nil
+	}
+
+	f.size = foundSize
+	f.legacy = kind == cache.CAS && c.storageMode == casblob.Identity
+
+	blobPathBase := filepath.Join(c.dir, c.FileLocationBase(kind, f.legacy, hash, foundSize))
+	tf, random, err := tfc.CreateTemp(blobPathBase, f.legacy)
+	if err != nil {
+		r.Close()
+		c.recordWrite(err)
+		f.abort(false)
+		return nil, -1, internalErr(err)
+	}
+
+	f.random = random
+	f.backend = r
+	f.tf = tf
+	f.src = io.TeeReader(r, tf)
+
+	uncompressedOnDisk := (kind != cache.CAS) || (c.storageMode == casblob.Identity)
+
+	// Partial reads and compressed reads of uncompressed items are rare
+	// enough that we don't stream them. Items which are verified must be
+	// downloaded first, see proxyverify.go.
+	if offset == 0 && !(uncompressedOnDisk && zstd) && !c.verifyProxyRead(kind) {
+		var rc io.ReadCloser
+		if uncompressedOnDisk {
+			rc = io.NopCloser(f.src)
+		} else if zstd {
+			rc, err = casblob.GetZstdStreamReadCloser(c.zstd, f.src, foundSize)
+		} else {
+			rc, err = casblob.GetUncompressedStreamReadCloser(c.zstd, f.src, foundSize)
+		}
+		if err == nil {
+			cache.RecordLookup(ctx, cache.SourceProxy, storedCompression(kind, f.legacy), foundSize)
+			return &proxyStream{ReadCloser: rc, f: f}, foundSize, nil
+		}
+
+		// Download the rest of the item, and try to read it from disk.
+		log.Printf("Unable to stream %s from the proxy backend: %v", key, err)
+	}
+
+	rc, err := f.downloadAndOpen(offset, zstd, uncompressedOnDisk)
+	if err != nil {
+		return nil, -1, err
+	}
+	if rc == nil {
+		return nil, -1, nil // The item was corrupt.
+	}
+
+	cache.RecordLookup(ctx, cache.SourceProxy, storedCompression(kind, f.legacy), foundSize-offset)
+	return rc, foundSize, nil
+}
+
+// Give up on the fetch before a tempfile was created.
+func (f *proxyFetch) abort(missing bool) {
+	f.unreserve()
+	f.finish(false, missing)
+}
+
+func (f *proxyFetch) unreserve() {
+	if f.reservedSize <= 0 {
+		return
+	}
+
+	_ = f.c.unreserve(f.reservedSize)
+	f.reservedSize = 0
+}
+
+// Finish writing the item to the tempfile, and return its size on disk.
+func (f *proxyFetch) download() (int64, error) {
+	_, err := bufpool.Copy(io.Discard, f.src)
+	f.backend.Close()
+
+	var sizeOnDisk int64
+	if err == nil {
+		sizeOnDisk, err = f.tf.Seek(0, io.SeekCurrent)
+	}
+	if err == nil {
+		err = f.c.syncFile(f.key.Kind(), f.tf)
+	}
+
+	closeErr := f.tf.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		f.c.recordWrite(err)
+		return -1, err
+	}
+
+	return sizeOnDisk, nil
+}
+
+// Add the downloaded item to the cache if err is nil, otherwise remove
+// the tempfile. Returns an error if the item could not be committed.
+func (f *proxyFetch) done(sizeOnDisk int64, err error) error {
+	blobFile := f.tf.Name()
+
+	removeTempfile := true
+	if err == nil {
+		blobFile, err = f.c.renameTempfile(f.key.Kind(), blobFile)
+		if err != nil {
+			f.c.recordWrite(err)
+		}
+	}
+	if err == nil {
+		var unreserve bool
+		unreserve, removeTempfile, err = f.c.commit(f.ctx, f.key, f.legacy, blobFile, f.reservedSize, f.size, sizeOnDisk, f.random)
+		if !unreserve {
+			f.reservedSize = 0
+		}
+	}
+
+	// No lock required to remove stray tempfiles.
+	if removeTempfile {
+		os.Remove(blobFile)
+	}
+
+	f.unreserve()
+	f.finish(err == nil, false)
+
+	return err
+}
+
+// Download the whole item, commit it and return a reader for it, or nil
+// if it failed verification.
+func (f *proxyFetch) downloadAndOpen(offset int64, zstd bool, uncompressedOnDisk bool) (io.ReadCloser, error) {
+	sizeOnDisk, err := f.download()
+	if err != nil {
+		f.done(-1, err)
+		return nil, internalErr(err)
+	}
+
+	if f.c.verifyProxyRead(f.key.Kind()) {
+		err = f.verify()
+		if err == errCorruptProxyBlob {
+			f.done(-1, err)
+			return nil, nil
+		}
+		if err != nil {
+			f.done(-1, err)
+			return nil, internalErr(err)
+		}
+	}
+
+	// Open the file before committing it, so it can't be evicted first.
+	rcf, err := sharedfile.Open(f.tf.Name())
+	if err != nil {
+		f.done(-1, err)
+		return nil, internalErr(err)
+	}
+
+	var rc io.ReadCloser
+	if uncompressedOnDisk {
+		if offset > 0 {
+			_, err = rcf.Seek(offset, io.SeekStart)
+		}
+
+		if err != nil {
+			rcf.Close()
+		} else if zstd {
+			rc, err = casblob.GetLegacyZstdReadCloser(f.c.zstd, rcf)
+		} else {
+			rc = rcf
+		}
+	} else { // Compressed CAS blob.
+		if zstd {
+			rc, err = casblob.GetZstdReadCloser(f.c.zstd, rcf, f.size, offset)
+		} else {
+			rc, err = casblob.GetUncompressedReadCloser(f.c.zstd, rcf, f.size, offset)
+		}
+	}
+	if err != nil {
+		f.done(-1, err)
+		return nil, internalErr(err)
+	}
+
+	err = f.done(sizeOnDisk, nil)
+	if err != nil {
+		rc.Close()
+		return nil, internalErr(err)
+	}
+
+	return rc, nil
+}
+
+// A proxyStream provides an item to the client while it is downloaded
+// from the proxy backend. When it is closed the rest of the item is
+// downloaded, if the client didn't read all of it, and committed to the
+// cache.
+type proxyStream struct {
+	io.ReadCloser
+	f      *proxyFetch
+	closed bool
+}
+
+func (s *proxyStream) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	s.ReadCloser.Close()
+
+	sizeOnDisk, err := s.f.download()
+	return s.f.done(sizeOnDisk, err)
+}
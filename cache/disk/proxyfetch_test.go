@@ -0,0 +1,247 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+// pipeProxy is a cache.Proxy which serves a single item, whose data is
+// written to the returned pipe by the test.
+type pipeProxy struct {
+	hash string
+	size int64
+	pr   *io.PipeReader
+}
+
+func (p *pipeProxy) Put(ctx context.Context, kind cache.EntryKind, hash string, logicalSize int64, sizeOnDisk int64, rc io.ReadCloser) {
+	rc.Close()
+}
+
+func (p *pipeProxy) Get(ctx context.Context, kind cache.EntryKind, hash string) (io.ReadCloser, int64, error) {
+	if hash != p.hash {
+		return nil, -1, nil
+	}
+	return p.pr, p.size, nil
+}
+
+func (p *pipeProxy) Contains(ctx context.Context, kind cache.EntryKind, hash string) (bool, int64) {
+	return hash == p.hash, p.size
+}
+
+func newPipeProxyCache(t *testing.T, hash string, size int64) (*diskCache, *io.PipeWriter) {
+	cacheDir := tempDir(t)
+	t.Cleanup(func() { os.RemoveAll(cacheDir) })
+
+	pr, pw := io.Pipe()
+	p := &pipeProxy{hash: hash, size: size, pr: pr}
+
+	c, err := New(cacheDir, 10*1024*1024, WithProxyBackend(p), WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.(*diskCache), pw
+}
+
+// Return data in the format it is stored in by the cache.
+func compressedBlob(t *testing.T, c *diskCache, data []byte, hash string) []byte {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	f, err := os.CreateTemp(dir, "blob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = casblob.WriteAndClose(c.zstd, bytes.NewReader(data), f, casblob.Zstandard, hash, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blob
+}
+
+func TestProxyStreaming(t *testing.T) {
+	data, hash := testutils.RandomDataAndHash(3 * 1024 * 1024)
+
+	testCases := []struct {
+		name string
+		kind cache.EntryKind
+		zstd bool
+	}{
+		{"raw", cache.RAW, false},
+		{"cas", cache.CAS, false},
+		{"cas zstd", cache.CAS, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, pw := newPipeProxyCache(t, hash, int64(len(data)))
+
+			blob := data
+			if tc.kind == cache.CAS {
+				blob = compressedBlob(t, c, data, hash)
+			}
+
+			// Send the first half of the item, and close the pipe
+			// after sending the rest.
+			go func() {
+				_, err := pw.Write(blob[:len(blob)/2])
+				if err != nil {
+					return
+				}
+				_, err = pw.Write(blob[len(blob)/2:])
+				pw.CloseWithError(err)
+			}()
+
+			var rc io.ReadCloser
+			var size int64
+			var err error
+			if tc.zstd {
+				rc, size, err = c.GetZstd(context.Background(), hash, int64(len(data)), 0)
+			} else {
+				rc, size, err = c.Get(context.Background(), tc.kind, hash, int64(len(data)), 0)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rc == nil {
+				t.Fatal("Expected the item to be found")
+			}
+			if size != int64(len(data)) {
+				t.Errorf("Expected size %d, got %d", len(data), size)
+			}
+
+			found, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.zstd {
+				found, err = c.zstd.DecodeAll(found)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !bytes.Equal(found, data) {
+				t.Error("Unexpected data from the proxy stream")
+			}
+
+			// The item is only added to the cache once it is closed.
+			if c.lru.Len() != 0 {
+				t.Errorf("Expected an empty cache, found %d items", c.lru.Len())
+			}
+			err = rc.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.lru.Len() != 1 {
+				t.Errorf("Expected one item in the cache, found %d items", c.lru.Len())
+			}
+		})
+	}
+}
+
+func TestProxyStreamingFirstBytes(t *testing.T) {
+	data, hash := testutils.RandomDataAndHash(1024)
+	c, pw := newPipeProxyCache(t, hash, int64(len(data)))
+
+	// Send the first part of the item, but not the rest yet.
+	go pw.Write(data[:100])
+
+	ctx, lookup := cache.WithLookupResult(context.Background())
+	rc, _, err := c.Get(ctx, cache.RAW, hash, int64(len(data)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc == nil {
+		t.Fatal("Expected the item to be found")
+	}
+	expected := cache.LookupResult{Source: cache.SourceProxy, StoredCompression: "identity", ServedSize: int64(len(data))}
+	if *lookup != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *lookup)
+	}
+
+	// The client can read before the download finishes.
+	first := make([]byte, 100)
+	_, err = io.ReadFull(rc, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, data[:100]) {
+		t.Error("Unexpected data at the start of the stream")
+	}
+
+	// Closing the stream early still downloads and commits the item.
+	go func() {
+		_, err := pw.Write(data[100:])
+		pw.CloseWithError(err)
+	}()
+	err = rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, lookup = cache.WithLookupResult(context.Background())
+	rc, _, err = c.Get(ctx, cache.RAW, hash, int64(len(data)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc == nil {
+		t.Fatal("Expected the item to be in the cache")
+	}
+	if lookup.Source != cache.SourceLocal {
+		t.Errorf("Expected a local hit, got %+v", *lookup)
+	}
+	defer rc.Close()
+	found, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(found, data) {
+		t.Error("Unexpected data in the cache")
+	}
+}
+
+func TestProxyStreamingFailure(t *testing.T) {
+	data, hash := testutils.RandomDataAndHash(1024)
+	c, pw := newPipeProxyCache(t, hash, int64(len(data)))
+
+	go func() {
+		pw.Write(data[:100])
+		pw.CloseWithError(io.ErrUnexpectedEOF)
+	}()
+
+	rc, _, err := c.Get(context.Background(), cache.RAW, hash, int64(len(data)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc == nil {
+		t.Fatal("Expected the item to be found")
+	}
+
+	_, err = io.ReadAll(rc)
+	if err == nil {
+		t.Error("Expected the interrupted download to fail")
+	}
+	if rc.Close() == nil {
+		t.Error("Expected the interrupted download not to be committed")
+	}
+
+	if c.lru.Len() != 0 {
+		t.Errorf("Expected an empty cache, found %d items", c.lru.Len())
+	}
+	if c.lru.currentSize != 0 || c.lru.reservedSize != 0 {
+		t.Errorf("Expected no space to be used, found %d bytes (%d reserved)",
+			c.lru.currentSize, c.lru.reservedSize)
+	}
+}
@@ -0,0 +1,235 @@
+package disk
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// The backends of the proxy backend, see cache.ProxyBackends, are checked
+// periodically, and their health is reported by ProxyBackends, eg on the
+// status page. With the proxy_required setting, the first check happens
+// at startup, and writes are refused while any backend is unavailable,
+// so that write-through deployments don't silently accept items which
+// never reach the backend. Reads are still served.
+//
+// Operators can disable a backend with SetProxyBackendEnabled, eg during
+// bucket maintenance, and enable it again later. Requests which would use
+// a disabled backend behave as if there was no proxy backend. With
+// proxy_required, a disabled backend counts as unavailable.
+
+// How often to check if the proxy backend is available.
+const defaultProxyProbeInterval = 10 * time.Second
+
+// How long to wait for the proxy backend to respond to a check.
+const proxyProbeTimeout = 5 * time.Second
+
+var errProxyUnavailable = &cache.Error{
+	Code: http.StatusServiceUnavailable,
+	Text: "Refusing writes while the proxy backend is unavailable (proxy_required is set)",
+}
+
+var errProxyDisabled = &cache.Error{
+	Code: http.StatusServiceUnavailable,
+	Text: "The proxy backend is disabled",
+}
+
+// ProxyBackendStatus describes a backend of the proxy backend.
+type ProxyBackendStatus struct {
+	Name      string
+	Enabled   bool
+	Healthy   bool
+	LastCheck int64  // Unix time, or 0 if it hasn't been checked yet.
+	LastError string `json:",omitempty"`
+}
+
+type proxyBackend struct {
+	proxy  cache.Proxy
+	status ProxyBackendStatus
+}
+
+// Set up the tracking of the backends of the proxy backend.
+func (c *diskCache) initProxyBackends() {
+	c.proxyBackends = make(map[string]*proxyBackend)
+	for name, p := range cache.ProxyBackends(c.proxy) {
+		c.proxyBackends[name] = &proxyBackend{
+			proxy:  p,
+			status: ProxyBackendStatus{Name: name, Enabled: true, Healthy: true},
+		}
+		c.gaugeProxyBackendEnabled.WithLabelValues(name).Set(1)
+	}
+}
+
+// Returns true if writes must be refused because the proxy backend is
+// required but unavailable.
+func (c *diskCache) refuseWrites() bool {
+	if !c.proxyRequired || c.proxyHealthy.Load() {
+		return false
+	}
+
+	c.counterRefusedWrites.Inc()
+	return true
+}
+
+// Returns true if requests with ctx must not use the proxy backend,
+// because the backend which handles them is disabled.
+func (c *diskCache) proxyDisabled(ctx context.Context) bool {
+	if c.numDisabledBackends.Load() == 0 {
+		return false
+	}
+
+	name := cache.ProxyBackendName(ctx, c.proxy)
+
+	c.proxyBackendsMu.Lock()
+	defer c.proxyBackendsMu.Unlock()
+
+	b, found := c.proxyBackends[name]
+	return found && !b.status.Enabled
+}
+
+// ProxyBackends returns the status of each backend of the proxy backend,
+// sorted by name, or nil if there is no proxy backend.
+func (c *diskCache) ProxyBackends() []ProxyBackendStatus {
+	c.proxyBackendsMu.Lock()
+	defer c.proxyBackendsMu.Unlock()
+
+	if len(c.proxyBackends) == 0 {
+		return nil
+	}
+
+	statuses := make([]ProxyBackendStatus, 0, len(c.proxyBackends))
+	for _, b := range c.proxyBackends {
+		statuses = append(statuses, b.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+}
+
+// SetProxyBackendEnabled enables or disables the named backend of the
+// proxy backend. Returns an error with status 404 if there is no such
+// backend.
+func (c *diskCache) SetProxyBackendEnabled(name string, enabled bool) error {
+	c.proxyBackendsMu.Lock()
+	b, found := c.proxyBackends[name]
+	if !found {
+		c.proxyBackendsMu.Unlock()
+		return &cache.Error{
+			Code: http.StatusNotFound,
+			Text: fmt.Sprintf("There is no proxy backend named %q", name),
+		}
+	}
+
+	changed := b.status.Enabled != enabled
+	b.status.Enabled = enabled
+	if changed && enabled {
+		c.numDisabledBackends.Add(-1)
+	} else if changed {
+		c.numDisabledBackends.Add(1)
+	}
+	c.proxyBackendsMu.Unlock()
+
+	if !changed {
+		return nil
+	}
+
+	if enabled {
+		c.gaugeProxyBackendEnabled.WithLabelValues(name).Set(1)
+		log.Printf("Enabled the %s proxy backend", name)
+	} else {
+		c.gaugeProxyBackendEnabled.WithLabelValues(name).Set(0)
+		log.Printf("Disabled the %s proxy backend", name)
+	}
+
+	c.updateProxyHealth(nil)
+
+	return nil
+}
+
+// Check if the backends of the proxy backend are available, and record
+// the results.
+func (c *diskCache) checkProxyHealth() {
+	ctx, cancel := context.WithTimeout(context.Background(), proxyProbeTimeout)
+	defer cancel()
+
+	c.proxyBackendsMu.Lock()
+	backends := make(map[string]cache.Proxy, len(c.proxyBackends))
+	for name, b := range c.proxyBackends {
+		backends[name] = b.proxy
+	}
+	c.proxyBackendsMu.Unlock()
+
+	errs := make(map[string]error, len(backends))
+	for name, p := range backends {
+		errs[name] = cache.CheckProxyHealth(ctx, p)
+	}
+
+	c.updateProxyHealth(errs)
+}
+
+// Record the results of health checks, by backend name, and update
+// whether the proxy backend as a whole is available.
+func (c *diskCache) updateProxyHealth(errs map[string]error) {
+	now := time.Now().Unix()
+	healthy := true
+	var reason error
+
+	c.proxyBackendsMu.Lock()
+	for name, b := range c.proxyBackends {
+		if err, checked := errs[name]; checked {
+			if b.status.Healthy != (err == nil) {
+				if err == nil {
+					log.Printf("The %s proxy backend is available again", name)
+				} else {
+					log.Printf("The %s proxy backend is unavailable: %v", name, err)
+				}
+			}
+
+			b.status.Healthy = err == nil
+			b.status.LastCheck = now
+			b.status.LastError = ""
+			if err != nil {
+				b.status.LastError = err.Error()
+			}
+		}
+
+		if !b.status.Healthy && reason == nil {
+			reason = fmt.Errorf("the %s proxy backend is unavailable: %s", name, b.status.LastError)
+		} else if !b.status.Enabled && reason == nil {
+			reason = fmt.Errorf("the %s proxy backend is disabled", name)
+		}
+		healthy = healthy && b.status.Healthy && b.status.Enabled
+	}
+	c.proxyBackendsMu.Unlock()
+
+	if c.proxyHealthy.Swap(healthy) != healthy && c.proxyRequired {
+		if healthy {
+			log.Println("The proxy backend is available again, accepting writes")
+		} else {
+			log.Printf("Refusing writes, %v", reason)
+		}
+	}
+
+	if healthy {
+		c.gaugeProxyHealthy.Set(1)
+	} else {
+		c.gaugeProxyHealthy.Set(0)
+	}
+}
+
+// Check the proxy backend every c.proxyProbeInterval.
+func (c *diskCache) monitorProxyHealth() {
+	ticker := time.NewTicker(c.proxyProbeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		c.checkProxyHealth()
+	}
+}
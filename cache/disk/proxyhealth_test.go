@@ -0,0 +1,165 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// flakyProxy is a cache.Proxy which contains nothing, and fails every
+// Get while down is set.
+type flakyProxy struct {
+	down atomic.Bool
+	puts atomic.Int32
+	gets atomic.Int32
+}
+
+func (p *flakyProxy) Put(ctx context.Context, kind cache.EntryKind, hash string, logicalSize int64, sizeOnDisk int64, rc io.ReadCloser) {
+	p.puts.Add(1)
+	rc.Close()
+}
+
+func (p *flakyProxy) Get(ctx context.Context, kind cache.EntryKind, hash string) (io.ReadCloser, int64, error) {
+	p.gets.Add(1)
+	if p.down.Load() {
+		return nil, -1, errors.New("connection refused")
+	}
+	return nil, -1, nil
+}
+
+func (p *flakyProxy) Contains(ctx context.Context, kind cache.EntryKind, hash string) (bool, int64) {
+	return false, -1
+}
+
+func TestProxyRequired(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	p := &flakyProxy{}
+	p.down.Store(true)
+
+	cI, err := New(cacheDir, BlockSize*10, WithProxyBackend(p), WithProxyRequired(),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cI.(*diskCache)
+
+	data, hash := testutils.RandomDataAndHash(100)
+
+	// The proxy backend is down at startup, so writes are refused.
+	err = c.Put(context.Background(), cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	var cerr *cache.Error
+	if !errors.As(err, &cerr) || cerr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected the write to be refused with status %d, got %v", http.StatusServiceUnavailable, err)
+	}
+	if n := testutil.ToFloat64(c.counterRefusedWrites); n != 1 {
+		t.Errorf("Expected 1 refused write, got %v", n)
+	}
+	if v := testutil.ToFloat64(c.gaugeProxyHealthy); v != 0 {
+		t.Errorf("Expected the proxy backend to be reported as unhealthy, got %v", v)
+	}
+
+	// Writes are accepted once the proxy backend is back.
+	p.down.Store(false)
+	c.checkProxyHealth()
+
+	err = c.Put(context.Background(), cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := testutil.ToFloat64(c.gaugeProxyHealthy); v != 1 {
+		t.Errorf("Expected the proxy backend to be reported as healthy, got %v", v)
+	}
+
+	// Reads are still served while the proxy backend is down.
+	p.down.Store(true)
+	c.checkProxyHealth()
+
+	found, _ := c.Contains(context.Background(), cache.CAS, hash, int64(len(data)))
+	if !found {
+		t.Error("Expected the item to be readable while the proxy backend is down")
+	}
+}
+
+func TestProxyRequiredWithoutProxy(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	_, err := New(cacheDir, BlockSize*10, WithProxyRequired(), WithAccessLogger(testutils.NewSilentLogger()))
+	if err == nil {
+		t.Error("Expected an error when a proxy backend is required but not set")
+	}
+}
+
+func TestProxyBackendDisable(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	p := &flakyProxy{}
+
+	cI, err := New(cacheDir, BlockSize*10, WithProxyBackend(p), WithProxyRequired(),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cI.(*diskCache)
+
+	statuses := c.ProxyBackends()
+	if len(statuses) != 1 || statuses[0].Name != cache.DefaultBackendName ||
+		!statuses[0].Enabled || !statuses[0].Healthy || statuses[0].LastCheck == 0 {
+		t.Fatalf("Expected one enabled and healthy backend, got %+v", statuses)
+	}
+
+	err = c.SetProxyBackendEnabled("missing", false)
+	var cerr *cache.Error
+	if !errors.As(err, &cerr) || cerr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a missing backend, got %v", http.StatusNotFound, err)
+	}
+
+	err = c.SetProxyBackendEnabled(cache.DefaultBackendName, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses = c.ProxyBackends(); statuses[0].Enabled {
+		t.Errorf("Expected the backend to be disabled, got %+v", statuses)
+	}
+	if v := testutil.ToFloat64(c.gaugeProxyBackendEnabled.WithLabelValues(cache.DefaultBackendName)); v != 0 {
+		t.Errorf("Expected the backend to be reported as disabled, got %v", v)
+	}
+
+	// Reads don't use the disabled backend, and with proxy_required,
+	// writes are refused.
+	data, hash := testutils.RandomDataAndHash(100)
+	gets := p.gets.Load()
+	found, _ := c.Contains(context.Background(), cache.CAS, hash, int64(len(data)))
+	if found || p.gets.Load() != gets {
+		t.Error("Expected a miss without using the disabled backend")
+	}
+	err = c.Put(context.Background(), cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if !errors.As(err, &cerr) || cerr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the write to be refused with status %d, got %v", http.StatusServiceUnavailable, err)
+	}
+
+	err = c.SetProxyBackendEnabled(cache.DefaultBackendName, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Put(context.Background(), cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.puts.Load() != 1 {
+		t.Errorf("Expected the write to be uploaded to the enabled backend, got %d uploads", p.puts.Load())
+	}
+}
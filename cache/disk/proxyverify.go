@@ -0,0 +1,66 @@
+package disk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"log"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+	"github.com/buchgr/bazel-remote/v2/utils/bufpool"
+	"github.com/buchgr/bazel-remote/v2/utils/sharedfile"
+)
+
+// Items fetched from the proxy backend are trusted by default: CAS blobs
+// are streamed to the client while they are downloaded, and added to the
+// cache without checking their content. With WithProxyReadVerification,
+// CAS blobs are downloaded completely and hashed before they are added
+// to the cache or served. Blobs which don't match their digest are
+// discarded and counted per proxy backend, see cache.BackendNamer, and
+// the read is treated as a cache miss. Action cache and raw entries are
+// not content addressed, so they can't be verified.
+
+var errCorruptProxyBlob = errors.New("The blob from the proxy backend does not match its digest")
+
+// Returns true if items of kind which are fetched from the proxy backend
+// must be verified before they are used.
+func (c *diskCache) verifyProxyRead(kind cache.EntryKind) bool {
+	return c.verifyProxyReads && kind == cache.CAS
+}
+
+// Check that the downloaded tempfile matches the blob's digest. Returns
+// errCorruptProxyBlob if it does not.
+func (f *proxyFetch) verify() error {
+	file, err := sharedfile.Open(f.tf.Name())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var rc io.ReadCloser = file
+	if !f.legacy {
+		rc, err = casblob.GetUncompressedReadCloser(f.c.zstd, file, f.size, 0)
+	}
+
+	valid := false
+	if err == nil {
+		h := sha256.New()
+		var n int64
+		n, err = bufpool.Copy(h, rc)
+		rc.Close()
+		valid = err == nil && n == f.size && bytes.Equal(h.Sum(nil), f.key.digest[:])
+	}
+
+	backend := cache.ProxyBackendName(f.ctx, f.c.proxy)
+	f.c.counterProxyVerifiedBlobs.WithLabelValues(backend).Inc()
+	if valid {
+		return nil
+	}
+
+	f.c.counterProxyCorruptBlobs.WithLabelValues(backend).Inc()
+	log.Printf("Discarded %s from the %s proxy backend, which does not match its digest", f.key, backend)
+
+	return errCorruptProxyBlob
+}
@@ -0,0 +1,73 @@
+package disk
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestProxyReadVerification(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	p := &prewarmProxy{
+		listingProxy: listingProxy{
+			items: make(map[string]cache.ProxyItem),
+			blobs: make(map[string][]byte),
+		},
+		sizes: make(map[string]int64),
+	}
+
+	cI, err := New(cacheDir, BlockSize*10, WithProxyBackend(p), WithProxyReadVerification(),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cI.(*diskCache)
+
+	valid, validHash := testutils.RandomDataAndHash(100)
+	p.add(compressedBlob(t, c, valid, validHash), validHash, int64(len(valid)))
+
+	// The proxy backend serves the content of another blob for this one.
+	other, otherHash := testutils.RandomDataAndHash(100)
+	_, corruptHash := testutils.RandomDataAndHash(100)
+	p.add(compressedBlob(t, c, other, otherHash), corruptHash, int64(len(other)))
+
+	rc, _, err := c.Get(context.Background(), cache.CAS, validHash, int64(len(valid)), 0)
+	if err != nil || rc == nil {
+		t.Fatal("Expected a cache hit for the valid blob", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != string(valid) {
+		t.Error("Expected the valid blob's content", err)
+	}
+
+	rc, _, err = c.Get(context.Background(), cache.CAS, corruptHash, int64(len(other)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc != nil {
+		rc.Close()
+		t.Fatal("Expected a cache miss for the corrupt blob")
+	}
+
+	key, _ := newKey(cache.CAS, corruptHash)
+	if _, found := c.lru.peek(key); found {
+		t.Error("Expected the corrupt blob not to be added to the cache")
+	}
+
+	backend := cache.DefaultBackendName
+	if n := testutil.ToFloat64(c.counterProxyVerifiedBlobs.WithLabelValues(backend)); n != 2 {
+		t.Errorf("Expected 2 verified blobs, got %v", n)
+	}
+	if n := testutil.ToFloat64(c.counterProxyCorruptBlobs.WithLabelValues(backend)); n != 1 {
+		t.Errorf("Expected 1 corrupt blob, got %v", n)
+	}
+}
@@ -0,0 +1,90 @@
+package disk
+
+import (
+	"log"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// PurgeStats reports the progress of a purge.
+type PurgeStats struct {
+	// The number of entries removed so far, and their size on disk.
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+
+	// The number of entries which are still to be checked.
+	Remaining int `json:"remaining"`
+}
+
+// Remove entries in batches, so requests are not blocked for too long.
+const purgeBatchSize = 10000
+
+// Purge removes all entries of the given kinds, or of any kind if kinds
+// is empty, which were last accessed before the given time, even if
+// they are leased. progress is called after each batch of entries, if
+// it is not nil.
+func (c *diskCache) Purge(before time.Time, kinds []cache.EntryKind, progress func(PurgeStats)) PurgeStats {
+	cutoff := unixSeconds(before)
+
+	matches := func(e *entry) bool {
+		if e.lastAccess >= cutoff {
+			return false
+		}
+		if len(kinds) == 0 {
+			return true
+		}
+		for _, kind := range kinds {
+			if e.key.Kind() == kind {
+				return true
+			}
+		}
+		return false
+	}
+
+	c.mu.Lock()
+	var keys []Key
+	for ele := c.lru.ll.Back(); ele != nil; ele = ele.Prev() {
+		e := ele.Value.(*entry)
+		if matches(e) {
+			keys = append(keys, e.key)
+		}
+	}
+	c.mu.Unlock()
+
+	log.Printf("Purging %d entries last accessed before %s", len(keys),
+		before.UTC().Format(time.RFC3339))
+
+	stats := PurgeStats{Remaining: len(keys)}
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > purgeBatchSize {
+			n = purgeBatchSize
+		}
+
+		c.mu.Lock()
+		for _, key := range keys[:n] {
+			// The entry might have been accessed, overwritten or
+			// evicted since it was found.
+			ele, found := c.lru.cache[key]
+			if !found || !matches(ele.Value.(*entry)) {
+				continue
+			}
+			stats.Entries++
+			stats.Bytes += ele.Value.(*entry).value.sizeOnDisk
+			c.lru.Remove(key)
+		}
+		c.mu.Unlock()
+
+		keys = keys[n:]
+		stats.Remaining = len(keys)
+		if progress != nil {
+			progress(stats)
+		}
+	}
+
+	log.Printf("Finished purging the cache: removed %d entries (%d bytes)",
+		stats.Entries, stats.Bytes)
+
+	return stats
+}
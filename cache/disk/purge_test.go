@@ -0,0 +1,73 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestPurge(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, BlockSize*10, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	testCache.lru.now = func() time.Time { return now }
+
+	put := func(kind cache.EntryKind, data string) string {
+		hash := hashStr(data)
+		err := testCache.Put(ctx, kind, hash, int64(len(data)), bytes.NewReader([]byte(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+
+	oldAC := put(cache.AC, "old ac")
+	oldRAW := put(cache.RAW, "old raw")
+	now = now.Add(time.Hour)
+	newAC := put(cache.AC, "new ac")
+
+	cutoff := now.Add(-time.Minute)
+
+	// Only purge AC entries first.
+	var progress []PurgeStats
+	stats := testCache.Purge(cutoff, []cache.EntryKind{cache.AC}, func(s PurgeStats) {
+		progress = append(progress, s)
+	})
+	expected := PurgeStats{Entries: 1, Bytes: int64(len("old ac"))}
+	if stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+	if len(progress) != 1 || progress[0] != expected {
+		t.Errorf("Expected one progress report, got %+v", progress)
+	}
+
+	// Check without touching the entries.
+	for hash, kind := range map[string]cache.EntryKind{oldAC: cache.AC, oldRAW: cache.RAW, newAC: cache.AC} {
+		key, _ := newKey(kind, hash)
+		_, found := testCache.lru.peek(key)
+		if found != (hash != oldAC) {
+			t.Errorf("Unexpected presence of %s %s: %v", kind, hash, found)
+		}
+	}
+
+	stats = testCache.Purge(cutoff, nil, nil)
+	if stats.Entries != 1 {
+		t.Errorf("Expected the old RAW entry to be purged, got %+v", stats)
+	}
+	if testCache.lru.Len() != 1 {
+		t.Errorf("Expected only the new entry to remain, found %d entries", testCache.lru.Len())
+	}
+}
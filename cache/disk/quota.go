@@ -0,0 +1,183 @@
+package disk
+
+import (
+	"container/list"
+	"sort"
+)
+
+// Entries added by requests are attributed to the instance name of the
+// request, so that the disk space used by each instance can be tracked,
+// and optionally limited by a quota.
+//
+// When an instance with a quota would exceed it, its own least recently
+// used entries are evicted. When the whole cache is full, entries are
+// normally evicted in LRU order regardless of their instance. But if the
+// quotas add up to more than the cache size, instances which use more
+// than their proportional share of the cache (quota * maxSize / sum of
+// all quotas) have their entries evicted first, starting with the one
+// furthest over its share.
+
+// InstanceUsage describes the disk space used by the cache entries which
+// are attributed to an instance name.
+type InstanceUsage struct {
+	SizeBytes  int64 `json:"size_bytes"`
+	QuotaBytes int64 `json:"quota_bytes,omitempty"` // 0 if there is no quota.
+}
+
+type instanceUsage struct {
+	name string
+
+	// Total size of the instance's entries, rounded up like currentSize.
+	size int64
+
+	// The maximum size of the instance's entries, or 0 for no quota.
+	quota int64
+
+	// The instance's entries, if it has a quota, from most to least
+	// recently used. The values are the entries' elements in the main
+	// LRU list.
+	ll *list.List
+}
+
+// Set the quotas for the given instance names, in bytes. This must be
+// called before any entries are added.
+func (c *SizedLRU) setInstanceQuotas(quotas map[string]int64) {
+	for name, quota := range quotas {
+		// Intern the name now, so that its entries are attributed to it
+		// even if the table of instance names fills up later.
+		newInstanceID(name)
+
+		u := &instanceUsage{name: name, quota: quota, ll: list.New()}
+		c.instances[name] = u
+		c.quotaUsages = append(c.quotaUsages, u)
+		c.totalQuota += quota
+		c.gaugeInstanceSizeBytes.WithLabelValues(name).Set(0)
+	}
+
+	sort.Slice(c.quotaUsages, func(i, j int) bool {
+		return c.quotaUsages[i].name < c.quotaUsages[j].name
+	})
+}
+
+// Returns the quota of the instance which value is attributed to, or 0
+// if there is none.
+func (c *SizedLRU) instanceQuota(value lruItem) int64 {
+	if value.instance == noInstance {
+		return 0
+	}
+
+	u, found := c.instances[value.instance.name()]
+	if !found {
+		return 0
+	}
+	return u.quota
+}
+
+// Attribute the entry in ele to its value's instance, if any, and count
+// it against the entry limit of its kind.
+func (c *SizedLRU) attach(ele *list.Element) {
+	c.attachKind(ele)
+
+	e := ele.Value.(*entry)
+	if e.value.instance == noInstance {
+		return
+	}
+
+	name := e.value.instance.name()
+	u, found := c.instances[name]
+	if !found {
+		u = &instanceUsage{name: name}
+		c.instances[u.name] = u
+	}
+
+	e.usage = u
+	u.size += roundUp4k(e.value.sizeOnDisk)
+	if u.ll != nil {
+		e.instanceEle = u.ll.PushFront(ele)
+		c.gaugeInstanceSizeBytes.WithLabelValues(u.name).Set(float64(u.size))
+	}
+}
+
+// Stop attributing e to an instance.
+func (c *SizedLRU) detach(e *entry) {
+	c.detachKind(e)
+
+	u := e.usage
+	if u == nil {
+		return
+	}
+
+	u.size -= roundUp4k(e.value.sizeOnDisk)
+	if u.ll != nil {
+		u.ll.Remove(e.instanceEle)
+		c.gaugeInstanceSizeBytes.WithLabelValues(u.name).Set(float64(u.size))
+	} else if u.size == 0 {
+		// Don't keep track of instances which no longer have entries.
+		delete(c.instances, u.name)
+	}
+
+	e.usage = nil
+	e.instanceEle = nil
+}
+
+// Evict the least recently used entries of u until it is within its
+// quota, if it has one.
+func (c *SizedLRU) enforceQuota(u *instanceUsage) {
+	if u == nil || u.quota == 0 {
+		return
+	}
+
+	for u.size > u.quota {
+		c.removeElement(u.ll.Back().Value.(*list.Element))
+	}
+}
+
+// Returns the element to evict next to make room in the cache, avoiding
+// keep if possible. This is the least recently used element which is not
+// leased, unless an instance is over its proportional share of the cache.
+func (c *SizedLRU) evictionVictim(keep *list.Element) *list.Element {
+	if c.totalQuota > c.maxSize {
+		now := c.now().UnixNano()
+		var victim *instanceUsage
+		maxRatio := 1.0
+		for _, u := range c.quotaUsages {
+			back := u.ll.Back()
+			if back == nil || back.Value.(*list.Element) == keep ||
+				back.Value.(*list.Element).Value.(*entry).leased(now) {
+				continue
+			}
+
+			share := float64(u.quota) * float64(c.maxSize) / float64(c.totalQuota)
+			ratio := float64(u.size) / share
+			if ratio > maxRatio {
+				victim = u
+				maxRatio = ratio
+			}
+		}
+
+		if victim != nil {
+			return victim.ll.Back().Value.(*list.Element)
+		}
+	}
+
+	return c.unleasedBack(keep)
+}
+
+// Returns the disk space used by each instance which has entries or a
+// quota.
+func (c *SizedLRU) instanceUsage() map[string]InstanceUsage {
+	usage := make(map[string]InstanceUsage, len(c.instances))
+	for name, u := range c.instances {
+		usage[name] = InstanceUsage{SizeBytes: u.size, QuotaBytes: u.quota}
+	}
+	return usage
+}
+
+// InstanceUsage returns the disk space used by each instance name which
+// has entries in the cache or a quota.
+func (c *diskCache) InstanceUsage() map[string]InstanceUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.instanceUsage()
+}
@@ -0,0 +1,128 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func instanceItem(instance string) lruItem {
+	return lruItem{size: BlockSize, sizeOnDisk: BlockSize, instance: newInstanceID(instance)}
+}
+
+func TestInstanceQuota(t *testing.T) {
+	lru := NewSizedLRU(10*BlockSize, nil, 0)
+	lru.setInstanceQuotas(map[string]int64{"a": 2 * BlockSize})
+
+	lru.Add(testKey("a1"), instanceItem("a"))
+	lru.Add(testKey("b1"), instanceItem("b"))
+	lru.Add(testKey("a2"), instanceItem("a"))
+	lru.Add(testKey("unattributed"), lruItem{size: BlockSize, sizeOnDisk: BlockSize})
+
+	// Mark a1 as more recently used than a2.
+	lru.Get(testKey("a1"))
+
+	lru.Add(testKey("a3"), instanceItem("a"))
+
+	if _, found := lru.peek(testKey("a2")); found {
+		t.Error("Expected a2 to be evicted when instance a exceeded its quota")
+	}
+	for _, key := range []string{"a1", "a3", "b1", "unattributed"} {
+		if _, found := lru.peek(testKey(key)); !found {
+			t.Errorf("Expected %s to remain in the cache", key)
+		}
+	}
+
+	expected := map[string]InstanceUsage{
+		"a": {SizeBytes: 2 * BlockSize, QuotaBytes: 2 * BlockSize},
+		"b": {SizeBytes: BlockSize},
+	}
+	if usage := lru.instanceUsage(); !reflect.DeepEqual(usage, expected) {
+		t.Errorf("Expected usage %v, got %v", expected, usage)
+	}
+
+	// Entries which are overwritten are attributed to the new instance,
+	// and instances without entries or a quota are dropped.
+	lru.Add(testKey("b1"), instanceItem("c"))
+	lru.Remove(testKey("a1"))
+	expected = map[string]InstanceUsage{
+		"a": {SizeBytes: BlockSize, QuotaBytes: 2 * BlockSize},
+		"c": {SizeBytes: BlockSize},
+	}
+	if usage := lru.instanceUsage(); !reflect.DeepEqual(usage, expected) {
+		t.Errorf("Expected usage %v, got %v", expected, usage)
+	}
+
+	if lru.Add(testKey("large"), lruItem{size: 3 * BlockSize, sizeOnDisk: 3 * BlockSize, instance: newInstanceID("a")}) {
+		t.Error("Expected an item larger than the instance's quota to be rejected")
+	}
+}
+
+func TestProportionalEviction(t *testing.T) {
+	// The quotas add up to twice the cache size, so each instance's
+	// proportional share is 2 blocks.
+	lru := NewSizedLRU(4*BlockSize, nil, 0)
+	lru.setInstanceQuotas(map[string]int64{"a": 4 * BlockSize, "b": 4 * BlockSize})
+
+	lru.Add(testKey("b1"), instanceItem("b"))
+	lru.Add(testKey("a1"), instanceItem("a"))
+	lru.Add(testKey("a2"), instanceItem("a"))
+	lru.Add(testKey("a3"), instanceItem("a"))
+
+	// b1 is the least recently used entry, but instance a is over its
+	// share.
+	lru.Add(testKey("b2"), instanceItem("b"))
+	if _, found := lru.peek(testKey("a1")); found {
+		t.Error("Expected a1 to be evicted")
+	}
+	if _, found := lru.peek(testKey("b1")); !found {
+		t.Error("Expected b1 to remain in the cache")
+	}
+
+	// Now both instances are at their share, so the least recently
+	// used entry is evicted.
+	lru.Add(testKey("unattributed"), lruItem{size: BlockSize, sizeOnDisk: BlockSize})
+	if _, found := lru.peek(testKey("b1")); found {
+		t.Error("Expected b1 to be evicted")
+	}
+	checkSizeAndNumItems(t, lru, 4*BlockSize, 4)
+}
+
+func TestPutInstanceQuota(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := New(cacheDir, 10*BlockSize,
+		WithAccessLogger(testutils.NewSilentLogger()),
+		WithInstanceQuotas(map[string]int64{"small": BlockSize}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := cache.WithInstanceName(context.Background(), "small")
+
+	data, hash := testutils.RandomDataAndHash(2 * BlockSize)
+	err = c.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	var cerr *cache.Error
+	if !errors.As(err, &cerr) || cerr.Code != http.StatusInsufficientStorage {
+		t.Fatalf("Expected a blob larger than the quota to be rejected, got %v", err)
+	}
+
+	data, hash = testutils.RandomDataAndHash(100)
+	err = c.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usage := c.InstanceUsage()["small"]
+	if usage.SizeBytes != BlockSize || usage.QuotaBytes != BlockSize {
+		t.Errorf("Expected one block attributed to instance small, got %+v", usage)
+	}
+}
@@ -0,0 +1,104 @@
+package disk
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// Switch to read-only mode after this many consecutive writes to the
+// cache directory have failed.
+const maxConsecutiveWriteErrors = 10
+
+// How often to check if writes work again, after switching to read-only
+// mode because of write errors.
+const defaultWriteProbeInterval = time.Minute
+
+var errReadOnly = &cache.Error{
+	Code: http.StatusServiceUnavailable,
+	Text: "The cache is in read-only mode",
+}
+
+func (c *diskCache) isReadOnly() bool {
+	return c.readOnly || c.degraded.Load()
+}
+
+// Returns true if err came from the filesystem, rather than from eg
+// the client or the proxy backend.
+func isDiskError(err error) bool {
+	var pathErr *fs.PathError
+	return errors.As(err, &pathErr)
+}
+
+// Record the result of writing a file to the cache directory, and switch
+// to read-only mode if writes keep failing.
+func (c *diskCache) recordWrite(err error) {
+	if err == nil {
+		c.consecutiveWriteErrors.Store(0)
+		return
+	}
+
+	if !isDiskError(err) {
+		return
+	}
+
+	c.counterWriteErrors.Inc()
+
+	if c.consecutiveWriteErrors.Add(1) < maxConsecutiveWriteErrors {
+		return
+	}
+
+	if c.degraded.CompareAndSwap(false, true) {
+		log.Printf("Switching to read-only mode after %d consecutive write errors, the last one: %v",
+			maxConsecutiveWriteErrors, err)
+		c.gaugeReadOnly.Set(1)
+		go c.probeWrites()
+	}
+}
+
+// Periodically try to write to the cache directory, and leave read-only
+// mode once that works.
+func (c *diskCache) probeWrites() {
+	ticker := time.NewTicker(c.writeProbeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := c.writeProbe()
+		if err != nil {
+			log.Printf("Staying in read-only mode, writes still fail: %v", err)
+			continue
+		}
+
+		c.consecutiveWriteErrors.Store(0)
+		c.degraded.Store(false)
+		if !c.readOnly {
+			c.gaugeReadOnly.Set(0)
+		}
+		log.Println("Writes to the cache directory work again, leaving read-only mode")
+		return
+	}
+}
+
+func (c *diskCache) writeProbe() error {
+	f, err := os.CreateTemp(c.dir, "write-probe-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(make([]byte, BlockSize))
+	if err == nil {
+		err = f.Sync()
+	}
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -0,0 +1,110 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestReadOnly(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	ctx := context.Background()
+	data := []byte("hello")
+	hash := hashStr(string(data))
+
+	testCache, err := New(cacheDir, BlockSize*10,
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = testCache.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen the same directory in read-only mode.
+	testCache, err = New(cacheDir, BlockSize*10,
+		WithAccessLogger(testutils.NewSilentLogger()),
+		WithReadOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, _ := testCache.Contains(ctx, cache.CAS, hash, int64(len(data)))
+	if !found {
+		t.Error("Expected existing entries to be available in read-only mode")
+	}
+
+	other := []byte("world")
+	err = testCache.Put(ctx, cache.CAS, hashStr(string(other)), int64(len(other)), bytes.NewReader(other))
+	var cerr *cache.Error
+	if !errors.As(err, &cerr) || cerr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected a read-only error, got %v", err)
+	}
+}
+
+func TestReadOnlyDegradation(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, BlockSize*10,
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+	testCache.writeProbeInterval = 10 * time.Millisecond
+
+	diskErr := &fs.PathError{Op: "write", Path: "test", Err: syscall.ENOSPC}
+
+	// Errors which don't come from the filesystem are ignored.
+	for i := 0; i < maxConsecutiveWriteErrors; i++ {
+		testCache.recordWrite(errors.New("client went away"))
+	}
+	if testCache.isReadOnly() {
+		t.Fatal("Expected non-disk errors not to cause read-only mode")
+	}
+
+	// A successful write resets the count.
+	for i := 0; i < maxConsecutiveWriteErrors-1; i++ {
+		testCache.recordWrite(diskErr)
+	}
+	testCache.recordWrite(nil)
+	testCache.recordWrite(diskErr)
+	if testCache.isReadOnly() {
+		t.Fatal("Expected a successful write to reset the error count")
+	}
+
+	for i := 0; i < maxConsecutiveWriteErrors; i++ {
+		testCache.recordWrite(diskErr)
+	}
+	if !testCache.isReadOnly() {
+		t.Fatal("Expected persistent write errors to cause read-only mode")
+	}
+
+	// Writes to the directory work, so the probe should switch back.
+	deadline := time.Now().Add(10 * time.Second)
+	for testCache.isReadOnly() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected to leave read-only mode once writes work again")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	data := []byte("hello")
+	err = testCache.Put(context.Background(), cache.CAS, hashStr(string(data)), int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,238 @@
+package disk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/backendproxy"
+	"github.com/buchgr/bazel-remote/v2/utils/sharedfile"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Over time the local cache and the proxy backend drift apart, eg when
+// uploads to the backend are dropped under load, or when other instances
+// write to the same backend. Reconciliation compares the entries in the
+// local cache with the items listed by the proxy backend, uploads the
+// local entries which the backend is missing, and optionally downloads
+// the items which were recently added to the backend.
+
+// ReconcileOptions configures a reconciliation.
+type ReconcileOptions struct {
+	// Download the items which are missing from the local cache, and
+	// were modified in the proxy backend within this duration. Nothing
+	// is downloaded if this is 0.
+	DownloadWindow time.Duration
+}
+
+// ReconcileReport reports the result of a reconciliation.
+type ReconcileReport struct {
+	// The number of entries which were missing from the proxy backend,
+	// and how many of them were queued for upload.
+	LocalOnly int `json:"local_only"`
+	Uploaded  int `json:"uploaded"`
+
+	// The number of items which were missing from the local cache, and
+	// how many of them were downloaded.
+	RemoteOnly int `json:"remote_only"`
+	Downloaded int `json:"downloaded"`
+
+	// The number of uploads and downloads which failed.
+	Failed int `json:"failed"`
+}
+
+var errNoLister = &cache.Error{
+	Code: http.StatusNotImplemented,
+	Text: "Reconciliation requires a proxy backend which can list its items",
+}
+
+// Wait for the queued uploads after this many entries, so that the
+// upload queue doesn't overflow.
+const reconcileUploadBatchSize = 100
+
+// The maximum number of concurrent downloads from the proxy backend.
+const reconcileConcurrency = 16
+
+// Reconcile compares the local cache with the items in the proxy backend,
+// uploads the entries which the backend is missing, and downloads the
+// recently modified items which the local cache is missing, if
+// opts.DownloadWindow > 0. Only one reconciliation runs at a time.
+func (c *diskCache) Reconcile(ctx context.Context, opts ReconcileOptions) (ReconcileReport, error) {
+	var report ReconcileReport
+
+	lister, ok := c.proxy.(cache.Lister)
+	if !ok {
+		return report, errNoLister
+	}
+
+	if c.proxyDisabled(ctx) {
+		return report, errProxyDisabled
+	}
+
+	c.reconcileMu.Lock()
+	defer c.reconcileMu.Unlock()
+
+	remote := make(map[Key]struct{})
+	var recent []Key
+	cutoff := time.Now().Add(-opts.DownloadWindow)
+
+	err := lister.List(ctx, func(item cache.ProxyItem) error {
+		key, ok := newKey(item.Kind, item.Hash)
+		if !ok {
+			return nil
+		}
+
+		remote[key] = struct{}{}
+		if opts.DownloadWindow > 0 && item.LastModified.After(cutoff) {
+			recent = append(recent, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("Failed to list the proxy backend: %w", err)
+	}
+
+	c.mu.Lock()
+	keys := c.lru.keys()
+	c.mu.Unlock()
+
+	local := make(map[Key]struct{}, len(keys))
+	for _, key := range keys {
+		local[key] = struct{}{}
+		if _, found := remote[key]; found {
+			continue
+		}
+
+		report.LocalOnly++
+		queued, err := c.uploadToProxy(ctx, key)
+		if err != nil {
+			log.Printf("Warning: failed to upload %s to the proxy backend: %v", key, err)
+			report.Failed++
+			continue
+		}
+		if !queued {
+			continue
+		}
+
+		report.Uploaded++
+		c.counterReconcileUploads.Inc()
+		if report.Uploaded%reconcileUploadBatchSize == 0 {
+			err = backendproxy.Flush(ctx)
+			if err != nil {
+				return report, err
+			}
+		}
+	}
+
+	for key := range remote {
+		if _, found := local[key]; !found {
+			report.RemoteOnly++
+		}
+	}
+
+	c.gaugeReconcileLocalOnly.Set(float64(report.LocalOnly))
+	c.gaugeReconcileRemoteOnly.Set(float64(report.RemoteOnly))
+
+	var downloaded, failed int64
+
+	var g errgroup.Group
+	g.SetLimit(reconcileConcurrency)
+
+	for _, key := range recent {
+		if _, found := local[key]; found {
+			continue
+		}
+
+		key := key
+		g.Go(func() error {
+			found, err := c.downloadFromProxy(ctx, key)
+			if err != nil {
+				log.Printf("Warning: failed to download %s from the proxy backend: %v", key, err)
+				atomic.AddInt64(&failed, 1)
+				return nil
+			}
+			if found {
+				atomic.AddInt64(&downloaded, 1)
+				c.counterReconcileDownloads.Inc()
+			}
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	report.Downloaded = int(downloaded)
+	report.Failed += int(failed)
+
+	return report, ctx.Err()
+}
+
+// Queue the local entry with key for upload to the proxy backend. Returns
+// false if the entry was evicted in the meantime.
+func (c *diskCache) uploadToProxy(ctx context.Context, key Key) (bool, error) {
+	c.mu.Lock()
+	item, found := c.lru.peek(key)
+	c.mu.Unlock()
+	if !found {
+		return false, nil
+	}
+
+	rc, err := sharedfile.Open(c.getElementPath(key, item))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Doesn't block, the upload happens in the background.
+	c.proxy.Put(ctx, key.Kind(), key.Hash(), item.size, item.sizeOnDisk, rc)
+	return true, nil
+}
+
+// Add the item with key from the proxy backend to the local cache.
+// Returns false if the proxy backend doesn't have it anymore.
+func (c *diskCache) downloadFromProxy(ctx context.Context, key Key) (bool, error) {
+	rc, _, err := c.get(ctx, key.Kind(), key.Hash(), -1, 0, false)
+	if err != nil {
+		return false, err
+	}
+	if rc == nil {
+		return false, nil
+	}
+
+	// Items from the proxy backend are added to the cache once they
+	// have been read and closed.
+	_, err = io.Copy(io.Discard, rc)
+	closeErr := rc.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return err == nil, err
+}
+
+// Reconcile with the proxy backend every c.reconcileInterval.
+func (c *diskCache) reconcilePeriodically() {
+	ticker := time.NewTicker(c.reconcileInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		opts := ReconcileOptions{DownloadWindow: c.reconcileDownloadWindow}
+		report, err := c.Reconcile(context.Background(), opts)
+		if err != nil {
+			log.Printf("Warning: failed to reconcile with the proxy backend: %v", err)
+			continue
+		}
+
+		log.Printf("Reconciled with the proxy backend: %d entries missing from the backend (%d uploaded), %d items missing locally (%d downloaded), %d failed",
+			report.LocalOnly, report.Uploaded, report.RemoteOnly, report.Downloaded, report.Failed)
+	}
+}
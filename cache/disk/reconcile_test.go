@@ -0,0 +1,152 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// listingProxy is a cache.Proxy and cache.Lister which serves RAW items
+// from a map, and records the items which are put to it.
+type listingProxy struct {
+	items map[string]cache.ProxyItem
+	blobs map[string][]byte
+
+	mu   sync.Mutex
+	puts []string
+}
+
+func (p *listingProxy) add(data []byte, hash string, modified time.Time) {
+	p.items[hash] = cache.ProxyItem{Kind: cache.RAW, Hash: hash, SizeOnDisk: int64(len(data)), LastModified: modified}
+	p.blobs[hash] = data
+}
+
+func (p *listingProxy) Put(ctx context.Context, kind cache.EntryKind, hash string, logicalSize int64, sizeOnDisk int64, rc io.ReadCloser) {
+	p.mu.Lock()
+	p.puts = append(p.puts, hash)
+	p.mu.Unlock()
+	rc.Close()
+}
+
+func (p *listingProxy) Get(ctx context.Context, kind cache.EntryKind, hash string) (io.ReadCloser, int64, error) {
+	data, found := p.blobs[hash]
+	if !found {
+		return nil, -1, nil
+	}
+	return io.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+}
+
+func (p *listingProxy) Contains(ctx context.Context, kind cache.EntryKind, hash string) (bool, int64) {
+	data, found := p.blobs[hash]
+	return found, int64(len(data))
+}
+
+func (p *listingProxy) List(ctx context.Context, fn func(item cache.ProxyItem) error) error {
+	for _, item := range p.items {
+		err := fn(item)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestReconcile(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	p := &listingProxy{
+		items: make(map[string]cache.ProxyItem),
+		blobs: make(map[string][]byte),
+	}
+
+	cI, err := New(cacheDir, BlockSize*10, WithProxyBackend(p), WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cI.(*diskCache)
+
+	// In both the cache and the proxy backend.
+	shared, sharedHash := testutils.RandomDataAndHash(100)
+	p.add(shared, sharedHash, time.Now())
+
+	// Only in the cache.
+	localOnly, localOnlyHash := testutils.RandomDataAndHash(100)
+
+	for hash, data := range map[string][]byte{sharedHash: shared, localOnlyHash: localOnly} {
+		err = c.Put(context.Background(), cache.RAW, hash, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.puts = nil
+
+	// Only in the proxy backend, added recently and a while ago.
+	recent, recentHash := testutils.RandomDataAndHash(100)
+	p.add(recent, recentHash, time.Now())
+	old, oldHash := testutils.RandomDataAndHash(100)
+	p.add(old, oldHash, time.Now().Add(-48*time.Hour))
+
+	report, err := c.Reconcile(context.Background(), ReconcileOptions{DownloadWindow: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ReconcileReport{LocalOnly: 1, Uploaded: 1, RemoteOnly: 2, Downloaded: 1}
+	if report != expected {
+		t.Errorf("Expected %+v, got %+v", expected, report)
+	}
+	if len(p.puts) != 1 || p.puts[0] != localOnlyHash {
+		t.Errorf("Expected %s to be uploaded, got %v", localOnlyHash, p.puts)
+	}
+
+	recentKey, _ := newKey(cache.RAW, recentHash)
+	if _, found := c.lru.peek(recentKey); !found {
+		t.Error("Expected the recent item to be downloaded")
+	}
+	oldKey, _ := newKey(cache.RAW, oldHash)
+	if _, found := c.lru.peek(oldKey); found {
+		t.Error("Expected the old item not to be downloaded")
+	}
+
+	if n := testutil.ToFloat64(c.gaugeReconcileLocalOnly); n != 1 {
+		t.Errorf("Expected 1 local only item, got %v", n)
+	}
+	if n := testutil.ToFloat64(c.gaugeReconcileRemoteOnly); n != 2 {
+		t.Errorf("Expected 2 remote only items, got %v", n)
+	}
+	if n := testutil.ToFloat64(c.counterReconcileDownloads); n != 1 {
+		t.Errorf("Expected 1 download, got %v", n)
+	}
+}
+
+func TestReconcileWithoutLister(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	p := &blockingProxy{release: make(chan struct{})}
+	cI, err := New(cacheDir, BlockSize*10, WithProxyBackend(p), WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = cI.Reconcile(context.Background(), ReconcileOptions{})
+	if err == nil {
+		t.Error("Expected an error for a proxy backend which can't list its items")
+	}
+
+	_, err = New(cacheDir, BlockSize*10, WithProxyBackend(p), WithReconciliation(time.Hour, 0),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err == nil {
+		t.Error("Expected an error for periodic reconciliation with a proxy backend which can't list its items")
+	}
+}
@@ -0,0 +1,158 @@
+package disk
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Evicting many entries at once, eg after the cache size or a quota was
+// reduced, removes many files. By default the file of each evicted entry
+// is removed by its own goroutine, see removeFile, limited by
+// fileRemovalSem. With WithBatchFileRemovals, the files are queued
+// instead, and a single goroutine removes them in batches, grouped by
+// directory. On Linux, the files in each directory are unlinked relative
+// to a file descriptor for the directory, so the path of the directory is
+// only resolved once per batch. With WithFileRemovalRate, removals are
+// spread out to at most a given number per second in either mode, so
+// that they don't starve reads and writes of disk bandwidth.
+
+// The maximum number of files which are taken from the queue at once.
+const fileRemovalBatchSize = 10000
+
+// The number of files which are removed between checks of the rate
+// limit, in batches.
+const fileRemovalPacingChunk = 100
+
+// A rate limit for file removals. A nil *removalPacer does not limit
+// removals.
+type removalPacer struct {
+	interval time.Duration // Between removals.
+
+	mu   sync.Mutex
+	next time.Time // When the next removal is allowed.
+}
+
+func newRemovalPacer(perSecond int) *removalPacer {
+	return &removalPacer{interval: time.Second / time.Duration(perSecond)}
+}
+
+// Wait until n more removals are allowed.
+func (p *removalPacer) wait(n int) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	start := p.next
+	p.next = p.next.Add(time.Duration(n) * p.interval)
+	p.mu.Unlock()
+
+	time.Sleep(time.Until(start))
+}
+
+// A queue of files to remove in batches.
+type fileRemovalQueue struct {
+	mu    sync.Mutex
+	files []string
+	wake  chan struct{}
+
+	pacer      *removalPacer // May be nil.
+	pending    *atomic.Int64 // The files which were not removed yet.
+	queued     prometheus.Gauge
+	inProgress prometheus.Gauge
+	errors     prometheus.Counter
+}
+
+// Returns a fileRemovalQueue which uses the rate limit and metrics of c,
+// and starts the goroutine which removes the queued files.
+func newFileRemovalQueue(c *diskCache) *fileRemovalQueue {
+	q := &fileRemovalQueue{
+		wake:       make(chan struct{}, 1),
+		pacer:      c.removalPacer,
+		pending:    &c.pendingRemovals,
+		queued:     c.gaugeFileRemovalsQueued,
+		inProgress: c.gaugeFileRemovalsInProgress,
+		errors:     c.counterFileRemovalErrors,
+	}
+	go q.run()
+	return q
+}
+
+// Queue files for removal. This does not block.
+func (q *fileRemovalQueue) add(files ...string) {
+	q.mu.Lock()
+	q.files = append(q.files, files...)
+	q.mu.Unlock()
+	q.queued.Add(float64(len(files)))
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *fileRemovalQueue) run() {
+	for range q.wake {
+		for {
+			q.mu.Lock()
+			files := q.files
+			if len(files) > fileRemovalBatchSize {
+				files = files[:fileRemovalBatchSize:fileRemovalBatchSize]
+				q.files = q.files[fileRemovalBatchSize:]
+			} else {
+				q.files = nil
+			}
+			q.mu.Unlock()
+
+			if len(files) == 0 {
+				break
+			}
+			q.removeBatch(files)
+		}
+	}
+}
+
+// Remove a batch of files, grouped by directory.
+func (q *fileRemovalQueue) removeBatch(files []string) {
+	sort.Strings(files)
+
+	for len(files) > 0 {
+		dir := filepath.Dir(files[0])
+		n := 1
+		for n < len(files) && filepath.Dir(files[n]) == dir {
+			n++
+		}
+
+		names := make([]string, n)
+		for i, f := range files[:n] {
+			names[i] = filepath.Base(f)
+		}
+		files = files[n:]
+
+		for len(names) > 0 {
+			chunk := names
+			if len(chunk) > fileRemovalPacingChunk {
+				chunk = chunk[:fileRemovalPacingChunk]
+			}
+			names = names[len(chunk):]
+
+			q.pacer.wait(len(chunk))
+
+			q.inProgress.Add(float64(len(chunk)))
+			q.queued.Sub(float64(len(chunk)))
+			failed := removeFilesInDir(dir, chunk)
+			q.inProgress.Sub(float64(len(chunk)))
+			q.pending.Add(-int64(len(chunk)))
+			q.errors.Add(float64(failed))
+		}
+	}
+}
@@ -0,0 +1,37 @@
+//go:build linux
+// +build linux
+
+package disk
+
+import (
+	"log"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// Remove the named files in dir, relative to a file descriptor for dir,
+// and return the number of files which could not be removed. Files which
+// don't exist are not counted.
+func removeFilesInDir(dir string, names []string) int {
+	fd, err := unix.Open(dir, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err == unix.ENOENT {
+		return 0
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to open %s to remove evicted cache files: %v", dir, err)
+		return len(names)
+	}
+	defer unix.Close(fd)
+
+	failed := 0
+	for _, name := range names {
+		err = unix.Unlinkat(fd, name, 0)
+		if err != nil && err != unix.ENOENT {
+			failed++
+			log.Printf("ERROR: failed to remove evicted cache file: %s", filepath.Join(dir, name))
+		}
+	}
+
+	return failed
+}
@@ -0,0 +1,26 @@
+//go:build !linux
+// +build !linux
+
+package disk
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Remove the named files in dir, and return the number of files which
+// could not be removed. Files which don't exist are not counted.
+func removeFilesInDir(dir string, names []string) int {
+	failed := 0
+	for _, name := range names {
+		f := filepath.Join(dir, name)
+		err := os.Remove(f)
+		if err != nil && !os.IsNotExist(err) {
+			failed++
+			log.Printf("ERROR: failed to remove evicted cache file: %s", f)
+		}
+	}
+
+	return failed
+}
@@ -0,0 +1,126 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestRemovalPacer(t *testing.T) {
+	var nilPacer *removalPacer
+	nilPacer.wait(1000) // Doesn't block.
+
+	p := newRemovalPacer(100)
+
+	start := time.Now()
+	p.wait(10) // The first removals are allowed immediately.
+	p.wait(10) // These wait for the first 10 removals' 100ms.
+	elapsed := time.Since(start)
+
+	if elapsed < 90*time.Millisecond {
+		t.Error("Expected removals to be limited to 100 per second, took", elapsed)
+	}
+}
+
+func TestRemoveFilesInDir(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"a", "b"} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A non-empty directory can't be removed.
+	err := os.MkdirAll(filepath.Join(dir, "c", "child"), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := removeFilesInDir(dir, []string{"a", "b", "c", "missing"})
+	if failed != 1 {
+		t.Error("Expected 1 file to fail to be removed, got", failed)
+	}
+
+	for _, name := range []string{"a", "b"} {
+		_, err = os.Stat(filepath.Join(dir, name))
+		if !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", name, err)
+		}
+	}
+
+	failed = removeFilesInDir(filepath.Join(dir, "missing"), []string{"a"})
+	if failed != 0 {
+		t.Error("Expected no failures in a missing directory, got", failed)
+	}
+}
+
+func TestBatchFileRemovals(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, BlockSize*4,
+		WithBatchFileRemovals(),
+		WithFileRemovalRate(1000),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+
+	// Add more entries than fit in the cache, so some are evicted.
+	ctx := context.Background()
+	var paths []string
+	for i := 0; i < 8; i++ {
+		data, hash := testutils.RandomDataAndHash(BlockSize)
+		err = testCache.Put(ctx, cache.RAW, hash, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, _ := newKey(cache.RAW, hash)
+		item, _ := testCache.lru.peek(key)
+		paths = append(paths, testCache.getElementPath(key, item))
+	}
+
+	for i := 0; testutil.ToFloat64(testCache.gaugeFileRemovalsQueued) != 0; i++ {
+		if i == 1000 {
+			t.Fatal("Timed out waiting for the files to be removed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; testutil.ToFloat64(testCache.gaugeFileRemovalsInProgress) != 0; i++ {
+		if i == 1000 {
+			t.Fatal("Timed out waiting for the files to be removed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	testCache.mu.Lock()
+	defer testCache.mu.Unlock()
+
+	numFiles := 0
+	for _, p := range paths {
+		_, err = os.Stat(p)
+		if err == nil {
+			numFiles++
+		} else if !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+	}
+	if numFiles != testCache.lru.Len() {
+		t.Errorf("Expected %d files to be left, found %d", testCache.lru.Len(), numFiles)
+	}
+	if n := testutil.ToFloat64(testCache.counterFileRemovalErrors); n != 0 {
+		t.Error("Expected no removal errors, got", n)
+	}
+}
@@ -0,0 +1,222 @@
+package disk
+
+import (
+	"encoding/hex"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/tempfile"
+)
+
+// Rescans bring the index in line with shard directories which were
+// changed by something other than bazel-remote, without restarting it:
+// entries whose files are missing are removed from the index, and files
+// which are not in the index are added to it, as they would be at
+// startup.
+
+// RescanStats reports the result of a rescan.
+type RescanStats struct {
+	// The number of shard directories which were listed.
+	Shards int `json:"shards"`
+
+	// The number of index entries whose files were missing, and which
+	// were removed from the index.
+	Missing int `json:"missing"`
+
+	// The number of files which were not in the index, and were added.
+	Added int `json:"added"`
+
+	// The number of files which were not in the index, and were removed
+	// because the index has another file for the same entry, or because
+	// they are larger than the cache.
+	Removed int `json:"removed"`
+
+	// The number of files which were skipped, because their names are
+	// not recognised or they were modified within rescanGracePeriod.
+	Skipped int `json:"skipped"`
+}
+
+// Files which were modified this recently are not added to the index or
+// removed by rescans, because they might be being committed by a write.
+const rescanGracePeriod = time.Minute
+
+// Rescan reconciles the index with the shard directories of the given
+// kinds, or of all kinds if kinds is empty. If shard is not empty, only
+// the shard directory with that name, eg "ab", is rescanned for each
+// kind.
+func (c *diskCache) Rescan(kinds []cache.EntryKind, shard string) (RescanStats, error) {
+	var prefixes []byte
+	if shard == "" {
+		for i := 0; i < 256; i++ {
+			prefixes = append(prefixes, byte(i))
+		}
+	} else {
+		if !shardDirRegex.MatchString(shard) {
+			return RescanStats{}, badReqErr("Invalid shard directory %q, expected two lowercase hex characters", shard)
+		}
+		prefixes, _ = hex.DecodeString(shard)
+	}
+
+	if len(kinds) == 0 {
+		kinds = []cache.EntryKind{cache.AC, cache.CAS, cache.RAW}
+	}
+
+	var stats RescanStats
+	for _, kind := range kinds {
+		err := c.rescan(kind, prefixes, &stats)
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	log.Printf("Rescanned %d shard directories: removed %d entries with missing files from the index, added %d files, removed %d files and skipped %d files",
+		stats.Shards, stats.Missing, stats.Added, stats.Removed, stats.Skipped)
+
+	return stats, nil
+}
+
+// An index entry, found by a rescan.
+type rescanEntry struct {
+	key  Key
+	item lruItem
+}
+
+// Rescan the shard directories of kind whose hashes start with one of
+// prefixes, and add the results to stats.
+func (c *diskCache) rescan(kind cache.EntryKind, prefixes []byte, stats *RescanStats) error {
+	var wanted [256]bool
+	for _, p := range prefixes {
+		wanted[p] = true
+	}
+
+	// Find the index entries in the shards, in a single pass over the
+	// index.
+	var indexed [256][]rescanEntry
+	c.mu.Lock()
+	for key, ele := range c.lru.cache {
+		if key.Kind() == kind && wanted[key.digest[0]] {
+			indexed[key.digest[0]] = append(indexed[key.digest[0]],
+				rescanEntry{key: key, item: ele.Value.(*entry).value})
+		}
+	}
+	c.mu.Unlock()
+
+	for _, p := range prefixes {
+		err := c.rescanShardDir(kind, p, indexed[p], stats)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rescan a single shard directory, which had the given index entries.
+func (c *diskCache) rescanShardDir(kind cache.EntryKind, prefix byte, indexed []rescanEntry, stats *RescanStats) error {
+	dirName := filepath.Join(c.dir, kind.DirName(), hex.EncodeToString([]byte{prefix}))
+
+	des, err := listDir(dirName)
+	if err != nil {
+		return internalErr(err)
+	}
+	stats.Shards++
+
+	files := make(map[string]dirEntry, len(des))
+	for _, de := range des {
+		if !de.isDir {
+			files[de.name] = de
+		}
+	}
+
+	for _, e := range indexed {
+		name := path.Base(c.FileLocation(kind, e.item.legacy, e.key.Hash(), e.item.size, e.item.random.String()))
+		if _, found := files[name]; found {
+			delete(files, name)
+			continue
+		}
+
+		// The entry might have been replaced since we listed the
+		// directory.
+		_, err = os.Stat(filepath.Join(dirName, name))
+		if !os.IsNotExist(err) {
+			continue
+		}
+
+		c.mu.Lock()
+		removed := c.removeUnchanged(e.key, e.item)
+		c.mu.Unlock()
+		if removed {
+			c.counterZombieEntries.Inc()
+			stats.Missing++
+		}
+	}
+
+	// The remaining files are not in the index.
+	for name, de := range files {
+		if strings.HasSuffix(name, tempfile.Suffix) {
+			// Tempfiles of writes in progress, or of interrupted
+			// writes, which are removed at startup.
+			continue
+		}
+
+		key, item, err := parseCacheFile(kind, dirName, name, de.size)
+		if err != nil {
+			log.Printf("Warning: skipping file during rescan: %v", err)
+			stats.Skipped++
+			continue
+		}
+
+		filePath := filepath.Join(dirName, name)
+		fi, err := os.Stat(filePath)
+		if err != nil || time.Since(fi.ModTime()) < rescanGracePeriod {
+			stats.Skipped++
+			continue
+		}
+
+		c.mu.Lock()
+		if _, found := c.inflight[key]; found {
+			c.mu.Unlock()
+			stats.Skipped++
+			continue
+		}
+		if _, found := c.fetches[key]; found {
+			c.mu.Unlock()
+			stats.Skipped++
+			continue
+		}
+
+		_, found := c.lru.peek(key)
+		added := !found && c.lru.addAt(key, item, c.lastAccessTime(de))
+		c.mu.Unlock()
+
+		if added {
+			stats.Added++
+			continue
+		}
+
+		err = os.Remove(filePath)
+		if err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: failed to remove %q during rescan: %v", filePath, err)
+			continue
+		}
+		stats.Removed++
+	}
+
+	return nil
+}
+
+// Remove the index entry for key if it still refers to item, and return
+// true if it was removed. Must be called with mu held.
+func (c *diskCache) removeUnchanged(key Key, item lruItem) bool {
+	current, exists := c.lru.peek(key)
+	if !exists || current.random != item.random || current.legacy != item.legacy {
+		return false
+	}
+	c.lru.Remove(key)
+	return true
+}
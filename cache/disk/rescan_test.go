@@ -0,0 +1,120 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestRescan(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, BlockSize*10, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+
+	ctx := context.Background()
+	old := time.Now().Add(-time.Hour)
+
+	// Four RAW entries in the same shard.
+	var datas [][]byte
+	var hashes []string
+	for len(hashes) < 4 {
+		data, hash := testutils.RandomDataAndHash(100)
+		if len(hashes) > 0 && hash[:2] != hashes[0][:2] {
+			continue
+		}
+		datas = append(datas, data)
+		hashes = append(hashes, hash)
+	}
+	shard := hashes[0][:2]
+	shardDir := filepath.Join(cacheDir, cache.RAW.DirName(), shard)
+
+	for _, i := range []int{0, 1} {
+		err = testCache.Put(ctx, cache.RAW, hashes[i], int64(len(datas[i])), bytes.NewReader(datas[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Entry 0 is deleted.
+	key0, _ := newKey(cache.RAW, hashes[0])
+	item0, _ := testCache.lru.peek(key0)
+	err = os.Remove(testCache.getElementPath(key0, item0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Entry 1 has a stale file, entry 2 is restored from a backup and
+	// entry 3 is being written.
+	files := map[string][]byte{
+		hashes[1] + "-123": datas[1],
+		hashes[2] + "-456": datas[2],
+		hashes[3] + "-789": datas[3],
+		"README":           []byte("not a cache file"),
+	}
+	for name, data := range files {
+		p := filepath.Join(shardDir, name)
+		err = os.WriteFile(p, data, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != hashes[3]+"-789" {
+			err = os.Chtimes(p, old, old)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	_, err = testCache.Rescan(nil, "zz")
+	if cerr, ok := err.(*cache.Error); !ok || cerr.Code != 400 {
+		t.Fatal("Expected a bad request error for an invalid shard, got", err)
+	}
+
+	stats, err := testCache.Rescan([]cache.EntryKind{cache.RAW}, shard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := RescanStats{Shards: 1, Missing: 1, Added: 1, Removed: 1, Skipped: 2}
+	if stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+
+	for i, expectFound := range []bool{false, true, true, false} {
+		found, _ := testCache.Contains(ctx, cache.RAW, hashes[i], int64(len(datas[i])))
+		if found != expectFound {
+			t.Errorf("Entry %d: expected found=%t, got %t", i, expectFound, found)
+		}
+	}
+
+	rc, _, err := testCache.Get(ctx, cache.RAW, hashes[2], int64(len(datas[2])), 0)
+	if err != nil || rc == nil {
+		t.Fatal("Expected a cache hit for the added file, got", err)
+	}
+	rc.Close()
+
+	_, err = os.Stat(filepath.Join(shardDir, hashes[1]+"-123"))
+	if !os.IsNotExist(err) {
+		t.Error("Expected the stale file to be removed, got", err)
+	}
+
+	// Nothing changes when rescanning every shard.
+	stats, err = testCache.Rescan(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = RescanStats{Shards: 3 * 256, Skipped: 2}
+	if stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+}
@@ -0,0 +1,516 @@
+package disk
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/tempfile"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Uploads of large CAS blobs can be resumed after an interruption, eg a
+// dropped connection or a server restart, with WithResumableUploads. The
+// data is received in a partial file in the uploads directory, and the
+// number of bytes which have been synced to disk is recorded in a journal
+// after every uploadSyncInterval bytes, and when the upload is
+// interrupted. Clients which resume the upload ask for the committed
+// size, see CommittedSize, and send the rest of the blob from there. Once
+// the whole blob has been received it is added to the cache from the
+// partial file, like any other upload.
+//
+// At startup the journal is replayed: partial files are truncated to
+// their committed sizes, and files which are not in the journal are
+// removed, so the partial data which is reused after a crash is always
+// data that was synced.
+//
+// The journal is a text file with a "<hash>-<size> <committed size>"
+// record per line. The last record of each upload wins, and a committed
+// size of -1 means the upload finished or was abandoned.
+
+const uploadsDirName = "uploads"
+
+const journalName = "journal"
+
+// Partial data is synced and journaled after this many bytes.
+const uploadSyncInterval = 16 * 1024 * 1024
+
+// Partial uploads which haven't been resumed for this long are removed.
+const partialUploadTTL = 24 * time.Hour
+
+// The journal is rewritten when it has this many more records than
+// partial uploads.
+const maxStaleJournalRecords = 1000
+
+var errWriteOffset = &cache.Error{
+	Code: http.StatusBadRequest,
+	Text: "Writes from non-zero offsets are only supported for resumable uploads",
+}
+
+var errUploadInProgress = &cache.Error{
+	Code: http.StatusConflict,
+	Text: "The upload is in progress in another request",
+}
+
+type partialUpload struct {
+	committed int64     // The number of bytes synced to the partial file.
+	updated   time.Time // When the upload was last active.
+	active    bool      // Set while a request is writing to the file.
+}
+
+// It is safe to call the methods of a nil *uploadJournal, which doesn't
+// keep partial uploads.
+type uploadJournal struct {
+	dir     string // The uploads directory.
+	minSize int64  // Smaller blobs are not resumable.
+
+	mu      sync.Mutex
+	f       *os.File                  // The journal, opened for appending.
+	records int                       // The number of records in f.
+	uploads map[string]*partialUpload // By partial file name.
+
+	counterResumed prometheus.Counter
+}
+
+func newUploadJournal(cacheDir string, minSize int64) *uploadJournal {
+	return &uploadJournal{
+		dir:     filepath.Join(cacheDir, uploadsDirName),
+		minSize: minSize,
+		uploads: make(map[string]*partialUpload),
+		counterResumed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_cache_resumed_uploads_total",
+			Help: "The total number of CAS uploads which were resumed from a non-zero offset, reusing partially uploaded data",
+		}),
+	}
+}
+
+func (j *uploadJournal) registerMetrics() {
+	if j == nil {
+		return
+	}
+
+	prometheus.MustRegister(j.counterResumed)
+}
+
+// The name of the partial file for a blob.
+func partialName(hash string, size int64) string {
+	return hash + "-" + strconv.FormatInt(size, 10)
+}
+
+// Replay the journal, bring the partial files in line with it and
+// rewrite it. Must be called before any other methods.
+func (j *uploadJournal) recover() error {
+
+	err := os.MkdirAll(j.dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	committed, err := readJournal(filepath.Join(j.dir, journalName))
+	if err != nil {
+		return err
+	}
+
+	des, err := os.ReadDir(j.dir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, de := range des {
+		name := de.Name()
+		if name == journalName {
+			continue
+		}
+
+		path := filepath.Join(j.dir, name)
+		n, found := committed[name]
+		if found && n > 0 {
+			fi, err := de.Info()
+			if err == nil && fi.Mode().IsRegular() && fi.Size() >= n {
+				// Drop any data which was written after the last sync.
+				err = os.Truncate(path, n)
+				if err == nil {
+					j.uploads[name] = &partialUpload{committed: n, updated: now}
+					continue
+				}
+			}
+		}
+
+		err = os.RemoveAll(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(j.uploads) > 0 {
+		log.Printf("Found %d partial uploads which can be resumed", len(j.uploads))
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.rewrite()
+}
+
+// Returns the last committed size of each upload in the named journal,
+// omitting those which finished. A torn record at the end, from a crash
+// while it was being written, is ignored.
+func readJournal(name string) (map[string]int64, error) {
+	committed := make(map[string]int64)
+
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return committed, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			// Records without a newline are torn.
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			break
+		}
+		n, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			break
+		}
+
+		if n < 0 {
+			delete(committed, fields[0])
+		} else {
+			committed[fields[0]] = n
+		}
+	}
+
+	return committed, nil
+}
+
+// Replace the journal with one that only has a record for each partial
+// upload, and open it for appending. Must be called with j.mu held.
+func (j *uploadJournal) rewrite() error {
+	if j.f != nil {
+		j.f.Close()
+		j.f = nil
+	}
+
+	name := filepath.Join(j.dir, journalName)
+	tmpName := name + tempfile.Suffix
+
+	f, err := os.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, tempfile.FinalMode)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for pn, u := range j.uploads {
+		fmt.Fprintf(w, "%s %d\n", pn, u.committed)
+	}
+	err = w.Flush()
+	if err == nil {
+		err = f.Sync()
+	}
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpName, name)
+	}
+	if err == nil {
+		err = syncDir(j.dir)
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	j.f, err = os.OpenFile(name, os.O_WRONLY|os.O_APPEND, tempfile.FinalMode)
+	if err != nil {
+		return err
+	}
+	j.records = len(j.uploads)
+
+	return nil
+}
+
+// Append a record to the journal and sync it. Must be called with j.mu
+// held.
+func (j *uploadJournal) record(pn string, committed int64) error {
+	if j.f == nil {
+		return errors.New("The uploads journal is not open")
+	}
+
+	_, err := fmt.Fprintf(j.f, "%s %d\n", pn, committed)
+	if err == nil {
+		err = j.f.Sync()
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write to the uploads journal: %w", err)
+	}
+
+	j.records++
+	if j.records > len(j.uploads)+maxStaleJournalRecords {
+		return j.rewrite()
+	}
+
+	return nil
+}
+
+// Remove the partial uploads which haven't been active for
+// partialUploadTTL. Must be called with j.mu held.
+func (j *uploadJournal) expire(now time.Time) {
+	for pn, u := range j.uploads {
+		if u.active || now.Sub(u.updated) < partialUploadTTL {
+			continue
+		}
+
+		delete(j.uploads, pn)
+		os.Remove(filepath.Join(j.dir, pn))
+		err := j.record(pn, -1)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// Open the partial file for a blob, positioned at offset, for a request
+// which writes to it. The partial data after offset is discarded. end
+// must be called when the request has finished.
+func (j *uploadJournal) begin(hash string, size int64, offset int64) (string, *os.File, error) {
+	pn := partialName(hash, size)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	j.expire(now)
+
+	u, found := j.uploads[pn]
+	if found && u.active {
+		return "", nil, errUploadInProgress
+	}
+
+	var committed int64
+	if found {
+		committed = u.committed
+	}
+	if offset > committed {
+		return "", nil, badReqErr("Invalid write offset %d, %d bytes have been committed",
+			offset, committed)
+	}
+
+	f, err := os.OpenFile(filepath.Join(j.dir, pn), os.O_WRONLY|os.O_CREATE, tempfile.FinalMode)
+	if err == nil {
+		err = f.Truncate(offset)
+		if err == nil {
+			_, err = f.Seek(offset, io.SeekStart)
+		}
+		if err != nil {
+			f.Close()
+		}
+	}
+	if err != nil {
+		return "", nil, internalErr(err)
+	}
+
+	if !found {
+		u = &partialUpload{}
+		j.uploads[pn] = u
+	}
+	if offset < committed {
+		u.committed = offset
+		err = j.record(pn, offset)
+		if err != nil {
+			f.Close()
+			u.committed = 0
+			return "", nil, internalErr(err)
+		}
+	}
+	u.active = true
+	u.updated = now
+
+	if offset > 0 {
+		j.counterResumed.Inc()
+	}
+
+	return pn, f, nil
+}
+
+// Record that the first committed bytes of the partial file have been
+// synced.
+func (j *uploadJournal) commit(pn string, committed int64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.uploads[pn].committed = committed
+	return j.record(pn, committed)
+}
+
+// Finish a request which wrote to the partial file. If keep is false the
+// partial upload is removed, otherwise it can be resumed.
+func (j *uploadJournal) end(pn string, keep bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	u := j.uploads[pn]
+	u.active = false
+	u.updated = time.Now()
+	if keep && u.committed > 0 {
+		return
+	}
+
+	delete(j.uploads, pn)
+	os.Remove(filepath.Join(j.dir, pn))
+	err := j.record(pn, -1)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (j *uploadJournal) committedSize(hash string, size int64) int64 {
+	if j == nil {
+		return 0
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	u, found := j.uploads[partialName(hash, size)]
+	if !found || u.active {
+		return 0
+	}
+	return u.committed
+}
+
+// ResumablePut is like Put for CAS blobs, except the data from r starts
+// at offset. If resumable uploads are enabled and the blob is large
+// enough, the data which was received before r fails is kept, so the
+// upload can be resumed from CommittedSize. Otherwise offset must be 0.
+func (c *diskCache) ResumablePut(ctx context.Context, hash string, size int64, offset int64, r io.Reader) error {
+	defer func() {
+		// Like Put, read all the data from r before returning.
+		_, _ = io.Copy(io.Discard, r)
+	}()
+
+	if c.uploads == nil || size < c.uploads.minSize {
+		if offset != 0 {
+			return errWriteOffset
+		}
+		return c.Put(ctx, cache.CAS, hash, size, r)
+	}
+
+	if offset < 0 || offset > size {
+		return badReqErr("Invalid write offset %d for a blob of size %d", offset, size)
+	}
+	if size > c.maxBlobSize {
+		return badReqErr("Blob size %d too large, max blob size is %d", size, c.maxBlobSize)
+	}
+	if c.isReadOnly() {
+		return errReadOnly
+	}
+	if c.loading.Load() {
+		return errLoading
+	}
+	if c.refuseStandbyWrite(ctx) {
+		return errStandby
+	}
+
+	pn, f, err := c.uploads.begin(hash, size, offset)
+	if err != nil {
+		return err
+	}
+
+	keep := true
+	defer func() {
+		c.uploads.end(pn, keep)
+	}()
+
+	committed := offset
+	for committed < size {
+		n, copyErr := io.CopyN(f, r, min64(uploadSyncInterval, size-committed))
+		if n > 0 {
+			err = f.Sync()
+			if err == nil {
+				committed += n
+				err = c.uploads.commit(pn, committed)
+			}
+			if err != nil {
+				f.Close()
+				c.recordWrite(err)
+				return internalErr(err)
+			}
+		}
+
+		if copyErr == io.EOF {
+			f.Close()
+			return badReqErr("Unexpected end of data at offset %d, expected %d bytes",
+				committed, size)
+		}
+		if copyErr != nil {
+			f.Close()
+			return internalErr(copyErr)
+		}
+	}
+
+	err = f.Close()
+	if err != nil {
+		return internalErr(err)
+	}
+
+	rf, err := os.Open(filepath.Join(c.uploads.dir, pn))
+	if err != nil {
+		return internalErr(err)
+	}
+	defer rf.Close()
+
+	err = c.Put(ctx, cache.CAS, hash, size, rf)
+	if err != nil {
+		// Keep the data if the cache is temporarily unable to store it,
+		// but not if it was invalid.
+		var cerr *cache.Error
+		keep = errors.As(err, &cerr) && (cerr.Code == http.StatusServiceUnavailable ||
+			cerr.Code == http.StatusInsufficientStorage)
+		return err
+	}
+
+	keep = false
+	return nil
+}
+
+// CommittedSize returns the number of bytes of the given CAS blob which
+// can be skipped when its upload is resumed with ResumablePut, or 0.
+func (c *diskCache) CommittedSize(hash string, size int64) int64 {
+	return c.uploads.committedSize(hash, size)
+}
+
+func min64(a int64, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,211 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+var errInterrupted = errors.New("interrupted")
+
+// Returns the first n bytes of data, and then errInterrupted.
+func interruptedReader(data []byte, n int) io.Reader {
+	return io.MultiReader(bytes.NewReader(data[:n]), iotest.ErrReader(errInterrupted))
+}
+
+func getCAS(t *testing.T, c Cache, hash string, size int64) []byte {
+	t.Helper()
+
+	rc, _, err := c.Get(context.Background(), cache.CAS, hash, size, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc == nil {
+		t.Fatal("Expected a cache hit for", hash)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestResumablePut(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := New(cacheDir, 100*BlockSize, WithResumableUploads(1000),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	data, hash := testutils.RandomDataAndHash(5000)
+	size := int64(len(data))
+
+	err = c.ResumablePut(ctx, hash, size, 0, interruptedReader(data, 2000))
+	if err == nil {
+		t.Fatal("Expected the interrupted upload to fail")
+	}
+	if committed := c.CommittedSize(hash, size); committed != 2000 {
+		t.Fatal("Expected 2000 committed bytes, found", committed)
+	}
+
+	// Offsets past the committed size are rejected.
+	err = c.ResumablePut(ctx, hash, size, 3000, bytes.NewReader(data[3000:]))
+	var cerr *cache.Error
+	if !errors.As(err, &cerr) || cerr.Code != http.StatusBadRequest {
+		t.Fatal("Expected a bad request error, got", err)
+	}
+	if committed := c.CommittedSize(hash, size); committed != 2000 {
+		t.Fatal("Expected 2000 committed bytes, found", committed)
+	}
+
+	// Resuming from an earlier offset discards the rest.
+	err = c.ResumablePut(ctx, hash, size, 1000, interruptedReader(data[1000:], 500))
+	if err == nil {
+		t.Fatal("Expected the interrupted upload to fail")
+	}
+	if committed := c.CommittedSize(hash, size); committed != 1500 {
+		t.Fatal("Expected 1500 committed bytes, found", committed)
+	}
+
+	err = c.ResumablePut(ctx, hash, size, 1500, bytes.NewReader(data[1500:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if committed := c.CommittedSize(hash, size); committed != 0 {
+		t.Fatal("Expected the partial upload to be removed, found", committed)
+	}
+	if !bytes.Equal(getCAS(t, c, hash, size), data) {
+		t.Fatal("Unexpected data")
+	}
+
+	// Smaller blobs can't be resumed.
+	smallData, smallHash := testutils.RandomDataAndHash(500)
+	err = c.ResumablePut(ctx, smallHash, int64(len(smallData)), 0, interruptedReader(smallData, 200))
+	if err == nil {
+		t.Fatal("Expected the interrupted upload to fail")
+	}
+	if committed := c.CommittedSize(smallHash, int64(len(smallData))); committed != 0 {
+		t.Fatal("Expected no committed bytes, found", committed)
+	}
+	err = c.ResumablePut(ctx, smallHash, int64(len(smallData)), 200, bytes.NewReader(smallData[200:]))
+	if err != errWriteOffset {
+		t.Fatal("Expected errWriteOffset, got", err)
+	}
+
+	des, err := os.ReadDir(filepath.Join(cacheDir, uploadsDirName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(des) != 1 || des[0].Name() != journalName {
+		t.Fatal("Expected only the journal in the uploads directory, found", des)
+	}
+}
+
+func TestResumablePutRecovery(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := New(cacheDir, 100*BlockSize, WithResumableUploads(1),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	data, hash := testutils.RandomDataAndHash(5000)
+	size := int64(len(data))
+
+	err = c.ResumablePut(ctx, hash, size, 0, interruptedReader(data, 3000))
+	if err == nil {
+		t.Fatal("Expected the interrupted upload to fail")
+	}
+
+	// Simulate a crash: data which was written after the last sync, a
+	// torn journal record, an untracked partial file and a tempfile.
+	uploadsDir := filepath.Join(cacheDir, uploadsDirName)
+	pf, err := os.OpenFile(filepath.Join(uploadsDir, partialName(hash, size)), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pf.Write([]byte("garbage"))
+	pf.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jf, err := os.OpenFile(filepath.Join(uploadsDir, journalName), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = jf.Write([]byte(partialName(hash, size) + " 30"))
+	jf.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherHash := testutils.RandomDataAndHash(100)
+	untracked := filepath.Join(uploadsDir, partialName(otherHash, 100))
+	err = os.WriteFile(untracked, []byte("untracked"), 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmp := filepath.Join(cacheDir, "cas.v2", otherHash[:2], otherHash+"-100-123.tmp")
+	err = os.WriteFile(tmp, []byte("incomplete"), 0664)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = New(cacheDir, 100*BlockSize, WithResumableUploads(1),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if committed := c.CommittedSize(hash, size); committed != 3000 {
+		t.Fatal("Expected 3000 committed bytes, found", committed)
+	}
+	for _, name := range []string{untracked, tmp} {
+		_, err = os.Stat(name)
+		if !os.IsNotExist(err) {
+			t.Fatalf("Expected %q to be removed, got %v", name, err)
+		}
+	}
+	_, _, numItems, _ := c.Stats()
+	if numItems != 0 {
+		t.Fatal("Expected no cache items, found", numItems)
+	}
+
+	err = c.ResumablePut(ctx, hash, size, 3000, bytes.NewReader(data[3000:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(getCAS(t, c, hash, size), data) {
+		t.Fatal("Unexpected data")
+	}
+
+	// The uploads directory is removed if resumable uploads are disabled.
+	_, err = New(cacheDir, 100*BlockSize, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(uploadsDir)
+	if !os.IsNotExist(err) {
+		t.Fatal("Expected the uploads directory to be removed, got", err)
+	}
+}
@@ -0,0 +1,88 @@
+//go:build linux
+// +build linux
+
+package disk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Set if statx(2) is not available, eg on kernels older than 4.11 or
+// in some sandboxes, in which case we use fstatat(2) instead.
+var statxUnavailable atomic.Bool
+
+// Returns the entries of a shard directory. The files are stat'ed
+// relative to the directory's file descriptor, which avoids resolving
+// the full path of each file, and statx only asks the filesystem for the
+// size, access time and modification time.
+func listDir(dirName string) ([]dirEntry, error) {
+	f, err := os.Open(dirName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// The file types are taken from the getdents64 results, so this
+	// doesn't stat the files.
+	des, err := f.ReadDir(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	fd := int(f.Fd())
+
+	entries := make([]dirEntry, len(des))
+	for i, de := range des {
+		entries[i].name = de.Name()
+		entries[i].isDir = de.IsDir()
+		if entries[i].isDir {
+			continue
+		}
+
+		entries[i].size, entries[i].atime, entries[i].mtime, err = statAt(fd, entries[i].name)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get file info for %q: %w",
+				filepath.Join(dirName, entries[i].name), err)
+		}
+	}
+
+	return entries, nil
+}
+
+// Returns the size, access time and modification time of the named file
+// in the directory referred to by dirfd.
+func statAt(dirfd int, name string) (int64, time.Time, time.Time, error) {
+	if !statxUnavailable.Load() {
+		const mask = unix.STATX_SIZE | unix.STATX_ATIME | unix.STATX_MTIME
+
+		var stx unix.Statx_t
+		err := unix.Statx(dirfd, name,
+			unix.AT_SYMLINK_NOFOLLOW|unix.AT_STATX_DONT_SYNC, mask, &stx)
+		if err == nil && stx.Mask&mask == mask {
+			return int64(stx.Size), time.Unix(stx.Atime.Sec, int64(stx.Atime.Nsec)),
+				time.Unix(stx.Mtime.Sec, int64(stx.Mtime.Nsec)), nil
+		}
+		if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EPERM) {
+			statxUnavailable.Store(true)
+		} else if err != nil {
+			return 0, time.Time{}, time.Time{}, &os.PathError{Op: "statx", Path: name, Err: err}
+		}
+		// Otherwise the filesystem didn't return all the fields in
+		// mask, so try fstatat.
+	}
+
+	var st unix.Stat_t
+	err := unix.Fstatat(dirfd, name, &st, unix.AT_SYMLINK_NOFOLLOW)
+	if err != nil {
+		return 0, time.Time{}, time.Time{}, &os.PathError{Op: "fstatat", Path: name, Err: err}
+	}
+
+	return st.Size, time.Unix(st.Atim.Unix()), time.Unix(st.Mtim.Unix()), nil
+}
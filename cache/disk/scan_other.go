@@ -0,0 +1,39 @@
+//go:build !linux
+// +build !linux
+
+package disk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Returns the entries of a shard directory.
+func listDir(dirName string) ([]dirEntry, error) {
+	des, err := os.ReadDir(dirName)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]dirEntry, len(des))
+	for i, de := range des {
+		entries[i].name = de.Name()
+		entries[i].isDir = de.IsDir()
+		if entries[i].isDir {
+			continue
+		}
+
+		info, err := de.Info()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get file info for %q: %w",
+				filepath.Join(dirName, entries[i].name), err)
+		}
+
+		entries[i].size = info.Size()
+		entries[i].atime = accessTime(info)
+		entries[i].mtime = info.ModTime()
+	}
+
+	return entries, nil
+}
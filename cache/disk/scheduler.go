@@ -0,0 +1,251 @@
+package disk
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// When the disk is saturated, the I/O of batch requests, eg from nightly
+// CI builds, yields to that of interactive requests, see cache.Priority.
+// The I/O scheduler limits the number of concurrent disk reads and writes
+// and proxy requests. When requests are waiting for a slot, it serves the
+// priorities in weighted round robin order. This is only enabled with
+// WithIOScheduler.
+
+const defaultInteractiveWeight = 4
+
+type ioWaiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// It is safe to call the methods of a nil *ioScheduler, which doesn't
+// limit anything.
+type ioScheduler struct {
+	mu      sync.Mutex
+	free    int
+	weights [cache.NumPriorities]int
+	credits [cache.NumPriorities]int
+	queues  [cache.NumPriorities][]*ioWaiter // FIFO.
+
+	counterWaits   *prometheus.CounterVec
+	counterWaitSec *prometheus.CounterVec
+}
+
+// Returns an ioScheduler which allows slots concurrent I/O operations,
+// and serves interactiveWeight waiting interactive operations for each
+// waiting batch operation.
+func newIOScheduler(slots int, interactiveWeight int) *ioScheduler {
+	s := &ioScheduler{
+		free: slots,
+		counterWaits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_io_waits_total",
+			Help: "The total number of disk I/O operations and proxy requests which waited for the I/O scheduler, by priority",
+		}, []string{"priority"}),
+		counterWaitSec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bazel_remote_disk_io_wait_seconds_total",
+			Help: "The total time that disk I/O operations and proxy requests waited for the I/O scheduler, by priority",
+		}, []string{"priority"}),
+	}
+
+	s.weights[cache.PriorityInteractive] = interactiveWeight
+	s.weights[cache.PriorityBatch] = 1
+	s.credits = s.weights
+
+	return s
+}
+
+func (s *ioScheduler) registerMetrics() {
+	if s == nil {
+		return
+	}
+
+	prometheus.MustRegister(s.counterWaits)
+	prometheus.MustRegister(s.counterWaitSec)
+}
+
+// Wait for a slot for an I/O operation of the request with the given
+// context. Unless an error is returned, release must be called when the
+// operation has finished.
+func (s *ioScheduler) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	p := cache.RequestPriority(ctx)
+
+	s.mu.Lock()
+	if s.free > 0 {
+		// Slots are handed over to waiting operations, so nothing is
+		// waiting.
+		s.free--
+		s.mu.Unlock()
+		return nil
+	}
+	w := &ioWaiter{ready: make(chan struct{})}
+	s.queues[p] = append(s.queues[p], w)
+	s.mu.Unlock()
+
+	start := time.Now()
+	defer func() {
+		s.counterWaits.WithLabelValues(p.String()).Inc()
+		s.counterWaitSec.WithLabelValues(p.String()).Add(time.Since(start).Seconds())
+	}()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	if w.granted {
+		// We were given a slot after all, pass it on.
+		s.releaseLocked()
+	} else {
+		q := s.queues[p]
+		for i := range q {
+			if q[i] == w {
+				s.queues[p] = append(q[:i], q[i+1:]...)
+				break
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	return ctx.Err()
+}
+
+// Release a slot acquired by acquire.
+func (s *ioScheduler) release() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.releaseLocked()
+	s.mu.Unlock()
+}
+
+// Must be called with s.mu held.
+func (s *ioScheduler) releaseLocked() {
+	w := s.next()
+	if w == nil {
+		s.free++
+		return
+	}
+
+	w.granted = true
+	close(w.ready)
+}
+
+// Remove and return the next waiting operation, or nil if there is none.
+// Must be called with s.mu held.
+func (s *ioScheduler) next() *ioWaiter {
+	for pass := 0; pass < 2; pass++ {
+		for p := range s.queues {
+			if len(s.queues[p]) > 0 && s.credits[p] > 0 {
+				s.credits[p]--
+				w := s.queues[p][0]
+				s.queues[p][0] = nil
+				s.queues[p] = s.queues[p][1:]
+				return w
+			}
+		}
+
+		// The priorities with waiting operations have used up their
+		// credits, start a new round.
+		s.credits = s.weights
+	}
+
+	return nil
+}
+
+// Check whether the proxy backend has an item, after waiting for a slot.
+func (c *diskCache) proxyContains(ctx context.Context, kind cache.EntryKind, hash string) (bool, int64) {
+	if c.proxyDisabled(ctx) {
+		return false, -1
+	}
+
+	err := c.io.acquire(ctx)
+	if err != nil {
+		return false, -1
+	}
+	defer c.io.release()
+
+	return c.proxy.Contains(ctx, kind, hash)
+}
+
+// Returns rc, or a wrapper which waits for a slot for each read from rc,
+// if there is a scheduler.
+func (s *ioScheduler) readCloser(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	if s == nil {
+		return rc
+	}
+
+	return &scheduledReadCloser{ReadCloser: rc, ctx: ctx, s: s}
+}
+
+type scheduledReadCloser struct {
+	io.ReadCloser
+	ctx context.Context
+	s   *ioScheduler
+}
+
+func (r *scheduledReadCloser) Read(p []byte) (int, error) {
+	err := r.s.acquire(r.ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer r.s.release()
+
+	return r.ReadCloser.Read(p)
+}
+
+// Returns r, or a wrapper which holds a slot from each read from r until
+// the next one, if there is a scheduler. This covers the writes of the
+// data that was read, but not waiting for more data. The returned
+// function must be called to release the last slot.
+func (s *ioScheduler) writeSource(ctx context.Context, r io.Reader) (io.Reader, func()) {
+	if s == nil {
+		return r, func() {}
+	}
+
+	src := &scheduledSource{Reader: r, ctx: ctx, s: s}
+	return src, src.done
+}
+
+type scheduledSource struct {
+	io.Reader
+	ctx  context.Context
+	s    *ioScheduler
+	held bool
+}
+
+func (r *scheduledSource) Read(p []byte) (int, error) {
+	r.done()
+
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		acqErr := r.s.acquire(r.ctx)
+		if acqErr != nil {
+			return 0, acqErr
+		}
+		r.held = true
+	}
+
+	return n, err
+}
+
+func (r *scheduledSource) done() {
+	if r.held {
+		r.s.release()
+		r.held = false
+	}
+}
@@ -0,0 +1,169 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+// Wait until n operations are waiting for s.
+func waitForQueued(t *testing.T, s *ioScheduler, n int) {
+	t.Helper()
+
+	for i := 0; i < 1000; i++ {
+		s.mu.Lock()
+		queued := 0
+		for _, q := range s.queues {
+			queued += len(q)
+		}
+		s.mu.Unlock()
+
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatal("Timed out waiting for operations to queue")
+}
+
+func TestIOSchedulerWeights(t *testing.T) {
+	s := newIOScheduler(1, 2)
+
+	err := s.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	granted := make(chan struct{})
+
+	priorities := []cache.Priority{
+		cache.PriorityBatch, cache.PriorityBatch,
+		cache.PriorityInteractive, cache.PriorityInteractive,
+		cache.PriorityInteractive, cache.PriorityInteractive,
+	}
+	for i, p := range priorities {
+		go func(p cache.Priority) {
+			err := s.acquire(cache.WithPriority(context.Background(), p))
+			if err != nil {
+				t.Error(err)
+			}
+
+			mu.Lock()
+			order = append(order, p.String()[:1])
+			mu.Unlock()
+			granted <- struct{}{}
+		}(p)
+
+		waitForQueued(t, s, i+1)
+	}
+
+	// Each operation that gets the slot holds it until we release it.
+	for range priorities {
+		s.release()
+		<-granted
+	}
+	s.release()
+
+	actual := strings.Join(order, "")
+	if actual != "iibiib" {
+		t.Fatal("Expected the order iibiib, got", actual)
+	}
+
+	if s.free != 1 {
+		t.Fatal("Expected the slot to be free, found", s.free)
+	}
+}
+
+func TestIOSchedulerCancel(t *testing.T) {
+	s := newIOScheduler(1, 1)
+
+	err := s.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.acquire(ctx)
+	}()
+
+	waitForQueued(t, s, 1)
+	cancel()
+
+	err = <-done
+	if err != context.Canceled {
+		t.Fatal("Expected a canceled acquire, got", err)
+	}
+	waitForQueued(t, s, 0)
+
+	s.release()
+	if s.free != 1 {
+		t.Fatal("Expected the slot to be free, found", s.free)
+	}
+}
+
+func TestIOSchedulerNil(t *testing.T) {
+	var s *ioScheduler
+
+	err := s.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.release()
+
+	r := strings.NewReader("data")
+	if src, done := s.writeSource(context.Background(), r); src != r {
+		t.Fatal("Expected the reader to be returned unchanged")
+	} else {
+		done()
+	}
+}
+
+func TestIOSchedulerCache(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := New(cacheDir, 8*BlockSize, WithIOScheduler(1, 0), WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := cache.WithPriority(context.Background(), cache.PriorityBatch)
+	for _, kind := range []cache.EntryKind{cache.CAS, cache.RAW} {
+		data, hash := testutils.RandomDataAndHash(3000)
+		err = c.Put(ctx, kind, hash, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rc, _, err := c.Get(ctx, kind, hash, int64(len(data)), 0)
+		if err != nil || rc == nil {
+			t.Fatal("Expected a cache hit, got", err)
+		}
+		found, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(found, data) {
+			t.Fatalf("Unexpected %s data", kind)
+		}
+	}
+
+	// All the slots were released.
+	if c.(*diskCache).io.free != 1 {
+		t.Fatal("Expected the slot to be free, found", c.(*diskCache).io.free)
+	}
+}
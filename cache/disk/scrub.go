@@ -0,0 +1,119 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+	"github.com/buchgr/bazel-remote/v2/utils/bufpool"
+	"github.com/buchgr/bazel-remote/v2/utils/sharedfile"
+)
+
+// Verify the CAS blobs in the cache during each maintenance window, and
+// remove the ones which are corrupt. If a window closes before all the
+// blobs have been verified, scrubbing continues in the next window.
+func (c *diskCache) scrub() {
+	var last time.Time
+	for {
+		start, err := c.maintenance.Wait(context.Background(), last)
+		if err != nil {
+			return
+		}
+
+		log.Println("Maintenance window opened, scrubbing the cache")
+		last = c.scrubPass(start)
+	}
+}
+
+// Returns the start of the maintenance window in which the pass finished.
+func (c *diskCache) scrubPass(start time.Time) time.Time {
+	c.mu.Lock()
+	keys := c.lru.keys()
+	c.mu.Unlock()
+
+	var checked, corrupt int
+
+	for i, key := range keys {
+		if !c.maintenance.Active() {
+			log.Printf("Maintenance window closed, pausing scrubbing after %d of %d entries",
+				i, len(keys))
+			start, _ = c.maintenance.Wait(context.Background(), time.Time{})
+		}
+
+		if key.Kind() != cache.CAS {
+			continue
+		}
+
+		found, valid, err := c.verifyBlob(key)
+		if err != nil {
+			log.Printf("Warning: failed to scrub %s: %v", key, err)
+			continue
+		}
+		if !found {
+			continue // Evicted in the meantime.
+		}
+
+		checked++
+		c.counterScrubbedBlobs.Inc()
+
+		if !valid {
+			corrupt++
+			c.counterCorruptBlobs.Inc()
+			log.Printf("Removed corrupt blob %s", key)
+		}
+	}
+
+	log.Printf("Finished scrubbing the cache: verified %d CAS blobs, removed %d corrupt blobs",
+		checked, corrupt)
+
+	return start
+}
+
+// Check that the content of the CAS blob stored under key matches its
+// hash, and remove it from the cache if it does not.
+func (c *diskCache) verifyBlob(key Key) (found bool, valid bool, err error) {
+	c.mu.Lock()
+	item, found := c.lru.peek(key)
+	c.mu.Unlock()
+	if !found {
+		return false, false, nil
+	}
+
+	f, err := sharedfile.Open(filepath.Join(c.dir, c.FileLocation(cache.CAS, item.legacy, key.Hash(), item.size, item.random.String())))
+	if os.IsNotExist(err) {
+		return false, false, nil // Replaced or evicted in the meantime.
+	}
+	if err != nil {
+		return true, false, err
+	}
+
+	var rc io.ReadCloser = f
+	if !item.legacy {
+		rc, err = casblob.GetUncompressedReadCloser(c.zstd, f, item.size, 0)
+	}
+
+	valid = false
+	if err == nil {
+		h := sha256.New()
+		var n int64
+		n, err = bufpool.Copy(h, rc)
+		rc.Close()
+		valid = err == nil && n == item.size && bytes.Equal(h.Sum(nil), key.digest[:])
+	}
+	f.Close()
+
+	if valid {
+		return true, true, nil
+	}
+
+	c.removeCorruptBlob(key, item)
+
+	return true, false, nil
+}
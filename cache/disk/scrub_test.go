@@ -0,0 +1,65 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/maintenance"
+)
+
+func TestScrub(t *testing.T) {
+	for _, mode := range []string{"zstd", "uncompressed"} {
+		cacheDir := tempDir(t)
+		defer os.RemoveAll(cacheDir)
+
+		window := maintenance.NewWindow(nil, time.Hour)
+
+		testCacheI, err := New(cacheDir, BlockSize*10,
+			WithAccessLogger(testutils.NewSilentLogger()),
+			WithStorageMode(mode))
+		if err != nil {
+			t.Fatal(err)
+		}
+		testCache := testCacheI.(*diskCache)
+
+		// Scrub below, rather than in the background.
+		testCache.maintenance = window
+
+		ctx := context.Background()
+		good := []byte("good data")
+		bad := []byte("bad data!")
+		for _, data := range [][]byte{good, bad} {
+			err = testCache.Put(ctx, cache.CAS, hashStr(string(data)), int64(len(data)), bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		badHash := hashStr(string(bad))
+		badKey, _ := newKey(cache.CAS, badHash)
+		item, _ := testCache.lru.peek(badKey)
+		badPath := path.Join(cacheDir, testCache.FileLocation(cache.CAS, item.legacy, badHash, item.size, item.random.String()))
+		err = os.WriteFile(badPath, []byte("corrupted"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		window.Trigger()
+		testCache.scrubPass(time.Now())
+
+		found, _ := testCache.Contains(ctx, cache.CAS, hashStr(string(good)), int64(len(good)))
+		if !found {
+			t.Errorf("%s: expected the valid blob to be kept", mode)
+		}
+		found, _ = testCache.Contains(ctx, cache.CAS, badHash, int64(len(bad)))
+		if found {
+			t.Errorf("%s: expected the corrupt blob to be removed", mode)
+		}
+	}
+}
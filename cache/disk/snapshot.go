@@ -0,0 +1,114 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// A snapshot lists the entries in the cache at one point in time, so
+// that they can be exported while the cache keeps serving requests.
+// Entries which are evicted or overwritten while a snapshot is open are
+// removed from the index as usual, but their files are only removed
+// after the last open snapshot is closed, so every entry in a snapshot
+// can be read until then. Meanwhile the cache directory can grow beyond
+// the maximum cache size, by the size of those files.
+
+// Snapshot is a consistent view of the entries in a cache, which must
+// be closed when it is no longer needed. See diskCache.Snapshot.
+type Snapshot struct {
+	// The entries in the cache when the snapshot was taken, from the
+	// least to the most recently used. The paths are relative to the
+	// cache directory, and the access and modification times are not
+	// set.
+	Entries []EntryInfo
+
+	// When the snapshot was taken.
+	Created time.Time
+
+	c      *diskCache
+	closed bool // Protected by c.mu.
+}
+
+// Snapshot returns a snapshot of the entries in the cache.
+func (c *diskCache) Snapshot() *Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &Snapshot{
+		Entries: make([]EntryInfo, 0, c.lru.Len()),
+		Created: time.Now(),
+		c:       c,
+	}
+	for ele := c.lru.ll.Back(); ele != nil; ele = ele.Prev() {
+		e := ele.Value.(*entry)
+		kind := e.key.Kind()
+		hash := e.key.Hash()
+		s.Entries = append(s.Entries, EntryInfo{
+			Kind:        kind,
+			Hash:        hash,
+			Path:        c.FileLocation(kind, e.value.legacy, hash, e.value.size, e.value.random.String()),
+			LogicalSize: e.value.size,
+			SizeOnDisk:  e.value.sizeOnDisk,
+			Random:      e.value.random.String(),
+			Legacy:      e.value.legacy,
+		})
+	}
+	c.openSnapshots++
+
+	return s
+}
+
+// Open opens the file of one of the snapshot's entries for reading.
+func (s *Snapshot) Open(e EntryInfo) (*os.File, error) {
+	return os.Open(filepath.Join(s.c.dir, e.Path))
+}
+
+// Close releases the snapshot. It is safe to call this more than once.
+func (s *Snapshot) Close() {
+	c := s.c
+
+	c.mu.Lock()
+	if s.closed {
+		c.mu.Unlock()
+		return
+	}
+	s.closed = true
+	c.openSnapshots--
+
+	var removals []string
+	if c.openSnapshots == 0 {
+		removals = c.deferredRemovals
+		c.deferredRemovals = nil
+	}
+	c.mu.Unlock()
+
+	if len(removals) > 0 && c.removals != nil {
+		c.removals.add(removals...)
+	} else if len(removals) > 0 {
+		go func() {
+			for _, f := range removals {
+				c.removeFile(f)
+			}
+		}()
+	}
+}
+
+// Remove the file of an entry which was removed from the index, unless
+// a snapshot is open. This must be called with the lock held.
+func (c *diskCache) removeEvictedFile(f string) {
+	c.pendingRemovals.Add(1)
+
+	if c.openSnapshots > 0 {
+		c.deferredRemovals = append(c.deferredRemovals, f)
+		return
+	}
+
+	if c.removals != nil {
+		c.removals.add(f)
+		return
+	}
+
+	// Run in a goroutine so we can release the lock sooner.
+	go c.removeFile(f)
+}
@@ -0,0 +1,83 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestSnapshot(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, BlockSize*2, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+
+	ctx := context.Background()
+	put := func(data string) {
+		err := testCache.Put(ctx, cache.RAW, hashStr(data), int64(len(data)), bytes.NewReader([]byte(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	put("first")
+	put("second")
+
+	s := testCache.Snapshot()
+	defer s.Close()
+
+	if len(s.Entries) != 2 || s.Entries[0].Hash != hashStr("first") || s.Entries[1].Hash != hashStr("second") {
+		t.Fatalf("Expected a snapshot of the two entries, from least to most recently used, got %+v", s.Entries)
+	}
+
+	// Evict the first entry.
+	put("third")
+	if found, _ := testCache.Contains(ctx, cache.RAW, hashStr("first"), -1); found {
+		t.Fatal("Expected the first entry to be evicted")
+	}
+
+	// The evicted entry can still be read from the snapshot.
+	f, err := s.Open(s.Entries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first" {
+		t.Errorf("Expected to read %q from the snapshot, got %q", "first", data)
+	}
+
+	// Closing the snapshot removes the evicted file.
+	s.Close()
+	s.Close()
+	evicted := filepath.Join(cacheDir, s.Entries[0].Path)
+	for i := 0; ; i++ {
+		_, err = os.Stat(evicted)
+		if os.IsNotExist(err) {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("Expected %s to be removed after the snapshot was closed", evicted)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if testCache.openSnapshots != 0 || len(testCache.deferredRemovals) != 0 {
+		t.Errorf("Expected no open snapshots or deferred removals, got %d and %d",
+			testCache.openSnapshots, len(testCache.deferredRemovals))
+	}
+}
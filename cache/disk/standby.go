@@ -0,0 +1,48 @@
+package disk
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/replication"
+)
+
+// With WithStandby, the cache starts as the standby of a primary
+// bazel-remote instance, which replicates its writes to it, see the
+// replication package. A standby serves reads, but refuses all writes
+// except those replicated from a peer, and reports that it is a standby,
+// so that load balancers send clients to the primary. When the primary
+// fails, the standby is promoted with Promote, eg through the admin API,
+// and from then on accepts writes like any other instance.
+
+var errStandby = &cache.Error{
+	Code: http.StatusServiceUnavailable,
+	Text: "The cache is a standby, and only accepts writes replicated from its primary",
+}
+
+// Returns true if a write with ctx must be refused because the cache is
+// a standby.
+func (c *diskCache) refuseStandbyWrite(ctx context.Context) bool {
+	return c.standby.Load() && !replication.IsFromPeer(ctx)
+}
+
+// Standby returns true if the cache is a standby which hasn't been
+// promoted yet.
+func (c *diskCache) Standby() bool {
+	return c.standby.Load()
+}
+
+// Promote makes a standby cache accept writes from clients. Returns
+// false if the cache was not a standby.
+func (c *diskCache) Promote() bool {
+	if !c.standby.CompareAndSwap(true, false) {
+		return false
+	}
+
+	c.gaugeStandby.Set(0)
+	log.Println("Promoted from standby, accepting writes from clients")
+
+	return true
+}
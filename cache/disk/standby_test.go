@@ -0,0 +1,58 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/replication"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestStandby(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	cI, err := New(cacheDir, BlockSize*10, WithStandby(), WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cI.(*diskCache)
+
+	if !c.Standby() {
+		t.Fatal("Expected the cache to be a standby")
+	}
+	if v := testutil.ToFloat64(c.gaugeStandby); v != 1 {
+		t.Errorf("Expected the standby gauge to be 1, got %v", v)
+	}
+
+	put := func(ctx context.Context) error {
+		data, hash := testutils.RandomDataAndHash(100)
+		return c.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	}
+
+	if err := put(context.Background()); err != errStandby {
+		t.Errorf("Expected a standby to refuse writes from clients, got %v", err)
+	}
+	if err := put(replication.FromPeer(context.Background())); err != nil {
+		t.Errorf("Expected a standby to accept replicated writes, got %v", err)
+	}
+
+	if !c.Promote() {
+		t.Error("Expected the standby to be promoted")
+	}
+	if c.Promote() {
+		t.Error("Expected a promoted cache not to be promoted again")
+	}
+	if v := testutil.ToFloat64(c.gaugeStandby); v != 0 {
+		t.Errorf("Expected the standby gauge to be 0, got %v", v)
+	}
+
+	if err := put(context.Background()); err != nil {
+		t.Errorf("Expected a promoted cache to accept writes, got %v", err)
+	}
+}
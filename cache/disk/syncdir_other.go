@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package disk
+
+import (
+	"os"
+)
+
+// Flush the entries of the named directory to stable storage.
+func syncDir(name string) error {
+	d, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+
+	err = d.Sync()
+	closeErr := d.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return err
+}
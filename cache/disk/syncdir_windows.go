@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package disk
+
+// Directories can't be synced on windows, where NTFS journals the
+// directory entries itself.
+func syncDir(name string) error {
+	return nil
+}
@@ -0,0 +1,88 @@
+package disk
+
+import (
+	"encoding/hex"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// DirectoryUsage describes the disk space used by the entries in a
+// directory of the cache, like du does: "." for the whole cache, the
+// directory of a kind, eg "cas.v2", or a shard directory, eg "cas.v2/ab".
+type DirectoryUsage struct {
+	Path       string `json:"path"`
+	NumEntries int    `json:"num_entries"`
+
+	// The size on disk, rounded up to BlockSize like the cache size, and
+	// the uncompressed size of the entries.
+	SizeBytes    int64 `json:"size_bytes"`
+	LogicalBytes int64 `json:"logical_bytes"`
+}
+
+// The deepest level of DirectoryUsage reports: the shard directories.
+const MaxUsageDepth = 2
+
+var usageKinds = []cache.EntryKind{cache.AC, cache.CAS, cache.RAW}
+
+func (u *DirectoryUsage) add(e *entry) {
+	u.NumEntries++
+	u.SizeBytes += roundUp4k(e.value.sizeOnDisk)
+	u.LogicalBytes += e.value.size
+}
+
+// Returns the disk space used by the entries in each directory, from the
+// index, down to the given depth: 0 for only the whole cache, 1 for the
+// directories of each kind, and 2 for their shard directories.
+// Directories without entries are left out, except for the whole cache.
+// The directories are sorted by path.
+func (c *SizedLRU) directoryUsage(depth int) []DirectoryUsage {
+	total := DirectoryUsage{Path: "."}
+	var kinds [cache.RAW + 1]DirectoryUsage
+	var shards [cache.RAW + 1][256]DirectoryUsage
+
+	for ele := c.ll.Front(); ele != nil; ele = ele.Next() {
+		e := ele.Value.(*entry)
+		total.add(e)
+		kinds[e.key.kind].add(e)
+		shards[e.key.kind][e.key.digest[0]].add(e)
+	}
+
+	result := []DirectoryUsage{total}
+	if depth < 1 {
+		return result
+	}
+
+	for _, kind := range usageKinds {
+		u := kinds[kind]
+		if u.NumEntries == 0 {
+			continue
+		}
+		u.Path = kind.DirName()
+		result = append(result, u)
+		if depth < 2 {
+			continue
+		}
+
+		for i := range shards[kind] {
+			shard := shards[kind][i]
+			if shard.NumEntries == 0 {
+				continue
+			}
+			shard.Path = kind.DirName() + "/" + hex.EncodeToString([]byte{byte(i)})
+			result = append(result, shard)
+		}
+	}
+
+	return result
+}
+
+// DirectoryUsage returns the disk space used by the entries in each
+// directory of the cache down to depth, which must be between 0 and
+// MaxUsageDepth, computed from the index rather than by walking the cache
+// directory.
+func (c *diskCache) DirectoryUsage(depth int) []DirectoryUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.directoryUsage(depth)
+}
@@ -0,0 +1,49 @@
+package disk
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+func TestDirectoryUsage(t *testing.T) {
+	lru := NewSizedLRU(10*BlockSize, nil, 0)
+
+	usageKey := func(kind cache.EntryKind, first byte, last byte) Key {
+		k := Key{kind: uint8(kind)}
+		k.digest[0] = first
+		k.digest[len(k.digest)-1] = last
+		return k
+	}
+
+	lru.Add(usageKey(cache.CAS, 0xab, 1), lruItem{size: 100, sizeOnDisk: 50})
+	lru.Add(usageKey(cache.CAS, 0xab, 2), lruItem{size: 200, sizeOnDisk: BlockSize + 1})
+	lru.Add(usageKey(cache.CAS, 0x01, 1), lruItem{size: 10, sizeOnDisk: 10})
+	lru.Add(usageKey(cache.AC, 0xff, 1), lruItem{size: 20, sizeOnDisk: 20})
+
+	expected := []DirectoryUsage{
+		{Path: ".", NumEntries: 4, SizeBytes: 5 * BlockSize, LogicalBytes: 330},
+		{Path: "ac.v2", NumEntries: 1, SizeBytes: BlockSize, LogicalBytes: 20},
+		{Path: "ac.v2/ff", NumEntries: 1, SizeBytes: BlockSize, LogicalBytes: 20},
+		{Path: "cas.v2", NumEntries: 3, SizeBytes: 4 * BlockSize, LogicalBytes: 310},
+		{Path: "cas.v2/01", NumEntries: 1, SizeBytes: BlockSize, LogicalBytes: 10},
+		{Path: "cas.v2/ab", NumEntries: 2, SizeBytes: 3 * BlockSize, LogicalBytes: 300},
+	}
+
+	usage := lru.directoryUsage(2)
+	if !reflect.DeepEqual(usage, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, usage)
+	}
+
+	usage = lru.directoryUsage(1)
+	if !reflect.DeepEqual(usage, []DirectoryUsage{expected[0], expected[1], expected[3]}) {
+		t.Errorf("Expected only the kind directories at depth 1, got %+v", usage)
+	}
+
+	empty := NewSizedLRU(10*BlockSize, nil, 0)
+	usage = empty.directoryUsage(2)
+	if !reflect.DeepEqual(usage, []DirectoryUsage{{Path: "."}}) {
+		t.Errorf("Expected only the empty cache, got %+v", usage)
+	}
+}
@@ -0,0 +1,97 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"math/rand"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/notify"
+)
+
+// Unlike casblob files, legacy CAS blobs, which are stored uncompressed
+// with a ".v1" suffix, have no checksums, so corruption on disk goes
+// unnoticed until a client checks the digest, or the blob is scrubbed.
+// With WithLegacyReadVerification, a fraction of the uncompressed reads
+// of whole legacy blobs hash the data as it is served. If it does not
+// match the digest, the read fails instead of returning io.EOF, and the
+// blob is removed from the cache.
+
+// Returns true if a read of item from offset should be verified.
+func (c *diskCache) shouldVerifyRead(item lruItem, offset int64) bool {
+	if c.legacyVerifyRatio <= 0 || !item.legacy || offset != 0 {
+		return false
+	}
+	return c.legacyVerifyRatio >= 1 || rand.Float64() < c.legacyVerifyRatio
+}
+
+// An io.ReadCloser which checks that the data read from a legacy CAS blob
+// matches its hash.
+type verifyingReader struct {
+	rc   io.ReadCloser
+	c    *diskCache
+	key  Key
+	item lruItem
+
+	h    hash.Hash
+	n    int64
+	done bool
+}
+
+func (c *diskCache) newVerifyingReader(rc io.ReadCloser, key Key, item lruItem) *verifyingReader {
+	return &verifyingReader{
+		rc:   rc,
+		c:    c,
+		key:  key,
+		item: item,
+		h:    sha256.New(),
+	}
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	if r.done {
+		return n, err
+	}
+
+	r.h.Write(p[:n])
+	r.n += int64(n)
+
+	if err != io.EOF {
+		return n, err
+	}
+	r.done = true
+
+	r.c.counterVerifiedReads.Inc()
+	if r.n == r.item.size && bytes.Equal(r.h.Sum(nil), r.key.digest[:]) {
+		return n, err
+	}
+
+	r.c.counterCorruptReads.Inc()
+	r.c.removeCorruptBlob(r.key, r.item)
+	log.Printf("Removed corrupt blob %s, found while reading it", r.key)
+
+	return n, fmt.Errorf("The blob %s is corrupt on disk, and was removed from the cache", r.key)
+}
+
+func (r *verifyingReader) Close() error {
+	return r.rc.Close()
+}
+
+// Remove the CAS blob stored under key from the cache if it still refers
+// to item, whose content does not match its hash. Must be called without
+// holding mu.
+func (c *diskCache) removeCorruptBlob(key Key, item lruItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Don't remove the entry if it was replaced while we were reading it.
+	if c.removeUnchanged(key, item) {
+		c.notifier.Notify(notify.NewEvent(context.Background(), notify.EventCorruptBlob, cache.CAS, key.Hash(), item.size))
+	}
+}
@@ -0,0 +1,97 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestLegacyReadVerification(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, BlockSize*10,
+		WithStorageMode("uncompressed"),
+		WithLegacyReadVerification(1),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+
+	ctx := context.Background()
+	var hashes []string
+	var datas [][]byte
+	for i := 0; i < 2; i++ {
+		data, hash := testutils.RandomDataAndHash(1000)
+		err = testCache.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		datas = append(datas, data)
+		hashes = append(hashes, hash)
+	}
+
+	// Corrupt the second blob, without changing its size.
+	key, _ := newKey(cache.CAS, hashes[1])
+	item, _ := testCache.lru.peek(key)
+	if !item.legacy {
+		t.Fatal("Expected a legacy blob")
+	}
+	corrupt := bytes.Repeat([]byte{'x'}, len(datas[1]))
+	err = os.WriteFile(testCache.getElementPath(key, item), corrupt, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(i int, offset int64) ([]byte, error) {
+		rc, _, err := testCache.Get(ctx, cache.CAS, hashes[i], int64(len(datas[i])), offset)
+		if err != nil {
+			return nil, err
+		}
+		if rc == nil {
+			t.Fatalf("Expected blob %d to be found", i)
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	data, err := read(0, 0)
+	if err != nil || !bytes.Equal(data, datas[0]) {
+		t.Fatal("Expected the valid blob to be read, got", err)
+	}
+
+	// Reads from an offset are not verified.
+	data, err = read(1, 10)
+	if err != nil || !bytes.Equal(data, corrupt[10:]) {
+		t.Fatal("Expected a partial read not to be verified, got", err)
+	}
+
+	_, err = read(1, 0)
+	if err == nil {
+		t.Fatal("Expected an error when reading a corrupt blob")
+	}
+
+	found, _ := testCache.Contains(ctx, cache.CAS, hashes[1], int64(len(datas[1])))
+	if found {
+		t.Error("Expected the corrupt blob to be removed")
+	}
+	found, _ = testCache.Contains(ctx, cache.CAS, hashes[0], int64(len(datas[0])))
+	if !found {
+		t.Error("Expected the valid blob to be kept")
+	}
+
+	if n := testutil.ToFloat64(testCache.counterVerifiedReads); n != 2 {
+		t.Error("Expected 2 verified reads, found", n)
+	}
+	if n := testutil.ToFloat64(testCache.counterCorruptReads); n != 1 {
+		t.Error("Expected 1 corrupt read, found", n)
+	}
+}
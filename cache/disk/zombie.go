@@ -0,0 +1,66 @@
+package disk
+
+import (
+	"log"
+	"path"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// Files in the cache directory can be deleted by something other than
+// bazel-remote, eg by an operator freeing disk space by hand. The index
+// still lists such "zombie" entries, so they would be reported as present
+// and fail to be read until they were evicted. Instead, when a read finds
+// that the file of an entry is missing, the entry is removed from the
+// index. With WithZombieRescan, the entry's shard directory is then
+// rescanned, see rescan.go, since files are rarely deleted one at a time.
+
+// Remove the index entry for key if it still refers to item, whose file
+// was found to be missing. Returns true if the entry was removed. Must be
+// called without holding mu.
+func (c *diskCache) dropZombie(key Key, item lruItem) bool {
+	c.mu.Lock()
+	dropped := c.removeUnchanged(key, item)
+	shard := c.shardDir(key)
+	rescan := dropped && c.zombieRescan && !c.zombieRescans[shard]
+	if rescan {
+		c.zombieRescans[shard] = true
+	}
+	c.mu.Unlock()
+
+	if !dropped {
+		// Replaced or evicted in the meantime.
+		return false
+	}
+
+	c.counterZombieEntries.Inc()
+	log.Printf("Warning: removed %s from the index, its file %q is missing",
+		key, c.getElementPath(key, item))
+
+	if rescan {
+		go c.rescanZombieShard(key, shard)
+	}
+
+	return true
+}
+
+// Returns the shard directory of key, relative to the cache directory,
+// eg "cas.v2/ab".
+func (c *diskCache) shardDir(key Key) string {
+	return path.Join(key.Kind().DirName(), key.Hash()[:2])
+}
+
+// Rescan the shard directory of key, as returned by shardDir, after a
+// zombie entry was found in it.
+func (c *diskCache) rescanZombieShard(key Key, shard string) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.zombieRescans, shard)
+		c.mu.Unlock()
+	}()
+
+	_, err := c.Rescan([]cache.EntryKind{key.Kind()}, path.Base(shard))
+	if err != nil {
+		log.Printf("Warning: failed to rescan %s for missing files: %v", shard, err)
+	}
+}
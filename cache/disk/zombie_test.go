@@ -0,0 +1,147 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+// Add a CAS blob to the cache, and return the path of its file.
+func putZombieTestBlob(t *testing.T, c *diskCache, data []byte, hash string) string {
+	t.Helper()
+
+	err := c.Put(context.Background(), cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := newKey(cache.CAS, hash)
+	item, found := c.lru.peek(key)
+	if !found {
+		t.Fatal("Expected the blob to be in the index")
+	}
+	return c.getElementPath(key, item)
+}
+
+func TestZombieEntries(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, BlockSize*10, WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+
+	data, hash := testutils.RandomDataAndHash(100)
+	blobPath := putZombieTestBlob(t, testCache, data, hash)
+
+	err = os.Remove(blobPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	rc, _, err := testCache.Get(ctx, cache.CAS, hash, int64(len(data)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc != nil {
+		rc.Close()
+		t.Fatal("Expected a cache miss for a deleted file")
+	}
+
+	found, _ := testCache.Contains(ctx, cache.CAS, hash, int64(len(data)))
+	if found {
+		t.Error("Expected the entry to be removed from the index")
+	}
+	if testCache.lru.TotalSize() != 0 {
+		t.Error("Expected the size of the index to be 0, found", testCache.lru.TotalSize())
+	}
+	if n := testutil.ToFloat64(testCache.counterZombieEntries); n != 1 {
+		t.Error("Expected 1 zombie entry, found", n)
+	}
+
+	// The blob can be added again.
+	putZombieTestBlob(t, testCache, data, hash)
+	rc, _, err = testCache.Get(ctx, cache.CAS, hash, int64(len(data)), 0)
+	if err != nil || rc == nil {
+		t.Fatal("Expected a cache hit, got", err)
+	}
+	rc.Close()
+}
+
+func TestZombieRescan(t *testing.T) {
+	cacheDir := tempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	testCacheI, err := New(cacheDir, BlockSize*10,
+		WithZombieRescan(),
+		WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache := testCacheI.(*diskCache)
+
+	// Three blobs in the same shard, and one in another.
+	var datas [][]byte
+	var hashes []string
+	var paths []string
+	for len(hashes) < 4 {
+		data, hash := testutils.RandomDataAndHash(100)
+		sameShard := len(hashes) == 0 || hash[:2] == hashes[0][:2]
+		if sameShard != (len(hashes) < 3) {
+			continue
+		}
+		datas = append(datas, data)
+		hashes = append(hashes, hash)
+		paths = append(paths, putZombieTestBlob(t, testCache, data, hash))
+	}
+
+	// Delete all but one of the files in the shard, and the other file.
+	for _, p := range []string{paths[0], paths[1], paths[3]} {
+		err = os.Remove(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	rc, _, err := testCache.Get(ctx, cache.CAS, hashes[0], int64(len(datas[0])), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc != nil {
+		rc.Close()
+		t.Fatal("Expected a cache miss for a deleted file")
+	}
+
+	// Wait for the rescan to remove the other missing file in the shard.
+	for i := 0; i < 1000; i++ {
+		testCache.mu.Lock()
+		rescanning := len(testCache.zombieRescans)
+		testCache.mu.Unlock()
+		if rescanning == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	expected := []bool{false, false, true, true}
+	for i, hash := range hashes {
+		found, _ := testCache.Contains(ctx, cache.CAS, hash, int64(len(datas[i])))
+		if found != expected[i] {
+			t.Errorf("Blob %d: expected found=%t, got %t", i, expected[i], found)
+		}
+	}
+	if n := testutil.ToFloat64(testCache.counterZombieEntries); n != 2 {
+		t.Error("Expected 2 zombie entries, found", n)
+	}
+}
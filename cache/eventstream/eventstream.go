@@ -0,0 +1,287 @@
+// Package eventstream exports structured records of cache operations
+// (reads, writes and evictions) to a message broker, for aggregating
+// build telemetry. Records are published in batches in the background,
+// with at-most-once semantics: records which can't be queued, or whose
+// batch fails to publish, are dropped rather than retried, so the cache
+// never waits for the broker.
+package eventstream
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// OpRead records a Get request, which is either a hit or a miss.
+	OpRead = "read"
+
+	// OpWrite records an entry being written to the cache.
+	OpWrite = "write"
+
+	// OpEvict records an entry being removed from the cache.
+	OpEvict = "evict"
+)
+
+// GetOps returns the valid operation names.
+func GetOps() []string {
+	return []string{OpRead, OpWrite, OpEvict}
+}
+
+// IsValidOp returns true if op is a valid operation name.
+func IsValidOp(op string) bool {
+	for _, o := range GetOps() {
+		if op == o {
+			return true
+		}
+	}
+	return false
+}
+
+var records = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "bazel_remote_event_stream_records_total",
+	Help: "The total number of cache operation records exported to the event stream, by result (published, failed or dropped)",
+}, []string{"result"})
+
+// Record describes a cache operation. It is published as a JSON object.
+type Record struct {
+	Op     string `json:"op"`
+	Kind   string `json:"kind"` // "ac", "cas" or "raw".
+	Hash   string `json:"hash"`
+	Size   int64  `json:"size"`
+	Result string `json:"result,omitempty"` // "hit" or "miss", for reads.
+
+	// The instance name and tool invocation ID of the request, if any.
+	// Evictions are not attributed to a request.
+	Instance     string `json:"instance,omitempty"`
+	InvocationID string `json:"invocation_id,omitempty"`
+
+	Time time.Time `json:"time"`
+}
+
+// A publisher sends a batch of records to a broker.
+type publisher interface {
+	publish(batch []Record) error
+}
+
+// Options configure an Exporter.
+type Options struct {
+	// The operations to export, or all of them if empty.
+	Ops []string
+
+	// The maximum number of records to publish at once.
+	BatchSize int
+
+	// How long to wait for a batch to fill up before publishing it.
+	FlushInterval time.Duration
+
+	// The maximum number of records waiting to be published. Records
+	// for operations which happen when the queue is full are dropped.
+	QueueSize int
+
+	// Whether to replace the hashes in records with keyed hashes of
+	// them, and to leave out the instance names and invocation IDs, so
+	// that records can be shared without revealing which entries were
+	// cached. The key is random, so the same hash is replaced with the
+	// same value until bazel-remote restarts.
+	Anonymize bool
+}
+
+func (o *Options) validate() error {
+	for _, op := range o.Ops {
+		if !IsValidOp(op) {
+			return fmt.Errorf("Invalid event stream operation: %q", op)
+		}
+	}
+	if o.BatchSize <= 0 {
+		return fmt.Errorf("Invalid event stream batch size: %d", o.BatchSize)
+	}
+	if o.FlushInterval <= 0 {
+		return fmt.Errorf("Invalid event stream flush interval: %s", o.FlushInterval)
+	}
+	if o.QueueSize <= 0 {
+		return fmt.Errorf("Invalid event stream queue size: %d", o.QueueSize)
+	}
+	return nil
+}
+
+// Exporter queues records of cache operations and publishes them in
+// batches. It is safe to call the methods of a nil *Exporter, which
+// doesn't export anything.
+type Exporter struct {
+	pub           publisher
+	ops           map[string]bool
+	queue         chan Record
+	batchSize     int
+	flushInterval time.Duration
+	errorLogger   cache.Logger
+	now           func() time.Time
+
+	// The key of the keyed hashes in anonymized records, or nil.
+	anonymizeKey []byte
+
+	// The number of queued records which have not been handled.
+	pending atomic.Int64
+}
+
+func newExporter(pub publisher, opts Options, errorLogger cache.Logger) (*Exporter, error) {
+	err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	ops := opts.Ops
+	if len(ops) == 0 {
+		ops = GetOps()
+	}
+
+	e := &Exporter{
+		pub:           pub,
+		ops:           make(map[string]bool, len(ops)),
+		queue:         make(chan Record, opts.QueueSize),
+		batchSize:     opts.BatchSize,
+		flushInterval: opts.FlushInterval,
+		errorLogger:   errorLogger,
+		now:           time.Now,
+	}
+	for _, op := range ops {
+		e.ops[op] = true
+	}
+
+	if opts.Anonymize {
+		e.anonymizeKey = make([]byte, 32)
+		_, err = rand.Read(e.anonymizeKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	go e.run()
+
+	return e, nil
+}
+
+// Read records a Get request for the entry with the given kind and hash,
+// made with ctx, which read size bytes if it was a hit.
+func (e *Exporter) Read(ctx context.Context, kind cache.EntryKind, hash string, size int64, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	e.export(ctx, OpRead, kind, hash, size, result)
+}
+
+// Write records an entry of size bytes being written to the cache by a
+// request made with ctx.
+func (e *Exporter) Write(ctx context.Context, kind cache.EntryKind, hash string, size int64) {
+	e.export(ctx, OpWrite, kind, hash, size, "")
+}
+
+// Evict records an entry of size bytes being removed from the cache.
+func (e *Exporter) Evict(kind cache.EntryKind, hash string, size int64) {
+	e.export(context.Background(), OpEvict, kind, hash, size, "")
+}
+
+// Queue a record, if its operation is exported. This does not block.
+func (e *Exporter) export(ctx context.Context, op string, kind cache.EntryKind, hash string, size int64, result string) {
+	if e == nil || !e.ops[op] {
+		return
+	}
+
+	r := Record{
+		Op:     op,
+		Kind:   kind.String(),
+		Hash:   hash,
+		Size:   size,
+		Result: result,
+
+		Instance:     cache.InstanceName(ctx),
+		InvocationID: cache.InvocationID(ctx),
+
+		Time: e.now().UTC(),
+	}
+
+	if e.anonymizeKey != nil {
+		mac := hmac.New(sha256.New, e.anonymizeKey)
+		mac.Write([]byte(hash))
+		r.Hash = hex.EncodeToString(mac.Sum(nil))
+		r.Instance = ""
+		r.InvocationID = ""
+	}
+
+	e.pending.Add(1)
+	select {
+	case e.queue <- r:
+	default:
+		// Don't log each dropped record, that could be a lot of
+		// output when the broker is slow.
+		e.pending.Add(-1)
+		records.WithLabelValues("dropped").Inc()
+	}
+}
+
+func (e *Exporter) run() {
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, e.batchSize)
+	for {
+		select {
+		case r := <-e.queue:
+			batch = append(batch, r)
+			if len(batch) < e.batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		e.publish(batch)
+		batch = batch[:0]
+	}
+}
+
+func (e *Exporter) publish(batch []Record) {
+	err := e.pub.publish(batch)
+	if err != nil {
+		records.WithLabelValues("failed").Add(float64(len(batch)))
+		e.errorLogger.Printf("EVENT STREAM: failed to publish %d records: %s", len(batch), err)
+	} else {
+		records.WithLabelValues("published").Add(float64(len(batch)))
+	}
+
+	e.pending.Add(-int64(len(batch)))
+}
+
+// Flush waits until all the queued records have been handled, or until
+// ctx is done.
+func (e *Exporter) Flush(ctx context.Context) error {
+	if e == nil {
+		return nil
+	}
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for e.pending.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
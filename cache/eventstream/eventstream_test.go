@@ -0,0 +1,204 @@
+package eventstream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+const testHash = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+type testPublisher struct {
+	mu      sync.Mutex
+	batches [][]Record
+	block   chan struct{} // If not nil, publish waits for it to be closed.
+	err     error
+}
+
+func (p *testPublisher) publish(batch []Record) error {
+	if p.block != nil {
+		<-p.block
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.batches = append(p.batches, append([]Record(nil), batch...))
+	return p.err
+}
+
+func (p *testPublisher) getBatches() [][]Record {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.batches
+}
+
+func TestBatching(t *testing.T) {
+	pub := &testPublisher{}
+	e, err := newExporter(pub, Options{BatchSize: 2, FlushInterval: 200 * time.Millisecond, QueueSize: 10},
+		testutils.NewSilentLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := cache.WithInvocationID(cache.WithInstanceName(context.Background(), "foo"), "build-1")
+	e.Read(ctx, cache.CAS, testHash, 5, true)
+	e.Read(ctx, cache.AC, testHash, 0, false)
+	e.Write(ctx, cache.CAS, testHash, 5)
+
+	err = e.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first two records fill a batch, the third is published after
+	// the flush interval.
+	batches := pub.getBatches()
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("Expected batches of 2 and 1 records, got %+v", batches)
+	}
+
+	hit := batches[0][0]
+	if hit.Op != OpRead || hit.Result != "hit" || hit.Kind != "cas" || hit.Size != 5 ||
+		hit.Instance != "foo" || hit.InvocationID != "build-1" {
+		t.Errorf("Unexpected record: %+v", hit)
+	}
+	if miss := batches[0][1]; miss.Result != "miss" || miss.Kind != "ac" {
+		t.Errorf("Unexpected record: %+v", miss)
+	}
+	if write := batches[1][0]; write.Op != OpWrite || write.Result != "" {
+		t.Errorf("Unexpected record: %+v", write)
+	}
+}
+
+func TestOpsFilter(t *testing.T) {
+	pub := &testPublisher{}
+	e, err := newExporter(pub, Options{Ops: []string{OpEvict}, BatchSize: 10,
+		FlushInterval: 10 * time.Millisecond, QueueSize: 10}, testutils.NewSilentLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Read(context.Background(), cache.CAS, testHash, 5, true)
+	e.Write(context.Background(), cache.CAS, testHash, 5)
+	e.Evict(cache.CAS, testHash, 5)
+
+	err = e.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batches := pub.getBatches()
+	if len(batches) != 1 || len(batches[0]) != 1 || batches[0][0].Op != OpEvict {
+		t.Fatalf("Expected only an eviction record, got %+v", batches)
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	pub := &testPublisher{}
+	e, err := newExporter(pub, Options{BatchSize: 10, FlushInterval: 10 * time.Millisecond,
+		QueueSize: 10, Anonymize: true}, testutils.NewSilentLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const otherHash = "486ea46224d1bb4fb680f34f7c9ad96a8f24ec88be73ea8e5a6c65260e9cb8a7"
+	ctx := cache.WithInvocationID(cache.WithInstanceName(context.Background(), "foo"), "build-1")
+	e.Write(ctx, cache.CAS, testHash, 5)
+	e.Read(ctx, cache.CAS, testHash, 5, true)
+	e.Read(ctx, cache.CAS, otherHash, 0, false)
+
+	err = e.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var records []Record
+	for _, b := range pub.getBatches() {
+		records = append(records, b...)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %+v", records)
+	}
+
+	for _, r := range records {
+		if r.Hash == testHash || r.Hash == otherHash || len(r.Hash) != 64 {
+			t.Errorf("Expected an anonymized hash, got %q", r.Hash)
+		}
+		if r.Instance != "" || r.InvocationID != "" {
+			t.Errorf("Expected no instance name or invocation ID, got %+v", r)
+		}
+	}
+	if records[0].Hash != records[1].Hash || records[0].Hash == records[2].Hash {
+		t.Errorf("Expected the same hashes to be anonymized consistently, got %+v", records)
+	}
+	if records[1].Size != 5 || records[1].Result != "hit" {
+		t.Errorf("Expected sizes and results to be kept, got %+v", records[1])
+	}
+}
+
+func TestBackpressure(t *testing.T) {
+	pub := &testPublisher{block: make(chan struct{}), err: errors.New("broker unavailable")}
+	e, err := newExporter(pub, Options{BatchSize: 1, FlushInterval: time.Hour, QueueSize: 2},
+		testutils.NewSilentLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first record is taken from the queue and blocks in publish,
+	// the next two fill the queue and the rest are dropped without
+	// blocking.
+	e.Write(context.Background(), cache.CAS, testHash, 1)
+	for len(e.queue) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 10; i++ {
+		e.Write(context.Background(), cache.CAS, testHash, 1)
+	}
+
+	if p := e.pending.Load(); p != 3 {
+		t.Errorf("Expected 3 pending records, got %d", p)
+	}
+
+	close(pub.block)
+	err = e.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Failed batches are not retried.
+	if n := len(pub.getBatches()); n != 3 {
+		t.Errorf("Expected 3 publish attempts, got %d", n)
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	valid := Options{BatchSize: 1, FlushInterval: time.Second, QueueSize: 1}
+
+	invalid := []Options{valid, valid, valid, valid}
+	invalid[0].Ops = []string{"delete"}
+	invalid[1].BatchSize = 0
+	invalid[2].FlushInterval = 0
+	invalid[3].QueueSize = -1
+
+	for _, opts := range invalid {
+		_, err := newExporter(&testPublisher{}, opts, testutils.NewSilentLogger())
+		if err == nil {
+			t.Errorf("Expected an error for invalid options %+v", opts)
+		}
+	}
+}
+
+func TestNilExporter(t *testing.T) {
+	var e *Exporter
+	e.Read(context.Background(), cache.CAS, testHash, 1, true)
+	e.Write(context.Background(), cache.CAS, testHash, 1)
+	e.Evict(cache.CAS, testHash, 1)
+	if err := e.Flush(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
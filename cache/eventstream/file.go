@@ -0,0 +1,53 @@
+package eventstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// Appends each record to a file, as a line of JSON. The file can be
+// replayed with the bench subcommand, or analyzed with the analyze
+// subcommand.
+type filePublisher struct {
+	f  *os.File
+	wr *bufio.Writer
+}
+
+// NewFile returns an Exporter which appends records to the file at path,
+// which is created if it doesn't exist.
+func NewFile(path string, opts Options, errorLogger cache.Logger) (*Exporter, error) {
+	pub, err := newFilePublisher(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newExporter(pub, opts, errorLogger)
+}
+
+func newFilePublisher(path string) (*filePublisher, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the event stream file: %w", err)
+	}
+
+	return &filePublisher{f: f, wr: bufio.NewWriter(f)}, nil
+}
+
+func (p *filePublisher) publish(batch []Record) error {
+	for _, r := range batch {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		p.wr.Write(line)
+		p.wr.WriteByte('\n')
+	}
+
+	// Write whole batches, so that the file only ends with a partial
+	// line if the disk is full.
+	return p.wr.Flush()
+}
@@ -0,0 +1,74 @@
+package eventstream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestFile(t *testing.T) {
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.jsonl")
+
+	opts := Options{BatchSize: 2, FlushInterval: 10 * time.Millisecond, QueueSize: 10}
+	for i := 0; i < 2; i++ {
+		// Records are appended to an existing file.
+		e, err := NewFile(path, opts, testutils.NewSilentLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Write(context.Background(), cache.CAS, testHash, 5)
+		e.Read(context.Background(), cache.CAS, testHash, 5, true)
+		e.Evict(cache.CAS, testHash, 5)
+		err = e.Flush(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var ops []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var r Record
+		err = json.Unmarshal(s.Bytes(), &r)
+		if err != nil {
+			t.Fatalf("Invalid line %q: %v", s.Text(), err)
+		}
+		if r.Hash != testHash || r.Size != 5 || r.Time.IsZero() {
+			t.Errorf("Unexpected record: %+v", r)
+		}
+		ops = append(ops, r.Op)
+	}
+
+	expected := []string{OpWrite, OpRead, OpEvict, OpWrite, OpRead, OpEvict}
+	if len(ops) != len(expected) {
+		t.Fatalf("Expected records of %v, got %v", expected, ops)
+	}
+	for i := range ops {
+		if ops[i] != expected[i] {
+			t.Fatalf("Expected records of %v, got %v", expected, ops)
+		}
+	}
+}
+
+func TestInvalidFile(t *testing.T) {
+	_, err := NewFile("/nonexistent/dir/events.jsonl",
+		Options{BatchSize: 1, FlushInterval: time.Second, QueueSize: 1}, testutils.NewSilentLogger())
+	if err == nil {
+		t.Error("Expected an error for a file which can't be created")
+	}
+}
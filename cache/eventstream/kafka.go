@@ -0,0 +1,96 @@
+package eventstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// How long to wait for the Kafka REST proxy to accept a batch.
+const kafkaTimeout = 30 * time.Second
+
+var errUnexpectedStatus = errors.New("unexpected status")
+
+// Publishes each batch of records in one request to a Kafka REST proxy,
+// using the v2 API: https://docs.confluent.io/platform/current/kafka-rest/api.html
+// Records are keyed by hash, so the records for an entry go to the same
+// partition.
+type kafkaRESTPublisher struct {
+	topicURL string
+	client   *http.Client
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value Record `json:"value"`
+}
+
+type kafkaRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+// NewKafkaREST returns an Exporter which publishes records to topic via
+// the Kafka REST proxy at restURL, eg http://kafka-rest:8082.
+func NewKafkaREST(restURL string, topic string, client *http.Client, opts Options, errorLogger cache.Logger) (*Exporter, error) {
+	u, err := url.Parse(restURL)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid Kafka REST proxy URL %q: %w", restURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("Invalid Kafka REST proxy URL %q: must use http or https", restURL)
+	}
+	if topic == "" {
+		return nil, errors.New("Empty Kafka topic")
+	}
+
+	pub := &kafkaRESTPublisher{
+		topicURL: strings.TrimRight(u.String(), "/") + "/topics/" + url.PathEscape(topic),
+		client:   client,
+	}
+
+	return newExporter(pub, opts, errorLogger)
+}
+
+func (p *kafkaRESTPublisher) publish(batch []Record) error {
+	req := kafkaRequest{Records: make([]kafkaRecord, len(batch))}
+	for i, r := range batch {
+		req.Records[i] = kafkaRecord{Key: r.Hash, Value: r}
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), kafkaTimeout)
+	defer cancel()
+
+	post, err := http.NewRequestWithContext(ctx, http.MethodPost, p.topicURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	post.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	post.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	rsp, err := p.client.Do(post)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	_, _ = io.Copy(io.Discard, rsp.Body)
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %d", errUnexpectedStatus, rsp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package eventstream
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestKafkaREST(t *testing.T) {
+	requests := make(chan kafkaRequest, 10)
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/topics/bazel-remote-events" ||
+			r.Header.Get("Content-Type") != "application/vnd.kafka.json.v2+json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var req kafkaRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests <- req
+	}))
+	defer proxy.Close()
+
+	e, err := NewKafkaREST(proxy.URL+"/", "bazel-remote-events", &http.Client{},
+		Options{BatchSize: 2, FlushInterval: time.Hour, QueueSize: 10},
+		testutils.NewSilentLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Write(context.Background(), cache.CAS, testHash, 5)
+	e.Evict(cache.AC, testHash, 10)
+
+	err = e.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := <-requests
+	if len(req.Records) != 2 {
+		t.Fatalf("Expected 2 records in one request, got %+v", req)
+	}
+	for _, r := range req.Records {
+		if r.Key != testHash {
+			t.Errorf("Expected records to be keyed by hash, got %q", r.Key)
+		}
+	}
+	if req.Records[0].Value.Op != OpWrite || req.Records[1].Value.Op != OpEvict {
+		t.Errorf("Unexpected records: %+v", req.Records)
+	}
+}
+
+func TestKafkaRESTError(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer proxy.Close()
+
+	p := &kafkaRESTPublisher{topicURL: proxy.URL + "/topics/missing", client: &http.Client{}}
+	err := p.publish([]Record{{Op: OpWrite}})
+	if err == nil {
+		t.Error("Expected an error for an unknown topic")
+	}
+}
@@ -0,0 +1,168 @@
+package eventstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// A minimal NATS server which records the CONNECT options and the
+// messages published to it.
+type fakeNATSServer struct {
+	ln       net.Listener
+	connects chan map[string]interface{}
+	messages chan string // "subject payload"
+}
+
+func newFakeNATSServer(t *testing.T, info string) *fakeNATSServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fakeNATSServer{
+		ln:       ln,
+		connects: make(chan map[string]interface{}, 10),
+		messages: make(chan string, 100),
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, info)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeNATSServer) serve(conn net.Conn, info string) {
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "INFO %s\r\n", info)
+
+	rdr := bufio.NewReader(conn)
+	for {
+		line, err := rdr.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "CONNECT "):
+			var opts map[string]interface{}
+			_ = json.Unmarshal([]byte(line[len("CONNECT "):]), &opts)
+			s.connects <- opts
+		case strings.HasPrefix(line, "PUB "):
+			fields := strings.Fields(line)
+			n, _ := strconv.Atoi(fields[2])
+			payload := make([]byte, n+2)
+			_, err = io.ReadFull(rdr, payload)
+			if err != nil {
+				return
+			}
+			s.messages <- fields[1] + " " + string(payload[:n])
+		case line == "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		}
+	}
+}
+
+func (s *fakeNATSServer) url(userinfo string) string {
+	return "nats://" + userinfo + s.ln.Addr().String()
+}
+
+func TestNATSPublisher(t *testing.T) {
+	s := newFakeNATSServer(t, `{"server_id":"test"}`)
+	defer s.ln.Close()
+
+	p, err := newNATSPublisher(s.url("user:pass@"), "bazel-remote.events")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := []Record{
+		{Op: OpWrite, Kind: "cas", Hash: testHash, Size: 5},
+		{Op: OpEvict, Kind: "ac", Hash: testHash, Size: 10},
+	}
+	err = p.publish(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := <-s.connects
+	if opts["user"] != "user" || opts["pass"] != "pass" || opts["verbose"] != false {
+		t.Errorf("Unexpected CONNECT options: %v", opts)
+	}
+
+	for _, expected := range batch {
+		msg := <-s.messages
+		subject, payload, _ := strings.Cut(msg, " ")
+		if subject != "bazel-remote.events" {
+			t.Errorf("Expected subject bazel-remote.events, got %q", subject)
+		}
+		var r Record
+		err = json.Unmarshal([]byte(payload), &r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Op != expected.Op || r.Kind != expected.Kind || r.Size != expected.Size {
+			t.Errorf("Expected %+v, got %+v", expected, r)
+		}
+	}
+
+	// The connection is reused for the next batch.
+	err = p.publish(batch[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-s.messages
+	if len(s.connects) != 0 {
+		t.Error("Expected the connection to be reused")
+	}
+}
+
+func TestNATSPublisherTLSRequired(t *testing.T) {
+	s := newFakeNATSServer(t, `{"tls_required":true}`)
+	defer s.ln.Close()
+
+	p, err := newNATSPublisher(s.url(""), "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.publish([]Record{{Op: OpWrite}})
+	if err == nil || !strings.Contains(err.Error(), "TLS") {
+		t.Errorf("Expected an error about TLS, got %v", err)
+	}
+	if p.conn != nil {
+		t.Error("Expected the connection to be closed after an error")
+	}
+}
+
+func TestInvalidNATSPublisher(t *testing.T) {
+	testCases := []struct {
+		url     string
+		subject string
+	}{
+		{"http://localhost:4222", "events"},
+		{"nats://localhost:4222", ""},
+		{"nats://localhost:4222", "bad subject"},
+	}
+
+	for _, tc := range testCases {
+		_, err := newNATSPublisher(tc.url, tc.subject)
+		if err == nil {
+			t.Errorf("Expected an error for URL %q and subject %q", tc.url, tc.subject)
+		}
+	}
+}
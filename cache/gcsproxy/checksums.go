@@ -0,0 +1,131 @@
+package gcsproxy
+
+import (
+	"bytes"
+	"encoding/base64"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/backendproxy"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// With checksums enabled, objects are uploaded with their CRC32C checksum
+// in the x-goog-hash header, which GCS verifies before it stores them.
+// GCS stores a CRC32C checksum with every object, and sends it with
+// downloads, which are verified against it while they are read, so that
+// corruption in transit is detected in either direction without reading
+// the data again. This is done by an http.RoundTripper under the HTTP
+// proxy backend which talks to GCS, like the one for S3 Express One Zone
+// in the s3proxy package.
+
+var checksumMismatches = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "bazel_remote_gcs_checksum_mismatches",
+	Help: "The total number of GCS backend uploads and downloads rejected because the data did not match its checksum, by operation",
+}, []string{"operation"})
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// An http.RoundTripper which adds CRC32C checksums to uploads, and
+// verifies downloads against theirs.
+type checksumTransport struct {
+	base        http.RoundTripper
+	errorLogger cache.Logger
+}
+
+func (t *checksumTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sent := false
+	if req.Method == http.MethodPut && req.Body != nil && req.Body != http.NoBody {
+		sum, err := backendproxy.Checksum(req.Body, crc32.New(crc32cTable))
+		if err != nil {
+			// A RoundTripper must close the body, even on errors.
+			req.Body.Close()
+			return nil, err
+		}
+
+		if sum != nil {
+			// A RoundTripper must not modify the request.
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Goog-Hash", "crc32c="+base64.StdEncoding.EncodeToString(sum))
+			sent = true
+		}
+	}
+
+	rsp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case sent && rsp.StatusCode == http.StatusBadRequest:
+		t.checkBadDigest(req, rsp)
+	case req.Method == http.MethodGet && rsp.StatusCode == http.StatusOK:
+		t.verifyDownload(req, rsp)
+	}
+
+	return rsp, nil
+}
+
+// Counts the upload rejected with rsp if GCS found that it doesn't match
+// its checksum.
+func (t *checksumTransport) checkBadDigest(req *http.Request, rsp *http.Response) {
+	// Only the start of the error is read, and put back for the caller.
+	start, _ := io.ReadAll(io.LimitReader(rsp.Body, 1024))
+	rsp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(start), rsp.Body), rsp.Body}
+
+	if bytes.Contains(start, []byte("<Code>BadDigest</Code>")) {
+		checksumMismatches.WithLabelValues("upload").Inc()
+		t.errorLogger.Printf("GCS UPLOAD %s: the upload did not match its checksum", req.URL)
+	}
+}
+
+// Makes the body of rsp verify the object against its CRC32C checksum
+// while it is read.
+func (t *checksumTransport) verifyDownload(req *http.Request, rsp *http.Response) {
+	// Objects which GCS decompresses when they are downloaded, or which
+	// were decompressed by the transport, don't match their checksum.
+	// bazel-remote doesn't upload such objects.
+	encoding := rsp.Header.Get("X-Goog-Stored-Content-Encoding")
+	if rsp.Uncompressed || (encoding != "" && encoding != "identity") {
+		return
+	}
+
+	want := crc32cHash(rsp.Header)
+	if want == nil {
+		return
+	}
+
+	rsp.Body = backendproxy.VerifyChecksum(rsp.Body, crc32.New(crc32cTable), want, rsp.ContentLength, func() {
+		checksumMismatches.WithLabelValues("download").Inc()
+		t.errorLogger.Printf("GCS DOWNLOAD %s: %v", req.URL, backendproxy.ErrChecksumMismatch)
+	})
+}
+
+// Returns the CRC32C checksum in the x-goog-hash headers of h, or nil if
+// there isn't one. The headers are lists of hashes like
+// "crc32c=n03x6A==,md5=Ojk9c3dhfxgoKVVHYwFbHQ==".
+func crc32cHash(h http.Header) []byte {
+	for _, v := range h.Values("X-Goog-Hash") {
+		for _, hash := range strings.Split(v, ",") {
+			name, value, found := strings.Cut(strings.TrimSpace(hash), "=")
+			if !found || name != "crc32c" {
+				continue
+			}
+
+			sum, err := base64.StdEncoding.DecodeString(value)
+			if err == nil && len(sum) == crc32.Size {
+				return sum
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package gcsproxy
+
+import (
+	"bytes"
+	"encoding/base64"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/backendproxy"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func crc32cHeader(data []byte) string {
+	h := crc32.New(crc32cTable)
+	h.Write(data)
+	return "crc32c=" + base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func TestCRC32CHash(t *testing.T) {
+	data := []byte("some object data")
+	expected := crc32cHeader(data)
+
+	testCases := [][]string{
+		{expected},
+		{expected + ",md5=Ojk9c3dhfxgoKVVHYwFbHQ=="},
+		{"md5=Ojk9c3dhfxgoKVVHYwFbHQ==, " + expected},
+		{"md5=Ojk9c3dhfxgoKVVHYwFbHQ==", expected},
+	}
+	for _, values := range testCases {
+		h := http.Header{"X-Goog-Hash": values}
+		sum := crc32cHash(h)
+		if "crc32c="+base64.StdEncoding.EncodeToString(sum) != expected {
+			t.Errorf("Expected %q from the headers %q, got %x", expected, values, sum)
+		}
+	}
+
+	for _, values := range [][]string{nil, {"md5=Ojk9c3dhfxgoKVVHYwFbHQ=="}, {"crc32c=invalid"}} {
+		if sum := crc32cHash(http.Header{"X-Goog-Hash": values}); sum != nil {
+			t.Errorf("Expected no checksum from the headers %q, got %x", values, sum)
+		}
+	}
+}
+
+func TestChecksumTransport(t *testing.T) {
+	data := []byte("some object data")
+	corrupt := false
+
+	var uploadedHash string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			uploadedHash = r.Header.Get("X-Goog-Hash")
+			body, _ := io.ReadAll(r.Body)
+			if uploadedHash != crc32cHeader(body) {
+				w.WriteHeader(http.StatusBadRequest)
+				io.WriteString(w, "<?xml version='1.0' encoding='UTF-8'?><Error><Code>BadDigest</Code></Error>")
+			}
+		case http.MethodGet:
+			w.Header().Set("X-Goog-Hash", crc32cHeader(data)+",md5=Ojk9c3dhfxgoKVVHYwFbHQ==")
+			if corrupt {
+				w.Write(append([]byte("S"), data[1:]...))
+			} else {
+				w.Write(data)
+			}
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &checksumTransport{
+		base:        http.DefaultTransport,
+		errorLogger: testutils.NewSilentLogger(),
+	}}
+
+	// Uploads are sent with the checksum of the file.
+	path := filepath.Join(t.TempDir(), "blob")
+	err := os.WriteFile(path, data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/cas/1234", f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.ContentLength = int64(len(data))
+	rsp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK || uploadedHash != crc32cHeader(data) {
+		t.Errorf("Expected the upload to be sent with its checksum, got status %d and %q", rsp.StatusCode, uploadedHash)
+	}
+
+	// Uploads which don't match their checksum are rejected, and the
+	// error is passed on.
+	uploadMismatches := testutil.ToFloat64(checksumMismatches.WithLabelValues("upload"))
+	req, err = http.NewRequest(http.MethodPut, server.URL+"/cas/1234", &changingFile{data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(rsp.Body)
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), "BadDigest") {
+		t.Errorf("Expected the upload to be rejected with the error from the server, got status %d and %q",
+			rsp.StatusCode, body)
+	}
+	if uploadedHash != crc32cHeader(data) {
+		t.Errorf("Expected the upload to be sent with the checksum of the original data, got %q", uploadedHash)
+	}
+	if n := testutil.ToFloat64(checksumMismatches.WithLabelValues("upload")) - uploadMismatches; n != 1 {
+		t.Errorf("Expected one upload checksum mismatch to be counted, got %v", n)
+	}
+
+	// Downloads are verified.
+	downloadMismatches := testutil.ToFloat64(checksumMismatches.WithLabelValues("download"))
+	for _, corrupt = range []bool{false, true} {
+		rsp, err = client.Get(server.URL + "/cas/1234")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = io.ReadAll(rsp.Body)
+		rsp.Body.Close()
+
+		if corrupt && err != backendproxy.ErrChecksumMismatch {
+			t.Errorf("Expected a corrupt download to fail with %v, got %v", backendproxy.ErrChecksumMismatch, err)
+		} else if !corrupt && err != nil {
+			t.Errorf("Expected the download to succeed, got %v", err)
+		}
+	}
+	if n := testutil.ToFloat64(checksumMismatches.WithLabelValues("download")) - downloadMismatches; n != 1 {
+		t.Errorf("Expected one download checksum mismatch to be counted, got %v", n)
+	}
+}
+
+// A file which is changed after its checksum was computed, so that the
+// upload doesn't match it.
+type changingFile struct {
+	data []byte
+	r    *bytes.Reader
+}
+
+func (f *changingFile) Read(p []byte) (int, error) {
+	if f.r == nil {
+		f.r = bytes.NewReader(f.data)
+	}
+	return f.r.Read(p)
+}
+
+func (f *changingFile) Seek(offset int64, whence int) (int64, error) {
+	if f.r == nil {
+		f.r = bytes.NewReader(f.data)
+	}
+	n, err := f.r.Seek(offset, whence)
+	if err == nil && n == 0 && whence == io.SeekStart {
+		// Rewound after the checksum was computed.
+		f.r = bytes.NewReader(append([]byte("S"), f.data[1:]...))
+	}
+	return n, err
+}
+
+func (f *changingFile) Close() error {
+	return nil
+}
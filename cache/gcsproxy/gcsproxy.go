@@ -16,8 +16,9 @@ import (
 	"golang.org/x/oauth2/google"
 )
 
-// New creates a cache that proxies requests to Google Cloud Storage.
-func New(bucket string, useDefaultCredentials bool, jsonCredentialsFile string, storageMode string,
+// New creates a cache that proxies requests to Google Cloud Storage. With
+// checksums, uploads and downloads are verified with CRC32C checksums.
+func New(bucket string, useDefaultCredentials bool, jsonCredentialsFile string, checksums bool, storageMode string,
 	accessLogger cache.Logger, errorLogger cache.Logger, numUploaders, maxQueuedUploads int) (cache.Proxy, error) {
 	var remoteClient *http.Client
 	var err error
@@ -49,6 +50,13 @@ func New(bucket string, useDefaultCredentials bool, jsonCredentialsFile string,
 
 	errorLogger.Printf("Proxying artifacts to GCS bucket '%s'.\n", bucket)
 
+	if checksums {
+		remoteClient.Transport = &checksumTransport{
+			base:        remoteClient.Transport,
+			errorLogger: errorLogger,
+		}
+	}
+
 	baseURL := url.URL{
 		Scheme: "https",
 		Host:   "storage.googleapis.com",
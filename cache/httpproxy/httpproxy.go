@@ -149,9 +149,7 @@ func (r *remoteHTTPProxyCache) Put(ctx context.Context, kind cache.EntryKind, ha
 		Rc:          rc,
 	}
 
-	select {
-	case r.uploadQueue <- item:
-	default:
+	if !backendproxy.Enqueue(r.uploadQueue, item) {
 		r.errorLogger.Printf("too many uploads queued")
 		rc.Close()
 	}
@@ -0,0 +1,353 @@
+// Package notify sends notifications about cache events, like uploads
+// and blobs whose contents don't match their digest, to an HTTP webhook
+// or an external command, eg for security tooling which watches for
+// specific digests.
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// EventUpload is sent when a blob is written to the cache.
+	EventUpload = "upload"
+
+	// EventHashMismatch is sent when a CAS upload is rejected because
+	// its contents don't match its digest.
+	EventHashMismatch = "hash_mismatch"
+
+	// EventCorruptBlob is sent when a CAS blob whose contents don't
+	// match its digest is found in the cache, and removed.
+	EventCorruptBlob = "corrupt_blob"
+)
+
+// GetEventTypes returns the valid event type names.
+func GetEventTypes() []string {
+	return []string{EventUpload, EventHashMismatch, EventCorruptBlob}
+}
+
+// IsValidEventType returns true if eventType is a valid event type name.
+func IsValidEventType(eventType string) bool {
+	for _, t := range GetEventTypes() {
+		if eventType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// How long to wait for a webhook or command to handle an event.
+const deliveryTimeout = 30 * time.Second
+
+var notifications = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "bazel_remote_notifications_total",
+	Help: "The total number of event notifications, by event type and result (sent, failed or dropped)",
+}, []string{"event", "result"})
+
+// Event describes something which happened to a cache entry. It is sent
+// as a JSON object.
+type Event struct {
+	Type     string    `json:"type"`
+	Kind     string    `json:"kind"` // "ac", "cas" or "raw".
+	Hash     string    `json:"hash"`
+	Size     int64     `json:"size"`
+	Instance string    `json:"instance,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// NewEvent returns an Event of the given type for the entry with the
+// given kind, hash and size, made by the request with ctx.
+func NewEvent(ctx context.Context, eventType string, kind cache.EntryKind, hash string, size int64) Event {
+	return Event{
+		Type:     eventType,
+		Kind:     kind.String(),
+		Hash:     hash,
+		Size:     size,
+		Instance: cache.InstanceName(ctx),
+		Time:     time.Now().UTC(),
+	}
+}
+
+// Filter selects the events to send. Empty fields match everything.
+type Filter struct {
+	Events  []string
+	Kinds   []string
+	MinSize int64
+	MaxSize int64
+
+	// If not nil, only events for these hashes are sent.
+	Digests map[string]struct{}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns true if e should be sent.
+func (f *Filter) Match(e *Event) bool {
+	if len(f.Events) > 0 && !contains(f.Events, e.Type) {
+		return false
+	}
+
+	if len(f.Kinds) > 0 && !contains(f.Kinds, e.Kind) {
+		return false
+	}
+
+	if e.Size < f.MinSize || (f.MaxSize > 0 && e.Size > f.MaxSize) {
+		return false
+	}
+
+	if f.Digests != nil {
+		if _, found := f.Digests[e.Hash]; !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// LoadDigests reads a file with one sha256 hash per line, for
+// Filter.Digests. Blank lines and lines starting with '#' are ignored.
+func LoadDigests(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	digests := make(map[string]struct{})
+
+	s := bufio.NewScanner(f)
+	for lineNum := 1; s.Scan(); lineNum++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		hash := strings.ToLower(line)
+		b, err := hex.DecodeString(hash)
+		if err != nil || len(b) != 32 {
+			return nil, fmt.Errorf("%s:%d: invalid sha256 hash: %q", path, lineNum, line)
+		}
+
+		digests[hash] = struct{}{}
+	}
+
+	if err = s.Err(); err != nil {
+		return nil, err
+	}
+
+	return digests, nil
+}
+
+// Notifier sends the events which match its filter to a webhook or a
+// command, from a bounded queue. It is safe to call the methods of a nil
+// *Notifier, which doesn't send anything.
+type Notifier struct {
+	filter      Filter
+	deliver     func(payload []byte) error
+	queue       chan Event
+	interval    time.Duration // The minimum time between deliveries.
+	errorLogger cache.Logger
+
+	// The number of queued events which have not been handled.
+	pending atomic.Int64
+}
+
+// NewWebhook returns a Notifier which POSTs each event to webhookURL.
+// At most bufferSize events are queued, and if rateLimit is greater than
+// zero at most rateLimit events are sent per second. Events which arrive
+// when the queue is full are dropped.
+func NewWebhook(webhookURL string, client *http.Client, filter Filter,
+	bufferSize int, rateLimit float64, errorLogger cache.Logger) (*Notifier, error) {
+
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid notification webhook URL %q: %w", webhookURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("Invalid notification webhook URL %q: must use http or https", webhookURL)
+	}
+
+	deliver := func(payload []byte) error {
+		return postWebhook(client, u.String(), payload)
+	}
+
+	return newNotifier(deliver, filter, bufferSize, rateLimit, errorLogger)
+}
+
+// NewCommand returns a Notifier which runs command once for each event,
+// with the event on its standard input. The command is split into
+// arguments on whitespace, and is not run by a shell. Queueing and rate
+// limiting work like NewWebhook.
+func NewCommand(command string, filter Filter,
+	bufferSize int, rateLimit float64, errorLogger cache.Logger) (*Notifier, error) {
+
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("Empty notification command")
+	}
+
+	deliver := func(payload []byte) error {
+		return runCommand(args, payload)
+	}
+
+	return newNotifier(deliver, filter, bufferSize, rateLimit, errorLogger)
+}
+
+func newNotifier(deliver func([]byte) error, filter Filter,
+	bufferSize int, rateLimit float64, errorLogger cache.Logger) (*Notifier, error) {
+
+	if bufferSize <= 0 {
+		return nil, fmt.Errorf("Invalid notification buffer size: %d", bufferSize)
+	}
+	if rateLimit < 0 {
+		return nil, fmt.Errorf("Invalid notification rate limit: %v", rateLimit)
+	}
+
+	n := &Notifier{
+		filter:      filter,
+		deliver:     deliver,
+		queue:       make(chan Event, bufferSize),
+		errorLogger: errorLogger,
+	}
+	if rateLimit > 0 {
+		n.interval = time.Duration(float64(time.Second) / rateLimit)
+	}
+
+	go n.run()
+
+	return n, nil
+}
+
+// Notify queues e to be sent, if it matches the filter. This does not
+// block.
+func (n *Notifier) Notify(e Event) {
+	if n == nil || !n.filter.Match(&e) {
+		return
+	}
+
+	n.pending.Add(1)
+	select {
+	case n.queue <- e:
+	default:
+		n.pending.Add(-1)
+		notifications.WithLabelValues(e.Type, "dropped").Inc()
+		n.errorLogger.Printf("NOTIFY %s %s/%s: too many notifications queued", e.Type, e.Kind, e.Hash)
+	}
+}
+
+func (n *Notifier) run() {
+	var next time.Time
+	for e := range n.queue {
+		if n.interval > 0 {
+			time.Sleep(time.Until(next))
+			next = time.Now().Add(n.interval)
+		}
+
+		n.send(e)
+		n.pending.Add(-1)
+	}
+}
+
+func (n *Notifier) send(e Event) {
+	payload, err := json.Marshal(e)
+	if err == nil {
+		err = n.deliver(payload)
+	}
+	if err != nil {
+		notifications.WithLabelValues(e.Type, "failed").Inc()
+		n.errorLogger.Printf("NOTIFY %s %s/%s: %s", e.Type, e.Kind, e.Hash, err)
+		return
+	}
+
+	notifications.WithLabelValues(e.Type, "sent").Inc()
+}
+
+// Flush waits until all the queued events have been handled, or until
+// ctx is done.
+func (n *Notifier) Flush(ctx context.Context) error {
+	if n == nil {
+		return nil
+	}
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for n.pending.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+var errUnexpectedStatus = errors.New("unexpected status")
+
+func postWebhook(client *http.Client, u string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	rsp.Body.Close()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return fmt.Errorf("%w: %d", errUnexpectedStatus, rsp.StatusCode)
+	}
+
+	return nil
+}
+
+func runCommand(args []string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		out = bytes.TrimSpace(out)
+		if len(out) > 0 {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+
+	return nil
+}
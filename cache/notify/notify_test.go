@@ -0,0 +1,222 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+const (
+	hashA = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	hashB = "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"
+)
+
+func TestFilter(t *testing.T) {
+	ctx := context.Background()
+	upload := NewEvent(ctx, EventUpload, cache.CAS, hashA, 100)
+	mismatch := NewEvent(ctx, EventHashMismatch, cache.CAS, hashB, 100)
+	acUpload := NewEvent(ctx, EventUpload, cache.AC, hashA, 10)
+
+	testCases := []struct {
+		name     string
+		filter   Filter
+		event    Event
+		expected bool
+	}{
+		{"empty filter", Filter{}, upload, true},
+		{"event type", Filter{Events: []string{EventHashMismatch}}, upload, false},
+		{"event type match", Filter{Events: []string{EventHashMismatch}}, mismatch, true},
+		{"kind", Filter{Kinds: []string{"cas"}}, acUpload, false},
+		{"kind match", Filter{Kinds: []string{"ac", "cas"}}, acUpload, true},
+		{"min size", Filter{MinSize: 101}, upload, false},
+		{"max size", Filter{MaxSize: 99}, upload, false},
+		{"size range", Filter{MinSize: 100, MaxSize: 100}, upload, true},
+		{"digests", Filter{Digests: map[string]struct{}{hashB: {}}}, upload, false},
+		{"digests match", Filter{Digests: map[string]struct{}{hashB: {}}}, mismatch, true},
+		{"empty digests", Filter{Digests: map[string]struct{}{}}, upload, false},
+	}
+
+	for _, tc := range testCases {
+		if tc.filter.Match(&tc.event) != tc.expected {
+			t.Errorf("%s: expected Match to return %v", tc.name, tc.expected)
+		}
+	}
+}
+
+func TestLoadDigests(t *testing.T) {
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "digests")
+	err := os.WriteFile(path, []byte("# Known bad blobs.\n"+hashA+"\n\n  "+strings.ToUpper(hashB)+"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digests, err := LoadDigests(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digests) != 2 {
+		t.Fatalf("Expected 2 digests, got %v", digests)
+	}
+	for _, h := range []string{hashA, hashB} {
+		if _, found := digests[h]; !found {
+			t.Errorf("Expected %s to be loaded", h)
+		}
+	}
+
+	err = os.WriteFile(path, []byte(hashA+"\nnot-a-hash\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadDigests(path)
+	if err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("Expected an error on line 2, got %v", err)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var mu sync.Mutex
+	var received []Event
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var e Event
+		err := json.Unmarshal(body, &e)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		received = append(received, e)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	n, err := NewWebhook(server.URL, &http.Client{}, Filter{Kinds: []string{"cas"}},
+		10, 0, testutils.NewSilentLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := cache.WithInstanceName(context.Background(), "foo")
+	n.Notify(NewEvent(ctx, EventUpload, cache.AC, hashA, 10))
+	n.Notify(NewEvent(ctx, EventUpload, cache.CAS, hashB, 20))
+
+	err = n.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 event, got %+v", received)
+	}
+	e := received[0]
+	if e.Type != EventUpload || e.Kind != "cas" || e.Hash != hashB || e.Size != 20 || e.Instance != "foo" {
+		t.Errorf("Unexpected event: %+v", e)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	var delivered sync.WaitGroup
+	delivered.Add(2)
+
+	deliver := func(payload []byte) error {
+		<-block
+		delivered.Done()
+		return nil
+	}
+
+	n, err := newNotifier(deliver, Filter{}, 1, 0, testutils.NewSilentLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first event is taken from the queue and blocks, the second
+	// one waits in the queue, and the third one is dropped.
+	ctx := context.Background()
+	n.Notify(NewEvent(ctx, EventUpload, cache.CAS, hashA, 1))
+	for n.pending.Load() != 1 || len(n.queue) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	n.Notify(NewEvent(ctx, EventUpload, cache.CAS, hashA, 2))
+	n.Notify(NewEvent(ctx, EventUpload, cache.CAS, hashA, 3))
+
+	if p := n.pending.Load(); p != 2 {
+		t.Errorf("Expected 2 pending events, got %d", p)
+	}
+
+	close(block)
+	delivered.Wait()
+
+	err = n.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+
+	deliver := func(payload []byte) error {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+		return nil
+	}
+
+	n, err := newNotifier(deliver, Filter{}, 10, 20, testutils.NewSilentLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		n.Notify(NewEvent(context.Background(), EventUpload, cache.CAS, hashA, int64(i)))
+	}
+
+	err = n.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(times) != 3 {
+		t.Fatalf("Expected 3 deliveries, got %d", len(times))
+	}
+	if d := times[2].Sub(times[0]); d < 90*time.Millisecond {
+		t.Errorf("Expected deliveries to be at least 50ms apart, the first and last were %v apart", d)
+	}
+}
+
+func TestNilNotifier(t *testing.T) {
+	var n *Notifier
+	n.Notify(NewEvent(context.Background(), EventUpload, cache.CAS, hashA, 1))
+	if err := n.Flush(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
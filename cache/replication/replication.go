@@ -0,0 +1,505 @@
+// Package replication streams cache writes to peer bazel-remote instances
+// over their HTTP API, so that instances behind a load balancer converge
+// on the same contents.
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/discovery"
+	"github.com/buchgr/bazel-remote/v2/utils/sharedfile"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Header is set to the peers' shared secret on requests from a
+// replicating peer. Writes received with this header are not replicated
+// any further, to avoid loops.
+const Header = "X-Bazel-Remote-Replicated"
+
+const (
+	// ConflictLastWriteWins means that an AC entry received from a peer
+	// replaces any existing entry with the same key.
+	ConflictLastWriteWins = "last_write_wins"
+
+	// ConflictKeepExisting means that an AC entry received from a peer
+	// is discarded if an entry with the same key already exists.
+	ConflictKeepExisting = "keep_existing"
+)
+
+// GetConflictPolicies returns the valid conflict policy names.
+func GetConflictPolicies() []string {
+	return []string{ConflictLastWriteWins, ConflictKeepExisting}
+}
+
+// IsValidConflictPolicy returns true if policy is a valid policy name.
+func IsValidConflictPolicy(policy string) bool {
+	for _, p := range GetConflictPolicies() {
+		if policy == p {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	uploads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bazel_remote_replication_uploads_total",
+		Help: "The total number of replication uploads to peers, by result",
+	}, []string{"peer", "result"})
+
+	queueLength = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bazel_remote_replication_queue_length",
+		Help: "The number of writes waiting to be replicated to each peer",
+	}, []string{"peer"})
+
+	lag = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bazel_remote_replication_lag_seconds",
+		Help:    "The time between a local write and it being stored by a peer",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 15),
+	}, []string{"peer"})
+
+	conflicts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bazel_remote_replication_conflicts_total",
+		Help: "The number of AC entries received from peers which differed from an existing entry, by resolution",
+	}, []string{"resolution"})
+
+	peerCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "bazel_remote_replication_peers",
+		Help: "The number of peers which writes are replicated to",
+	})
+)
+
+type peerCtxKey struct{}
+
+// FromPeer returns a copy of ctx which marks a write as having been
+// received from a replication peer.
+func FromPeer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, peerCtxKey{}, true)
+}
+
+// IsFromPeer returns true if ctx was created by FromPeer.
+func IsFromPeer(ctx context.Context) bool {
+	fromPeer, _ := ctx.Value(peerCtxKey{}).(bool)
+	return fromPeer
+}
+
+// Replicator sends local cache writes to a set of peers, and decides
+// how to handle conflicting writes received from peers.
+type Replicator struct {
+	includeCAS     bool
+	conflictPolicy string
+	secret         string
+	client         *http.Client
+	errorLogger    cache.Logger
+
+	numUploaders     int
+	maxQueuedUploads int
+
+	mu    sync.RWMutex
+	peers []*peer
+
+	// The number of queued uploads to all peers which have not finished.
+	pending atomic.Int64
+
+	// See Discover.
+	discover         *url.URL
+	discoverInterval time.Duration
+	resolver         *discovery.Resolver
+	checkHealth      func(ctx context.Context, baseURL string) error // May be nil.
+}
+
+type replicateReq struct {
+	kind        cache.EntryKind
+	hash        string
+	logicalSize int64
+	sizeOnDisk  int64
+	legacy      bool
+	rc          io.ReadCloser
+	queued      time.Time
+}
+
+type peer struct {
+	baseURL     string
+	label       string // For metrics and logging, without credentials.
+	secret      string
+	client      *http.Client
+	queue       chan replicateReq
+	pending     *atomic.Int64
+	errorLogger cache.Logger
+}
+
+// New returns a Replicator which sends AC writes, and CAS writes if
+// includeCAS is true, to each of peerURLs. The writes carry secret, so
+// that the peers can tell them apart from client writes.
+func New(peerURLs []string, includeCAS bool, conflictPolicy string,
+	secret string, client *http.Client, errorLogger cache.Logger,
+	numUploaders, maxQueuedUploads int) (*Replicator, error) {
+
+	if !IsValidConflictPolicy(conflictPolicy) {
+		return nil, fmt.Errorf("Invalid replication conflict policy: %q", conflictPolicy)
+	}
+
+	r := &Replicator{
+		includeCAS:       includeCAS,
+		conflictPolicy:   conflictPolicy,
+		secret:           secret,
+		client:           client,
+		errorLogger:      errorLogger,
+		numUploaders:     numUploaders,
+		maxQueuedUploads: maxQueuedUploads,
+	}
+	r.checkHealth = func(ctx context.Context, baseURL string) error {
+		return discovery.CheckHealth(ctx, client, baseURL)
+	}
+
+	for _, pu := range peerURLs {
+		u, err := parsePeerURL(pu)
+		if err != nil {
+			return nil, err
+		}
+		r.peers = append(r.peers, r.newPeer(u))
+	}
+	peerCount.Set(float64(len(r.peers)))
+
+	return r, nil
+}
+
+func parsePeerURL(peerURL string) (*url.URL, error) {
+	u, err := url.Parse(peerURL)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid replication peer URL %q: %w", peerURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("Invalid replication peer URL %q: must use http or https", peerURL)
+	}
+	return u, nil
+}
+
+// Returns a peer at u, whose uploaders run until its queue is closed.
+func (r *Replicator) newPeer(u *url.URL) *peer {
+	p := &peer{
+		baseURL:     strings.TrimRight(u.String(), "/"),
+		label:       u.Host,
+		secret:      r.secret,
+		client:      r.client,
+		pending:     &r.pending,
+		errorLogger: r.errorLogger,
+	}
+
+	if r.numUploaders > 0 && r.maxQueuedUploads > 0 {
+		p.queue = make(chan replicateReq, r.maxQueuedUploads)
+		for i := 0; i < r.numUploaders; i++ {
+			go func() {
+				for req := range p.queue {
+					queueLength.WithLabelValues(p.label).Dec()
+					p.upload(req)
+					p.pending.Add(-1)
+				}
+			}()
+		}
+	}
+
+	return p
+}
+
+// Discover replaces the peers with the bazel-remote instances named by
+// discoverURL, which are looked up with resolver every interval.
+// Instances which are on this host or which don't respond to health
+// checks are left out. Standbys are included, since they receive writes from their primary.
+func (r *Replicator) Discover(discoverURL string, interval time.Duration, resolver *discovery.Resolver) error {
+	u, err := url.Parse(discoverURL)
+	if err != nil {
+		return fmt.Errorf("Invalid replication discovery URL %q: %w", discoverURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("Invalid replication discovery URL %q: must use http or https", discoverURL)
+	}
+
+	r.discover = u
+	r.discoverInterval = interval
+	r.resolver = resolver
+	r.refresh(context.Background())
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		for range ticker.C {
+			r.refresh(context.Background())
+		}
+	}()
+
+	return nil
+}
+
+// Resolve the discovery URL, and update the peers if they changed.
+// Returns true if they did.
+func (r *Replicator) refresh(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, r.discoverInterval)
+	defer cancel()
+
+	baseURLs, err := r.resolver.Resolve(ctx, r.discover)
+	if err != nil {
+		r.errorLogger.Printf("Replication peer discovery failed: %s", err)
+		return false
+	}
+
+	var wg sync.WaitGroup
+	found := make([]*url.URL, len(baseURLs))
+	for i, bu := range baseURLs {
+		u, err := url.Parse(bu)
+		if err != nil || discovery.IsLocalHost(u.Hostname()) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, u *url.URL) {
+			defer wg.Done()
+			if r.checkHealth != nil {
+				err := r.checkHealth(ctx, u.String())
+				if err != nil && !errors.Is(err, discovery.ErrUnavailable) {
+					r.errorLogger.Printf("Not replicating to unhealthy peer %s: %s", u.Host, err)
+					return
+				}
+			}
+			found[i] = u
+		}(i, u)
+	}
+	wg.Wait()
+
+	var peerURLs []*url.URL
+	for _, u := range found {
+		if u != nil {
+			peerURLs = append(peerURLs, u)
+		}
+	}
+
+	if !r.setPeers(peerURLs) {
+		return false
+	}
+
+	r.errorLogger.Printf("Replication peers changed: %s", strings.Join(r.Peers(), ", "))
+	return true
+}
+
+// Replace the peers with peerURLs, keeping the queues of the peers which
+// remain. Returns true if the peers changed.
+func (r *Replicator) setPeers(peerURLs []*url.URL) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing := make(map[string]*peer, len(r.peers))
+	for _, p := range r.peers {
+		existing[p.baseURL] = p
+	}
+
+	peers := make([]*peer, 0, len(peerURLs))
+	for _, u := range peerURLs {
+		baseURL := strings.TrimRight(u.String(), "/")
+		if p, found := existing[baseURL]; found {
+			peers = append(peers, p)
+			delete(existing, baseURL)
+			continue
+		}
+		peers = append(peers, r.newPeer(u))
+	}
+
+	changed := len(existing) > 0 || len(peers) != len(r.peers)
+
+	// The uploads queued for removed peers are still attempted.
+	for _, p := range existing {
+		if p.queue != nil {
+			close(p.queue)
+		}
+	}
+
+	r.peers = peers
+	peerCount.Set(float64(len(r.peers)))
+
+	return changed
+}
+
+// Peers returns the hosts of the peers which writes are replicated to.
+func (r *Replicator) Peers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hosts := make([]string, 0, len(r.peers))
+	for _, p := range r.peers {
+		hosts = append(hosts, p.label)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// Replicate queues the cache file at filePath for upload to each peer.
+// This does not block.
+func (r *Replicator) Replicate(kind cache.EntryKind, hash string, logicalSize int64, sizeOnDisk int64, legacy bool, filePath string) {
+	if kind == cache.CAS && !r.includeCAS {
+		return
+	}
+
+	now := time.Now()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.peers {
+		if p.queue == nil {
+			continue
+		}
+
+		// Open the file now, in case it is evicted before the upload.
+		f, err := sharedfile.Open(filePath)
+		if err != nil {
+			r.errorLogger.Printf("REPLICATE %s/%s: %s", kind, hash, err)
+			continue
+		}
+
+		req := replicateReq{
+			kind:        kind,
+			hash:        hash,
+			logicalSize: logicalSize,
+			sizeOnDisk:  sizeOnDisk,
+			legacy:      legacy,
+			rc:          f,
+			queued:      now,
+		}
+
+		r.pending.Add(1)
+		select {
+		case p.queue <- req:
+			queueLength.WithLabelValues(p.label).Inc()
+		default:
+			r.pending.Add(-1)
+			uploads.WithLabelValues(p.label, "dropped").Inc()
+			r.errorLogger.Printf("REPLICATE %s/%s to %s: too many uploads queued", kind, hash, p.label)
+			f.Close()
+		}
+	}
+}
+
+// Flush waits until all the queued uploads have finished, or until ctx
+// is done.
+func (r *Replicator) Flush(ctx context.Context) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for r.pending.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// KeepExisting returns true if conflicting AC entries received from a
+// peer should be discarded, and false if they should replace the
+// existing entry.
+func (r *Replicator) KeepExisting() bool {
+	return r.conflictPolicy == ConflictKeepExisting
+}
+
+// RecordConflict updates the conflict metrics.
+func (r *Replicator) RecordConflict(kind cache.EntryKind, hash string) {
+	resolution := "overwritten"
+	if r.KeepExisting() {
+		resolution = "kept"
+	}
+	conflicts.WithLabelValues(resolution).Inc()
+	r.errorLogger.Printf("REPLICATE %s/%s: conflicting entry received from peer, %s", kind, hash, resolution)
+}
+
+var errUnexpectedStatus = errors.New("unexpected status")
+
+func (p *peer) requestURL(kind cache.EntryKind, hash string) string {
+	if kind == cache.CAS {
+		return p.baseURL + "/cas/" + hash
+	}
+
+	// RAW entries are AC entries stored without validation.
+	return p.baseURL + "/ac/" + hash
+}
+
+func (p *peer) upload(req replicateReq) {
+	defer req.rc.Close()
+
+	err := p.doUpload(req)
+	if err != nil {
+		uploads.WithLabelValues(p.label, "failure").Inc()
+		p.errorLogger.Printf("REPLICATE %s/%s to %s: %s", req.kind, req.hash, p.label, err)
+		return
+	}
+
+	uploads.WithLabelValues(p.label, "success").Inc()
+	lag.WithLabelValues(p.label).Observe(time.Since(req.queued).Seconds())
+}
+
+func (p *peer) doUpload(req replicateReq) error {
+	u := p.requestURL(req.kind, req.hash)
+
+	if req.kind == cache.CAS {
+		// CAS blobs never conflict, so don't upload them if the peer
+		// already has them.
+		head, err := http.NewRequest(http.MethodHead, u, nil)
+		if err != nil {
+			return err
+		}
+		head.Header.Set(Header, p.secret)
+		rsp, err := p.client.Do(head)
+		if err == nil {
+			rsp.Body.Close()
+			if rsp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+	}
+
+	var body io.Reader = req.rc
+	if req.logicalSize == 0 {
+		body = http.NoBody
+	}
+
+	put, err := http.NewRequest(http.MethodPut, u, body)
+	if err != nil {
+		return err
+	}
+	put.Header.Set(Header, p.secret)
+	put.Header.Set("Content-Type", "application/octet-stream")
+	put.ContentLength = req.sizeOnDisk
+
+	if req.kind == cache.CAS && !req.legacy && req.logicalSize > 0 {
+		// Compressed CAS blobs are stored as valid zstd streams, so
+		// they can be sent as-is.
+		put.Header.Set("Content-Encoding", "zstd")
+		put.Header.Set("X-Digest-SizeBytes", strconv.FormatInt(req.logicalSize, 10))
+	}
+
+	rsp, err := p.client.Do(put)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	_, _ = io.Copy(io.Discard, rsp.Body)
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %d", errUnexpectedStatus, rsp.StatusCode)
+	}
+
+	return nil
+}
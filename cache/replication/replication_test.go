@@ -0,0 +1,221 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/discovery"
+)
+
+func TestFromPeer(t *testing.T) {
+	ctx := context.Background()
+	if IsFromPeer(ctx) {
+		t.Error("Expected a plain context not to be from a peer")
+	}
+	if !IsFromPeer(FromPeer(ctx)) {
+		t.Error("Expected the context to be from a peer")
+	}
+}
+
+type receivedPut struct {
+	path            string
+	body            string
+	replicated      string
+	contentEncoding string
+}
+
+func TestReplicate(t *testing.T) {
+	var mu sync.Mutex
+	var puts []receivedPut
+	received := make(chan struct{}, 10)
+
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			// The peer already has this CAS blob.
+			if r.URL.Path == "/cas/"+existingHash {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		puts = append(puts, receivedPut{
+			path:            r.URL.Path,
+			body:            string(body),
+			replicated:      r.Header.Get(Header),
+			contentEncoding: r.Header.Get("Content-Encoding"),
+		})
+		mu.Unlock()
+		received <- struct{}{}
+	}))
+	defer peer.Close()
+
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	acFile := filepath.Join(dir, "ac")
+	err := os.WriteFile(acFile, []byte("action result"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := New([]string{peer.URL}, true, ConflictLastWriteWins, "s3cret", &http.Client{},
+		testutils.NewSilentLogger(), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Replicate(cache.AC, acHash, 13, 13, false, acFile)
+	r.Replicate(cache.CAS, existingHash, 13, 13, true, acFile)
+	r.Replicate(cache.CAS, missingHash, 13, 13, true, acFile)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for replication")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(puts) != 2 {
+		t.Fatalf("Expected 2 PUTs, got %+v", puts)
+	}
+	expected := []receivedPut{
+		{path: "/ac/" + acHash, body: "action result", replicated: "s3cret"},
+		{path: "/cas/" + missingHash, body: "action result", replicated: "s3cret"},
+	}
+	for i := range expected {
+		if puts[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], puts[i])
+		}
+	}
+}
+
+func TestFlush(t *testing.T) {
+	unblock := make(chan struct{})
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer peer.Close()
+
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	acFile := filepath.Join(dir, "ac")
+	err := os.WriteFile(acFile, []byte("action result"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := New([]string{peer.URL}, false, ConflictLastWriteWins, "s3cret", &http.Client{},
+		testutils.NewSilentLogger(), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Replicate(cache.AC, acHash, 13, 13, false, acFile)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err = r.Flush(ctx)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected Flush to time out while the upload is blocked, got %v", err)
+	}
+
+	close(unblock)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = r.Flush(ctx)
+	if err != nil {
+		t.Fatal("Expected Flush to wait for the upload to finish, got", err)
+	}
+}
+
+const (
+	acHash       = "0000000000000000000000000000000000000000000000000000000000000001"
+	existingHash = "0000000000000000000000000000000000000000000000000000000000000002"
+	missingHash  = "0000000000000000000000000000000000000000000000000000000000000003"
+)
+
+func TestNewInvalid(t *testing.T) {
+	logger := testutils.NewSilentLogger()
+
+	_, err := New([]string{"http://peer:8080"}, false, "bogus", "s3cret", &http.Client{}, logger, 1, 1)
+	if err == nil {
+		t.Error("Expected an error for an invalid conflict policy")
+	}
+
+	_, err = New([]string{"peer:8080"}, false, ConflictKeepExisting, "s3cret", &http.Client{}, logger, 1, 1)
+	if err == nil {
+		t.Error("Expected an error for a peer URL without a scheme")
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	statuses := map[string]error{
+		"http://10.0.0.2:8080": discovery.ErrUnavailable, // A standby.
+		"http://10.0.0.3:8080": errors.New("connection refused"),
+	}
+
+	r, err := New(nil, false, ConflictKeepExisting, "s3cret", &http.Client{}, testutils.NewSilentLogger(), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.resolver = &discovery.Resolver{
+		LookupHost: func(ctx context.Context, host string) ([]string, error) {
+			return []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, nil
+		},
+	}
+	r.checkHealth = func(ctx context.Context, baseURL string) error {
+		return statuses[baseURL]
+	}
+	r.discoverInterval = time.Second
+	r.discover, err = url.Parse("http://bazel-remote:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.refresh(context.Background()) {
+		t.Error("Expected the peers to change")
+	}
+	expected := []string{"10.0.0.1:8080", "10.0.0.2:8080"}
+	if !reflect.DeepEqual(r.Peers(), expected) {
+		t.Errorf("Expected peers %v, got %v", expected, r.Peers())
+	}
+
+	queue := r.peers[0].queue
+	statuses = map[string]error{"http://10.0.0.1:8080": errors.New("connection refused")}
+	if !r.refresh(context.Background()) {
+		t.Error("Expected the peers to change")
+	}
+	expected = []string{"10.0.0.2:8080", "10.0.0.3:8080"}
+	if !reflect.DeepEqual(r.Peers(), expected) {
+		t.Errorf("Expected peers %v, got %v", expected, r.Peers())
+	}
+	if _, ok := <-queue; ok {
+		t.Error("Expected the queue of the removed peer to be closed")
+	}
+
+	if r.refresh(context.Background()) {
+		t.Error("Expected no change")
+	}
+}
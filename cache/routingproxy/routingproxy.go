@@ -0,0 +1,98 @@
+// Package routingproxy is a cache.Proxy which sends requests to one of
+// several proxy backends, depending on the instance name of the request.
+package routingproxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+type routingProxy struct {
+	backends map[string]cache.Proxy
+	fallback cache.Proxy
+}
+
+// New returns a cache.Proxy which forwards each request to the proxy in
+// backends for the request's instance name, or to fallback if there is
+// none. Requests for other instance names miss if fallback is nil.
+func New(backends map[string]cache.Proxy, fallback cache.Proxy) cache.Proxy {
+	return &routingProxy{backends: backends, fallback: fallback}
+}
+
+func (r *routingProxy) backend(ctx context.Context) cache.Proxy {
+	p, found := r.backends[cache.InstanceName(ctx)]
+	if found {
+		return p
+	}
+	return r.fallback
+}
+
+func (r *routingProxy) Put(ctx context.Context, kind cache.EntryKind, hash string, logicalSize int64, sizeOnDisk int64, rc io.ReadCloser) {
+	p := r.backend(ctx)
+	if p == nil {
+		rc.Close()
+		return
+	}
+	p.Put(ctx, kind, hash, logicalSize, sizeOnDisk, rc)
+}
+
+func (r *routingProxy) Get(ctx context.Context, kind cache.EntryKind, hash string) (io.ReadCloser, int64, error) {
+	p := r.backend(ctx)
+	if p == nil {
+		return nil, -1, nil
+	}
+	return p.Get(ctx, kind, hash)
+}
+
+func (r *routingProxy) Contains(ctx context.Context, kind cache.EntryKind, hash string) (bool, int64) {
+	p := r.backend(ctx)
+	if p == nil {
+		return false, -1
+	}
+	return p.Contains(ctx, kind, hash)
+}
+
+// BackendName implements cache.BackendNamer. Backends are named after
+// their instance name, and the fallback cache.DefaultBackendName.
+func (r *routingProxy) BackendName(ctx context.Context) string {
+	instance := cache.InstanceName(ctx)
+	if _, found := r.backends[instance]; found {
+		return instance
+	}
+	return cache.DefaultBackendName
+}
+
+// Backends implements cache.MultiBackend.
+func (r *routingProxy) Backends() map[string]cache.Proxy {
+	backends := make(map[string]cache.Proxy, len(r.backends)+1)
+	for instance, p := range r.backends {
+		backends[instance] = p
+	}
+	if r.fallback != nil {
+		backends[cache.DefaultBackendName] = r.fallback
+	}
+	return backends
+}
+
+// CheckHealth implements cache.HealthChecker. The routing proxy is
+// healthy if all of its backends are.
+func (r *routingProxy) CheckHealth(ctx context.Context) error {
+	for instance, p := range r.backends {
+		err := cache.CheckProxyHealth(ctx, p)
+		if err != nil {
+			return fmt.Errorf("The proxy backend for instance %q is unavailable: %w", instance, err)
+		}
+	}
+
+	if r.fallback != nil {
+		err := cache.CheckProxyHealth(ctx, r.fallback)
+		if err != nil {
+			return fmt.Errorf("The fallback proxy backend is unavailable: %w", err)
+		}
+	}
+
+	return nil
+}
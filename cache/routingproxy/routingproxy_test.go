@@ -0,0 +1,144 @@
+package routingproxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// A proxy which contains every blob, and records the hashes put to it.
+type namedProxy struct {
+	name string
+	puts []string
+}
+
+func (p *namedProxy) Put(ctx context.Context, kind cache.EntryKind, hash string, logicalSize int64, sizeOnDisk int64, rc io.ReadCloser) {
+	p.puts = append(p.puts, hash)
+	rc.Close()
+}
+
+func (p *namedProxy) Get(ctx context.Context, kind cache.EntryKind, hash string) (io.ReadCloser, int64, error) {
+	return io.NopCloser(strings.NewReader(p.name)), int64(len(p.name)), nil
+}
+
+func (p *namedProxy) Contains(ctx context.Context, kind cache.EntryKind, hash string) (bool, int64) {
+	return true, int64(len(p.name))
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func getName(t *testing.T, p cache.Proxy, ctx context.Context) string {
+	rc, _, err := p.Get(ctx, cache.CAS, "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc == nil {
+		return ""
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRouting(t *testing.T) {
+	teamA := &namedProxy{name: "team-a"}
+	fallback := &namedProxy{name: "fallback"}
+	p := New(map[string]cache.Proxy{"team/a": teamA}, fallback)
+
+	ctxA := cache.WithInstanceName(context.Background(), "team/a")
+	ctxB := cache.WithInstanceName(context.Background(), "team/b")
+
+	if name := getName(t, p, ctxA); name != "team-a" {
+		t.Errorf("Expected instance team/a to use its own backend, got %q", name)
+	}
+	if name := getName(t, p, ctxB); name != "fallback" {
+		t.Errorf("Expected instance team/b to use the fallback backend, got %q", name)
+	}
+	if name := getName(t, p, context.Background()); name != "fallback" {
+		t.Errorf("Expected requests without an instance name to use the fallback backend, got %q", name)
+	}
+
+	bn := p.(cache.BackendNamer)
+	if name := bn.BackendName(ctxA); name != "team/a" {
+		t.Errorf("Expected the backend of team/a to be named after it, got %q", name)
+	}
+	if name := bn.BackendName(ctxB); name != cache.DefaultBackendName {
+		t.Errorf("Expected the fallback backend to be named %q, got %q", cache.DefaultBackendName, name)
+	}
+
+	p.Put(ctxA, cache.CAS, "a", 0, 0, io.NopCloser(strings.NewReader("")))
+	p.Put(ctxB, cache.CAS, "b", 0, 0, io.NopCloser(strings.NewReader("")))
+	if len(teamA.puts) != 1 || teamA.puts[0] != "a" {
+		t.Errorf("Expected a single put to team-a, got %v", teamA.puts)
+	}
+	if len(fallback.puts) != 1 || fallback.puts[0] != "b" {
+		t.Errorf("Expected a single put to fallback, got %v", fallback.puts)
+	}
+}
+
+func TestRoutingWithoutFallback(t *testing.T) {
+	teamA := &namedProxy{name: "team-a"}
+	p := New(map[string]cache.Proxy{"team/a": teamA}, nil)
+
+	ctx := cache.WithInstanceName(context.Background(), "team/b")
+
+	if name := getName(t, p, ctx); name != "" {
+		t.Errorf("Expected a cache miss for an instance without a backend, got %q", name)
+	}
+	if found, _ := p.Contains(ctx, cache.CAS, "hash"); found {
+		t.Error("Expected Contains to be false for an instance without a backend")
+	}
+
+	rc := &closeRecorder{Reader: strings.NewReader("")}
+	p.Put(ctx, cache.CAS, "b", 0, 0, rc)
+	if !rc.closed {
+		t.Error("Expected Put to close the reader for an instance without a backend")
+	}
+	if len(teamA.puts) != 0 {
+		t.Errorf("Expected no puts to team-a, got %v", teamA.puts)
+	}
+}
+
+// A proxy which fails every request.
+type brokenProxy struct{}
+
+func (p *brokenProxy) Put(ctx context.Context, kind cache.EntryKind, hash string, logicalSize int64, sizeOnDisk int64, rc io.ReadCloser) {
+	rc.Close()
+}
+
+func (p *brokenProxy) Get(ctx context.Context, kind cache.EntryKind, hash string) (io.ReadCloser, int64, error) {
+	return nil, -1, errors.New("connection refused")
+}
+
+func (p *brokenProxy) Contains(ctx context.Context, kind cache.EntryKind, hash string) (bool, int64) {
+	return false, -1
+}
+
+func TestRoutingHealth(t *testing.T) {
+	healthy := New(map[string]cache.Proxy{"team/a": &namedProxy{name: "team-a"}}, nil)
+	if err := cache.CheckProxyHealth(context.Background(), healthy); err != nil {
+		t.Errorf("Expected the routing proxy to be healthy, got %v", err)
+	}
+
+	broken := New(map[string]cache.Proxy{"team/a": &namedProxy{name: "team-a"}}, &brokenProxy{})
+	err := cache.CheckProxyHealth(context.Background(), broken)
+	if err == nil || !strings.Contains(err.Error(), "fallback") {
+		t.Errorf("Expected the fallback backend to be reported as unavailable, got %v", err)
+	}
+}
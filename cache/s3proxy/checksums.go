@@ -0,0 +1,63 @@
+package s3proxy
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/backendproxy"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// With checksums enabled, objects are uploaded with their SHA256 checksum
+// in the x-amz-checksum-sha256 header, which S3 verifies before it stores
+// them, and stores with them. Downloads request the stored checksum, and
+// verify the data against it while it is read, so that corruption in
+// transit is detected in either direction without reading the data again.
+//
+// The checksum of an upload is computed from the file in the local cache
+// before it is sent, except for uncompressed CAS blobs, whose checksum is
+// their hash. Objects which were uploaded without a checksum, or in parts,
+// can't be verified, and are downloaded as before.
+
+var checksumMismatches = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "bazel_remote_s3_checksum_mismatches",
+	Help: "The total number of s3 backend uploads and downloads rejected because the data did not match its checksum, by operation",
+}, []string{"operation"})
+
+// Returns the SHA256 checksum of the object uploaded for item, or nil if
+// it can't be computed without reading item.Rc.
+func (c *s3Cache) uploadChecksum(item backendproxy.UploadReq) ([]byte, error) {
+	if item.Kind == cache.CAS && !c.v2mode {
+		return hex.DecodeString(item.Hash)
+	}
+
+	return backendproxy.Checksum(item.Rc, sha256.New())
+}
+
+// Returns true if err is S3's error for an upload which doesn't match the
+// checksum it was sent with.
+func isBadDigest(err error) bool {
+	return err != nil && minio.ToErrorResponse(err).Code == "BadDigest"
+}
+
+// Returns rc, which verifies the object described by info against its
+// SHA256 checksum while it is read, if it has one.
+func (c *s3Cache) verifyDownload(rc io.ReadCloser, info minio.ObjectInfo, key string) io.ReadCloser {
+	// The checksums of objects uploaded in parts are checksums of the
+	// parts' checksums, which are not base64 encoded SHA256 hashes.
+	want, err := base64.StdEncoding.DecodeString(info.ChecksumSHA256)
+	if err != nil || len(want) != sha256.Size {
+		return rc
+	}
+
+	return backendproxy.VerifyChecksum(rc, sha256.New(), want, info.Size, func() {
+		checksumMismatches.WithLabelValues("download").Inc()
+		logResponse(c.errorLogger, "DOWNLOAD", c.bucket, key, backendproxy.ErrChecksumMismatch)
+	})
+}
@@ -0,0 +1,105 @@
+package s3proxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/backendproxy"
+
+	"github.com/minio/minio-go/v7"
+)
+
+func TestUploadChecksum(t *testing.T) {
+	data, hash := testutils.RandomDataAndHash(100)
+	sum := sha256.Sum256(data)
+
+	path := filepath.Join(t.TempDir(), "blob")
+	err := os.WriteFile(path, data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// The checksum of uncompressed CAS blobs is their hash, so the file
+	// isn't read.
+	c := &s3Cache{}
+	checksum, err := c.uploadChecksum(backendproxy.UploadReq{Kind: cache.CAS, Hash: hash, Rc: io.NopCloser(nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(checksum, sum[:]) {
+		t.Errorf("Expected the checksum of an uncompressed CAS blob to be its hash, got %x", checksum)
+	}
+
+	// Otherwise the file is read, and rewound to be uploaded.
+	c.v2mode = true
+	checksum, err = c.uploadChecksum(backendproxy.UploadReq{Kind: cache.CAS, Hash: hash, Rc: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(checksum, sum[:]) {
+		t.Errorf("Expected the checksum of the file %x, got %x", sum, checksum)
+	}
+	uploaded, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(uploaded, data) {
+		t.Error("Expected the file to be rewound after computing its checksum")
+	}
+}
+
+func TestIsBadDigest(t *testing.T) {
+	if !isBadDigest(minio.ErrorResponse{Code: "BadDigest"}) {
+		t.Error("Expected BadDigest errors to be checksum mismatches")
+	}
+	for _, err := range []error{nil, errors.New("BadDigest"), minio.ErrorResponse{Code: "NoSuchKey"}} {
+		if isBadDigest(err) {
+			t.Errorf("Expected %v not to be a checksum mismatch", err)
+		}
+	}
+}
+
+func TestVerifyDownload(t *testing.T) {
+	data := []byte("some object data")
+	sum := sha256.Sum256(data)
+	checksum := base64.StdEncoding.EncodeToString(sum[:])
+	corrupt := append([]byte("S"), data[1:]...)
+
+	c := &s3Cache{bucket: "bucket", errorLogger: testutils.NewSilentLogger()}
+
+	testCases := []struct {
+		data     []byte
+		checksum string
+		err      error
+	}{
+		{data, checksum, nil},
+		{corrupt, checksum, backendproxy.ErrChecksumMismatch},
+		// Objects without a checksum, or uploaded in parts, can't be
+		// verified.
+		{corrupt, "", nil},
+		{corrupt, checksum + "-2", nil},
+	}
+
+	for _, tc := range testCases {
+		info := minio.ObjectInfo{Size: int64(len(tc.data)), ChecksumSHA256: tc.checksum}
+		rc := c.verifyDownload(io.NopCloser(bytes.NewReader(tc.data)), info, "cas/00/0000")
+
+		_, err := io.ReadAll(rc)
+		if err != tc.err {
+			t.Errorf("Expected %v when reading data with checksum %q, got %v", tc.err, tc.checksum, err)
+		}
+	}
+}
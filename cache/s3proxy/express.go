@@ -0,0 +1,273 @@
+package s3proxy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+// S3 Express One Zone directory buckets are named "base--azid--x-s3",
+// and are served from zonal endpoints which only accept virtual-hosted
+// requests over https. Instead of signing each request with the
+// configured credentials, clients call CreateSession on the bucket to get
+// short-lived session credentials, sign requests with them for the
+// "s3express" service, and send the session token in the
+// X-Amz-S3session-Token header. minio-go doesn't support this, so for
+// directory buckets it is configured to send unsigned requests, which
+// expressTransport signs. minio-go also mistakes zonal endpoints for
+// regional ones and replaces them, so expressTransport sends requests to
+// the bucket's zonal host.
+
+const directoryBucketSuffix = "--x-s3"
+
+// The service name which requests to directory buckets are signed for.
+const expressService = "s3express"
+
+// Session credentials are valid for 5 minutes. They are replaced when
+// they expire within this margin.
+const expressSessionMargin = time.Minute
+
+// IsDirectoryBucket returns true if bucket is the name of an S3 Express
+// One Zone directory bucket.
+func IsDirectoryBucket(bucket string) bool {
+	return strings.HasSuffix(bucket, directoryBucketSuffix)
+}
+
+// ExpressEndpoint returns the zonal endpoint of the directory bucket in
+// region, eg "s3express-use1-az4.us-east-1.amazonaws.com" for
+// "cache--use1-az4--x-s3".
+func ExpressEndpoint(bucket string, region string) (string, error) {
+	base := strings.TrimSuffix(bucket, directoryBucketSuffix)
+	i := strings.LastIndex(base, "--")
+	if !IsDirectoryBucket(bucket) || i <= 0 || i+2 == len(base) {
+		return "", fmt.Errorf("Invalid S3 directory bucket name %q, expected base--azid--x-s3", bucket)
+	}
+
+	return fmt.Sprintf("s3express-%s.%s.amazonaws.com", base[i+2:], region), nil
+}
+
+type expressSession struct {
+	accessKeyID     string
+	secretAccessKey string
+	token           string
+	expiration      time.Time
+}
+
+// An http.RoundTripper which sends requests to the directory bucket at
+// host, signed with session credentials which it creates with creds.
+type expressTransport struct {
+	base   http.RoundTripper
+	creds  *credentials.Credentials
+	region string
+	host   string // The bucket's virtual host on the zonal endpoint.
+
+	mu      sync.Mutex
+	session *expressSession
+
+	now func() time.Time
+}
+
+func newExpressTransport(base http.RoundTripper, creds *credentials.Credentials,
+	bucket string, endpoint string, region string) *expressTransport {
+
+	return &expressTransport{
+		base:   base,
+		creds:  creds,
+		region: region,
+		host:   bucket + "." + endpoint,
+		now:    time.Now,
+	}
+}
+
+func (t *expressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s, err := t.getSession(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	// A RoundTripper must not modify the request.
+	req = req.Clone(req.Context())
+	req.URL.Scheme = "https"
+	req.URL.Host = t.host
+	req.Host = ""
+	req.Header.Del("Authorization")
+	req.Header.Set("X-Amz-S3session-Token", s.token)
+	if req.Header.Get("X-Amz-Content-Sha256") == "" {
+		req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+	}
+	signV4(req, s.accessKeyID, s.secretAccessKey, t.region, expressService, t.now())
+
+	return t.base.RoundTrip(req)
+}
+
+// Returns the current session, after creating a new one if it expires
+// soon.
+func (t *expressTransport) getSession(ctx context.Context) (*expressSession, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.session != nil && t.now().Add(expressSessionMargin).Before(t.session.expiration) {
+		return t.session, nil
+	}
+
+	s, err := t.createSession(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create an S3 Express session: %w", err)
+	}
+
+	t.session = s
+	return s, nil
+}
+
+type createSessionResult struct {
+	Credentials struct {
+		AccessKeyID     string    `xml:"AccessKeyId"`
+		SecretAccessKey string    `xml:"SecretAccessKey"`
+		SessionToken    string    `xml:"SessionToken"`
+		Expiration      time.Time `xml:"Expiration"`
+	} `xml:"Credentials"`
+}
+
+func (t *expressTransport) createSession(ctx context.Context) (*expressSession, error) {
+	v, err := t.creds.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+t.host+"/?session", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Amz-Content-Sha256", emptySHA256)
+	if v.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", v.SessionToken)
+	}
+	signV4(req, v.AccessKeyID, v.SecretAccessKey, t.region, expressService, t.now())
+
+	rsp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(rsp.Body, 1024))
+		return nil, fmt.Errorf("status %d: %s", rsp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var result createSessionResult
+	err = xml.NewDecoder(rsp.Body).Decode(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	c := result.Credentials
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" || c.SessionToken == "" {
+		return nil, fmt.Errorf("incomplete session credentials")
+	}
+
+	return &expressSession{
+		accessKeyID:     c.AccessKeyID,
+		secretAccessKey: c.SecretAccessKey,
+		token:           c.SessionToken,
+		expiration:      c.Expiration,
+	}, nil
+}
+
+// The SHA256 hash of an empty payload.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Add an AWS signature version 4 Authorization header to req, which
+// signs its host, content type and X-Amz-* headers. The payload hash is
+// taken from the X-Amz-Content-Sha256 header, if it is set.
+func signV4(req *http.Request, accessKeyID string, secretAccessKey string,
+	region string, service string, now time.Time) {
+
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	payloadHash := req.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		payloadHash = emptySHA256
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.Join(strings.Fields(v), " ")
+			}
+			headers[name] = strings.Join(trimmed, ",")
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3utils.EncodePath(req.URL.Path),
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	date := now.Format("20060102")
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
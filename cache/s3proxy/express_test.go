@@ -0,0 +1,111 @@
+package s3proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+func TestExpressEndpoint(t *testing.T) {
+	endpoint, err := ExpressEndpoint("bazel-cache--use1-az4--x-s3", "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoint != "s3express-use1-az4.us-east-1.amazonaws.com" {
+		t.Errorf("Unexpected endpoint: %s", endpoint)
+	}
+
+	for _, bucket := range []string{"bazel-cache", "bazel-cache--x-s3", "--use1-az4--x-s3"} {
+		_, err = ExpressEndpoint(bucket, "us-east-1")
+		if err == nil {
+			t.Errorf("Expected an error for %q", bucket)
+		}
+	}
+}
+
+func TestSignV4(t *testing.T) {
+	// The get-vanilla case from the AWS signature version 4 test suite.
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signV4(req, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service",
+		time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if auth := req.Header.Get("Authorization"); auth != expected {
+		t.Errorf("Expected Authorization %q, got %q", expected, auth)
+	}
+}
+
+func TestExpressTransport(t *testing.T) {
+	sessions := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.Contains(auth, "/us-east-1/s3express/aws4_request") {
+			t.Errorf("Unexpected Authorization: %q", auth)
+		}
+
+		if _, found := r.URL.Query()["session"]; found {
+			if !strings.Contains(auth, "Credential=AKID/") {
+				t.Errorf("Expected CreateSession to be signed with the configured credentials: %q", auth)
+			}
+			sessions++
+			fmt.Fprintf(w, `<CreateSessionResult><Credentials>
+<SessionToken>token-%d</SessionToken><SecretAccessKey>secret</SecretAccessKey>
+<AccessKeyId>session-akid</AccessKeyId><Expiration>2030-01-01T00:05:00Z</Expiration>
+</Credentials></CreateSessionResult>`, sessions)
+			return
+		}
+
+		if !strings.Contains(auth, "Credential=session-akid/") {
+			t.Errorf("Expected the request to be signed with the session credentials: %q", auth)
+		}
+		_, _ = io.WriteString(w, r.Header.Get("X-Amz-S3session-Token"))
+	}))
+	defer srv.Close()
+
+	tr := &expressTransport{
+		base:   srv.Client().Transport,
+		creds:  credentials.NewStaticV4("AKID", "SECRET", ""),
+		region: "us-east-1",
+		host:   strings.TrimPrefix(srv.URL, "https://"),
+	}
+
+	get := func(now time.Time) string {
+		tr.now = func() time.Time { return now }
+
+		// minio-go replaces zonal endpoints with regional ones.
+		req, err := http.NewRequest(http.MethodGet, "https://bucket.s3.dualstack.us-east-1.amazonaws.com/cas.v2/ab/abcd", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rsp, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rsp.Body.Close()
+		token, _ := io.ReadAll(rsp.Body)
+		return string(token)
+	}
+
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	if token := get(start); token != "token-1" {
+		t.Errorf("Expected the first session's token, got %q", token)
+	}
+	if token := get(start.Add(time.Minute)); token != "token-1" {
+		t.Errorf("Expected the session to be reused, got %q", token)
+	}
+	if token := get(start.Add(4*time.Minute + 30*time.Second)); token != "token-2" {
+		t.Errorf("Expected a new session before the first one expires, got %q", token)
+	}
+}
@@ -2,11 +2,15 @@ package s3proxy
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"path"
+	"strconv"
+	"time"
 
 	"github.com/buchgr/bazel-remote/v2/cache"
 	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
@@ -28,8 +32,27 @@ type s3Cache struct {
 	v2mode           bool
 	updateTimestamps bool
 	objectKey        func(hash string, kind cache.EntryKind) string
+
+	// The names of the tags to add to uploaded objects, by attribute.
+	// See ObjectTagAttributes.
+	tagNames map[string]string
+
+	// The storage classes to upload objects with, by kind, see
+	// GetStorageClasses. Kinds without one use the bucket's default.
+	storageClasses map[string]string
+
+	// Whether to upload and verify objects with SHA256 checksums, see
+	// checksums.go.
+	checksums bool
 }
 
+// The attributes which uploaded objects can be tagged with, so that
+// bucket lifecycle rules can treat them differently:
+//   - "kind": the kind of cache entry, "ac", "cas" or "raw".
+//   - "size": the uncompressed size of the entry in bytes.
+//   - "created": the time of the upload, as an RFC 3339 timestamp.
+var ObjectTagAttributes = []string{"kind", "size", "created"}
+
 var (
 	cacheHits = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "bazel_remote_s3_cache_hits",
@@ -44,6 +67,9 @@ var (
 // Used in place of minio's verbose "NoSuchKey" error.
 var errNotFound = errors.New("NOT FOUND")
 
+// Logged for objects which must be restored before they can be read.
+var errArchived = errors.New("ARCHIVED")
+
 // New returns a new instance of the S3-API based cache
 func New(
 	// S3CloudStorageConfig struct fields:
@@ -54,6 +80,9 @@ func New(
 	DisableSSL bool,
 	UpdateTimestamps bool,
 	Region string,
+	ObjectTags map[string]string,
+	StorageClasses map[string]string,
+	Checksums bool,
 
 	storageMode string, accessLogger cache.Logger,
 	errorLogger cache.Logger, numUploaders, maxQueuedUploads int) cache.Proxy {
@@ -74,6 +103,30 @@ func New(
 		Region: Region,
 		Secure: !DisableSSL,
 	}
+
+	if IsDirectoryBucket(Bucket) {
+		if Endpoint == "" {
+			Endpoint, err = ExpressEndpoint(Bucket, Region)
+			if err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		base, err := minio.DefaultTransport(true)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		// Requests are sent unsigned by minio, and signed with session
+		// credentials by the transport, see express.go.
+		opts.Creds = credentials.NewStatic("", "", "", credentials.SignatureAnonymous)
+		opts.Transport = newExpressTransport(base, Credentials, Bucket, Endpoint, Region)
+		opts.BucketLookup = minio.BucketLookupDNS
+		opts.Secure = true
+
+		log.Printf("Using the S3 Express One Zone directory bucket %s, at %s", Bucket, Endpoint)
+	}
+
 	minioCore, err = minio.NewCore(Endpoint, opts)
 	if err != nil {
 		log.Fatalln(err)
@@ -92,6 +145,9 @@ func New(
 		errorLogger:      errorLogger,
 		v2mode:           storageMode == "zstd",
 		updateTimestamps: UpdateTimestamps,
+		tagNames:         ObjectTags,
+		storageClasses:   StorageClasses,
+		checksums:        Checksums,
 	}
 
 	if c.v2mode {
@@ -133,6 +189,16 @@ func objectKeyV1(prefix string, hash string, kind cache.EntryKind) string {
 	return path.Join(prefix, kind.String(), hash[:2], hash)
 }
 
+// Returns the prefix of the object keys of the given kind of entries.
+func kindPrefix(prefix string, kind cache.EntryKind, v2mode bool) string {
+	dir := kind.String()
+	if kind == cache.CAS && v2mode {
+		dir = "cas.v2"
+	}
+
+	return path.Join(prefix, dir) + "/"
+}
+
 // Helper function for logging responses
 func logResponse(log cache.Logger, method, bucket, key string, err error) {
 	status := "OK"
@@ -144,6 +210,22 @@ func logResponse(log cache.Logger, method, bucket, key string, err error) {
 }
 
 func (c *s3Cache) UploadFile(item backendproxy.UploadReq) {
+	metadata := map[string]string{
+		"Content-Type": "application/octet-stream",
+	}
+
+	if c.checksums {
+		sum, err := c.uploadChecksum(item)
+		if err != nil {
+			logResponse(c.errorLogger, "CHECKSUM", c.bucket, c.objectKey(item.Hash, item.Kind), err)
+			item.Rc.Close()
+			return
+		}
+		if sum != nil {
+			metadata["X-Amz-Checksum-Sha256"] = base64.StdEncoding.EncodeToString(sum)
+		}
+	}
+
 	_, err := c.mcore.PutObject(
 		context.Background(),
 		c.bucket,                          // bucketName
@@ -153,38 +235,63 @@ func (c *s3Cache) UploadFile(item backendproxy.UploadReq) {
 		"",                                // md5base64
 		"",                                // sha256
 		minio.PutObjectOptions{
-			UserMetadata: map[string]string{
-				"Content-Type": "application/octet-stream",
-			},
+			UserMetadata: metadata,
+			UserTags:     objectTags(c.tagNames, item, time.Now()),
+			StorageClass: c.storageClasses[item.Kind.String()],
 		}, // metadata
 	)
 
+	if isBadDigest(err) {
+		checksumMismatches.WithLabelValues("upload").Inc()
+	}
+
 	logResponse(c.accessLogger, "UPLOAD", c.bucket, c.objectKey(item.Hash, item.Kind), err)
 
 	item.Rc.Close()
 }
 
+// Returns the tags to add to the object uploaded for item, or nil if
+// objects are not tagged.
+func objectTags(tagNames map[string]string, item backendproxy.UploadReq, now time.Time) map[string]string {
+	if len(tagNames) == 0 {
+		return nil
+	}
+
+	tags := make(map[string]string, len(tagNames))
+	for attribute, name := range tagNames {
+		switch attribute {
+		case "kind":
+			tags[name] = item.Kind.String()
+		case "size":
+			tags[name] = strconv.FormatInt(item.LogicalSize, 10)
+		case "created":
+			tags[name] = now.UTC().Format(time.RFC3339)
+		}
+	}
+	return tags
+}
+
 func (c *s3Cache) Put(ctx context.Context, kind cache.EntryKind, hash string, logicalSize int64, sizeOnDisk int64, rc io.ReadCloser) {
 	if c.uploadQueue == nil {
 		rc.Close()
 		return
 	}
 
-	select {
-	case c.uploadQueue <- backendproxy.UploadReq{
+	item := backendproxy.UploadReq{
 		Hash:        hash,
 		LogicalSize: logicalSize,
 		SizeOnDisk:  sizeOnDisk,
 		Kind:        kind,
 		Rc:          rc,
-	}:
-	default:
+	}
+
+	if !backendproxy.Enqueue(c.uploadQueue, item) {
 		c.errorLogger.Printf("too many uploads queued\n")
 		rc.Close()
 	}
 }
 
-func (c *s3Cache) UpdateModificationTimestamp(ctx context.Context, bucket string, object string) {
+func (c *s3Cache) UpdateModificationTimestamp(ctx context.Context, kind cache.EntryKind, bucket string, object string) {
 	src := minio.CopySrcOptions{
 		Bucket: bucket,
 		Object: object,
@@ -196,6 +303,19 @@ func (c *s3Cache) UpdateModificationTimestamp(ctx context.Context, bucket string
 		ReplaceMetadata: true,
 	}
 
+	// The copy would otherwise be stored in the bucket's default class.
+	if class := c.storageClasses[kind.String()]; class != "" {
+		dst.UserMetadata = map[string]string{"X-Amz-Storage-Class": class}
+	}
+
+	// And without a checksum, so downloads of it couldn't be verified.
+	if c.checksums {
+		if dst.UserMetadata == nil {
+			dst.UserMetadata = make(map[string]string, 1)
+		}
+		dst.UserMetadata["X-Amz-Checksum-Algorithm"] = "SHA256"
+	}
+
 	_, err := c.mcore.ComposeObject(context.Background(), dst, src)
 
 	logResponse(c.accessLogger, "COMPOSE", bucket, object, err)
@@ -203,11 +323,15 @@ func (c *s3Cache) UpdateModificationTimestamp(ctx context.Context, bucket string
 
 func (c *s3Cache) Get(ctx context.Context, kind cache.EntryKind, hash string) (io.ReadCloser, int64, error) {
 
+	opts := minio.GetObjectOptions{
+		Checksum: c.checksums,
+	}
+
 	rc, info, _, err := c.mcore.GetObject(
 		ctx,
-		c.bucket,                 // bucketName
-		c.objectKey(hash, kind),  // objectName
-		minio.GetObjectOptions{}, // opts
+		c.bucket,                // bucketName
+		c.objectKey(hash, kind), // objectName
+		opts,                    // opts
 	)
 	if err != nil {
 		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
@@ -215,6 +339,12 @@ func (c *s3Cache) Get(ctx context.Context, kind cache.EntryKind, hash string) (i
 			logResponse(c.accessLogger, "DOWNLOAD", c.bucket, c.objectKey(hash, kind), errNotFound)
 			return nil, -1, nil
 		}
+		if minio.ToErrorResponse(err).Code == "InvalidObjectState" {
+			// The object is archived, and hasn't been restored.
+			cacheMisses.Inc()
+			logResponse(c.accessLogger, "DOWNLOAD", c.bucket, c.objectKey(hash, kind), errArchived)
+			return nil, -1, nil
+		}
 		cacheMisses.Inc()
 		logResponse(c.accessLogger, "DOWNLOAD", c.bucket, c.objectKey(hash, kind), err)
 		return nil, -1, err
@@ -222,11 +352,15 @@ func (c *s3Cache) Get(ctx context.Context, kind cache.EntryKind, hash string) (i
 	cacheHits.Inc()
 
 	if c.updateTimestamps {
-		c.UpdateModificationTimestamp(ctx, c.bucket, c.objectKey(hash, kind))
+		c.UpdateModificationTimestamp(ctx, kind, c.bucket, c.objectKey(hash, kind))
 	}
 
 	logResponse(c.accessLogger, "DOWNLOAD", c.bucket, c.objectKey(hash, kind), nil)
 
+	if c.checksums {
+		rc = c.verifyDownload(rc, info, c.objectKey(hash, kind))
+	}
+
 	if kind == cache.CAS && c.v2mode {
 		return casblob.ExtractLogicalSize(rc)
 	}
@@ -248,6 +382,10 @@ func (c *s3Cache) Contains(ctx context.Context, kind cache.EntryKind, hash strin
 	exists = (err == nil)
 	if err != nil {
 		err = errNotFound
+	} else if isArchived(s) {
+		// Reads would fail until the object is restored.
+		exists = false
+		err = errArchived
 	} else if kind != cache.CAS || !c.v2mode {
 		size = s.Size
 	}
@@ -256,3 +394,57 @@ func (c *s3Cache) Contains(ctx context.Context, kind cache.EntryKind, hash strin
 
 	return exists, size
 }
+
+// List implements cache.Lister, by listing the objects under the
+// prefix of each kind of entry. Objects whose names are not valid
+// hashes are skipped.
+func (c *s3Cache) List(ctx context.Context, fn func(item cache.ProxyItem) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // Stop listing if fn fails.
+
+	for _, kind := range []cache.EntryKind{cache.AC, cache.CAS, cache.RAW} {
+		opts := minio.ListObjectsOptions{
+			Prefix:    kindPrefix(c.prefix, kind, c.v2mode),
+			Recursive: true,
+		}
+
+		for obj := range c.mcore.Client.ListObjects(ctx, c.bucket, opts) {
+			if obj.Err != nil {
+				logResponse(c.accessLogger, "LIST", c.bucket, opts.Prefix, obj.Err)
+				return obj.Err
+			}
+
+			hash := path.Base(obj.Key)
+			if !isHash(hash) {
+				continue
+			}
+
+			err := fn(cache.ProxyItem{
+				Kind:         kind,
+				Hash:         hash,
+				SizeOnDisk:   obj.Size,
+				LastModified: obj.LastModified,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		logResponse(c.accessLogger, "LIST", c.bucket, opts.Prefix, nil)
+	}
+
+	return nil
+}
+
+// Returns true if s is a lowercase hex SHA256 hash.
+func isHash(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
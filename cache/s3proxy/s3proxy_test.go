@@ -1,9 +1,16 @@
 package s3proxy
 
 import (
+	"net/http"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/backendproxy"
+
+	"github.com/minio/minio-go/v7"
 )
 
 func TestObjectKey(t *testing.T) {
@@ -36,3 +43,82 @@ func TestObjectKey(t *testing.T) {
 		}
 	}
 }
+
+func TestKindPrefix(t *testing.T) {
+	hash := strings.Repeat("a", 64)
+
+	for _, prefix := range []string{"", "test", "foo/bar"} {
+		for _, kind := range []cache.EntryKind{cache.AC, cache.CAS, cache.RAW} {
+			if p := kindPrefix(prefix, kind, true); !strings.HasPrefix(objectKeyV2(prefix, hash, kind), p) {
+				t.Errorf("Expected the v2 object key of %s in %q to start with %q", kind, prefix, p)
+			}
+			if p := kindPrefix(prefix, kind, false); !strings.HasPrefix(objectKeyV1(prefix, hash, kind), p) {
+				t.Errorf("Expected the v1 object key of %s in %q to start with %q", kind, prefix, p)
+			}
+		}
+	}
+
+	if p := kindPrefix("test", cache.CAS, false); p != "test/cas/" {
+		t.Errorf("Expected prefix \"test/cas/\", got %q", p)
+	}
+}
+
+func TestIsHash(t *testing.T) {
+	if !isHash(strings.Repeat("0a", 32)) {
+		t.Error("Expected a valid hash")
+	}
+	for _, s := range []string{"", "1234", strings.Repeat("0A", 32), strings.Repeat("g", 64)} {
+		if isHash(s) {
+			t.Errorf("Expected %q not to be a valid hash", s)
+		}
+	}
+}
+
+func TestObjectTags(t *testing.T) {
+	item := backendproxy.UploadReq{Kind: cache.AC, LogicalSize: 1234}
+	now := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	if tags := objectTags(nil, item, now); tags != nil {
+		t.Errorf("Expected no tags, got %v", tags)
+	}
+
+	tagNames := map[string]string{
+		"kind":    "cache-kind",
+		"size":    "cache-size",
+		"created": "cache-created",
+	}
+	expected := map[string]string{
+		"cache-kind":    "ac",
+		"cache-size":    "1234",
+		"cache-created": "2024-03-01T12:30:00Z",
+	}
+	if tags := objectTags(tagNames, item, now); !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected %v, got %v", expected, tags)
+	}
+}
+
+func TestIsArchived(t *testing.T) {
+	archiveStatus := make(http.Header)
+	archiveStatus.Set("X-Amz-Archive-Status", "DEEP_ARCHIVE_ACCESS")
+
+	testCases := []struct {
+		info     minio.ObjectInfo
+		expected bool
+	}{
+		{minio.ObjectInfo{}, false},
+		{minio.ObjectInfo{StorageClass: "STANDARD_IA"}, false},
+		{minio.ObjectInfo{StorageClass: "GLACIER_IR"}, false},
+		{minio.ObjectInfo{StorageClass: "GLACIER"}, true},
+		{minio.ObjectInfo{StorageClass: "DEEP_ARCHIVE", Restore: &minio.RestoreInfo{OngoingRestore: true}}, true},
+		{minio.ObjectInfo{StorageClass: "DEEP_ARCHIVE", Restore: &minio.RestoreInfo{}}, false},
+		{minio.ObjectInfo{StorageClass: "INTELLIGENT_TIERING"}, false},
+		{minio.ObjectInfo{StorageClass: "INTELLIGENT_TIERING", Metadata: archiveStatus}, true},
+	}
+
+	for _, tc := range testCases {
+		if isArchived(tc.info) != tc.expected {
+			t.Errorf("Expected isArchived to return %v for class %q, restore %+v and metadata %v",
+				tc.expected, tc.info.StorageClass, tc.info.Restore, tc.info.Metadata)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package s3proxy
+
+import (
+	"github.com/minio/minio-go/v7"
+)
+
+// The storage classes which objects can be uploaded with. Objects in the
+// GLACIER and DEEP_ARCHIVE classes, or in the archive tiers of
+// INTELLIGENT_TIERING, must be restored before they can be read, so they
+// are only useful for entries which are rarely read.
+func GetStorageClasses() []string {
+	return []string{
+		"STANDARD",
+		"REDUCED_REDUNDANCY",
+		"STANDARD_IA",
+		"ONEZONE_IA",
+		"INTELLIGENT_TIERING",
+		"GLACIER_IR",
+		"GLACIER",
+		"DEEP_ARCHIVE",
+	}
+}
+
+// IsValidStorageClass returns true if class is a valid storage class.
+func IsValidStorageClass(class string) bool {
+	for _, c := range GetStorageClasses() {
+		if class == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the object must be restored before it can be read,
+// ie it is archived and hasn't been restored yet, or its restore is in
+// progress.
+func isArchived(info minio.ObjectInfo) bool {
+	if info.Restore != nil && !info.Restore.OngoingRestore {
+		return false
+	}
+
+	switch info.StorageClass {
+	case "GLACIER", "DEEP_ARCHIVE":
+		return true
+	}
+
+	// Set for INTELLIGENT_TIERING objects in the archive tiers.
+	return info.Metadata.Get("X-Amz-Archive-Status") != ""
+}
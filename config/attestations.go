@@ -0,0 +1,54 @@
+package config
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/buchgr/bazel-remote/v2/utils/attest"
+)
+
+// Returns the public keys in the given PEM files.
+func loadAttestationKeys(files []string) ([]crypto.PublicKey, error) {
+	keys := make([]crypto.PublicKey, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read attestation public key: %w", err)
+		}
+		key, err := attest.ParsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid attestation public key %q: %w", file, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func validateAttestations(a *AttestationsConfig) error {
+	if a == nil {
+		return nil
+	}
+
+	if !a.Enabled {
+		return errors.New("'attestations.public_key_files' requires 'attestations.enabled'")
+	}
+
+	_, err := loadAttestationKeys(a.PublicKeyFiles)
+	return err
+}
+
+func (c *Config) setAttestationKeys() error {
+	if c.Attestations == nil {
+		return nil
+	}
+
+	keys, err := loadAttestationKeys(c.Attestations.PublicKeyFiles)
+	if err != nil {
+		return err
+	}
+
+	c.AttestationKeys = keys
+	return nil
+}
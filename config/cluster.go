@@ -0,0 +1,33 @@
+package config
+
+import (
+	"net/http"
+
+	"github.com/buchgr/bazel-remote/v2/cache/cluster"
+	"github.com/buchgr/bazel-remote/v2/utils/discovery"
+)
+
+func (c *Config) setHashRing() error {
+	if c.Cluster == nil {
+		return nil
+	}
+
+	var resolver *discovery.Resolver
+	if c.Cluster.Discover != "" {
+		var err error
+		resolver, err = discovery.NewResolver(c.Cluster.DiscoverMode)
+		if err != nil {
+			return err
+		}
+	}
+
+	r, err := cluster.New(c.Cluster.Self, c.Cluster.Members,
+		c.Cluster.Discover, c.Cluster.DiscoverInterval, resolver,
+		c.PeerSecret, &http.Client{}, c.ErrorLogger)
+	if err != nil {
+		return err
+	}
+
+	c.HashRing = r
+	return nil
+}
@@ -1,14 +1,13 @@
 package config
 
 import (
+	"crypto"
 	"crypto/tls"
 	"errors"
 	"fmt"
-	"io"
 	"log"
 	"math"
 	"net"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,7 +15,19 @@ import (
 
 	"github.com/buchgr/bazel-remote/v2/cache"
 	"github.com/buchgr/bazel-remote/v2/cache/azblobproxy"
+	"github.com/buchgr/bazel-remote/v2/cache/cluster"
+	"github.com/buchgr/bazel-remote/v2/cache/eventstream"
+	"github.com/buchgr/bazel-remote/v2/cache/notify"
+	"github.com/buchgr/bazel-remote/v2/cache/replication"
 	"github.com/buchgr/bazel-remote/v2/cache/s3proxy"
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+	"github.com/buchgr/bazel-remote/v2/utils/discovery"
+	"github.com/buchgr/bazel-remote/v2/utils/enroll"
+	"github.com/buchgr/bazel-remote/v2/utils/faults"
+	"github.com/buchgr/bazel-remote/v2/utils/limiter"
+	"github.com/buchgr/bazel-remote/v2/utils/maintenance"
+	"github.com/buchgr/bazel-remote/v2/utils/throttle"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
 
 	"github.com/urfave/cli/v2"
 	yaml "gopkg.in/yaml.v3"
@@ -27,11 +38,128 @@ type GoogleCloudStorageConfig struct {
 	Bucket                string `yaml:"bucket"`
 	UseDefaultCredentials bool   `yaml:"use_default_credentials"`
 	JSONCredentialsFile   string `yaml:"json_credentials_file"`
+	Checksums             bool   `yaml:"checksums"`
 }
 
 // HTTPBackendConfig stores the configuration for a HTTP proxy backend.
+// The connection settings which are zero use the net/http defaults.
 type HTTPBackendConfig struct {
-	BaseURL string `yaml:"url"`
+	BaseURL             string        `yaml:"url"`
+	MaxIdleConns        int           `yaml:"max_idle_conns"`
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int           `yaml:"max_conns_per_host"`
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout"`
+	DisableHTTP2        bool          `yaml:"disable_http2"`
+	TLSSessionCacheSize int           `yaml:"tls_session_cache_size"`
+}
+
+// ReplicationConfig stores the configuration for replicating writes to
+// peer bazel-remote instances.
+type ReplicationConfig struct {
+	Peers            []string      `yaml:"peers"`
+	Discover         string        `yaml:"discover"`
+	DiscoverInterval time.Duration `yaml:"discover_interval"`
+	DiscoverMode     string        `yaml:"discover_mode"`
+	IncludeCAS       bool          `yaml:"include_cas"`
+	ConflictPolicy   string        `yaml:"conflict_policy"`
+}
+
+// ClusterConfig stores the configuration for spreading cache entries
+// across a group of bazel-remote instances.
+type ClusterConfig struct {
+	Self             string        `yaml:"self"`
+	Members          []string      `yaml:"members"`
+	Discover         string        `yaml:"discover"`
+	DiscoverInterval time.Duration `yaml:"discover_interval"`
+	DiscoverMode     string        `yaml:"discover_mode"`
+}
+
+// MaintenanceConfig stores the configuration for maintenance windows,
+// during which heavy background work runs.
+type MaintenanceConfig struct {
+	Schedule string        `yaml:"schedule"`
+	Duration time.Duration `yaml:"duration"`
+}
+
+// NotificationsConfig stores the configuration for sending notifications
+// about cache events to a webhook or a command.
+type NotificationsConfig struct {
+	WebhookURL  string   `yaml:"webhook_url"`
+	Command     string   `yaml:"command"`
+	Events      []string `yaml:"events"`
+	Kinds       []string `yaml:"kinds"`
+	MinSize     int64    `yaml:"min_size"`
+	MaxSize     int64    `yaml:"max_size"`
+	DigestsFile string   `yaml:"digests_file"`
+	BufferSize  int      `yaml:"buffer_size"`
+	RateLimit   float64  `yaml:"rate_limit"`
+}
+
+// EventStreamConfig stores the configuration for exporting records of
+// cache operations to a message broker.
+type EventStreamConfig struct {
+	NATSURL       string        `yaml:"nats_url"`
+	KafkaRESTURL  string        `yaml:"kafka_rest_url"`
+	File          string        `yaml:"file"`
+	Topic         string        `yaml:"topic"`
+	Ops           []string      `yaml:"ops"`
+	BatchSize     int           `yaml:"batch_size"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	QueueSize     int           `yaml:"queue_size"`
+	Anonymize     bool          `yaml:"anonymize"`
+}
+
+// CORSConfig stores the configuration for allowing web pages on other
+// origins to access the HTTP server.
+type CORSConfig struct {
+	AllowedOrigins []string      `yaml:"allowed_origins"`
+	AllowedMethods []string      `yaml:"allowed_methods"`
+	MaxAge         time.Duration `yaml:"max_age"`
+}
+
+// RemoteExecutionConfig stores the configuration for forwarding Remote
+// Execution API requests to an external scheduler.
+type RemoteExecutionConfig struct {
+	URL       string `yaml:"url"`
+	TLSCaFile string `yaml:"tls_ca_file"`
+}
+
+// PrewarmConfig stores the configuration for prewarming the cache with
+// the results of a seed build when a webhook reports a merge.
+type PrewarmConfig struct {
+	SeedURL       string `yaml:"seed_url"`
+	WebhookSecret string `yaml:"webhook_secret"`
+	Ref           string `yaml:"ref"`
+}
+
+// FaultInjectionConfig stores the configuration for injecting faults into
+// responses, to test how clients handle a misbehaving cache.
+type FaultInjectionConfig struct {
+	Enabled bool     `yaml:"enabled"`
+	Rules   []string `yaml:"rules"`
+}
+
+// AttestationsConfig stores the configuration for attaching attestations,
+// eg SLSA provenance, to CAS blobs.
+type AttestationsConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	PublicKeyFiles []string `yaml:"public_key_files"`
+}
+
+// MetadataConfig stores the configuration for recording small values
+// about blobs, eg virus scan verdicts, with a TTL.
+type MetadataConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	MaxTTL  time.Duration `yaml:"max_ttl"`
+}
+
+// EnrollmentConfig stores the configuration for issuing short-lived client
+// certificates to clients which present an enrollment token.
+type EnrollmentConfig struct {
+	CACertFile   string        `yaml:"ca_cert_file"`
+	CAKeyFile    string        `yaml:"ca_key_file"`
+	TokensFile   string        `yaml:"tokens_file"`
+	CertLifetime time.Duration `yaml:"cert_lifetime"`
 }
 
 // Config holds the top-level configuration for bazel-remote.
@@ -39,27 +167,60 @@ type Config struct {
 	HTTPAddress                 string                    `yaml:"http_address"`
 	GRPCAddress                 string                    `yaml:"grpc_address"`
 	ProfileAddress              string                    `yaml:"profile_address"`
+	AdminAddress                string                    `yaml:"admin_address"`
+	AdminUI                     bool                      `yaml:"admin_ui"`
+	InvocationStatsRetention    time.Duration             `yaml:"invocation_stats_retention"`
+	InvocationTranscriptLimit   int                       `yaml:"invocation_transcript_max_entries"`
 	Dir                         string                    `yaml:"dir"`
 	MaxSize                     int                       `yaml:"max_size"`
 	StorageMode                 string                    `yaml:"storage_mode"`
 	ZstdImplementation          string                    `yaml:"zstd_implementation"`
+	StartupScanWorkers          int                       `yaml:"startup_scan_workers"`
+	MaxConcurrentFileRemovals   int                       `yaml:"max_concurrent_file_removals"`
+	BatchFileRemovals           bool                      `yaml:"batch_file_removals"`
+	MaxFileRemovalRate          int                       `yaml:"max_file_removal_rate"`
+	FsyncPolicy                 map[string]string         `yaml:"fsync_policy"`
+	FsyncBatchInterval          time.Duration             `yaml:"fsync_batch_interval"`
+	MmapReads                   bool                      `yaml:"mmap_reads"`
+	AccessJournal               bool                      `yaml:"access_journal"`
+	Provenance                  bool                      `yaml:"provenance"`
+	ZombieRescan                bool                      `yaml:"zombie_rescan"`
+	VerifyLegacyReads           float64                   `yaml:"verify_legacy_reads"`
+	InlineBlobSize              int64                     `yaml:"inline_blob_size"`
+	InlineMaxBytes              int64                     `yaml:"inline_max_bytes"`
+	CASLeaseDuration            time.Duration             `yaml:"cas_lease_duration"`
+	UploadWait                  time.Duration             `yaml:"upload_wait"`
+	ProxyRequired               bool                      `yaml:"proxy_required"`
+	VerifyProxyReads            bool                      `yaml:"verify_proxy_reads"`
+	ProxyReadsWhileLoading      bool                      `yaml:"proxy_reads_while_loading"`
+	WriteThroughWhileLoading    bool                      `yaml:"write_through_while_loading"`
+	ReconcileInterval           time.Duration             `yaml:"reconcile_interval"`
+	ReconcileDownloadWindow     time.Duration             `yaml:"reconcile_download_window"`
 	HtpasswdFile                string                    `yaml:"htpasswd_file"`
 	TLSCaFile                   string                    `yaml:"tls_ca_file"`
 	TLSCertFile                 string                    `yaml:"tls_cert_file"`
 	TLSKeyFile                  string                    `yaml:"tls_key_file"`
 	AllowUnauthenticatedReads   bool                      `yaml:"allow_unauthenticated_reads"`
+	Listeners                   []ListenerConfig          `yaml:"listeners"`
 	S3CloudStorage              *S3CloudStorageConfig     `yaml:"s3_proxy,omitempty"`
 	AzBlobConfig                *AzBlobStorageConfig      `yaml:"azblob_proxy,omitempty"`
 	GoogleCloudStorage          *GoogleCloudStorageConfig `yaml:"gcs_proxy,omitempty"`
 	HTTPBackend                 *HTTPBackendConfig        `yaml:"http_proxy,omitempty"`
+	InstanceProxies             map[string]string         `yaml:"instance_proxies"`
 	NumUploaders                int                       `yaml:"num_uploaders"`
 	MaxQueuedUploads            int                       `yaml:"max_queued_uploads"`
 	IdleTimeout                 time.Duration             `yaml:"idle_timeout"`
 	DisableHTTPACValidation     bool                      `yaml:"disable_http_ac_validation"`
+	HTTPVerifyDigests           []string                  `yaml:"http_verify_digests"`
+	HTTPGzipMinSize             int64                     `yaml:"http_gzip_min_size"`
+	HTTPGzipMaxConcurrent       int                       `yaml:"http_gzip_max_concurrent"`
+	HTTPCompatMode              bool                      `yaml:"http_compat_mode"`
 	DisableGRPCACDepsCheck      bool                      `yaml:"disable_grpc_ac_deps_check"`
+	SymlinkPolicy               string                    `yaml:"symlink_policy"`
 	EnableACKeyInstanceMangling bool                      `yaml:"enable_ac_key_instance_mangling"`
 	EnableEndpointMetrics       bool                      `yaml:"enable_endpoint_metrics"`
 	MetricsDurationBuckets      []float64                 `yaml:"endpoint_metrics_duration_buckets"`
+	MetricsInstanceNames        []string                  `yaml:"endpoint_metrics_instance_names"`
 	ExperimentalRemoteAssetAPI  bool                      `yaml:"experimental_remote_asset_api"`
 	HTTPReadTimeout             time.Duration             `yaml:"http_read_timeout"`
 	HTTPWriteTimeout            time.Duration             `yaml:"http_write_timeout"`
@@ -67,12 +228,57 @@ type Config struct {
 	LogTimezone                 string                    `yaml:"log_timezone"`
 	MaxBlobSize                 int64                     `yaml:"max_blob_size"`
 	MaxProxyBlobSize            int64                     `yaml:"max_proxy_blob_size"`
+	ReadOnly                    bool                      `yaml:"read_only"`
+	Standby                     bool                      `yaml:"standby"`
+	Replication                 *ReplicationConfig        `yaml:"replication,omitempty"`
+	Cluster                     *ClusterConfig            `yaml:"cluster,omitempty"`
+	PeerSecret                  string                    `yaml:"peer_secret"`
+	Maintenance                 *MaintenanceConfig        `yaml:"maintenance,omitempty"`
+	Notifications               *NotificationsConfig      `yaml:"notifications,omitempty"`
+	EventStream                 *EventStreamConfig        `yaml:"event_stream,omitempty"`
+	CORS                        *CORSConfig               `yaml:"cors,omitempty"`
+	RemoteExecution             *RemoteExecutionConfig    `yaml:"experimental_remote_execution,omitempty"`
+	Prewarm                     *PrewarmConfig            `yaml:"prewarm,omitempty"`
+	FaultInjection              *FaultInjectionConfig     `yaml:"fault_injection,omitempty"`
+	Attestations                *AttestationsConfig       `yaml:"attestations,omitempty"`
+	Metadata                    *MetadataConfig           `yaml:"metadata,omitempty"`
+	Enrollment                  *EnrollmentConfig         `yaml:"enrollment,omitempty"`
+	MaxConcurrentRequests       int                       `yaml:"max_concurrent_requests"`
+	MaxConcurrentPerEndpoint    map[string]int            `yaml:"max_concurrent_requests_per_endpoint"`
+	MaxSizePerInstance          map[string]int            `yaml:"max_size_per_instance"`
+	MaxEntriesPerKind           map[string]int            `yaml:"max_entries_per_kind"`
+	MaxFindMissingDigests       int                       `yaml:"max_find_missing_digests"`
+	MaxBatchDigests             int                       `yaml:"max_batch_digests"`
+	MaxBatchTotalSize           int64                     `yaml:"max_batch_total_size"`
+	MaxHTTPBodySize             int64                     `yaml:"max_http_body_size"`
+	MaxDownloadRatePerConn      int64                     `yaml:"max_download_rate_per_connection"`
+	MaxDownloadRatePerIdentity  int64                     `yaml:"max_download_rate_per_identity"`
+	IOSchedulerSlots            int                       `yaml:"io_scheduler_slots"`
+	IOSchedulerWeight           int                       `yaml:"io_scheduler_interactive_weight"`
+	ResumableUploadMinSize      int64                     `yaml:"resumable_upload_min_size"`
+	MaxInflightUploadSize       int64                     `yaml:"max_inflight_upload_size"`
+	DiskHeadroom                string                    `yaml:"disk_headroom"`
+	CheckFreeSpace              bool                      `yaml:"check_free_space"`
 
 	// Fields that are created by combinations of the flags above.
-	ProxyBackend cache.Proxy
-	TLSConfig    *tls.Config
-	AccessLogger *log.Logger
-	ErrorLogger  *log.Logger
+	ProxyBackend      cache.Proxy             `yaml:"-"`
+	Replicator        *replication.Replicator `yaml:"-"`
+	HashRing          *cluster.Cluster        `yaml:"-"`
+	MaintenanceWindow *maintenance.Window     `yaml:"-"`
+	Notifier          *notify.Notifier        `yaml:"-"`
+	EventExporter     *eventstream.Exporter   `yaml:"-"`
+	ExecutionBackend  pb.ExecutionClient      `yaml:"-"`
+	Limiter           *limiter.Limiter        `yaml:"-"`
+	Throttler         *throttle.Throttler     `yaml:"-"`
+	FaultInjector     *faults.Injector        `yaml:"-"`
+	AttestationKeys   []crypto.PublicKey      `yaml:"-"`
+	CertIssuer        *enroll.Issuer          `yaml:"-"`
+	MaxSizePercent    float64                 `yaml:"-"`
+	HeadroomBytes     int64                   `yaml:"-"`
+	HeadroomPercent   float64                 `yaml:"-"`
+	TLSConfig         *tls.Config             `yaml:"-"`
+	AccessLogger      *log.Logger             `yaml:"-"`
+	ErrorLogger       *log.Logger             `yaml:"-"`
 }
 
 type YamlConfig struct {
@@ -95,7 +301,7 @@ var defaultDurationBuckets = []float64{.5, 1, 2.5, 5, 10, 20, 40, 80, 160, 320}
 
 // newFromArgs returns a validated Config with the specified values, and
 // an error if there were any problems with the validation.
-func newFromArgs(dir string, maxSize int, storageMode string, zstdImplementation string,
+func newFromArgs(dir string, maxSize int, maxSizePercent float64, storageMode string, zstdImplementation string,
 	httpAddress string, grpcAddress string,
 	profileAddress string,
 	htpasswdFile string,
@@ -105,22 +311,87 @@ func newFromArgs(dir string, maxSize int, storageMode string, zstdImplementation
 	tlsCertFile string,
 	tlsKeyFile string,
 	allowUnauthenticatedReads bool,
+	listeners []ListenerConfig,
 	idleTimeout time.Duration,
 	hc *HTTPBackendConfig,
 	gcs *GoogleCloudStorageConfig,
 	s3 *S3CloudStorageConfig,
 	azblob *AzBlobStorageConfig,
 	disableHTTPACValidation bool,
+	httpVerifyDigests []string,
+	httpGzipMinSize int64,
+	httpGzipMaxConcurrent int,
+	httpCompatMode bool,
 	disableGRPCACDepsCheck bool,
+	symlinkPolicy string,
 	enableACKeyInstanceMangling bool,
 	enableEndpointMetrics bool,
+	metricsDurationBuckets []float64,
+	metricsInstanceNames []string,
 	experimentalRemoteAssetAPI bool,
 	httpReadTimeout time.Duration,
 	httpWriteTimeout time.Duration,
 	accessLogLevel string,
 	logTimezone string,
 	maxBlobSize int64,
-	maxProxyBlobSize int64) (*Config, error) {
+	maxProxyBlobSize int64,
+	rep *ReplicationConfig,
+	clusterConfig *ClusterConfig,
+	peerSecret string,
+	readOnly bool,
+	standby bool,
+	adminAddress string,
+	adminUI bool,
+	maintenanceConfig *MaintenanceConfig,
+	maxConcurrentRequests int,
+	maxConcurrentPerEndpoint map[string]int,
+	maxSizePerInstance map[string]int,
+	maxEntriesPerKind map[string]int,
+	maxFindMissingDigests int,
+	maxBatchDigests int,
+	maxBatchTotalSize int64,
+	maxHTTPBodySize int64,
+	maxDownloadRatePerConn int64,
+	maxDownloadRatePerIdentity int64,
+	ioSchedulerSlots int,
+	ioSchedulerWeight int,
+	resumableUploadMinSize int64,
+	maxInflightUploadSize int64,
+	diskHeadroom string,
+	checkFreeSpace bool,
+	instanceProxies map[string]string,
+	startupScanWorkers int,
+	maxConcurrentFileRemovals int,
+	batchFileRemovals bool,
+	maxFileRemovalRate int,
+	fsyncPolicy map[string]string,
+	fsyncBatchInterval time.Duration,
+	mmapReads bool,
+	accessJournal bool,
+	provenance bool,
+	zombieRescan bool,
+	verifyLegacyReads float64,
+	inlineBlobSize int64,
+	inlineMaxBytes int64,
+	casLeaseDuration time.Duration,
+	uploadWait time.Duration,
+	proxyRequired bool,
+	verifyProxyReads bool,
+	proxyReadsWhileLoading bool,
+	writeThroughWhileLoading bool,
+	reconcileInterval time.Duration,
+	reconcileDownloadWindow time.Duration,
+	invocationStatsRetention time.Duration,
+	invocationTranscriptLimit int,
+	notificationsConfig *NotificationsConfig,
+	eventStreamConfig *EventStreamConfig,
+	corsConfig *CORSConfig,
+	remoteExecutionConfig *RemoteExecutionConfig,
+	prewarmConfig *PrewarmConfig,
+	faultInjectionConfig *FaultInjectionConfig,
+	attestationsConfig *AttestationsConfig,
+	metadataConfig *MetadataConfig,
+	enrollmentConfig *EnrollmentConfig) (*Config, error) {
 
 	c := Config{
 		HTTPAddress:                 httpAddress,
@@ -128,8 +399,30 @@ func newFromArgs(dir string, maxSize int, storageMode string, zstdImplementation
 		ProfileAddress:              profileAddress,
 		Dir:                         dir,
 		MaxSize:                     maxSize,
+		MaxSizePercent:              maxSizePercent,
 		StorageMode:                 storageMode,
 		ZstdImplementation:          zstdImplementation,
+		StartupScanWorkers:          startupScanWorkers,
+		MaxConcurrentFileRemovals:   maxConcurrentFileRemovals,
+		BatchFileRemovals:           batchFileRemovals,
+		MaxFileRemovalRate:          maxFileRemovalRate,
+		FsyncPolicy:                 fsyncPolicy,
+		FsyncBatchInterval:          fsyncBatchInterval,
+		MmapReads:                   mmapReads,
+		AccessJournal:               accessJournal,
+		Provenance:                  provenance,
+		ZombieRescan:                zombieRescan,
+		VerifyLegacyReads:           verifyLegacyReads,
+		InlineBlobSize:              inlineBlobSize,
+		InlineMaxBytes:              inlineMaxBytes,
+		CASLeaseDuration:            casLeaseDuration,
+		UploadWait:                  uploadWait,
+		ProxyRequired:               proxyRequired,
+		VerifyProxyReads:            verifyProxyReads,
+		ProxyReadsWhileLoading:      proxyReadsWhileLoading,
+		WriteThroughWhileLoading:    writeThroughWhileLoading,
+		ReconcileInterval:           reconcileInterval,
+		ReconcileDownloadWindow:     reconcileDownloadWindow,
 		HtpasswdFile:                htpasswdFile,
 		MaxQueuedUploads:            maxQueuedUploads,
 		NumUploaders:                numUploaders,
@@ -137,16 +430,24 @@ func newFromArgs(dir string, maxSize int, storageMode string, zstdImplementation
 		TLSCertFile:                 tlsCertFile,
 		TLSKeyFile:                  tlsKeyFile,
 		AllowUnauthenticatedReads:   allowUnauthenticatedReads,
+		Listeners:                   listeners,
 		S3CloudStorage:              s3,
 		AzBlobConfig:                azblob,
 		GoogleCloudStorage:          gcs,
 		HTTPBackend:                 hc,
+		InstanceProxies:             instanceProxies,
 		IdleTimeout:                 idleTimeout,
 		DisableHTTPACValidation:     disableHTTPACValidation,
+		HTTPVerifyDigests:           httpVerifyDigests,
+		HTTPGzipMinSize:             httpGzipMinSize,
+		HTTPGzipMaxConcurrent:       httpGzipMaxConcurrent,
+		HTTPCompatMode:              httpCompatMode,
 		DisableGRPCACDepsCheck:      disableGRPCACDepsCheck,
+		SymlinkPolicy:               symlinkPolicy,
 		EnableACKeyInstanceMangling: enableACKeyInstanceMangling,
 		EnableEndpointMetrics:       enableEndpointMetrics,
-		MetricsDurationBuckets:      defaultDurationBuckets,
+		MetricsDurationBuckets:      metricsDurationBuckets,
+		MetricsInstanceNames:        metricsInstanceNames,
 		ExperimentalRemoteAssetAPI:  experimentalRemoteAssetAPI,
 		HTTPReadTimeout:             httpReadTimeout,
 		HTTPWriteTimeout:            httpWriteTimeout,
@@ -154,6 +455,41 @@ func newFromArgs(dir string, maxSize int, storageMode string, zstdImplementation
 		LogTimezone:                 logTimezone,
 		MaxBlobSize:                 maxBlobSize,
 		MaxProxyBlobSize:            maxProxyBlobSize,
+		Replication:                 rep,
+		Cluster:                     clusterConfig,
+		PeerSecret:                  peerSecret,
+		ReadOnly:                    readOnly,
+		Standby:                     standby,
+		AdminAddress:                adminAddress,
+		AdminUI:                     adminUI,
+		InvocationStatsRetention:    invocationStatsRetention,
+		InvocationTranscriptLimit:   invocationTranscriptLimit,
+		Maintenance:                 maintenanceConfig,
+		Notifications:               notificationsConfig,
+		EventStream:                 eventStreamConfig,
+		CORS:                        corsConfig,
+		RemoteExecution:             remoteExecutionConfig,
+		Prewarm:                     prewarmConfig,
+		FaultInjection:              faultInjectionConfig,
+		Attestations:                attestationsConfig,
+		Metadata:                    metadataConfig,
+		Enrollment:                  enrollmentConfig,
+		MaxConcurrentRequests:       maxConcurrentRequests,
+		MaxFindMissingDigests:       maxFindMissingDigests,
+		MaxBatchDigests:             maxBatchDigests,
+		MaxBatchTotalSize:           maxBatchTotalSize,
+		MaxHTTPBodySize:             maxHTTPBodySize,
+		MaxDownloadRatePerConn:      maxDownloadRatePerConn,
+		MaxDownloadRatePerIdentity:  maxDownloadRatePerIdentity,
+		IOSchedulerSlots:            ioSchedulerSlots,
+		IOSchedulerWeight:           ioSchedulerWeight,
+		ResumableUploadMinSize:      resumableUploadMinSize,
+		MaxInflightUploadSize:       maxInflightUploadSize,
+		DiskHeadroom:                diskHeadroom,
+		CheckFreeSpace:              checkFreeSpace,
+		MaxConcurrentPerEndpoint:    maxConcurrentPerEndpoint,
+		MaxSizePerInstance:          maxSizePerInstance,
+		MaxEntriesPerKind:           maxEntriesPerKind,
 	}
 
 	err := validateConfig(&c)
@@ -165,23 +501,50 @@ func newFromArgs(dir string, maxSize int, storageMode string, zstdImplementation
 
 // newFromYamlFile reads configuration settings from a YAML file then returns
 // a validated Config with those settings, and an error if there were any
-// problems.
+// problems. Environment variables are expanded and included files are
+// merged before validation.
 func newFromYamlFile(path string) (*Config, error) {
-	file, err := os.Open(path)
+	var l yamlLoader
+	node, err := l.loadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to open config file '%s': %v", path, err)
+		return nil, err
 	}
-	defer file.Close()
 
-	data, err := io.ReadAll(file)
+	return decodeYaml(node, nil)
+}
+
+// NewFromYamlFileStrict is like newFromYamlFile, but also returns an
+// error for each key in the file or its included files which is not
+// recognised, eg because of a typo.
+func NewFromYamlFileStrict(path string) (*Config, error) {
+	l := yamlLoader{strict: true}
+	node, err := l.loadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to read config file '%s': %v", path, err)
+		return nil, err
+	}
+
+	if len(l.unknownKeys) > 0 {
+		return nil, fmt.Errorf("Unknown keys in YAML config:\n  %s",
+			strings.Join(l.unknownKeys, "\n  "))
 	}
 
-	return newFromYaml(data)
+	return decodeYaml(node, nil)
 }
 
 func newFromYaml(data []byte) (*Config, error) {
+	var l yamlLoader
+	node, err := l.load(data, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeYaml(node, nil)
+}
+
+// decodeYaml returns a validated Config from a YAML mapping node. If ctx
+// is not nil, the flags and environment variables which are set override
+// the settings from the YAML.
+func decodeYaml(node *yaml.Node, ctx *cli.Context) (*Config, error) {
 	yc := YamlConfig{
 		Config: Config{
 			StorageMode:            "zstd",
@@ -196,11 +559,24 @@ func newFromYaml(data []byte) (*Config, error) {
 		},
 	}
 
-	err := yaml.Unmarshal(data, &yc)
+	maxSizePercent, err := takeAutoMaxSize(node)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse YAML config: %v", err)
+	}
+
+	err = node.Decode(&yc)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to parse YAML config: %v", err)
 	}
 	c := yc.Config
+	c.MaxSizePercent = maxSizePercent
+
+	if ctx != nil {
+		err = applyFlags(ctx, &c)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if c.HTTPAddress == "" {
 		c.HTTPAddress = net.JoinHostPort(yc.Host, strconv.Itoa(yc.Port))
@@ -218,6 +594,54 @@ func newFromYaml(data []byte) (*Config, error) {
 		sort.Float64s(c.MetricsDurationBuckets)
 	}
 
+	if c.Replication != nil && c.Replication.ConflictPolicy == "" {
+		c.Replication.ConflictPolicy = replication.ConflictLastWriteWins
+	}
+
+	if c.Replication != nil && c.Replication.Discover != "" && c.Replication.DiscoverInterval == 0 {
+		c.Replication.DiscoverInterval = 30 * time.Second
+	}
+
+	if c.Replication != nil && c.Replication.Discover != "" && c.Replication.DiscoverMode == "" {
+		c.Replication.DiscoverMode = discovery.ModeDNS
+	}
+
+	if c.Cluster != nil && c.Cluster.DiscoverInterval == 0 {
+		c.Cluster.DiscoverInterval = 30 * time.Second
+	}
+
+	if c.Cluster != nil && c.Cluster.DiscoverMode == "" {
+		c.Cluster.DiscoverMode = discovery.ModeDNS
+	}
+
+	if c.Maintenance != nil && c.Maintenance.Duration == 0 {
+		c.Maintenance.Duration = defaultMaintenanceDuration
+	}
+
+	if c.Notifications != nil && c.Notifications.BufferSize == 0 {
+		c.Notifications.BufferSize = defaultNotificationBufferSize
+	}
+
+	if c.EventStream != nil {
+		setEventStreamDefaults(c.EventStream)
+	}
+
+	if c.CORS != nil {
+		setCORSDefaults(c.CORS)
+	}
+
+	if c.Prewarm != nil {
+		setPrewarmDefaults(c.Prewarm)
+	}
+
+	if c.Metadata != nil {
+		setMetadataDefaults(c.Metadata)
+	}
+
+	if c.Enrollment != nil {
+		setEnrollmentDefaults(c.Enrollment)
+	}
+
 	err = validateConfig(&c)
 	if err != nil {
 		return nil, err
@@ -231,7 +655,7 @@ func validateConfig(c *Config) error {
 		return errors.New("The 'dir' flag/key is required")
 	}
 
-	if c.MaxSize <= 0 {
+	if c.MaxSize <= 0 && c.MaxSizePercent == 0 {
 		return errors.New("The 'max_size' flag/key must be set to a value > 0")
 	}
 
@@ -260,6 +684,26 @@ func validateConfig(c *Config) error {
 		return errors.New("At most one of the S3/GCS/HTTP proxy backends is allowed")
 	}
 
+	if c.ProxyRequired && proxyCount == 0 && len(c.InstanceProxies) == 0 {
+		return errors.New("'proxy_required' is set, but no proxy backend is configured")
+	}
+
+	if c.VerifyProxyReads && proxyCount == 0 && len(c.InstanceProxies) == 0 {
+		return errors.New("'verify_proxy_reads' is set, but no proxy backend is configured")
+	}
+
+	if c.ProxyReadsWhileLoading && proxyCount == 0 && len(c.InstanceProxies) == 0 {
+		return errors.New("'proxy_reads_while_loading' is set, but no proxy backend is configured")
+	}
+
+	if c.WriteThroughWhileLoading && !c.ProxyReadsWhileLoading {
+		return errors.New("'write_through_while_loading' requires 'proxy_reads_while_loading'")
+	}
+
+	if c.Prewarm != nil && proxyCount == 0 {
+		return errors.New("'prewarm' requires a proxy backend to download from")
+	}
+
 	var httpPort string
 	if strings.HasPrefix(c.HTTPAddress, "unix://") {
 		if c.HTTPAddress[len("unix://"):] == "" {
@@ -298,23 +742,26 @@ func validateConfig(c *Config) error {
 		}
 	}
 
-	if c.GRPCAddress == disabledGRPCListener && c.ExperimentalRemoteAssetAPI {
-		return errors.New("Remote Asset API support depends on gRPC being enabled")
+	if strings.HasPrefix(c.AdminAddress, "unix://") && c.AdminAddress[len("unix://"):] == "" {
+		return errors.New("'admin_address' Unix socket specification is missing a socket path")
 	}
 
-	if (c.TLSCertFile != "" && c.TLSKeyFile == "") || (c.TLSCertFile == "" && c.TLSKeyFile != "") {
-		return errors.New("When enabling TLS one must specify both " +
-			"'tls_key_file' and 'tls_cert_file'")
+	if c.AdminUI && (c.AdminAddress == "" || c.HtpasswdFile == "") {
+		return errors.New("'admin_ui' requires 'admin_address', and 'htpasswd_file' to authenticate its users")
 	}
 
-	if c.TLSCaFile != "" && (c.TLSCertFile == "" || c.TLSKeyFile == "") {
-		return errors.New("When enabling mTLS (authenticating client " +
-			"certificates) the server must have it's own 'tls_key_file' " +
-			"and 'tls_cert_file' specified.")
+	if c.GRPCAddress == disabledGRPCListener && c.ExperimentalRemoteAssetAPI {
+		return errors.New("Remote Asset API support depends on gRPC being enabled")
 	}
 
-	if c.AllowUnauthenticatedReads && c.TLSCaFile == "" && c.HtpasswdFile == "" {
-		return errors.New("AllowUnauthenticatedReads setting is only available when authentication is enabled")
+	err := validateAuth(c.TLSCaFile, c.TLSCertFile, c.TLSKeyFile, c.HtpasswdFile, c.AllowUnauthenticatedReads)
+	if err != nil {
+		return err
+	}
+
+	err = validateListeners(c)
+	if err != nil {
+		return err
 	}
 
 	if c.MaxBlobSize <= 0 {
@@ -339,6 +786,12 @@ func validateConfig(c *Config) error {
 		if c.HTTPBackend.BaseURL == "" {
 			return errors.New("The 'url' field is required for 'http_proxy'")
 		}
+
+		if c.HTTPBackend.MaxIdleConns < 0 || c.HTTPBackend.MaxIdleConnsPerHost < 0 ||
+			c.HTTPBackend.MaxConnsPerHost < 0 || c.HTTPBackend.IdleConnTimeout < 0 ||
+			c.HTTPBackend.TLSSessionCacheSize < 0 {
+			return errors.New("The connection settings of 'http_proxy' must not be negative")
+		}
 	}
 
 	if c.S3CloudStorage != nil {
@@ -349,6 +802,21 @@ func validateConfig(c *Config) error {
 		if c.S3CloudStorage.KeyVersion != nil && *c.S3CloudStorage.KeyVersion != 2 {
 			return fmt.Errorf("s3.key_version (deprecated) must be 2, found %d", c.S3CloudStorage.KeyVersion)
 		}
+
+		err := c.S3CloudStorage.validateObjectTags()
+		if err != nil {
+			return err
+		}
+
+		err = c.S3CloudStorage.validateStorageClasses()
+		if err != nil {
+			return err
+		}
+
+		err = c.S3CloudStorage.validateDirectoryBucket()
+		if err != nil {
+			return err
+		}
 	}
 
 	if c.AzBlobConfig != nil {
@@ -365,6 +833,11 @@ func validateConfig(c *Config) error {
 		}
 	}
 
+	err = validateInstanceProxies(c)
+	if err != nil {
+		return err
+	}
+
 	if c.MetricsDurationBuckets != nil {
 		duplicates := make(map[float64]bool)
 		for _, bucket := range c.MetricsDurationBuckets {
@@ -376,6 +849,10 @@ func validateConfig(c *Config) error {
 		}
 	}
 
+	if len(c.MetricsInstanceNames) > 0 && !c.EnableEndpointMetrics {
+		return errors.New("'endpoint_metrics_instance_names' requires 'enable_endpoint_metrics'")
+	}
+
 	switch c.AccessLogLevel {
 	case "none", "all":
 	default:
@@ -388,6 +865,272 @@ func validateConfig(c *Config) error {
 		return errors.New("'log_timezone' must be set to either \"UTC\", \"local\" or \"none\"")
 	}
 
+	if c.Replication != nil {
+		if (len(c.Replication.Peers) == 0) == (c.Replication.Discover == "") {
+			return errors.New("Exactly one of 'replication.peers' and 'replication.discover' must be set")
+		}
+
+		if c.Replication.Discover != "" && c.Replication.DiscoverInterval <= 0 {
+			return errors.New("'replication.discover_interval' must be greater than zero")
+		}
+
+		if c.Replication.Discover != "" && !discovery.IsValidMode(c.Replication.DiscoverMode) {
+			return fmt.Errorf("Invalid replication.discover_mode: %q", c.Replication.DiscoverMode)
+		}
+
+		if !replication.IsValidConflictPolicy(c.Replication.ConflictPolicy) {
+			return fmt.Errorf("Invalid replication.conflict_policy: %q", c.Replication.ConflictPolicy)
+		}
+	}
+
+	if (c.Replication != nil || c.Standby) && c.PeerSecret == "" {
+		return errors.New("'peer_secret' must be set with replication or standby")
+	}
+
+	if c.Cluster != nil {
+		if c.Cluster.Self == "" {
+			return errors.New("'cluster.self' must be set")
+		}
+
+		if (len(c.Cluster.Members) == 0) == (c.Cluster.Discover == "") {
+			return errors.New("Exactly one of 'cluster.members' and 'cluster.discover' must be set")
+		}
+
+		if c.Cluster.Discover != "" && c.Cluster.DiscoverInterval <= 0 {
+			return errors.New("'cluster.discover_interval' must be greater than zero")
+		}
+
+		if c.Cluster.Discover != "" && !discovery.IsValidMode(c.Cluster.DiscoverMode) {
+			return fmt.Errorf("Invalid cluster.discover_mode: %q", c.Cluster.DiscoverMode)
+		}
+
+		if c.PeerSecret == "" {
+			return errors.New("'peer_secret' must be set in cluster mode")
+		}
+	}
+
+	if c.Maintenance != nil {
+		if c.Maintenance.Schedule != "" {
+			_, err := maintenance.ParseSchedule(c.Maintenance.Schedule)
+			if err != nil {
+				return fmt.Errorf("Invalid 'maintenance.schedule': %w", err)
+			}
+		}
+
+		if c.Maintenance.Duration <= 0 {
+			return errors.New("'maintenance.duration' must be greater than zero")
+		}
+	}
+
+	err = validateNotifications(c.Notifications)
+	if err != nil {
+		return err
+	}
+
+	err = validateEventStream(c.EventStream)
+	if err != nil {
+		return err
+	}
+
+	err = validateCORS(c.CORS)
+	if err != nil {
+		return err
+	}
+
+	err = validateRemoteExecution(c.RemoteExecution)
+	if err != nil {
+		return err
+	}
+
+	err = validatePrewarm(c.Prewarm)
+	if err != nil {
+		return err
+	}
+
+	err = validateFaultInjection(c.FaultInjection)
+	if err != nil {
+		return err
+	}
+
+	err = validateAttestations(c.Attestations)
+	if err != nil {
+		return err
+	}
+
+	err = validateMetadata(c.Metadata)
+	if err != nil {
+		return err
+	}
+
+	err = validateEnrollment(c.Enrollment, c.TLSCertFile)
+	if err != nil {
+		return err
+	}
+
+	if c.StartupScanWorkers < 0 {
+		return errors.New("'startup_scan_workers' must not be negative")
+	}
+
+	if c.MaxConcurrentFileRemovals < 0 {
+		return errors.New("'max_concurrent_file_removals' must not be negative")
+	}
+
+	if c.MaxFileRemovalRate < 0 {
+		return errors.New("'max_file_removal_rate' must not be negative")
+	}
+
+	err = validateFsyncPolicies(c)
+	if err != nil {
+		return err
+	}
+
+	if c.FsyncBatchInterval < 0 {
+		return errors.New("'fsync_batch_interval' must not be negative")
+	}
+
+	if c.CASLeaseDuration < 0 {
+		return errors.New("'cas_lease_duration' must not be negative")
+	}
+
+	if c.UploadWait < 0 {
+		return errors.New("'upload_wait' must not be negative")
+	}
+
+	if c.ReconcileInterval < 0 {
+		return errors.New("'reconcile_interval' must not be negative")
+	}
+	if c.ReconcileInterval > 0 && (c.S3CloudStorage == nil || len(c.InstanceProxies) > 0) {
+		return errors.New("'reconcile_interval' requires the s3_proxy backend, without 'instance_proxies'")
+	}
+	if c.ReconcileDownloadWindow < 0 {
+		return errors.New("'reconcile_download_window' must not be negative")
+	}
+
+	for _, kind := range c.HTTPVerifyDigests {
+		if kind != "ac" && kind != "raw" {
+			return fmt.Errorf("Invalid kind in 'http_verify_digests': %q, expected ac or raw (CAS uploads are always verified)", kind)
+		}
+	}
+
+	if c.HTTPGzipMinSize < 0 {
+		return errors.New("'http_gzip_min_size' must not be negative")
+	}
+	if c.HTTPGzipMaxConcurrent < 0 {
+		return errors.New("'http_gzip_max_concurrent' must not be negative")
+	}
+
+	if c.InvocationStatsRetention < 0 {
+		return errors.New("'invocation_stats_retention' must not be negative")
+	}
+	if c.InvocationTranscriptLimit < 0 {
+		return errors.New("'invocation_transcript_max_entries' must not be negative")
+	}
+	if c.InvocationTranscriptLimit > 0 && c.InvocationStatsRetention == 0 {
+		return errors.New("'invocation_transcript_max_entries' requires 'invocation_stats_retention'")
+	}
+
+	if c.MaxConcurrentRequests < 0 {
+		return errors.New("'max_concurrent_requests' must not be negative")
+	}
+
+	if c.MaxFindMissingDigests < 0 {
+		return errors.New("'max_find_missing_digests' must not be negative")
+	}
+	if c.MaxBatchDigests < 0 {
+		return errors.New("'max_batch_digests' must not be negative")
+	}
+	if c.MaxBatchTotalSize < 0 {
+		return errors.New("'max_batch_total_size' must not be negative")
+	}
+	if c.MaxHTTPBodySize < 0 {
+		return errors.New("'max_http_body_size' must not be negative")
+	}
+
+	if c.MaxDownloadRatePerConn < 0 {
+		return errors.New("'max_download_rate_per_connection' must not be negative")
+	}
+	if c.MaxDownloadRatePerIdentity < 0 {
+		return errors.New("'max_download_rate_per_identity' must not be negative")
+	}
+
+	if c.IOSchedulerSlots < 0 {
+		return errors.New("'io_scheduler_slots' must not be negative")
+	}
+	if c.IOSchedulerWeight < 0 {
+		return errors.New("'io_scheduler_interactive_weight' must not be negative")
+	}
+
+	if c.ResumableUploadMinSize < 0 {
+		return errors.New("'resumable_upload_min_size' must not be negative")
+	}
+
+	if c.MaxInflightUploadSize < 0 {
+		return errors.New("'max_inflight_upload_size' must not be negative")
+	}
+
+	err = validateDiskHeadroom(c.DiskHeadroom)
+	if err != nil {
+		return err
+	}
+
+	if c.VerifyLegacyReads < 0 || c.VerifyLegacyReads > 1 {
+		return errors.New("'verify_legacy_reads' must be between 0 and 1")
+	}
+
+	if c.InlineBlobSize < 0 || c.InlineBlobSize > 64*1024 {
+		return errors.New("'inline_blob_size' must be between 0 and 65536")
+	}
+
+	if c.InlineMaxBytes < 0 {
+		return errors.New("'inline_max_bytes' must not be negative")
+	}
+
+	_, err = validate.ParseSymlinkPolicy(c.SymlinkPolicy)
+	if err != nil {
+		return err
+	}
+
+	for endpoint, limit := range c.MaxConcurrentPerEndpoint {
+		if !isValidEndpoint(endpoint) {
+			return fmt.Errorf("Invalid endpoint in 'max_concurrent_requests_per_endpoint': %q, "+
+				"expected an HTTP method (GET, HEAD or PUT) or a gRPC service and method, eg ByteStream/Write", endpoint)
+		}
+		if limit <= 0 {
+			return fmt.Errorf("The 'max_concurrent_requests_per_endpoint' limit for %s must be greater than zero", endpoint)
+		}
+	}
+
+	for instance, size := range c.MaxSizePerInstance {
+		if size <= 0 || (c.MaxSizePercent == 0 && size > c.MaxSize) {
+			return fmt.Errorf("The 'max_size_per_instance' size for instance %q must be greater than zero and at most 'max_size', found %d", instance, size)
+		}
+	}
+
+	err = validateMaxEntries(c)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate the TLS and authentication settings of a listener.
+func validateAuth(tlsCaFile, tlsCertFile, tlsKeyFile, htpasswdFile string, allowUnauthenticatedReads bool) error {
+	if (tlsCertFile != "" && tlsKeyFile == "") || (tlsCertFile == "" && tlsKeyFile != "") {
+		return errors.New("When enabling TLS one must specify both " +
+			"'tls_key_file' and 'tls_cert_file'")
+	}
+
+	if tlsCaFile != "" && (tlsCertFile == "" || tlsKeyFile == "") {
+		return errors.New("When enabling mTLS (authenticating client " +
+			"certificates) the server must have it's own 'tls_key_file' " +
+			"and 'tls_cert_file' specified.")
+	}
+
+	if allowUnauthenticatedReads && tlsCaFile == "" && htpasswdFile == "" {
+		return errors.New("AllowUnauthenticatedReads setting is only available when authentication is enabled")
+	}
+
 	return nil
 }
 
@@ -410,6 +1153,59 @@ func Get(ctx *cli.Context) (*Config, error) {
 		return nil, err
 	}
 
+	err = cfg.setReplicator()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.setHashRing()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.setMaintenanceWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.setNotifier()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.setEventExporter()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.setExecutionBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.setLimiter()
+	cfg.setThrottler()
+
+	err = cfg.setFaultInjector()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.setAttestationKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.setDiskHeadroom()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.setCertIssuer()
+	if err != nil {
+		return nil, err
+	}
+
 	err = cfg.setTLSConfig()
 	if err != nil {
 		return nil, err
@@ -418,11 +1214,24 @@ func Get(ctx *cli.Context) (*Config, error) {
 	return cfg, nil
 }
 
+// Parse returns a validated Config from the config file or the flags and
+// environment variables. Unlike Get, it does not set up the fields which
+// are derived from the settings, like ProxyBackend.
+func Parse(ctx *cli.Context) (*Config, error) {
+	return get(ctx)
+}
+
 // Return a Config with all the basic fields set.
 func get(ctx *cli.Context) (*Config, error) {
 	configFile := ctx.String("config_file")
 	if configFile != "" {
-		return newFromYamlFile(configFile)
+		var l yamlLoader
+		node, err := l.loadFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return decodeYaml(node, ctx)
 	}
 
 	httpAddress := ctx.String("http_address")
@@ -443,27 +1252,46 @@ func get(ctx *cli.Context) (*Config, error) {
 	}
 
 	var s3 *S3CloudStorageConfig
-	if ctx.String("s3.bucket") != "" {
+	if ctx.String("s3_proxy.bucket") != "" {
+		objectTags, err := parseS3ObjectTags(ctx.StringSlice("s3_proxy.object_tags"))
+		if err != nil {
+			return nil, err
+		}
+
+		storageClasses, err := parseS3StorageClasses(ctx.StringSlice("s3_proxy.storage_classes"))
+		if err != nil {
+			return nil, err
+		}
+
 		s3 = &S3CloudStorageConfig{
-			Endpoint:                 ctx.String("s3.endpoint"),
-			Bucket:                   ctx.String("s3.bucket"),
-			Prefix:                   ctx.String("s3.prefix"),
-			AuthMethod:               ctx.String("s3.auth_method"),
-			AccessKeyID:              ctx.String("s3.access_key_id"),
-			SecretAccessKey:          ctx.String("s3.secret_access_key"),
-			DisableSSL:               ctx.Bool("s3.disable_ssl"),
-			UpdateTimestamps:         ctx.Bool("s3.update_timestamps"),
-			IAMRoleEndpoint:          ctx.String("s3.iam_role_endpoint"),
-			Region:                   ctx.String("s3.region"),
-			AWSProfile:               ctx.String("s3.aws_profile"),
-			AWSSharedCredentialsFile: ctx.String("s3.aws_shared_credentials_file"),
+			Endpoint:                 ctx.String("s3_proxy.endpoint"),
+			Bucket:                   ctx.String("s3_proxy.bucket"),
+			Prefix:                   ctx.String("s3_proxy.prefix"),
+			AuthMethod:               ctx.String("s3_proxy.auth_method"),
+			AccessKeyID:              ctx.String("s3_proxy.access_key_id"),
+			SecretAccessKey:          ctx.String("s3_proxy.secret_access_key"),
+			DisableSSL:               ctx.Bool("s3_proxy.disable_ssl"),
+			UpdateTimestamps:         ctx.Bool("s3_proxy.update_timestamps"),
+			IAMRoleEndpoint:          ctx.String("s3_proxy.iam_role_endpoint"),
+			Region:                   ctx.String("s3_proxy.region"),
+			AWSProfile:               ctx.String("s3_proxy.aws_profile"),
+			AWSSharedCredentialsFile: ctx.String("s3_proxy.aws_shared_credentials_file"),
+			ObjectTags:               objectTags,
+			StorageClasses:           storageClasses,
+			Checksums:                ctx.Bool("s3_proxy.checksums"),
 		}
 	}
 
 	var hc *HTTPBackendConfig
 	if ctx.String("http_proxy.url") != "" {
 		hc = &HTTPBackendConfig{
-			BaseURL: ctx.String("http_proxy.url"),
+			BaseURL:             ctx.String("http_proxy.url"),
+			MaxIdleConns:        ctx.Int("http_proxy.max_idle_conns"),
+			MaxIdleConnsPerHost: ctx.Int("http_proxy.max_idle_conns_per_host"),
+			MaxConnsPerHost:     ctx.Int("http_proxy.max_conns_per_host"),
+			IdleConnTimeout:     ctx.Duration("http_proxy.idle_conn_timeout"),
+			DisableHTTP2:        ctx.Bool("http_proxy.disable_http2"),
+			TLSSessionCacheSize: ctx.Int("http_proxy.tls_session_cache_size"),
 		}
 	}
 
@@ -473,28 +1301,194 @@ func get(ctx *cli.Context) (*Config, error) {
 			Bucket:                ctx.String("gcs_proxy.bucket"),
 			UseDefaultCredentials: ctx.Bool("gcs_proxy.use_default_credentials"),
 			JSONCredentialsFile:   ctx.String("gcs_proxy.json_credentials_file"),
+			Checksums:             ctx.Bool("gcs_proxy.checksums"),
 		}
 	}
 
 	var azblob *AzBlobStorageConfig
-	if ctx.String("azblob.tenant_id") != "" {
+	if ctx.String("azblob_proxy.tenant_id") != "" {
 		azblob = &AzBlobStorageConfig{
-			TenantID:         ctx.String("azblob.tenant_id"),
-			StorageAccount:   ctx.String("azblob.storage_account"),
-			ContainerName:    ctx.String("azblob.container_name"),
-			Prefix:           ctx.String("azblob.prefix"),
-			AuthMethod:       ctx.String("azblob.auth_method"),
-			ClientID:         ctx.String("azblob.client_id"),
-			ClientSecret:     ctx.String("azblob.client_secret"),
-			CertPath:         ctx.String("azblob.cert_path"),
-			SharedKey:        ctx.String("azblob.shared_key"),
-			UpdateTimestamps: ctx.Bool("azblob.update_timestamps"),
+			TenantID:         ctx.String("azblob_proxy.tenant_id"),
+			StorageAccount:   ctx.String("azblob_proxy.storage_account"),
+			ContainerName:    ctx.String("azblob_proxy.container_name"),
+			Prefix:           ctx.String("azblob_proxy.prefix"),
+			AuthMethod:       ctx.String("azblob_proxy.auth_method"),
+			ClientID:         ctx.String("azblob_proxy.client_id"),
+			ClientSecret:     ctx.String("azblob_proxy.client_secret"),
+			CertPath:         ctx.String("azblob_proxy.cert_path"),
+			SharedKey:        ctx.String("azblob_proxy.shared_key"),
+			UpdateTimestamps: ctx.Bool("azblob_proxy.update_timestamps"),
+		}
+	}
+
+	var rep *ReplicationConfig
+	if len(ctx.StringSlice("replication.peers")) > 0 || ctx.String("replication.discover") != "" {
+		rep = &ReplicationConfig{
+			Peers:            ctx.StringSlice("replication.peers"),
+			Discover:         ctx.String("replication.discover"),
+			DiscoverInterval: ctx.Duration("replication.discover_interval"),
+			DiscoverMode:     ctx.String("replication.discover_mode"),
+			IncludeCAS:       ctx.Bool("replication.include_cas"),
+			ConflictPolicy:   ctx.String("replication.conflict_policy"),
+		}
+	}
+
+	var clusterConfig *ClusterConfig
+	if ctx.String("cluster.self") != "" {
+		clusterConfig = &ClusterConfig{
+			Self:             ctx.String("cluster.self"),
+			Members:          ctx.StringSlice("cluster.members"),
+			Discover:         ctx.String("cluster.discover"),
+			DiscoverInterval: ctx.Duration("cluster.discover_interval"),
+			DiscoverMode:     ctx.String("cluster.discover_mode"),
+		}
+	}
+
+	var maintenanceConfig *MaintenanceConfig
+	if ctx.String("maintenance.schedule") != "" || ctx.IsSet("maintenance.duration") {
+		maintenanceConfig = &MaintenanceConfig{
+			Schedule: ctx.String("maintenance.schedule"),
+			Duration: ctx.Duration("maintenance.duration"),
+		}
+	}
+
+	var notificationsConfig *NotificationsConfig
+	if ctx.String("notifications.webhook_url") != "" || ctx.String("notifications.command") != "" {
+		notificationsConfig = &NotificationsConfig{
+			WebhookURL:  ctx.String("notifications.webhook_url"),
+			Command:     ctx.String("notifications.command"),
+			Events:      ctx.StringSlice("notifications.events"),
+			Kinds:       ctx.StringSlice("notifications.kinds"),
+			MinSize:     ctx.Int64("notifications.min_size"),
+			MaxSize:     ctx.Int64("notifications.max_size"),
+			DigestsFile: ctx.String("notifications.digests_file"),
+			BufferSize:  ctx.Int("notifications.buffer_size"),
+			RateLimit:   ctx.Float64("notifications.rate_limit"),
+		}
+	}
+
+	var eventStreamConfig *EventStreamConfig
+	if ctx.String("event_stream.nats_url") != "" || ctx.String("event_stream.kafka_rest_url") != "" ||
+		ctx.String("event_stream.file") != "" {
+		eventStreamConfig = &EventStreamConfig{
+			NATSURL:       ctx.String("event_stream.nats_url"),
+			KafkaRESTURL:  ctx.String("event_stream.kafka_rest_url"),
+			File:          ctx.String("event_stream.file"),
+			Topic:         ctx.String("event_stream.topic"),
+			Ops:           ctx.StringSlice("event_stream.ops"),
+			BatchSize:     ctx.Int("event_stream.batch_size"),
+			FlushInterval: ctx.Duration("event_stream.flush_interval"),
+			QueueSize:     ctx.Int("event_stream.queue_size"),
+			Anonymize:     ctx.Bool("event_stream.anonymize"),
+		}
+	}
+
+	var remoteExecutionConfig *RemoteExecutionConfig
+	if ctx.String("experimental_remote_execution.url") != "" {
+		remoteExecutionConfig = &RemoteExecutionConfig{
+			URL:       ctx.String("experimental_remote_execution.url"),
+			TLSCaFile: ctx.String("experimental_remote_execution.tls_ca_file"),
+		}
+	}
+
+	var prewarmConfig *PrewarmConfig
+	if ctx.String("prewarm.seed_url") != "" {
+		prewarmConfig = &PrewarmConfig{
+			SeedURL:       ctx.String("prewarm.seed_url"),
+			WebhookSecret: ctx.String("prewarm.webhook_secret"),
+			Ref:           ctx.String("prewarm.ref"),
 		}
 	}
 
+	var faultInjectionConfig *FaultInjectionConfig
+	if ctx.Bool("fault_injection.enabled") || len(ctx.StringSlice("fault_injection.rules")) > 0 {
+		faultInjectionConfig = &FaultInjectionConfig{
+			Enabled: ctx.Bool("fault_injection.enabled"),
+			Rules:   ctx.StringSlice("fault_injection.rules"),
+		}
+	}
+
+	var attestationsConfig *AttestationsConfig
+	if ctx.Bool("attestations.enabled") || len(ctx.StringSlice("attestations.public_key_files")) > 0 {
+		attestationsConfig = &AttestationsConfig{
+			Enabled:        ctx.Bool("attestations.enabled"),
+			PublicKeyFiles: ctx.StringSlice("attestations.public_key_files"),
+		}
+	}
+
+	var metadataConfig *MetadataConfig
+	if ctx.Bool("metadata.enabled") || ctx.IsSet("metadata.max_ttl") {
+		metadataConfig = &MetadataConfig{
+			Enabled: ctx.Bool("metadata.enabled"),
+			MaxTTL:  ctx.Duration("metadata.max_ttl"),
+		}
+	}
+
+	var enrollmentConfig *EnrollmentConfig
+	if ctx.String("enrollment.ca_cert_file") != "" || ctx.String("enrollment.ca_key_file") != "" ||
+		ctx.String("enrollment.tokens_file") != "" || ctx.IsSet("enrollment.cert_lifetime") {
+		enrollmentConfig = &EnrollmentConfig{
+			CACertFile:   ctx.String("enrollment.ca_cert_file"),
+			CAKeyFile:    ctx.String("enrollment.ca_key_file"),
+			TokensFile:   ctx.String("enrollment.tokens_file"),
+			CertLifetime: ctx.Duration("enrollment.cert_lifetime"),
+		}
+	}
+
+	var corsConfig *CORSConfig
+	if len(ctx.StringSlice("cors.allowed_origins")) > 0 {
+		corsConfig = &CORSConfig{
+			AllowedOrigins: ctx.StringSlice("cors.allowed_origins"),
+			AllowedMethods: ctx.StringSlice("cors.allowed_methods"),
+			MaxAge:         ctx.Duration("cors.max_age"),
+		}
+	}
+
+	metricsDurationBuckets := defaultDurationBuckets
+	if ctx.IsSet("endpoint_metrics_duration_buckets") {
+		metricsDurationBuckets = ctx.Float64Slice("endpoint_metrics_duration_buckets")
+		sort.Float64s(metricsDurationBuckets)
+	}
+
+	maxConcurrentPerEndpoint, err := parseEndpointLimits(ctx.StringSlice("max_concurrent_requests_per_endpoint"))
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize, maxSizePercent, err := parseMaxSize(ctx.String("max_size"))
+	if err != nil {
+		return nil, err
+	}
+
+	listeners, err := parseListeners(ctx.StringSlice("listeners"))
+	if err != nil {
+		return nil, err
+	}
+
+	maxSizePerInstance, err := parseInstanceSizes(ctx.StringSlice("max_size_per_instance"))
+	if err != nil {
+		return nil, err
+	}
+
+	maxEntriesPerKind, err := parseMaxEntries(ctx.StringSlice("max_entries_per_kind"))
+	if err != nil {
+		return nil, err
+	}
+
+	instanceProxies, err := parseInstanceProxies(ctx.StringSlice("instance_proxies"))
+	if err != nil {
+		return nil, err
+	}
+
+	fsyncPolicy, err := parseFsyncPolicies(ctx.StringSlice("fsync_policy"))
+	if err != nil {
+		return nil, err
+	}
+
 	return newFromArgs(
 		ctx.String("dir"),
-		ctx.Int("max_size"),
+		maxSize,
+		maxSizePercent,
 		ctx.String("storage_mode"),
 		ctx.String("zstd_implementation"),
 		httpAddress,
@@ -507,15 +1501,23 @@ func get(ctx *cli.Context) (*Config, error) {
 		ctx.String("tls_cert_file"),
 		ctx.String("tls_key_file"),
 		ctx.Bool("allow_unauthenticated_reads"),
+		listeners,
 		ctx.Duration("idle_timeout"),
 		hc,
 		gcs,
 		s3,
 		azblob,
 		ctx.Bool("disable_http_ac_validation"),
+		ctx.StringSlice("http_verify_digests"),
+		ctx.Int64("http_gzip_min_size"),
+		ctx.Int("http_gzip_max_concurrent"),
+		ctx.Bool("http_compat_mode"),
 		ctx.Bool("disable_grpc_ac_deps_check"),
+		ctx.String("symlink_policy"),
 		ctx.Bool("enable_ac_key_instance_mangling"),
 		ctx.Bool("enable_endpoint_metrics"),
+		metricsDurationBuckets,
+		ctx.StringSlice("endpoint_metrics_instance_names"),
 		ctx.Bool("experimental_remote_asset_api"),
 		ctx.Duration("http_read_timeout"),
 		ctx.Duration("http_write_timeout"),
@@ -523,5 +1525,62 @@ func get(ctx *cli.Context) (*Config, error) {
 		ctx.String("log_timezone"),
 		ctx.Int64("max_blob_size"),
 		ctx.Int64("max_proxy_blob_size"),
+		rep,
+		clusterConfig,
+		ctx.String("peer_secret"),
+		ctx.Bool("read_only"),
+		ctx.Bool("standby"),
+		ctx.String("admin_address"),
+		ctx.Bool("admin_ui"),
+		maintenanceConfig,
+		ctx.Int("max_concurrent_requests"),
+		maxConcurrentPerEndpoint,
+		maxSizePerInstance,
+		maxEntriesPerKind,
+		ctx.Int("max_find_missing_digests"),
+		ctx.Int("max_batch_digests"),
+		ctx.Int64("max_batch_total_size"),
+		ctx.Int64("max_http_body_size"),
+		ctx.Int64("max_download_rate_per_connection"),
+		ctx.Int64("max_download_rate_per_identity"),
+		ctx.Int("io_scheduler_slots"),
+		ctx.Int("io_scheduler_interactive_weight"),
+		ctx.Int64("resumable_upload_min_size"),
+		ctx.Int64("max_inflight_upload_size"),
+		ctx.String("disk_headroom"),
+		ctx.Bool("check_free_space"),
+		instanceProxies,
+		ctx.Int("startup_scan_workers"),
+		ctx.Int("max_concurrent_file_removals"),
+		ctx.Bool("batch_file_removals"),
+		ctx.Int("max_file_removal_rate"),
+		fsyncPolicy,
+		ctx.Duration("fsync_batch_interval"),
+		ctx.Bool("mmap_reads"),
+		ctx.Bool("access_journal"),
+		ctx.Bool("provenance"),
+		ctx.Bool("zombie_rescan"),
+		ctx.Float64("verify_legacy_reads"),
+		ctx.Int64("inline_blob_size"),
+		ctx.Int64("inline_max_bytes"),
+		ctx.Duration("cas_lease_duration"),
+		ctx.Duration("upload_wait"),
+		ctx.Bool("proxy_required"),
+		ctx.Bool("verify_proxy_reads"),
+		ctx.Bool("proxy_reads_while_loading"),
+		ctx.Bool("write_through_while_loading"),
+		ctx.Duration("reconcile_interval"),
+		ctx.Duration("reconcile_download_window"),
+		ctx.Duration("invocation_stats_retention"),
+		ctx.Int("invocation_transcript_max_entries"),
+		notificationsConfig,
+		eventStreamConfig,
+		corsConfig,
+		remoteExecutionConfig,
+		prewarmConfig,
+		faultInjectionConfig,
+		attestationsConfig,
+		metadataConfig,
+		enrollmentConfig,
 	)
 }
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// The methods which cross-origin requests may use by default, ie
+// read-only access.
+var defaultCORSAllowedMethods = []string{http.MethodGet, http.MethodHead}
+
+func setCORSDefaults(cors *CORSConfig) {
+	if len(cors.AllowedMethods) == 0 {
+		cors.AllowedMethods = defaultCORSAllowedMethods
+	}
+}
+
+func validateCORS(cors *CORSConfig) error {
+	if cors == nil {
+		return nil
+	}
+
+	if len(cors.AllowedOrigins) == 0 {
+		return errors.New("'cors.allowed_origins' must be set")
+	}
+
+	for _, origin := range cors.AllowedOrigins {
+		if origin != "*" && !isOrigin(origin) {
+			return fmt.Errorf("Invalid origin in 'cors.allowed_origins': %q, expected \"*\" or scheme://host[:port]", origin)
+		}
+	}
+
+	allOrigins := false
+	for _, origin := range cors.AllowedOrigins {
+		allOrigins = allOrigins || origin == "*"
+	}
+
+	for _, method := range cors.AllowedMethods {
+		switch method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut:
+			if allOrigins {
+				return errors.New("'cors.allowed_methods' must not include PUT when 'cors.allowed_origins' is \"*\"")
+			}
+		default:
+			return fmt.Errorf("Invalid method in 'cors.allowed_methods': %q, expected GET, HEAD or PUT", method)
+		}
+	}
+
+	if cors.MaxAge < 0 {
+		return errors.New("'cors.max_age' must not be negative")
+	}
+
+	return nil
+}
+
+// Returns true if s is an origin as sent by browsers in the Origin
+// header, eg https://cache-ui.example.com:8443.
+func isOrigin(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		u.Path == "" && u.User == nil && u.RawQuery == "" && u.Fragment == ""
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"bytes"
+	"net/url"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// The placeholder for secrets in Redacted configs.
+const redactedValue = "REDACTED"
+
+// Redacted returns a copy of c with secrets, like access keys and the
+// passwords in URLs, replaced by a placeholder. Secrets which are not
+// set are left empty.
+func (c *Config) Redacted() *Config {
+	r := *c
+
+	if c.S3CloudStorage != nil {
+		s3 := *c.S3CloudStorage
+		s3.SecretAccessKey = redact(s3.SecretAccessKey)
+		r.S3CloudStorage = &s3
+	}
+
+	if c.AzBlobConfig != nil {
+		azblob := *c.AzBlobConfig
+		azblob.ClientSecret = redact(azblob.ClientSecret)
+		azblob.SharedKey = redact(azblob.SharedKey)
+		r.AzBlobConfig = &azblob
+	}
+
+	if c.HTTPBackend != nil {
+		hc := *c.HTTPBackend
+		hc.BaseURL = redactURL(hc.BaseURL)
+		r.HTTPBackend = &hc
+	}
+
+	if c.InstanceProxies != nil {
+		r.InstanceProxies = make(map[string]string, len(c.InstanceProxies))
+		for instance, u := range c.InstanceProxies {
+			r.InstanceProxies[instance] = redactURL(u)
+		}
+	}
+
+	if c.Replication != nil {
+		rep := *c.Replication
+		rep.Peers = make([]string, len(c.Replication.Peers))
+		for i, p := range c.Replication.Peers {
+			rep.Peers[i] = redactURL(p)
+		}
+		rep.Discover = redactURL(rep.Discover)
+		r.Replication = &rep
+	}
+
+	if c.Cluster != nil {
+		cl := *c.Cluster
+		cl.Self = redactURL(cl.Self)
+		cl.Members = make([]string, len(c.Cluster.Members))
+		for i, m := range c.Cluster.Members {
+			cl.Members[i] = redactURL(m)
+		}
+		cl.Discover = redactURL(cl.Discover)
+		r.Cluster = &cl
+	}
+
+	r.PeerSecret = redact(c.PeerSecret)
+
+	if c.Notifications != nil {
+		n := *c.Notifications
+		n.WebhookURL = redactURL(n.WebhookURL)
+		r.Notifications = &n
+	}
+
+	if c.EventStream != nil {
+		es := *c.EventStream
+		es.NATSURL = redactURL(es.NATSURL)
+		es.KafkaRESTURL = redactURL(es.KafkaRESTURL)
+		r.EventStream = &es
+	}
+
+	if c.RemoteExecution != nil {
+		re := *c.RemoteExecution
+		re.URL = redactURL(re.URL)
+		r.RemoteExecution = &re
+	}
+
+	if c.Prewarm != nil {
+		p := *c.Prewarm
+		p.SeedURL = redactURL(p.SeedURL)
+		p.WebhookSecret = redact(p.WebhookSecret)
+		r.Prewarm = &p
+	}
+
+	return &r
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// Replace the password in rawURL, if there is one.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// Don't risk leaking a password in a URL we can't parse.
+		return redact(rawURL)
+	}
+
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), redactedValue)
+	}
+
+	return u.String()
+}
+
+// YAML returns c in the format of a YAML config file.
+func (c *Config) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err := enc.Encode(c)
+	if err != nil {
+		return nil, err
+	}
+	err = enc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/utils/enroll"
+)
+
+// How long enrolled client certificates are valid by default, and at most.
+const (
+	defaultEnrollmentCertLifetime = 24 * time.Hour
+	maxEnrollmentCertLifetime     = 7 * 24 * time.Hour
+)
+
+func setEnrollmentDefaults(e *EnrollmentConfig) {
+	if e.CertLifetime == 0 {
+		e.CertLifetime = defaultEnrollmentCertLifetime
+	}
+}
+
+func validateEnrollment(e *EnrollmentConfig, tlsCertFile string) error {
+	if e == nil {
+		return nil
+	}
+
+	if e.CACertFile == "" || e.CAKeyFile == "" || e.TokensFile == "" {
+		return errors.New("'enrollment' requires 'enrollment.ca_cert_file', 'enrollment.ca_key_file' and 'enrollment.tokens_file'")
+	}
+	if e.CertLifetime <= 0 || e.CertLifetime > maxEnrollmentCertLifetime {
+		return fmt.Errorf("'enrollment.cert_lifetime' must be positive and at most %s", maxEnrollmentCertLifetime)
+	}
+
+	// Otherwise the enrollment tokens would be sent in plain text.
+	if tlsCertFile == "" {
+		return errors.New("'enrollment' requires 'tls_cert_file' and 'tls_key_file'")
+	}
+
+	return nil
+}
+
+func (c *Config) setCertIssuer() error {
+	if c.Enrollment == nil {
+		return nil
+	}
+
+	e := c.Enrollment
+	issuer, err := enroll.LoadIssuer(e.CACertFile, e.CAKeyFile, e.TokensFile, e.CertLifetime)
+	if err != nil {
+		return err
+	}
+
+	c.CertIssuer = issuer
+	return nil
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse "kind=count" flag values.
+func parseMaxEntries(values []string) (map[string]int, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	limits := make(map[string]int, len(values))
+	for _, v := range values {
+		kind, countStr, found := strings.Cut(v, "=")
+		count, err := strconv.Atoi(countStr)
+		if !found || err != nil {
+			return nil, fmt.Errorf("Invalid --max_entries_per_kind value %q, expected kind=count", v)
+		}
+		limits[kind] = count
+	}
+
+	return limits, nil
+}
+
+func validateMaxEntries(c *Config) error {
+	for kind, count := range c.MaxEntriesPerKind {
+		if !isEntryKind(kind) {
+			return fmt.Errorf("Invalid kind in 'max_entries_per_kind': %q, expected one of %s",
+				kind, strings.Join(entryKinds, ", "))
+		}
+
+		if count <= 0 {
+			return fmt.Errorf("The 'max_entries_per_kind' limit for %s must be greater than zero, found %d", kind, count)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache/eventstream"
+)
+
+// Defaults for the event stream settings which are not set.
+const (
+	defaultEventStreamTopic         = "bazel-remote-events"
+	defaultEventStreamBatchSize     = 100
+	defaultEventStreamFlushInterval = time.Second
+	defaultEventStreamQueueSize     = 10000
+)
+
+func setEventStreamDefaults(es *EventStreamConfig) {
+	if es.Topic == "" {
+		es.Topic = defaultEventStreamTopic
+	}
+	if es.BatchSize == 0 {
+		es.BatchSize = defaultEventStreamBatchSize
+	}
+	if es.FlushInterval == 0 {
+		es.FlushInterval = defaultEventStreamFlushInterval
+	}
+	if es.QueueSize == 0 {
+		es.QueueSize = defaultEventStreamQueueSize
+	}
+}
+
+func validateEventStream(es *EventStreamConfig) error {
+	if es == nil {
+		return nil
+	}
+
+	sinks := 0
+	for _, s := range []string{es.NATSURL, es.KafkaRESTURL, es.File} {
+		if s != "" {
+			sinks++
+		}
+	}
+	if sinks != 1 {
+		return errors.New("Exactly one of 'event_stream.nats_url', 'event_stream.kafka_rest_url' and 'event_stream.file' must be set")
+	}
+
+	for _, op := range es.Ops {
+		if !eventstream.IsValidOp(op) {
+			return fmt.Errorf("Invalid operation in 'event_stream.ops': %q, expected one of %s",
+				op, strings.Join(eventstream.GetOps(), ", "))
+		}
+	}
+
+	if es.BatchSize <= 0 {
+		return errors.New("'event_stream.batch_size' must be greater than zero")
+	}
+
+	if es.FlushInterval <= 0 {
+		return errors.New("'event_stream.flush_interval' must be greater than zero")
+	}
+
+	if es.QueueSize <= 0 {
+		return errors.New("'event_stream.queue_size' must be greater than zero")
+	}
+
+	return nil
+}
+
+func (c *Config) setEventExporter() error {
+	if c.EventStream == nil {
+		return nil
+	}
+
+	opts := eventstream.Options{
+		Ops:           c.EventStream.Ops,
+		BatchSize:     c.EventStream.BatchSize,
+		FlushInterval: c.EventStream.FlushInterval,
+		QueueSize:     c.EventStream.QueueSize,
+		Anonymize:     c.EventStream.Anonymize,
+	}
+
+	var e *eventstream.Exporter
+	var err error
+	if c.EventStream.NATSURL != "" {
+		e, err = eventstream.NewNATS(c.EventStream.NATSURL, c.EventStream.Topic, opts, c.ErrorLogger)
+	} else if c.EventStream.File != "" {
+		e, err = eventstream.NewFile(c.EventStream.File, opts, c.ErrorLogger)
+	} else {
+		e, err = eventstream.NewKafkaREST(c.EventStream.KafkaRESTURL, c.EventStream.Topic,
+			&http.Client{}, opts, c.ErrorLogger)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.EventExporter = e
+	return nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+)
+
+func validateRemoteExecution(re *RemoteExecutionConfig) error {
+	if re == nil {
+		return nil
+	}
+
+	if re.URL == "" {
+		return errors.New("'experimental_remote_execution.url' must be set")
+	}
+
+	u, err := url.Parse(re.URL)
+	if err != nil || (u.Scheme != "grpc" && u.Scheme != "grpcs") || u.Host == "" ||
+		(u.Path != "" && u.Path != "/") || u.User != nil || u.RawQuery != "" {
+		return fmt.Errorf("Invalid 'experimental_remote_execution.url': %q, expected grpc://host:port or grpcs://host:port", re.URL)
+	}
+
+	if re.TLSCaFile != "" && u.Scheme != "grpcs" {
+		return errors.New("'experimental_remote_execution.tls_ca_file' can only be used with a grpcs:// URL")
+	}
+
+	return nil
+}
+
+func (c *Config) setExecutionBackend() error {
+	if c.RemoteExecution == nil {
+		return nil
+	}
+
+	u, err := url.Parse(c.RemoteExecution.URL)
+	if err != nil {
+		return err
+	}
+
+	creds := insecure.NewCredentials()
+	if u.Scheme == "grpcs" {
+		tlsConfig := &tls.Config{}
+		if c.RemoteExecution.TLSCaFile != "" {
+			caCert, err := os.ReadFile(c.RemoteExecution.TLSCaFile)
+			if err != nil {
+				return fmt.Errorf("Error reading the remote execution TLS CA file: %w", err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+				return fmt.Errorf("Failed to add the remote execution TLS CA certificate to the cert pool")
+			}
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	// The connection is made in the background, and requests fail with
+	// UNAVAILABLE until it is established.
+	conn, err := grpc.Dial(u.Host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("Failed to connect to the remote execution backend %q: %w", u.Host, err)
+	}
+
+	c.ExecutionBackend = pb.NewExecutionClient(conn)
+	return nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/buchgr/bazel-remote/v2/utils/faults"
+)
+
+// Returns the parsed fault injection rules.
+func parseFaultRules(rules []string) ([]faults.Rule, error) {
+	parsed := make([]faults.Rule, 0, len(rules))
+	for _, s := range rules {
+		r, err := faults.ParseRule(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, r)
+	}
+	return parsed, nil
+}
+
+func validateFaultInjection(f *FaultInjectionConfig) error {
+	if f == nil {
+		return nil
+	}
+
+	// Fault injection must never be enabled by accident, eg by a config
+	// file copied from a staging environment, so it needs both the rules
+	// and the flag which enables them.
+	if !f.Enabled {
+		return errors.New("'fault_injection.rules' requires 'fault_injection.enabled'")
+	}
+	if len(f.Rules) == 0 {
+		return errors.New("'fault_injection.enabled' requires at least one rule in 'fault_injection.rules'")
+	}
+
+	_, err := parseFaultRules(f.Rules)
+	return err
+}
+
+func (c *Config) setFaultInjector() error {
+	if c.FaultInjection == nil {
+		return nil
+	}
+
+	rules, err := parseFaultRules(c.FaultInjection.Rules)
+	if err != nil {
+		return err
+	}
+
+	c.FaultInjector = faults.New(rules)
+	return nil
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Each setting in Config can be specified by a YAML key, a command line
+// flag and an environment variable, which are all named consistently:
+// eg the bucket key in the s3_proxy section of the config file can also
+// be set with --s3_proxy.bucket or BAZEL_REMOTE_S3_PROXY_BUCKET.
+//
+// Settings are taken from the first of these sources which sets them:
+//  1. command line flags
+//  2. environment variables
+//  3. the config file, if --config_file is specified
+//  4. the default values
+
+// A setting is a single value in Config, identified by its key: the YAML
+// key, prefixed by the YAML key of the section it is in, if any, eg
+// "s3_proxy.bucket". This is also the name of its flag.
+type setting struct {
+	key   string
+	index []int // The index sequence for reflect.Value.FieldByIndex.
+}
+
+// Returns the settings in Config, in the order of its fields.
+func settings() []setting {
+	return appendSettings(nil, reflect.TypeOf(Config{}), "", nil)
+}
+
+func appendSettings(s []setting, t reflect.Type, prefix string, index []int) []setting {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if f.Type.Kind() == reflect.Pointer && f.Type.Elem().Kind() == reflect.Struct {
+			s = appendSettings(s, f.Type.Elem(), prefix+name+".", fieldIndex)
+			continue
+		}
+
+		s = append(s, setting{key: prefix + name, index: fieldIndex})
+	}
+
+	return s
+}
+
+// Returns the environment variable for the setting with the given key,
+// eg BAZEL_REMOTE_S3_PROXY_BUCKET for "s3_proxy.bucket".
+func envVarName(key string) string {
+	return "BAZEL_REMOTE_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Parsers for the flags of map settings, by key.
+var mapFlagParsers = map[string]func([]string) (map[string]int, error){
+	"max_concurrent_requests_per_endpoint": parseEndpointLimits,
+	"max_size_per_instance":                parseInstanceSizes,
+	"max_entries_per_kind":                 parseMaxEntries,
+}
+
+// Parsers for the flags of string map settings, by key.
+var stringMapFlagParsers = map[string]func([]string) (map[string]string, error){
+	"instance_proxies":         parseInstanceProxies,
+	"fsync_policy":             parseFsyncPolicies,
+	"s3_proxy.object_tags":     parseS3ObjectTags,
+	"s3_proxy.storage_classes": parseS3StorageClasses,
+}
+
+// Override the settings in c with the flags and environment variables in
+// ctx which are set. Sections of c are created as required.
+func applyFlags(ctx *cli.Context, c *Config) error {
+	cv := reflect.ValueOf(c).Elem()
+
+	for _, s := range settings() {
+		if !ctx.IsSet(s.key) {
+			continue
+		}
+
+		v := cv
+		for i, idx := range s.index {
+			v = v.Field(idx)
+			if i < len(s.index)-1 {
+				if v.IsNil() {
+					v.Set(reflect.New(v.Type().Elem()))
+				}
+				v = v.Elem()
+			}
+		}
+
+		if s.key == "max_size" {
+			// Either a number of GiB or "auto:<percent>%".
+			var err error
+			c.MaxSize, c.MaxSizePercent, err = parseMaxSize(ctx.String(s.key))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch p := v.Addr().Interface().(type) {
+		case *string:
+			*p = ctx.String(s.key)
+		case *bool:
+			*p = ctx.Bool(s.key)
+		case *int:
+			*p = ctx.Int(s.key)
+		case **int:
+			n := ctx.Int(s.key)
+			*p = &n
+		case *int64:
+			*p = ctx.Int64(s.key)
+		case *time.Duration:
+			*p = ctx.Duration(s.key)
+		case *[]string:
+			*p = ctx.StringSlice(s.key)
+		case *[]float64:
+			*p = ctx.Float64Slice(s.key)
+		case *map[string]int:
+			parse, found := mapFlagParsers[s.key]
+			if !found {
+				return fmt.Errorf("No parser for the %s flag", s.key)
+			}
+			m, err := parse(ctx.StringSlice(s.key))
+			if err != nil {
+				return err
+			}
+			*p = m
+		case *[]ListenerConfig:
+			listeners, err := parseListeners(ctx.StringSlice(s.key))
+			if err != nil {
+				return err
+			}
+			*p = listeners
+		case *map[string]string:
+			parse, found := stringMapFlagParsers[s.key]
+			if !found {
+				return fmt.Errorf("No parser for the %s flag", s.key)
+			}
+			m, err := parse(ctx.StringSlice(s.key))
+			if err != nil {
+				return err
+			}
+			*p = m
+		default:
+			return fmt.Errorf("Unsupported type %s for the %s flag", v.Type(), s.key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/buchgr/bazel-remote/v2/utils/flags"
+)
+
+func TestFlagForEverySetting(t *testing.T) {
+	cliFlags := make(map[string]cli.DocGenerationFlag)
+	for _, f := range flags.GetCliFlags() {
+		cliFlags[f.Names()[0]] = f.(cli.DocGenerationFlag)
+	}
+
+	for _, s := range settings() {
+		f, found := cliFlags[s.key]
+		if !found {
+			t.Errorf("Missing flag --%s", s.key)
+			continue
+		}
+
+		envVars := f.GetEnvVars()
+		if len(envVars) == 0 || envVars[0] != envVarName(s.key) {
+			t.Errorf("Expected the --%s flag to use the %s environment variable first, got %v",
+				s.key, envVarName(s.key), envVars)
+		}
+	}
+}
+
+func TestFlagsOverrideYaml(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(configFile, []byte(`dir: /opt/cache-dir
+max_size: 10
+http_address: localhost:8080
+s3_proxy:
+  endpoint: minio.example.com:9000
+  bucket: from-yaml
+  auth_method: access_key
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("BAZEL_REMOTE_MAX_SIZE", "20")
+	t.Setenv("BAZEL_REMOTE_HTTP_ADDRESS", "localhost:8081")
+	t.Setenv("BAZEL_REMOTE_REPLICATION_PEERS", "http://peer-a:8080,http://peer-b:8080")
+	t.Setenv("BAZEL_REMOTE_PEER_SECRET", "s3cret")
+
+	var config *Config
+	app := cli.NewApp()
+	app.Flags = flags.GetCliFlags()
+	app.Action = func(ctx *cli.Context) error {
+		config, err = get(ctx)
+		return err
+	}
+
+	err = app.Run([]string{"bazel-remote",
+		"--config_file", configFile,
+		"--max_size", "30",
+		"--s3.bucket", "from-flag",
+		"--endpoint_metrics_duration_buckets", "2",
+		"--endpoint_metrics_duration_buckets", "1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Dir != "/opt/cache-dir" {
+		t.Errorf("Expected dir from the config file, got %q", config.Dir)
+	}
+	if config.HTTPAddress != "localhost:8081" {
+		t.Errorf("Expected the environment variable to override http_address, got %q", config.HTTPAddress)
+	}
+	if config.MaxSize != 30 {
+		t.Errorf("Expected the flag to override max_size and the environment variable, got %d", config.MaxSize)
+	}
+	if config.S3CloudStorage.Bucket != "from-flag" || config.S3CloudStorage.Endpoint != "minio.example.com:9000" {
+		t.Errorf("Expected the flag alias to override s3_proxy.bucket only, got %+v", config.S3CloudStorage)
+	}
+	if !reflect.DeepEqual(config.MetricsDurationBuckets, []float64{1, 2}) {
+		t.Errorf("Expected sorted duration buckets from the flags, got %v", config.MetricsDurationBuckets)
+	}
+
+	expected := &ReplicationConfig{
+		Peers:          []string{"http://peer-a:8080", "http://peer-b:8080"},
+		ConflictPolicy: "last_write_wins",
+	}
+	if !reflect.DeepEqual(config.Replication, expected) {
+		t.Errorf("Expected a replication section from the environment, %+v, got %+v", expected, config.Replication)
+	}
+}
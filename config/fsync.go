@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+)
+
+// The kinds of cache entries, as used in the config.
+var entryKinds = []string{"ac", "cas", "raw"}
+
+// Parse "kind=policy" flag values.
+func parseFsyncPolicies(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	policies := make(map[string]string, len(values))
+	for _, v := range values {
+		kind, policy, found := strings.Cut(v, "=")
+		if !found {
+			return nil, fmt.Errorf("Invalid --fsync_policy value %q, expected kind=policy", v)
+		}
+		policies[kind] = policy
+	}
+
+	return policies, nil
+}
+
+func validateFsyncPolicies(c *Config) error {
+	for kind, policy := range c.FsyncPolicy {
+		if !isEntryKind(kind) {
+			return fmt.Errorf("Invalid kind in 'fsync_policy': %q, expected one of %s",
+				kind, strings.Join(entryKinds, ", "))
+		}
+
+		if !disk.IsValidFsyncPolicy(policy) {
+			return fmt.Errorf("Invalid 'fsync_policy' for %s: %q, expected one of %s",
+				kind, policy, strings.Join(disk.GetFsyncPolicies(), ", "))
+		}
+	}
+
+	return nil
+}
+
+func isEntryKind(kind string) bool {
+	for _, k := range entryKinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
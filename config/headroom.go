@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parses a percentage between 0 and 100, exclusive, eg "5%" or "2.5%".
+func parsePercent(s string) (float64, error) {
+	if !strings.HasSuffix(s, "%") {
+		return 0, fmt.Errorf("Invalid percentage %q, expected eg 5%%", s)
+	}
+
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || !(percent > 0 && percent < 100) {
+		return 0, fmt.Errorf("Invalid percentage %q, expected a value between 0%% and 100%%", s)
+	}
+
+	return percent, nil
+}
+
+// The multipliers of the suffixes of sizes.
+var sizeSuffixes = map[string]int64{
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// Parses a disk_headroom value: a percentage of the filesystem's size,
+// eg "5%", or a number of bytes, with an optional K, M, G or T suffix
+// for KiB, MiB, GiB or TiB, eg "10G". Exactly one of the results is
+// non-zero if there is no error.
+func parseDiskHeadroom(s string) (bytes int64, percent float64, err error) {
+	if strings.HasSuffix(s, "%") {
+		percent, err = parsePercent(s)
+		return 0, percent, err
+	}
+
+	value, multiplier := s, int64(1)
+	if len(s) > 0 {
+		if m, ok := sizeSuffixes[s[len(s)-1:]]; ok {
+			value, multiplier = s[:len(s)-1], m
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 || n > (1<<62)/multiplier {
+		return 0, 0, fmt.Errorf("Invalid 'disk_headroom' %q, expected a percentage of the filesystem's size, eg 5%%, or a number of bytes, eg 10G", s)
+	}
+
+	return n * multiplier, 0, nil
+}
+
+func validateDiskHeadroom(s string) error {
+	if s == "" {
+		return nil
+	}
+
+	_, _, err := parseDiskHeadroom(s)
+	return err
+}
+
+func (c *Config) setDiskHeadroom() error {
+	if c.DiskHeadroom == "" {
+		return nil
+	}
+
+	var err error
+	c.HeadroomBytes, c.HeadroomPercent, err = parseDiskHeadroom(c.DiskHeadroom)
+	return err
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/buchgr/bazel-remote/v2/utils/limiter"
+	"github.com/buchgr/bazel-remote/v2/utils/throttle"
+)
+
+func (c *Config) setLimiter() {
+	if c.MaxConcurrentRequests == 0 && len(c.MaxConcurrentPerEndpoint) == 0 {
+		return
+	}
+
+	c.Limiter = limiter.New(c.MaxConcurrentRequests, c.MaxConcurrentPerEndpoint)
+}
+
+func (c *Config) setThrottler() {
+	if c.MaxDownloadRatePerConn == 0 && c.MaxDownloadRatePerIdentity == 0 {
+		return
+	}
+
+	c.Throttler = throttle.New(c.MaxDownloadRatePerConn, c.MaxDownloadRatePerIdentity)
+}
+
+// Endpoints are either HTTP methods which the cache handler supports, or
+// gRPC methods in the form "Service/Method".
+func isValidEndpoint(endpoint string) bool {
+	switch endpoint {
+	case http.MethodGet, http.MethodHead, http.MethodPut:
+		return true
+	}
+
+	service, method, found := strings.Cut(endpoint, "/")
+	return found && service != "" && method != "" && !strings.Contains(method, "/")
+}
+
+// Parse "endpoint=limit" flag values.
+func parseEndpointLimits(values []string) (map[string]int, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	limits := make(map[string]int, len(values))
+	for _, v := range values {
+		endpoint, limitStr, found := strings.Cut(v, "=")
+		limit, err := strconv.Atoi(limitStr)
+		if !found || err != nil {
+			return nil, fmt.Errorf("Invalid --max_concurrent_requests_per_endpoint value %q, expected endpoint=limit", v)
+		}
+		limits[endpoint] = limit
+	}
+
+	return limits, nil
+}
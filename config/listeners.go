@@ -0,0 +1,145 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// The protocols of additional listeners.
+const (
+	ListenerHTTP = "http"
+	ListenerGRPC = "grpc"
+)
+
+// ListenerConfig is an additional HTTP or gRPC listener, which serves the
+// same cache as http_address and grpc_address, but with its own TLS and
+// authentication settings. The top-level TLS and authentication settings
+// do not apply to it.
+type ListenerConfig struct {
+	Protocol                  string `yaml:"protocol"`
+	Address                   string `yaml:"address"`
+	TLSCaFile                 string `yaml:"tls_ca_file"`
+	TLSCertFile               string `yaml:"tls_cert_file"`
+	TLSKeyFile                string `yaml:"tls_key_file"`
+	HtpasswdFile              string `yaml:"htpasswd_file"`
+	AllowUnauthenticatedReads bool   `yaml:"allow_unauthenticated_reads"`
+
+	TLSConfig *tls.Config `yaml:"-"`
+}
+
+// ForListener returns a copy of c with the address, TLS and
+// authentication settings of the listener l, to set up a server on it
+// like for http_address or grpc_address.
+func (c *Config) ForListener(l *ListenerConfig) *Config {
+	lc := *c
+
+	if l.Protocol == ListenerGRPC {
+		lc.GRPCAddress = l.Address
+	} else {
+		lc.HTTPAddress = l.Address
+	}
+
+	lc.TLSCaFile = l.TLSCaFile
+	lc.TLSCertFile = l.TLSCertFile
+	lc.TLSKeyFile = l.TLSKeyFile
+	lc.HtpasswdFile = l.HtpasswdFile
+	lc.AllowUnauthenticatedReads = l.AllowUnauthenticatedReads
+	lc.TLSConfig = l.TLSConfig
+
+	return &lc
+}
+
+// Parse listeners flag values, formatted as protocol://address, optionally
+// followed by "?" and the other settings of the listener as URL query
+// parameters, eg
+// "grpc://0.0.0.0:9093?tls_cert_file=server.pem&tls_key_file=server.key".
+func parseListeners(values []string) ([]ListenerConfig, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	listeners := make([]ListenerConfig, 0, len(values))
+	for _, value := range values {
+		protocol, rest, found := strings.Cut(value, "://")
+		if !found {
+			return nil, fmt.Errorf("Invalid listener %q, expected protocol://address, eg grpc://0.0.0.0:9093", value)
+		}
+		address, query, _ := strings.Cut(rest, "?")
+
+		params, err := url.ParseQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid listener %q: %v", value, err)
+		}
+
+		l := ListenerConfig{Protocol: protocol, Address: address}
+		for key, v := range params {
+			if len(v) != 1 {
+				return nil, fmt.Errorf("Invalid listener %q: %s is set more than once", value, key)
+			}
+
+			switch key {
+			case "tls_ca_file":
+				l.TLSCaFile = v[0]
+			case "tls_cert_file":
+				l.TLSCertFile = v[0]
+			case "tls_key_file":
+				l.TLSKeyFile = v[0]
+			case "htpasswd_file":
+				l.HtpasswdFile = v[0]
+			case "allow_unauthenticated_reads":
+				l.AllowUnauthenticatedReads, err = strconv.ParseBool(v[0])
+				if err != nil {
+					return nil, fmt.Errorf("Invalid listener %q: allow_unauthenticated_reads must be true or false", value)
+				}
+			default:
+				return nil, fmt.Errorf("Invalid listener %q: unknown setting %s", value, key)
+			}
+		}
+
+		listeners = append(listeners, l)
+	}
+
+	return listeners, nil
+}
+
+func validateListeners(c *Config) error {
+	addresses := map[string]bool{c.HTTPAddress: true}
+	if c.GRPCAddress != "" && c.GRPCAddress != disabledGRPCListener {
+		addresses[c.GRPCAddress] = true
+	}
+
+	for _, l := range c.Listeners {
+		if l.Protocol != ListenerHTTP && l.Protocol != ListenerGRPC {
+			return fmt.Errorf("Invalid protocol %q for the listener on %q, expected %q or %q",
+				l.Protocol, l.Address, ListenerHTTP, ListenerGRPC)
+		}
+
+		if strings.HasPrefix(l.Address, "unix://") {
+			if l.Address[len("unix://"):] == "" {
+				return errors.New("A 'listeners' Unix socket specification is missing a socket path")
+			}
+		} else {
+			_, _, err := net.SplitHostPort(l.Address)
+			if err != nil {
+				return fmt.Errorf("Invalid address %q in 'listeners', it must either be formatted as [host]:port or unix://socket.path", l.Address)
+			}
+		}
+
+		if addresses[l.Address] {
+			return fmt.Errorf("The address %q is used by more than one listener", l.Address)
+		}
+		addresses[l.Address] = true
+
+		err := validateAuth(l.TLSCaFile, l.TLSCertFile, l.TLSKeyFile, l.HtpasswdFile, l.AllowUnauthenticatedReads)
+		if err != nil {
+			return fmt.Errorf("Invalid listener on %q: %w", l.Address, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/utils/maintenance"
+)
+
+// The duration of manually opened maintenance windows, if there is no
+// maintenance configuration.
+const defaultMaintenanceDuration = time.Hour
+
+func (c *Config) setMaintenanceWindow() error {
+	if c.Maintenance == nil {
+		c.MaintenanceWindow = maintenance.NewWindow(nil, defaultMaintenanceDuration)
+		return nil
+	}
+
+	var schedule *maintenance.Schedule
+	if c.Maintenance.Schedule != "" {
+		var err error
+		schedule, err = maintenance.ParseSchedule(c.Maintenance.Schedule)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.MaintenanceWindow = maintenance.NewWindow(schedule, c.Maintenance.Duration)
+	return nil
+}
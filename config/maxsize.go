@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// The prefix of max_size values which derive the maximum size of the
+// cache from the size of the cache directory's filesystem, eg "auto:90%".
+const autoMaxSizePrefix = "auto:"
+
+// Parses a max_size value: a number of GiB, or "auto:" followed by a
+// percentage of the size of the cache directory's filesystem, eg
+// "auto:90%". Both results are zero if s is empty, otherwise exactly one
+// of them is non-zero if there is no error.
+func parseMaxSize(s string) (gib int, percent float64, err error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+
+	if strings.HasPrefix(s, autoMaxSizePrefix) {
+		percent, err = parsePercent(strings.TrimPrefix(s, autoMaxSizePrefix))
+		if err != nil {
+			return 0, 0, fmt.Errorf("Invalid 'max_size' %q: %v", s, err)
+		}
+		return 0, percent, nil
+	}
+
+	gib, err = strconv.Atoi(s)
+	if err != nil || gib <= 0 {
+		return 0, 0, fmt.Errorf("Invalid 'max_size' %q, expected a number of GiB, eg 500, or a percentage of the filesystem's size, eg auto:90%%", s)
+	}
+
+	return gib, 0, nil
+}
+
+// If the max_size key in the mapping node n has an "auto:" value, remove
+// it and return the percentage, so that the rest of n can be decoded
+// into a Config.
+func takeAutoMaxSize(n *yaml.Node) (float64, error) {
+	for i := 0; i < len(n.Content); i += 2 {
+		value := n.Content[i+1]
+		if n.Content[i].Value != "max_size" || value.Kind != yaml.ScalarNode ||
+			!strings.HasPrefix(value.Value, autoMaxSizePrefix) {
+			continue
+		}
+
+		_, percent, err := parseMaxSize(value.Value)
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %v", value.Line, err)
+		}
+
+		n.Content = append(n.Content[:i], n.Content[i+2:]...)
+		return percent, nil
+	}
+
+	return 0, nil
+}
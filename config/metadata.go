@@ -0,0 +1,30 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+// How long metadata values are kept by default, and at most.
+const defaultMetadataMaxTTL = 7 * 24 * time.Hour
+
+func setMetadataDefaults(m *MetadataConfig) {
+	if m.MaxTTL == 0 {
+		m.MaxTTL = defaultMetadataMaxTTL
+	}
+}
+
+func validateMetadata(m *MetadataConfig) error {
+	if m == nil {
+		return nil
+	}
+
+	if !m.Enabled {
+		return errors.New("'metadata.max_ttl' requires 'metadata.enabled'")
+	}
+	if m.MaxTTL < 0 {
+		return errors.New("'metadata.max_ttl' must not be negative")
+	}
+
+	return nil
+}
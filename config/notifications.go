@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/buchgr/bazel-remote/v2/cache/notify"
+)
+
+// The default number of notifications which can be queued before new
+// ones are dropped.
+const defaultNotificationBufferSize = 1000
+
+func validateNotifications(n *NotificationsConfig) error {
+	if n == nil {
+		return nil
+	}
+
+	if (n.WebhookURL == "") == (n.Command == "") {
+		return errors.New("Exactly one of 'notifications.webhook_url' and 'notifications.command' must be set")
+	}
+
+	for _, e := range n.Events {
+		if !notify.IsValidEventType(e) {
+			return fmt.Errorf("Invalid event in 'notifications.events': %q, expected one of %s",
+				e, strings.Join(notify.GetEventTypes(), ", "))
+		}
+	}
+
+	for _, kind := range n.Kinds {
+		if !isEntryKind(kind) {
+			return fmt.Errorf("Invalid kind in 'notifications.kinds': %q, expected one of %s",
+				kind, strings.Join(entryKinds, ", "))
+		}
+	}
+
+	if n.MinSize < 0 {
+		return errors.New("'notifications.min_size' must not be negative")
+	}
+
+	if n.MaxSize < 0 {
+		return errors.New("'notifications.max_size' must not be negative")
+	}
+
+	if n.MaxSize > 0 && n.MaxSize < n.MinSize {
+		return errors.New("'notifications.max_size' must not be less than 'notifications.min_size'")
+	}
+
+	if n.BufferSize <= 0 {
+		return errors.New("'notifications.buffer_size' must be greater than zero")
+	}
+
+	if n.RateLimit < 0 {
+		return errors.New("'notifications.rate_limit' must not be negative")
+	}
+
+	return nil
+}
+
+func (c *Config) setNotifier() error {
+	if c.Notifications == nil {
+		return nil
+	}
+
+	filter := notify.Filter{
+		Events:  c.Notifications.Events,
+		Kinds:   c.Notifications.Kinds,
+		MinSize: c.Notifications.MinSize,
+		MaxSize: c.Notifications.MaxSize,
+	}
+
+	if c.Notifications.DigestsFile != "" {
+		digests, err := notify.LoadDigests(c.Notifications.DigestsFile)
+		if err != nil {
+			return fmt.Errorf("Failed to load 'notifications.digests_file': %w", err)
+		}
+		filter.Digests = digests
+	}
+
+	var n *notify.Notifier
+	var err error
+	if c.Notifications.WebhookURL != "" {
+		n, err = notify.NewWebhook(c.Notifications.WebhookURL, &http.Client{}, filter,
+			c.Notifications.BufferSize, c.Notifications.RateLimit, c.ErrorLogger)
+	} else {
+		n, err = notify.NewCommand(c.Notifications.Command, filter,
+			c.Notifications.BufferSize, c.Notifications.RateLimit, c.ErrorLogger)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.Notifier = n
+	return nil
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// The ref which triggers a prewarm by default.
+const defaultPrewarmRef = "refs/heads/main"
+
+func setPrewarmDefaults(p *PrewarmConfig) {
+	if p.Ref == "" {
+		p.Ref = defaultPrewarmRef
+	}
+}
+
+func validatePrewarm(p *PrewarmConfig) error {
+	if p == nil {
+		return nil
+	}
+
+	if p.SeedURL == "" {
+		return errors.New("'prewarm.seed_url' must be set")
+	}
+
+	u, err := url.Parse(p.SeedURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("Invalid 'prewarm.seed_url': %q, expected an http:// or https:// URL", p.SeedURL)
+	}
+
+	if p.WebhookSecret == "" {
+		return errors.New("'prewarm.webhook_secret' must be set, to authenticate webhook requests")
+	}
+
+	return nil
+}
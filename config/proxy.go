@@ -1,79 +1,245 @@
 package config
 
 import (
+	"crypto/tls"
+	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 
+	"github.com/buchgr/bazel-remote/v2/cache"
 	"github.com/buchgr/bazel-remote/v2/cache/azblobproxy"
 	"github.com/buchgr/bazel-remote/v2/cache/gcsproxy"
 	"github.com/buchgr/bazel-remote/v2/cache/httpproxy"
+	"github.com/buchgr/bazel-remote/v2/cache/routingproxy"
 	"github.com/buchgr/bazel-remote/v2/cache/s3proxy"
 )
 
+// The URL schemes which can be used in instance_proxies.
+const (
+	instanceProxyS3     = "s3"
+	instanceProxyGCS    = "gs"
+	instanceProxyAzBlob = "azblob"
+	instanceProxyHTTP   = "http"
+	instanceProxyHTTPS  = "https"
+)
+
 func (c *Config) setProxy() error {
-	if c.GoogleCloudStorage != nil {
-		proxyCache, err := gcsproxy.New(c.GoogleCloudStorage.Bucket,
-			c.GoogleCloudStorage.UseDefaultCredentials, c.GoogleCloudStorage.JSONCredentialsFile,
-			c.StorageMode, c.AccessLogger, c.ErrorLogger, c.NumUploaders, c.MaxQueuedUploads)
-		if err != nil {
-			return err
+	proxy, err := c.newProxy(c.GoogleCloudStorage, c.HTTPBackend, c.S3CloudStorage, c.AzBlobConfig)
+	if err != nil {
+		return err
+	}
+
+	if len(c.InstanceProxies) > 0 {
+		backends := make(map[string]cache.Proxy, len(c.InstanceProxies))
+		for instance, rawURL := range c.InstanceProxies {
+			backends[instance], err = c.newInstanceProxy(rawURL)
+			if err != nil {
+				return fmt.Errorf("Failed to set up the proxy backend for instance %q: %w", instance, err)
+			}
 		}
 
-		c.ProxyBackend = proxyCache
-		return nil
+		proxy = routingproxy.New(backends, proxy)
 	}
 
-	if c.HTTPBackend != nil {
-		httpClient := &http.Client{}
+	c.ProxyBackend = proxy
+	return nil
+}
+
+// Returns the proxy backend for the first non-nil config, or nil if they
+// are all nil.
+func (c *Config) newProxy(gcs *GoogleCloudStorageConfig, hc *HTTPBackendConfig,
+	s3 *S3CloudStorageConfig, azblob *AzBlobStorageConfig) (cache.Proxy, error) {
+
+	if gcs != nil {
+		return gcsproxy.New(gcs.Bucket,
+			gcs.UseDefaultCredentials, gcs.JSONCredentialsFile, gcs.Checksums,
+			c.StorageMode, c.AccessLogger, c.ErrorLogger, c.NumUploaders, c.MaxQueuedUploads)
+	}
+
+	if hc != nil {
+		httpClient := &http.Client{Transport: hc.newTransport()}
 		var baseURL *url.URL
-		baseURL, err := url.Parse(c.HTTPBackend.BaseURL)
+		baseURL, err := url.Parse(hc.BaseURL)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		proxyCache, err := httpproxy.New(baseURL, c.StorageMode,
+		return httpproxy.New(baseURL, c.StorageMode,
 			httpClient, c.AccessLogger, c.ErrorLogger, c.NumUploaders, c.MaxQueuedUploads)
+	}
+
+	if s3 != nil {
+		creds, err := s3.GetCredentials()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		c.ProxyBackend = proxyCache
-		return nil
+		return s3proxy.New(
+			s3.Endpoint,
+			s3.Bucket,
+			s3.Prefix,
+			creds,
+			s3.DisableSSL,
+			s3.UpdateTimestamps,
+			s3.Region,
+			s3.ObjectTags,
+			s3.StorageClasses,
+			s3.Checksums,
+			c.StorageMode, c.AccessLogger, c.ErrorLogger, c.NumUploaders, c.MaxQueuedUploads), nil
 	}
 
-	if c.S3CloudStorage != nil {
-		creds, err := c.S3CloudStorage.GetCredentials()
+	if azblob != nil {
+		creds, err := azblob.GetCredentials()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		c.ProxyBackend = s3proxy.New(
-			c.S3CloudStorage.Endpoint,
-			c.S3CloudStorage.Bucket,
-			c.S3CloudStorage.Prefix,
+		return azblobproxy.New(
+			azblob.StorageAccount,
+			azblob.ContainerName,
+			azblob.Prefix,
 			creds,
-			c.S3CloudStorage.DisableSSL,
-			c.S3CloudStorage.UpdateTimestamps,
-			c.S3CloudStorage.Region,
-			c.StorageMode, c.AccessLogger, c.ErrorLogger, c.NumUploaders, c.MaxQueuedUploads)
-		return nil
+			azblob.SharedKey,
+			azblob.UpdateTimestamps,
+			c.StorageMode, c.AccessLogger, c.ErrorLogger, c.NumUploaders, c.MaxQueuedUploads,
+		), nil
 	}
 
-	if c.AzBlobConfig != nil {
-		creds, err := c.AzBlobConfig.GetCredentials()
+	return nil, nil
+}
+
+// Returns the transport for a HTTP proxy backend, which is a copy of
+// http.DefaultTransport with the connection settings which are set.
+func (hc *HTTPBackendConfig) newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	// Otherwise the transport requests gzip compressed responses, which
+	// backends with gzip enabled send without a Content-Length.
+	t.DisableCompression = true
+
+	if hc.MaxIdleConns > 0 {
+		t.MaxIdleConns = hc.MaxIdleConns
+	}
+	if hc.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = hc.MaxIdleConnsPerHost
+	}
+	if hc.MaxConnsPerHost > 0 {
+		t.MaxConnsPerHost = hc.MaxConnsPerHost
+	}
+	if hc.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = hc.IdleConnTimeout
+	}
+
+	if hc.DisableHTTP2 {
+		// A non-nil, empty TLSNextProto map disables HTTP/2.
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	if hc.TLSSessionCacheSize > 0 {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.ClientSessionCache = tls.NewLRUClientSessionCache(hc.TLSSessionCacheSize)
+	}
+
+	return t
+}
+
+// Returns the proxy backend for an instance_proxies URL. The settings
+// which are not part of the URL, like credentials, are taken from the
+// proxy backend section of the same type.
+func (c *Config) newInstanceProxy(rawURL string) (cache.Proxy, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := u.Host
+	prefix := strings.Trim(u.Path, "/")
+
+	switch u.Scheme {
+	case instanceProxyS3:
+		s3 := *c.S3CloudStorage
+		s3.Bucket = bucket
+		s3.Prefix = prefix
+		return c.newProxy(nil, nil, &s3, nil)
+
+	case instanceProxyGCS:
+		gcs := *c.GoogleCloudStorage
+		gcs.Bucket = bucket
+		return c.newProxy(&gcs, nil, nil, nil)
+
+	case instanceProxyAzBlob:
+		azblob := *c.AzBlobConfig
+		azblob.ContainerName = bucket
+		azblob.Prefix = prefix
+		return c.newProxy(nil, nil, nil, &azblob)
+
+	case instanceProxyHTTP, instanceProxyHTTPS:
+		var hc HTTPBackendConfig
+		if c.HTTPBackend != nil {
+			hc = *c.HTTPBackend
+		}
+		hc.BaseURL = rawURL
+		return c.newProxy(nil, &hc, nil, nil)
+	}
+
+	return nil, fmt.Errorf("Unsupported URL scheme %q", u.Scheme)
+}
+
+// Parse "instance=url" flag values. The instance name is everything
+// before the first "=", and may be empty for the default instance.
+func parseInstanceProxies(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	proxies := make(map[string]string, len(values))
+	for _, v := range values {
+		instance, rawURL, found := strings.Cut(v, "=")
+		if !found {
+			return nil, fmt.Errorf("Invalid --instance_proxies value %q, expected instance=url", v)
+		}
+		proxies[instance] = rawURL
+	}
+
+	return proxies, nil
+}
+
+func validateInstanceProxies(c *Config) error {
+	for instance, rawURL := range c.InstanceProxies {
+		u, err := url.Parse(rawURL)
 		if err != nil {
-			return err
+			return fmt.Errorf("Invalid 'instance_proxies' URL for instance %q: %w", instance, err)
 		}
 
-		c.ProxyBackend = azblobproxy.New(
-			c.AzBlobConfig.StorageAccount,
-			c.AzBlobConfig.ContainerName,
-			c.AzBlobConfig.Prefix,
-			creds,
-			c.AzBlobConfig.SharedKey,
-			c.AzBlobConfig.UpdateTimestamps,
-			c.StorageMode, c.AccessLogger, c.ErrorLogger, c.NumUploaders, c.MaxQueuedUploads,
-		)
-		return nil
+		if u.Host == "" {
+			return fmt.Errorf("Invalid 'instance_proxies' URL for instance %q: %q, the bucket, container or host is missing", instance, rawURL)
+		}
+
+		var section string
+		switch u.Scheme {
+		case instanceProxyS3:
+			if c.S3CloudStorage == nil {
+				section = "s3_proxy"
+			}
+		case instanceProxyGCS:
+			if c.GoogleCloudStorage == nil {
+				section = "gcs_proxy"
+			}
+		case instanceProxyAzBlob:
+			if c.AzBlobConfig == nil {
+				section = "azblob_proxy"
+			}
+		case instanceProxyHTTP, instanceProxyHTTPS:
+		default:
+			return fmt.Errorf("Invalid 'instance_proxies' URL for instance %q: %q, expected one of the schemes s3, gs, azblob, http or https", instance, rawURL)
+		}
+
+		if section != "" {
+			return fmt.Errorf("The 'instance_proxies' URL for instance %q requires a '%s' section for the other settings of the backend", instance, section)
+		}
 	}
 
 	return nil
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// InstanceQuotas returns the max_size_per_instance settings in bytes.
+func (c *Config) InstanceQuotas() map[string]int64 {
+	if len(c.MaxSizePerInstance) == 0 {
+		return nil
+	}
+
+	quotas := make(map[string]int64, len(c.MaxSizePerInstance))
+	for instance, size := range c.MaxSizePerInstance {
+		quotas[instance] = int64(size) * 1024 * 1024 * 1024
+	}
+	return quotas
+}
+
+// Parse "instance=size" flag values. The instance name is everything
+// before the last "=", and may be empty for the default instance.
+func parseInstanceSizes(values []string) (map[string]int, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	sizes := make(map[string]int, len(values))
+	for _, v := range values {
+		i := strings.LastIndex(v, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("Invalid --max_size_per_instance value %q, expected instance=size", v)
+		}
+		size, err := strconv.Atoi(v[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid --max_size_per_instance value %q, expected instance=size", v)
+		}
+		sizes[v[:i]] = size
+	}
+
+	return sizes, nil
+}
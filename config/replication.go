@@ -0,0 +1,36 @@
+package config
+
+import (
+	"net/http"
+
+	"github.com/buchgr/bazel-remote/v2/cache/replication"
+	"github.com/buchgr/bazel-remote/v2/utils/discovery"
+)
+
+func (c *Config) setReplicator() error {
+	if c.Replication == nil {
+		return nil
+	}
+
+	r, err := replication.New(c.Replication.Peers, c.Replication.IncludeCAS,
+		c.Replication.ConflictPolicy, c.PeerSecret, &http.Client{}, c.ErrorLogger,
+		c.NumUploaders, c.MaxQueuedUploads)
+	if err != nil {
+		return err
+	}
+
+	if c.Replication.Discover != "" {
+		resolver, err := discovery.NewResolver(c.Replication.DiscoverMode)
+		if err != nil {
+			return err
+		}
+
+		err = r.Discover(c.Replication.Discover, c.Replication.DiscoverInterval, resolver)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.Replicator = r
+	return nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/buchgr/bazel-remote/v2/cache/s3proxy"
 
@@ -24,6 +25,18 @@ type S3CloudStorageConfig struct {
 	KeyVersion               *int   `yaml:"key_version"`
 	AWSProfile               string `yaml:"aws_profile"`
 	AWSSharedCredentialsFile string `yaml:"aws_shared_credentials_file"`
+
+	// The names of the tags to add to uploaded objects, by attribute.
+	// See s3proxy.ObjectTagAttributes.
+	ObjectTags map[string]string `yaml:"object_tags"`
+
+	// The storage classes to upload objects with, by kind of entry.
+	// See s3proxy.GetStorageClasses.
+	StorageClasses map[string]string `yaml:"storage_classes"`
+
+	// Whether to upload objects with SHA256 checksums, and verify
+	// downloads against them.
+	Checksums bool `yaml:"checksums"`
 }
 
 func (s3c S3CloudStorageConfig) GetCredentials() (*credentials.Credentials, error) {
@@ -47,3 +60,117 @@ func (s3c S3CloudStorageConfig) GetCredentials() (*credentials.Credentials, erro
 
 	return nil, fmt.Errorf("invalid s3.auth_method: %s", s3c.AuthMethod)
 }
+
+// The maximum length of S3 object tag names.
+const maxS3TagNameLength = 128
+
+// Parse "attribute=tag_name" flag values.
+func parseS3ObjectTags(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	tags := make(map[string]string, len(values))
+	for _, v := range values {
+		attribute, name, found := strings.Cut(v, "=")
+		if !found {
+			return nil, fmt.Errorf("Invalid --s3_proxy.object_tags value %q, expected attribute=tag_name", v)
+		}
+		tags[attribute] = name
+	}
+
+	return tags, nil
+}
+
+// Parse "kind=class" flag values.
+func parseS3StorageClasses(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	classes := make(map[string]string, len(values))
+	for _, v := range values {
+		kind, class, found := strings.Cut(v, "=")
+		if !found {
+			return nil, fmt.Errorf("Invalid --s3_proxy.storage_classes value %q, expected kind=class", v)
+		}
+		classes[kind] = class
+	}
+
+	return classes, nil
+}
+
+func (s3c S3CloudStorageConfig) validateStorageClasses() error {
+	for kind, class := range s3c.StorageClasses {
+		if !isEntryKind(kind) {
+			return fmt.Errorf("Invalid kind in 's3_proxy.storage_classes': %q, expected one of %s",
+				kind, strings.Join(entryKinds, ", "))
+		}
+
+		if !s3proxy.IsValidStorageClass(class) {
+			return fmt.Errorf("Invalid 's3_proxy.storage_classes' for %s: %q, expected one of %s",
+				kind, class, strings.Join(s3proxy.GetStorageClasses(), ", "))
+		}
+	}
+
+	return nil
+}
+
+func (s3c S3CloudStorageConfig) validateObjectTags() error {
+	names := make(map[string]bool, len(s3c.ObjectTags))
+
+	for attribute, name := range s3c.ObjectTags {
+		if !isObjectTagAttribute(attribute) {
+			return fmt.Errorf("Invalid attribute in 's3_proxy.object_tags': %q, expected one of %s",
+				attribute, strings.Join(s3proxy.ObjectTagAttributes, ", "))
+		}
+		if name == "" || len(name) > maxS3TagNameLength {
+			return fmt.Errorf("Invalid tag name in 's3_proxy.object_tags' for %s: %q, must be 1 to %d characters",
+				attribute, name, maxS3TagNameLength)
+		}
+		if names[name] {
+			return fmt.Errorf("Duplicate tag name in 's3_proxy.object_tags': %q", name)
+		}
+		names[name] = true
+	}
+
+	return nil
+}
+
+// S3 Express One Zone directory buckets are only served over https from
+// regional zonal endpoints, and don't support object tags or storage
+// classes.
+func (s3c S3CloudStorageConfig) validateDirectoryBucket() error {
+	if !s3proxy.IsDirectoryBucket(s3c.Bucket) {
+		return nil
+	}
+
+	if s3c.Region == "" {
+		return fmt.Errorf("'s3_proxy.region' is required for the directory bucket %q", s3c.Bucket)
+	}
+	if s3c.DisableSSL {
+		return fmt.Errorf("'s3_proxy.disable_ssl' can't be used with the directory bucket %q", s3c.Bucket)
+	}
+	if len(s3c.ObjectTags) > 0 {
+		return fmt.Errorf("'s3_proxy.object_tags' can't be used with the directory bucket %q", s3c.Bucket)
+	}
+	if len(s3c.StorageClasses) > 0 {
+		return fmt.Errorf("'s3_proxy.storage_classes' can't be used with the directory bucket %q", s3c.Bucket)
+	}
+
+	if s3c.Endpoint == "" {
+		_, err := s3proxy.ExpressEndpoint(s3c.Bucket, s3c.Region)
+		return err
+	}
+
+	return nil
+}
+
+func isObjectTagAttribute(attribute string) bool {
+	for _, a := range s3proxy.ObjectTagAttributes {
+		if attribute == a {
+			return true
+		}
+	}
+	return false
+}
@@ -8,26 +8,46 @@ import (
 )
 
 func (c *Config) setTLSConfig() error {
-	if len(c.TLSCaFile) != 0 {
+	var err error
+	c.TLSConfig, err = newTLSConfig(c.TLSCaFile, c.TLSCertFile, c.TLSKeyFile)
+	if err != nil {
+		return err
+	}
+
+	for i := range c.Listeners {
+		l := &c.Listeners[i]
+		l.TLSConfig, err = newTLSConfig(l.TLSCaFile, l.TLSCertFile, l.TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("Listener on %q: %w", l.Address, err)
+		}
+	}
+
+	return nil
+}
+
+// Returns the TLS config for a listener with the given files, or nil if
+// TLS is not enabled.
+func newTLSConfig(caFile string, certFile string, keyFile string) (*tls.Config, error) {
+	if len(caFile) != 0 {
 		caCertPool := x509.NewCertPool()
-		caCert, err := os.ReadFile(c.TLSCaFile)
+		caCert, err := os.ReadFile(caFile)
 		if err != nil {
-			return fmt.Errorf("Error reading TLS CA File: %w", err)
+			return nil, fmt.Errorf("Error reading TLS CA File: %w", err)
 		}
 		added := caCertPool.AppendCertsFromPEM(caCert)
 		if !added {
-			return fmt.Errorf("Failed to add certificate to cert pool.")
+			return nil, fmt.Errorf("Failed to add certificate to cert pool.")
 		}
 
 		readCert, err := tls.LoadX509KeyPair(
-			c.TLSCertFile,
-			c.TLSKeyFile,
+			certFile,
+			keyFile,
 		)
 		if err != nil {
-			return fmt.Errorf("Error reading certificate/key pair: %w", err)
+			return nil, fmt.Errorf("Error reading certificate/key pair: %w", err)
 		}
 
-		c.TLSConfig = &tls.Config{
+		return &tls.Config{
 			Certificates: []tls.Certificate{readCert},
 			ClientCAs:    caCertPool,
 
@@ -37,26 +57,22 @@ func (c *Config) setTLSConfig() error {
 			// we require auth for.
 			// See server.checkGRPCClientCert and httpCache.hasValidClientCert.
 			ClientAuth: tls.VerifyClientCertIfGiven,
-		}
-
-		return nil
+		}, nil
 	}
 
-	if len(c.TLSCertFile) != 0 && len(c.TLSKeyFile) != 0 {
+	if len(certFile) != 0 && len(keyFile) != 0 {
 		readCert, err := tls.LoadX509KeyPair(
-			c.TLSCertFile,
-			c.TLSKeyFile,
+			certFile,
+			keyFile,
 		)
 		if err != nil {
-			return fmt.Errorf("Error reading certificate/key pair: %w", err)
+			return nil, fmt.Errorf("Error reading certificate/key pair: %w", err)
 		}
 
-		c.TLSConfig = &tls.Config{
+		return &tls.Config{
 			Certificates: []tls.Certificate{readCert},
-		}
-
-		return nil
+		}, nil
 	}
 
-	return nil
+	return nil, nil
 }
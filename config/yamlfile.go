@@ -0,0 +1,276 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// The key for including other YAML files, whose settings are overridden
+// by the including file.
+const includeKey = "include"
+
+// Matches "${NAME}" and "${NAME:-default}", and "$${" which is replaced
+// with a literal "${".
+var envVarRegex = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// yamlLoader reads YAML config files, expands environment variables in
+// their values and merges included files.
+type yamlLoader struct {
+	// If true, collect the keys which do not correspond to any config
+	// setting in unknownKeys.
+	strict      bool
+	unknownKeys []string
+
+	// The absolute paths of the files currently being loaded, to detect
+	// include cycles.
+	loading []string
+}
+
+func (l *yamlLoader) loadFile(path string) (*yaml.Node, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range l.loading {
+		if p == absPath {
+			cycle := append(append([]string{}, l.loading[i:]...), absPath)
+			return nil, fmt.Errorf("Config file include cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read config file '%s': %v", path, err)
+	}
+
+	l.loading = append(l.loading, absPath)
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+
+	return l.load(data, path)
+}
+
+// Parse data, which was read from the file named path or "" if it was not
+// read from a file, and return a mapping node with the resulting settings.
+func (l *yamlLoader) load(data []byte, path string) (*yaml.Node, error) {
+	// Prefix for error messages.
+	where := ""
+	if path != "" {
+		where = path + ": "
+	}
+
+	var doc yaml.Node
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse YAML config: %s%v", where, err)
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(doc.Content) == 0 {
+		return merged, nil // An empty file.
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("Failed to parse YAML config: %sexpected a mapping at the top level", where)
+	}
+
+	err = expandEnv(root)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse YAML config: %s%v", where, err)
+	}
+
+	includes, err := takeIncludes(root)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse YAML config: %s%v", where, err)
+	}
+
+	if l.strict {
+		l.checkKeys(root, reflect.TypeOf(YamlConfig{}), where, "")
+	}
+
+	for _, include := range includes {
+		if !filepath.IsAbs(include) && path != "" {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+
+		n, err := l.loadFile(include)
+		if err != nil {
+			return nil, err
+		}
+
+		mergeMappings(merged, n)
+	}
+
+	mergeMappings(merged, root)
+
+	return merged, nil
+}
+
+// Replace environment variable references in all the scalar values under
+// n, and return an error if any of them are not set and have no default.
+func expandEnv(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.MappingNode:
+		// Only expand the values, not the keys.
+		for i := 1; i < len(n.Content); i += 2 {
+			err := expandEnv(n.Content[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case yaml.SequenceNode:
+		for _, c := range n.Content {
+			err := expandEnv(c)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case yaml.ScalarNode:
+		var err error
+		expanded := envVarRegex.ReplaceAllStringFunc(n.Value, func(m string) string {
+			if m == "$${" {
+				return "${"
+			}
+
+			sm := envVarRegex.FindStringSubmatch(m)
+			value, found := os.LookupEnv(sm[1])
+			if found {
+				return value
+			}
+			if sm[2] != "" {
+				return sm[2][len(":-"):]
+			}
+
+			if err == nil {
+				err = fmt.Errorf("line %d: environment variable %s is not set", n.Line, sm[1])
+			}
+			return m
+		})
+		if err != nil {
+			return err
+		}
+
+		if expanded != n.Value {
+			n.Value = expanded
+
+			// Let the type of plain scalars be inferred from the expanded
+			// value, eg so that "${MAX_SIZE}" can be used as an int.
+			if n.Style&(yaml.TaggedStyle|yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) == 0 {
+				n.Tag = ""
+			}
+		}
+	}
+
+	return nil
+}
+
+// Remove the include key from the mapping node n, and return the listed
+// files. The value can be a single file or a list of files.
+func takeIncludes(n *yaml.Node) ([]string, error) {
+	for i := 0; i < len(n.Content); i += 2 {
+		if n.Content[i].Value != includeKey {
+			continue
+		}
+
+		value := n.Content[i+1]
+		n.Content = append(n.Content[:i], n.Content[i+2:]...)
+
+		var includes []string
+		switch value.Kind {
+		case yaml.ScalarNode:
+			includes = []string{value.Value}
+		case yaml.SequenceNode:
+			err := value.Decode(&includes)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: '%s' must be a file or a list of files", value.Line, includeKey)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: '%s' must be a file or a list of files", value.Line, includeKey)
+		}
+
+		return includes, nil
+	}
+
+	return nil, nil
+}
+
+// Merge the mapping node src into dst. Nested mappings are merged, and
+// all other values in src replace those in dst.
+func mergeMappings(dst *yaml.Node, src *yaml.Node) {
+	for i := 0; i < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+
+		j := 0
+		for ; j < len(dst.Content); j += 2 {
+			if dst.Content[j].Value == key.Value {
+				break
+			}
+		}
+
+		if j == len(dst.Content) {
+			dst.Content = append(dst.Content, key, value)
+		} else if value.Kind == yaml.MappingNode && dst.Content[j+1].Kind == yaml.MappingNode {
+			mergeMappings(dst.Content[j+1], value)
+		} else {
+			dst.Content[j+1] = value
+		}
+	}
+}
+
+// Add the keys in the mapping node n which do not correspond to a field
+// of the struct type t to l.unknownKeys, and check nested mappings
+// recursively.
+func (l *yamlLoader) checkKeys(n *yaml.Node, t reflect.Type, where string, section string) {
+	fields := make(map[string]reflect.Type)
+	yamlFields(t, fields)
+
+	for i := 0; i < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+
+		ft, found := fields[key.Value]
+		if !found {
+			msg := fmt.Sprintf("%sline %d: unknown key %q", where, key.Line, key.Value)
+			if section != "" {
+				msg += fmt.Sprintf(" in %q", section)
+			}
+			l.unknownKeys = append(l.unknownKeys, msg)
+			continue
+		}
+
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && value.Kind == yaml.MappingNode {
+			l.checkKeys(value, ft, where, key.Value)
+		}
+	}
+}
+
+// Add the YAML keys of the struct type t to fields, with their types.
+func yamlFields(t reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if opts == "inline" {
+			yamlFields(f.Type, fields)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fields[name] = f.Type
+	}
+}
@@ -11,17 +11,23 @@ import (
 	"os/signal"
 	"runtime"
 	"strings"
-	"syscall"
+	"sync"
+	"time"
 
 	auth "github.com/abbot/go-http-auth"
 
+	"github.com/buchgr/bazel-remote/v2/cache"
 	"github.com/buchgr/bazel-remote/v2/cache/disk"
 
 	"github.com/buchgr/bazel-remote/v2/config"
 	"github.com/buchgr/bazel-remote/v2/server"
+	"github.com/buchgr/bazel-remote/v2/subcommands"
+	"github.com/buchgr/bazel-remote/v2/utils/backendproxy"
 	"github.com/buchgr/bazel-remote/v2/utils/flags"
+	"github.com/buchgr/bazel-remote/v2/utils/handoff"
 	"github.com/buchgr/bazel-remote/v2/utils/idle"
 	"github.com/buchgr/bazel-remote/v2/utils/rlimit"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -41,24 +47,36 @@ import (
 // is set through linker options.
 var gitCommit string
 
+// Receives the signals which shut down the server, and the stop requests
+// from the windows service control manager.
+var sigChan = make(chan os.Signal, 1)
+
 func main() {
 	app := cli.NewApp()
 
 	cli.AppHelpTemplate = flags.Template
+	cli.CommandHelpTemplate = flags.CommandTemplate
 	cli.HelpPrinterCustom = flags.HelpPrinter
 	// Force the use of cli.HelpPrinterCustom.
 	app.ExtraInfo = func() map[string]string { return map[string]string{} }
 
 	app.Flags = flags.GetCliFlags()
+	app.Commands = subcommands.Commands()
+	// Subcommand help is available via "bazel-remote <command> --help".
+	app.HideHelpCommand = true
 	app.Action = run
 
-	err := app.Run(os.Args)
+	err := runApp(app)
 	if err != nil {
 		log.Fatal("bazel-remote terminated:", err)
 	}
 }
 
 func run(ctx *cli.Context) error {
+	if ctx.Bool("dump_config") {
+		return dumpConfig(ctx)
+	}
+
 	c, err := config.Get(ctx)
 	if err != nil {
 		fmt.Fprintf(ctx.App.Writer, "%v\n\n", err)
@@ -87,47 +105,85 @@ func run(ctx *cli.Context) error {
 
 	rlimit.Raise()
 
-	grpcSem := semaphore.NewWeighted(1)
-	var grpcServer *grpc.Server
+	hf, err := handoff.New()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	httpSem := semaphore.NewWeighted(1)
-	var httpServer *http.Server
+	// The configs of the HTTP and gRPC listeners: http_address and
+	// grpc_address with the top-level settings, followed by the
+	// listeners section.
+	httpConfigs := []*config.Config{c}
+	var grpcConfigs []*config.Config
+	if c.GRPCAddress != "none" {
+		grpcConfigs = append(grpcConfigs, c)
+	}
+	for i := range c.Listeners {
+		lc := c.ForListener(&c.Listeners[i])
+		if c.Listeners[i].Protocol == config.ListenerGRPC {
+			grpcConfigs = append(grpcConfigs, lc)
+		} else {
+			httpConfigs = append(httpConfigs, lc)
+		}
+	}
+
+	// A server is only started if its semaphore can be acquired, so that
+	// on shutdown, the servers which were started are stopped and the
+	// others are not started later.
+	grpcSems := newSemaphores(len(grpcConfigs))
+	grpcServers := make([]*grpc.Server, len(grpcConfigs))
+
+	httpSems := newSemaphores(len(httpConfigs))
+	httpServers := make([]*http.Server, len(httpConfigs))
 
 	idleTimeoutChan := make(chan struct{}, 1)
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		select {
-		case sig := <-sigChan:
-			log.Printf("Received signal: %s, attempting graceful shutdown", sig)
-		case <-idleTimeoutChan:
-			log.Println("Idle timeout reached, attempting graceful shutdown")
-		}
+	// Closed once the servers have been stopped. idleShutdown is set
+	// before that.
+	stopped := make(chan struct{})
+	var idleShutdown bool
 
-		go func() {
-			if !grpcSem.TryAcquire(1) {
-				if grpcServer != nil {
-					log.Println("Stopping gRPC server")
-					grpcServer.GracefulStop()
-					log.Println("gRPC server stopped")
+	signal.Notify(sigChan, handledSignals...)
+	go func() {
+		idleShutdown = waitForShutdown(sigChan, idleTimeoutChan, hf)
+
+		var wg sync.WaitGroup
+		wg.Add(len(grpcConfigs) + len(httpConfigs))
+
+		for i := range grpcConfigs {
+			i := i
+			go func() {
+				defer wg.Done()
+				if !grpcSems[i].TryAcquire(1) {
+					if grpcServers[i] != nil {
+						log.Println("Stopping gRPC server on address", grpcConfigs[i].GRPCAddress)
+						grpcServers[i].GracefulStop()
+						log.Println("gRPC server stopped on address", grpcConfigs[i].GRPCAddress)
+					}
 				}
-			}
-		}()
+			}()
+		}
 
-		go func() {
-			if !httpSem.TryAcquire(1) {
-				if httpServer != nil {
-					log.Println("Stopping HTTP server")
-					err := httpServer.Shutdown(context.Background())
-					if err != nil {
-						log.Println("Error occurred while stopping HTTP server:", err)
-					} else {
-						log.Println("HTTP server stopped")
+		for i := range httpConfigs {
+			i := i
+			go func() {
+				defer wg.Done()
+				if !httpSems[i].TryAcquire(1) {
+					if httpServers[i] != nil {
+						log.Println("Stopping HTTP server on address", httpConfigs[i].HTTPAddress)
+						err := httpServers[i].Shutdown(context.Background())
+						if err != nil {
+							log.Println("Error occurred while stopping HTTP server:", err)
+						} else {
+							log.Println("HTTP server stopped on address", httpConfigs[i].HTTPAddress)
+						}
 					}
 				}
-			}
-		}()
+			}()
+		}
+
+		wg.Wait()
+		close(stopped)
 	}()
 
 	log.Println("Storage mode:", c.StorageMode)
@@ -141,41 +197,156 @@ func run(ctx *cli.Context) error {
 		disk.WithMaxBlobSize(c.MaxBlobSize),
 		disk.WithProxyMaxBlobSize(c.MaxProxyBlobSize),
 		disk.WithAccessLogger(c.AccessLogger),
+		disk.WithInstanceQuotas(c.InstanceQuotas()),
+		disk.WithMaxEntries(c.MaxEntriesPerKind),
+		disk.WithScanWorkers(c.StartupScanWorkers),
+		disk.WithMaxConcurrentFileRemovals(c.MaxConcurrentFileRemovals),
+		disk.WithFsyncPolicies(c.FsyncPolicy),
+		disk.WithFsyncBatchInterval(c.FsyncBatchInterval),
+		disk.WithCASLeaseDuration(c.CASLeaseDuration),
+		disk.WithUploadWait(c.UploadWait),
+		disk.WithReconciliation(c.ReconcileInterval, c.ReconcileDownloadWindow),
+	}
+	if c.MmapReads {
+		opts = append(opts, disk.WithMmapReads())
+	}
+	if c.AccessJournal {
+		opts = append(opts, disk.WithAccessJournal())
+	}
+	if c.Provenance {
+		opts = append(opts, disk.WithProvenance())
+	}
+	if c.BatchFileRemovals {
+		opts = append(opts, disk.WithBatchFileRemovals())
+	}
+	if c.MaxFileRemovalRate > 0 {
+		opts = append(opts, disk.WithFileRemovalRate(c.MaxFileRemovalRate))
+	}
+	if c.ZombieRescan {
+		opts = append(opts, disk.WithZombieRescan())
+	}
+	if c.VerifyLegacyReads > 0 {
+		opts = append(opts, disk.WithLegacyReadVerification(c.VerifyLegacyReads))
+	}
+	if c.InlineBlobSize > 0 {
+		opts = append(opts, disk.WithInlineBlobSize(c.InlineBlobSize))
+		if c.InlineMaxBytes > 0 {
+			opts = append(opts, disk.WithInlineMaxBytes(c.InlineMaxBytes))
+		}
+	}
+	if c.InvocationStatsRetention > 0 {
+		opts = append(opts, disk.WithInvocationStats(c.InvocationStatsRetention))
+	}
+	if c.InvocationTranscriptLimit > 0 {
+		opts = append(opts, disk.WithInvocationTranscripts(c.InvocationTranscriptLimit))
+	}
+	if c.AdminUI {
+		opts = append(opts, disk.WithActivityTracking())
+	}
+	if c.IOSchedulerSlots > 0 {
+		opts = append(opts, disk.WithIOScheduler(c.IOSchedulerSlots, c.IOSchedulerWeight))
+	}
+	if c.ResumableUploadMinSize > 0 {
+		opts = append(opts, disk.WithResumableUploads(c.ResumableUploadMinSize))
+	}
+	if c.MaxInflightUploadSize > 0 {
+		opts = append(opts, disk.WithMaxInflightUploadSize(c.MaxInflightUploadSize))
+	}
+	if c.DiskHeadroom != "" {
+		opts = append(opts, disk.WithDiskHeadroom(c.HeadroomBytes, c.HeadroomPercent))
+	}
+	if c.CheckFreeSpace {
+		opts = append(opts, disk.WithFreeSpaceCheck())
 	}
 	if c.ProxyBackend != nil {
 		opts = append(opts, disk.WithProxyBackend(c.ProxyBackend))
 	}
+	if c.Replicator != nil {
+		log.Printf("Replicating writes to peers: %s", strings.Join(c.Replicator.Peers(), ", "))
+		opts = append(opts, disk.WithReplicator(c.Replicator))
+	}
+	if c.Notifier != nil {
+		opts = append(opts, disk.WithNotifier(c.Notifier))
+	}
+	if c.EventExporter != nil {
+		opts = append(opts, disk.WithEventStream(c.EventExporter))
+	}
+	if c.HashRing != nil {
+		log.Printf("Cluster mode enabled, members: %s", strings.Join(c.HashRing.Members(), ", "))
+		opts = append(opts, disk.WithCluster(c.HashRing))
+	}
 	if c.EnableEndpointMetrics {
 		opts = append(opts, disk.WithEndpointMetrics())
+		if len(c.MetricsInstanceNames) > 0 {
+			opts = append(opts, disk.WithMetricsInstanceNames(c.MetricsInstanceNames))
+		}
+	}
+	if c.ReadOnly {
+		log.Println("Read-only mode: writes will be rejected")
+		opts = append(opts, disk.WithReadOnly())
+	}
+	if c.Standby {
+		log.Println("Standby mode: writes from clients will be refused until promoted")
+		opts = append(opts, disk.WithStandby())
+	}
+	if c.ProxyRequired {
+		log.Println("Writes will be refused while the proxy backend is unavailable")
+		opts = append(opts, disk.WithProxyRequired())
+	}
+	if c.ProxyReadsWhileLoading {
+		log.Println("Reads will be served from the proxy backend until the existing files have been loaded")
+		opts = append(opts, disk.WithProxyReadsWhileLoading(c.WriteThroughWhileLoading))
+	}
+	if c.VerifyProxyReads {
+		log.Println("CAS blobs fetched from the proxy backend will be verified")
+		opts = append(opts, disk.WithProxyReadVerification())
+	}
+	if c.Maintenance != nil && c.Maintenance.Schedule != "" {
+		log.Printf("Maintenance windows: %q for %v", c.Maintenance.Schedule, c.Maintenance.Duration)
+	}
+	opts = append(opts, disk.WithMaintenance(c.MaintenanceWindow))
+
+	// The previous bazel-remote process, if any, uses the cache directory
+	// until it exits, so take over its listeners to let it shut down, and
+	// wait for that before loading the cache directory. Connections are
+	// queued by the kernel in the meantime, and no requests are served
+	// until the cache directory has been loaded, see the README.
+	inherited := hf.Inherited()
+	var lns *listeners
+	if inherited {
+		log.Println("Using the listeners inherited from the previous bazel-remote process")
+		lns = listenAll(hf, c, httpConfigs, grpcConfigs)
+
+		log.Println("Waiting for the previous bazel-remote process to exit")
+		err = hf.WaitForPrevious()
+		if err != nil {
+			log.Fatal("Failed to wait for the previous bazel-remote process:", err)
+		}
 	}
 
-	diskCache, err := disk.New(c.Dir, int64(c.MaxSize)*1024*1024*1024, opts...)
+	maxSize, err := maxSizeBytes(c)
 	if err != nil {
 		log.Fatal(err)
 	}
-	diskCache.RegisterMetrics()
 
-	servers := new(errgroup.Group)
-
-	var htpasswdSecrets auth.SecretProvider
+	diskCache, err := disk.New(c.Dir, maxSize, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	diskCache.RegisterMetrics()
 
-	authMode := "disabled"
-	if c.HtpasswdFile != "" {
-		authMode = "basic"
-		htpasswdSecrets = auth.HtpasswdFileProvider(c.HtpasswdFile)
-	} else if c.TLSCaFile != "" {
-		authMode = "mTLS"
+	if c.MaxSizePercent > 0 && reloadSignal != nil {
+		go reloadMaxSize(c, diskCache)
 	}
-	log.Println("Authentication:", authMode)
 
-	if authMode != "disabled" {
-		if c.AllowUnauthenticatedReads {
-			log.Println("Access mode: authentication required for writes, unauthenticated reads allowed")
-		} else {
-			log.Println("Access mode: authentication required")
-		}
+	if c.HashRing != nil {
+		c.HashRing.Start()
 	}
 
+	servers := new(errgroup.Group)
+
+	htpasswdSecrets := listenerAuth(c)
+
 	var idleTimer *idle.Timer
 	if c.IdleTimeout > 0 {
 		idleTimer = idle.NewTimer(c.IdleTimeout, idleTimeoutChan)
@@ -187,17 +358,56 @@ func run(ctx *cli.Context) error {
 	}
 	log.Println("Mangling non-empty instance names with AC keys:", acKeyManglingStatus)
 
-	servers.Go(func() error {
-		err := startHttpServer(c, &httpServer, htpasswdSecrets, idleTimer, httpSem, diskCache)
-		if err != nil {
-			log.Fatal("HTTP server returned fatal error:", err)
+	if c.Limiter != nil {
+		log.Printf("Limiting concurrent requests: %d in total (0 means no limit), per endpoint: %v",
+			c.MaxConcurrentRequests, c.MaxConcurrentPerEndpoint)
+	}
+
+	if c.Throttler != nil {
+		log.Printf("Limiting download bandwidth in bytes per second (0 means no limit): %d per connection, %d per identity",
+			c.MaxDownloadRatePerConn, c.MaxDownloadRatePerIdentity)
+	}
+
+	if c.FaultInjector != nil {
+		log.Printf("WARNING: injecting faults into responses, for testing only: %s",
+			strings.Join(c.FaultInjection.Rules, " "))
+	}
+
+	if !inherited {
+		lns = listenAll(hf, c, httpConfigs, grpcConfigs)
+	}
+	httpListeners := lns.http
+	grpcListeners := lns.grpc
+	profileListener := lns.profile
+	adminListener := lns.admin
+
+	for i, hc := range httpConfigs {
+		i, hc := i, hc
+		secrets := htpasswdSecrets
+		if hc != c {
+			log.Println("Additional HTTP listener on address", hc.HTTPAddress)
+			secrets = listenerAuth(hc)
+		}
+
+		servers.Go(func() error {
+			err := startHttpServer(hc, httpListeners[i], &httpServers[i], secrets, idleTimer, httpSems[i], diskCache)
+			if err != nil {
+				log.Fatal("HTTP server returned fatal error:", err)
+			}
+			return nil
+		})
+	}
+
+	for i, gc := range grpcConfigs {
+		i, gc := i, gc
+		secrets := htpasswdSecrets
+		if gc != c {
+			log.Println("Additional gRPC listener on address", gc.GRPCAddress)
+			secrets = listenerAuth(gc)
 		}
-		return nil
-	})
 
-	if c.GRPCAddress != "none" {
 		servers.Go(func() error {
-			err := startGrpcServer(c, &grpcServer, htpasswdSecrets, idleTimer, grpcSem, diskCache)
+			err := startGrpcServer(gc, grpcListeners[i], &grpcServers[i], secrets, idleTimer, grpcSems[i], diskCache)
 			if err != nil {
 				log.Fatal("gRPC server returned fatal error:", err)
 			}
@@ -210,8 +420,30 @@ func run(ctx *cli.Context) error {
 			// Allow access to /debug/pprof/ URLs.
 			log.Printf("Starting HTTP server for profiling on address %s",
 				c.ProfileAddress)
-			log.Fatal(`Failed to listen on address: "`, c.ProfileAddress,
-				`": `, http.ListenAndServe(c.ProfileAddress, nil))
+			log.Fatal(`Failed to serve on address: "`, c.ProfileAddress,
+				`": `, http.Serve(profileListener, nil))
+		}()
+	}
+
+	if c.AdminAddress != "" {
+		configYAML, err := c.Redacted().YAML()
+		if err != nil {
+			log.Fatal("Failed to encode the effective configuration:", err)
+		}
+
+		go func() {
+			adminHandler := server.NewAdminHandler(diskCache, c.MaintenanceWindow, configYAML, c.ErrorLogger)
+			if c.AdminUI {
+				authenticator := &auth.BasicAuth{Realm: c.AdminAddress, Secrets: htpasswdSecrets}
+				adminHandler.EnableUI(func(handler http.HandlerFunc) http.HandlerFunc {
+					return basicAuthWrapper(handler, authenticator)
+				})
+				log.Printf("Serving the admin UI from /ui/ on address %s", c.AdminAddress)
+			}
+			log.Printf("Starting HTTP server for the admin API on address %s",
+				c.AdminAddress)
+			log.Fatal(`Failed to serve on address: "`, c.AdminAddress,
+				`": `, http.Serve(adminListener, adminHandler))
 		}()
 	}
 
@@ -220,16 +452,259 @@ func run(ctx *cli.Context) error {
 		idleTimer.Start()
 	}
 
-	return servers.Wait()
+	err = servers.Wait()
+	<-stopped
+
+	if idleShutdown {
+		exitIdle(c, idleTimer, diskCache)
+	}
+
+	return err
 }
 
-func startHttpServer(c *config.Config, httpServer **http.Server,
+// The maximum time to wait for queued uploads to finish before exiting
+// after the idle timeout.
+const idleFlushTimeout = time.Minute
+
+// Wait for queued proxy and replication uploads, notifications and event
+// stream records to finish, log a summary and exit with idle.ExitCode.
+func exitIdle(c *config.Config, idleTimer *idle.Timer, diskCache disk.Cache) {
+	ctx, cancel := context.WithTimeout(context.Background(), idleFlushTimeout)
+
+	if c.ProxyBackend != nil {
+		err := backendproxy.Flush(ctx)
+		if err != nil {
+			log.Println("Failed to finish queued proxy uploads:", err)
+		}
+	}
+
+	if c.Replicator != nil {
+		err := c.Replicator.Flush(ctx)
+		if err != nil {
+			log.Println("Failed to finish queued replication uploads:", err)
+		}
+	}
+
+	if c.Notifier != nil {
+		err := c.Notifier.Flush(ctx)
+		if err != nil {
+			log.Println("Failed to send queued notifications:", err)
+		}
+	}
+
+	if c.EventExporter != nil {
+		err := c.EventExporter.Flush(ctx)
+		if err != nil {
+			log.Println("Failed to publish queued event stream records:", err)
+		}
+	}
+
+	cancel()
+
+	stats := idleTimer.Stats()
+	totalSize, _, numItems, _ := diskCache.Stats()
+	log.Printf("Exiting after %v idle, uptime: %v, requests served: %d, cache items: %d, cache size: %d bytes",
+		stats.IdleFor.Round(time.Second), stats.Uptime.Round(time.Second),
+		stats.NumRequests, numItems, totalSize)
+
+	os.Exit(idle.ExitCode)
+}
+
+// Print the effective configuration, with secrets redacted.
+func dumpConfig(ctx *cli.Context) error {
+	c, err := config.Parse(ctx)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	data, err := c.Redacted().YAML()
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	_, err = ctx.App.Writer.Write(data)
+	return err
+}
+
+// Block until the server should shut down, and return true if this is
+// because of the idle timeout. On restartSignal, a new bazel-remote process is
+// started which takes over the listeners, and we shut down once it has
+// them. It waits for this process to exit before it loads the cache
+// directory.
+func waitForShutdown(sigChan chan os.Signal, idleTimeoutChan chan struct{}, hf *handoff.Handoff) bool {
+	for {
+		select {
+		case sig := <-sigChan:
+			if sig != restartSignal {
+				log.Printf("Received signal: %s, attempting graceful shutdown", sig)
+				return false
+			}
+
+			log.Printf("Received signal: %s, handing over to a new bazel-remote process", sig)
+			err := hf.Restart()
+			if err != nil {
+				log.Println("Failed to hand over, continuing to serve:", err)
+				continue
+			}
+			log.Println("The new bazel-remote process is ready, attempting graceful shutdown")
+			return false
+		case <-idleTimeoutChan:
+			log.Println("Idle timeout reached, attempting graceful shutdown")
+			return true
+		}
+	}
+}
+
+// Returns the maximum size of the disk cache in bytes. With
+// max_size=auto:<percent>%, this is the percentage of the size of the
+// cache directory's filesystem, rounded down to disk.BlockSize.
+func maxSizeBytes(c *config.Config) (int64, error) {
+	if c.MaxSizePercent == 0 {
+		return int64(c.MaxSize) * 1024 * 1024 * 1024, nil
+	}
+
+	err := os.MkdirAll(c.Dir, os.ModePerm)
+	if err != nil {
+		return 0, err
+	}
+
+	fsSize, err := disk.FilesystemSize(c.Dir)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to derive max_size from the size of the cache directory's filesystem: %w", err)
+	}
+
+	maxSize := int64(float64(fsSize)*c.MaxSizePercent/100) &^ (disk.BlockSize - 1)
+	if maxSize <= 0 {
+		return 0, fmt.Errorf("The cache directory's filesystem is too small for max_size=auto:%v%%: %d bytes", c.MaxSizePercent, fsSize)
+	}
+
+	log.Printf("max_size=auto:%v%% of the cache directory's filesystem (%d bytes): %d bytes (%.1f GiB)",
+		c.MaxSizePercent, fsSize, maxSize, float64(maxSize)/(1024*1024*1024))
+
+	return maxSize, nil
+}
+
+// Derive the maximum size of the disk cache from the size of the cache
+// directory's filesystem again on each reloadSignal, eg after the volume
+// was resized.
+func reloadMaxSize(c *config.Config, diskCache disk.Cache) {
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, reloadSignal)
+
+	for sig := range reloadChan {
+		log.Printf("Received signal: %s, updating max_size", sig)
+
+		maxSize, err := maxSizeBytes(c)
+		if err != nil {
+			log.Println("Failed to update max_size, keeping the current value:", err)
+			continue
+		}
+		diskCache.SetMaxSize(maxSize)
+	}
+}
+
+// Log the authentication settings of a listener, and return the secrets
+// for basic authentication if it is enabled, otherwise nil.
+func listenerAuth(c *config.Config) auth.SecretProvider {
+	var htpasswdSecrets auth.SecretProvider
+
+	authMode := "disabled"
+	if c.HtpasswdFile != "" {
+		authMode = "basic"
+		htpasswdSecrets = auth.HtpasswdFileProvider(c.HtpasswdFile)
+	} else if c.TLSCaFile != "" {
+		authMode = "mTLS"
+	}
+	log.Println("Authentication:", authMode)
+
+	if authMode != "disabled" {
+		if c.AllowUnauthenticatedReads {
+			log.Println("Access mode: authentication required for writes, unauthenticated reads allowed")
+		} else {
+			log.Println("Access mode: authentication required")
+		}
+	}
+
+	return htpasswdSecrets
+}
+
+func newSemaphores(n int) []*semaphore.Weighted {
+	sems := make([]*semaphore.Weighted, n)
+	for i := range sems {
+		sems[i] = semaphore.NewWeighted(1)
+	}
+	return sems
+}
+
+// Listen on addr, which is either a TCP address or "unix://" followed by
+// a socket path, or reuse the listener from the previous process.
+// The listeners which bazel-remote serves on.
+type listeners struct {
+	http    []net.Listener
+	grpc    []net.Listener
+	profile net.Listener
+	admin   net.Listener
+}
+
+// Create the listeners, or take them over from the previous bazel-remote
+// process, and tell it that it can shut down.
+func listenAll(hf *handoff.Handoff, c *config.Config, httpConfigs []*config.Config, grpcConfigs []*config.Config) *listeners {
+	lns := &listeners{
+		http: make([]net.Listener, len(httpConfigs)),
+		grpc: make([]net.Listener, len(grpcConfigs)),
+	}
+
+	for i, hc := range httpConfigs {
+		lns.http[i] = listen(hf, hc.HTTPAddress)
+	}
+
+	for i, gc := range grpcConfigs {
+		lns.grpc[i] = listen(hf, gc.GRPCAddress)
+	}
+
+	if c.ProfileAddress != "" {
+		lns.profile = listen(hf, c.ProfileAddress)
+	}
+
+	if c.AdminAddress != "" {
+		lns.admin = listen(hf, c.AdminAddress)
+	}
+
+	err := hf.Ready()
+	if err != nil {
+		log.Fatal("Failed to notify the previous bazel-remote process:", err)
+	}
+
+	return lns
+}
+
+func listen(hf *handoff.Handoff, addr string) net.Listener {
+	network := "tcp"
+	path := addr
+	if strings.HasPrefix(addr, "unix://") {
+		network = "unix"
+		path = addr[len("unix://"):]
+	}
+
+	ln, err := hf.Listen(network, path)
+	if err != nil {
+		log.Fatal(`Failed to listen on address: "`, addr, `": `, err)
+	}
+
+	return ln
+}
+
+func startHttpServer(c *config.Config, ln net.Listener, httpServer **http.Server,
 	htpasswdSecrets auth.SecretProvider, idleTimer *idle.Timer,
 	httpSem *semaphore.Weighted, diskCache disk.Cache) error {
 
 	mux := http.NewServeMux()
+	var handler http.Handler = mux
+	if c.CORS != nil {
+		handler = server.CORS(mux, c.CORS.AllowedOrigins, c.CORS.AllowedMethods, c.CORS.MaxAge)
+	}
 	*httpServer = &http.Server{
-		Handler:      mux,
+		Handler:      handler,
 		ReadTimeout:  c.HTTPReadTimeout,
 		TLSConfig:    c.TLSConfig,
 		WriteTimeout: c.HTTPWriteTimeout,
@@ -238,10 +713,41 @@ func startHttpServer(c *config.Config, httpServer **http.Server,
 	checkClientCertForReads := c.TLSCaFile != "" && !c.AllowUnauthenticatedReads
 	checkClientCertForWrites := c.TLSCaFile != ""
 	validateAC := !c.DisableHTTPACValidation
+	symlinkPolicy, err := validate.ParseSymlinkPolicy(c.SymlinkPolicy)
+	if err != nil {
+		return err
+	}
+	var verifyDigests []cache.EntryKind
+	for _, kind := range []cache.EntryKind{cache.AC, cache.RAW} {
+		for _, name := range c.HTTPVerifyDigests {
+			if name == kind.String() {
+				verifyDigests = append(verifyDigests, kind)
+			}
+		}
+	}
+	var gzipConfig *server.GzipConfig
+	if c.HTTPGzipMinSize > 0 {
+		log.Printf("Compressing HTTP responses of at least %d bytes with gzip", c.HTTPGzipMinSize)
+		gzipConfig = &server.GzipConfig{
+			MinSize:       c.HTTPGzipMinSize,
+			MaxConcurrent: c.HTTPGzipMaxConcurrent,
+		}
+	}
+	peers := server.PeerConfig{
+		Secret:      c.PeerSecret,
+		Cluster:     c.HashRing != nil,
+		Replication: c.Replicator != nil || c.Standby,
+	}
 	h := server.NewHTTPCache(diskCache, c.AccessLogger, c.ErrorLogger, validateAC,
-		c.EnableACKeyInstanceMangling, checkClientCertForReads, checkClientCertForWrites, gitCommit)
+		c.EnableACKeyInstanceMangling, verifyDigests, checkClientCertForReads, checkClientCertForWrites, gzipConfig, symlinkPolicy, c.HTTPCompatMode, peers, gitCommit)
 
 	cacheHandler := h.CacheHandler
+	if c.Attestations != nil {
+		cacheHandler = server.AttestationsHTTP(cacheHandler, diskCache, c.AttestationKeys, h.CheckClientCert, c.ErrorLogger)
+	}
+	if c.Metadata != nil {
+		cacheHandler = server.MetadataHTTP(cacheHandler, diskCache, c.Metadata.MaxTTL, h.CheckClientCert, c.ErrorLogger)
+	}
 	var basicAuthenticator auth.BasicAuth
 	if c.HtpasswdFile != "" {
 		if c.AllowUnauthenticatedReads {
@@ -269,12 +775,32 @@ func startHttpServer(c *config.Config, httpServer **http.Server,
 		}
 	}
 
+	if c.Limiter != nil {
+		cacheHandler = server.LimitHTTP(cacheHandler, c.Limiter)
+	}
+
+	if c.MaxHTTPBodySize > 0 {
+		cacheHandler = server.LimitHTTPBodySize(cacheHandler, c.MaxHTTPBodySize)
+	}
+
+	if c.Throttler != nil {
+		cacheHandler = server.ThrottleHTTP(cacheHandler, c.Throttler)
+	}
+
+	if c.FaultInjector != nil {
+		cacheHandler = server.InjectFaultsHTTP(cacheHandler, c.FaultInjector)
+	}
+
+	if c.Provenance {
+		cacheHandler = server.RecordUploaderHTTP(cacheHandler)
+	}
+
+	if c.IOSchedulerSlots > 0 {
+		cacheHandler = server.PriorityHTTP(cacheHandler)
+	}
+
 	if c.EnableEndpointMetrics {
-		metricsMdlw := middleware.New(middleware.Config{
-			Recorder: httpmetrics.NewRecorder(httpmetrics.Config{
-				DurationBuckets: c.MetricsDurationBuckets,
-			}),
-		})
+		metricsMdlw := httpMetricsMiddleware(c)
 
 		middlewareHandler := middlewarestd.Handler("metrics", metricsMdlw, promhttp.Handler())
 		if !c.AllowUnauthenticatedReads {
@@ -290,24 +816,29 @@ func startHttpServer(c *config.Config, httpServer **http.Server,
 
 		ch := cacheHandler // Avoid an infinite loop in the closure below.
 		cacheHandler = func(w http.ResponseWriter, r *http.Request) {
-			middlewarestd.Handler(r.Method, metricsMdlw, http.HandlerFunc(ch)).ServeHTTP(w, r)
+			server.MetricsHandler(r.Method, metricsMdlw, http.HandlerFunc(ch)).ServeHTTP(w, r)
 		}
 	}
 
-	mux.HandleFunc("/status", statusHandler)
-	mux.HandleFunc("/", cacheHandler)
-
-	var ln net.Listener
-	var err error
-	if strings.HasPrefix(c.HTTPAddress, "unix://") {
-		ln, err = net.Listen("unix", c.HTTPAddress[len("unix://"):])
-	} else {
-		ln, err = net.Listen("tcp", c.HTTPAddress)
+	// Enrollment has its own token authentication, so that clients can
+	// get a certificate before they have one.
+	if c.CertIssuer != nil && c.TLSConfig != nil {
+		log.Printf("Issuing client certificates at /enroll on address %s", c.HTTPAddress)
+		mux.Handle("/enroll", server.EnrollHTTP(c.CertIssuer, c.AccessLogger, c.ErrorLogger))
 	}
-	if err != nil {
-		log.Fatal(`Failed to listen on address: "`, c.HTTPAddress, `": `, err)
+
+	// The prewarm webhook authenticates requests with its own secret, so
+	// that CI systems can call it without cache credentials.
+	if c.Prewarm != nil {
+		log.Printf("Prewarming the cache when %s is updated, via the /prewarm webhook on address %s",
+			c.Prewarm.Ref, c.HTTPAddress)
+		mux.Handle("/prewarm", server.PrewarmHTTP(diskCache, c.Prewarm.SeedURL,
+			c.Prewarm.WebhookSecret, c.Prewarm.Ref, c.ErrorLogger))
 	}
 
+	mux.HandleFunc("/status", statusHandler)
+	mux.HandleFunc("/", cacheHandler)
+
 	validateStatus := "disabled"
 	if validateAC {
 		validateStatus = "enabled"
@@ -321,7 +852,7 @@ func startHttpServer(c *config.Config, httpServer **http.Server,
 		}
 
 		log.Printf("Starting HTTPS server on address %s", c.HTTPAddress)
-		err = (*httpServer).ServeTLS(ln, c.TLSCertFile, c.TLSKeyFile)
+		err := (*httpServer).ServeTLS(ln, c.TLSCertFile, c.TLSKeyFile)
 		if err == http.ErrServerClosed {
 			log.Println("HTTPS server stopped")
 			return nil
@@ -344,7 +875,7 @@ func startHttpServer(c *config.Config, httpServer **http.Server,
 	return err
 }
 
-func startGrpcServer(c *config.Config, grpcServer **grpc.Server,
+func startGrpcServer(c *config.Config, ln net.Listener, grpcServer **grpc.Server,
 	htpasswdSecrets auth.SecretProvider, idleTimer *idle.Timer,
 	grpcSem *semaphore.Weighted, diskCache disk.Cache) error {
 
@@ -358,6 +889,39 @@ func startGrpcServer(c *config.Config, grpcServer **grpc.Server,
 		grpc_prometheus.EnableHandlingTimeHistogram(grpc_prometheus.WithHistogramBuckets(c.MetricsDurationBuckets))
 	}
 
+	if c.InvocationStatsRetention > 0 || c.Provenance {
+		streamInterceptors = append(streamInterceptors, server.RequestMetadataStreamServerInterceptor)
+		unaryInterceptors = append(unaryInterceptors, server.RequestMetadataUnaryServerInterceptor)
+	}
+
+	if c.Provenance {
+		streamInterceptors = append(streamInterceptors, server.UploaderStreamServerInterceptor)
+		unaryInterceptors = append(unaryInterceptors, server.UploaderUnaryServerInterceptor)
+	}
+
+	if c.IOSchedulerSlots > 0 {
+		streamInterceptors = append(streamInterceptors, server.PriorityStreamServerInterceptor)
+		unaryInterceptors = append(unaryInterceptors, server.PriorityUnaryServerInterceptor)
+	}
+
+	if c.Limiter != nil {
+		gl := server.NewGrpcLimiter(c.Limiter)
+		streamInterceptors = append(streamInterceptors, gl.StreamServerInterceptor)
+		unaryInterceptors = append(unaryInterceptors, gl.UnaryServerInterceptor)
+	}
+
+	if c.Throttler != nil {
+		gt := server.NewGrpcThrottler(c.Throttler)
+		streamInterceptors = append(streamInterceptors, gt.StreamServerInterceptor)
+		unaryInterceptors = append(unaryInterceptors, gt.UnaryServerInterceptor)
+	}
+
+	if c.FaultInjector != nil {
+		gf := server.NewGrpcFaultInjector(c.FaultInjector)
+		streamInterceptors = append(streamInterceptors, gf.StreamServerInterceptor)
+		unaryInterceptors = append(unaryInterceptors, gf.UnaryServerInterceptor)
+	}
+
 	if c.TLSConfig != nil {
 		opts = append(opts, grpc.Creds(credentials.NewTLS(c.TLSConfig)))
 
@@ -391,6 +955,12 @@ func startGrpcServer(c *config.Config, grpcServer **grpc.Server,
 	}
 	log.Println("gRPC AC dependency checks:", validateStatus)
 
+	symlinkPolicy, err := validate.ParseSymlinkPolicy(c.SymlinkPolicy)
+	if err != nil {
+		return err
+	}
+	log.Println("Absolute symlink policy:", symlinkPolicy)
+
 	enableRemoteAssetAPI := c.ExperimentalRemoteAssetAPI
 	remoteAssetStatus := "disabled"
 	if enableRemoteAssetAPI {
@@ -398,11 +968,8 @@ func startGrpcServer(c *config.Config, grpcServer **grpc.Server,
 	}
 	log.Println("experimental gRPC remote asset API:", remoteAssetStatus)
 
-	network := "tcp"
-	addr := c.GRPCAddress
-	if strings.HasPrefix(c.GRPCAddress, "unix://") {
-		network = "unix"
-		addr = c.GRPCAddress[len("unix://"):]
+	if c.RemoteExecution != nil {
+		log.Println("experimental gRPC remote execution API: forwarding to", c.RemoteExecution.URL)
 	}
 
 	*grpcServer = grpc.NewServer(opts...)
@@ -412,16 +979,41 @@ func startGrpcServer(c *config.Config, grpcServer **grpc.Server,
 		return nil
 	}
 
-	log.Println("Starting gRPC server on address", addr)
+	log.Println("Starting gRPC server on address", c.GRPCAddress)
 
-	return server.ListenAndServeGRPC(*grpcServer,
-		network, addr,
+	return server.ServeGRPC(ln, *grpcServer,
 		validateAC,
 		c.EnableACKeyInstanceMangling,
 		enableRemoteAssetAPI,
+		server.RequestLimits{
+			FindMissingDigests: c.MaxFindMissingDigests,
+			BatchDigests:       c.MaxBatchDigests,
+			BatchTotalSize:     c.MaxBatchTotalSize,
+		},
+		symlinkPolicy,
+		c.ExecutionBackend,
 		diskCache, c.AccessLogger, c.ErrorLogger)
 }
 
+var (
+	httpMetricsOnce sync.Once
+	httpMetricsMdlw middleware.Middleware
+)
+
+// Returns the middleware which records the HTTP endpoint metrics. It is
+// shared by the HTTP listeners, since the metrics can only be registered
+// once.
+func httpMetricsMiddleware(c *config.Config) middleware.Middleware {
+	httpMetricsOnce.Do(func() {
+		httpMetricsMdlw = middleware.New(middleware.Config{
+			Recorder: httpmetrics.NewRecorder(httpmetrics.Config{
+				DurationBuckets: c.MetricsDurationBuckets,
+			}),
+		})
+	})
+	return httpMetricsMdlw
+}
+
 // A http.HandlerFunc wrapper which requires successful basic
 // authentication for all requests.
 func basicAuthWrapper(handler http.HandlerFunc, authenticator *auth.BasicAuth) http.HandlerFunc {
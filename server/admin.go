@@ -0,0 +1,689 @@
+package server
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	"github.com/buchgr/bazel-remote/v2/utils/bufpool"
+	"github.com/buchgr/bazel-remote/v2/utils/maintenance"
+	"github.com/buchgr/bazel-remote/v2/utils/tempfile"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+)
+
+// AdminHandler serves the admin API, which lets operators inspect and
+// control a running bazel-remote instance. It does not authenticate
+// requests, so it should be served on a separate address which only
+// operators can reach.
+type AdminHandler struct {
+	mux         *http.ServeMux
+	cache       disk.Cache
+	maintenance *maintenance.Window
+	configYAML  []byte
+	errorLogger cache.Logger
+}
+
+type maintenanceData struct {
+	Active     bool
+	NextWindow int64 // Unix time, or 0 if there is no schedule.
+}
+
+type standbyData struct {
+	Standby bool
+}
+
+// NewAdminHandler returns a new AdminHandler. configYAML is served from
+// /config, and should have any secrets redacted.
+func NewAdminHandler(c disk.Cache, maintenanceWindow *maintenance.Window, configYAML []byte, errorLogger cache.Logger) *AdminHandler {
+	h := &AdminHandler{
+		mux:         http.NewServeMux(),
+		cache:       c,
+		maintenance: maintenanceWindow,
+		configYAML:  configYAML,
+		errorLogger: errorLogger,
+	}
+
+	h.mux.HandleFunc("/maintenance", h.handleMaintenance)
+	h.mux.HandleFunc("/eviction", h.handleEviction)
+	h.mux.HandleFunc("/config", h.handleConfig)
+	h.mux.HandleFunc("/instances", h.handleInstances)
+	h.mux.HandleFunc("/usage", h.handleUsage)
+	h.mux.HandleFunc("/churn", h.handleChurn)
+	h.mux.HandleFunc("/invocations", h.handleInvocations)
+	h.mux.HandleFunc("/invocations/transcript", h.handleInvocationTranscript)
+	h.mux.HandleFunc("/provenance", h.handleProvenance)
+	h.mux.HandleFunc("/snapshot", h.handleSnapshot)
+	h.mux.HandleFunc("/import", h.handleImport)
+	h.mux.HandleFunc("/purge", h.handlePurge)
+	h.mux.HandleFunc("/reconcile", h.handleReconcile)
+	h.mux.HandleFunc("/rescan", h.handleRescan)
+	h.mux.HandleFunc("/standby", h.handleStandby)
+	h.mux.HandleFunc("/proxy", h.handleProxy)
+	h.mux.HandleFunc("/proxy/enable", h.handleProxyEnable)
+	h.mux.HandleFunc("/proxy/disable", h.handleProxyEnable)
+
+	return h
+}
+
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// Report whether a maintenance window is open, and open one on POST.
+func (h *AdminHandler) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		h.maintenance.Trigger()
+	default:
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := maintenanceData{Active: h.maintenance.Active()}
+	if next := h.maintenance.Next(); !next.IsZero() {
+		data.NextWindow = next.Unix()
+	}
+
+	h.writeJSON(w, data)
+}
+
+// Report whether the cache is a standby, and promote it on POST.
+func (h *AdminHandler) handleStandby(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		h.cache.Promote()
+	default:
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.writeJSON(w, standbyData{Standby: h.cache.Standby()})
+}
+
+// Report the status of the proxy backends.
+func (h *AdminHandler) handleProxy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.writeJSON(w, h.cache.ProxyBackends())
+}
+
+// Enable or disable the proxy backend named by the "backend" parameter,
+// by default cache.DefaultBackendName, and report the status of the
+// proxy backends.
+func (h *AdminHandler) handleProxyEnable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	backend := r.URL.Query().Get("backend")
+	if backend == "" {
+		backend = cache.DefaultBackendName
+	}
+
+	err := h.cache.SetProxyBackendEnabled(backend, r.URL.Path == "/proxy/enable")
+	if err != nil {
+		code := http.StatusInternalServerError
+		if cerr, ok := err.(*cache.Error); ok {
+			code = cerr.Code
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	h.writeJSON(w, h.cache.ProxyBackends())
+}
+
+// Report which entries would be evicted to shrink the cache to the
+// target_size query parameter, in bytes.
+func (h *AdminHandler) handleEviction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	targetSize, err := strconv.ParseInt(r.URL.Query().Get("target_size"), 10, 64)
+	if err != nil || targetSize < 0 {
+		http.Error(w, "The target_size parameter must be a non-negative number of bytes",
+			http.StatusBadRequest)
+		return
+	}
+
+	h.writeJSON(w, h.cache.SimulateEviction(targetSize))
+}
+
+// Report the disk space used by each instance name, and its quota if it
+// has one.
+func (h *AdminHandler) handleInstances(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.writeJSON(w, h.cache.InstanceUsage())
+}
+
+// Report the disk space used by the whole cache, the directory of each
+// kind and their shard directories, or only down to the level given by
+// the depth parameter.
+func (h *AdminHandler) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	depth := disk.MaxUsageDepth
+	if value := r.URL.Query().Get("depth"); value != "" {
+		var err error
+		depth, err = strconv.Atoi(value)
+		if err != nil || depth < 0 || depth > disk.MaxUsageDepth {
+			http.Error(w, fmt.Sprintf("The depth parameter must be between 0 and %d", disk.MaxUsageDepth),
+				http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.writeJSON(w, h.cache.DirectoryUsage(depth))
+}
+
+// Report how much data was written to the cache since startup compared
+// to how much was read back, per kind of entry.
+func (h *AdminHandler) handleChurn(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.writeJSON(w, h.cache.Churn())
+}
+
+// Report the cache statistics of the client tool invocations seen within
+// the retention window, or only the invocation given by the id query
+// parameter.
+func (h *AdminHandler) handleInvocations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats := h.cache.InvocationStats()
+	if stats == nil {
+		http.Error(w, "Invocation statistics are not collected, see --invocation_stats_retention",
+			http.StatusNotFound)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		h.writeJSON(w, stats)
+		return
+	}
+
+	for _, s := range stats {
+		if s.InvocationID == id {
+			h.writeJSON(w, s)
+			return
+		}
+	}
+
+	http.Error(w, "Invocation not found", http.StatusNotFound)
+}
+
+// Download the transcript of the cache requests made by the client tool
+// invocation given by the id query parameter.
+func (h *AdminHandler) handleInvocationTranscript(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "Missing id query parameter", http.StatusBadRequest)
+		return
+	}
+
+	tr, err := h.cache.InvocationTranscript(id)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if cerr, ok := err.(*cache.Error); ok {
+			code = cerr.Code
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", "transcript-"+id+".json"))
+	h.writeJSON(w, tr)
+}
+
+// Report who uploaded the entry given by the kind (by default cas) and
+// hash query parameters.
+func (h *AdminHandler) handleProvenance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	kind := cache.CAS
+	if k := r.URL.Query().Get("kind"); k != "" {
+		var ok bool
+		kind, ok = parseEntryKind(k)
+		if !ok {
+			http.Error(w, "Invalid kind, expected ac, cas or raw", http.StatusBadRequest)
+			return
+		}
+	}
+
+	records, err := h.cache.Provenance(kind, r.URL.Query().Get("hash"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if cerr, ok := err.(*cache.Error); ok {
+			code = cerr.Code
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	h.writeJSON(w, records)
+}
+
+// SnapshotManifestName is the name of the manifest at the start of a
+// snapshot tarball.
+const SnapshotManifestName = "snapshot.json"
+
+// The manifest of a snapshot tarball, in the format of the manifests
+// written by the backup subcommand.
+type snapshotManifest struct {
+	Started time.Time               `json:"started"`
+	Entries []snapshotManifestEntry `json:"entries"`
+}
+
+type snapshotManifestEntry struct {
+	Kind        string `json:"kind"`
+	Hash        string `json:"hash"`
+	LogicalSize int64  `json:"logical_size"`
+	SizeOnDisk  int64  `json:"size_on_disk"`
+	Legacy      bool   `json:"legacy,omitempty"`
+	Random      string `json:"random"`
+}
+
+// Stream a tarball of a consistent snapshot of the cache, without pausing
+// requests: a manifest of the entries, followed by their files in the
+// same order, at their paths relative to the cache directory.
+func (h *AdminHandler) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	s := h.cache.Snapshot()
+	defer s.Close()
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	err := writeSnapshot(w, s)
+	if err != nil {
+		h.errorLogger.Printf("Failed to export a snapshot: %v", err)
+
+		// Abort the response, so the client doesn't mistake a
+		// truncated tarball for a complete one.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func writeSnapshot(w io.Writer, s *disk.Snapshot) error {
+	m := snapshotManifest{
+		Started: s.Created.UTC(),
+		Entries: make([]snapshotManifestEntry, 0, len(s.Entries)),
+	}
+	for _, e := range s.Entries {
+		m.Entries = append(m.Entries, snapshotManifestEntry{
+			Kind:        e.Kind.String(),
+			Hash:        e.Hash,
+			LogicalSize: e.LogicalSize,
+			SizeOnDisk:  e.SizeOnDisk,
+			Legacy:      e.Legacy,
+			Random:      e.Random,
+		})
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:    SnapshotManifestName,
+		Mode:    tempfile.FinalMode,
+		Size:    int64(len(data)),
+		ModTime: s.Created,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range s.Entries {
+		err = writeSnapshotEntry(tw, s, e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+func writeSnapshotEntry(tw *tar.Writer, s *disk.Snapshot, e disk.EntryInfo) error {
+	f, err := s.Open(e)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Files which were committed just before the snapshot was taken
+	// might not have been marked as complete yet, so set the mode of
+	// complete files explicitly.
+	err = tw.WriteHeader(&tar.Header{
+		Name:    e.Path,
+		Mode:    tempfile.FinalMode,
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = bufpool.Copy(tw, f)
+	return err
+}
+
+// Merge the entries in a snapshot tarball, in the format served from
+// /snapshot, into the cache. Entries which are already in the cache are
+// skipped, and only as many entries are imported as fit in the free
+// space, so no entries are evicted. Report how many entries were
+// imported.
+func (h *AdminHandler) handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	tr := tar.NewReader(r.Body)
+	entries, err := readSnapshotManifest(tr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	importer := h.cache.NewImporter(entries)
+	for _, e := range entries {
+		hdr, err := tr.Next()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read the snapshot: %v", err), http.StatusBadRequest)
+			return
+		}
+		if !strings.Contains(hdr.Name, e.Hash) {
+			msg := fmt.Sprintf("Expected a file for %s in the snapshot, found %q",
+				cache.LookupKey(e.Kind, e.Hash), hdr.Name)
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		// Keep going, the other entries might be fine.
+		err = importer.Import(r.Context(), e, tr)
+		if err != nil {
+			h.errorLogger.Printf("%v", err)
+		}
+	}
+
+	h.writeJSON(w, importer.Stats)
+}
+
+// Read the manifest at the start of a snapshot tarball.
+func readSnapshotManifest(tr *tar.Reader) ([]disk.EntryInfo, error) {
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the snapshot: %w", err)
+	}
+	if hdr.Name != SnapshotManifestName {
+		return nil, fmt.Errorf("Expected the snapshot to start with %s, found %q",
+			SnapshotManifestName, hdr.Name)
+	}
+
+	var m snapshotManifest
+	err = json.NewDecoder(tr).Decode(&m)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the snapshot manifest: %w", err)
+	}
+
+	entries := make([]disk.EntryInfo, 0, len(m.Entries))
+	for _, me := range m.Entries {
+		kind, ok := parseEntryKind(me.Kind)
+		if !ok {
+			return nil, fmt.Errorf("Unknown entry kind in the snapshot manifest: %q", me.Kind)
+		}
+		if !validate.HashKeyRegex.MatchString(me.Hash) {
+			return nil, fmt.Errorf("Invalid hash in the snapshot manifest: %q", me.Hash)
+		}
+
+		entries = append(entries, disk.EntryInfo{
+			Kind:        kind,
+			Hash:        me.Hash,
+			LogicalSize: me.LogicalSize,
+			SizeOnDisk:  me.SizeOnDisk,
+			Random:      me.Random,
+			Legacy:      me.Legacy,
+		})
+	}
+
+	return entries, nil
+}
+
+func parseEntryKind(s string) (cache.EntryKind, bool) {
+	for _, kind := range []cache.EntryKind{cache.AC, cache.CAS, cache.RAW} {
+		if kind.String() == s {
+			return kind, true
+		}
+	}
+	return 0, false
+}
+
+// Remove all entries which were last accessed before the older_than
+// query parameter, an RFC 3339 timestamp or a date, optionally only of
+// the kinds given by kind parameters. The progress is streamed as one
+// JSON object per line, the last of which reports the final result.
+func (h *AdminHandler) handlePurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	before, err := parsePurgeTime(query.Get("older_than"))
+	if err != nil {
+		http.Error(w, "The older_than parameter must be an RFC 3339 timestamp or a YYYY-MM-DD date",
+			http.StatusBadRequest)
+		return
+	}
+
+	kinds, err := parseKindParams(query["kind"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	progress := func(stats disk.PurgeStats) {
+		if stats.Remaining == 0 {
+			return // The final result is written below.
+		}
+		// Keep purging if this fails, the client might just have
+		// gone away.
+		if enc.Encode(stats) == nil && flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	stats := h.cache.Purge(before, kinds, progress)
+	err = enc.Encode(stats)
+	if err != nil {
+		h.errorLogger.Printf("Failed to encode admin API response: %v", err)
+	}
+}
+
+// Parse kind query parameters, each of which can be a comma separated
+// list of kinds.
+func parseKindParams(values []string) ([]cache.EntryKind, error) {
+	var kinds []cache.EntryKind
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			kind, ok := parseEntryKind(strings.ToLower(name))
+			if !ok {
+				return nil, fmt.Errorf("Unknown kind: %q", html.EscapeString(name))
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds, nil
+}
+
+func parsePurgeTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// Reconcile the cache with the proxy backend, downloading the items
+// which were added to the backend within the optional download_window
+// query parameter, a duration like "24h". Report the drift which was
+// found, and how much of it was fixed.
+func (h *AdminHandler) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var opts disk.ReconcileOptions
+	if value := r.URL.Query().Get("download_window"); value != "" {
+		var err error
+		opts.DownloadWindow, err = time.ParseDuration(value)
+		if err != nil || opts.DownloadWindow < 0 {
+			http.Error(w, "The download_window parameter must be a non-negative duration, eg 24h",
+				http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.cache.Reconcile(r.Context(), opts)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if cerr, ok := err.(*cache.Error); ok {
+			code = cerr.Code
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	h.writeJSON(w, report)
+}
+
+// Reconcile the index with the shard directories of the kinds given by
+// kind parameters, or of all kinds, after files were added or removed by
+// something other than bazel-remote. The optional shard parameter, eg
+// "ab", limits the rescan to a single shard directory of each kind.
+// Report the changes which were made.
+func (h *AdminHandler) handleRescan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	kinds, err := parseKindParams(query["kind"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.cache.Rescan(kinds, query.Get("shard"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if cerr, ok := err.(*cache.Error); ok {
+			code = cerr.Code
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	h.writeJSON(w, stats)
+}
+
+// Show the effective configuration, in the format of a YAML config file.
+func (h *AdminHandler) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	_, err := w.Write(h.configYAML)
+	if err != nil {
+		h.errorLogger.Printf("Failed to write admin API response: %v", err)
+	}
+}
+
+func (h *AdminHandler) writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	err := enc.Encode(v)
+	if err != nil {
+		h.errorLogger.Printf("Failed to encode admin API response: %v", err)
+	}
+}
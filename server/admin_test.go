@@ -0,0 +1,892 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/maintenance"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+)
+
+func TestAdminMaintenance(t *testing.T) {
+	window := maintenance.NewWindow(nil, time.Hour)
+	h := NewAdminHandler(nil, window, nil, testutils.NewSilentLogger())
+
+	get := func(method string) maintenanceData {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, "/maintenance", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d", http.StatusOK, method, rr.Code)
+		}
+
+		var data maintenanceData
+		err := json.Unmarshal(rr.Body.Bytes(), &data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	if data := get(http.MethodGet); data.Active || data.NextWindow != 0 {
+		t.Errorf("Expected no maintenance window, got %+v", data)
+	}
+
+	if data := get(http.MethodPost); !data.Active {
+		t.Errorf("Expected POST to open a maintenance window, got %+v", data)
+	}
+
+	if !window.Active() {
+		t.Error("Expected the maintenance window to be open")
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/maintenance", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for DELETE, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+}
+
+func TestAdminStandby(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithStandby(),
+		disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+	hc := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
+
+	get := func(method string) standbyData {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, "/standby", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d", http.StatusOK, method, rr.Code)
+		}
+
+		var data standbyData
+		err := json.Unmarshal(rr.Body.Bytes(), &data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	status := func() int {
+		rr := httptest.NewRecorder()
+		hc.StatusPageHandler(rr, httptest.NewRequest(http.MethodGet, "/status", nil))
+		return rr.Code
+	}
+
+	if data := get(http.MethodGet); !data.Standby {
+		t.Errorf("Expected a standby, got %+v", data)
+	}
+	if code := status(); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the status page of a standby to have status %d, got %d",
+			http.StatusServiceUnavailable, code)
+	}
+
+	if data := get(http.MethodPost); data.Standby {
+		t.Errorf("Expected POST to promote the standby, got %+v", data)
+	}
+	if code := status(); code != http.StatusOK {
+		t.Errorf("Expected the status page to have status %d after promotion, got %d", http.StatusOK, code)
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/standby", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for DELETE, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+}
+
+// nopProxy is a cache.Proxy which contains nothing.
+type nopProxy struct{}
+
+func (p nopProxy) Put(ctx context.Context, kind cache.EntryKind, hash string, logicalSize int64, sizeOnDisk int64, rc io.ReadCloser) {
+	rc.Close()
+}
+
+func (p nopProxy) Get(ctx context.Context, kind cache.EntryKind, hash string) (io.ReadCloser, int64, error) {
+	return nil, -1, nil
+}
+
+func (p nopProxy) Contains(ctx context.Context, kind cache.EntryKind, hash string) (bool, int64) {
+	return false, -1
+}
+
+func TestAdminProxy(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithProxyBackend(nopProxy{}),
+		disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+
+	request := func(method string, target string, expectedCode int) []disk.ProxyBackendStatus {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, target, nil))
+		if rr.Code != expectedCode {
+			t.Fatalf("Expected status %d for %s %s, got %d", expectedCode, method, target, rr.Code)
+		}
+		if rr.Code != http.StatusOK {
+			return nil
+		}
+
+		var data []disk.ProxyBackendStatus
+		err := json.Unmarshal(rr.Body.Bytes(), &data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	data := request(http.MethodGet, "/proxy", http.StatusOK)
+	if len(data) != 1 || data[0].Name != cache.DefaultBackendName || !data[0].Enabled {
+		t.Errorf("Expected one enabled backend, got %+v", data)
+	}
+
+	data = request(http.MethodPost, "/proxy/disable", http.StatusOK)
+	if len(data) != 1 || data[0].Enabled {
+		t.Errorf("Expected POST to disable the backend, got %+v", data)
+	}
+
+	data = request(http.MethodPost, "/proxy/enable?backend="+cache.DefaultBackendName, http.StatusOK)
+	if len(data) != 1 || !data[0].Enabled {
+		t.Errorf("Expected POST to enable the backend, got %+v", data)
+	}
+
+	request(http.MethodPost, "/proxy/disable?backend=missing", http.StatusNotFound)
+	request(http.MethodGet, "/proxy/disable", http.StatusMethodNotAllowed)
+	request(http.MethodPost, "/proxy", http.StatusMethodNotAllowed)
+}
+
+func TestAdminEviction(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, hash := testutils.RandomDataAndHash(100)
+	err = c.Put(context.Background(), cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+
+	for _, query := range []string{"", "?target_size=-1", "?target_size=1GB"} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/eviction"+query, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %q, got %d", http.StatusBadRequest, query, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/eviction?target_size=0", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var report disk.EvictionReport
+	err = json.Unmarshal(rr.Body.Bytes(), &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.NumEntries != 1 || report.NumEntriesByKind["cas"] != 1 {
+		t.Errorf("Expected the blob to be evicted, got %+v", report)
+	}
+
+	found, _ := c.Contains(context.Background(), cache.CAS, hash, int64(len(data)))
+	if !found {
+		t.Error("Expected the blob to remain in the cache")
+	}
+}
+
+func TestAdminConfig(t *testing.T) {
+	configYAML := []byte("dir: /opt/cache-dir\nmax_size: 42\n")
+	h := NewAdminHandler(nil, maintenance.NewWindow(nil, time.Hour), configYAML, testutils.NewSilentLogger())
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/config", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if !bytes.Equal(rr.Body.Bytes(), configYAML) {
+		t.Errorf("Expected %q, got %q", configYAML, rr.Body.Bytes())
+	}
+}
+
+func TestAdminInstances(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize,
+		disk.WithAccessLogger(testutils.NewSilentLogger()),
+		disk.WithInstanceQuotas(map[string]int64{"team-a": 5 * disk.BlockSize}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, hash := testutils.RandomDataAndHash(100)
+	ctx := cache.WithInstanceName(context.Background(), "team-b")
+	err = c.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/instances", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var usage map[string]disk.InstanceUsage
+	err = json.Unmarshal(rr.Body.Bytes(), &usage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]disk.InstanceUsage{
+		"team-a": {QuotaBytes: 5 * disk.BlockSize},
+		"team-b": {SizeBytes: disk.BlockSize},
+	}
+	if !reflect.DeepEqual(usage, expected) {
+		t.Errorf("Expected %v, got %v", expected, usage)
+	}
+}
+
+func TestAdminUsage(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, hash := testutils.RandomDataAndHash(100)
+	err = c.Put(context.Background(), cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+
+	get := func(path string) []disk.DirectoryUsage {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d", http.StatusOK, path, rr.Code)
+		}
+
+		var usage []disk.DirectoryUsage
+		err := json.Unmarshal(rr.Body.Bytes(), &usage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return usage
+	}
+
+	entry := disk.DirectoryUsage{NumEntries: 1, SizeBytes: disk.BlockSize, LogicalBytes: int64(len(data))}
+	withPath := func(path string) disk.DirectoryUsage {
+		u := entry
+		u.Path = path
+		return u
+	}
+
+	expected := []disk.DirectoryUsage{withPath("."), withPath("cas.v2"), withPath("cas.v2/" + hash[:2])}
+	if usage := get("/usage"); !reflect.DeepEqual(usage, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, usage)
+	}
+	if usage := get("/usage?depth=0"); !reflect.DeepEqual(usage, expected[:1]) {
+		t.Errorf("Expected %+v, got %+v", expected[:1], usage)
+	}
+
+	for _, invalid := range []string{"-1", "3", "x"} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/usage?depth="+invalid, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for depth %s, got %d", http.StatusBadRequest, invalid, rr.Code)
+		}
+	}
+}
+
+func TestAdminChurn(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, hash := testutils.RandomDataAndHash(100)
+	err = c.Put(context.Background(), cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, _, err := c.Get(context.Background(), cache.CAS, hash, int64(len(data)), 0)
+	if err != nil || rc == nil {
+		t.Fatalf("Expected a cache hit, got %v", err)
+	}
+	rc.Close()
+
+	h := NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/churn", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var report disk.ChurnReport
+	err = json.Unmarshal(rr.Body.Bytes(), &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Kinds) != 3 || report.Kinds[1].Kind != "cas" {
+		t.Fatalf("Expected a report for each kind, got %+v", report)
+	}
+	cas := report.Kinds[1]
+	if cas.EntriesWritten != 1 || cas.BytesWritten != int64(len(data)) ||
+		cas.BytesRead != int64(len(data)) || cas.WriteAmplification != 1 {
+		t.Errorf("Expected one blob written and read, got %+v", cas)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/churn", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for POST, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+}
+
+func TestAdminInvocations(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize,
+		disk.WithAccessLogger(testutils.NewSilentLogger()),
+		disk.WithInvocationStats(time.Hour),
+		disk.WithInvocationTranscripts(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, hash := testutils.RandomDataAndHash(100)
+	ctx := cache.WithInvocationID(context.Background(), "build-1")
+	err = c.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, _ := c.Contains(ctx, cache.CAS, hash, int64(len(data)))
+	if !found {
+		t.Fatal("Expected the blob to be found")
+	}
+
+	h := NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+
+	get := func(url string, expectedCode int) []byte {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		if rr.Code != expectedCode {
+			t.Fatalf("Expected status %d for %s, got %d", expectedCode, url, rr.Code)
+		}
+		return rr.Body.Bytes()
+	}
+
+	var stats []disk.InvocationStats
+	err = json.Unmarshal(get("/invocations", http.StatusOK), &stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].InvocationID != "build-1" {
+		t.Fatalf("Expected stats for build-1, got %+v", stats)
+	}
+
+	var s disk.InvocationStats
+	err = json.Unmarshal(get("/invocations?id=build-1", http.StatusOK), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.CASHits != 1 || s.BytesWritten != int64(len(data)) {
+		t.Errorf("Expected one CAS hit and %d bytes written, got %+v", len(data), s)
+	}
+
+	get("/invocations?id=build-2", http.StatusNotFound)
+
+	var tr disk.InvocationTranscript
+	err = json.Unmarshal(get("/invocations/transcript?id=build-1", http.StatusOK), &tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.Entries) != 2 || tr.Entries[0].Op != "write" || tr.Entries[1].Result != "hit" ||
+		tr.Entries[1].Hash != hash {
+		t.Errorf("Expected a write and a read hit of %s, got %+v", hash, tr)
+	}
+
+	get("/invocations/transcript?id=build-2", http.StatusNotFound)
+	get("/invocations/transcript", http.StatusBadRequest)
+
+	// Without WithInvocationStats, no stats are collected.
+	disabledDir := testutils.TempDir(t)
+	defer os.RemoveAll(disabledDir)
+
+	h.cache, err = disk.New(disabledDir, 10*disk.BlockSize,
+		disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get("/invocations", http.StatusNotFound)
+	get("/invocations/transcript?id=build-1", http.StatusNotFound)
+}
+
+func TestAdminProvenance(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize,
+		disk.WithAccessLogger(testutils.NewSilentLogger()),
+		disk.WithProvenance())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, hash := testutils.RandomDataAndHash(100)
+	ctx := cache.WithUploader(context.Background(), "user:alice")
+	err = c.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+
+	get := func(url string, expectedCode int) []byte {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		if rr.Code != expectedCode {
+			t.Fatalf("Expected status %d for %s, got %d", expectedCode, url, rr.Code)
+		}
+		return rr.Body.Bytes()
+	}
+
+	var records []disk.ProvenanceRecord
+	err = json.Unmarshal(get("/provenance?hash="+hash, http.StatusOK), &records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Uploader != "user:alice" || records[0].Kind != "cas" {
+		t.Fatalf("Expected a CAS upload by alice, got %+v", records)
+	}
+
+	err = json.Unmarshal(get("/provenance?kind=ac&hash="+hash, http.StatusOK), &records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Errorf("Expected no AC records, got %+v", records)
+	}
+
+	get("/provenance?kind=foo&hash="+hash, http.StatusBadRequest)
+	get("/provenance?hash=invalid", http.StatusBadRequest)
+}
+
+func TestAdminSnapshot(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	casData, casHash := testutils.RandomDataAndHash(100)
+	err = c.Put(context.Background(), cache.CAS, casHash, int64(len(casData)), bytes.NewReader(casData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawData, rawHash := testutils.RandomDataAndHash(200)
+	err = c.Put(context.Background(), cache.RAW, rawHash, int64(len(rawData)), bytes.NewReader(rawData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/snapshot", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	// Extract the tarball into a new cache directory.
+	seedDir := testutils.TempDir(t)
+	defer os.RemoveAll(seedDir)
+
+	tr := tar.NewReader(rr.Body)
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr.Name != SnapshotManifestName {
+		t.Fatalf("Expected the tarball to start with %s, got %s", SnapshotManifestName, hdr.Name)
+	}
+	var m snapshotManifest
+	err = json.NewDecoder(tr).Decode(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 2 || m.Entries[0].Hash != casHash || m.Entries[1].Hash != rawHash {
+		t.Fatalf("Unexpected manifest entries: %+v", m.Entries)
+	}
+
+	for i := range m.Entries {
+		hdr, err = tr.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(hdr.Name, m.Entries[i].Hash) {
+			t.Errorf("Expected file %d to be for %s, got %s", i, m.Entries[i].Hash, hdr.Name)
+		}
+
+		name := filepath.Join(seedDir, filepath.FromSlash(hdr.Name))
+		err = os.MkdirAll(filepath.Dir(name), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(name, data, os.FileMode(hdr.Mode))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = tr.Next(); err != io.EOF {
+		t.Fatalf("Expected the end of the tarball, got %v", err)
+	}
+
+	seeded, err := disk.New(seedDir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for kind, hash := range map[cache.EntryKind]string{cache.CAS: casHash, cache.RAW: rawHash} {
+		if found, _ := seeded.Contains(context.Background(), kind, hash, -1); !found {
+			t.Errorf("Expected the %s entry %s in the seeded cache", kind, hash)
+		}
+	}
+}
+
+func TestAdminImport(t *testing.T) {
+	sourceDir := testutils.TempDir(t)
+	defer os.RemoveAll(sourceDir)
+	targetDir := testutils.TempDir(t)
+	defer os.RemoveAll(targetDir)
+
+	source, err := disk.New(sourceDir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := disk.New(targetDir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	casData, casHash := testutils.RandomDataAndHash(100)
+	for _, c := range []disk.Cache{source, target} {
+		err = c.Put(context.Background(), cache.CAS, casHash, int64(len(casData)), bytes.NewReader(casData))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	acData, acHash := testutils.RandomDataAndHash(200)
+	err = source.Put(context.Background(), cache.AC, acHash, int64(len(acData)), bytes.NewReader(acData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	NewAdminHandler(source, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger()).
+		ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/snapshot", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	h := NewAdminHandler(target, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+
+	snapshot := rr.Body.Bytes()
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/import", bytes.NewReader(snapshot)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var stats disk.ImportStats
+	err = json.NewDecoder(rr.Body).Decode(&stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := disk.ImportStats{Imported: 1, ImportedBytes: 200, Duplicates: 1}
+	if stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+	if found, _ := target.Contains(context.Background(), cache.AC, acHash, -1); !found {
+		t.Error("Expected the AC entry to be imported")
+	}
+
+	// Truncated snapshots are rejected.
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/import", bytes.NewReader(snapshot[:len(snapshot)/2])))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a truncated snapshot, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestAdminPurge(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kind := range []cache.EntryKind{cache.AC, cache.RAW} {
+		data, hash := testutils.RandomDataAndHash(100)
+		err = c.Put(context.Background(), kind, hash, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+
+	purge := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/purge?"+query, nil))
+		return rr
+	}
+
+	for _, query := range []string{"", "older_than=yesterday", "older_than=2023-01-01&kind=foo"} {
+		if rr := purge(query); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %q, got %d", http.StatusBadRequest, query, rr.Code)
+		}
+	}
+
+	// Nothing is older than this.
+	rr := purge("older_than=2023-01-01")
+	var stats disk.PurgeStats
+	err = json.NewDecoder(rr.Body).Decode(&stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (disk.PurgeStats{}) {
+		t.Errorf("Expected nothing to be purged, got %+v", stats)
+	}
+
+	tomorrow := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	rr = purge("older_than=" + tomorrow + "&kind=RAW")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	err = json.NewDecoder(rr.Body).Decode(&stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 1 || stats.Bytes != 100 {
+		t.Errorf("Expected the RAW entry to be purged, got %+v", stats)
+	}
+
+	_, _, numItems, _ := c.Stats()
+	if numItems != 1 {
+		t.Errorf("Expected the AC entry to remain, found %d entries", numItems)
+	}
+}
+
+func TestAdminReconcile(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+
+	reconcile := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/reconcile?"+query, nil))
+		return rr
+	}
+
+	if rr := reconcile("download_window=yesterday"); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid download window, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	// There is no proxy backend to reconcile with.
+	if rr := reconcile("download_window=24h"); rr.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status %d without a proxy backend, got %d", http.StatusNotImplemented, rr.Code)
+	}
+}
+
+func TestAdminRescan(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+
+	rescan := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/rescan?"+query, nil))
+		return rr
+	}
+
+	for _, query := range []string{"kind=foo", "shard=abc", "shard=AB"} {
+		if rr := rescan(query); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %q, got %d", http.StatusBadRequest, query, rr.Code)
+		}
+	}
+
+	rr := rescan("kind=cas,ac&shard=ab")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var stats disk.RescanStats
+	err = json.NewDecoder(rr.Body).Decode(&stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (disk.RescanStats{Shards: 2}) {
+		t.Errorf("Expected 2 empty shards to be rescanned, got %+v", stats)
+	}
+}
+
+func TestAdminUI(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithActivityTracking(),
+		disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, hash := testutils.RandomDataAndHash(100)
+	err = c.Put(context.Background(), cache.RAW, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, _ := c.Contains(context.Background(), cache.RAW, hash, -1)
+	if !found {
+		t.Fatal("Expected the entry to be found")
+	}
+
+	h := NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour), nil, testutils.NewSilentLogger())
+
+	// The UI is only served once enabled, and its requests are passed
+	// through the wrapper.
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d before enabling the UI, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	h.EnableUI(func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				http.Error(w, "Authorization required", http.StatusUnauthorized)
+				return
+			}
+			handler(w, r)
+		}
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Authorization", "test")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, r)
+		return rr
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without authorization, got %d", http.StatusUnauthorized, rr.Code)
+	}
+
+	rr = get("/ui/")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "<title>bazel-remote</title>") {
+		t.Errorf("Expected the UI page, got status %d", rr.Code)
+	}
+
+	rr = get("/ui/activity")
+	var activity uiActivity
+	err = json.Unmarshal(rr.Body.Bytes(), &activity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activity.NumItems != 1 || len(activity.HitRate) != 1 || activity.HitRate[0].Hits != 1 {
+		t.Errorf("Unexpected activity: %+v", activity)
+	}
+
+	rr = get("/ui/largest?n=5")
+	var largest []disk.EntrySummary
+	err = json.Unmarshal(rr.Body.Bytes(), &largest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(largest) != 1 || largest[0].Hash != hash {
+		t.Errorf("Expected the largest entry to be %s, got %+v", hash, largest)
+	}
+
+	testCases := []struct {
+		path     string
+		expected int
+	}{
+		{"/ui/largest?n=0", http.StatusBadRequest},
+		{"/ui/entry?kind=raw&hash=" + hash, http.StatusOK},
+		{"/ui/entry?kind=cas&hash=" + hash, http.StatusNotFound},
+		{"/ui/entry?kind=other&hash=" + hash, http.StatusBadRequest},
+		{"/ui/entry?kind=raw&hash=invalid", http.StatusBadRequest},
+		{"/ui/other", http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		rr = get(tc.path)
+		if rr.Code != tc.expected {
+			t.Errorf("Expected status %d for %s, got %d", tc.expected, tc.path, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	_ "embed"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+)
+
+// The admin UI is a single page, served from /ui/ on the admin address,
+// which shows the recent activity of the cache and lets operators look up
+// entries. It gets its data from the JSON endpoints below /ui/.
+
+//go:embed admin_ui.html
+var adminUIPage []byte
+
+// The default and maximum number of entries reported by /ui/largest.
+const (
+	defaultLargestEntries = 20
+	maxLargestEntries     = 1000
+)
+
+type uiActivity struct {
+	CurrSize int64 `json:"curr_size"`
+	MaxSize  int64 `json:"max_size"`
+	NumItems int   `json:"num_items"`
+
+	disk.Activity
+}
+
+// EnableUI serves the admin UI. Unlike the rest of the admin API, its
+// requests are passed through wrap, which must authenticate them.
+func (h *AdminHandler) EnableUI(wrap func(http.HandlerFunc) http.HandlerFunc) {
+	h.mux.HandleFunc("/ui/", wrap(h.handleUIPage))
+	h.mux.HandleFunc("/ui/activity", wrap(h.handleUIActivity))
+	h.mux.HandleFunc("/ui/largest", wrap(h.handleUILargest))
+	h.mux.HandleFunc("/ui/entry", wrap(h.handleUIEntry))
+}
+
+func (h *AdminHandler) handleUIPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+	if r.URL.Path != "/ui/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err := w.Write(adminUIPage)
+	if err != nil {
+		h.errorLogger.Printf("Failed to write admin UI response: %v", err)
+	}
+}
+
+// Report the size of the cache, its hit rate over time and the entries
+// which were evicted most recently.
+func (h *AdminHandler) handleUIActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	currSize, _, numItems, _ := h.cache.Stats()
+	h.writeJSON(w, uiActivity{
+		CurrSize: currSize,
+		MaxSize:  h.cache.MaxSize(),
+		NumItems: numItems,
+		Activity: h.cache.Activity(),
+	})
+}
+
+// Report the largest entries in the cache, as many as the optional n
+// query parameter.
+func (h *AdminHandler) handleUILargest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	n := defaultLargestEntries
+	if value := r.URL.Query().Get("n"); value != "" {
+		var err error
+		n, err = strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxLargestEntries {
+			http.Error(w, fmt.Sprintf("The n parameter must be a number from 1 to %d", maxLargestEntries),
+				http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.writeJSON(w, h.cache.LargestEntries(n))
+}
+
+// Describe the entry given by the kind and hash query parameters.
+func (h *AdminHandler) handleUIEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	kind, ok := parseEntryKind(query.Get("kind"))
+	if !ok {
+		http.Error(w, "The kind parameter must be ac, cas or raw", http.StatusBadRequest)
+		return
+	}
+	hash := query.Get("hash")
+	if !validate.HashKeyRegex.MatchString(hash) {
+		http.Error(w, "The hash parameter must be a SHA256 hash in hex", http.StatusBadRequest)
+		return
+	}
+
+	summary, found := h.cache.Describe(kind, hash)
+	if !found {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	h.writeJSON(w, summary)
+}
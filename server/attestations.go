@@ -0,0 +1,210 @@
+package server
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	"github.com/buchgr/bazel-remote/v2/utils/attest"
+)
+
+var attestationsPath = regexp.MustCompile("^/?(.*/)?attestations/([a-f0-9]{64})$")
+
+// The maximum size of an uploaded attestation.
+const maxAttestationSize = 1024 * 1024
+
+// The maximum number of attestations kept for a blob. When there are
+// more, the oldest ones are dropped.
+const maxAttestationsPerBlob = 64
+
+// Serves the attestations of CAS blobs, which are stored as a list of
+// DSSE envelopes in a RAW entry per blob.
+type attestationsHandler struct {
+	cache       disk.Cache
+	keys        []crypto.PublicKey
+	errorLogger cache.Logger
+
+	// Returns false after responding to requests without a required
+	// client certificate, see HTTPCache.CheckClientCert.
+	checkClientCert func(w http.ResponseWriter, r *http.Request) bool
+
+	// Serialize the updates of the attestations of a blob, striped by
+	// the first byte of its hash.
+	locks [256]sync.Mutex
+}
+
+// AttestationsHTTP wraps handler, and serves GET and PUT requests for
+// [<instance>/]attestations/<sha256> itself: PUT adds the DSSE envelope
+// in the request body to the attestations of the CAS blob with the
+// given hash, and GET returns them as a JSON list, oldest first. If
+// keys is not empty, uploaded envelopes must be signed by one of them.
+// checkClientCert is called first, like the client certificate checks
+// of CacheHandler for the other paths.
+func AttestationsHTTP(handler http.HandlerFunc, c disk.Cache, keys []crypto.PublicKey,
+	checkClientCert func(w http.ResponseWriter, r *http.Request) bool, errorLogger cache.Logger) http.HandlerFunc {
+
+	a := &attestationsHandler{
+		cache:           c,
+		keys:            keys,
+		errorLogger:     errorLogger,
+		checkClientCert: checkClientCert,
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		m := attestationsPath.FindStringSubmatch(r.URL.Path)
+		if m == nil {
+			handler(w, r)
+			return
+		}
+
+		if !a.checkClientCert(w, r) {
+			return
+		}
+
+		a.serveHTTP(w, r, strings.TrimSuffix(m[1], "/"), m[2])
+	}
+}
+
+// Returns the hash of the RAW entry which stores the attestations of
+// the CAS blob with the given hash.
+func attestationsKey(hash string) string {
+	sum := sha256.Sum256([]byte("attestations/" + hash))
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *attestationsHandler) serveHTTP(w http.ResponseWriter, r *http.Request, instance string, hash string) {
+	defer r.Body.Close()
+
+	switch r.Method {
+	case http.MethodGet:
+		envelopes, err := a.load(r, instance, hash)
+		if err != nil {
+			a.errorLogger.Printf("Failed to load the attestations of %s: %v", hash, err)
+			http.Error(w, "Failed to load the attestations", http.StatusInternalServerError)
+			return
+		}
+		if len(envelopes) == 0 {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(envelopes)
+		if err != nil {
+			a.errorLogger.Printf("Failed to write the attestations of %s: %v", hash, err)
+		}
+
+	case http.MethodPut:
+		a.handlePut(w, r, instance, hash)
+
+	default:
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+	}
+}
+
+// Returns the attestations of the blob with the given hash, or nil if it
+// has none.
+func (a *attestationsHandler) load(r *http.Request, instance string, hash string) ([]*attest.Envelope, error) {
+	ctx := cache.WithInstanceName(r.Context(), instance)
+	rc, _, err := a.cache.Get(ctx, cache.RAW, attestationsKey(hash), -1, 0)
+	if err != nil || rc == nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var envelopes []*attest.Envelope
+	err = json.NewDecoder(rc).Decode(&envelopes)
+	if err != nil {
+		return nil, err
+	}
+	return envelopes, nil
+}
+
+func (a *attestationsHandler) handlePut(w http.ResponseWriter, r *http.Request, instance string, hash string) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxAttestationSize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxAttestationSize {
+		msg := fmt.Sprintf("Attestation too large, the limit is %d bytes", maxAttestationSize)
+		http.Error(w, msg, http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	env, err := attest.ParseEnvelope(data, hash)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(a.keys) > 0 {
+		err = env.Verify(a.keys)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	added, err := json.Marshal(env)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	mu := &a.locks[hash[0]]
+	mu.Lock()
+	defer mu.Unlock()
+
+	envelopes, err := a.load(r, instance, hash)
+	if err != nil {
+		a.errorLogger.Printf("Failed to load the attestations of %s: %v", hash, err)
+		http.Error(w, "Failed to load the attestations", http.StatusInternalServerError)
+		return
+	}
+
+	for _, e := range envelopes {
+		existing, err := json.Marshal(e)
+		if err == nil && bytes.Equal(existing, added) {
+			// Already attached.
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	envelopes = append(envelopes, env)
+	if len(envelopes) > maxAttestationsPerBlob {
+		envelopes = envelopes[len(envelopes)-maxAttestationsPerBlob:]
+	}
+
+	list, err := json.Marshal(envelopes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ctx := cache.WithInstanceName(r.Context(), instance)
+	err = a.cache.Put(ctx, cache.RAW, attestationsKey(hash), int64(len(list)), bytes.NewReader(list))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if cerr, ok := err.(*cache.Error); ok {
+			code = cerr.Code
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/attest"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+)
+
+// Allows all requests, without client certificate checks.
+func allowAll(w http.ResponseWriter, r *http.Request) bool {
+	return true
+}
+
+// Returns an HTTPCache for c which requires client certificates for
+// writes, but not for reads, like with tls_ca_file and
+// allow_unauthenticated_reads.
+func newClientCertHTTPCache(c disk.Cache) HTTPCache {
+	return NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil,
+		false, true, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
+}
+
+// Returns r, as if it was sent with a verified client certificate.
+func withClientCert(r *http.Request) *http.Request {
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	return r
+}
+
+func TestAttestationsHTTP(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fallthroughs := 0
+	handler := AttestationsHTTP(func(w http.ResponseWriter, r *http.Request) {
+		fallthroughs++
+	}, c, []crypto.PublicKey{pub}, allowAll, testutils.NewSilentLogger())
+
+	_, hash := testutils.RandomDataAndHash(100)
+
+	envelope := func(subject string, predicate string, sign bool) []byte {
+		env := attest.Envelope{
+			PayloadType: attest.InTotoPayloadType,
+			Payload: []byte(`{"_type":"https://in-toto.io/Statement/v1","subject":[{"digest":{"sha256":"` +
+				subject + `"}}],"predicateType":"` + predicate + `"}`),
+			Signatures: []attest.Signature{{Sig: []byte("bogus")}},
+		}
+		if sign {
+			env.Signatures[0].Sig = ed25519.Sign(priv, env.PAE())
+		}
+		data, err := json.Marshal(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	do := func(method string, url string, body []byte, expectedCode int) []byte {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(method, url, bytes.NewReader(body)))
+		if rr.Code != expectedCode {
+			t.Fatalf("Expected status %d for %s %s, got %d: %s", expectedCode, method, url, rr.Code, rr.Body)
+		}
+		return rr.Body.Bytes()
+	}
+
+	url := "/attestations/" + hash
+	do(http.MethodGet, url, nil, http.StatusNotFound)
+
+	slsa := envelope(hash, "https://slsa.dev/provenance/v1", true)
+	do(http.MethodPut, url, slsa, http.StatusOK)
+	do(http.MethodPut, url, slsa, http.StatusOK)
+	do(http.MethodPut, "/instance/attestations/"+hash, envelope(hash, "https://example.com/test-result", true),
+		http.StatusOK)
+
+	var envelopes []attest.Envelope
+	err = json.Unmarshal(do(http.MethodGet, url, nil, http.StatusOK), &envelopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(envelopes) != 2 || !bytes.Contains(envelopes[0].Payload, []byte("slsa.dev")) {
+		t.Errorf("Expected the SLSA provenance and the test result attestations, got %d: %+v",
+			len(envelopes), envelopes)
+	}
+
+	// Unsigned attestations, and attestations of other blobs, are
+	// rejected.
+	do(http.MethodPut, url, envelope(hash, "https://slsa.dev/provenance/v1", false), http.StatusForbidden)
+	_, otherHash := testutils.RandomDataAndHash(100)
+	do(http.MethodPut, url, envelope(otherHash, "https://slsa.dev/provenance/v1", true), http.StatusBadRequest)
+	do(http.MethodPut, url, []byte("not json"), http.StatusBadRequest)
+	do(http.MethodPut, url, make([]byte, maxAttestationSize+1), http.StatusRequestEntityTooLarge)
+	do(http.MethodDelete, url, nil, http.StatusMethodNotAllowed)
+
+	// Other requests are passed through.
+	do(http.MethodGet, "/cas/"+hash, nil, http.StatusOK)
+	if fallthroughs != 1 {
+		t.Errorf("Expected 1 request to be passed through, got %d", fallthroughs)
+	}
+}
+
+func TestAttestationsHTTPClientCert(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := newClientCertHTTPCache(c)
+	handler := AttestationsHTTP(h.CacheHandler, c, nil, h.CheckClientCert, testutils.NewSilentLogger())
+
+	_, hash := testutils.RandomDataAndHash(100)
+	url := "/attestations/" + hash
+	env, err := json.Marshal(attest.Envelope{
+		PayloadType: attest.InTotoPayloadType,
+		Payload: []byte(`{"_type":"https://in-toto.io/Statement/v1","subject":[{"digest":{"sha256":"` +
+			hash + `"}}],"predicateType":"https://slsa.dev/provenance/v1"}`),
+		Signatures: []attest.Signature{{Sig: []byte("unchecked")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(r *http.Request, expectedCode int) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler(rr, r)
+		if rr.Code != expectedCode {
+			t.Fatalf("Expected status %d for %s %s, got %d: %s", expectedCode, r.Method, r.URL, rr.Code, rr.Body)
+		}
+	}
+
+	// Writes need a client certificate, even without signature checks.
+	do(httptest.NewRequest(http.MethodPut, url, bytes.NewReader(env)), http.StatusUnauthorized)
+	do(httptest.NewRequest(http.MethodGet, url, nil), http.StatusNotFound)
+
+	do(withClientCert(httptest.NewRequest(http.MethodPut, url, bytes.NewReader(env))), http.StatusOK)
+	do(httptest.NewRequest(http.MethodGet, url, nil), http.StatusOK)
+}
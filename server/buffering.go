@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	syncpool "github.com/mostynb/zstdpool-syncpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Most requests stream blob data between the client and the cache with
+// bounded memory. The exceptions are requests whose protocol requires
+// whole blobs in a single message, like BatchReadBlobs, BatchUpdateBlobs,
+// GetTree and AC entries. bufferUsage records how much blob data such a
+// request holds in memory, so that requests which use a lot of memory
+// show up in the metrics, before they cause an out of memory error.
+
+var peakBufferedBytes = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name: "bazel_remote_request_peak_buffered_bytes",
+	Help: "The peak amount of blob data held in memory by each request which buffers blobs, by method",
+	// 1 KiB to 1 GiB.
+	Buckets: prometheus.ExponentialBuckets(1024, 4, 11),
+}, []string{"method"})
+
+var bufferedBytes = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "bazel_remote_request_buffered_bytes",
+	Help: "The amount of blob data currently held in memory by requests which buffer blobs",
+})
+
+// bufferUsage tracks the blob data buffered by a single request. It is
+// not safe for concurrent use.
+type bufferUsage struct {
+	method  string
+	current int64
+	peak    int64
+}
+
+func newBufferUsage(method string) *bufferUsage {
+	return &bufferUsage{method: method}
+}
+
+// Record that n more bytes are buffered.
+func (b *bufferUsage) add(n int) {
+	b.current += int64(n)
+	bufferedBytes.Add(float64(n))
+	if b.current > b.peak {
+		b.peak = b.current
+	}
+}
+
+// Record that n bytes are no longer buffered.
+func (b *bufferUsage) release(n int) {
+	b.current -= int64(n)
+	bufferedBytes.Sub(float64(n))
+}
+
+// Release anything which is still buffered, and record the peak usage.
+// This should be called when the request has finished.
+func (b *bufferUsage) done() {
+	b.release(int(b.current))
+	peakBufferedBytes.WithLabelValues(b.method).Observe(float64(b.peak))
+}
+
+// Read all of r, or return an error if r has more than limit bytes. This
+// bounds the memory used to decompress data whose size is known.
+func readAllLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("the uncompressed data is larger than %d bytes", limit)
+	}
+	return data, nil
+}
+
+// Returns a reader of the uncompressed contents of the zstd compressed
+// data from r, which must be closed to return the decoder to the pool.
+func newZstdReadCloser(r io.Reader) (io.ReadCloser, error) {
+	dec, ok := decoderPool.Get().(*syncpool.DecoderWrapper)
+	if !ok {
+		return nil, errDecoderPoolFail
+	}
+	err := dec.Reset(r)
+	if err != nil {
+		dec.Close()
+		return nil, err
+	}
+	return dec.IOReadCloser(), nil
+}
+
+// Return the uncompressed contents of the zstd compressed data, or an
+// error if they are larger than limit bytes.
+func unzstd(data []byte, limit int64) ([]byte, error) {
+	rc, err := newZstdReadCloser(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return readAllLimited(rc, limit)
+}
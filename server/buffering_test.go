@@ -0,0 +1,55 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBufferUsage(t *testing.T) {
+	before := testutil.ToFloat64(bufferedBytes)
+
+	usage := newBufferUsage("test")
+	usage.add(100)
+	usage.add(50)
+	usage.release(120)
+	usage.add(10)
+
+	if usage.peak != 150 {
+		t.Fatal("Expected a peak of 150 bytes, got", usage.peak)
+	}
+	if buffered := testutil.ToFloat64(bufferedBytes) - before; buffered != 40 {
+		t.Fatal("Expected 40 buffered bytes, got", buffered)
+	}
+
+	usage.done()
+	if buffered := testutil.ToFloat64(bufferedBytes) - before; buffered != 0 {
+		t.Fatal("Expected no buffered bytes, got", buffered)
+	}
+}
+
+func TestUnzstdLimit(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 10000)
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed := enc.EncodeAll(data, nil)
+
+	found, err := unzstd(compressed, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(found, data) {
+		t.Fatal("Unexpected uncompressed data")
+	}
+
+	// The uncompressed data doesn't fit the limit, eg because the size
+	// of the blob was wrong or the data is a decompression bomb.
+	_, err = unzstd(compressed, int64(len(data)-1))
+	if err == nil {
+		t.Fatal("Expected an error for data larger than the limit")
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Browsers only let web pages on other origins read responses from
+// bazel-remote, eg to show cache statistics, if its HTTP responses allow
+// it with CORS (Cross-Origin Resource Sharing) headers.
+
+// The response headers which cross-origin clients are allowed to read,
+// besides the CORS-safelisted ones.
+var corsExposedHeaders = strings.Join([]string{
+	"Content-Encoding",
+	"Content-Length",
+	cacheSourceHeader,
+	storedCompressionHeader,
+	servedSizeHeader,
+}, ", ")
+
+type corsHandler struct {
+	handler        http.Handler
+	allowedOrigins map[string]bool // Or nil, to allow all origins.
+	allowedMethods map[string]bool
+	methods        string
+	maxAge         string
+}
+
+// CORS wraps handler, and adds CORS headers to the responses to requests
+// from allowedOrigins ("*" allows all origins), which may use
+// allowedMethods. Preflight requests are answered without calling
+// handler, and browsers may cache the answers for maxAge if it is
+// greater than zero. Requests from the listed origins may include
+// credentials, but requests allowed by "*" may not, so that any web page
+// can't use the credentials which a browser has stored for the cache.
+func CORS(handler http.Handler, allowedOrigins []string, allowedMethods []string, maxAge time.Duration) http.Handler {
+	h := &corsHandler{
+		handler:        handler,
+		allowedOrigins: make(map[string]bool, len(allowedOrigins)),
+		allowedMethods: make(map[string]bool, len(allowedMethods)),
+		methods:        strings.Join(allowedMethods, ", "),
+	}
+
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			h.allowedOrigins = nil
+			break
+		}
+		h.allowedOrigins[origin] = true
+	}
+
+	for _, method := range allowedMethods {
+		h.allowedMethods[method] = true
+	}
+
+	if maxAge > 0 {
+		h.maxAge = strconv.Itoa(int(maxAge.Seconds()))
+	}
+
+	return h
+}
+
+func (h *corsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// Not a cross-origin request.
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	// The response depends on the Origin header, even if it isn't
+	// allowed, so caches must not reuse it for other origins.
+	w.Header().Add("Vary", "Origin")
+
+	allowed := h.allowedOrigins == nil || h.allowedOrigins[origin]
+
+	requestMethod := r.Header.Get("Access-Control-Request-Method")
+	if r.Method == http.MethodOptions && requestMethod != "" {
+		// A preflight request, which browsers send without credentials,
+		// so it must be answered before authentication.
+		if !allowed || !h.allowedMethods[requestMethod] {
+			http.Error(w, "CORS request not allowed", http.StatusForbidden)
+			return
+		}
+
+		h.setAllowOrigin(w, origin)
+		w.Header().Set("Access-Control-Allow-Methods", h.methods)
+		if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		if h.maxAge != "" {
+			w.Header().Set("Access-Control-Max-Age", h.maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if allowed && h.allowedMethods[r.Method] {
+		h.setAllowOrigin(w, origin)
+		w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+	}
+
+	h.handler.ServeHTTP(w, r)
+}
+
+// Allows requests from origin, which is allowed, to read the response.
+func (h *corsHandler) setAllowOrigin(w http.ResponseWriter, origin string) {
+	if h.allowedOrigins == nil {
+		// Browsers don't send credentials to a wildcard origin.
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Credentials", "true")
+}
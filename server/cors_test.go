@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	called := false
+	handler := CORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}), []string{"https://ui.example.com"}, []string{http.MethodGet, http.MethodHead}, 10*time.Minute)
+
+	testCases := []struct {
+		name          string
+		method        string
+		origin        string
+		requestMethod string
+		status        int
+		allowOrigin   string
+		called        bool
+	}{
+		{"same origin", http.MethodGet, "", "", http.StatusOK, "", true},
+		{"allowed", http.MethodGet, "https://ui.example.com", "", http.StatusOK, "https://ui.example.com", true},
+		{"other origin", http.MethodGet, "https://other.example.com", "", http.StatusOK, "", true},
+		{"other method", http.MethodPut, "https://ui.example.com", "", http.StatusOK, "", true},
+		{"preflight", http.MethodOptions, "https://ui.example.com", http.MethodHead, http.StatusNoContent, "https://ui.example.com", false},
+		{"preflight other origin", http.MethodOptions, "https://other.example.com", http.MethodGet, http.StatusForbidden, "", false},
+		{"preflight other method", http.MethodOptions, "https://ui.example.com", http.MethodPut, http.StatusForbidden, "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			called = false
+			r := httptest.NewRequest(tc.method, "/status", nil)
+			if tc.origin != "" {
+				r.Header.Set("Origin", tc.origin)
+			}
+			if tc.requestMethod != "" {
+				r.Header.Set("Access-Control-Request-Method", tc.requestMethod)
+				r.Header.Set("Access-Control-Request-Headers", "authorization")
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, r)
+			if rr.Code != tc.status {
+				t.Errorf("Expected status %d, got %d", tc.status, rr.Code)
+			}
+			if called != tc.called {
+				t.Errorf("Expected the handler to be called: %v", tc.called)
+			}
+			if found := rr.Header().Get("Access-Control-Allow-Origin"); found != tc.allowOrigin {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tc.allowOrigin, found)
+			}
+			if tc.allowOrigin != "" && rr.Header().Get("Access-Control-Allow-Credentials") != "true" {
+				t.Error("Expected credentials to be allowed for a listed origin")
+			}
+			if tc.origin != "" && rr.Header().Get("Vary") != "Origin" {
+				t.Errorf("Expected Vary: Origin, got %q", rr.Header().Get("Vary"))
+			}
+
+			if tc.status == http.StatusNoContent {
+				expected := map[string]string{
+					"Access-Control-Allow-Methods": "GET, HEAD",
+					"Access-Control-Allow-Headers": "authorization",
+					"Access-Control-Max-Age":       "600",
+				}
+				for header, value := range expected {
+					if found := rr.Header().Get(header); found != value {
+						t.Errorf("Expected %s: %s, got %q", header, value, found)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCORSAllOrigins(t *testing.T) {
+	handler := CORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		[]string{"*"}, []string{http.MethodGet}, 0)
+
+	r := httptest.NewRequest(http.MethodGet, "/cas/0", nil)
+	r.Header.Set("Origin", "http://localhost:3000")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, r)
+	if found := rr.Header().Get("Access-Control-Allow-Origin"); found != "*" {
+		t.Errorf("Expected all origins to be allowed, got %q", found)
+	}
+	if found := rr.Header().Get("Access-Control-Allow-Credentials"); found != "" {
+		t.Errorf("Expected credentials not to be allowed for all origins, got %q", found)
+	}
+	if rr.Header().Get("Access-Control-Expose-Headers") == "" {
+		t.Error("Expected Access-Control-Expose-Headers to be set")
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/enroll"
+)
+
+// The maximum size of a certificate signing request.
+const maxCSRSize = 64 * 1024
+
+// EnrollHTTP returns a handler for POST requests with a PEM encoded
+// certificate signing request, and an enrollment token in an
+// "Authorization: Bearer <token>" header, which responds with a PEM
+// encoded client certificate signed by the issuer, followed by the CA
+// certificate. It is only served over TLS, so that the tokens are not
+// sent in plain text, and doesn't require client certificates itself.
+func EnrollHTTP(issuer *enroll.Issuer, accessLogger cache.Logger, errorLogger cache.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		if r.Method != http.MethodPost {
+			msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+			http.Error(w, msg, http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.TLS == nil {
+			http.Error(w, "Enrollment requires TLS", http.StatusForbidden)
+			return
+		}
+
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || !issuer.ValidToken(token) {
+			errorLogger.Printf("Rejected an enrollment request with an invalid token from %s", r.RemoteAddr)
+			http.Error(w, "Invalid enrollment token", http.StatusUnauthorized)
+			return
+		}
+
+		csr, err := io.ReadAll(io.LimitReader(r.Body, maxCSRSize+1))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(csr) > maxCSRSize {
+			msg := fmt.Sprintf("Certificate signing request too large, the limit is %d bytes", maxCSRSize)
+			http.Error(w, msg, http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		certPEM, cert, err := issuer.Issue(csr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		accessLogger.Printf("ENROLL %s: issued a client certificate for %q which expires at %s",
+			r.RemoteAddr, cert.Subject.CommonName, cert.NotAfter.UTC().Format(time.RFC3339))
+
+		w.Header().Set("Content-Type", "application/x-pem-file")
+		_, _ = w.Write(certPEM)
+	})
+}
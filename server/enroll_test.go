@@ -0,0 +1,108 @@
+package server
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/enroll"
+)
+
+func newTestIssuer(t *testing.T) *enroll.Issuer {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer, err := enroll.NewIssuer(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+		[]string{"secret-token"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return issuer
+}
+
+func TestEnrollHTTP(t *testing.T) {
+	handler := EnrollHTTP(newTestIssuer(t), testutils.NewSilentLogger(), testutils.NewSilentLogger())
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader,
+		&x509.CertificateRequest{Subject: pkix.Name{CommonName: "ci-agent-1"}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+
+	do := func(method string, authorization string, body []byte, useTLS bool, expectedCode int) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/enroll", bytes.NewReader(body))
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		if useTLS {
+			r.TLS = &tls.ConnectionState{}
+		}
+		handler.ServeHTTP(rr, r)
+		if rr.Code != expectedCode {
+			t.Fatalf("Expected status %d, got %d: %s", expectedCode, rr.Code, rr.Body)
+		}
+		return rr
+	}
+
+	do(http.MethodGet, "Bearer secret-token", nil, true, http.StatusMethodNotAllowed)
+	do(http.MethodPost, "Bearer secret-token", csr, false, http.StatusForbidden)
+	do(http.MethodPost, "", csr, true, http.StatusUnauthorized)
+	do(http.MethodPost, "Bearer wrong-token", csr, true, http.StatusUnauthorized)
+	do(http.MethodPost, "Basic secret-token", csr, true, http.StatusUnauthorized)
+	do(http.MethodPost, "Bearer secret-token", []byte("not a CSR"), true, http.StatusBadRequest)
+	do(http.MethodPost, "Bearer secret-token", make([]byte, maxCSRSize+1), true, http.StatusRequestEntityTooLarge)
+
+	rr := do(http.MethodPost, "Bearer secret-token", csr, true, http.StatusOK)
+	block, _ := pem.Decode(rr.Body.Bytes())
+	if block == nil || block.Type != "CERTIFICATE" {
+		t.Fatalf("Expected a PEM encoded certificate, got %s", rr.Body)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject.CommonName != "ci-agent-1" || !key.PublicKey.Equal(cert.PublicKey) {
+		t.Errorf("Expected a certificate for the requested name and key, got %+v", cert.Subject)
+	}
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"google.golang.org/genproto/googleapis/bytestream"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpc_status "google.golang.org/grpc/status"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+	"github.com/buchgr/bazel-remote/v2/utils/faults"
+)
+
+const injectedFaultMessage = "Injected fault"
+
+// Wait for the injected latency, or until ctx is done.
+func injectLatency(ctx context.Context, latency time.Duration) error {
+	if latency <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(latency)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Returns a copy of data with injected corruption. The data may be
+// shared, eg with the cache's memory mapped files, so it is not modified.
+func corruptCopy(data []byte) []byte {
+	corrupt := make([]byte, len(data))
+	copy(corrupt, data)
+	faults.CorruptBytes(corrupt)
+	return corrupt
+}
+
+// InjectFaultsHTTP wraps handler, and injects the faults which the
+// injector picks into the responses to GET, HEAD and PUT requests.
+func InjectFaultsHTTP(handler http.HandlerFunc, i *faults.Injector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodPut:
+		default:
+			handler(w, r)
+			return
+		}
+
+		f := i.Pick(r.Method)
+
+		err := injectLatency(r.Context(), f.Latency)
+		if err != nil {
+			return
+		}
+
+		if f.Error != 0 {
+			http.Error(w, injectedFaultMessage, f.Error)
+			return
+		}
+
+		if f.Corrupt {
+			// This hides the io.ReaderFrom implementation of w, so that
+			// the response body goes through Write.
+			w = &corruptResponseWriter{ResponseWriter: w}
+		}
+
+		handler(w, r)
+	}
+}
+
+type corruptResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w *corruptResponseWriter) Write(p []byte) (int, error) {
+	return w.ResponseWriter.Write(corruptCopy(p))
+}
+
+// Unwrap lets http.ResponseController reach the underlying
+// http.ResponseWriter.
+func (w *corruptResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// GrpcFaultInjector wraps a faults.Injector, and provides gRPC
+// interceptors that inject the faults which it picks into responses.
+type GrpcFaultInjector struct {
+	injector *faults.Injector
+}
+
+// NewGrpcFaultInjector returns a GrpcFaultInjector that wraps the given
+// faults.Injector.
+func NewGrpcFaultInjector(i *faults.Injector) *GrpcFaultInjector {
+	return &GrpcFaultInjector{injector: i}
+}
+
+// Returns the faults for a request to fullMethod, after waiting for the
+// injected latency. Returns an error if the request must fail.
+func (g *GrpcFaultInjector) inject(ctx context.Context, fullMethod string) (faults.Faults, error) {
+	if fullMethod == grpcHealthServiceName {
+		return faults.Faults{}, nil
+	}
+
+	f := g.injector.Pick(grpcEndpoint(fullMethod))
+
+	err := injectLatency(ctx, f.Latency)
+	if err != nil {
+		return f, grpc_status.FromContextError(err).Err()
+	}
+
+	if f.Error != 0 {
+		return f, grpc_status.Error(codes.Code(f.Error), injectedFaultMessage)
+	}
+
+	return f, nil
+}
+
+// StreamServerInterceptor injects faults into streaming requests, and
+// corrupts the data in ByteStream/Read responses.
+func (g *GrpcFaultInjector) StreamServerInterceptor(srv interface{},
+	ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+
+	f, err := g.inject(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	if f.Corrupt {
+		ss = &corruptServerStream{ServerStream: ss}
+	}
+
+	return handler(srv, ss)
+}
+
+// UnaryServerInterceptor injects faults into unary requests, and corrupts
+// the data in BatchReadBlobs responses.
+func (g *GrpcFaultInjector) UnaryServerInterceptor(ctx context.Context,
+	req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+
+	f, err := g.inject(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := handler(ctx, req)
+	if err != nil || !f.Corrupt {
+		return resp, err
+	}
+
+	if batch, ok := resp.(*pb.BatchReadBlobsResponse); ok {
+		for _, r := range batch.Responses {
+			r.Data = corruptCopy(r.Data)
+		}
+	}
+
+	return resp, nil
+}
+
+type corruptServerStream struct {
+	grpc.ServerStream
+}
+
+func (ss *corruptServerStream) SendMsg(m interface{}) error {
+	if resp, ok := m.(*bytestream.ReadResponse); ok {
+		m = &bytestream.ReadResponse{Data: corruptCopy(resp.Data)}
+	}
+	return ss.ServerStream.SendMsg(m)
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+	"github.com/buchgr/bazel-remote/v2/utils/faults"
+)
+
+func TestInjectFaultsHTTP(t *testing.T) {
+	data := []byte("some blob data")
+	i := faults.New([]faults.Rule{
+		{Endpoint: http.MethodGet, Corrupt: true, Probability: 1},
+		{Endpoint: http.MethodPut, Error: http.StatusServiceUnavailable, Probability: 1},
+	})
+
+	called := false
+	handler := InjectFaultsHTTP(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		_, _ = w.Write(data)
+	}, i)
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodPut, "/cas/abc", nil))
+	if rr.Code != http.StatusServiceUnavailable || called {
+		t.Fatalf("Expected an injected %d error, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, "/cas/abc", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if bytes.Equal(rr.Body.Bytes(), data) {
+		t.Fatal("Expected the response body to be corrupted")
+	}
+	if string(data) != "some blob data" {
+		t.Fatal("Expected the handler's data not to be modified")
+	}
+
+	rr = httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodHead, "/cas/abc", nil))
+	if rr.Code != http.StatusOK || !bytes.Equal(rr.Body.Bytes(), data) {
+		t.Fatal("Expected no faults for an endpoint without rules")
+	}
+}
+
+func TestGrpcFaultInjector(t *testing.T) {
+	gf := NewGrpcFaultInjector(faults.New([]faults.Rule{
+		{Endpoint: "ActionCache/GetActionResult", Error: int(codes.Unavailable), Probability: 1},
+		{Endpoint: "ContentAddressableStorage/BatchReadBlobs", Corrupt: true, Probability: 1},
+	}))
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/build.bazel.remote.execution.v2.ActionCache/GetActionResult"}
+	_, err := gf.UnaryServerInterceptor(context.Background(), nil, info,
+		func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected an injected UNAVAILABLE error, got %v", err)
+	}
+
+	data := []byte("some blob data")
+	info = &grpc.UnaryServerInfo{FullMethod: "/build.bazel.remote.execution.v2.ContentAddressableStorage/BatchReadBlobs"}
+	resp, err := gf.UnaryServerInterceptor(context.Background(), nil, info,
+		func(context.Context, interface{}) (interface{}, error) {
+			return &pb.BatchReadBlobsResponse{
+				Responses: []*pb.BatchReadBlobsResponse_Response{{Data: data}},
+			}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(resp.(*pb.BatchReadBlobsResponse).Responses[0].Data, data) {
+		t.Fatal("Expected the blob data to be corrupted")
+	}
+
+	// Health checks never fail.
+	info = &grpc.UnaryServerInfo{FullMethod: grpcHealthServiceName}
+	_, err = gf.UnaryServerInterceptor(context.Background(), nil, info,
+		func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+	if err != nil {
+		t.Fatalf("Expected no faults for health checks, got %v", err)
+	}
+}
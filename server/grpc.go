@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"time"
 
 	"google.golang.org/genproto/googleapis/bytestream"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
@@ -15,6 +17,8 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	asset "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/asset/v1"
 	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
@@ -41,6 +45,21 @@ type grpcServer struct {
 	errorLogger  cache.Logger
 	depsCheck    bool
 	mangleACKeys bool
+	limits       RequestLimits
+
+	// How absolute output symlink targets in uploaded ActionResults are
+	// handled.
+	symlinkPolicy validate.SymlinkPolicy
+
+	// The scheduler which Execute and WaitExecution requests are
+	// forwarded to, or nil if the Execution service is disabled.
+	execution pb.ExecutionClient
+
+	// The operations which are being forwarded from the scheduler.
+	operations executionOperations
+
+	// The blobs which BatchUpdateBlobs requests are writing.
+	uploads batchUploads
 }
 
 var readOnlyMethods = map[string]struct{}{
@@ -48,6 +67,7 @@ var readOnlyMethods = map[string]struct{}{
 	"/build.bazel.remote.execution.v2.ContentAddressableStorage/FindMissingBlobs": {},
 	"/build.bazel.remote.execution.v2.ContentAddressableStorage/BatchReadBlobs":   {},
 	"/build.bazel.remote.execution.v2.ContentAddressableStorage/GetTree":          {},
+	"/build.bazel.remote.execution.v2.ContentAddressableStorage/SplitBlob":        {},
 	"/build.bazel.remote.execution.v2.Capabilities/GetCapabilities":               {},
 	"/google.bytestream.ByteStream/Read":                                          {},
 }
@@ -61,6 +81,9 @@ func ListenAndServeGRPC(
 	validateACDeps bool,
 	mangleACKeys bool,
 	enableRemoteAssetAPI bool,
+	limits RequestLimits,
+	symlinkPolicy validate.SymlinkPolicy,
+	execution pb.ExecutionClient,
 	c disk.Cache, a cache.Logger, e cache.Logger) error {
 
 	listener, err := net.Listen(network, addr)
@@ -68,19 +91,27 @@ func ListenAndServeGRPC(
 		return err
 	}
 
-	return serveGRPC(listener, srv, validateACDeps, mangleACKeys, enableRemoteAssetAPI, c, a, e)
+	return ServeGRPC(listener, srv, validateACDeps, mangleACKeys, enableRemoteAssetAPI, limits, symlinkPolicy, execution, c, a, e)
 }
 
-func serveGRPC(l net.Listener, srv *grpc.Server,
+// ServeGRPC is like ListenAndServeGRPC, but uses an existing listener.
+func ServeGRPC(l net.Listener, srv *grpc.Server,
 	validateACDepsCheck bool,
 	mangleACKeys bool,
 	enableRemoteAssetAPI bool,
+	limits RequestLimits,
+	symlinkPolicy validate.SymlinkPolicy,
+	execution pb.ExecutionClient,
 	c disk.Cache, a cache.Logger, e cache.Logger) error {
 
 	s := &grpcServer{
 		cache: c, accessLogger: a, errorLogger: e,
 		depsCheck:    validateACDepsCheck,
 		mangleACKeys: mangleACKeys,
+		limits:       limits,
+
+		symlinkPolicy: symlinkPolicy,
+		execution:     execution,
 	}
 	pb.RegisterActionCacheServer(srv, s)
 	pb.RegisterCapabilitiesServer(srv, s)
@@ -89,14 +120,40 @@ func serveGRPC(l net.Listener, srv *grpc.Server,
 	if enableRemoteAssetAPI {
 		asset.RegisterFetchServer(srv, s)
 	}
+	if execution != nil {
+		pb.RegisterExecutionServer(srv, s)
+	}
 
 	h := health.NewServer()
 	grpc_health_v1.RegisterHealthServer(srv, h)
-	h.SetServingStatus(grpcHealthServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+	if c.Standby() {
+		h.SetServingStatus(grpcHealthServiceName, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+		go serveWhenPromoted(h, c)
+	} else {
+		h.SetServingStatus(grpcHealthServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+	}
 
 	return srv.Serve(l)
 }
 
+// How often a standby checks whether it was promoted, to update its
+// health status.
+const standbyPollInterval = time.Second
+
+// Report the health service as serving once the standby cache c has been
+// promoted, so that load balancers send clients to it.
+func serveWhenPromoted(h *health.Server, c disk.Cache) {
+	ticker := time.NewTicker(standbyPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !c.Standby() {
+			h.SetServingStatus(grpcHealthServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+			return
+		}
+	}
+}
+
 // Capabilities interface:
 
 func (s *grpcServer) GetCapabilities(ctx context.Context,
@@ -118,15 +175,28 @@ func (s *grpcServer) GetCapabilities(ctx context.Context,
 					},
 				},
 			},
-			MaxBatchTotalSizeBytes:          0, // "no limit"
+			MaxBatchTotalSizeBytes:          s.limits.BatchTotalSize, // 0 means "no limit"
 			SymlinkAbsolutePathStrategy:     pb.SymlinkAbsolutePathStrategy_ALLOWED,
 			SupportedCompressors:            []pb.Compressor_Value{pb.Compressor_ZSTD},
 			SupportedBatchUpdateCompressors: []pb.Compressor_Value{pb.Compressor_ZSTD},
+			SplitBlobSupport:                true,
+			SpliceBlobSupport:               true,
 		},
 		LowApiVersion:  &semver.SemVer{Major: int32(2)},
 		HighApiVersion: &semver.SemVer{Major: int32(2), Minor: int32(3)},
 	}
 
+	if !s.symlinkPolicy.AllowsAbsolute() {
+		resp.CacheCapabilities.SymlinkAbsolutePathStrategy = pb.SymlinkAbsolutePathStrategy_DISALLOWED
+	}
+
+	if s.execution != nil {
+		resp.ExecutionCapabilities = &pb.ExecutionCapabilities{
+			DigestFunction: pb.DigestFunction_SHA256,
+			ExecEnabled:    true,
+		}
+	}
+
 	s.accessLogger.Printf("GRPC GETCAPABILITIES")
 
 	return &resp, nil
@@ -242,6 +312,74 @@ func gRPCErrCode(err error, dflt codes.Code) codes.Code {
 	if ok && cerr.Code == http.StatusBadRequest {
 		return codes.InvalidArgument
 	}
+	if ok && cerr.Code == http.StatusServiceUnavailable {
+		return codes.Unavailable
+	}
+	if ok && cerr.Code == http.StatusConflict {
+		return codes.Aborted
+	}
+	if ok && (cerr.Code == http.StatusTooManyRequests || cerr.Code == http.StatusInsufficientStorage) {
+		return codes.ResourceExhausted
+	}
 
 	return dflt
 }
+
+// Return a gRPC status error with the given message for err, with a code
+// chosen by gRPCErrCode, and error details which help clients to react:
+// a RetryInfo detail for errors which are worth retrying later, eg while
+// too much data is being uploaded, and a QuotaFailure detail for errors
+// caused by a limit or quota.
+func gRPCCacheError(err error, dflt codes.Code, msg string) error {
+	return gRPCCacheStatus(err, dflt, msg).Err()
+}
+
+func gRPCCacheStatus(err error, dflt codes.Code, msg string) *status.Status {
+	code := gRPCErrCode(err, dflt)
+	st := status.New(code, msg)
+
+	if delay, ok := retryDelay(err); ok {
+		st = withDetail(st, &errdetails.RetryInfo{
+			RetryDelay: durationpb.New(delay),
+		})
+	}
+
+	cerr, ok := err.(*cache.Error)
+	if ok && cerr.QuotaSubject != "" {
+		st = withDetail(st, &errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{{
+				Subject:     cerr.QuotaSubject,
+				Description: cerr.Text,
+			}},
+		})
+	}
+
+	return st
+}
+
+// Return st with the detail added, or st if the detail can't be added.
+func withDetail(st *status.Status, detail protoiface.MessageV1) *status.Status {
+	withDetail, err := st.WithDetails(detail)
+	if err != nil {
+		return st
+	}
+	return withDetail
+}
+
+// Return a gRPC status error with the given code and message, and a
+// PreconditionFailure detail which lists the missing blobs, in the
+// "blobs/<hash>/<size>" form which the remote execution API uses for
+// missing inputs.
+func gRPCMissingBlobsError(code codes.Code, msg string, missing []*pb.Digest) error {
+	st := status.New(code, msg)
+
+	violations := make([]*errdetails.PreconditionFailure_Violation, 0, len(missing))
+	for _, digest := range missing {
+		violations = append(violations, &errdetails.PreconditionFailure_Violation{
+			Type:    "MISSING",
+			Subject: fmt.Sprintf("blobs/%s/%d", digest.Hash, digest.SizeBytes),
+		})
+	}
+
+	return withDetail(st, &errdetails.PreconditionFailure{Violations: violations}).Err()
+}
@@ -15,6 +15,7 @@ import (
 
 	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
@@ -53,10 +54,14 @@ func (s *grpcServer) GetActionResult(ctx context.Context,
 		return nil, errNilGetActionResultRequest
 	}
 
+	ctx = cache.WithInstanceName(ctx, req.InstanceName)
+
 	if req.ActionDigest == nil {
 		return nil, errNilActionDigest
 	}
 
+	ctx, lookup := cache.WithLookupResult(ctx)
+
 	if s.mangleACKeys {
 		req.ActionDigest.Hash = cache.TransformActionCacheKey(req.ActionDigest.Hash, req.InstanceName, s.accessLogger)
 	}
@@ -76,10 +81,11 @@ func (s *grpcServer) GetActionResult(ctx context.Context,
 		rdr, sizeBytes, err := s.cache.Get(ctx, cache.AC, req.ActionDigest.Hash, unknownActionResultSize, 0)
 		if err != nil {
 			s.accessLogger.Printf("%s %s %s", logPrefix, req.ActionDigest.Hash, err)
-			return nil, status.Error(codes.Unknown, err.Error())
+			return nil, gRPCCacheError(err, codes.Unknown, err.Error())
 		}
 		if rdr == nil || sizeBytes <= 0 {
 			s.accessLogger.Printf("%s %s %s", logPrefix, req.ActionDigest.Hash, "NOT FOUND")
+			_ = grpc.SetTrailer(ctx, lookupTrailer(&cache.LookupResult{}))
 			return nil, status.Error(codes.NotFound,
 				fmt.Sprintf("%s not found in AC", req.ActionDigest.Hash))
 		}
@@ -106,17 +112,19 @@ func (s *grpcServer) GetActionResult(ctx context.Context,
 		}
 
 		s.accessLogger.Printf("%s %s OK", logPrefix, req.ActionDigest.Hash)
+		_ = grpc.SetTrailer(ctx, lookupTrailer(lookup))
 		return result, nil
 	}
 
 	result, _, err := s.cache.GetValidatedActionResult(ctx, req.ActionDigest.Hash)
 	if err != nil {
 		s.accessLogger.Printf("%s %s %s", logPrefix, req.ActionDigest.Hash, err)
-		return nil, status.Error(codes.Unknown, err.Error())
+		return nil, gRPCCacheError(err, codes.Unknown, err.Error())
 	}
 
 	if result == nil {
 		s.accessLogger.Printf("%s %s NOT FOUND", logPrefix, req.ActionDigest.Hash)
+		_ = grpc.SetTrailer(ctx, lookupTrailer(&cache.LookupResult{}))
 		return nil, status.Error(codes.NotFound,
 			fmt.Sprintf("%s not found in AC", req.ActionDigest.Hash))
 	}
@@ -129,14 +137,14 @@ func (s *grpcServer) GetActionResult(ctx context.Context,
 		&result.StdoutRaw, &result.StdoutDigest, &inlinedSoFar)
 	if err != nil {
 		s.accessLogger.Printf("%s %s %s", logPrefix, req.ActionDigest.Hash, err)
-		return nil, status.Error(codes.Unknown, err.Error())
+		return nil, gRPCCacheError(err, codes.Unknown, err.Error())
 	}
 
 	err = s.maybeInline(ctx, req.InlineStderr,
 		&result.StderrRaw, &result.StderrDigest, &inlinedSoFar)
 	if err != nil {
 		s.accessLogger.Printf("%s %s %s", logPrefix, req.ActionDigest.Hash, err)
-		return nil, status.Error(codes.Unknown, err.Error())
+		return nil, gRPCCacheError(err, codes.Unknown, err.Error())
 	}
 
 	inlinableFiles := make(map[string]struct{}, len(req.InlineOutputFiles))
@@ -148,11 +156,12 @@ func (s *grpcServer) GetActionResult(ctx context.Context,
 		err = s.maybeInline(ctx, ok, &of.Contents, &of.Digest, &inlinedSoFar)
 		if err != nil {
 			s.accessLogger.Printf("%s %s %s", logPrefix, req.ActionDigest.Hash, err)
-			return nil, status.Error(codes.Unknown, err.Error())
+			return nil, gRPCCacheError(err, codes.Unknown, err.Error())
 		}
 	}
 
 	s.accessLogger.Printf("GRPC AC GET %s OK", req.ActionDigest.Hash)
+	_ = grpc.SetTrailer(ctx, lookupTrailer(lookup))
 
 	return result, nil
 }
@@ -224,6 +233,8 @@ func (s *grpcServer) UpdateActionResult(ctx context.Context,
 		return nil, errNilUpdateActionResultRequest
 	}
 
+	ctx = cache.WithInstanceName(ctx, req.InstanceName)
+
 	if req.ActionDigest == nil {
 		return nil, errNilActionDigest
 	}
@@ -243,6 +254,12 @@ func (s *grpcServer) UpdateActionResult(ctx context.Context,
 		return nil, err
 	}
 
+	err = validate.Symlinks(req.ActionResult, s.symlinkPolicy)
+	if err != nil {
+		s.accessLogger.Printf("%s %s %s", logPrefix, req.ActionDigest.Hash, err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	// Ensure that the serialized ActionResult has non-zero length.
 	addWorkerMetadataGRPC(ctx, req.ActionResult)
 
@@ -262,8 +279,7 @@ func (s *grpcServer) UpdateActionResult(ctx context.Context,
 		int64(len(data)), bytes.NewReader(data))
 	if err != nil && err != io.EOF {
 		s.accessLogger.Printf("%s %s %s", logPrefix, req.ActionDigest.Hash, err)
-		code := gRPCErrCode(err, codes.Internal)
-		return nil, status.Error(code, err.Error())
+		return nil, gRPCCacheError(err, codes.Internal, err.Error())
 	}
 
 	// Also cache any inlined blobs, separately in the CAS.
@@ -286,8 +302,7 @@ func (s *grpcServer) UpdateActionResult(ctx context.Context,
 				f.Digest.SizeBytes, bytes.NewReader(f.Contents))
 			if err != nil && err != io.EOF {
 				s.accessLogger.Printf("%s %s %s", logPrefix, req.ActionDigest.Hash, err)
-				code := gRPCErrCode(err, codes.Internal)
-				return nil, status.Error(code, err.Error())
+				return nil, gRPCCacheError(err, codes.Internal, err.Error())
 			}
 			s.accessLogger.Printf("GRPC CAS PUT %s OK", f.Digest.Hash)
 		}
@@ -309,8 +324,7 @@ func (s *grpcServer) UpdateActionResult(ctx context.Context,
 			bytes.NewReader(req.ActionResult.StdoutRaw))
 		if err != nil && err != io.EOF {
 			s.accessLogger.Printf("%s %s %s", logPrefix, req.ActionDigest.Hash, err)
-			code := gRPCErrCode(err, codes.Internal)
-			return nil, status.Error(code, err.Error())
+			return nil, gRPCCacheError(err, codes.Internal, err.Error())
 		}
 		s.accessLogger.Printf("GRPC CAS PUT %s OK", hash)
 	}
@@ -331,8 +345,7 @@ func (s *grpcServer) UpdateActionResult(ctx context.Context,
 			bytes.NewReader(req.ActionResult.StderrRaw))
 		if err != nil && err != io.EOF {
 			s.accessLogger.Printf("%s %s %s", logPrefix, req.ActionDigest.Hash, err)
-			code := gRPCErrCode(err, codes.Internal)
-			return nil, status.Error(code, err.Error())
+			return nil, gRPCCacheError(err, codes.Internal, err.Error())
 		}
 		s.accessLogger.Printf("GRPC CAS PUT %s OK", hash)
 	}
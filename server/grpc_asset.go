@@ -1,7 +1,6 @@
 package server
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
@@ -9,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
 
 	"google.golang.org/genproto/googleapis/rpc/status"
@@ -56,6 +56,8 @@ func (s *grpcServer) FetchBlob(ctx context.Context, req *asset.FetchBlobRequest)
 		return nil, errNilFetchBlobRequest
 	}
 
+	ctx = cache.WithInstanceName(ctx, req.InstanceName)
+
 	for _, q := range req.GetQualifiers() {
 		if q == nil {
 			return &asset.FetchBlobResponse{
@@ -161,17 +163,33 @@ func (s *grpcServer) fetchItem(ctx context.Context, uri string, expectedHash str
 
 	expectedSize := resp.ContentLength
 	if expectedHash == "" || expectedSize < 0 {
-		// We can't call Put until we know the hash and size.
+		// We can't call Put until we know the hash and size, so spool
+		// the data to a temporary file instead of holding it in memory.
+
+		tmp, err := os.CreateTemp("", "bazel-remote-fetch-")
+		if err != nil {
+			s.errorLogger.Printf("failed to create temporary file: %v", err)
+			return false, "", int64(-1)
+		}
+		defer func() {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}()
 
-		data, err := io.ReadAll(resp.Body)
+		hasher := sha256.New()
+		expectedSize, err = io.Copy(io.MultiWriter(tmp, hasher), resp.Body)
 		if err != nil {
 			s.errorLogger.Printf("failed to read data: %v", uri)
 			return false, "", int64(-1)
 		}
 
-		expectedSize = int64(len(data))
-		hashBytes := sha256.Sum256(data)
-		hashStr := hex.EncodeToString(hashBytes[:])
+		_, err = tmp.Seek(0, io.SeekStart)
+		if err != nil {
+			s.errorLogger.Printf("failed to read temporary file: %v", err)
+			return false, "", int64(-1)
+		}
+
+		hashStr := hex.EncodeToString(hasher.Sum(nil))
 
 		if expectedHash != "" && hashStr != expectedHash {
 			s.errorLogger.Printf("URI data has hash %s, expected %s",
@@ -180,7 +198,7 @@ func (s *grpcServer) fetchItem(ctx context.Context, uri string, expectedHash str
 		}
 
 		expectedHash = hashStr
-		rc = io.NopCloser(bytes.NewReader(data))
+		rc = tmp
 	}
 
 	err = s.cache.Put(ctx, cache.CAS, expectedHash, expectedSize, rc)
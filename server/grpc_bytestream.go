@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"strconv"
 	"strings"
 
 	"google.golang.org/genproto/googleapis/bytestream"
@@ -14,6 +13,7 @@ import (
 
 	"github.com/buchgr/bazel-remote/v2/cache"
 	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+	"github.com/buchgr/bazel-remote/v2/utils/bufpool"
 
 	"github.com/klauspost/compress/zstd"
 
@@ -106,10 +106,12 @@ func (s *grpcServer) Read(req *bytestream.ReadRequest,
 	var rc io.ReadCloser
 	var foundSize int64
 
+	ctx := cache.WithInstanceName(resp.Context(), resourceInstanceName(req.ResourceName))
+	ctx, lookup := cache.WithLookupResult(ctx)
 	if cmp == casblob.Zstandard {
-		rc, foundSize, err = s.cache.GetZstd(resp.Context(), hash, size, req.ReadOffset)
+		rc, foundSize, err = s.cache.GetZstd(ctx, hash, size, req.ReadOffset)
 	} else {
-		rc, foundSize, err = s.cache.Get(resp.Context(), cache.CAS, hash, size, req.ReadOffset)
+		rc, foundSize, err = s.cache.Get(ctx, cache.CAS, hash, size, req.ReadOffset)
 	}
 
 	if rc != nil {
@@ -119,9 +121,9 @@ func (s *grpcServer) Read(req *bytestream.ReadRequest,
 	if err != nil {
 		msg := fmt.Sprintf("GRPC BYTESTREAM READ FAILED: %s %v", hash, err)
 		s.accessLogger.Printf(msg)
-		code := gRPCErrCode(err, codes.Internal)
-		return status.Error(code, msg)
+		return gRPCCacheError(err, codes.Internal, msg)
 	}
+	resp.SetTrailer(lookupTrailer(lookup))
 	if rc == nil {
 		msg := fmt.Sprintf("GRPC BYTESTREAM READ BLOB NOT FOUND: %s", hash)
 		s.accessLogger.Printf(msg)
@@ -141,7 +143,9 @@ func (s *grpcServer) Read(req *bytestream.ReadRequest,
 		bufSize = maxChunkSize
 	}
 
-	buf := make([]byte, bufSize)
+	pooledBuf := bufpool.Get(int(bufSize))
+	defer bufpool.Put(pooledBuf)
+	buf := *pooledBuf
 
 	var chunkResp bytestream.ReadResponse
 	for {
@@ -181,180 +185,44 @@ func (s *grpcServer) Read(req *bytestream.ReadRequest,
 }
 
 // Parse a ReadRequest.ResourceName, return the validated hash, size, compression type and an error.
+// Parse a ReadRequest.ResourceName, and return the validated hash, size,
+// compression type and an optional error. See resource_name.go.
 func (s *grpcServer) parseReadResource(name string, errorPrefix string) (string, int64, casblob.CompressionType, error) {
+	rn, err := parseReadResourceName(name)
+	if err != nil {
+		s.accessLogger.Printf("%s: %s", errorPrefix, err)
+		return "", 0, casblob.Identity, err
+	}
 
-	// The resource name should be of the format:
-	// [{instance_name}]/blobs/{hash}/{size}
-	// Or:
-	// [{instance_name}]/compressed-blobs/{compressor}/{uncompressed_hash}/{uncompressed_size}
-
-	// Instance_name is ignored in this bytestream implementation, so don't
-	// bother returning it. It is not allowed to contain "blobs" as a distinct
-	// path segment.
+	return rn.hash, rn.size, rn.compression, nil
+}
 
+// Returns the instance name at the start of a read or write resource
+// name, or "" if there is none. Instance names cannot contain "blobs",
+// "compressed-blobs" or "uploads" as distinct path segments.
+func resourceInstanceName(name string) string {
 	fields := strings.Split(name, "/")
-	var rem []string
-	foundBlobs := false
-	foundCompressedBlobs := false
 	for i := range fields {
-		if fields[i] == "blobs" {
-			rem = fields[i+1:]
-			foundBlobs = true
-			break
-		}
-
-		if fields[i] == "compressed-blobs" {
-			rem = fields[i+1:]
-			foundCompressedBlobs = true
-			break
-		}
-	}
-
-	if foundBlobs {
-		if len(rem) != 2 {
-			msg := fmt.Sprintf("Unable to parse resource name: %s", name)
-			s.accessLogger.Printf("%s: %s", errorPrefix, msg)
-			return "", 0, casblob.Identity,
-				status.Error(codes.InvalidArgument, msg)
-		}
-
-		hash := rem[0]
-
-		size, err := strconv.ParseInt(rem[1], 10, 64)
-		if err != nil {
-			msg := fmt.Sprintf("Invalid size: %s from %q", rem[1], name)
-			s.accessLogger.Printf("%s: %s", errorPrefix, msg)
-			return "", 0, casblob.Identity,
-				status.Error(codes.InvalidArgument, msg)
-		}
-		if size < 0 {
-			msg := fmt.Sprintf("Invalid size (must be non-negative): %d from %q", size, name)
-			s.accessLogger.Printf("%s: %s", errorPrefix, msg)
-			return "", 0, casblob.Identity,
-				status.Error(codes.InvalidArgument, msg)
-		}
-
-		err = s.validateHash(hash, size, errorPrefix)
-		if err != nil {
-			return "", 0, casblob.Identity, err
+		switch fields[i] {
+		case "blobs", "compressed-blobs", "uploads":
+			return strings.Join(fields[:i], "/")
 		}
-
-		return hash, size, casblob.Identity, nil
-	}
-
-	if !foundCompressedBlobs || len(rem) != 3 {
-		msg := fmt.Sprintf("Unable to parse resource name: %s", name)
-		s.accessLogger.Printf("%s: %s", errorPrefix, msg)
-		return "", 0, casblob.Identity,
-			status.Error(codes.InvalidArgument, msg)
 	}
-
-	if rem[0] != "zstd" {
-		msg := fmt.Sprintf("Unable to parse compressor in resource name: %s", name)
-		s.accessLogger.Printf("%s: %s", errorPrefix, msg)
-		return "", 0, casblob.Identity,
-			status.Error(codes.InvalidArgument, msg)
-	}
-
-	hash := rem[1]
-	sizeStr := rem[2]
-
-	size, err := strconv.ParseInt(sizeStr, 10, 64)
-	if err != nil {
-		msg := fmt.Sprintf("Invalid size: %s from %q", sizeStr, name)
-		s.accessLogger.Printf("%s: %s", errorPrefix, msg)
-		return "", 0, casblob.Zstandard,
-			status.Error(codes.InvalidArgument, msg)
-	}
-	if size < 0 {
-		msg := fmt.Sprintf("Invalid size (must be non-negative): %d from %q", size, name)
-		s.accessLogger.Printf("%s: %s", errorPrefix, msg)
-		return "", 0, casblob.Zstandard,
-			status.Error(codes.InvalidArgument, msg)
-	}
-
-	err = s.validateHash(hash, size, errorPrefix)
-	if err != nil {
-		return "", 0, casblob.Zstandard, err
-	}
-
-	return hash, size, casblob.Zstandard, nil
+	return ""
 }
 
 // Parse a WriteRequest.ResourceName, return the validated hash, size,
-// compression type and an optional error.
+// compression type and an optional error. See resource_name.go.
 func (s *grpcServer) parseWriteResource(r string) (string, int64, casblob.CompressionType, error) {
-
-	// req.ResourceName is of the form:
-	// [{instance_name}/]uploads/{uuid}/blobs/{hash}/{size}[/{optionalmetadata}]
-	// Or, for compressed blobs:
-	// [{instance_name}/]uploads/{uuid}/compressed-blobs/{compressor}/{uncompressed_hash}/{uncompressed_size}[{/optional_metadata}]
-
-	fields := strings.Split(r, "/")
-	var rem []string
-	for i := range fields {
-		if fields[i] == "uploads" {
-			rem = fields[i+1:]
-			break
-		}
-	}
-
-	if len(rem) < 4 {
-		return "", 0, casblob.Identity,
-			status.Errorf(codes.InvalidArgument, "Unable to parse resource name: %s", r)
-	}
-
-	// rem[0] should hold the uuid, which we don't use- ignore it.
-
-	if rem[1] == "blobs" {
-		hash := rem[2]
-		size, err := strconv.ParseInt(rem[3], 10, 64)
-		if err != nil {
-			return "", 0, casblob.Identity,
-				status.Errorf(codes.InvalidArgument, "Unable to parse size: %s from %q", rem[3], r)
-		}
-
-		if size < 0 {
-			return "", 0, casblob.Identity,
-				status.Errorf(codes.InvalidArgument, "Invalid size (must be non-negative): %d from %q", size, r)
-		}
-
-		err = s.validateHash(hash, size, "GRPC BYTESTREAM READ FAILED")
-		if err != nil {
-			return "", 0, casblob.Identity, err
-		}
-
-		return hash, size, casblob.Identity, nil
-	}
-
-	if rem[1] != "compressed-blobs" || len(rem) < 5 || rem[2] != "zstd" {
-		return "", 0, casblob.Zstandard,
-			status.Errorf(codes.InvalidArgument, "Unable to parse resource name: %s", r)
-	}
-
-	sizeStr := rem[4]
-
-	size, err := strconv.ParseInt(sizeStr, 10, 64)
+	rn, err := parseWriteResourceName(r)
 	if err != nil {
-		return "", 0, casblob.Zstandard,
-			status.Errorf(codes.InvalidArgument, "Unable to parse size: %s from %q", sizeStr, r)
+		return "", 0, casblob.Identity, err
 	}
 
-	if size < 0 {
-		return "", 0, casblob.Zstandard,
-			status.Errorf(codes.InvalidArgument, "Invalid size (must be non-negative): %d from %q", size, r)
-	}
-
-	hash := rem[3]
-	err = s.validateHash(hash, size, "GRPC BYTESTREAM READ FAILED")
-	if err != nil {
-		return "", 0, casblob.Zstandard, err
-	}
-
-	return hash, size, casblob.Zstandard, nil
+	return rn.hash, rn.size, rn.compression, nil
 }
 
-var errWriteOffset error = errors.New("bytestream writes from non-zero offsets are unsupported")
+var errWriteOffset error = errors.New("compressed bytestream writes from non-zero offsets are unsupported")
 var errDecoderPoolFail error = errors.New("failed to get DecoderWrapper from pool")
 
 func (s *grpcServer) Write(srv bytestream.ByteStream_WriteServer) error {
@@ -367,6 +235,7 @@ func (s *grpcServer) Write(srv bytestream.ByteStream_WriteServer) error {
 	resourceNameChan := make(chan string, 1)
 
 	cmp := casblob.Identity
+	putStarted := false // Set by the receive loop before it sends to recvResult.
 	var dec *zstd.Decoder
 	defer func() {
 		if dec != nil {
@@ -428,8 +297,12 @@ func (s *grpcServer) Write(srv bytestream.ByteStream_WriteServer) error {
 					return
 				}
 
-				resp.CommittedSize = req.WriteOffset
-				if req.WriteOffset != 0 {
+				// Uncompressed uploads can be resumed from the size
+				// returned by QueryWriteStatus, if the cache kept the
+				// data, see disk.ResumablePut.
+				offset := req.WriteOffset
+				resp.CommittedSize = offset
+				if offset != 0 && cmp != casblob.Identity {
 					err = errWriteOffset
 					s.accessLogger.Printf("GRPC BYTESTREAM WRITE FAILED: %s", err)
 					recvResult <- err
@@ -453,8 +326,15 @@ func (s *grpcServer) Write(srv bytestream.ByteStream_WriteServer) error {
 					rc = dec.IOReadCloser()
 				}
 
+				ctx := cache.WithInstanceName(srv.Context(), resourceInstanceName(resourceName))
+				putStarted = true
 				go func() {
-					err := s.cache.Put(srv.Context(), cache.CAS, hash, size, rc)
+					var err error
+					if cmp == casblob.Identity {
+						err = s.cache.ResumablePut(ctx, hash, size, offset, rc)
+					} else {
+						err = s.cache.Put(ctx, cache.CAS, hash, size, rc)
+					}
 					putResult <- err
 				}()
 
@@ -522,6 +402,11 @@ func (s *grpcServer) Write(srv bytestream.ByteStream_WriteServer) error {
 			}
 
 			_ = pw.CloseWithError(err)
+			if putStarted {
+				// Let the cache keep the data which was received,
+				// before the client can try to resume the upload.
+				<-putResult
+			}
 			s.accessLogger.Printf("GRPC BYTESTREAM WRITE FAILED: %s %s",
 				resourceName, err.Error())
 			return err
@@ -553,7 +438,7 @@ func (s *grpcServer) Write(srv bytestream.ByteStream_WriteServer) error {
 
 		msg := fmt.Sprintf("GRPC BYTESTREAM WRITE CACHE ERROR: %s %v", resourceName, err)
 		s.accessLogger.Printf(msg)
-		return status.Error(codes.Internal, msg)
+		return gRPCCacheError(err, codes.Internal, msg)
 	}
 
 	select {
@@ -576,8 +461,7 @@ func (s *grpcServer) Write(srv bytestream.ByteStream_WriteServer) error {
 	if err != nil {
 		msg := fmt.Sprintf("GRPC BYTESTREAM WRITE FAILED: %s Cache Put failed: %v", resourceName, err)
 		s.accessLogger.Printf(msg)
-		code := gRPCErrCode(err, codes.Internal)
-		return status.Error(code, msg)
+		return gRPCCacheError(err, codes.Internal, msg)
 	}
 
 	err = srv.SendAndClose(&resp)
@@ -597,18 +481,23 @@ func (s *grpcServer) QueryWriteStatus(ctx context.Context, req *bytestream.Query
 		return nil, errNilQueryWriteStatusRequest
 	}
 
-	hash, size, _, err := s.parseWriteResource(req.ResourceName)
+	hash, size, cmp, err := s.parseWriteResource(req.ResourceName)
 	if err != nil {
 		return nil, err
 	}
 
-	// We don't support partial writes, so the status will either be fully written
-	// and complete, or 0 written and incomplete.
+	// The status is either fully written and complete, or incomplete with
+	// the size of the data which was kept from an interrupted upload, if
+	// any.
 
 	exists, _ := s.cache.Contains(ctx, cache.CAS, hash, size)
 
 	if !exists {
-		return &bytestream.QueryWriteStatusResponse{CommittedSize: 0, Complete: false}, nil
+		var committed int64
+		if cmp == casblob.Identity {
+			committed = s.cache.CommittedSize(hash, size)
+		}
+		return &bytestream.QueryWriteStatusResponse{CommittedSize: committed, Complete: false}, nil
 	}
 
 	return &bytestream.QueryWriteStatusResponse{CommittedSize: size, Complete: true}, nil
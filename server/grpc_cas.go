@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 
 	"google.golang.org/genproto/googleapis/rpc/code"
@@ -44,6 +45,11 @@ func (s *grpcServer) FindMissingBlobs(ctx context.Context,
 		return nil, errNilFindMissingBlobsRequest
 	}
 
+	err := checkDigestLimit("blob_digests", len(req.BlobDigests), s.limits.FindMissingDigests)
+	if err != nil {
+		return nil, err
+	}
+
 	errorPrefix := "GRPC CAS HEAD"
 	for _, digest := range req.BlobDigests {
 
@@ -57,6 +63,8 @@ func (s *grpcServer) FindMissingBlobs(ctx context.Context,
 		}
 	}
 
+	ctx = cache.WithInstanceName(ctx, req.InstanceName)
+
 	missingBlobs, err := s.cache.FindMissingCasBlobs(ctx, req.BlobDigests)
 	if err != nil {
 		return nil, err
@@ -72,59 +80,94 @@ func (s *grpcServer) BatchUpdateBlobs(ctx context.Context,
 		return nil, errNilBatchUpdateBlobsRequest
 	}
 
+	digests := make([]*pb.Digest, 0, len(in.Requests))
+	for _, req := range in.Requests {
+		if req != nil {
+			digests = append(digests, req.Digest)
+		}
+	}
+	err := s.limits.checkBatch("requests", digests)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = cache.WithInstanceName(ctx, in.InstanceName)
+
+	// The request's data was received in a single message.
+	usage := newBufferUsage("BatchUpdateBlobs")
+	defer usage.done()
+	for _, req := range in.Requests {
+		if req != nil {
+			usage.add(len(req.Data))
+		}
+	}
+
 	resp := pb.BatchUpdateBlobsResponse{
 		Responses: make([]*pb.BatchUpdateBlobsResponse_Response,
 			0, len(in.Requests)),
 	}
 
+	// The blobs which were written by this request, see grpc_uploads.go.
+	written := make(map[string]bool, len(in.Requests))
+
 	errorPrefix := "GRPC CAS PUT"
 	for _, req := range in.Requests {
 		// TODO: consider fanning-out goroutines here.
 
+		// Invalid digests fail with a status for the digest, so that
+		// the other blobs in the batch are still written.
+		rr := pb.BatchUpdateBlobsResponse_Response{Status: &status.Status{}}
+		resp.Responses = append(resp.Responses, &rr)
+
 		if req == nil {
-			return nil, errNilBatchUpdateBlobsRequest_Request
+			rr.Status = digestStatus(errNilBatchUpdateBlobsRequest_Request, codes.InvalidArgument)
+			continue
 		}
 
 		if req.Digest == nil {
-			return nil, errNilDigest
+			rr.Status = digestStatus(errNilDigest, codes.InvalidArgument)
+			continue
 		}
 
-		err := s.validateHash(req.Digest.Hash, req.Digest.SizeBytes, errorPrefix)
-		if err != nil {
-			return nil, err
+		rr.Digest = &pb.Digest{
+			Hash:      req.Digest.Hash,
+			SizeBytes: req.Digest.SizeBytes,
 		}
 
-		rr := pb.BatchUpdateBlobsResponse_Response{
-			Digest: &pb.Digest{
-				Hash:      req.Digest.Hash,
-				SizeBytes: req.Digest.SizeBytes,
-			},
-			Status: &status.Status{},
+		err := s.validateHash(req.Digest.Hash, req.Digest.SizeBytes, errorPrefix)
+		if err != nil {
+			rr.Status = digestStatus(err, codes.InvalidArgument)
+			continue
 		}
-		resp.Responses = append(resp.Responses, &rr)
 
 		if req.Compressor != pb.Compressor_IDENTITY && req.Compressor != pb.Compressor_ZSTD {
 			s.errorLogger.Printf("%s %s UNSUPPORTED COMPRESSOR: %s", errorPrefix, req.Digest.Hash, req.Compressor)
-			rr.Status.Code = int32(gRPCErrCode(err, codes.InvalidArgument))
+			rr.Status = digestStatus(fmt.Errorf("Unsupported compressor: %s", req.Compressor), codes.InvalidArgument)
+			continue
+		}
+
+		if req.Digest.SizeBytes == 0 && (len(req.Data) == 0 || req.Compressor == pb.Compressor_ZSTD) {
+			// The empty blob is always present, see validateHash.
+			s.accessLogger.Printf("GRPC CAS PUT %s OK", req.Digest.Hash)
 			continue
 		}
 
-		if req.Compressor == pb.Compressor_ZSTD {
-			req.Data, err = decoder.DecodeAll(req.Data, nil)
-			if err != nil {
-				s.errorLogger.Printf("%s %s %s", errorPrefix, req.Digest.Hash, err)
-				rr.Status.Code = int32(gRPCErrCode(err, codes.Internal))
-				continue
-			}
+		if written[req.Digest.Hash] {
+			s.accessLogger.Printf("GRPC CAS PUT %s OK, DUPLICATE", req.Digest.Hash)
+			continue
 		}
 
-		err = s.cache.Put(ctx, cache.CAS, req.Digest.Hash,
-			int64(len(req.Data)), bytes.NewReader(req.Data))
-		if err != nil && err != io.EOF {
+		finish, skip, err := s.uploads.begin(ctx, req.Digest.Hash)
+		if err == nil && !skip {
+			err = s.putBatchBlob(ctx, req)
+			finish(err)
+		}
+		if err != nil {
 			s.errorLogger.Printf("%s %s %s", errorPrefix, req.Digest.Hash, err)
-			rr.Status.Code = int32(gRPCErrCode(err, codes.Internal))
+			rr.Status = digestStatus(err, codes.Internal)
 			continue
 		}
+		written[req.Digest.Hash] = true
 
 		s.accessLogger.Printf("GRPC CAS PUT %s OK", req.Digest.Hash)
 	}
@@ -132,6 +175,38 @@ func (s *grpcServer) BatchUpdateBlobs(ctx context.Context,
 	return &resp, nil
 }
 
+// Returns the status of a single digest in a batch request which failed
+// with err, or dflt if err doesn't map to a gRPC code.
+func digestStatus(err error, dflt codes.Code) *status.Status {
+	if st, ok := grpc_status.FromError(err); ok {
+		return st.Proto()
+	}
+	return gRPCCacheStatus(err, dflt, err.Error()).Proto()
+}
+
+// Write a blob from a BatchUpdateBlobs request to the cache.
+func (s *grpcServer) putBatchBlob(ctx context.Context, req *pb.BatchUpdateBlobsRequest_Request) error {
+	var rdr io.Reader = bytes.NewReader(req.Data)
+	size := int64(len(req.Data))
+	if req.Compressor == pb.Compressor_ZSTD {
+		// Decompress the data while it is written, instead of
+		// holding the uncompressed blob in memory.
+		zrc, err := newZstdReadCloser(rdr)
+		if err != nil {
+			return err
+		}
+		defer zrc.Close()
+		rdr = zrc
+		size = req.Digest.SizeBytes
+	}
+
+	err := s.cache.Put(ctx, cache.CAS, req.Digest.Hash, size, rdr)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
 // Return the data for a blob, or an error.  If the blob was not
 // found, the returned error is errBlobNotFound. Only use this
 // function when it's OK to buffer the entire blob in memory.
@@ -176,33 +251,37 @@ func (s *grpcServer) getBlobResponse(ctx context.Context, digest *pb.Digest, all
 	var data []byte
 	var err error
 
-	if allowZstd {
+	// The empty blob is served uncompressed by getBlobData, since its
+	// compressed form is larger.
+	if allowZstd && digest.SizeBytes != 0 {
 		rc, foundSize, err := s.cache.GetZstd(ctx, digest.Hash, digest.SizeBytes, 0)
 		if rc != nil {
 			defer rc.Close()
 		}
-		if rc == nil || foundSize != digest.SizeBytes {
-			s.accessLogger.Printf("GRPC CAS GET %s NOT FOUND", digest.Hash)
-			r.Status = &status.Status{Code: int32(code.Code_NOT_FOUND)}
+		if err != nil {
+			s.errorLogger.Printf("GRPC CAS GET %s INTERNAL ERROR: %v", digest.Hash, err)
+			r.Status = digestStatus(err, codes.Internal)
 			return &r
 		}
 
-		if err != nil {
-			s.errorLogger.Printf("GRPC CAS GET %s INTERNAL ERROR: %v", digest.Hash, err)
-			r.Status = &status.Status{Code: int32(code.Code_INTERNAL)}
+		if rc == nil || foundSize != digest.SizeBytes {
+			s.accessLogger.Printf("GRPC CAS GET %s NOT FOUND", digest.Hash)
+			r.Status = &status.Status{Code: int32(code.Code_NOT_FOUND)}
 			return &r
 		}
 
 		data, err := io.ReadAll(rc)
 		if err != nil {
 			s.errorLogger.Printf("GRPC CAS GET %s INTERNAL ERROR: %v", digest.Hash, err)
-			r.Status = &status.Status{Code: int32(code.Code_INTERNAL)}
+			r.Status = digestStatus(err, codes.Internal)
 			return &r
 		}
 
 		r.Data = data
 		r.Compressor = pb.Compressor_ZSTD
 
+		s.accessLogger.Printf("GRPC CAS GET %s OK", digest.Hash)
+		r.Status = &status.Status{Code: int32(codes.OK)}
 		return &r
 	}
 
@@ -216,7 +295,7 @@ func (s *grpcServer) getBlobResponse(ctx context.Context, digest *pb.Digest, all
 	if err != nil {
 		s.errorLogger.Printf("GRPC CAS GET %s INTERNAL ERROR: %v",
 			digest.Hash, err)
-		r.Status = &status.Status{Code: int32(code.Code_INTERNAL)}
+		r.Status = digestStatus(err, codes.Internal)
 		return &r
 	}
 
@@ -235,6 +314,13 @@ func (s *grpcServer) BatchReadBlobs(ctx context.Context,
 		return nil, errNilBatchReadBlobsRequest
 	}
 
+	err := s.limits.checkBatch("digests", in.Digests)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = cache.WithInstanceName(ctx, in.InstanceName)
+
 	resp := pb.BatchReadBlobsResponse{
 		Responses: make([]*pb.BatchReadBlobsResponse_Response,
 			0, len(in.Digests)),
@@ -248,19 +334,34 @@ func (s *grpcServer) BatchReadBlobs(ctx context.Context,
 		}
 	}
 
+	// The response's data is sent in a single message.
+	usage := newBufferUsage("BatchReadBlobs")
+	defer usage.done()
+
 	errorPrefix := "GRPC CAS GET"
 	for _, digest := range in.Digests {
 		// TODO: consider fanning-out goroutines here.
 
+		// Invalid digests fail with a status for the digest, so that
+		// the other blobs in the batch are still read.
 		if digest == nil {
-			return nil, errNilDigest
+			resp.Responses = append(resp.Responses, &pb.BatchReadBlobsResponse_Response{
+				Status: digestStatus(errNilDigest, codes.InvalidArgument),
+			})
+			continue
 		}
 
 		err := s.validateHash(digest.Hash, digest.SizeBytes, errorPrefix)
 		if err != nil {
-			return nil, err
+			resp.Responses = append(resp.Responses, &pb.BatchReadBlobsResponse_Response{
+				Digest: digest,
+				Status: digestStatus(err, codes.InvalidArgument),
+			})
+			continue
 		}
-		resp.Responses = append(resp.Responses, s.getBlobResponse(ctx, digest, allowZstd))
+		r := s.getBlobResponse(ctx, digest, allowZstd)
+		usage.add(len(r.Data))
+		resp.Responses = append(resp.Responses, r)
 	}
 
 	return &resp, nil
@@ -287,7 +388,9 @@ func (s *grpcServer) GetTree(in *pb.GetTreeRequest,
 		return err
 	}
 
-	data, err := s.getBlobData(stream.Context(), in.RootDigest.Hash, in.RootDigest.SizeBytes)
+	ctx := cache.WithInstanceName(stream.Context(), in.InstanceName)
+
+	data, err := s.getBlobData(ctx, in.RootDigest.Hash, in.RootDigest.SizeBytes)
 	if err == errBlobNotFound {
 		s.accessLogger.Printf("GRPC CAS GETTREEREQUEST %s NOT FOUND",
 			in.RootDigest.Hash)
@@ -295,9 +398,13 @@ func (s *grpcServer) GetTree(in *pb.GetTreeRequest,
 	}
 	if err != nil {
 		s.accessLogger.Printf("%s %s %s", errorPrefix, in.RootDigest.Hash, err)
-		return grpc_status.Error(codes.Unknown, err.Error())
+		return gRPCCacheError(err, codes.Unknown, err.Error())
 	}
 
+	usage := newBufferUsage("GetTree")
+	defer usage.done()
+	usage.add(len(data))
+
 	dir := pb.Directory{}
 	err = proto.Unmarshal(data, &dir)
 	if err != nil {
@@ -305,7 +412,7 @@ func (s *grpcServer) GetTree(in *pb.GetTreeRequest,
 		return grpc_status.Error(codes.DataLoss, err.Error())
 	}
 
-	err = s.fillDirectories(stream.Context(), &resp, &dir, errorPrefix)
+	err = s.fillDirectories(ctx, &resp, &dir, usage, errorPrefix)
 	if err != nil {
 		return err
 	}
@@ -323,7 +430,7 @@ func (s *grpcServer) GetTree(in *pb.GetTreeRequest,
 
 // Attempt to populate `resp`. Return errors for invalid requests, but
 // otherwise attempt to return as many blobs as possible.
-func (s *grpcServer) fillDirectories(ctx context.Context, resp *pb.GetTreeResponse, dir *pb.Directory, errorPrefix string) error {
+func (s *grpcServer) fillDirectories(ctx context.Context, resp *pb.GetTreeResponse, dir *pb.Directory, usage *bufferUsage, errorPrefix string) error {
 
 	// Add this dir.
 	resp.Directories = append(resp.Directories, dir)
@@ -356,8 +463,9 @@ func (s *grpcServer) fillDirectories(ctx context.Context, resp *pb.GetTreeRespon
 
 		s.accessLogger.Printf("GRPC GETTREEREQUEST BLOB %s ADDED OK",
 			dirNode.Digest.Hash)
+		usage.add(len(data))
 
-		err = s.fillDirectories(ctx, resp, &dirMsg, errorPrefix)
+		err = s.fillDirectories(ctx, resp, &dirMsg, usage, errorPrefix)
 		if err != nil {
 			return err
 		}
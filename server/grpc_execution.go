@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+)
+
+// The experimental Execution service forwards Execute and WaitExecution
+// requests to an external scheduler, eg Buildbarn or Buildfarm, and
+// relays its operation updates back to the client. The CAS and action
+// cache are still served locally, so the scheduler and its workers must
+// use this bazel-remote as their cache.
+//
+// If the stream from the scheduler breaks before the operation is done,
+// eg because the scheduler restarted, the operation is followed again
+// with WaitExecution, so that clients don't have to retry the action.
+
+// The number of times in a row that a broken stream from the scheduler
+// is followed with WaitExecution, before the error is returned.
+const maxExecutionReconnects = 3
+
+var gaugeExecutionOperations = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "bazel_remote_execution_operations",
+	Help: "The number of remote execution operations which are being forwarded from the execution backend",
+})
+
+// The methods of the Execute and WaitExecution streams from the
+// scheduler which are used.
+type operationReceiver interface {
+	Recv() (*longrunning.Operation, error)
+}
+
+// The methods of the Execute and WaitExecution streams to the client
+// which are used.
+type operationSender interface {
+	Send(*longrunning.Operation) error
+}
+
+// executionOperations tracks the operations which are being forwarded,
+// by name. The zero value is ready to use.
+type executionOperations struct {
+	mu  sync.Mutex
+	ops map[string]*executionOperation
+}
+
+type executionOperation struct {
+	actionHash string
+	streams    int // The number of client streams following the operation.
+}
+
+// Register a client stream which follows the operation with the given
+// name, for the action with the given hash. If actionHash is empty, the
+// hash is taken from an earlier stream for the operation, if there is
+// one. Returns the action hash, and a function to call when the stream
+// ends.
+func (o *executionOperations) follow(name string, actionHash string) (string, func()) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	op, found := o.ops[name]
+	if !found {
+		if o.ops == nil {
+			o.ops = make(map[string]*executionOperation)
+		}
+		op = &executionOperation{actionHash: actionHash}
+		o.ops[name] = op
+		gaugeExecutionOperations.Inc()
+	} else if op.actionHash == "" {
+		op.actionHash = actionHash
+	}
+	op.streams++
+
+	unfollow := func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+
+		op.streams--
+		if op.streams == 0 {
+			delete(o.ops, name)
+			gaugeExecutionOperations.Dec()
+		}
+	}
+
+	return op.actionHash, unfollow
+}
+
+// Returns a context for requests to the scheduler, with the
+// RequestMetadata and other REAPI headers which the client sent, but not
+// its credentials.
+func executionContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	forwarded := metadata.MD{}
+	for key, values := range md {
+		if strings.HasPrefix(key, "build.bazel.remote.execution.v2.") {
+			forwarded[key] = values
+		}
+	}
+
+	return metadata.NewOutgoingContext(ctx, forwarded)
+}
+
+// Execution interface:
+
+func (s *grpcServer) Execute(req *pb.ExecuteRequest, srv pb.Execution_ExecuteServer) error {
+	logPrefix := "GRPC EXECUTE"
+
+	if req.ActionDigest == nil {
+		return errNilActionDigest
+	}
+
+	err := s.validateHash(req.ActionDigest.Hash, req.ActionDigest.SizeBytes, logPrefix)
+	if err != nil {
+		return err
+	}
+
+	ctx := executionContext(srv.Context())
+	stream, err := s.execution.Execute(ctx, req)
+	if err != nil {
+		s.accessLogger.Printf("%s %s %s", logPrefix, req.ActionDigest.Hash, err)
+		return err
+	}
+
+	return s.forwardOperations(ctx, logPrefix, "", req.ActionDigest.Hash, stream, srv)
+}
+
+func (s *grpcServer) WaitExecution(req *pb.WaitExecutionRequest, srv pb.Execution_WaitExecutionServer) error {
+	logPrefix := "GRPC WAITEXECUTION"
+
+	if req.Name == "" {
+		return status.Error(codes.InvalidArgument, "expected an operation name")
+	}
+
+	ctx := executionContext(srv.Context())
+	stream, err := s.execution.WaitExecution(ctx, req)
+	if err != nil {
+		s.accessLogger.Printf("%s %s %s", logPrefix, req.Name, err)
+		return err
+	}
+
+	return s.forwardOperations(ctx, logPrefix, req.Name, "", stream, srv)
+}
+
+// Send the operation updates from stream to srv, until the operation is
+// done. name is the operation's name, if it is already known, and
+// actionHash is the hash of its action, if it is known.
+func (s *grpcServer) forwardOperations(ctx context.Context, logPrefix string,
+	name string, actionHash string, stream operationReceiver, srv operationSender) error {
+
+	unfollow := func() {}
+	defer func() { unfollow() }()
+	if name != "" {
+		actionHash, unfollow = s.operations.follow(name, actionHash)
+	}
+
+	reconnects := 0
+	for {
+		op, err := stream.Recv()
+		if err == io.EOF {
+			err = status.Error(codes.Unavailable,
+				"the execution backend ended the stream before the operation was done")
+		}
+		if err != nil {
+			if name == "" || ctx.Err() != nil || status.Code(err) != codes.Unavailable ||
+				reconnects == maxExecutionReconnects {
+				s.accessLogger.Printf("%s %s %s %s", logPrefix, actionHash, name, err)
+				return err
+			}
+
+			reconnects++
+			s.errorLogger.Printf("%s %s: following operation %s again after: %v",
+				logPrefix, actionHash, name, err)
+			stream, err = s.execution.WaitExecution(ctx, &pb.WaitExecutionRequest{Name: name})
+			if err != nil {
+				s.accessLogger.Printf("%s %s %s %s", logPrefix, actionHash, name, err)
+				return err
+			}
+			continue
+		}
+		reconnects = 0
+
+		if name == "" && op.Name != "" {
+			name = op.Name
+			actionHash, unfollow = s.operations.follow(name, actionHash)
+		}
+
+		err = srv.Send(op)
+		if err != nil {
+			s.accessLogger.Printf("%s %s %s %s", logPrefix, actionHash, name, err)
+			return err
+		}
+
+		if op.Done {
+			s.accessLogger.Printf("%s %s %s %s", logPrefix, actionHash, name, operationResult(op))
+			return nil
+		}
+	}
+}
+
+// Returns a summary of the result of a finished operation, for the
+// access log.
+func operationResult(op *longrunning.Operation) string {
+	if op.GetError() != nil {
+		return codes.Code(op.GetError().Code).String()
+	}
+
+	var resp pb.ExecuteResponse
+	if op.GetResponse() == nil || op.GetResponse().UnmarshalTo(&resp) != nil {
+		return "DONE"
+	}
+	if resp.Status != nil && codes.Code(resp.Status.Code) != codes.OK {
+		return codes.Code(resp.Status.Code).String()
+	}
+	if resp.CachedResult {
+		return "OK, CACHED"
+	}
+	return "OK"
+}
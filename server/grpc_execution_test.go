@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+)
+
+// A scheduler which sends the first breakAfter operations of ops in
+// response to Execute, then fails with UNAVAILABLE, and sends the rest in
+// response to WaitExecution.
+type fakeScheduler struct {
+	ops        []*longrunning.Operation
+	breakAfter int
+
+	metadata     metadata.MD
+	waitRequests []string
+}
+
+func (f *fakeScheduler) Execute(req *pb.ExecuteRequest, srv pb.Execution_ExecuteServer) error {
+	f.metadata, _ = metadata.FromIncomingContext(srv.Context())
+
+	for _, op := range f.ops[:f.breakAfter] {
+		err := srv.Send(op)
+		if err != nil {
+			return err
+		}
+	}
+	if f.breakAfter < len(f.ops) {
+		return status.Error(codes.Unavailable, "scheduler restarting")
+	}
+	return nil
+}
+
+func (f *fakeScheduler) WaitExecution(req *pb.WaitExecutionRequest, srv pb.Execution_WaitExecutionServer) error {
+	f.waitRequests = append(f.waitRequests, req.Name)
+
+	for _, op := range f.ops[f.breakAfter:] {
+		err := srv.Send(op)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bufconnDial(t *testing.T, listener *bufconn.Listener) *grpc.ClientConn {
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func executionTestSetup(t *testing.T, scheduler *fakeScheduler) *grpc.ClientConn {
+	dir := testutils.TempDir(t)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	diskCache, err := disk.New(dir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schedulerListener := bufconn.Listen(1024 * 1024)
+	schedulerSrv := grpc.NewServer()
+	pb.RegisterExecutionServer(schedulerSrv, scheduler)
+	go func() {
+		_ = schedulerSrv.Serve(schedulerListener)
+	}()
+	t.Cleanup(schedulerSrv.Stop)
+	backend := bufconnDial(t, schedulerListener)
+
+	listener := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	go func() {
+		_ = ServeGRPC(listener, srv, true, false, false, RequestLimits{},
+			validate.SymlinksAllow, pb.NewExecutionClient(backend), diskCache,
+			testutils.NewSilentLogger(), testutils.NewSilentLogger())
+	}()
+	t.Cleanup(srv.Stop)
+
+	return bufconnDial(t, listener)
+}
+
+func testOperations(t *testing.T) []*longrunning.Operation {
+	resp, err := anypb.New(&pb.ExecuteResponse{
+		Result: &pb.ActionResult{ExitCode: 0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return []*longrunning.Operation{
+		{Name: "operations/1"},
+		{Name: "operations/1"},
+		{
+			Name:   "operations/1",
+			Done:   true,
+			Result: &longrunning.Operation_Response{Response: resp},
+		},
+	}
+}
+
+func receiveOperations(t *testing.T, stream pb.Execution_ExecuteClient) []*longrunning.Operation {
+	var ops []*longrunning.Operation
+	for {
+		op, err := stream.Recv()
+		if err == io.EOF {
+			return ops
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		ops = append(ops, op)
+	}
+}
+
+func TestGrpcExecuteForwarding(t *testing.T) {
+	for _, breakAfter := range []int{3, 1} {
+		scheduler := &fakeScheduler{ops: testOperations(t), breakAfter: breakAfter}
+		conn := executionTestSetup(t, scheduler)
+
+		caps, err := pb.NewCapabilitiesClient(conn).GetCapabilities(ctx, &pb.GetCapabilitiesRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !caps.GetExecutionCapabilities().GetExecEnabled() {
+			t.Error("Expected execution to be enabled in the capabilities")
+		}
+
+		_, hash := testutils.RandomDataAndHash(64)
+		md := metadata.Pairs(
+			"build.bazel.remote.execution.v2.requestmetadata-bin", "metadata",
+			"authorization", "Basic secret")
+		stream, err := pb.NewExecutionClient(conn).Execute(metadata.NewOutgoingContext(ctx, md),
+			&pb.ExecuteRequest{ActionDigest: &pb.Digest{Hash: hash, SizeBytes: 64}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ops := receiveOperations(t, stream)
+		if len(ops) != len(scheduler.ops) || !ops[len(ops)-1].Done {
+			t.Fatalf("Expected %d operations ending with a done one, got %v", len(scheduler.ops), ops)
+		}
+
+		if breakAfter < len(scheduler.ops) {
+			if len(scheduler.waitRequests) != 1 || scheduler.waitRequests[0] != "operations/1" {
+				t.Errorf("Expected the operation to be followed with WaitExecution, got %v",
+					scheduler.waitRequests)
+			}
+		} else if len(scheduler.waitRequests) != 0 {
+			t.Errorf("Expected no WaitExecution requests, got %v", scheduler.waitRequests)
+		}
+
+		if len(scheduler.metadata.Get("build.bazel.remote.execution.v2.requestmetadata-bin")) != 1 {
+			t.Error("Expected the request metadata to be forwarded")
+		}
+		if len(scheduler.metadata.Get("authorization")) != 0 {
+			t.Error("Expected the client's credentials not to be forwarded")
+		}
+	}
+}
+
+func TestGrpcExecuteInvalidDigest(t *testing.T) {
+	conn := executionTestSetup(t, &fakeScheduler{ops: testOperations(t), breakAfter: 3})
+
+	for _, tc := range badDigestTestCases {
+		stream, err := pb.NewExecutionClient(conn).Execute(ctx, &pb.ExecuteRequest{ActionDigest: tc.digest})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		checkBadDigestErr(t, err, tc)
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+)
+
+// The gRPC metadata key which clients like Bazel send a serialized
+// RequestMetadata message in, as described in remote_execution.proto.
+const requestMetadataKey = "build.bazel.remote.execution.v2.requestmetadata-bin"
+
+// Returns ctx with the tool invocation ID from the request's
+// RequestMetadata recorded by cache.WithInvocationID, if there is one.
+func withRequestMetadata(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	values := md.Get(requestMetadataKey)
+	if len(values) == 0 {
+		return ctx
+	}
+
+	var rm pb.RequestMetadata
+	err := proto.Unmarshal([]byte(values[0]), &rm)
+	if err != nil || rm.ToolInvocationId == "" {
+		return ctx
+	}
+
+	return cache.WithInvocationID(ctx, rm.ToolInvocationId)
+}
+
+// A grpc.ServerStream with a replaced context.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// RequestMetadataStreamServerInterceptor records the tool invocation ID
+// from the RequestMetadata of streaming requests in their context, so
+// that cache statistics can be collected per invocation.
+func RequestMetadataStreamServerInterceptor(srv interface{},
+	ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+
+	ctx := withRequestMetadata(ss.Context())
+	if ctx == ss.Context() {
+		return handler(srv, ss)
+	}
+
+	return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+}
+
+// RequestMetadataUnaryServerInterceptor records the tool invocation ID
+// from the RequestMetadata of unary requests in their context, so that
+// cache statistics can be collected per invocation.
+func RequestMetadataUnaryServerInterceptor(ctx context.Context,
+	req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+
+	return handler(withRequestMetadata(ctx), req)
+}
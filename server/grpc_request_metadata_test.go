@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+)
+
+func TestRequestMetadataInterceptor(t *testing.T) {
+	rm, err := proto.Marshal(&pb.RequestMetadata{ToolInvocationId: "build-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	intercept := func(ctx context.Context) string {
+		var id string
+		_, err := RequestMetadataUnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				id = cache.InvocationID(ctx)
+				return nil, nil
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(requestMetadataKey, string(rm)))
+	if id := intercept(ctx); id != "build-1" {
+		t.Errorf("Expected invocation ID build-1, got %q", id)
+	}
+
+	if id := intercept(context.Background()); id != "" {
+		t.Errorf("Expected no invocation ID without metadata, got %q", id)
+	}
+
+	ctx = metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(requestMetadataKey, "not a proto"))
+	if id := intercept(ctx); id != "" {
+		t.Errorf("Expected no invocation ID for invalid metadata, got %q", id)
+	}
+}
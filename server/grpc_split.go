@@ -0,0 +1,262 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	grpc_status "google.golang.org/grpc/status"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+)
+
+// SplitBlob and SpliceBlob let clients transfer only the chunks of large
+// blobs which they don't already have. Blobs are split at the chunk
+// boundaries of the casblob format, so a blob is split the same way no
+// matter how it was uploaded, and the chunks of blobs which differ only
+// near their ends are shared.
+
+var (
+	errNilSplitBlobRequest = grpc_status.Error(codes.InvalidArgument,
+		"expected a non-nil *SplitBlobRequest")
+	errNilSpliceBlobRequest = grpc_status.Error(codes.InvalidArgument,
+		"expected a non-nil *SpliceBlobRequest")
+)
+
+// Return an error if df is not a digest function that we support.
+func checkDigestFunction(df pb.DigestFunction_Value) error {
+	if df != pb.DigestFunction_UNKNOWN && df != pb.DigestFunction_SHA256 {
+		return grpc_status.Errorf(codes.InvalidArgument,
+			"Unsupported digest function: %s", df)
+	}
+	return nil
+}
+
+func (s *grpcServer) SplitBlob(ctx context.Context,
+	req *pb.SplitBlobRequest) (*pb.SplitBlobResponse, error) {
+
+	if req == nil {
+		return nil, errNilSplitBlobRequest
+	}
+
+	if req.BlobDigest == nil {
+		return nil, errNilDigest
+	}
+
+	errorPrefix := "GRPC CAS SPLIT"
+	hash := req.BlobDigest.Hash
+	size := req.BlobDigest.SizeBytes
+
+	err := s.validateHash(hash, size, errorPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkDigestFunction(req.DigestFunction)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = cache.WithInstanceName(ctx, req.InstanceName)
+
+	// The chunk boundaries of casblob aren't those of any of the chunking
+	// functions in the API, so the response leaves it UNKNOWN.
+	resp := &pb.SplitBlobResponse{}
+	if size == 0 {
+		// The empty blob is made of no chunks.
+		s.accessLogger.Printf("%s %s OK", errorPrefix, hash)
+		return resp, nil
+	}
+
+	rc, foundSize, err := s.cache.Get(ctx, cache.CAS, hash, size, 0)
+	if rc != nil {
+		defer rc.Close()
+	}
+	if err != nil {
+		s.errorLogger.Printf("%s %s %s", errorPrefix, hash, err)
+		return nil, gRPCCacheError(err, codes.Internal, err.Error())
+	}
+	if rc == nil || foundSize != size {
+		s.accessLogger.Printf("%s %s NOT FOUND", errorPrefix, hash)
+		return nil, grpc_status.Error(codes.NotFound, "Item not found")
+	}
+
+	buf := make([]byte, casblob.ChunkSize)
+	for remaining := size; remaining > 0; {
+		chunk := buf
+		if remaining < int64(len(chunk)) {
+			chunk = chunk[:remaining]
+		}
+		remaining -= int64(len(chunk))
+
+		_, err = io.ReadFull(rc, chunk)
+		if err != nil {
+			s.errorLogger.Printf("%s %s %s", errorPrefix, hash, err)
+			return nil, grpc_status.Error(codes.Internal, err.Error())
+		}
+
+		sum := sha256.Sum256(chunk)
+		digest := &pb.Digest{
+			Hash:      hex.EncodeToString(sum[:]),
+			SizeBytes: int64(len(chunk)),
+		}
+		resp.ChunkDigests = append(resp.ChunkDigests, digest)
+
+		if digest.SizeBytes == size {
+			// The blob is a single chunk, which we already have.
+			break
+		}
+
+		found, _ := s.cache.Contains(ctx, cache.CAS, digest.Hash, digest.SizeBytes)
+		if found {
+			continue
+		}
+
+		err = s.cache.Put(ctx, cache.CAS, digest.Hash, digest.SizeBytes,
+			bytes.NewReader(chunk))
+		if err != nil {
+			s.errorLogger.Printf("%s %s CHUNK %s %s", errorPrefix, hash, digest.Hash, err)
+			return nil, gRPCCacheError(err, codes.Internal, err.Error())
+		}
+	}
+
+	s.accessLogger.Printf("%s %s OK, %d CHUNKS", errorPrefix, hash, len(resp.ChunkDigests))
+	return resp, nil
+}
+
+func (s *grpcServer) SpliceBlob(ctx context.Context,
+	req *pb.SpliceBlobRequest) (*pb.SpliceBlobResponse, error) {
+
+	if req == nil {
+		return nil, errNilSpliceBlobRequest
+	}
+
+	if req.BlobDigest == nil {
+		return nil, errNilDigest
+	}
+
+	errorPrefix := "GRPC CAS SPLICE"
+	hash := req.BlobDigest.Hash
+	size := req.BlobDigest.SizeBytes
+
+	err := s.validateHash(hash, size, errorPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkDigestFunction(req.DigestFunction)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, digest := range req.ChunkDigests {
+		if digest == nil {
+			return nil, errNilDigest
+		}
+
+		err = s.validateHash(digest.Hash, digest.SizeBytes, errorPrefix)
+		if err != nil {
+			return nil, err
+		}
+		total += digest.SizeBytes
+	}
+	if total != size {
+		s.accessLogger.Printf("%s %s CHUNK SIZE MISMATCH: %d", errorPrefix, hash, total)
+		return nil, grpc_status.Errorf(codes.InvalidArgument,
+			"The chunks add up to %d bytes, expected %d", total, size)
+	}
+
+	ctx = cache.WithInstanceName(ctx, req.InstanceName)
+
+	resp := &pb.SpliceBlobResponse{BlobDigest: req.BlobDigest}
+
+	found, _ := s.cache.Contains(ctx, cache.CAS, hash, size)
+	if found {
+		s.accessLogger.Printf("%s %s OK, ALREADY PRESENT", errorPrefix, hash)
+		return resp, nil
+	}
+
+	// Check for all the missing chunks first, so that the client can
+	// upload them at once.
+	var missingChunks []*pb.Digest
+	for _, digest := range req.ChunkDigests {
+		found, _ := s.cache.Contains(ctx, cache.CAS, digest.Hash, digest.SizeBytes)
+		if !found {
+			missingChunks = append(missingChunks, digest)
+		}
+	}
+	if len(missingChunks) > 0 {
+		s.accessLogger.Printf("%s %s %d CHUNKS NOT FOUND", errorPrefix, hash, len(missingChunks))
+		return nil, gRPCMissingBlobsError(codes.NotFound,
+			fmt.Sprintf("%d chunks not found", len(missingChunks)), missingChunks)
+	}
+
+	// Stream the chunks to Put, hashing them on the way so that we can
+	// tell a digest mismatch from other errors.
+	pr, pw := io.Pipe()
+	hasher := sha256.New()
+	var missing *pb.Digest
+	complete := false
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		w := io.MultiWriter(pw, hasher)
+		for _, digest := range req.ChunkDigests {
+			rc, foundSize, err := s.cache.Get(ctx, cache.CAS, digest.Hash, digest.SizeBytes, 0)
+			if err == nil && (rc == nil || foundSize != digest.SizeBytes) {
+				missing = digest
+				err = errBlobNotFound
+			}
+			if err != nil {
+				if rc != nil {
+					rc.Close()
+				}
+				pw.CloseWithError(err)
+				return
+			}
+
+			_, err = io.CopyN(w, rc, digest.SizeBytes)
+			rc.Close()
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		complete = true
+		pw.Close()
+	}()
+
+	err = s.cache.Put(ctx, cache.CAS, hash, size, pr)
+	pr.Close()
+	<-done
+
+	if missing != nil {
+		s.accessLogger.Printf("%s %s CHUNK %s NOT FOUND", errorPrefix, hash, missing.Hash)
+		return nil, gRPCMissingBlobsError(codes.NotFound,
+			fmt.Sprintf("Chunk not found: %s/%d", missing.Hash, missing.SizeBytes),
+			[]*pb.Digest{missing})
+	}
+
+	if err != nil {
+		if complete && hex.EncodeToString(hasher.Sum(nil)) != hash {
+			s.accessLogger.Printf("%s %s DIGEST MISMATCH", errorPrefix, hash)
+			return nil, grpc_status.Error(codes.InvalidArgument,
+				fmt.Sprintf("The spliced blob does not match the digest %s/%d", hash, size))
+		}
+
+		s.errorLogger.Printf("%s %s %s", errorPrefix, hash, err)
+		return nil, gRPCCacheError(err, codes.Internal, err.Error())
+	}
+
+	s.accessLogger.Printf("%s %s OK, %d CHUNKS", errorPrefix, hash, len(req.ChunkDigests))
+	return resp, nil
+}
@@ -21,9 +21,11 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"google.golang.org/genproto/googleapis/bytestream"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 
@@ -31,6 +33,7 @@ import (
 	"github.com/buchgr/bazel-remote/v2/cache/disk"
 	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
 	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
 
 	"github.com/klauspost/compress/zstd"
 )
@@ -67,10 +70,10 @@ var (
 )
 
 func grpcTestSetup(t *testing.T) (tc grpcTestFixture) {
-	return grpcTestSetupInternal(t, false)
+	return grpcTestSetupInternal(t, false, RequestLimits{})
 }
 
-func grpcTestSetupInternal(t *testing.T, mangleACKeys bool) (tc grpcTestFixture) {
+func grpcTestSetupInternal(t *testing.T, mangleACKeys bool, limits RequestLimits, opts ...disk.Option) (tc grpcTestFixture) {
 	dir, err := os.MkdirTemp("", "bazel-remote-grpc-tests-"+t.Name())
 	if err != nil {
 		t.Fatal("Failed to create grpc test temp dir", err)
@@ -79,7 +82,8 @@ func grpcTestSetupInternal(t *testing.T, mangleACKeys bool) (tc grpcTestFixture)
 	// Add some overhead for likely CAS blob storage expansion.
 	cacheSize := int64(10 * maxChunkSize * 2)
 
-	diskCache, err := disk.New(dir, cacheSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	opts = append(opts, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	diskCache, err := disk.New(dir, cacheSize, opts...)
 	if err != nil {
 		fmt.Println("Test setup failed")
 		os.Exit(1)
@@ -99,12 +103,15 @@ func grpcTestSetupInternal(t *testing.T, mangleACKeys bool) (tc grpcTestFixture)
 	enableRemoteAssetAPI := true
 
 	go func() {
-		err2 := serveGRPC(
+		err2 := ServeGRPC(
 			listener,
 			grpc.NewServer(),
 			validateAC,
 			mangleACKeys,
 			enableRemoteAssetAPI,
+			limits,
+			validate.SymlinksAllow,
+			nil,
 			diskCache, accessLogger, errorLogger)
 		if err2 != nil {
 			fmt.Println(err2)
@@ -301,7 +308,7 @@ func TestGrpcAc(t *testing.T) {
 func TestAcKeyMangling(t *testing.T) {
 	t.Parallel()
 
-	fixture := grpcTestSetupInternal(t, true)
+	fixture := grpcTestSetupInternal(t, true, RequestLimits{})
 	defer os.Remove(fixture.tempdir)
 
 	ar := pb.ActionResult{
@@ -394,6 +401,46 @@ func TestGrpcCasEmptySha256(t *testing.T) {
 	if len(downResp.GetResponses()) != 1 {
 		t.Fatal("Expected 1 response, got", len(downResp.GetResponses()))
 	}
+
+	// The empty blob is served uncompressed, even if the client accepts
+	// zstd.
+	downReq.AcceptableCompressors = []pb.Compressor_Value{pb.Compressor_ZSTD}
+	downResp, err = fixture.casClient.BatchReadBlobs(ctx, &downReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := downResp.GetResponses()[0]
+	if r.Status.GetCode() != int32(codes.OK) || r.Compressor != pb.Compressor_IDENTITY || len(r.Data) != 0 {
+		t.Fatalf("Unexpected response for the empty blob: %v", r)
+	}
+
+	// Uploads of the empty blob are accepted without storing anything,
+	// however they are compressed.
+	upReq := pb.BatchUpdateBlobsRequest{
+		Requests: []*pb.BatchUpdateBlobsRequest_Request{
+			{Digest: &emptyDigest},
+			{Digest: &emptyDigest, Data: emptyZstdBlob, Compressor: pb.Compressor_ZSTD},
+		},
+	}
+	upResp, err := fixture.casClient.BatchUpdateBlobs(ctx, &upReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range upResp.GetResponses() {
+		if r.Status.GetCode() != int32(codes.OK) {
+			t.Fatalf("Expected the upload of the empty blob to succeed, got %v", r.Status)
+		}
+	}
+
+	missingResp, err := fixture.casClient.FindMissingBlobs(ctx, &pb.FindMissingBlobsRequest{
+		BlobDigests: []*pb.Digest{&emptyDigest, &emptyDigest},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missingResp.MissingBlobDigests) != 0 {
+		t.Fatal("Expected the empty blob not to be missing, got", missingResp.MissingBlobDigests)
+	}
 }
 
 func TestGrpcAcRequestInlinedBlobs(t *testing.T) {
@@ -1370,6 +1417,97 @@ func TestGrpcByteStreamQueryWriteStatus(t *testing.T) {
 	}
 }
 
+func TestGrpcByteStreamResumeWrite(t *testing.T) {
+	t.Parallel()
+
+	fixture := grpcTestSetupInternal(t, false, RequestLimits{}, disk.WithResumableUploads(1))
+	defer os.Remove(fixture.tempdir)
+
+	testBlob, testBlobHash := testutils.RandomDataAndHash(5000)
+
+	resourceName := fmt.Sprintf(
+		"%s/uploads/%s/blobs/%s/%d",
+		"instance",
+		uuid.New().String(),
+		testBlobHash,
+		len(testBlob),
+	)
+	queryReq := &bytestream.QueryWriteStatusRequest{ResourceName: resourceName}
+
+	// Send part of the blob, then drop the stream.
+	ctx, cancel := context.WithCancel(context.Background())
+	bswc, err := fixture.bsClient.Write(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bswc.Send(&bytestream.WriteRequest{
+		ResourceName: resourceName,
+		Data:         testBlob[:2000],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Give the server time to receive the data.
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	var committed int64
+	for i := 0; i < 1000 && committed == 0; i++ {
+		time.Sleep(time.Millisecond)
+
+		resp, err := fixture.bsClient.QueryWriteStatus(context.Background(), queryReq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Complete {
+			t.Fatal("Expected an incomplete upload")
+		}
+		committed = resp.CommittedSize
+	}
+	if committed != 2000 {
+		t.Fatal("Expected 2000 committed bytes, found", committed)
+	}
+
+	// Resume the upload.
+	bswc, err = fixture.bsClient.Write(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bswc.Send(&bytestream.WriteRequest{
+		ResourceName: resourceName,
+		WriteOffset:  committed,
+		Data:         testBlob[committed:],
+		FinishWrite:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := bswc.CloseAndRecv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.CommittedSize != int64(len(testBlob)) {
+		t.Fatalf("Expected CommittedSize == %d, got: %d", len(testBlob), resp.CommittedSize)
+	}
+
+	rc, _, err := fixture.diskCache.Get(context.Background(), cache.CAS, testBlobHash, int64(len(testBlob)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc == nil {
+		t.Fatal("Expected the blob to be in the cache")
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, testBlob) {
+		t.Fatal("Unexpected blob data")
+	}
+}
+
 func TestGrpcCasBasics(t *testing.T) {
 	t.Parallel()
 
@@ -1640,6 +1778,120 @@ func TestGrpcCasTreeRequest(t *testing.T) {
 	}
 }
 
+func TestGrpcCasSplitAndSplice(t *testing.T) {
+	t.Parallel()
+
+	fixture := grpcTestSetup(t)
+	defer os.Remove(fixture.tempdir)
+
+	// Two full chunks and a partial one.
+	blob, hash := testutils.RandomDataAndHash(2*casblob.ChunkSize + 1000)
+	blobDigest := &pb.Digest{Hash: hash, SizeBytes: int64(len(blob))}
+
+	_, err := fixture.casClient.SplitBlob(ctx, &pb.SplitBlobRequest{BlobDigest: blobDigest})
+	if status.Code(err) != codes.NotFound {
+		t.Fatal("Expected a NotFound error, got", err)
+	}
+
+	err = fixture.diskCache.Put(ctx, cache.CAS, hash, int64(len(blob)), bytes.NewReader(blob))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	splitResp, err := fixture.casClient.SplitBlob(ctx, &pb.SplitBlobRequest{BlobDigest: blobDigest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(splitResp.ChunkDigests) != 3 {
+		t.Fatal("Expected 3 chunks, got", len(splitResp.ChunkDigests))
+	}
+
+	// The chunks were added to the CAS, and make up the blob.
+	var chunks [][]byte
+	for _, d := range splitResp.ChunkDigests {
+		data, _, err := fixture.diskCache.Get(ctx, cache.CAS, d.Hash, d.SizeBytes, 0)
+		if err != nil || data == nil {
+			t.Fatal("Expected chunk", d.Hash, "to be found, got", err)
+		}
+		chunk, err := io.ReadAll(data)
+		data.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if !bytes.Equal(bytes.Join(chunks, nil), blob) {
+		t.Fatal("The chunks don't make up the blob")
+	}
+
+	// Splice a new blob from some of the chunks.
+	spliced := append(append([]byte{}, chunks[2]...), chunks[0]...)
+	sum := sha256.Sum256(spliced)
+	splicedDigest := &pb.Digest{Hash: hex.EncodeToString(sum[:]), SizeBytes: int64(len(spliced))}
+	chunkDigests := []*pb.Digest{splitResp.ChunkDigests[2], splitResp.ChunkDigests[0]}
+
+	spliceResp, err := fixture.casClient.SpliceBlob(ctx, &pb.SpliceBlobRequest{
+		BlobDigest:   splicedDigest,
+		ChunkDigests: chunkDigests,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(spliceResp.BlobDigest, splicedDigest) {
+		t.Fatal("Unexpected blob digest", spliceResp.BlobDigest)
+	}
+
+	rc, _, err := fixture.diskCache.Get(ctx, cache.CAS, splicedDigest.Hash, splicedDigest.SizeBytes, 0)
+	if err != nil || rc == nil {
+		t.Fatal("Expected the spliced blob to be found, got", err)
+	}
+	found, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(found, spliced) {
+		t.Fatal("Unexpected spliced blob data")
+	}
+
+	// The chunks in the wrong order.
+	_, otherHash := testutils.RandomDataAndHash(int64(len(spliced)))
+	_, err = fixture.casClient.SpliceBlob(ctx, &pb.SpliceBlobRequest{
+		BlobDigest:   &pb.Digest{Hash: otherHash, SizeBytes: int64(len(spliced))},
+		ChunkDigests: chunkDigests,
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatal("Expected an InvalidArgument error, got", err)
+	}
+
+	// A missing chunk.
+	missing, missingHash := testutils.RandomDataAndHash(100)
+	_, err = fixture.casClient.SpliceBlob(ctx, &pb.SpliceBlobRequest{
+		BlobDigest: &pb.Digest{Hash: otherHash, SizeBytes: splitResp.ChunkDigests[2].SizeBytes + 100},
+		ChunkDigests: []*pb.Digest{
+			splitResp.ChunkDigests[2],
+			{Hash: missingHash, SizeBytes: int64(len(missing))},
+		},
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Fatal("Expected a NotFound error, got", err)
+	}
+
+	// The missing chunks are listed in a PreconditionFailure detail.
+	var violations []string
+	for _, d := range status.Convert(err).Details() {
+		if pf, ok := d.(*errdetails.PreconditionFailure); ok {
+			for _, v := range pf.Violations {
+				violations = append(violations, v.Type+" "+v.Subject)
+			}
+		}
+	}
+	expected := fmt.Sprintf("MISSING blobs/%s/%d", missingHash, len(missing))
+	if len(violations) != 1 || violations[0] != expected {
+		t.Errorf("Expected the violation %q, got %v", expected, violations)
+	}
+}
+
 func TestBadUpdateActionResultRequest(t *testing.T) {
 	t.Parallel()
 
@@ -2431,6 +2683,22 @@ func TestParseWriteResource(t *testing.T) {
 	}
 }
 
+func TestResourceInstanceName(t *testing.T) {
+	hash := "0123456789012345678901234567890123456789012345678901234567890123"
+	tcs := map[string]string{
+		"blobs/" + hash + "/42":                                 "",
+		"foo/bar/blobs/" + hash + "/42":                         "foo/bar",
+		"foo/compressed-blobs/zstd/" + hash + "/42":             "foo",
+		"uploads/pretenduuid/blobs/" + hash + "/42":             "",
+		"foo/uploads/pretenduuid/compressed-blobs/zstd/" + hash: "foo",
+	}
+	for resourceName, expected := range tcs {
+		if instance := resourceInstanceName(resourceName); instance != expected {
+			t.Errorf("Expected instance name %q for %q, got %q", expected, resourceName, instance)
+		}
+	}
+}
+
 func TestCompressedBatchReadsAndWrites(t *testing.T) {
 	t.Parallel()
 
@@ -2524,6 +2792,188 @@ func TestCompressedBatchReadsAndWrites(t *testing.T) {
 	}
 }
 
+func TestGrpcBatchUpdateDuplicates(t *testing.T) {
+	t.Parallel()
+
+	fixture := grpcTestSetup(t)
+	defer os.Remove(fixture.tempdir)
+
+	blob, hash := testutils.RandomDataAndHash(1024)
+	digest := pb.Digest{Hash: hash, SizeBytes: int64(len(blob))}
+
+	// The second copy of the blob isn't written.
+	upReq := pb.BatchUpdateBlobsRequest{
+		Requests: []*pb.BatchUpdateBlobsRequest_Request{
+			{Digest: &digest, Data: blob},
+			{Digest: &digest, Data: blob},
+		},
+	}
+	upResp, err := fixture.casClient.BatchUpdateBlobs(ctx, &upReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(upResp.Responses) != 2 {
+		t.Fatal("Expected 2 responses, got", len(upResp.Responses))
+	}
+	for _, r := range upResp.Responses {
+		if r.Digest.Hash != hash || r.Status.GetCode() != int32(codes.OK) {
+			t.Fatalf("Unexpected response: %v", r)
+		}
+	}
+
+	downResp, err := fixture.casClient.BatchReadBlobs(ctx, &pb.BatchReadBlobsRequest{
+		Digests: []*pb.Digest{&digest},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downResp.Responses[0].Data, blob) {
+		t.Fatal("Response data did not match")
+	}
+}
+
+func TestGrpcBatchPerDigestErrors(t *testing.T) {
+	t.Parallel()
+
+	fixture := grpcTestSetup(t)
+	defer os.Remove(fixture.tempdir)
+
+	blob, hash := testutils.RandomDataAndHash(1024)
+	digest := pb.Digest{Hash: hash, SizeBytes: int64(len(blob))}
+
+	corrupt, corruptHash := testutils.RandomDataAndHash(1024)
+	corrupt[0]++
+	corruptDigest := pb.Digest{Hash: corruptHash, SizeBytes: int64(len(corrupt))}
+
+	invalidDigest := pb.Digest{Hash: "invalid", SizeBytes: 1}
+
+	// Invalid requests fail with a status for their digest, and the
+	// valid blob is still written.
+	upReq := pb.BatchUpdateBlobsRequest{
+		Requests: []*pb.BatchUpdateBlobsRequest_Request{
+			{Digest: &invalidDigest, Data: []byte{1}},
+			{Data: []byte{1}},
+			{Digest: &corruptDigest, Data: corrupt},
+			{Digest: &digest, Data: blob, Compressor: pb.Compressor_DEFLATE},
+			{Digest: &digest, Data: blob},
+		},
+	}
+	upResp, err := fixture.casClient.BatchUpdateBlobs(ctx, &upReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []codes.Code{
+		codes.InvalidArgument,
+		codes.InvalidArgument,
+		codes.InvalidArgument,
+		codes.InvalidArgument,
+		codes.OK,
+	}
+	if len(upResp.Responses) != len(expected) {
+		t.Fatalf("Expected %d responses, got %d", len(expected), len(upResp.Responses))
+	}
+	for i, r := range upResp.Responses {
+		if codes.Code(r.Status.GetCode()) != expected[i] {
+			t.Errorf("Expected response %d to have code %s, got %v", i, expected[i], r.Status)
+		}
+		if expected[i] != codes.OK && r.Status.GetMessage() == "" {
+			t.Errorf("Expected response %d to have an error message", i)
+		}
+	}
+
+	downResp, err := fixture.casClient.BatchReadBlobs(ctx, &pb.BatchReadBlobsRequest{
+		Digests: []*pb.Digest{&invalidDigest, &digest, &corruptDigest},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []codes.Code{codes.InvalidArgument, codes.OK, codes.NotFound}
+	if len(downResp.Responses) != len(expected) {
+		t.Fatalf("Expected %d responses, got %d", len(expected), len(downResp.Responses))
+	}
+	for i, r := range downResp.Responses {
+		if codes.Code(r.Status.GetCode()) != expected[i] {
+			t.Errorf("Expected response %d to have code %s, got %v", i, expected[i], r.Status)
+		}
+	}
+	if !bytes.Equal(downResp.Responses[1].Data, blob) {
+		t.Fatal("Response data did not match")
+	}
+}
+
+func TestGrpcSymlinkPolicy(t *testing.T) {
+	t.Parallel()
+
+	fixture := grpcTestSetup(t)
+	defer os.Remove(fixture.tempdir)
+
+	const execRoot = "/home/user/.cache/bazel/_bazel_user/0123/execroot/_main"
+
+	newRequest := func(target string) *pb.UpdateActionResultRequest {
+		_, hash := testutils.RandomDataAndHash(32)
+		return &pb.UpdateActionResultRequest{
+			ActionDigest: &pb.Digest{Hash: hash, SizeBytes: 32},
+			ActionResult: &pb.ActionResult{
+				OutputSymlinks: []*pb.OutputSymlink{
+					{Path: "bazel-out/bin/link", Target: target},
+				},
+			},
+		}
+	}
+
+	tcs := []struct {
+		policy   validate.SymlinkPolicy
+		strategy pb.SymlinkAbsolutePathStrategy_Value
+		target   string
+		expected string // The stored target, or "" if the request fails.
+	}{
+		{validate.SymlinksAllow, pb.SymlinkAbsolutePathStrategy_ALLOWED, "/usr/bin/x", "/usr/bin/x"},
+		{validate.SymlinksRejectAbsolute, pb.SymlinkAbsolutePathStrategy_DISALLOWED, "/usr/bin/x", ""},
+		{validate.SymlinksRejectAbsolute, pb.SymlinkAbsolutePathStrategy_DISALLOWED, "x", "x"},
+		{validate.SymlinksRewriteAbsolute, pb.SymlinkAbsolutePathStrategy_ALLOWED, "/usr/bin/x", ""},
+		{validate.SymlinksRewriteAbsolute, pb.SymlinkAbsolutePathStrategy_ALLOWED, execRoot + "/bazel-out/bin/x", "x"},
+	}
+
+	for _, tc := range tcs {
+		s := &grpcServer{
+			cache:         fixture.diskCache,
+			accessLogger:  testutils.NewSilentLogger(),
+			errorLogger:   testutils.NewSilentLogger(),
+			symlinkPolicy: tc.policy,
+		}
+
+		caps, err := s.GetCapabilities(ctx, &pb.GetCapabilitiesRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if caps.CacheCapabilities.SymlinkAbsolutePathStrategy != tc.strategy {
+			t.Errorf("Expected policy %q to advertise %s, got %s", tc.policy,
+				tc.strategy, caps.CacheCapabilities.SymlinkAbsolutePathStrategy)
+		}
+
+		req := newRequest(tc.target)
+		_, err = s.UpdateActionResult(ctx, req)
+		if tc.expected == "" {
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("Expected %q to be rejected with policy %q, got: %v", tc.target, tc.policy, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected %q to be accepted with policy %q, got: %v", tc.target, tc.policy, err)
+		}
+
+		ar, err := s.GetActionResult(ctx, &pb.GetActionResultRequest{ActionDigest: req.ActionDigest})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target := ar.OutputSymlinks[0].Target; target != tc.expected {
+			t.Errorf("Expected %q to be stored as %q with policy %q, got %q",
+				tc.target, tc.expected, tc.policy, target)
+		}
+	}
+}
+
 func TestHealthCheck(t *testing.T) {
 	t.Parallel()
 
@@ -2544,3 +2994,88 @@ func TestHealthCheck(t *testing.T) {
 		t.Fatalf("Expected health check to return SERVING status, got: %s", resp.Status.String())
 	}
 }
+
+func TestGrpcLookupTrailers(t *testing.T) {
+	t.Parallel()
+
+	fixture := grpcTestSetup(t)
+	defer os.Remove(fixture.tempdir)
+
+	data, hash := testutils.RandomDataAndHash(1024)
+	resourceName := fmt.Sprintf("blobs/%s/%d", hash, len(data))
+
+	// Read the blob, and return the trailer.
+	read := func() (metadata.MD, error) {
+		bsrc, err := fixture.bsClient.Read(ctx, &bytestream.ReadRequest{ResourceName: resourceName})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			_, err = bsrc.Recv()
+			if err == io.EOF {
+				return bsrc.Trailer(), nil
+			}
+			if err != nil {
+				return bsrc.Trailer(), err
+			}
+		}
+	}
+
+	md, err := read()
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound, got %v", err)
+	}
+	if source := md.Get("x-bazel-remote-cache-source"); len(source) != 1 || source[0] != "miss" {
+		t.Errorf("Expected a miss, got %q", source)
+	}
+
+	err = fixture.diskCache.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md, err = read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"x-bazel-remote-cache-source":       "local",
+		"x-bazel-remote-stored-compression": "zstd",
+		"x-bazel-remote-served-size":        "1024",
+	}
+	for key, value := range expected {
+		if found := md.Get(key); len(found) != 1 || found[0] != value {
+			t.Errorf("Expected %s: %s, got %q", key, value, found)
+		}
+	}
+
+	// GetActionResult responses have the same trailers.
+	ar := pb.ActionResult{ExitCode: int32(1)}
+	arData, err := proto.Marshal(&ar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arSum := sha256.Sum256(arData)
+	digest := pb.Digest{Hash: hex.EncodeToString(arSum[:]), SizeBytes: int64(len(arData))}
+
+	_, err = fixture.acClient.UpdateActionResult(ctx, &pb.UpdateActionResultRequest{
+		ActionDigest: &digest,
+		ActionResult: &ar,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var trailer metadata.MD
+	_, err = fixture.acClient.GetActionResult(ctx, &pb.GetActionResultRequest{ActionDigest: &digest},
+		grpc.Trailer(&trailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source := trailer.Get("x-bazel-remote-cache-source"); len(source) != 1 || source[0] != "local" {
+		t.Errorf("Expected a local hit, got %q", source)
+	}
+	if compression := trailer.Get("x-bazel-remote-stored-compression"); len(compression) != 1 || compression[0] != "identity" {
+		t.Errorf("Expected identity compression, got %q", compression)
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Clients which upload the outputs of many actions at once often send
+// the same blobs in several BatchUpdateBlobs requests at the same time.
+// Since a CAS blob's contents are determined by its hash, a blob which
+// another request is already writing is skipped once that write
+// succeeds, instead of being decompressed and written again. Blobs which
+// appear more than once in a single request are only written once.
+
+var skippedBatchUploads = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "bazel_remote_grpc_skipped_batch_uploads_total",
+	Help: "The total number of blobs in BatchUpdateBlobs requests which were not written, because another write of the same blob succeeded while they were waiting",
+})
+
+// batchUploads tracks the blobs which BatchUpdateBlobs requests are
+// writing. The zero value is ready to use.
+type batchUploads struct {
+	mu       sync.Mutex
+	inflight map[string]*batchUpload // By hash.
+}
+
+// A write of a blob which is in progress.
+type batchUpload struct {
+	done chan struct{} // Closed when the write finishes.
+	err  error         // The result of the write, set before done is closed.
+}
+
+// Wait until no other request is writing the blob with the given hash,
+// and register a write of it. If skip is true, another write of the blob
+// succeeded and there is nothing to do. Otherwise finish must be called
+// with the result of the write.
+func (u *batchUploads) begin(ctx context.Context, hash string) (finish func(error), skip bool, err error) {
+	for {
+		u.mu.Lock()
+		w, found := u.inflight[hash]
+		if !found {
+			if u.inflight == nil {
+				u.inflight = make(map[string]*batchUpload)
+			}
+			w = &batchUpload{done: make(chan struct{})}
+			u.inflight[hash] = w
+			u.mu.Unlock()
+
+			finish = func(err error) {
+				u.mu.Lock()
+				delete(u.inflight, hash)
+				u.mu.Unlock()
+
+				w.err = err
+				close(w.done)
+			}
+			return finish, false, nil
+		}
+		u.mu.Unlock()
+
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+
+		if w.err == nil {
+			skippedBatchUploads.Inc()
+			return nil, true, nil
+		}
+
+		// Try again, another waiting request might have started first.
+	}
+}
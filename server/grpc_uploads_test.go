@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBatchUploads(t *testing.T) {
+	var u batchUploads
+	ctx := context.Background()
+
+	finish, skip, err := u.begin(ctx, "a")
+	if err != nil || skip {
+		t.Fatal("Expected the first write to proceed, got", skip, err)
+	}
+
+	// Writes of other blobs don't wait.
+	finishB, skip, err := u.begin(ctx, "b")
+	if err != nil || skip {
+		t.Fatal("Expected a write of another blob to proceed, got", skip, err)
+	}
+	finishB(nil)
+
+	type result struct {
+		finish func(error)
+		skip   bool
+	}
+	results := make(chan result)
+	wait := func() {
+		go func() {
+			finish, skip, _ := u.begin(ctx, "a")
+			results <- result{finish, skip}
+		}()
+
+		select {
+		case <-results:
+			t.Fatal("Expected a concurrent write of the same blob to wait")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	// If the first write fails, the waiting write proceeds.
+	wait()
+	finish(errors.New("failed"))
+	r := <-results
+	if r.skip {
+		t.Fatal("Expected a waiting write to proceed after a failed write")
+	}
+
+	// If it succeeds, the waiting write is skipped.
+	skipped := testutil.ToFloat64(skippedBatchUploads)
+	wait()
+	r.finish(nil)
+	r = <-results
+	if !r.skip {
+		t.Fatal("Expected a waiting write to be skipped after a successful write")
+	}
+	if v := testutil.ToFloat64(skippedBatchUploads); v != skipped+1 {
+		t.Fatalf("Expected %g skipped uploads, got %g", skipped+1, v)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	finish, _, _ = u.begin(ctx, "a")
+	defer finish(nil)
+	cancel()
+	_, _, err = u.begin(cancelled, "a")
+	if err != context.Canceled {
+		t.Fatal("Expected a cancelled wait to fail, got", err)
+	}
+}
@@ -2,12 +2,18 @@ package server
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
 	"html"
 	"io"
 	"net"
 	"net/http"
+	"os"
 	"regexp"
 	"runtime"
 	"strconv"
@@ -15,12 +21,18 @@ import (
 	"time"
 
 	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/cluster"
 	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+	"github.com/buchgr/bazel-remote/v2/cache/replication"
+	"github.com/buchgr/bazel-remote/v2/utils/bufpool"
 	"github.com/buchgr/bazel-remote/v2/utils/validate"
 
 	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
 
-	"github.com/klauspost/compress/zstd"
+	"github.com/klauspost/compress/gzip"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 
@@ -29,13 +41,12 @@ import (
 
 var blobNameSHA256 = regexp.MustCompile("^/?(.*/)?(ac/|cas/)([a-f0-9]{64})$")
 
-var decoder, _ = zstd.NewReader(nil) // TODO: raise WithDecoderConcurrency ?
-
 // HTTPCache ...
 type HTTPCache interface {
 	CacheHandler(w http.ResponseWriter, r *http.Request)
 	StatusPageHandler(w http.ResponseWriter, r *http.Request)
 	VerifyClientCertHandler(wrapMe http.Handler) http.Handler
+	CheckClientCert(w http.ResponseWriter, r *http.Request) bool
 }
 
 type httpCache struct {
@@ -44,9 +55,29 @@ type httpCache struct {
 	errorLogger              cache.Logger
 	validateAC               bool
 	mangleACKeys             bool
+	verifyDigests            map[cache.EntryKind]bool
 	gitCommit                string
 	checkClientCertForReads  bool
 	checkClientCertForWrites bool
+	gzip                     *gzipLimiter
+	symlinkPolicy            validate.SymlinkPolicy
+	compatMode               bool
+	peers                    PeerConfig
+}
+
+// PeerConfig describes the other bazel-remote instances which send
+// requests to this one. Their requests are marked by a header, which is
+// only trusted if it has the shared secret of the instances.
+type PeerConfig struct {
+	Secret string
+
+	// Whether this instance is a cluster member, which serves the
+	// requests forwarded by the other members.
+	Cluster bool
+
+	// Whether this instance replicates writes or is a standby, which
+	// accepts the writes replicated by its peers.
+	Replication bool
 }
 
 type statusPageData struct {
@@ -58,13 +89,25 @@ type statusPageData struct {
 	ServerTime       int64
 	GitCommit        string
 	NumGoroutines    int
+	Standby          bool
+	Loading          bool
+	ProxyBackends    []disk.ProxyBackendStatus `json:",omitempty"`
 }
 
 // NewHTTPCache returns a new instance of the cache.
 // accessLogger will print one line for each HTTP request to stdout.
 // errorLogger will print unexpected server errors. Inexistent files and malformed URLs will not
 // be reported.
-func NewHTTPCache(cache disk.Cache, accessLogger cache.Logger, errorLogger cache.Logger, validateAC bool, mangleACKeys bool, checkClientCertForReads bool, checkClientCertForWrites bool, commit string) HTTPCache {
+// The SHA256 hash of CAS uploads is always verified, verifyDigests lists
+// other kinds of entries whose uploads are verified too.
+// GET responses are gzip compressed for clients which accept it, unless
+// gzipConfig is nil.
+// When validateAC is true, the output symlinks of uploaded ActionResults
+// are checked against symlinkPolicy.
+// compatMode enables HTTP compatibility mode, see http_compat.go.
+// peers describes the other bazel-remote instances whose requests are
+// trusted.
+func NewHTTPCache(cache disk.Cache, accessLogger cache.Logger, errorLogger cache.Logger, validateAC bool, mangleACKeys bool, verifyDigests []cache.EntryKind, checkClientCertForReads bool, checkClientCertForWrites bool, gzipConfig *GzipConfig, symlinkPolicy validate.SymlinkPolicy, compatMode bool, peers PeerConfig, commit string) HTTPCache {
 
 	_, _, numItems, _ := cache.Stats()
 
@@ -76,8 +119,13 @@ func NewHTTPCache(cache disk.Cache, accessLogger cache.Logger, errorLogger cache
 		errorLogger:              errorLogger,
 		validateAC:               validateAC,
 		mangleACKeys:             mangleACKeys,
+		verifyDigests:            kindSet(verifyDigests),
 		checkClientCertForReads:  checkClientCertForReads,
 		checkClientCertForWrites: checkClientCertForWrites,
+		gzip:                     newGzipLimiter(gzipConfig),
+		symlinkPolicy:            symlinkPolicy,
+		compatMode:               compatMode,
+		peers:                    peers,
 	}
 
 	if commit != "{STABLE_GIT_COMMIT}" {
@@ -87,6 +135,32 @@ func NewHTTPCache(cache disk.Cache, accessLogger cache.Logger, errorLogger cache
 	return hc
 }
 
+// Returns true if r was sent by another bazel-remote instance, which set
+// header to the shared secret, and enabled is true. Otherwise the header
+// is removed, so that it is not trusted further on.
+func (h *httpCache) fromPeer(r *http.Request, header string, enabled bool) bool {
+	value := r.Header.Get(header)
+	if value == "" {
+		return false
+	}
+
+	if enabled && h.peers.Secret != "" &&
+		subtle.ConstantTimeCompare([]byte(value), []byte(h.peers.Secret)) == 1 {
+		return true
+	}
+
+	r.Header.Del(header)
+	return false
+}
+
+func kindSet(kinds []cache.EntryKind) map[cache.EntryKind]bool {
+	set := make(map[cache.EntryKind]bool, len(kinds))
+	for _, kind := range kinds {
+		set[kind] = true
+	}
+	return set
+}
+
 // Parse cache artifact information from the request URL
 func parseRequestURL(url string, validateAC bool) (kind cache.EntryKind, hash string, instance string, err error) {
 	m := blobNameSHA256.FindStringSubmatch(url)
@@ -136,8 +210,9 @@ func (h *httpCache) handleContainsValidAC(w http.ResponseWriter, r *http.Request
 	h.logResponse(http.StatusOK, r)
 }
 
-func (h *httpCache) handleGetValidAC(w http.ResponseWriter, r *http.Request, hash string) {
-	_, data, err := h.cache.GetValidatedActionResult(r.Context(), hash)
+func (h *httpCache) handleGetValidAC(ctx context.Context, w http.ResponseWriter, r *http.Request, hash string) {
+	ctx, lookup := cache.WithLookupResult(ctx)
+	_, data, err := h.cache.GetValidatedActionResult(ctx, hash)
 	if err != nil {
 		http.Error(w, "Not found", http.StatusNotFound)
 		h.logResponse(http.StatusNotFound, r)
@@ -145,10 +220,12 @@ func (h *httpCache) handleGetValidAC(w http.ResponseWriter, r *http.Request, has
 	}
 
 	if data == nil {
+		setLookupHeaders(w.Header(), &cache.LookupResult{})
 		http.Error(w, "Not found", http.StatusNotFound)
 		h.logResponse(http.StatusNotFound, r)
 		return
 	}
+	setLookupHeaders(w.Header(), lookup)
 
 	if r.Header.Get("Accept") == "application/json" {
 		ar := &pb.ActionResult{}
@@ -205,14 +282,40 @@ func (h *httpCache) logResponse(code int, r *http.Request) {
 func (h *httpCache) CacheHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	kind, hash, instance, err := parseRequestURL(r.URL.Path, h.validateAC)
+	urlPath := r.URL.Path
+	urlSize := int64(-1)
+	if h.compatMode {
+		urlPath, urlSize = compatRequestPath(urlPath)
+	}
+
+	kind, hash, instance, err := parseRequestURL(urlPath, h.validateAC)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		h.logResponse(http.StatusBadRequest, r)
+		code := http.StatusBadRequest
+		if h.compatMode && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+			// Report a cache miss rather than a client error.
+			code = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), code)
+		h.logResponse(code, r)
 		return
 	}
 
-	if h.mangleACKeys && kind == cache.AC {
+	ctx := cache.WithInstanceName(r.Context(), instance)
+
+	// Requests forwarded from another cluster member are served from this
+	// instance. AC entries are validated by the forwarding member, which
+	// can check for the referenced blobs on all members, and AC keys have
+	// already been mangled by the forwarding member.
+	forwarded := h.fromPeer(r, cluster.Header, h.peers.Cluster)
+	if forwarded {
+		ctx = cluster.Forwarded(ctx)
+	}
+
+	// The hash in the URL, which the contents of uploads are verified
+	// against.
+	urlHash := hash
+
+	if h.mangleACKeys && kind == cache.AC && !forwarded {
 		hash = cache.TransformActionCacheKey(hash, instance, h.accessLogger)
 	}
 
@@ -224,24 +327,26 @@ func (h *httpCache) CacheHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if h.validateAC && kind == cache.AC {
-			h.handleGetValidAC(w, r, hash)
+		if h.validateAC && kind == cache.AC && !forwarded {
+			h.handleGetValidAC(ctx, w, r, hash)
 			return
 		}
 
+		ctx, lookup := cache.WithLookupResult(ctx)
+
 		var rdr io.ReadCloser
 		var sizeBytes int64
 
 		zstdCompressed := false
 		if kind == cache.CAS && strings.Contains(r.Header.Get("Accept-Encoding"), "zstd") {
-			rdr, sizeBytes, err = h.cache.GetZstd(r.Context(), hash, -1, 0)
+			rdr, sizeBytes, err = h.cache.GetZstd(ctx, hash, -1, 0)
 			zstdCompressed = true
 		} else {
-			rdr, sizeBytes, err = h.cache.Get(r.Context(), kind, hash, -1, 0)
+			rdr, sizeBytes, err = h.cache.Get(ctx, kind, hash, -1, 0)
 		}
 		if err != nil {
 			if e, ok := err.(*cache.Error); ok {
-				http.Error(w, e.Error(), e.Code)
+				httpCacheError(w, e)
 			} else {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 			}
@@ -250,22 +355,44 @@ func (h *httpCache) CacheHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if rdr == nil {
+			setLookupHeaders(w.Header(), lookup)
 			http.Error(w, "Not found", http.StatusNotFound)
 			h.logResponse(http.StatusNotFound, r)
 			return
 		}
 		defer rdr.Close()
 
+		// Responses to other cluster members are not compressed, since
+		// they are forwarded to the client as they are.
+		gzipCompressed := !zstdCompressed && !forwarded && h.gzip.acquire(r, sizeBytes)
+		if gzipCompressed {
+			defer h.gzip.release()
+		}
+
+		setLookupHeaders(w.Header(), lookup)
 		w.Header().Set("Content-Type", "application/octet-stream")
+		if h.gzip != nil {
+			w.Header().Set("Vary", "Accept-Encoding")
+		}
 		if zstdCompressed {
 			// TODO: calculate Content-Length for compressed blobs too
 			// (unless compressing on the fly).
 			w.Header().Set("Content-Encoding", "zstd")
+		} else if gzipCompressed {
+			w.Header().Set("Content-Encoding", "gzip")
 		} else {
 			w.Header().Set("Content-Length", strconv.FormatInt(sizeBytes, 10))
 		}
 
-		_, err := io.Copy(w, rdr)
+		var err error
+		if gzipCompressed {
+			err = copyGzip(w, rdr)
+		} else if f, ok := rdr.(*os.File); ok {
+			// Uncompressed blobs are served straight from their files.
+			_, err = copyFile(w, f)
+		} else {
+			_, err = bufpool.Copy(w, rdr)
+		}
 		if err != nil {
 			// No point calling http.Error here because we've already started writing data
 			h.errorLogger.Printf("Error writing %s/%s err: %s", kind.String(), hash, err.Error())
@@ -298,6 +425,8 @@ func (h *httpCache) CacheHandler(w http.ResponseWriter, r *http.Request) {
 			}
 
 			contentLength = int64(cl)
+		} else if contentLength == -1 && urlSize >= 0 {
+			contentLength = urlSize
 		}
 
 		if contentLength == -1 {
@@ -316,11 +445,15 @@ func (h *httpCache) CacheHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		zstdCompressed := false
+		gzipCompressed := false
 
-		// Content-Encoding must be one of "identity", "zstd" or not present.
+		// Content-Encoding must be one of "identity", "zstd", "gzip" or
+		// not present.
 		ce := r.Header.Get("Content-Encoding")
 		if ce == "zstd" {
 			zstdCompressed = true
+		} else if ce == "gzip" {
+			gzipCompressed = true
 		} else if ce != "" && ce != "identity" {
 			msg := fmt.Sprintf("Unsupported content-encoding: %q", ce)
 			http.Error(w, msg, http.StatusBadRequest)
@@ -328,6 +461,19 @@ func (h *httpCache) CacheHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if contentLength == 0 && kind == cache.CAS {
+			// The empty blob is always present, so there is nothing
+			// to store.
+			h.logResponse(http.StatusOK, r)
+			return
+		}
+
+		// Uploads forwarded by another cluster member or sent by a
+		// replication peer were verified when they were first received,
+		// and AC entries might have been modified since then.
+		fromPeer := h.fromPeer(r, replication.Header, h.peers.Replication)
+		verify := (kind == cache.CAS || h.verifyDigests[kind]) && !forwarded && !fromPeer
+
 		var rdr io.Reader = r.Body
 		if h.validateAC && kind == cache.AC {
 			// verify that this is a valid ActionResult
@@ -335,13 +481,22 @@ func (h *httpCache) CacheHandler(w http.ResponseWriter, r *http.Request) {
 			data, err := io.ReadAll(rdr)
 			if err != nil {
 				msg := "failed to read request body"
-				http.Error(w, msg, http.StatusInternalServerError)
+				code := http.StatusInternalServerError
+				if tooLarge := bodyTooLarge(r); tooLarge != nil {
+					msg = fmt.Sprintf("Request body too large, the limit is %d", tooLarge.Limit)
+					code = http.StatusRequestEntityTooLarge
+				}
+				http.Error(w, msg, code)
 				h.errorLogger.Printf("PUT %s: %s", path(kind, hash), msg)
 				return
 			}
 
+			usage := newBufferUsage("HTTP AC PUT")
+			defer usage.done()
+			usage.add(len(data))
+
 			if zstdCompressed {
-				uncompressed, err := decoder.DecodeAll(data, nil)
+				uncompressed, err := unzstd(data, contentLength)
 				if err != nil {
 					msg := fmt.Sprintf("failed to uncompress zstd-encoded request body: %v", err)
 					http.Error(w, msg, http.StatusBadRequest)
@@ -349,10 +504,25 @@ func (h *httpCache) CacheHandler(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 
+				usage.add(len(uncompressed))
 				data = uncompressed
 				zstdCompressed = false
 			}
 
+			if gzipCompressed {
+				uncompressed, err := gunzip(data, contentLength)
+				if err != nil {
+					msg := fmt.Sprintf("failed to uncompress gzip-encoded request body: %v", err)
+					http.Error(w, msg, http.StatusBadRequest)
+					h.errorLogger.Printf("PUT %s: %s", path(kind, hash), msg)
+					return
+				}
+
+				usage.add(len(uncompressed))
+				data = uncompressed
+				gzipCompressed = false
+			}
+
 			if int64(len(data)) != contentLength {
 				msg := fmt.Sprintf("sizes don't match. Expected %d, found %d",
 					contentLength, len(data))
@@ -361,6 +531,17 @@ func (h *httpCache) CacheHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
+			// Verify the data as uploaded, before worker metadata is
+			// added to it.
+			if verify {
+				sum := sha256.Sum256(data)
+				if actual := hex.EncodeToString(sum[:]); actual != urlHash {
+					h.rejectDigestMismatch(w, r, kind, hash, urlHash, actual)
+					return
+				}
+				verify = false
+			}
+
 			// Ensure that the serialized ActionResult has non-zero length.
 			ar, code, err := addWorkerMetadataHTTP(r.RemoteAddr, r.Header.Get("Content-Type"), data)
 			if err != nil {
@@ -371,6 +552,9 @@ func (h *httpCache) CacheHandler(w http.ResponseWriter, r *http.Request) {
 
 			// Note: we do not currently verify that the blobs exist in the CAS.
 			err = validate.ActionResult(ar)
+			if err == nil {
+				err = validate.Symlinks(ar, h.symlinkPolicy)
+			}
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				h.errorLogger.Printf("PUT %s: %s", path(kind, hash), err.Error())
@@ -412,10 +596,37 @@ func (h *httpCache) CacheHandler(w http.ResponseWriter, r *http.Request) {
 			rdr = rc
 		}
 
-		err := h.cache.Put(r.Context(), kind, hash, contentLength, rdr)
-		if err != nil {
-			if cerr, ok := err.(*cache.Error); ok {
-				http.Error(w, err.Error(), cerr.Code)
+		if gzipCompressed {
+			gz, err := gzip.NewReader(rdr)
+			if err != nil {
+				msg := fmt.Sprintf("Failed to create gzip reader: %v", err)
+				http.Error(w, msg, http.StatusBadRequest)
+				h.errorLogger.Printf("PUT %s: %s", path(kind, hash), msg)
+				return
+			}
+			defer gz.Close()
+			rdr = gz
+		}
+
+		if fromPeer {
+			ctx = replication.FromPeer(ctx)
+		}
+
+		var verifier *digestVerifier
+		if verify {
+			verifier = newDigestVerifier(rdr, urlHash)
+			rdr = verifier
+		}
+
+		err := h.cache.Put(ctx, kind, hash, contentLength, rdr)
+		if err != nil && verifier != nil && verifier.actual != "" {
+			h.rejectDigestMismatch(w, r, kind, hash, urlHash, verifier.actual)
+		} else if err != nil {
+			if tooLarge := bodyTooLarge(r); tooLarge != nil {
+				msg := fmt.Sprintf("Request body too large, the limit is %d", tooLarge.Limit)
+				http.Error(w, msg, http.StatusRequestEntityTooLarge)
+			} else if cerr, ok := err.(*cache.Error); ok {
+				httpCacheError(w, cerr)
 			} else {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 			}
@@ -431,14 +642,18 @@ func (h *httpCache) CacheHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if h.validateAC && kind == cache.AC {
+		if h.compatMode {
+			w.Header().Set("Content-Type", "application/octet-stream")
+		}
+
+		if h.validateAC && kind == cache.AC && !forwarded {
 			h.handleContainsValidAC(w, r, hash)
 			return
 		}
 
 		// Unvalidated path:
 
-		ok, size := h.cache.Contains(r.Context(), kind, hash, -1)
+		ok, size := h.cache.Contains(ctx, kind, hash, -1)
 		if !ok {
 			http.Error(w, "Not found", http.StatusNotFound)
 			h.logResponse(http.StatusNotFound, r)
@@ -494,7 +709,13 @@ func (h *httpCache) StatusPageHandler(w http.ResponseWriter, r *http.Request) {
 
 	goroutines := runtime.NumGoroutine()
 
+	// Let load balancers send clients to the primary instead.
+	standby := h.cache.Standby()
+
 	w.Header().Set("Content-Type", "application/json")
+	if standby {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", " ")
 	err := enc.Encode(statusPageData{
@@ -506,16 +727,76 @@ func (h *httpCache) StatusPageHandler(w http.ResponseWriter, r *http.Request) {
 		ServerTime:       time.Now().Unix(),
 		GitCommit:        h.gitCommit,
 		NumGoroutines:    goroutines,
+		Standby:          standby,
+		Loading:          h.cache.Loading(),
+		ProxyBackends:    h.cache.ProxyBackends(),
 	})
 	if err != nil {
 		h.errorLogger.Printf("Failed to encode status json: %s", err.Error())
 	}
 }
 
+var digestMismatches = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "bazel_remote_http_digest_mismatches_total",
+	Help: "The total number of HTTP uploads rejected because their contents did not match the hash in the URL, by kind",
+}, []string{"kind"})
+
+func (h *httpCache) rejectDigestMismatch(w http.ResponseWriter, r *http.Request, kind cache.EntryKind, hash string, expected string, actual string) {
+	digestMismatches.WithLabelValues(kind.String()).Inc()
+
+	msg := fmt.Sprintf("The uploaded data has SHA256 hash %s, expected %s", actual, expected)
+	http.Error(w, msg, http.StatusUnprocessableEntity)
+	h.errorLogger.Printf("PUT %s: %s", path(kind, hash), msg)
+}
+
+// digestVerifier hashes the data read from an upload, and fails the
+// final read if the SHA256 hash of the data doesn't match the expected
+// hash, so the upload is not committed to the cache.
+type digestVerifier struct {
+	r        io.Reader
+	h        hash.Hash
+	expected string
+
+	// The actual hash of the data, if it didn't match.
+	actual string
+}
+
+func newDigestVerifier(r io.Reader, expected string) *digestVerifier {
+	return &digestVerifier{r: r, h: sha256.New(), expected: expected}
+}
+
+func (v *digestVerifier) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.h.Write(p[:n])
+
+	if err == io.EOF {
+		actual := hex.EncodeToString(v.h.Sum(nil))
+		if actual != v.expected {
+			v.actual = actual
+			return n, fmt.Errorf("%w. Expected %s, found %s",
+				casblob.ErrChecksumMismatch, v.expected, actual)
+		}
+	}
+
+	return n, err
+}
+
 func path(kind cache.EntryKind, hash string) string {
 	return fmt.Sprintf("/%s/%s", kind, hash)
 }
 
+// Write the rest of f to w. If w implements io.ReaderFrom, which
+// http.ResponseWriter normally does, f is passed to its ReadFrom method
+// directly, so that net/http can send the file with sendfile(2) instead
+// of copying it through userspace buffers. io.Copy does not guarantee
+// this, since *os.File may implement io.WriterTo.
+func copyFile(w io.Writer, f *os.File) (int64, error) {
+	if rf, ok := w.(io.ReaderFrom); ok {
+		return rf.ReadFrom(f)
+	}
+	return io.Copy(w, f)
+}
+
 // If the http.Request is authenticated with a valid client certificate
 // then do nothing and return true. Otherwise, write an error to the
 // http.ResponseWriter, log the error and return false.
@@ -544,6 +825,19 @@ func (h *httpCache) hasValidClientCert(w http.ResponseWriter, r *http.Request) b
 	return true
 }
 
+// CheckClientCert applies the client certificate checks of CacheHandler
+// for the method of r, for the handlers of other paths which wrap it, eg
+// AttestationsHTTP. It returns false, after responding to r, if r needs
+// a valid client certificate which it doesn't have.
+func (h *httpCache) CheckClientCert(w http.ResponseWriter, r *http.Request) bool {
+	check := h.checkClientCertForWrites
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		check = h.checkClientCertForReads
+	}
+
+	return !check || h.hasValidClientCert(w, r)
+}
+
 // VerifyClientCertHandler returns a http.Handler which wraps another Handler,
 // but only calls the inner Handler if the request has a valid client cert.
 // This is only used when mutual TLS authentication is enabled.
@@ -0,0 +1,45 @@
+package server
+
+import (
+	pathpkg "path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// HTTP compatibility mode relaxes the HTTP API for clients which were
+// written against other HTTP caches, eg tools which integrate with Goma or
+// reclient's racing mode:
+//
+//   - Request paths are cleaned, so duplicate and trailing slashes are
+//     ignored, hashes may use uppercase hex digits, and the hash may be
+//     followed by the blob's size, like in REAPI resource names, eg
+//     /cas/<hash>/<size>. The size is used for PUTs without a
+//     Content-Length header.
+//   - GET and HEAD requests with malformed paths get 404 Not Found
+//     responses, which clients treat as cache misses, instead of 400 Bad
+//     Request.
+//   - Successful HEAD responses have the same Content-Type header as GET
+//     responses.
+
+var compatSizeSuffix = regexp.MustCompile("^(.*/(?:ac|cas)/[0-9a-fA-F]{64})/([0-9]+)$")
+
+// Returns the path of a request in compatibility mode, in the form which
+// parseRequestURL expects, and the size at the end of it, or -1 if there
+// is none.
+func compatRequestPath(p string) (string, int64) {
+	p = pathpkg.Clean("/" + p)
+
+	size := int64(-1)
+	m := compatSizeSuffix.FindStringSubmatch(p)
+	if m != nil {
+		n, err := strconv.ParseInt(m[2], 10, 64)
+		if err == nil {
+			p = m[1]
+			size = n
+		}
+	}
+
+	i := strings.LastIndexByte(p, '/')
+	return p[:i+1] + strings.ToLower(p[i+1:]), size
+}
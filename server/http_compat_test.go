@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+)
+
+func TestCompatRequestPath(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	upper := strings.ToUpper(hash)
+
+	tcs := []struct {
+		path         string
+		expectedPath string
+		expectedSize int64
+	}{
+		{"/cas/" + hash, "/cas/" + hash, -1},
+		{"cas/" + hash, "/cas/" + hash, -1},
+		{"/cas/" + upper, "/cas/" + hash, -1},
+		{"//foo//cas/" + hash + "/", "/foo/cas/" + hash, -1},
+		{"/cas/" + hash + "/42", "/cas/" + hash, 42},
+		{"/foo/ac/" + upper + "/0/", "/foo/ac/" + hash, 0},
+		{"/cas/" + hash + "/x", "/cas/" + hash + "/x", -1},
+	}
+
+	for _, tc := range tcs {
+		p, size := compatRequestPath(tc.path)
+		if p != tc.expectedPath || size != tc.expectedSize {
+			t.Errorf("Expected %q to be %q with size %d, got %q with size %d",
+				tc.path, tc.expectedPath, tc.expectedSize, p, size)
+		}
+	}
+}
+
+func TestHTTPCompatMode(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, hash := testutils.RandomDataAndHash(1024)
+	upper := strings.ToUpper(hash)
+
+	for _, compatMode := range []bool{false, true} {
+		h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, compatMode, PeerConfig{}, "")
+
+		// A PUT without Content-Length, with the size in the path.
+		r := httptest.NewRequest(http.MethodPut, "/cas/"+upper+"/"+strconv.Itoa(len(data)), io.NopCloser(bytes.NewReader(data)))
+		r.ContentLength = -1
+		rr := httptest.NewRecorder()
+		h.CacheHandler(rr, r)
+		expected := http.StatusBadRequest
+		if compatMode {
+			expected = http.StatusOK
+		}
+		if rr.Code != expected {
+			t.Errorf("Expected status %d for a PUT with compat mode %t, got %d", expected, compatMode, rr.Code)
+		}
+
+		rr = httptest.NewRecorder()
+		h.CacheHandler(rr, httptest.NewRequest(http.MethodGet, "/cas/invalid", nil))
+		expected = http.StatusBadRequest
+		if compatMode {
+			expected = http.StatusNotFound
+		}
+		if rr.Code != expected {
+			t.Errorf("Expected status %d for a malformed GET with compat mode %t, got %d", expected, compatMode, rr.Code)
+		}
+	}
+
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, true, PeerConfig{}, "")
+	rr := httptest.NewRecorder()
+	h.CacheHandler(rr, httptest.NewRequest(http.MethodHead, "/cas/"+hash, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for HEAD, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/octet-stream" {
+		t.Errorf("Expected HEAD to have Content-Type application/octet-stream, got %q", ct)
+	}
+	if cl := rr.Header().Get("Content-Length"); cl != strconv.Itoa(len(data)) {
+		t.Errorf("Expected HEAD to have Content-Length %d, got %q", len(data), cl)
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Some HTTP clients support gzip but not zstd. GET responses can be gzip
+// compressed for clients which accept it, if they are large enough to be
+// worth compressing. Since compression is CPU intensive, only a limited
+// number of responses are compressed at a time, and the others are sent
+// uncompressed. Uploads may be gzip compressed too.
+
+// GzipConfig configures gzip compression of HTTP responses.
+type GzipConfig struct {
+	// Only compress responses of at least this many bytes.
+	MinSize int64
+
+	// The maximum number of responses to compress at once, or 0 for the
+	// number of CPUs.
+	MaxConcurrent int
+}
+
+type gzipLimiter struct {
+	minSize int64
+	slots   chan struct{}
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		gz, _ := gzip.NewWriterLevel(nil, gzip.BestSpeed)
+		return gz
+	},
+}
+
+var gzipSkipped = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "bazel_remote_http_gzip_skipped_total",
+	Help: "The total number of HTTP responses which were sent uncompressed to clients which accept gzip, because too many responses were being compressed",
+})
+
+// Returns nil if config is nil.
+func newGzipLimiter(config *GzipConfig) *gzipLimiter {
+	if config == nil {
+		return nil
+	}
+
+	maxConcurrent := config.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = runtime.NumCPU()
+	}
+
+	return &gzipLimiter{
+		minSize: config.MinSize,
+		slots:   make(chan struct{}, maxConcurrent),
+	}
+}
+
+// Returns true if the response to r, with a body of size bytes, should be
+// gzip compressed. If so, release must be called once it has been sent.
+func (g *gzipLimiter) acquire(r *http.Request, size int64) bool {
+	if g == nil || size < g.minSize || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		return false
+	}
+
+	select {
+	case g.slots <- struct{}{}:
+		return true
+	default:
+		gzipSkipped.Inc()
+		return false
+	}
+}
+
+func (g *gzipLimiter) release() {
+	<-g.slots
+}
+
+// Returns true if the Accept-Encoding header value accepts gzip, ie it
+// lists gzip or * without a quality value of 0.
+func acceptsGzip(acceptEncoding string) bool {
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		name = strings.TrimSpace(name)
+		if name != "gzip" && name != "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key == "q" {
+				var err error
+				q, err = strconv.ParseFloat(value, 64)
+				if err != nil {
+					q = 0
+				}
+			}
+		}
+		return q > 0
+	}
+
+	return false
+}
+
+// Copy the response body from r to w, gzip compressed.
+func copyGzip(w io.Writer, r io.Reader) error {
+	gz := gzipWriterPool.Get().(*gzip.Writer)
+	defer gzipWriterPool.Put(gz)
+	gz.Reset(w)
+
+	_, err := io.Copy(gz, r)
+	closeErr := gz.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Return the uncompressed contents of the gzip compressed data, or an
+// error if they are larger than limit bytes.
+func gunzip(data []byte, limit int64) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	return readAllLimited(gz, limit)
+}
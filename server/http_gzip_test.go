@@ -0,0 +1,199 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	testCases := map[string]bool{
+		"":                  false,
+		"zstd":              false,
+		"gzip":              true,
+		"deflate, gzip":     true,
+		"gzip;q=0.5, zstd":  true,
+		"gzip;q=0":          false,
+		"*":                 true,
+		"identity, *;q=0":   false,
+		"gzip;q=invalid":    false,
+		"br, gzip ; q=1.0 ": true,
+	}
+
+	for acceptEncoding, expected := range testCases {
+		if acceptsGzip(acceptEncoding) != expected {
+			t.Errorf("Expected acceptsGzip(%q) to be %v", acceptEncoding, expected)
+		}
+	}
+}
+
+func newGzipTestCache(t *testing.T, config *GzipConfig) *httpCache {
+	cacheDir := testutils.TempDir(t)
+	t.Cleanup(func() { os.RemoveAll(cacheDir) })
+
+	c, err := disk.New(cacheDir, 100*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), false, false,
+		nil, false, false, config, validate.SymlinksAllow, false, PeerConfig{}, "").(*httpCache)
+}
+
+func gzipData(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	err := copyGzip(&buf, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGzipResponses(t *testing.T) {
+	h := newGzipTestCache(t, &GzipConfig{MinSize: 1024, MaxConcurrent: 1})
+
+	small, smallHash := testutils.RandomDataAndHash(100)
+	large, largeHash := testutils.RandomDataAndHash(4096)
+	for _, tc := range []struct {
+		data []byte
+		hash string
+	}{{small, smallHash}, {large, largeHash}} {
+		rr := httptest.NewRecorder()
+		h.CacheHandler(rr, httptest.NewRequest(http.MethodPut, "/cas/"+tc.hash, bytes.NewReader(tc.data)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		hash           string
+		data           []byte
+		acceptEncoding string
+		gzip           bool
+	}{
+		{"large", largeHash, large, "gzip", true},
+		{"small", smallHash, small, "gzip", false},
+		{"not accepted", largeHash, large, "", false},
+		{"zstd preferred", largeHash, large, "zstd, gzip", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/cas/"+tc.hash, nil)
+			r.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			rr := httptest.NewRecorder()
+			h.CacheHandler(rr, r)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+			}
+			if rr.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Expected Vary: Accept-Encoding, got %q", rr.Header().Get("Vary"))
+			}
+
+			if !tc.gzip {
+				if ce := rr.Header().Get("Content-Encoding"); ce == "gzip" {
+					t.Error("Expected an uncompressed response")
+				}
+				return
+			}
+
+			if ce := rr.Header().Get("Content-Encoding"); ce != "gzip" {
+				t.Fatalf("Expected a gzip compressed response, got Content-Encoding %q", ce)
+			}
+			if rr.Header().Get("Content-Length") != "" {
+				t.Error("Expected no Content-Length for a gzip compressed response")
+			}
+			found, err := gunzip(rr.Body.Bytes(), int64(len(tc.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(found, tc.data) {
+				t.Error("Unexpected data in the gzip compressed response")
+			}
+		})
+	}
+
+	// Responses are sent uncompressed while the limit of concurrent
+	// compressions is reached.
+	h.gzip.slots <- struct{}{}
+	defer h.gzip.release()
+
+	r := httptest.NewRequest(http.MethodGet, "/cas/"+largeHash, nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	h.CacheHandler(rr, r)
+	if ce := rr.Header().Get("Content-Encoding"); ce == "gzip" {
+		t.Error("Expected an uncompressed response while compressions are limited")
+	}
+	if !bytes.Equal(rr.Body.Bytes(), large) {
+		t.Error("Unexpected data in the uncompressed response")
+	}
+}
+
+func TestGzipDisabled(t *testing.T) {
+	h := newGzipTestCache(t, nil)
+
+	data, hash := testutils.RandomDataAndHash(4096)
+	rr := httptest.NewRecorder()
+	h.CacheHandler(rr, httptest.NewRequest(http.MethodPut, "/cas/"+hash, bytes.NewReader(data)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/cas/"+hash, nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	rr = httptest.NewRecorder()
+	h.CacheHandler(rr, r)
+	if ce := rr.Header().Get("Content-Encoding"); ce == "gzip" {
+		t.Error("Expected an uncompressed response")
+	}
+	if rr.Header().Get("Vary") != "" {
+		t.Error("Expected no Vary header")
+	}
+}
+
+func TestGzipUploads(t *testing.T) {
+	h := newGzipTestCache(t, nil)
+
+	for _, path := range []string{"/cas/", "/ac/"} {
+		data, hash := testutils.RandomDataAndHash(1024)
+
+		r := httptest.NewRequest(http.MethodPut, path+hash, bytes.NewReader(gzipData(t, data)))
+		r.Header.Set("Content-Encoding", "gzip")
+		r.Header.Set("X-Digest-SizeBytes", strconv.Itoa(len(data)))
+		rr := httptest.NewRecorder()
+		h.CacheHandler(rr, r)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d: %s", http.StatusOK, path, rr.Code, rr.Body.String())
+		}
+
+		rr = httptest.NewRecorder()
+		h.CacheHandler(rr, httptest.NewRequest(http.MethodGet, path+hash, nil))
+		found, err := io.ReadAll(rr.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(found, data) {
+			t.Errorf("Unexpected data for %s", path)
+		}
+	}
+
+	// Invalid gzip data is rejected.
+	data, hash := testutils.RandomDataAndHash(1024)
+	r := httptest.NewRequest(http.MethodPut, "/cas/"+hash, bytes.NewReader(data))
+	r.Header.Set("Content-Encoding", "gzip")
+	r.Header.Set("X-Digest-SizeBytes", strconv.Itoa(len(data)))
+	rr := httptest.NewRecorder()
+	h.CacheHandler(rr, r)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for invalid gzip data, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/buchgr/bazel-remote/v2/utils/bufpool"
+
+	"github.com/slok/go-http-metrics/middleware"
+)
+
+// MetricsHandler returns an http.Handler which measures the requests
+// served by h with m, like go-http-metrics's std.Handler. Unlike that
+// handler, the http.ResponseWriter passed to h implements io.ReaderFrom,
+// so that blobs can still be sent with sendfile(2).
+func MetricsHandler(handlerID string, m middleware.Middleware, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mw := &metricsResponseWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+		reporter := &metricsReporter{w: mw, r: r}
+
+		m.Measure(handlerID, reporter, func() {
+			h.ServeHTTP(mw, r)
+		})
+	})
+}
+
+type metricsReporter struct {
+	w *metricsResponseWriter
+	r *http.Request
+}
+
+func (m *metricsReporter) Method() string           { return m.r.Method }
+func (m *metricsReporter) Context() context.Context { return m.r.Context() }
+func (m *metricsReporter) URLPath() string          { return m.r.URL.Path }
+func (m *metricsReporter) StatusCode() int          { return m.w.statusCode }
+func (m *metricsReporter) BytesWritten() int64      { return m.w.bytesWritten }
+
+// An http.ResponseWriter which records the status code and the number of
+// bytes written.
+type metricsResponseWriter struct {
+	http.ResponseWriter
+	statusCode   int
+	bytesWritten int64
+}
+
+func (w *metricsResponseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *metricsResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.bytesWritten += int64(n)
+	return n, err
+}
+
+func (w *metricsResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = bufpool.Copy(w.ResponseWriter, src)
+	}
+	w.bytesWritten += n
+	return n, err
+}
+
+func (w *metricsResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the http.ResponseWriter does not implement http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+func (w *metricsResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -15,10 +16,14 @@ import (
 	"testing"
 
 	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/cluster"
 	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	"github.com/buchgr/bazel-remote/v2/cache/replication"
 	"github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
 
 	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+	"github.com/slok/go-http-metrics/middleware"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -37,7 +42,7 @@ func TestDownloadFile(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, false, false, "")
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
 
 	rr := httptest.NewRecorder()
 	handler := http.HandlerFunc(h.CacheHandler)
@@ -84,6 +89,55 @@ func TestDownloadFile(t *testing.T) {
 	}
 }
 
+// A ResponseRecorder which records whether ReadFrom was called with an
+// *os.File, which net/http can send with sendfile(2).
+type readFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFromFile bool
+}
+
+func (r *readFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	_, r.readFromFile = src.(*os.File)
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+func TestDownloadUncompressedFileZeroCopy(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	data, hash := testutils.RandomDataAndHash(1024)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize,
+		disk.WithAccessLogger(testutils.NewSilentLogger()),
+		disk.WithStorageMode("uncompressed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
+
+	handlers := map[string]http.Handler{
+		"plain":   http.HandlerFunc(h.CacheHandler),
+		"metrics": MetricsHandler("GET", middleware.New(middleware.Config{}), http.HandlerFunc(h.CacheHandler)),
+	}
+
+	handlers["plain"].ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/cas/"+hash, bytes.NewReader(data)))
+
+	for name, handler := range handlers {
+		rr := &readFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/cas/"+hash, nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d", name, http.StatusOK, rr.Code)
+		}
+		if !rr.readFromFile {
+			t.Errorf("%s: expected the blob to be passed to ReadFrom as an *os.File", name)
+		}
+		if !bytes.Equal(rr.Body.Bytes(), data) {
+			t.Errorf("%s: received the wrong content", name)
+		}
+	}
+}
+
 func TestUploadFilesConcurrently(t *testing.T) {
 	cacheDir := testutils.TempDir(t)
 	defer os.RemoveAll(cacheDir)
@@ -105,7 +159,7 @@ func TestUploadFilesConcurrently(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, false, false, "")
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
 	handler := http.HandlerFunc(h.CacheHandler)
 
 	var wg sync.WaitGroup
@@ -169,7 +223,7 @@ func TestUploadSameFileConcurrently(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, false, false, "")
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
 	handler := http.HandlerFunc(h.CacheHandler)
 
 	var wg sync.WaitGroup
@@ -210,14 +264,14 @@ func TestUploadCorruptedFile(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, false, false, "")
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
 	rr := httptest.NewRecorder()
 	handler := http.HandlerFunc(h.CacheHandler)
 	handler.ServeHTTP(rr, r)
 
-	if status := rr.Code; status != http.StatusInternalServerError {
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
 		t.Error("Handler returned wrong status code",
-			"expected", http.StatusInternalServerError,
+			"expected", http.StatusUnprocessableEntity,
 			"got", status)
 	}
 
@@ -238,6 +292,294 @@ func TestUploadCorruptedFile(t *testing.T) {
 	}
 }
 
+func TestUploadDigestMismatch(t *testing.T) {
+	data, hash := testutils.RandomDataAndHash(1024)
+	otherData, _ := testutils.RandomDataAndHash(1024)
+
+	testCases := []struct {
+		storageMode   string
+		verifyDigests []cache.EntryKind
+		path          string
+		header        string // A peer header which the client sets to "1".
+		expected      int
+	}{
+		// CAS uploads are verified, even if they are stored uncompressed.
+		{"zstd", nil, "/cas/", "", http.StatusUnprocessableEntity},
+		{"uncompressed", nil, "/cas/", "", http.StatusUnprocessableEntity},
+
+		// RAW uploads, ie AC uploads without validation, are only
+		// verified if enabled.
+		{"zstd", nil, "/ac/", "", http.StatusOK},
+		{"zstd", []cache.EntryKind{cache.RAW}, "/ac/", "", http.StatusUnprocessableEntity},
+
+		// Peer headers without the peer secret don't skip verification.
+		{"zstd", nil, "/cas/", cluster.Header, http.StatusUnprocessableEntity},
+		{"zstd", nil, "/cas/", replication.Header, http.StatusUnprocessableEntity},
+		{"zstd", []cache.EntryKind{cache.RAW}, "/ac/", cluster.Header, http.StatusUnprocessableEntity},
+		{"zstd", []cache.EntryKind{cache.RAW}, "/ac/", replication.Header, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		cacheDir := testutils.TempDir(t)
+		defer os.RemoveAll(cacheDir)
+
+		c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithStorageMode(tc.storageMode),
+			disk.WithAccessLogger(testutils.NewSilentLogger()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		peers := PeerConfig{Secret: "s3cret", Cluster: true, Replication: true}
+		h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), false, false,
+			tc.verifyDigests, false, false, nil, validate.SymlinksAllow, false, peers, "")
+
+		rr := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, tc.path+hash, bytes.NewReader(otherData))
+		if tc.header != "" {
+			r.Header.Set(tc.header, "1")
+		}
+		h.CacheHandler(rr, r)
+		if rr.Code != tc.expected {
+			t.Errorf("Expected status %d for %+v, got %d: %s", tc.expected, tc, rr.Code, rr.Body.String())
+		}
+
+		// Uploads which match are accepted.
+		rr = httptest.NewRecorder()
+		h.CacheHandler(rr, httptest.NewRequest(http.MethodPut, tc.path+hash, bytes.NewReader(data)))
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status %d for %+v, got %d: %s", http.StatusOK, tc, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestForwardedHeaderFromClient(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An action result that refers to a blob which is not in the cache,
+	// so it is only served if it is not validated.
+	_, missingHash := testutils.RandomDataAndHash(16)
+	ar := &pb.ActionResult{
+		OutputFiles: []*pb.OutputFile{{
+			Path:   "foo",
+			Digest: &pb.Digest{Hash: missingHash, SizeBytes: 16},
+		}},
+	}
+	data, err := proto.Marshal(ar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashBytes := sha256.Sum256(data)
+	hash := hex.EncodeToString(hashBytes[:])
+	err = c.Put(context.Background(), cache.AC, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		peers    PeerConfig
+		header   string
+		expected int
+	}{
+		{PeerConfig{}, "1", http.StatusNotFound},
+		{PeerConfig{Secret: "s3cret"}, "s3cret", http.StatusNotFound},
+		{PeerConfig{Secret: "s3cret", Cluster: true}, "wrong", http.StatusNotFound},
+		{PeerConfig{Secret: "s3cret", Cluster: true}, "s3cret", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, false, tc.peers, "")
+
+		r := httptest.NewRequest("GET", "/ac/"+hash, nil)
+		r.Header.Set(cluster.Header, tc.header)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(h.CacheHandler).ServeHTTP(rr, r)
+
+		if rr.Code != tc.expected {
+			t.Errorf("Expected status %d for %+v with header %q, got %d",
+				tc.expected, tc.peers, tc.header, rr.Code)
+		}
+		if tc.expected == http.StatusNotFound && r.Header.Get(cluster.Header) != "" {
+			t.Errorf("Expected the untrusted header to be removed for %+v", tc.peers)
+		}
+	}
+}
+
+func TestForwardedHeaderACKeyMangling(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	peers := PeerConfig{Secret: "s3cret", Cluster: true}
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, true, nil, false, false, nil, validate.SymlinksAllow, false, peers, "")
+
+	testCases := []struct {
+		header  string
+		mangled bool
+	}{
+		// AC keys are only left as they are for cluster members, which
+		// have already mangled them.
+		{"1", true},
+		{"s3cret", false},
+	}
+
+	for _, tc := range testCases {
+		data, err := proto.Marshal(&pb.ActionResult{StdoutRaw: []byte(tc.header)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashBytes := sha256.Sum256(data)
+		hash := hex.EncodeToString(hashBytes[:])
+
+		r := httptest.NewRequest(http.MethodPut, "/foo/ac/"+hash, bytes.NewReader(data))
+		r.Header.Set(cluster.Header, tc.header)
+		rr := httptest.NewRecorder()
+		h.CacheHandler(rr, r)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+
+		key := hash
+		if tc.mangled {
+			key = cache.TransformActionCacheKey(hash, "foo", testutils.NewSilentLogger())
+		}
+		found, _ := c.Contains(context.Background(), cache.AC, key, -1)
+		if !found {
+			t.Errorf("Expected the upload with header %q to be stored with key %s", tc.header, key)
+		}
+	}
+}
+
+func TestReplicatedHeaderFromClient(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 1024*1024, disk.WithStandby(),
+		disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		peers    PeerConfig
+		header   string
+		expected int
+	}{
+		{PeerConfig{}, "1", http.StatusServiceUnavailable},
+		{PeerConfig{Secret: "s3cret"}, "s3cret", http.StatusServiceUnavailable},
+		{PeerConfig{Secret: "s3cret", Replication: true}, "wrong", http.StatusServiceUnavailable},
+		{PeerConfig{Secret: "s3cret", Replication: true}, "s3cret", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, false, tc.peers, "")
+
+		data, hash := testutils.RandomDataAndHash(64)
+		r := httptest.NewRequest("PUT", "/cas/"+hash, bytes.NewReader(data))
+		r.Header.Set(replication.Header, tc.header)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(h.CacheHandler).ServeHTTP(rr, r)
+
+		if rr.Code != tc.expected {
+			t.Errorf("Expected status %d for %+v with header %q, got %d",
+				tc.expected, tc.peers, tc.header, rr.Code)
+		}
+	}
+}
+
+func TestLookupHeaders(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), false, false, nil, false, false, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
+
+	data, hash := testutils.RandomDataAndHash(1024)
+
+	rr := httptest.NewRecorder()
+	h.CacheHandler(rr, httptest.NewRequest(http.MethodGet, "/cas/"+hash, nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+	if source := rr.Header().Get(cacheSourceHeader); source != "miss" {
+		t.Errorf("Expected a miss, got %q", source)
+	}
+
+	for _, path := range []string{"/cas/", "/ac/"} {
+		rr = httptest.NewRecorder()
+		h.CacheHandler(rr, httptest.NewRequest(http.MethodPut, path+hash, bytes.NewReader(data)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+	}
+
+	testCases := []struct {
+		path        string
+		compression string
+	}{
+		{"/cas/", "zstd"},
+		{"/ac/", "identity"},
+	}
+	for _, tc := range testCases {
+		rr = httptest.NewRecorder()
+		h.CacheHandler(rr, httptest.NewRequest(http.MethodGet, tc.path+hash, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		expected := map[string]string{
+			cacheSourceHeader:       "local",
+			storedCompressionHeader: tc.compression,
+			servedSizeHeader:        "1024",
+		}
+		for header, value := range expected {
+			if found := rr.Header().Get(header); found != value {
+				t.Errorf("Expected %s: %s for %s, got %q", header, value, tc.path, found)
+			}
+		}
+	}
+}
+
+func TestUploadActionResultSymlinkPolicy(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 10*disk.BlockSize, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksRejectAbsolute, false, PeerConfig{}, "")
+
+	for target, expected := range map[string]int{
+		"relative/target":  http.StatusOK,
+		"/absolute/target": http.StatusBadRequest,
+	} {
+		data, err := proto.Marshal(&pb.ActionResult{
+			OutputSymlinks: []*pb.OutputSymlink{{Path: "link", Target: target}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, hash := testutils.RandomDataAndHash(32)
+
+		rr := httptest.NewRecorder()
+		h.CacheHandler(rr, httptest.NewRequest(http.MethodPut, "/ac/"+hash, bytes.NewReader(data)))
+		if rr.Code != expected {
+			t.Errorf("Expected status %d for target %q, got %d", expected, target, rr.Code)
+		}
+	}
+}
+
 func TestUploadEmptyActionResult(t *testing.T) {
 	cacheDir := testutils.TempDir(t)
 	defer os.RemoveAll(cacheDir)
@@ -250,11 +592,11 @@ func TestUploadEmptyActionResult(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	validate := true
+	validateAC := true
 	mangle := false
 	checkClientCertForReads := false
 	checkClientCertForWrites := false
-	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), validate, mangle, checkClientCertForReads, checkClientCertForWrites, "")
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), validateAC, mangle, nil, checkClientCertForReads, checkClientCertForWrites, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
 	rr := httptest.NewRecorder()
 	handler := http.HandlerFunc(h.CacheHandler)
 	handler.ServeHTTP(rr, r)
@@ -299,6 +641,7 @@ func TestUploadEmptyActionResult(t *testing.T) {
 func TestEmptyBlobAvailable(t *testing.T) {
 	testEmptyBlobAvailable(t, "HEAD")
 	testEmptyBlobAvailable(t, "GET")
+	testEmptyBlobAvailable(t, "PUT")
 }
 
 func testEmptyBlobAvailable(t *testing.T, method string) {
@@ -312,11 +655,11 @@ func testEmptyBlobAvailable(t *testing.T, method string) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	validate := true
+	validateAC := true
 	mangle := false
 	checkClientCertForReads := false
 	checkClientCertForWrites := false
-	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), validate, mangle, checkClientCertForReads, checkClientCertForWrites, "")
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), validateAC, mangle, nil, checkClientCertForReads, checkClientCertForWrites, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
 	rr := httptest.NewRecorder()
 	handler := http.HandlerFunc(h.CacheHandler)
 	handler.ServeHTTP(rr, r)
@@ -339,7 +682,7 @@ func TestStatusPage(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, false, false, "")
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
 	rr := httptest.NewRecorder()
 	handler := http.HandlerFunc(h.StatusPageHandler)
 	handler.ServeHTTP(rr, r)
@@ -483,7 +826,7 @@ func TestRemoteReturnsNotFound(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	h := NewHTTPCache(emptyCache, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, false, false, "")
+	h := NewHTTPCache(emptyCache, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
 	// create a fake http.Request
 	_, hash := testutils.RandomDataAndHash(1024)
 	url, _ := url.Parse(fmt.Sprintf("http://localhost:8080/ac/%s", hash))
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpc_status "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/buchgr/bazel-remote/v2/utils/limiter"
+)
+
+// Returns the error for a gRPC request which was rejected by a limiter,
+// which asks the client to retry after the given delay.
+func tooManyRequestsError(retryAfter time.Duration) error {
+	st := grpc_status.New(codes.ResourceExhausted, "too many concurrent requests")
+	st, err := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(retryAfter),
+	}, &errdetails.QuotaFailure{
+		Violations: []*errdetails.QuotaFailure_Violation{{
+			Subject:     "max_concurrent_requests",
+			Description: "too many concurrent requests",
+		}},
+	})
+	if err != nil {
+		return grpc_status.Error(codes.ResourceExhausted, "too many concurrent requests")
+	}
+	return st.Err()
+}
+
+// LimitHTTP wraps handler, and rejects requests with status 429 if they
+// would exceed the limiter's limits, with a Retry-After header which
+// grows with the number of rejected requests.
+func LimitHTTP(handler http.HandlerFunc, l *limiter.Limiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodPut:
+		default:
+			// Unsupported methods are rejected cheaply by the handler.
+			handler(w, r)
+			return
+		}
+
+		if !l.Acquire(r.Method) {
+			w.Header().Set("Retry-After", limiter.RetryAfterHeader(l.RetryAfter(r.Method)))
+			http.Error(w, "Too many concurrent requests", http.StatusTooManyRequests)
+			return
+		}
+		defer l.Release(r.Method)
+
+		handler(w, r)
+	}
+}
+
+// GrpcLimiter wraps a limiter.Limiter, and provides gRPC interceptors
+// that reject requests with RESOURCE_EXHAUSTED if they would exceed its
+// limits.
+type GrpcLimiter struct {
+	limiter *limiter.Limiter
+}
+
+// NewGrpcLimiter returns a GrpcLimiter that wraps the given limiter.Limiter.
+func NewGrpcLimiter(l *limiter.Limiter) *GrpcLimiter {
+	return &GrpcLimiter{limiter: l}
+}
+
+// Returns the limiter.Limiter endpoint name for a full gRPC method name,
+// eg "ByteStream/Write" for "/google.bytestream.ByteStream/Write".
+func grpcEndpoint(fullMethod string) string {
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if i := strings.LastIndex(service, "."); i >= 0 {
+		service = service[i+1:]
+	}
+	return service + "/" + method
+}
+
+// StreamServerInterceptor rejects streaming requests which would exceed
+// the concurrency limits.
+func (g *GrpcLimiter) StreamServerInterceptor(srv interface{},
+	ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+
+	if info.FullMethod == grpcHealthServiceName {
+		return handler(srv, ss)
+	}
+
+	endpoint := grpcEndpoint(info.FullMethod)
+	if !g.limiter.Acquire(endpoint) {
+		return tooManyRequestsError(g.limiter.RetryAfter(endpoint))
+	}
+	defer g.limiter.Release(endpoint)
+
+	return handler(srv, ss)
+}
+
+// UnaryServerInterceptor rejects unary requests which would exceed the
+// concurrency limits.
+func (g *GrpcLimiter) UnaryServerInterceptor(ctx context.Context,
+	req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+
+	if info.FullMethod == grpcHealthServiceName {
+		return handler(ctx, req)
+	}
+
+	endpoint := grpcEndpoint(info.FullMethod)
+	if !g.limiter.Acquire(endpoint) {
+		return nil, tooManyRequestsError(g.limiter.RetryAfter(endpoint))
+	}
+	defer g.limiter.Release(endpoint)
+
+	return handler(ctx, req)
+}
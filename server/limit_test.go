@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/limiter"
+)
+
+func TestLimitHTTP(t *testing.T) {
+	l := limiter.New(0, map[string]int{http.MethodPut: 1})
+
+	var nested *httptest.ResponseRecorder
+	handler := LimitHTTP(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/outer" {
+			return
+		}
+		// Make a second request while the first one is in flight.
+		nested = httptest.NewRecorder()
+		LimitHTTP(func(http.ResponseWriter, *http.Request) {}, l)(
+			nested, httptest.NewRequest(r.Method, "/inner", nil))
+	}, l)
+
+	for _, tc := range []struct {
+		method string
+		code   int
+	}{
+		{http.MethodPut, http.StatusTooManyRequests},
+		{http.MethodGet, http.StatusOK},
+	} {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(tc.method, "/outer", nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected the first %s to succeed, got %d", tc.method, rr.Code)
+		}
+		if nested.Code != tc.code {
+			t.Errorf("Expected a concurrent %s to get status %d, got %d", tc.method, tc.code, nested.Code)
+		}
+	}
+
+	if nested.Header().Get("Retry-After") != "" {
+		t.Error("Expected no Retry-After header on a successful request")
+	}
+
+	// The second request rejected within a second is asked to wait for
+	// the first one to be retried, plus up to half of that again.
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/outer", nil))
+	retryAfter := nested.Header().Get("Retry-After")
+	if retryAfter != "2" && retryAfter != "3" {
+		t.Errorf("Expected Retry-After: 2 or 3, got %q", retryAfter)
+	}
+}
+
+// Returns true if d is base plus the jitter added by limiter.Backoff.
+func withJitter(d time.Duration, base time.Duration) bool {
+	return d >= base && d < base+base/2
+}
+
+func TestGrpcEndpoint(t *testing.T) {
+	testCases := map[string]string{
+		"/google.bytestream.ByteStream/Write":                                         "ByteStream/Write",
+		"/build.bazel.remote.execution.v2.ContentAddressableStorage/FindMissingBlobs": "ContentAddressableStorage/FindMissingBlobs",
+		"/Service/Method": "Service/Method",
+	}
+	for fullMethod, expected := range testCases {
+		if endpoint := grpcEndpoint(fullMethod); endpoint != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, fullMethod, endpoint)
+		}
+	}
+}
+
+func TestGrpcLimiter(t *testing.T) {
+	gl := NewGrpcLimiter(limiter.New(1, nil))
+	info := &grpc.UnaryServerInfo{FullMethod: "/build.bazel.remote.execution.v2.ActionCache/GetActionResult"}
+
+	var nestedErr error
+	_, err := gl.UnaryServerInterceptor(context.Background(), nil, info,
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			_, nestedErr = gl.UnaryServerInterceptor(ctx, req, info,
+				func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+			return nil, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := status.Convert(nestedErr)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("Expected RESOURCE_EXHAUSTED for a concurrent request, got %v", nestedErr)
+	}
+	var retryInfo *errdetails.RetryInfo
+	for _, d := range st.Details() {
+		if ri, ok := d.(*errdetails.RetryInfo); ok {
+			retryInfo = ri
+		}
+	}
+	if retryInfo == nil || !withJitter(retryInfo.RetryDelay.AsDuration(), limiter.RetryAfter) {
+		t.Errorf("Expected a RetryInfo detail with delay %v plus jitter, got %v", limiter.RetryAfter, st.Details())
+	}
+
+	// Health checks are not limited.
+	healthInfo := &grpc.UnaryServerInfo{FullMethod: grpcHealthServiceName}
+	_, err = gl.UnaryServerInterceptor(context.Background(), nil, info,
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return gl.UnaryServerInterceptor(ctx, req, healthInfo,
+				func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		})
+	if err != nil {
+		t.Errorf("Expected health checks not to be limited, got %v", err)
+	}
+}
+
+func TestGRPCCacheErrorRetryInfo(t *testing.T) {
+	err := gRPCCacheError(&cache.Error{Code: http.StatusTooManyRequests, Text: "busy"},
+		codes.Internal, "busy")
+
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("Expected RESOURCE_EXHAUSTED for a throttled write, got %v", err)
+	}
+	var retryInfo *errdetails.RetryInfo
+	for _, d := range st.Details() {
+		if ri, ok := d.(*errdetails.RetryInfo); ok {
+			retryInfo = ri
+		}
+	}
+	if retryInfo == nil || !withJitter(retryInfo.RetryDelay.AsDuration(), limiter.RetryAfter) {
+		t.Errorf("Expected a RetryInfo detail with delay %v plus jitter, got %v", limiter.RetryAfter, st.Details())
+	}
+
+	// Other errors have no details.
+	err = gRPCCacheError(&cache.Error{Code: http.StatusBadRequest, Text: "bad"},
+		codes.Internal, "bad")
+	st = status.Convert(err)
+	if st.Code() != codes.InvalidArgument || len(st.Details()) != 0 {
+		t.Errorf("Expected an INVALID_ARGUMENT error without details, got %v", st.Proto())
+	}
+}
+
+func TestGRPCCacheErrorDetails(t *testing.T) {
+	// Quota errors have a QuotaFailure detail, and no RetryInfo.
+	err := gRPCCacheError(&cache.Error{
+		Code:         http.StatusInsufficientStorage,
+		Text:         "Blob size 100 is larger than the quota of instance \"a\" (10)",
+		QuotaSubject: "instance:a",
+	}, codes.Internal, "quota")
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted || len(st.Details()) != 1 {
+		t.Fatalf("Expected RESOURCE_EXHAUSTED with one detail, got %v", st.Proto())
+	}
+	qf, ok := st.Details()[0].(*errdetails.QuotaFailure)
+	if !ok || len(qf.Violations) != 1 || qf.Violations[0].Subject != "instance:a" {
+		t.Errorf("Expected a QuotaFailure for instance:a, got %v", st.Details())
+	}
+
+	// Throttled writes have both.
+	err = gRPCCacheError(&cache.Error{
+		Code:         http.StatusTooManyRequests,
+		Text:         "busy",
+		QuotaSubject: "max_inflight_upload_size",
+	}, codes.Internal, "busy")
+	st = status.Convert(err)
+	if st.Code() != codes.ResourceExhausted || len(st.Details()) != 2 {
+		t.Errorf("Expected RESOURCE_EXHAUSTED with two details, got %v", st.Proto())
+	}
+
+	// Unavailable errors can be retried.
+	err = gRPCCacheError(&cache.Error{Code: http.StatusServiceUnavailable, Text: "standby"},
+		codes.Internal, "standby")
+	st = status.Convert(err)
+	if st.Code() != codes.Unavailable || len(st.Details()) != 1 {
+		t.Fatalf("Expected UNAVAILABLE with one detail, got %v", st.Proto())
+	}
+	ri, ok := st.Details()[0].(*errdetails.RetryInfo)
+	if !ok || !withJitter(ri.RetryDelay.AsDuration(), unavailableRetryDelay) {
+		t.Errorf("Expected a RetryInfo detail with delay %v plus jitter, got %v", unavailableRetryDelay, st.Details())
+	}
+
+	// Concurrency limits have both too.
+	st = status.Convert(tooManyRequestsError(limiter.RetryAfter))
+	if len(st.Details()) != 2 {
+		t.Errorf("Expected a RetryInfo and a QuotaFailure detail, got %v", st.Details())
+	}
+}
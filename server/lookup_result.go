@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// The response headers which tell HTTP clients how a read was served.
+// gRPC clients receive the same values in the lowercase trailer metadata
+// keys of the same names.
+const (
+	// "local", "proxy", "peer" or "miss".
+	cacheSourceHeader = "X-Bazel-Remote-Cache-Source"
+
+	// "zstd" or "identity", if known.
+	storedCompressionHeader = "X-Bazel-Remote-Stored-Compression"
+
+	// The uncompressed size of the data served.
+	servedSizeHeader = "X-Bazel-Remote-Served-Size"
+)
+
+const sourceMiss = "miss"
+
+// Return the headers which describe lr.
+func lookupHeaders(lr *cache.LookupResult) map[string]string {
+	if lr.Source == "" {
+		return map[string]string{cacheSourceHeader: sourceMiss}
+	}
+
+	headers := map[string]string{
+		cacheSourceHeader: lr.Source,
+		servedSizeHeader:  strconv.FormatInt(lr.ServedSize, 10),
+	}
+	if lr.StoredCompression != "" {
+		headers[storedCompressionHeader] = lr.StoredCompression
+	}
+	return headers
+}
+
+// Set the response headers which describe lr.
+func setLookupHeaders(h http.Header, lr *cache.LookupResult) {
+	for key, value := range lookupHeaders(lr) {
+		h.Set(key, value)
+	}
+}
+
+// Return the gRPC trailer metadata which describes lr.
+func lookupTrailer(lr *cache.LookupResult) metadata.MD {
+	md := metadata.MD{}
+	for key, value := range lookupHeaders(lr) {
+		md.Set(key, value)
+	}
+	return md
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+)
+
+var metadataPath = regexp.MustCompile("^/?(.*/)?metadata/([a-z0-9._-]{1,64})/([a-f0-9]{64})$")
+
+// The maximum size of a metadata value.
+const maxMetadataSize = 64 * 1024
+
+// A metadata value, as stored in a RAW entry.
+type metadataRecord struct {
+	Expires     int64  `json:"expires"` // Unix time.
+	ContentType string `json:"content_type,omitempty"`
+	Value       []byte `json:"value"`
+}
+
+type metadataHandler struct {
+	cache       disk.Cache
+	maxTTL      time.Duration
+	errorLogger cache.Logger
+	now         func() time.Time
+
+	// Returns false after responding to requests without a required
+	// client certificate, see HTTPCache.CheckClientCert.
+	checkClientCert func(w http.ResponseWriter, r *http.Request) bool
+}
+
+// MetadataHTTP wraps handler, and serves GET and PUT requests for
+// [<instance>/]metadata/<namespace>/<sha256> itself, so that external
+// tools can record small values about blobs, eg virus scan verdicts,
+// for later pipeline stages. PUT stores the request body until the
+// duration given by the ttl query parameter has passed, at most maxTTL,
+// which is also the default. GET returns it, if it hasn't expired.
+// checkClientCert is called first, like the client certificate checks
+// of CacheHandler for the other paths.
+func MetadataHTTP(handler http.HandlerFunc, c disk.Cache, maxTTL time.Duration,
+	checkClientCert func(w http.ResponseWriter, r *http.Request) bool, errorLogger cache.Logger) http.HandlerFunc {
+
+	m := &metadataHandler{
+		cache:           c,
+		maxTTL:          maxTTL,
+		errorLogger:     errorLogger,
+		now:             time.Now,
+		checkClientCert: checkClientCert,
+	}
+
+	return m.wrap(handler)
+}
+
+func (m *metadataHandler) wrap(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parts := metadataPath.FindStringSubmatch(r.URL.Path)
+		if parts == nil {
+			handler(w, r)
+			return
+		}
+
+		if !m.checkClientCert(w, r) {
+			return
+		}
+
+		m.serveHTTP(w, r, strings.TrimSuffix(parts[1], "/"), parts[2], parts[3])
+	}
+}
+
+// Returns the hash of the RAW entry which stores the metadata value of
+// the blob with the given hash in the given namespace.
+func metadataKey(namespace string, hash string) string {
+	sum := sha256.Sum256([]byte("metadata/" + namespace + "/" + hash))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *metadataHandler) serveHTTP(w http.ResponseWriter, r *http.Request, instance string, namespace string, hash string) {
+	defer r.Body.Close()
+
+	ctx := cache.WithInstanceName(r.Context(), instance)
+	key := metadataKey(namespace, hash)
+
+	switch r.Method {
+	case http.MethodGet:
+		rc, _, err := m.cache.Get(ctx, cache.RAW, key, -1, 0)
+		if err != nil {
+			m.errorLogger.Printf("Failed to load the %s metadata of %s: %v", namespace, hash, err)
+			http.Error(w, "Failed to load the metadata", http.StatusInternalServerError)
+			return
+		}
+		if rc == nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		var rec metadataRecord
+		err = json.NewDecoder(rc).Decode(&rec)
+		rc.Close()
+		if err != nil {
+			m.errorLogger.Printf("Invalid %s metadata of %s: %v", namespace, hash, err)
+			http.Error(w, "Failed to load the metadata", http.StatusInternalServerError)
+			return
+		}
+
+		expires := time.Unix(rec.Expires, 0)
+		if !m.now().Before(expires) {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		if rec.ContentType != "" {
+			w.Header().Set("Content-Type", rec.ContentType)
+		}
+		w.Header().Set("Expires", expires.UTC().Format(http.TimeFormat))
+		_, _ = w.Write(rec.Value)
+
+	case http.MethodPut:
+		ttl := m.maxTTL
+		if s := r.URL.Query().Get("ttl"); s != "" {
+			var err error
+			ttl, err = time.ParseDuration(s)
+			if err != nil || ttl <= 0 {
+				http.Error(w, fmt.Sprintf("Invalid ttl: %q", html.EscapeString(s)), http.StatusBadRequest)
+				return
+			}
+			if ttl > m.maxTTL {
+				ttl = m.maxTTL
+			}
+		}
+
+		value, err := io.ReadAll(io.LimitReader(r.Body, maxMetadataSize+1))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(value) > maxMetadataSize {
+			msg := fmt.Sprintf("Metadata too large, the limit is %d bytes", maxMetadataSize)
+			http.Error(w, msg, http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		data, err := json.Marshal(metadataRecord{
+			Expires:     m.now().Add(ttl).Unix(),
+			ContentType: r.Header.Get("Content-Type"),
+			Value:       value,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = m.cache.Put(ctx, cache.RAW, key, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			code := http.StatusInternalServerError
+			if cerr, ok := err.(*cache.Error); ok {
+				code = cerr.Code
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+	default:
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+)
+
+func TestMetadataHTTP(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1000000, 0)
+	m := &metadataHandler{
+		cache:       c,
+		maxTTL:      24 * time.Hour,
+		errorLogger: testutils.NewSilentLogger(),
+		now:         func() time.Time { return now },
+
+		checkClientCert: allowAll,
+	}
+
+	fallthroughs := 0
+	handler := m.wrap(func(w http.ResponseWriter, r *http.Request) {
+		fallthroughs++
+	})
+
+	do := func(method string, url string, body []byte, expectedCode int) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r := httptest.NewRequest(method, url, bytes.NewReader(body))
+		if body != nil {
+			r.Header.Set("Content-Type", "application/json")
+		}
+		handler(rr, r)
+		if rr.Code != expectedCode {
+			t.Fatalf("Expected status %d for %s %s, got %d: %s", expectedCode, method, url, rr.Code, rr.Body)
+		}
+		return rr
+	}
+
+	_, hash := testutils.RandomDataAndHash(100)
+	url := "/metadata/virus-scan/" + hash
+	verdict := []byte(`{"verdict":"clean","scanner":"clamav"}`)
+
+	do(http.MethodGet, url, nil, http.StatusNotFound)
+	do(http.MethodPut, url+"?ttl=1h", verdict, http.StatusOK)
+
+	rr := do(http.MethodGet, url, nil, http.StatusOK)
+	if !bytes.Equal(rr.Body.Bytes(), verdict) {
+		t.Errorf("Expected %s, got %s", verdict, rr.Body)
+	}
+	if rr.Header().Get("Content-Type") != "application/json" || rr.Header().Get("Expires") == "" {
+		t.Errorf("Expected the content type and expiry time, got %v", rr.Header())
+	}
+
+	// Values expire after their TTL.
+	now = now.Add(time.Hour)
+	do(http.MethodGet, url, nil, http.StatusNotFound)
+
+	// TTLs are capped at the maximum.
+	do(http.MethodPut, url+"?ttl=48h", verdict, http.StatusOK)
+	now = now.Add(23 * time.Hour)
+	do(http.MethodGet, url, nil, http.StatusOK)
+	now = now.Add(time.Hour)
+	do(http.MethodGet, url, nil, http.StatusNotFound)
+
+	do(http.MethodPut, url, verdict, http.StatusOK)
+
+	// Namespaces are separate, instances are not.
+	do(http.MethodGet, "/metadata/license-scan/"+hash, nil, http.StatusNotFound)
+	do(http.MethodGet, "/team-a/metadata/virus-scan/"+hash, nil, http.StatusOK)
+
+	do(http.MethodPut, url+"?ttl=forever", verdict, http.StatusBadRequest)
+	do(http.MethodPut, url+"?ttl=-1h", verdict, http.StatusBadRequest)
+	do(http.MethodPut, url, make([]byte, maxMetadataSize+1), http.StatusRequestEntityTooLarge)
+	do(http.MethodDelete, url, nil, http.StatusMethodNotAllowed)
+
+	// Other requests are passed through.
+	do(http.MethodGet, "/metadata/Invalid/"+hash, nil, http.StatusOK)
+	do(http.MethodGet, "/cas/"+hash, nil, http.StatusOK)
+	if fallthroughs != 2 {
+		t.Errorf("Expected 2 requests to be passed through, got %d", fallthroughs)
+	}
+}
+
+func TestMetadataHTTPClientCert(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := newClientCertHTTPCache(c)
+	handler := MetadataHTTP(h.CacheHandler, c, time.Hour, h.CheckClientCert, testutils.NewSilentLogger())
+
+	_, hash := testutils.RandomDataAndHash(100)
+	url := "/metadata/virus-scan/" + hash
+	verdict := []byte(`{"verdict":"clean"}`)
+
+	do := func(r *http.Request, expectedCode int) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler(rr, r)
+		if rr.Code != expectedCode {
+			t.Fatalf("Expected status %d for %s %s, got %d: %s", expectedCode, r.Method, r.URL, rr.Code, rr.Body)
+		}
+	}
+
+	// Writes need a client certificate.
+	do(httptest.NewRequest(http.MethodPut, url, bytes.NewReader(verdict)), http.StatusUnauthorized)
+	do(httptest.NewRequest(http.MethodGet, url, nil), http.StatusNotFound)
+
+	do(withClientCert(httptest.NewRequest(http.MethodPut, url, bytes.NewReader(verdict))), http.StatusOK)
+	do(httptest.NewRequest(http.MethodGet, url, nil), http.StatusOK)
+
+	// So do reads, without allow_unauthenticated_reads.
+	h = NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil,
+		true, true, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
+	handler = MetadataHTTP(h.CacheHandler, c, time.Hour, h.CheckClientCert, testutils.NewSilentLogger())
+	do(httptest.NewRequest(http.MethodGet, url, nil), http.StatusUnauthorized)
+	do(withClientCert(httptest.NewRequest(http.MethodGet, url, nil)), http.StatusOK)
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+)
+
+// The maximum size of webhook payloads, as sent by GitHub.
+const maxWebhookPayloadSize = 25 * 1024 * 1024
+
+// How long fetching the seed build's action cache keys may take.
+const seedFetchTimeout = time.Minute
+
+// A webhook which prewarms the cache when the configured ref is updated.
+type prewarmWebhook struct {
+	cache       disk.Cache
+	errorLogger cache.Logger
+	seedURL     string
+	secret      string
+	ref         string
+
+	// Held while a prewarm is running.
+	running sync.Mutex
+}
+
+// The fields of GitHub push events, and Gerrit change-merged and
+// ref-updated events, which say which ref was updated.
+type webhookEvent struct {
+	Ref string `json:"ref"`
+
+	Type   string `json:"type"`
+	Change struct {
+		Branch string `json:"branch"`
+	} `json:"change"`
+	RefUpdate struct {
+		RefName string `json:"refName"`
+	} `json:"refUpdate"`
+}
+
+// Returns the ref which the event updated, or "" if it didn't update one.
+func (e *webhookEvent) updatedRef() string {
+	switch e.Type {
+	case "":
+		return e.Ref
+	case "change-merged":
+		return "refs/heads/" + e.Change.Branch
+	case "ref-updated":
+		return e.RefUpdate.RefName
+	}
+	return ""
+}
+
+// PrewarmHTTP returns a webhook handler which, when ref is updated,
+// prewarms c with the action cache entries listed at seedURL. Requests
+// must be authenticated with secret, either as the key of a GitHub
+// X-Hub-Signature-256 header, or as an "Authorization: Bearer <secret>"
+// header, so the webhook doesn't require any other authentication.
+func PrewarmHTTP(c disk.Cache, seedURL string, secret string, ref string, errorLogger cache.Logger) http.Handler {
+	return &prewarmWebhook{
+		cache:       c,
+		errorLogger: errorLogger,
+		seedURL:     seedURL,
+		secret:      secret,
+		ref:         ref,
+	}
+}
+
+// Start a prewarm in the background if the request reports an update of
+// the configured ref.
+func (p *prewarmWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("Method '%s' not supported.", html.EscapeString(r.Method))
+		http.Error(w, msg, http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookPayloadSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !p.authenticated(r, body) {
+		http.Error(w, "Invalid webhook signature or token", http.StatusUnauthorized)
+		return
+	}
+
+	var event webhookEvent
+	err = json.Unmarshal(body, &event)
+	if err != nil {
+		http.Error(w, "Invalid webhook payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Eg GitHub ping events, pushes to other branches, or other Gerrit
+	// events.
+	if event.updatedRef() != p.ref {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if !p.running.TryLock() {
+		http.Error(w, "A prewarm is already running", http.StatusConflict)
+		return
+	}
+
+	go func() {
+		defer p.running.Unlock()
+		p.prewarm()
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Returns true if the request has a valid GitHub signature of body, or
+// the secret as a bearer token.
+func (p *prewarmWebhook) authenticated(r *http.Request, body []byte) bool {
+	if p.secret == "" {
+		return false
+	}
+
+	signature := r.Header.Get("X-Hub-Signature-256")
+	if signature != "" {
+		expected, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+		if err != nil {
+			return false
+		}
+		mac := hmac.New(sha256.New, []byte(p.secret))
+		mac.Write(body)
+		return hmac.Equal(mac.Sum(nil), expected)
+	}
+
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return found && subtle.ConstantTimeCompare([]byte(token), []byte(p.secret)) == 1
+}
+
+func (p *prewarmWebhook) prewarm() {
+	hashes, err := fetchSeedKeys(p.seedURL)
+	if err != nil {
+		p.errorLogger.Printf("Failed to fetch the seed build's action cache keys: %v", err)
+		return
+	}
+
+	report, err := p.cache.Prewarm(context.Background(), hashes)
+	if err != nil {
+		p.errorLogger.Printf("Failed to prewarm the cache: %v", err)
+		return
+	}
+
+	log.Printf("Prewarmed the cache with %d action results (%d missing) and %d blobs: %d downloaded, %d failed",
+		report.ActionResults, report.Missing, report.Blobs, report.Downloaded, report.Failed)
+}
+
+// Returns the action cache keys listed at seedURL, one per line. Empty
+// lines and lines starting with # are ignored.
+func fetchSeedKeys(seedURL string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), seedFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, seedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var hashes []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hashes = append(hashes, line)
+	}
+
+	return hashes, scanner.Err()
+}
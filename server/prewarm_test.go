@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+// A disk.Cache which reports the hashes it is asked to prewarm with.
+type prewarmCache struct {
+	disk.Cache
+	hashes chan []string
+}
+
+func (c *prewarmCache) Prewarm(ctx context.Context, acHashes []string) (disk.PrewarmReport, error) {
+	c.hashes <- acHashes
+	return disk.PrewarmReport{ActionResults: len(acHashes)}, nil
+}
+
+func TestPrewarm(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	seed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "# Action cache keys of the seed build.\n%s\n\n", hash)
+	}))
+	defer seed.Close()
+
+	signature := func(body string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	githubPush := `{"ref": "refs/heads/main", "after": "0123abc"}`
+	gerritMerge := `{"type": "change-merged", "change": {"branch": "main"}}`
+
+	tcs := []struct {
+		name     string
+		method   string
+		body     string
+		header   string
+		value    string
+		expected int
+	}{
+		{"GET", http.MethodGet, "", "Authorization", "Bearer secret", http.StatusMethodNotAllowed},
+		{"no credentials", http.MethodPost, githubPush, "", "", http.StatusUnauthorized},
+		{"wrong token", http.MethodPost, gerritMerge, "Authorization", "Bearer wrong", http.StatusUnauthorized},
+		{"token without scheme", http.MethodPost, gerritMerge, "Authorization", "secret", http.StatusUnauthorized},
+		{"basic credentials", http.MethodPost, gerritMerge, "Authorization", "Basic secret", http.StatusUnauthorized},
+		{"wrong signature", http.MethodPost, githubPush, "X-Hub-Signature-256", signature("{}"), http.StatusUnauthorized},
+		{"other branch", http.MethodPost, `{"ref": "refs/heads/feature"}`, "Authorization", "Bearer secret", http.StatusNoContent},
+		{"GitHub ping", http.MethodPost, `{"zen": "Keep it logically awesome."}`, "Authorization", "Bearer secret", http.StatusNoContent},
+		{"other Gerrit event", http.MethodPost, `{"type": "patchset-created", "change": {"branch": "main"}}`, "Authorization", "Bearer secret", http.StatusNoContent},
+		{"invalid payload", http.MethodPost, "not json", "Authorization", "Bearer secret", http.StatusBadRequest},
+		{"GitHub push", http.MethodPost, githubPush, "X-Hub-Signature-256", signature(githubPush), http.StatusAccepted},
+		{"Gerrit merge", http.MethodPost, gerritMerge, "Authorization", "Bearer secret", http.StatusAccepted},
+	}
+
+	for _, tc := range tcs {
+		c := &prewarmCache{hashes: make(chan []string)}
+		h := PrewarmHTTP(c, seed.URL, "secret", "refs/heads/main", testutils.NewSilentLogger())
+
+		send := func() int {
+			r := httptest.NewRequest(tc.method, "/prewarm", strings.NewReader(tc.body))
+			if tc.header != "" {
+				r.Header.Set(tc.header, tc.value)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, r)
+			return rr.Code
+		}
+
+		if code := send(); code != tc.expected {
+			t.Fatalf("%s: expected status %d, got %d", tc.name, tc.expected, code)
+		}
+		if tc.expected != http.StatusAccepted {
+			continue
+		}
+
+		// The prewarm blocks until its hashes are received below.
+		if code := send(); code != http.StatusConflict {
+			t.Errorf("%s: expected status %d while a prewarm is running, got %d",
+				tc.name, http.StatusConflict, code)
+		}
+
+		select {
+		case hashes := <-c.hashes:
+			if !reflect.DeepEqual(hashes, []string{hash}) {
+				t.Errorf("%s: expected a prewarm with %s, got %v", tc.name, hash, hashes)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("%s: expected a prewarm to start", tc.name)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	grpc_status "google.golang.org/grpc/status"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// The HTTP header and gRPC metadata key which clients can set to the
+// priority of their requests, "interactive" (the default) or "batch", eg
+// with Bazel's --remote_header=x-bazel-remote-priority=batch flag.
+const priorityHeader = "X-Bazel-Remote-Priority"
+
+// Returns ctx with the priority named value recorded by
+// cache.WithPriority, or ctx if value is empty.
+func withPriority(ctx context.Context, value string) (context.Context, error) {
+	if value == "" {
+		return ctx, nil
+	}
+
+	p, ok := cache.ParsePriority(strings.ToLower(value))
+	if !ok {
+		return ctx, fmt.Errorf("Invalid %s: %q, expected \"interactive\" or \"batch\"",
+			priorityHeader, value)
+	}
+
+	return cache.WithPriority(ctx, p), nil
+}
+
+// PriorityHTTP wraps handler, and records the priority of each request
+// from its X-Bazel-Remote-Priority header in its context. Requests with
+// invalid priorities are rejected with status 400.
+func PriorityHTTP(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := withPriority(r.Context(), r.Header.Get(priorityHeader))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		handler(w, r.WithContext(ctx))
+	}
+}
+
+// Returns ctx with the priority from the request's gRPC metadata.
+func withGRPCPriority(ctx context.Context) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+
+	values := md.Get(priorityHeader)
+	if len(values) == 0 {
+		return ctx, nil
+	}
+
+	ctx, err := withPriority(ctx, values[0])
+	if err != nil {
+		return ctx, grpc_status.Error(codes.InvalidArgument, err.Error())
+	}
+	return ctx, nil
+}
+
+// PriorityStreamServerInterceptor records the priority of streaming
+// requests from their x-bazel-remote-priority metadata in their context.
+func PriorityStreamServerInterceptor(srv interface{},
+	ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+
+	ctx, err := withGRPCPriority(ss.Context())
+	if err != nil {
+		return err
+	}
+	if ctx == ss.Context() {
+		return handler(srv, ss)
+	}
+
+	return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+}
+
+// PriorityUnaryServerInterceptor records the priority of unary requests
+// from their x-bazel-remote-priority metadata in their context.
+func PriorityUnaryServerInterceptor(ctx context.Context,
+	req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+
+	ctx, err := withGRPCPriority(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
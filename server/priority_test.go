@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+func TestPriorityHTTP(t *testing.T) {
+	var priority cache.Priority
+	handler := PriorityHTTP(func(w http.ResponseWriter, r *http.Request) {
+		priority = cache.RequestPriority(r.Context())
+	})
+
+	tcs := []struct {
+		header   string
+		status   int
+		priority cache.Priority
+	}{
+		{"", http.StatusOK, cache.PriorityInteractive},
+		{"interactive", http.StatusOK, cache.PriorityInteractive},
+		{"batch", http.StatusOK, cache.PriorityBatch},
+		{"Batch", http.StatusOK, cache.PriorityBatch},
+		{"urgent", http.StatusBadRequest, cache.PriorityInteractive},
+	}
+
+	for _, tc := range tcs {
+		priority = cache.PriorityInteractive
+
+		r := httptest.NewRequest(http.MethodGet, "/cas/"+emptySha256, nil)
+		if tc.header != "" {
+			r.Header.Set(priorityHeader, tc.header)
+		}
+		rr := httptest.NewRecorder()
+		handler(rr, r)
+
+		if rr.Code != tc.status {
+			t.Errorf("%q: expected status %d, got %d", tc.header, tc.status, rr.Code)
+		}
+		if priority != tc.priority {
+			t.Errorf("%q: expected priority %s, got %s", tc.header, tc.priority, priority)
+		}
+	}
+}
+
+func TestPriorityUnaryServerInterceptor(t *testing.T) {
+	var priority cache.Priority
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		priority = cache.RequestPriority(ctx)
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/build.bazel.remote.execution.v2.ContentAddressableStorage/FindMissingBlobs"}
+
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("x-bazel-remote-priority", "batch"))
+	_, err := PriorityUnaryServerInterceptor(ctx, nil, info, handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priority != cache.PriorityBatch {
+		t.Fatal("Expected a batch priority, got", priority)
+	}
+
+	ctx = metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("x-bazel-remote-priority", "urgent"))
+	_, err = PriorityUnaryServerInterceptor(ctx, nil, info, handler)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatal("Expected an InvalidArgument error, got", err)
+	}
+}
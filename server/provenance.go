@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+// RecordUploaderHTTP wraps handler, and records the identity of the
+// client of PUT requests in their context, see clientIdentity, so that
+// the provenance of the entries they upload can be recorded.
+func RecordUploaderHTTP(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			handler(w, r)
+			return
+		}
+
+		username, _, _ := r.BasicAuth()
+		ctx := cache.WithUploader(r.Context(), clientIdentity(username, r.TLS, r.RemoteAddr))
+		handler(w, r.WithContext(ctx))
+	}
+}
+
+// Returns ctx with the identity of the client of the gRPC request
+// recorded by cache.WithUploader.
+func withUploader(ctx context.Context) context.Context {
+	_, identity := grpcClientIdentity(ctx)
+	return cache.WithUploader(ctx, identity)
+}
+
+// UploaderStreamServerInterceptor records the identity of the client of
+// streaming requests in their context, so that the provenance of the
+// entries they upload can be recorded.
+func UploaderStreamServerInterceptor(srv interface{},
+	ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+
+	return handler(srv, &contextServerStream{ServerStream: ss, ctx: withUploader(ss.Context())})
+}
+
+// UploaderUnaryServerInterceptor records the identity of the client of
+// unary requests in their context, so that the provenance of the entries
+// they upload can be recorded.
+func UploaderUnaryServerInterceptor(ctx context.Context,
+	req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+
+	return handler(withUploader(ctx), req)
+}
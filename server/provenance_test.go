@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+func TestRecordUploaderHTTP(t *testing.T) {
+	var uploader string
+	handler := RecordUploaderHTTP(func(w http.ResponseWriter, r *http.Request) {
+		uploader = cache.Uploader(r.Context())
+	})
+
+	r := httptest.NewRequest(http.MethodPut, "/cas/abc", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	handler(httptest.NewRecorder(), r)
+	if uploader != "ip:10.0.0.1" {
+		t.Errorf("Expected uploader ip:10.0.0.1, got %q", uploader)
+	}
+
+	r.SetBasicAuth("alice", "secret")
+	handler(httptest.NewRecorder(), r)
+	if uploader != "user:alice" {
+		t.Errorf("Expected uploader user:alice, got %q", uploader)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/cas/abc", nil)
+	handler(httptest.NewRecorder(), r)
+	if uploader != "" {
+		t.Errorf("Expected no uploader for a GET request, got %q", uploader)
+	}
+}
+
+func TestUploaderInterceptor(t *testing.T) {
+	intercept := func(ctx context.Context) string {
+		var uploader string
+		_, err := UploaderUnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				uploader = cache.Uploader(ctx)
+				return nil, nil
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return uploader
+	}
+
+	ctx := peer.NewContext(context.Background(),
+		&peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+	if uploader := intercept(ctx); uploader != "ip:10.0.0.1" {
+		t.Errorf("Expected uploader ip:10.0.0.1, got %q", uploader)
+	}
+
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(":authority", "alice:secret@localhost"))
+	if uploader := intercept(ctx); uploader != "user:alice" {
+		t.Errorf("Expected uploader user:alice, got %q", uploader)
+	}
+}
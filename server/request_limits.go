@@ -0,0 +1,119 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	grpc_status "google.golang.org/grpc/status"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+)
+
+// RequestLimits limits the size of individual gRPC requests, so that a
+// single request can't exhaust the server's memory. Zero values mean no
+// limit. See LimitHTTPBodySize for HTTP requests.
+type RequestLimits struct {
+	// The number of digests in a FindMissingBlobs request.
+	FindMissingDigests int
+
+	// The number of digests in a BatchReadBlobs or BatchUpdateBlobs
+	// request, and the total size of their blobs.
+	BatchDigests   int
+	BatchTotalSize int64
+}
+
+// Returns an INVALID_ARGUMENT error for a request whose field exceeds a
+// limit, with the violation in a BadRequest detail.
+func errLimitExceeded(field string, description string) error {
+	st := grpc_status.New(codes.InvalidArgument, description)
+	st, err := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: field, Description: description},
+		},
+	})
+	if err != nil {
+		return grpc_status.Error(codes.InvalidArgument, description)
+	}
+	return st.Err()
+}
+
+// Returns an error if the n digests in field exceed limit.
+func checkDigestLimit(field string, n int, limit int) error {
+	if limit > 0 && n > limit {
+		return errLimitExceeded(field,
+			fmt.Sprintf("Too many digests: %d, the limit is %d", n, limit))
+	}
+	return nil
+}
+
+// Returns an error if the digests in field of a batch request exceed
+// the batch limits.
+func (l RequestLimits) checkBatch(field string, digests []*pb.Digest) error {
+	err := checkDigestLimit(field, len(digests), l.BatchDigests)
+	if err != nil {
+		return err
+	}
+
+	if l.BatchTotalSize <= 0 {
+		return nil
+	}
+
+	var total int64
+	for _, d := range digests {
+		if d != nil {
+			total += d.SizeBytes
+		}
+	}
+	if total > l.BatchTotalSize {
+		return errLimitExceeded(field,
+			fmt.Sprintf("The total size of the blobs is %d bytes, the limit is %d", total, l.BatchTotalSize))
+	}
+
+	return nil
+}
+
+// LimitHTTPBodySize wraps handler, and rejects requests with status 413
+// if their bodies are larger than maxSize bytes.
+func LimitHTTPBodySize(handler http.HandlerFunc, maxSize int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxSize {
+			msg := fmt.Sprintf("Request body too large: %d bytes, the limit is %d", r.ContentLength, maxSize)
+			http.Error(w, msg, http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		// Bodies without a Content-Length header fail once they exceed
+		// the limit.
+		r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, maxSize)}
+
+		handler(w, r)
+	}
+}
+
+// Remembers whether reading the body exceeded the limit, because the
+// cache doesn't return the error it got from the body.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded *http.MaxBytesError
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && b.exceeded == nil {
+		errors.As(err, &b.exceeded)
+	}
+	return n, err
+}
+
+// Returns the error if reading the body of r exceeded the limit set by
+// LimitHTTPBodySize, or nil.
+func bodyTooLarge(r *http.Request) *http.MaxBytesError {
+	if b, ok := r.Body.(*limitedBody); ok {
+		return b.exceeded
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+)
+
+func checkLimitExceededErr(t *testing.T, err error, field string) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("Expected a grpc status error, got: %v", err)
+	}
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("Expected code InvalidArgument, got: %s %s", st.Code(), st.Message())
+	}
+
+	for _, detail := range st.Details() {
+		br, ok := detail.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		if len(br.FieldViolations) != 1 || br.FieldViolations[0].Field != field {
+			t.Fatalf("Expected a violation of field %q, got: %v", field, br.FieldViolations)
+		}
+		return
+	}
+
+	t.Fatal("Expected a BadRequest detail, got:", st.Details())
+}
+
+func TestGrpcRequestLimits(t *testing.T) {
+	t.Parallel()
+
+	fixture := grpcTestSetupInternal(t, false, RequestLimits{
+		FindMissingDigests: 2,
+		BatchDigests:       2,
+		BatchTotalSize:     100,
+	})
+	defer os.Remove(fixture.tempdir)
+
+	digests := make([]*pb.Digest, 3)
+	for i := range digests {
+		_, hash := testutils.RandomDataAndHash(10)
+		digests[i] = &pb.Digest{Hash: hash, SizeBytes: 10}
+	}
+
+	_, err := fixture.casClient.FindMissingBlobs(ctx,
+		&pb.FindMissingBlobsRequest{BlobDigests: digests[:2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = fixture.casClient.FindMissingBlobs(ctx,
+		&pb.FindMissingBlobsRequest{BlobDigests: digests})
+	checkLimitExceededErr(t, err, "blob_digests")
+
+	_, err = fixture.casClient.BatchReadBlobs(ctx,
+		&pb.BatchReadBlobsRequest{Digests: digests[:2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = fixture.casClient.BatchReadBlobs(ctx,
+		&pb.BatchReadBlobsRequest{Digests: digests})
+	checkLimitExceededErr(t, err, "digests")
+
+	data, hash := testutils.RandomDataAndHash(101)
+	_, err = fixture.casClient.BatchUpdateBlobs(ctx, &pb.BatchUpdateBlobsRequest{
+		Requests: []*pb.BatchUpdateBlobsRequest_Request{
+			{Digest: &pb.Digest{Hash: hash, SizeBytes: int64(len(data))}, Data: data},
+		},
+	})
+	checkLimitExceededErr(t, err, "requests")
+}
+
+func TestGrpcCapabilitiesBatchLimit(t *testing.T) {
+	s := grpcServer{
+		accessLogger: testutils.NewSilentLogger(),
+		limits:       RequestLimits{BatchTotalSize: 100},
+	}
+
+	caps, err := s.GetCapabilities(ctx, &pb.GetCapabilitiesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.CacheCapabilities.MaxBatchTotalSizeBytes != 100 {
+		t.Fatal("Expected MaxBatchTotalSizeBytes 100, got",
+			caps.CacheCapabilities.MaxBatchTotalSizeBytes)
+	}
+}
+
+func TestLimitHTTPBodySize(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
+	handler := LimitHTTPBodySize(h.CacheHandler, 100)
+
+	small, smallHash := testutils.RandomDataAndHash(100)
+	large, largeHash := testutils.RandomDataAndHash(101)
+
+	tcs := []struct {
+		name   string
+		hash   string
+		body   io.Reader
+		length int64
+		status int
+	}{
+		{"within the limit", smallHash, bytes.NewReader(small), int64(len(small)), http.StatusOK},
+		{"content length too large", largeHash, bytes.NewReader(large), int64(len(large)), http.StatusRequestEntityTooLarge},
+		// A chunked upload, whose size is only given by X-Digest-SizeBytes.
+		{"body too large", largeHash, io.MultiReader(bytes.NewReader(large)), -1, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tc := range tcs {
+		r := httptest.NewRequest(http.MethodPut, "/cas/"+tc.hash, tc.body)
+		r.ContentLength = tc.length
+		if tc.length == -1 {
+			r.Header.Set("X-Digest-SizeBytes", "101")
+		}
+		rr := httptest.NewRecorder()
+		handler(rr, r)
+
+		if rr.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tc.name, tc.status, rr.Code, rr.Body.String())
+		}
+	}
+}
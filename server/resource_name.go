@@ -0,0 +1,254 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	grpc_status "google.golang.org/grpc/status"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+)
+
+// ByteStream resource names identify the CAS blobs to read or write, in
+// the forms defined by the ByteStream API section of the REAPI spec:
+//
+// Reads:
+//   [{instance_name}/]blobs/{hash}/{size}
+//   [{instance_name}/]compressed-blobs/{compressor}/{uncompressed_hash}/{uncompressed_size}
+//
+// Writes:
+//   [{instance_name}/]uploads/{uuid}/blobs/{hash}/{size}[/{optional_metadata}]
+//   [{instance_name}/]uploads/{uuid}/compressed-blobs/{compressor}/{uncompressed_hash}/{uncompressed_size}[/{optional_metadata}]
+//
+// Malformed names are rejected with INVALID_ARGUMENT and a BadRequest
+// error detail which says what is wrong with them.
+
+// A parsed resource name.
+type resourceName struct {
+	instance    string
+	uuid        string // Writes only.
+	hash        string
+	size        int64
+	compression casblob.CompressionType
+	metadata    string // Writes only, may be empty.
+}
+
+// Path segments which instance names cannot contain, since they would
+// make resource names ambiguous.
+var reservedSegments = map[string]bool{
+	"blobs":            true,
+	"compressed-blobs": true,
+	"uploads":          true,
+	"actions":          true,
+	"actionResults":    true,
+	"operations":       true,
+	"capabilities":     true,
+}
+
+const (
+	readResourceForms = "[{instance_name}/]blobs/{hash}/{size} or " +
+		"[{instance_name}/]compressed-blobs/{compressor}/{uncompressed_hash}/{uncompressed_size}"
+	writeResourceForms = "[{instance_name}/]uploads/{uuid}/blobs/{hash}/{size}[/{optional_metadata}] or " +
+		"[{instance_name}/]uploads/{uuid}/compressed-blobs/{compressor}/{uncompressed_hash}/{uncompressed_size}[/{optional_metadata}]"
+)
+
+// resourceNameError describes why a resource name is invalid. It is
+// returned to clients as an INVALID_ARGUMENT status.
+type resourceNameError struct {
+	name   string
+	reason string
+}
+
+func (e *resourceNameError) Error() string {
+	return fmt.Sprintf("Invalid resource name %q: %s", e.name, e.reason)
+}
+
+// GRPCStatus returns the status which gRPC sends for e.
+func (e *resourceNameError) GRPCStatus() *grpc_status.Status {
+	st := grpc_status.New(codes.InvalidArgument, e.Error())
+	detailed, err := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "resource_name", Description: e.reason},
+		},
+	})
+	if err != nil {
+		return st
+	}
+	return detailed
+}
+
+func invalidResourceName(name string, format string, a ...interface{}) error {
+	return &resourceNameError{name: name, reason: fmt.Sprintf(format, a...)}
+}
+
+// Parse the resource name of a ByteStream Read request.
+func parseReadResourceName(name string) (resourceName, error) {
+	fields := strings.Split(name, "/")
+
+	start := -1
+	for i, f := range fields {
+		if f == "blobs" || f == "compressed-blobs" {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return resourceName{}, invalidResourceName(name,
+			"expected %s", readResourceForms)
+	}
+
+	var rn resourceName
+	var err error
+	rn.instance, err = parseResourceInstance(name, fields[:start])
+	if err != nil {
+		return resourceName{}, err
+	}
+
+	rest, err := parseResourceBlob(name, fields[start:], &rn)
+	if err != nil {
+		return resourceName{}, err
+	}
+	if len(rest) != 0 {
+		return resourceName{}, invalidResourceName(name,
+			"unexpected %q after the size, expected %s", strings.Join(rest, "/"), readResourceForms)
+	}
+
+	return rn, nil
+}
+
+// Parse the resource name of a ByteStream Write or QueryWriteStatus
+// request.
+func parseWriteResourceName(name string) (resourceName, error) {
+	fields := strings.Split(name, "/")
+
+	start := -1
+	for i, f := range fields {
+		if f == "uploads" {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return resourceName{}, invalidResourceName(name,
+			"missing \"uploads\", expected %s", writeResourceForms)
+	}
+
+	var rn resourceName
+	var err error
+	rn.instance, err = parseResourceInstance(name, fields[:start])
+	if err != nil {
+		return resourceName{}, err
+	}
+
+	if len(fields) < start+2 {
+		return resourceName{}, invalidResourceName(name,
+			"missing the upload UUID, expected %s", writeResourceForms)
+	}
+	// The UUID is only used by clients to tell their uploads apart, so
+	// it isn't checked.
+	rn.uuid = fields[start+1]
+
+	rest, err := parseResourceBlob(name, fields[start+2:], &rn)
+	if err != nil {
+		return resourceName{}, err
+	}
+	rn.metadata = strings.Join(rest, "/")
+
+	return rn, nil
+}
+
+// Return the instance name made of fields, which precede the blob or
+// upload part of the resource name.
+func parseResourceInstance(name string, fields []string) (string, error) {
+	for _, f := range fields {
+		if reservedSegments[f] {
+			return "", invalidResourceName(name,
+				"the instance name cannot contain %q as a path segment", f)
+		}
+	}
+	return strings.Join(fields, "/"), nil
+}
+
+// Parse the "blobs/{hash}/{size}" or
+// "compressed-blobs/{compressor}/{uncompressed_hash}/{uncompressed_size}"
+// part of a resource name, at the start of fields, into rn. Returns the
+// fields which follow it.
+func parseResourceBlob(name string, fields []string, rn *resourceName) ([]string, error) {
+	if len(fields) == 0 {
+		return nil, invalidResourceName(name,
+			"missing \"blobs\" or \"compressed-blobs\" after the upload UUID")
+	}
+
+	switch fields[0] {
+	case "blobs":
+		rn.compression = casblob.Identity
+		fields = fields[1:]
+	case "compressed-blobs":
+		if len(fields) < 2 || fields[1] == "" {
+			return nil, invalidResourceName(name,
+				"missing the compressor after \"compressed-blobs\"")
+		}
+		var err error
+		rn.compression, err = parseResourceCompressor(name, fields[1])
+		if err != nil {
+			return nil, err
+		}
+		fields = fields[2:]
+	default:
+		return nil, invalidResourceName(name,
+			"expected \"blobs\" or \"compressed-blobs\" after the upload UUID, found %q", fields[0])
+	}
+
+	if len(fields) < 1 || fields[0] == "" {
+		return nil, invalidResourceName(name, "missing the hash")
+	}
+	rn.hash = fields[0]
+	if len(rn.hash) != hashKeyLength {
+		return nil, invalidResourceName(name,
+			"the hash %q has %d characters, expected a SHA256 hash with %d", rn.hash, len(rn.hash), hashKeyLength)
+	}
+	if !validate.HashKeyRegex.MatchString(rn.hash) {
+		return nil, invalidResourceName(name,
+			"the hash %q is not a lowercase hexadecimal SHA256 hash", rn.hash)
+	}
+
+	if len(fields) < 2 || fields[1] == "" {
+		return nil, invalidResourceName(name, "missing the size after the hash")
+	}
+	sizeStr := fields[1]
+	if strings.TrimLeft(sizeStr, "0123456789") != "" {
+		return nil, invalidResourceName(name,
+			"the size %q is not a non-negative decimal integer", sizeStr)
+	}
+	var err error
+	rn.size, err = strconv.ParseInt(sizeStr, 10, 64)
+	if err != nil {
+		return nil, invalidResourceName(name, "the size %q is too large", sizeStr)
+	}
+	if rn.size == 0 && rn.hash != emptySha256 {
+		return nil, invalidResourceName(name,
+			"the size is 0, but %q is not the hash of the empty blob", rn.hash)
+	}
+
+	return fields[2:], nil
+}
+
+// Return the compression type of a compressor name in a resource name.
+func parseResourceCompressor(name string, compressor string) (casblob.CompressionType, error) {
+	switch compressor {
+	case "zstd":
+		return casblob.Zstandard, nil
+	case "identity":
+		return casblob.Identity, invalidResourceName(name,
+			"the identity compressor cannot be used with \"compressed-blobs\", use \"blobs\" instead")
+	case "deflate", "brotli":
+		return casblob.Identity, invalidResourceName(name,
+			"unsupported compressor %q, only \"zstd\" is supported", compressor)
+	}
+	return casblob.Identity, invalidResourceName(name,
+		"unknown compressor %q, only \"zstd\" is supported", compressor)
+}
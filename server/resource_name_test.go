@@ -0,0 +1,118 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	grpc_status "google.golang.org/grpc/status"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+)
+
+const testResourceHash = "0123456789012345678901234567890123456789012345678901234567890123"
+
+func TestParseResourceNames(t *testing.T) {
+	tcs := []struct {
+		name     string
+		write    bool
+		expected resourceName
+	}{
+		{
+			name:     "foo/bar/blobs/" + testResourceHash + "/42",
+			expected: resourceName{instance: "foo/bar", hash: testResourceHash, size: 42},
+		},
+		{
+			name: "compressed-blobs/zstd/" + emptySha256 + "/0",
+			expected: resourceName{hash: emptySha256, size: 0,
+				compression: casblob.Zstandard},
+		},
+		{
+			name:  "foo/uploads/d7b7b2a1-4d4b-4d5e-9f2a-6b6a1c9e0f11/blobs/" + testResourceHash + "/42",
+			write: true,
+			expected: resourceName{instance: "foo", uuid: "d7b7b2a1-4d4b-4d5e-9f2a-6b6a1c9e0f11",
+				hash: testResourceHash, size: 42},
+		},
+		{
+			name:  "uploads/uuid/compressed-blobs/zstd/" + testResourceHash + "/42/some/meta/data",
+			write: true,
+			expected: resourceName{uuid: "uuid", hash: testResourceHash, size: 42,
+				compression: casblob.Zstandard, metadata: "some/meta/data"},
+		},
+	}
+
+	for _, tc := range tcs {
+		parse := parseReadResourceName
+		if tc.write {
+			parse = parseWriteResourceName
+		}
+		rn, err := parse(tc.name)
+		if err != nil {
+			t.Errorf("Expected %q to be valid, got %v", tc.name, err)
+			continue
+		}
+		if rn != tc.expected {
+			t.Errorf("Expected %q to be parsed as %+v, got %+v", tc.name, tc.expected, rn)
+		}
+	}
+}
+
+func TestInvalidResourceNames(t *testing.T) {
+	tcs := []struct {
+		name   string
+		write  bool
+		reason string // A substring of the expected description.
+	}{
+		{name: "foo/" + testResourceHash + "/42", reason: "expected [{instance_name}/]blobs/"},
+		{name: "uploads/foo/blobs/" + testResourceHash + "/42", reason: "cannot contain \"uploads\""},
+		{name: "operations/blobs/" + testResourceHash + "/42", reason: "cannot contain \"operations\""},
+		{name: "blobs/" + testResourceHash, reason: "missing the size"},
+		{name: "blobs/" + testResourceHash + "/42/extra", reason: "unexpected \"extra\" after the size"},
+		{name: "blobs//42", reason: "missing the hash"},
+		{name: "blobs/" + testResourceHash[1:] + "/42", reason: "has 63 characters"},
+		{name: "blobs/A" + testResourceHash[1:] + "/42", reason: "not a lowercase hexadecimal"},
+		{name: "blobs/" + testResourceHash + "/-1", reason: "not a non-negative decimal integer"},
+		{name: "blobs/" + testResourceHash + "/9223372036854775808", reason: "too large"},
+		{name: "blobs/" + testResourceHash + "/0", reason: "not the hash of the empty blob"},
+		{name: "compressed-blobs/", reason: "missing the compressor"},
+		{name: "compressed-blobs/identity/" + testResourceHash + "/42", reason: "use \"blobs\" instead"},
+		{name: "compressed-blobs/brotli/" + testResourceHash + "/42", reason: "unsupported compressor \"brotli\""},
+		{name: "compressed-blobs/ZSTD/" + testResourceHash + "/42", reason: "unknown compressor \"ZSTD\""},
+		{name: "blobs/" + testResourceHash + "/42", write: true, reason: "missing \"uploads\""},
+		{name: "foo/uploads", write: true, reason: "missing the upload UUID"},
+		{name: "uploads/uuid", write: true, reason: "missing \"blobs\" or \"compressed-blobs\""},
+		{name: "uploads/uuid/with/blobs/" + testResourceHash + "/42", write: true, reason: "found \"with\""},
+	}
+
+	for _, tc := range tcs {
+		parse := parseReadResourceName
+		if tc.write {
+			parse = parseWriteResourceName
+		}
+		_, err := parse(tc.name)
+		if err == nil {
+			t.Errorf("Expected an error for %q", tc.name)
+			continue
+		}
+
+		st, ok := grpc_status.FromError(err)
+		if !ok || st.Code() != codes.InvalidArgument {
+			t.Errorf("Expected an INVALID_ARGUMENT status for %q, got %v", tc.name, err)
+			continue
+		}
+		if len(st.Details()) != 1 {
+			t.Errorf("Expected a BadRequest detail for %q, got %v", tc.name, st.Details())
+			continue
+		}
+		br, ok := st.Details()[0].(*errdetails.BadRequest)
+		if !ok || len(br.FieldViolations) != 1 || br.FieldViolations[0].Field != "resource_name" {
+			t.Errorf("Expected a resource_name field violation for %q, got %v", tc.name, st.Details()[0])
+			continue
+		}
+		if !strings.Contains(br.FieldViolations[0].Description, tc.reason) {
+			t.Errorf("Expected the description for %q to contain %q, got %q",
+				tc.name, tc.reason, br.FieldViolations[0].Description)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/utils/limiter"
+)
+
+// How long clients are asked to wait before retrying requests which
+// failed because the cache, or its proxy backend, was unavailable, before
+// jitter is added.
+const unavailableRetryDelay = 5 * time.Second
+
+// Return how long the client should wait before retrying a request which
+// failed with err, and true, if it is worth retrying, eg because too much
+// data was being uploaded, or the cache was unavailable. The delay has
+// jitter, so that clients which failed together retry at different times.
+func retryDelay(err error) (time.Duration, bool) {
+	cerr, ok := err.(*cache.Error)
+	if !ok {
+		return 0, false
+	}
+
+	switch cerr.Code {
+	case http.StatusTooManyRequests:
+		if cerr.RetryAfter > 0 {
+			return cerr.RetryAfter, true
+		}
+		return limiter.Backoff(limiter.RetryAfter, 0, 0), true
+	case http.StatusServiceUnavailable:
+		return limiter.Backoff(unavailableRetryDelay, 0, 0), true
+	}
+
+	return 0, false
+}
+
+// Reply to an HTTP request which failed with the cache error cerr, with
+// a Retry-After header if it is worth retrying.
+func httpCacheError(w http.ResponseWriter, cerr *cache.Error) {
+	if delay, ok := retryDelay(cerr); ok {
+		w.Header().Set("Retry-After", limiter.RetryAfterHeader(delay))
+	}
+	http.Error(w, cerr.Error(), cerr.Code)
+}
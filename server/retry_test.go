@@ -0,0 +1,61 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+)
+
+func TestHTTPCacheErrorRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		err        *cache.Error
+		retryAfter []string // The acceptable values, none means no header.
+	}{
+		{&cache.Error{Code: http.StatusServiceUnavailable, Text: "standby"}, []string{"5", "6", "7"}},
+		{&cache.Error{Code: http.StatusTooManyRequests, Text: "busy", RetryAfter: 10 * time.Second}, []string{"10"}},
+		{&cache.Error{Code: http.StatusTooManyRequests, Text: "busy"}, []string{"1"}},
+		{&cache.Error{Code: http.StatusInsufficientStorage, Text: "full"}, nil},
+		{&cache.Error{Code: http.StatusBadRequest, Text: "bad"}, nil},
+	} {
+		rr := httptest.NewRecorder()
+		httpCacheError(rr, tc.err)
+		if rr.Code != tc.err.Code {
+			t.Errorf("Expected status %d, got %d", tc.err.Code, rr.Code)
+		}
+
+		header := rr.Header().Get("Retry-After")
+		ok := len(tc.retryAfter) == 0 && header == ""
+		for _, v := range tc.retryAfter {
+			ok = ok || header == v
+		}
+		if !ok {
+			t.Errorf("Expected Retry-After to be one of %v for status %d, got %q", tc.retryAfter, tc.err.Code, header)
+		}
+	}
+}
+
+func TestRetryDelayJitter(t *testing.T) {
+	err := &cache.Error{Code: http.StatusServiceUnavailable, Text: "standby"}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		d, ok := retryDelay(err)
+		if !ok || d < unavailableRetryDelay || d >= unavailableRetryDelay*3/2 {
+			t.Fatalf("Expected %v plus jitter, got %v", unavailableRetryDelay, d)
+		}
+		seen[strconv.FormatInt(int64(d), 10)] = true
+	}
+	if len(seen) < 10 {
+		t.Errorf("Expected the delays to vary, got %d different delays", len(seen))
+	}
+
+	_, ok := retryDelay(errors.New("not a cache error"))
+	if ok {
+		t.Error("Expected no retry delay for other errors")
+	}
+}
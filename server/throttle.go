@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	grpc_status "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/buchgr/bazel-remote/v2/utils/throttle"
+)
+
+// Returns the identity of a client for bandwidth limits: its username if
+// it uses basic authentication, otherwise the common name of its client
+// certificate, otherwise its IP address.
+func clientIdentity(username string, tlsState *tls.ConnectionState, addr string) string {
+	if username != "" {
+		return "user:" + username
+	}
+
+	if tlsState != nil && len(tlsState.PeerCertificates) > 0 {
+		if cn := tlsState.PeerCertificates[0].Subject.CommonName; cn != "" {
+			return "cert:" + cn
+		}
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return "ip:" + host
+}
+
+// ThrottleHTTP wraps handler, and limits the bandwidth used by the
+// responses to GET requests.
+func ThrottleHTTP(handler http.HandlerFunc, t *throttle.Throttler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			handler(w, r)
+			return
+		}
+
+		username, _, _ := r.BasicAuth()
+		s := t.Open(r.RemoteAddr, clientIdentity(username, r.TLS, r.RemoteAddr))
+		defer s.Close()
+
+		// This hides the io.ReaderFrom implementation of w, so that files
+		// are copied in small writes instead of with sendfile(2).
+		handler(&throttledResponseWriter{ResponseWriter: w, ctx: r.Context(), stream: s}, r)
+	}
+}
+
+type throttledResponseWriter struct {
+	http.ResponseWriter
+	ctx    context.Context
+	stream *throttle.Stream
+}
+
+func (w *throttledResponseWriter) Write(p []byte) (int, error) {
+	err := w.stream.Wait(w.ctx, len(p))
+	if err != nil {
+		return 0, err
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying
+// http.ResponseWriter.
+func (w *throttledResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// GrpcThrottler wraps a throttle.Throttler, and provides gRPC interceptors
+// that limit the bandwidth used by ByteStream/Read and BatchReadBlobs
+// responses.
+type GrpcThrottler struct {
+	throttler *throttle.Throttler
+}
+
+// NewGrpcThrottler returns a GrpcThrottler that wraps the given
+// throttle.Throttler.
+func NewGrpcThrottler(t *throttle.Throttler) *GrpcThrottler {
+	return &GrpcThrottler{throttler: t}
+}
+
+// Returns the address and the identity, see clientIdentity, of the
+// client of the gRPC request with the given context.
+func grpcClientIdentity(ctx context.Context) (addr string, identity string) {
+	var tlsState *tls.ConnectionState
+
+	p, ok := peer.FromContext(ctx)
+	if ok {
+		addr = p.Addr.String()
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			tlsState = &tlsInfo.State
+		}
+	}
+
+	username, _, _ := getLogin(ctx)
+
+	return addr, clientIdentity(username, tlsState, addr)
+}
+
+// Returns a throttle.Stream for the client of the request with the given
+// context. Connections are identified by the client's address.
+func (g *GrpcThrottler) open(ctx context.Context) *throttle.Stream {
+	return g.throttler.Open(grpcClientIdentity(ctx))
+}
+
+// StreamServerInterceptor limits the bandwidth used by ByteStream/Read
+// responses.
+func (g *GrpcThrottler) StreamServerInterceptor(srv interface{},
+	ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+
+	if grpcEndpoint(info.FullMethod) != "ByteStream/Read" {
+		return handler(srv, ss)
+	}
+
+	s := g.open(ss.Context())
+	defer s.Close()
+
+	return handler(srv, &throttledServerStream{ServerStream: ss, stream: s})
+}
+
+// UnaryServerInterceptor limits the bandwidth used by BatchReadBlobs
+// responses, by delaying them according to their size.
+func (g *GrpcThrottler) UnaryServerInterceptor(ctx context.Context,
+	req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+
+	if grpcEndpoint(info.FullMethod) != "ContentAddressableStorage/BatchReadBlobs" {
+		return handler(ctx, req)
+	}
+
+	s := g.open(ctx)
+	defer s.Close()
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	if m, ok := resp.(proto.Message); ok {
+		err = s.Wait(ctx, proto.Size(m))
+		if err != nil {
+			return nil, grpc_status.FromContextError(err).Err()
+		}
+	}
+
+	return resp, nil
+}
+
+type throttledServerStream struct {
+	grpc.ServerStream
+	stream *throttle.Stream
+}
+
+func (ss *throttledServerStream) SendMsg(m interface{}) error {
+	if msg, ok := m.(proto.Message); ok {
+		err := ss.stream.Wait(ss.Context(), proto.Size(msg))
+		if err != nil {
+			return grpc_status.FromContextError(err).Err()
+		}
+	}
+	return ss.ServerStream.SendMsg(m)
+}
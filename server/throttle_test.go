@@ -0,0 +1,82 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/throttle"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+)
+
+func TestClientIdentity(t *testing.T) {
+	certState := &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{
+			{Subject: pkix.Name{CommonName: "ci-worker"}},
+		},
+	}
+
+	tcs := []struct {
+		username string
+		tlsState *tls.ConnectionState
+		addr     string
+		expected string
+	}{
+		{"alice", certState, "10.0.0.1:1234", "user:alice"},
+		{"", certState, "10.0.0.1:1234", "cert:ci-worker"},
+		{"", &tls.ConnectionState{}, "10.0.0.1:1234", "ip:10.0.0.1"},
+		{"", nil, "[::1]:1234", "ip:::1"},
+		{"", nil, "@", "ip:@"},
+	}
+
+	for _, tc := range tcs {
+		actual := clientIdentity(tc.username, tc.tlsState, tc.addr)
+		if actual != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, actual)
+		}
+	}
+}
+
+func TestThrottleHTTP(t *testing.T) {
+	cacheDir := testutils.TempDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	c, err := disk.New(cacheDir, 1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, hash := testutils.RandomDataAndHash(15000)
+	err = c.Put(ctx, cache.CAS, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(), true, false, nil, false, false, nil, validate.SymlinksAllow, false, PeerConfig{}, "")
+	handler := ThrottleHTTP(h.CacheHandler, throttle.New(10000, 0))
+
+	// The first 10000 bytes are a burst, the rest take half a second.
+	start := time.Now()
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, "/cas/"+hash, nil))
+	elapsed := time.Since(start)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if !bytes.Equal(rr.Body.Bytes(), data) {
+		t.Fatal("Unexpected response body")
+	}
+	if elapsed < 400*time.Millisecond {
+		t.Fatal("Expected the download to be throttled, it took", elapsed)
+	}
+}
@@ -0,0 +1,101 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"log"
+	"os"
+	"syscall"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// The name to register the windows service with, eg:
+// sc.exe create bazel-remote binPath= "C:\path\to\bazel-remote.exe --config_file C:\path\to\config.yml"
+const serviceName = "bazel-remote"
+
+// The signals which are delivered to sigChan.
+var handledSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// Handing over the listeners to a new process is not supported on windows.
+var restartSignal os.Signal
+
+// There is no SIGHUP on windows, max_size=auto:<percent>% is only derived
+// at startup.
+var reloadSignal os.Signal
+
+// Run app, as a windows service if we were started by the service control
+// manager.
+func runApp(app *cli.App) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return err
+	}
+	if !isService {
+		return app.Run(os.Args)
+	}
+
+	// Services have no console, so log to the windows event log instead.
+	elog, err := eventlog.Open(serviceName)
+	if err == nil {
+		defer elog.Close()
+		log.SetOutput(eventLogWriter{elog})
+	}
+
+	return svc.Run(serviceName, &service{app: app})
+}
+
+type service struct {
+	app *cli.App
+}
+
+// Execute implements svc.Handler.
+func (s *service) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.app.Run(os.Args)
+	}()
+
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				log.Println("bazel-remote terminated:", err)
+				return false, 1
+			}
+			return false, 0
+
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				changes <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending}
+				select {
+				case sigChan <- os.Interrupt:
+				default:
+					// A shutdown is already in progress.
+				}
+			}
+		}
+	}
+}
+
+type eventLogWriter struct {
+	elog *eventlog.Log
+}
+
+func (w eventLogWriter) Write(p []byte) (int, error) {
+	err := w.elog.Info(1, string(p))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/urfave/cli/v2"
+)
+
+// The signals which are delivered to sigChan.
+var handledSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGUSR2}
+
+// On this signal, a new bazel-remote process takes over the listeners.
+var restartSignal os.Signal = syscall.SIGUSR2
+
+// On this signal, max_size=auto:<percent>% is derived again.
+var reloadSignal os.Signal = syscall.SIGHUP
+
+func runApp(app *cli.App) error {
+	return app.Run(os.Args)
+}
@@ -0,0 +1,490 @@
+package subcommands
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	"github.com/buchgr/bazel-remote/v2/cache/eventstream"
+
+	"github.com/urfave/cli/v2"
+)
+
+// The fractions of the working set which are simulated if no sizes are
+// given.
+var defaultSizeFractions = []float64{0.1, 0.25, 0.5, 0.75}
+
+func analyzeCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "analyze",
+		Usage: "Estimate the hit ratio of an LRU cache of various sizes, by replaying access logs.",
+		UsageText: "bazel-remote analyze [--dir <dir>] [--sizes <GiB,...>] <log file>...\n\n" +
+			"The log files can contain access log lines (HTTP and gRPC) or event stream\n" +
+			"records, in the order that they were written. Use - to read from stdin.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "dir",
+				Usage: "The cache directory of the instance which wrote the logs, to look up the sizes of the entries. Access log lines only include the sizes of bytestream requests.",
+			},
+			&cli.StringFlag{
+				Name:        "sizes",
+				Usage:       "A comma-separated list of cache sizes to simulate, in GiB.",
+				DefaultText: "10%, 25%, 50% and 75% of the working set",
+			},
+			&cli.Int64Flag{
+				Name:        "default_size",
+				Usage:       "The size in bytes to assume for entries whose size is unknown.",
+				DefaultText: "0, ie ignore those entries",
+			},
+		},
+		Action: analyze,
+	}
+}
+
+const (
+	resultUnknown = iota
+	resultHit
+	resultMiss
+)
+
+// A read or write of a cache entry, parsed from a log line.
+type access struct {
+	kind   cache.EntryKind
+	hash   string
+	size   int64 // -1 if unknown.
+	write  bool
+	result int // For reads, whether the real cache had the entry.
+}
+
+func (a access) key() string {
+	return a.kind.String() + "/" + a.hash
+}
+
+var (
+	// Eg " GET 200       127.0.0.1 /instance/cas/<hash>".
+	httpAccessRegex = regexp.MustCompile(`\b(GET|PUT) +(\d{3}) +\S+ +(\S+)$`)
+	httpPathRegex   = regexp.MustCompile(`(?:^|/)(ac|cas)/([a-f0-9]{64})$`)
+
+	// Eg "GRPC CAS GET <hash> OK" or "GRPC AC GET <hash> NOT FOUND".
+	grpcAccessRegex = regexp.MustCompile(`\bGRPC (AC|CAS) (GET|PUT)(?: NODEPSCHECK)? ([a-f0-9]{64}) (OK|NOT FOUND)$`)
+
+	// Eg "GRPC BYTESTREAM READ COMPLETED <resource name>".
+	bytestreamRegex     = regexp.MustCompile(`\bGRPC BYTESTREAM (READ COMPLETED|WRITE COMPLETED:) (\S+)$`)
+	resourceRegex       = regexp.MustCompile(`(?:^|/)(?:blobs|compressed-blobs/zstd)/([a-f0-9]{64})/(\d+)$`)
+	bytestreamMissRegex = regexp.MustCompile(`\bGRPC BYTESTREAM READ BLOB NOT FOUND: ([a-f0-9]{64})$`)
+)
+
+var kindNames = map[string]cache.EntryKind{
+	"ac":  cache.AC,
+	"cas": cache.CAS,
+	"raw": cache.RAW,
+}
+
+// Parse a line from an access log or an event stream. Returns false for
+// lines which don't describe a read or write of a cache entry.
+func parseAccess(line string) (access, bool) {
+	line = strings.TrimSpace(line)
+
+	if strings.HasPrefix(line, "{") {
+		var r eventstream.Record
+		if json.Unmarshal([]byte(line), &r) != nil {
+			return access{}, false
+		}
+		kind, ok := kindNames[r.Kind]
+		if !ok {
+			return access{}, false
+		}
+		a := access{kind: kind, hash: r.Hash, size: r.Size}
+		switch {
+		case r.Op == eventstream.OpWrite:
+			a.write = true
+		case r.Op == eventstream.OpRead && r.Result == "hit":
+			a.result = resultHit
+		case r.Op == eventstream.OpRead && r.Result == "miss":
+			// The size of a miss is unknown.
+			a.size = -1
+			a.result = resultMiss
+		default:
+			return access{}, false
+		}
+		return a, true
+	}
+
+	if m := httpAccessRegex.FindStringSubmatch(line); m != nil {
+		p := httpPathRegex.FindStringSubmatch(m[3])
+		if p == nil {
+			return access{}, false
+		}
+		a := access{kind: kindNames[p[1]], hash: p[2], size: -1}
+		switch {
+		case m[1] == "PUT" && m[2] == "200":
+			a.write = true
+		case m[1] == "GET" && m[2] == "200":
+			a.result = resultHit
+		case m[1] == "GET" && m[2] == "404":
+			a.result = resultMiss
+		default:
+			return access{}, false
+		}
+		return a, true
+	}
+
+	if m := grpcAccessRegex.FindStringSubmatch(line); m != nil {
+		a := access{kind: kindNames[strings.ToLower(m[1])], hash: m[3], size: -1}
+		switch {
+		case m[2] == "PUT" && m[4] == "OK":
+			a.write = true
+		case m[2] == "GET" && m[4] == "OK":
+			a.result = resultHit
+		case m[2] == "GET":
+			a.result = resultMiss
+		default:
+			return access{}, false
+		}
+		return a, true
+	}
+
+	if m := bytestreamRegex.FindStringSubmatch(line); m != nil {
+		r := resourceRegex.FindStringSubmatch(m[2])
+		if r == nil {
+			return access{}, false
+		}
+		size, err := strconv.ParseInt(r[2], 10, 64)
+		if err != nil {
+			return access{}, false
+		}
+		a := access{kind: cache.CAS, hash: r[1], size: size}
+		if strings.HasPrefix(m[1], "WRITE") {
+			a.write = true
+		} else {
+			a.result = resultHit
+		}
+		return a, true
+	}
+
+	if m := bytestreamMissRegex.FindStringSubmatch(line); m != nil {
+		return access{kind: cache.CAS, hash: m[1], size: -1, result: resultMiss}, true
+	}
+
+	return access{}, false
+}
+
+// An LRU cache of a fixed size, which only tracks the sizes of the
+// entries.
+type simulation struct {
+	maxSize int64 // Zero for an unlimited size.
+	size    int64
+	ll      *list.List
+	entries map[string]*list.Element
+
+	reads, hits         int64
+	readBytes, hitBytes int64
+}
+
+type simEntry struct {
+	key  string
+	size int64
+}
+
+func newSimulation(maxSize int64) *simulation {
+	return &simulation{
+		maxSize: maxSize,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Replay a read or write of the entry with the given key. For reads,
+// observedHit is true if the real cache had the entry.
+func (s *simulation) access(key string, size int64, write bool, observedHit bool) {
+	ele, found := s.entries[key]
+
+	if !write {
+		s.reads++
+		s.readBytes += size
+		if found {
+			s.hits++
+			s.hitBytes += size
+			s.ll.MoveToFront(ele)
+			return
+		}
+		if !observedHit {
+			// The client builds the entry and uploads it, which is
+			// replayed as a separate write.
+			return
+		}
+		// The entry was written before the logs start, or this cache
+		// is smaller than the real one. Either way the client would
+		// have uploaded it after the miss, but that isn't in the logs.
+	}
+
+	if found {
+		s.size += size - ele.Value.(*simEntry).size
+		ele.Value.(*simEntry).size = size
+		s.ll.MoveToFront(ele)
+	} else {
+		if s.maxSize > 0 && size > s.maxSize {
+			return
+		}
+		s.entries[key] = s.ll.PushFront(&simEntry{key: key, size: size})
+		s.size += size
+	}
+
+	for s.maxSize > 0 && s.size > s.maxSize {
+		oldest := s.ll.Back()
+		e := oldest.Value.(*simEntry)
+		s.ll.Remove(oldest)
+		delete(s.entries, e.key)
+		s.size -= e.size
+	}
+}
+
+func (s *simulation) hitRatio() float64 {
+	if s.reads == 0 {
+		return 0
+	}
+	return float64(s.hits) / float64(s.reads)
+}
+
+func (s *simulation) byteHitRatio() float64 {
+	if s.readBytes == 0 {
+		return 0
+	}
+	return float64(s.hitBytes) / float64(s.readBytes)
+}
+
+type analyzeOptions struct {
+	sizes       []int64 // In bytes, or nil for the defaults.
+	defaultSize int64
+	entrySizes  map[string]int64 // From the cache directory, if any.
+}
+
+type analysis struct {
+	lines    int64
+	skipped  int64 // Accesses of entries with an unknown size.
+	observed struct{ reads, hits int64 }
+
+	workingSet  int64 // The total size of the distinct entries.
+	unlimited   *simulation
+	simulations []*simulation
+}
+
+// Replay the accesses in the logs read from rdr against simulated caches
+// of the given sizes, and of an unlimited size.
+func runAnalysis(rdr io.Reader, opts analyzeOptions) (*analysis, error) {
+	type replayed struct {
+		key   string
+		size  int64
+		write bool
+		hit   bool
+	}
+	var accesses []replayed
+
+	a := &analysis{}
+	sizes := make(map[string]int64)
+
+	scanner := bufio.NewScanner(rdr)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		a.lines++
+
+		acc, ok := parseAccess(scanner.Text())
+		if !ok {
+			continue
+		}
+		key := acc.key()
+
+		switch acc.result {
+		case resultHit:
+			a.observed.reads++
+			a.observed.hits++
+		case resultMiss:
+			a.observed.reads++
+		}
+
+		if size, ok := opts.entrySizes[key]; ok {
+			acc.size = size
+		}
+		if acc.size >= 0 {
+			sizes[key] = acc.size
+		}
+
+		accesses = append(accesses, replayed{key: key, size: acc.size, write: acc.write,
+			hit: acc.result == resultHit})
+	}
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	// The size of an entry is often only logged by some of its accesses,
+	// so look up the sizes after reading all of them.
+	distinct := make(map[string]int64)
+	for i := range accesses {
+		acc := &accesses[i]
+		if acc.size < 0 {
+			if size, ok := sizes[acc.key]; ok {
+				acc.size = size
+			} else if opts.defaultSize > 0 {
+				acc.size = opts.defaultSize
+			}
+		}
+		if acc.size >= 0 {
+			distinct[acc.key] = acc.size
+		}
+	}
+	for _, size := range distinct {
+		a.workingSet += size
+	}
+
+	maxSizes := opts.sizes
+	if maxSizes == nil {
+		for _, f := range defaultSizeFractions {
+			if s := int64(f * float64(a.workingSet)); s > 0 {
+				maxSizes = append(maxSizes, s)
+			}
+		}
+	}
+
+	a.unlimited = newSimulation(0)
+	for _, maxSize := range maxSizes {
+		a.simulations = append(a.simulations, newSimulation(maxSize))
+	}
+
+	for _, acc := range accesses {
+		if acc.size < 0 {
+			a.skipped++
+			continue
+		}
+		a.unlimited.access(acc.key, acc.size, acc.write, acc.hit)
+		for _, s := range a.simulations {
+			s.access(acc.key, acc.size, acc.write, acc.hit)
+		}
+	}
+
+	return a, nil
+}
+
+const gib = 1024 * 1024 * 1024
+
+func parseSizes(value string) ([]int64, error) {
+	var sizes []int64
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		size, err := strconv.ParseFloat(field, 64)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("Invalid cache size: %q", field)
+		}
+		sizes = append(sizes, int64(size*gib))
+	}
+	if len(sizes) == 0 {
+		return nil, fmt.Errorf("No cache sizes given")
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	return sizes, nil
+}
+
+// Format n bytes with a binary unit, eg "1.50 GiB".
+func formatBytes(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n) / 1024
+	for _, unit := range []string{"KiB", "MiB", "GiB"} {
+		if value < 1024 {
+			return fmt.Sprintf("%.2f %s", value, unit)
+		}
+		value /= 1024
+	}
+	return fmt.Sprintf("%.2f TiB", value)
+}
+
+func analyze(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return cli.Exit(fmt.Sprintf("Error: %s expects at least one log file\n\nUSAGE: %s",
+			ctx.Command.Name, ctx.Command.UsageText), 1)
+	}
+
+	opts := analyzeOptions{defaultSize: ctx.Int64("default_size")}
+	if opts.defaultSize < 0 {
+		return cli.Exit("The 'default_size' flag must not be negative", 1)
+	}
+
+	var err error
+	if ctx.IsSet("sizes") {
+		opts.sizes, err = parseSizes(ctx.String("sizes"))
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+	}
+
+	if dir := ctx.String("dir"); dir != "" {
+		opts.entrySizes = make(map[string]int64)
+		err = disk.Walk(dir, func(e disk.EntryInfo) error {
+			if !e.Incomplete {
+				opts.entrySizes[e.Kind.String()+"/"+e.Hash] = e.SizeOnDisk
+			}
+			return nil
+		})
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+	}
+
+	var readers []io.Reader
+	for _, name := range ctx.Args().Slice() {
+		if name == "-" {
+			readers = append(readers, os.Stdin)
+			continue
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+
+	a, err := runAnalysis(io.MultiReader(readers...), opts)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	printAnalysis(ctx.App.Writer, a)
+	return nil
+}
+
+func printAnalysis(out io.Writer, a *analysis) {
+	fmt.Fprintf(out, "Read %d lines, replayed %d reads.\n", a.lines, a.unlimited.reads)
+	if a.skipped > 0 {
+		fmt.Fprintf(out, "Skipped %d accesses of entries with an unknown size, see --dir and --default_size.\n", a.skipped)
+	}
+	if a.observed.reads > 0 {
+		fmt.Fprintf(out, "Observed hit ratio: %.1f%%\n",
+			100*float64(a.observed.hits)/float64(a.observed.reads))
+	}
+	fmt.Fprintf(out, "Working set: %s\n\n", formatBytes(a.workingSet))
+
+	w := tabwriter.NewWriter(out, 1, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "SIZE\tHITS\tHIT RATIO\tBYTE HIT RATIO\t")
+	for _, s := range a.simulations {
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%.1f%%\t\n", formatBytes(s.maxSize),
+			s.hits, 100*s.hitRatio(), 100*s.byteHitRatio())
+	}
+	fmt.Fprintf(w, "unlimited\t%d\t%.1f%%\t%.1f%%\t\n",
+		a.unlimited.hits, 100*a.unlimited.hitRatio(), 100*a.unlimited.byteHitRatio())
+	w.Flush()
+}
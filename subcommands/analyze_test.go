@@ -0,0 +1,203 @@
+package subcommands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	hashA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	hashB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	hashC = "cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"
+)
+
+func TestParseAccess(t *testing.T) {
+	testCases := []struct {
+		line     string
+		expected access
+	}{
+		{"2023/06/01 12:00:00  GET 200       127.0.0.1 /cas/" + hashA,
+			access{kind: cache.CAS, hash: hashA, size: -1, result: resultHit}},
+		{" GET 404       127.0.0.1 /foo/ac/" + hashA,
+			access{kind: cache.AC, hash: hashA, size: -1, result: resultMiss}},
+		{" PUT 200       127.0.0.1 /ac/" + hashA,
+			access{kind: cache.AC, hash: hashA, size: -1, write: true}},
+		{"2023/06/01 12:00:00 GRPC AC GET " + hashA + " NOT FOUND",
+			access{kind: cache.AC, hash: hashA, size: -1, result: resultMiss}},
+		{"GRPC CAS PUT " + hashA + " OK",
+			access{kind: cache.CAS, hash: hashA, size: -1, write: true}},
+		{"GRPC BYTESTREAM READ COMPLETED foo/blobs/" + hashA + "/42",
+			access{kind: cache.CAS, hash: hashA, size: 42, result: resultHit}},
+		{"GRPC BYTESTREAM WRITE COMPLETED: uploads/1234/compressed-blobs/zstd/" + hashA + "/42",
+			access{kind: cache.CAS, hash: hashA, size: 42, write: true}},
+		{"GRPC BYTESTREAM READ BLOB NOT FOUND: " + hashA,
+			access{kind: cache.CAS, hash: hashA, size: -1, result: resultMiss}},
+		{`{"op":"read","kind":"raw","hash":"` + hashA + `","size":42,"result":"hit"}`,
+			access{kind: cache.RAW, hash: hashA, size: 42, result: resultHit}},
+		{`{"op":"write","kind":"cas","hash":"` + hashA + `","size":42}`,
+			access{kind: cache.CAS, hash: hashA, size: 42, write: true}},
+	}
+
+	for _, tc := range testCases {
+		a, ok := parseAccess(tc.line)
+		if !ok {
+			t.Errorf("Failed to parse %q", tc.line)
+			continue
+		}
+		if a != tc.expected {
+			t.Errorf("Expected %+v for %q, got %+v", tc.expected, tc.line, a)
+		}
+	}
+
+	for _, line := range []string{
+		"",
+		"GRPC GETCAPABILITIES",
+		" GET 500       127.0.0.1 /cas/" + hashA,
+		" GET 200       127.0.0.1 /status",
+		`{"op":"evict","kind":"cas","hash":"` + hashA + `","size":42}`,
+	} {
+		if a, ok := parseAccess(line); ok {
+			t.Errorf("Expected %q to be ignored, got %+v", line, a)
+		}
+	}
+}
+
+func TestAnalysis(t *testing.T) {
+	logs := strings.Join([]string{
+		"GRPC BYTESTREAM WRITE COMPLETED: blobs/" + hashA + "/100",
+		" PUT 200       127.0.0.1 /cas/" + hashB,
+		"GRPC CAS GET " + hashA + " OK",
+		`{"op":"write","kind":"cas","hash":"` + hashC + `","size":100}`,
+		" GET 200       127.0.0.1 /cas/" + hashB,
+		"GRPC BYTESTREAM READ COMPLETED blobs/" + hashA + "/100",
+		"GRPC CAS GET " + hashC + " NOT FOUND",
+	}, "\n")
+
+	opts := analyzeOptions{
+		sizes:      []int64{200},
+		entrySizes: map[string]int64{"cas/" + hashB: 100},
+	}
+	a, err := runAnalysis(strings.NewReader(logs), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.observed.reads != 4 || a.observed.hits != 3 {
+		t.Errorf("Expected 3 observed hits from 4 reads, got %+v", a.observed)
+	}
+	if a.workingSet != 300 {
+		t.Errorf("Expected a working set of 300 bytes, got %d", a.workingSet)
+	}
+	if a.skipped != 0 {
+		t.Errorf("Expected no skipped accesses, got %d", a.skipped)
+	}
+
+	// B is evicted by writing C, A by reading B again (which the client
+	// would upload after the miss) and C by reading A again.
+	if len(a.simulations) != 1 || a.simulations[0].reads != 4 || a.simulations[0].hits != 1 {
+		t.Errorf("Expected 1 hit from 4 reads in a 200 byte cache, got %+v", a.simulations[0])
+	}
+	if a.unlimited.reads != 4 || a.unlimited.hits != 4 {
+		t.Errorf("Expected 4 hits from 4 reads in an unlimited cache, got %+v", a.unlimited)
+	}
+}
+
+func TestAnalyzeUnknownSizes(t *testing.T) {
+	logs := " PUT 200       127.0.0.1 /ac/" + hashA + "\n" +
+		" GET 200       127.0.0.1 /ac/" + hashA + "\n"
+
+	a, err := runAnalysis(strings.NewReader(logs), analyzeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.skipped != 2 || a.unlimited.reads != 0 {
+		t.Errorf("Expected 2 skipped accesses, got %d skipped and %d reads", a.skipped, a.unlimited.reads)
+	}
+
+	a, err = runAnalysis(strings.NewReader(logs), analyzeOptions{defaultSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.skipped != 0 || a.unlimited.hits != 1 || a.workingSet != 10 {
+		t.Errorf("Expected 1 hit with the default size, got %+v", a)
+	}
+}
+
+func TestAnalyzeCommand(t *testing.T) {
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "access.log")
+	logs := "GRPC BYTESTREAM WRITE COMPLETED: blobs/" + hashA + "/100\n" +
+		"GRPC BYTESTREAM READ COMPLETED blobs/" + hashA + "/100\n"
+	err := os.WriteFile(file, []byte(logs), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := new(bytes.Buffer)
+	app := &cli.App{
+		Name:     "bazel-remote",
+		Writer:   output,
+		Commands: Commands(),
+	}
+
+	err = app.Run([]string{"bazel-remote", "analyze", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	for i, expected := range []string{
+		"Read 2 lines, replayed 1 reads.",
+		"Observed hit ratio: 100.0%",
+		"Working set: 100 B",
+		"",
+		"SIZE HITS HIT RATIO BYTE HIT RATIO",
+		"10 B 0 0.0% 0.0%",
+		"25 B 0 0.0% 0.0%",
+		"50 B 0 0.0% 0.0%",
+		"75 B 0 0.0% 0.0%",
+		"unlimited 1 100.0% 100.0%",
+	} {
+		if i >= len(lines) || strings.Join(strings.Fields(lines[i]), " ") != expected {
+			t.Fatalf("Expected line %d to be %q, got:\n%s", i, expected, output.String())
+		}
+	}
+}
+
+func TestParseSizes(t *testing.T) {
+	sizes, err := parseSizes("10, 0.5,1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sizes) != 3 || sizes[0] != gib/2 || sizes[1] != gib || sizes[2] != 10*gib {
+		t.Errorf("Expected sorted sizes in bytes, got %v", sizes)
+	}
+
+	for _, value := range []string{"", "1,foo", "-1", "0"} {
+		_, err = parseSizes(value)
+		if err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+
+	for n, expected := range map[int64]string{
+		1023:       "1023 B",
+		1536:       "1.50 KiB",
+		10 * gib:   "10.00 GiB",
+		2048 * gib: "2.00 TiB",
+	} {
+		if f := formatBytes(n); f != expected {
+			t.Errorf("Expected %q for %d bytes, got %q", expected, n, f)
+		}
+	}
+}
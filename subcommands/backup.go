@@ -0,0 +1,277 @@
+package subcommands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+// Save an in-progress manifest after this many uploads, so an
+// interrupted backup does not need to start from scratch.
+const defaultCheckpointInterval = 1000
+
+func backupCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "backup",
+		Usage:     "Incrementally back up AC and optionally CAS entries to S3.",
+		UsageText: "bazel-remote backup --dir <dir> --s3.bucket <bucket> [options]",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "dir",
+				Usage:   "The cache directory to back up. This flag is required.",
+				EnvVars: []string{"BAZEL_REMOTE_DIR"},
+			},
+			&cli.BoolFlag{
+				Name:        "include_cas",
+				Usage:       "Whether to back up CAS blobs as well as AC entries.",
+				DefaultText: "false, ie only back up the AC",
+				EnvVars:     []string{"BAZEL_REMOTE_BACKUP_INCLUDE_CAS"},
+			},
+			&cli.DurationFlag{
+				Name:        "cas_max_age",
+				Usage:       "If --include_cas is set, only back up CAS blobs which were accessed within this duration.",
+				DefaultText: "0s, ie back up all CAS blobs",
+				EnvVars:     []string{"BAZEL_REMOTE_BACKUP_CAS_MAX_AGE"},
+			},
+			&cli.IntFlag{
+				Name:    "concurrency",
+				Value:   16,
+				Usage:   "The number of entries to upload in parallel.",
+				EnvVars: []string{"BAZEL_REMOTE_BACKUP_CONCURRENCY"},
+			},
+		}, s3StoreFlags()...),
+		Action: backup,
+	}
+}
+
+type backupOptions struct {
+	dir                string
+	includeCAS         bool
+	casMaxAge          time.Duration
+	concurrency        int
+	checkpointInterval int
+}
+
+type backupStats struct {
+	resumed   bool
+	uploaded  int
+	bytes     int64
+	unchanged int
+	vanished  int
+	manifest  string
+}
+
+func backup(ctx *cli.Context) error {
+	err := checkArgs(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	opts := backupOptions{
+		dir:                ctx.String("dir"),
+		includeCAS:         ctx.Bool("include_cas"),
+		casMaxAge:          ctx.Duration("cas_max_age"),
+		concurrency:        ctx.Int("concurrency"),
+		checkpointInterval: defaultCheckpointInterval,
+	}
+	if opts.dir == "" {
+		return cli.Exit("The 'dir' flag must be set", 1)
+	}
+	if opts.concurrency < 1 {
+		return cli.Exit("The 'concurrency' flag must be set to a value > 0", 1)
+	}
+
+	store, err := newS3Store(ctx)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	sigCtx, stop := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	stats, err := runBackup(sigCtx, store, opts)
+	if stats.resumed {
+		fmt.Fprintln(ctx.App.Writer, "Resumed an interrupted backup.")
+	}
+	fmt.Fprintf(ctx.App.Writer, "Uploaded %d entries (%d bytes), %d unchanged, %d removed during the backup.\n",
+		stats.uploaded, stats.bytes, stats.unchanged, stats.vanished)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Backup failed, re-run to resume: %v", err), 1)
+	}
+	fmt.Fprintf(ctx.App.Writer, "Wrote manifest %s\n", stats.manifest)
+
+	return nil
+}
+
+type pendingUpload struct {
+	entry manifestEntry
+	path  string
+}
+
+func runBackup(ctx context.Context, store objectStore, opts backupOptions) (backupStats, error) {
+	var stats backupStats
+
+	// Entries which have already been uploaded, by lookup key.
+	prev := make(map[string]manifestEntry)
+
+	latest, err := loadManifest(ctx, store, latestManifestKey)
+	if err != nil {
+		return stats, err
+	}
+	if latest != nil {
+		for _, e := range latest.Entries {
+			prev[e.lookupKey()] = e
+		}
+	}
+
+	m := &manifest{Started: time.Now().UTC()}
+
+	inProgress, err := loadManifest(ctx, store, inProgressManifestKey)
+	if err != nil {
+		return stats, err
+	}
+	if inProgress != nil {
+		stats.resumed = true
+		m.Started = inProgress.Started
+		for _, e := range inProgress.Entries {
+			prev[e.lookupKey()] = e
+		}
+	}
+
+	var todo []pendingUpload
+	err = disk.Walk(opts.dir, func(e disk.EntryInfo) error {
+		if e.Incomplete || e.Kind == cache.RAW {
+			return nil
+		}
+		if e.Kind == cache.CAS {
+			if !opts.includeCAS {
+				return nil
+			}
+			if opts.casMaxAge > 0 && time.Since(e.Atime) > opts.casMaxAge {
+				return nil
+			}
+		}
+
+		me := newManifestEntry(e)
+		p, found := prev[me.lookupKey()]
+		if found && p == me {
+			stats.unchanged++
+			m.Entries = append(m.Entries, me)
+			return nil
+		}
+
+		todo = append(todo, pendingUpload{entry: me, path: e.Path})
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	var mu sync.Mutex // Protects m and stats from here on.
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(opts.concurrency)
+
+	for _, p := range todo {
+		p := p
+		g.Go(func() error {
+			err := uploadEntry(gctx, store, p)
+			if errors.Is(err, os.ErrNotExist) {
+				// The entry was evicted or overwritten since we
+				// walked the cache directory.
+				mu.Lock()
+				stats.vanished++
+				mu.Unlock()
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("Failed to upload %q: %w", p.path, err)
+			}
+
+			mu.Lock()
+			m.Entries = append(m.Entries, p.entry)
+			stats.uploaded++
+			stats.bytes += p.entry.SizeOnDisk
+			var checkpoint []byte
+			if stats.uploaded%opts.checkpointInterval == 0 {
+				checkpoint, err = json.Marshal(m)
+			}
+			mu.Unlock()
+
+			if err != nil {
+				return err
+			}
+			if checkpoint != nil {
+				return putManifest(gctx, store, inProgressManifestKey, checkpoint)
+			}
+			return nil
+		})
+	}
+
+	err = g.Wait()
+	if err != nil {
+		// Record what we managed to upload, so the next run can
+		// resume from here. Use a fresh context, in case we were
+		// interrupted.
+		data, merr := json.Marshal(m)
+		if merr == nil {
+			_ = putManifest(context.Background(), store, inProgressManifestKey, data)
+		}
+		return stats, err
+	}
+
+	completed := time.Now().UTC()
+	m.Completed = &completed
+	m.sort()
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.manifest = "manifests/" + m.Started.Format(manifestTimeFormat) + ".json"
+	err = putManifest(ctx, store, stats.manifest, data)
+	if err != nil {
+		return stats, err
+	}
+	err = putManifest(ctx, store, latestManifestKey, data)
+	if err != nil {
+		return stats, err
+	}
+
+	if inProgress != nil || stats.uploaded >= opts.checkpointInterval {
+		err = store.remove(ctx, inProgressManifestKey)
+		if err != nil && err != errObjectNotFound {
+			return stats, err
+		}
+	}
+
+	return stats, nil
+}
+
+func uploadEntry(ctx context.Context, store objectStore, p pendingUpload) error {
+	key, err := p.entry.objectKey()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(p.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return store.put(ctx, key, f, p.entry.SizeOnDisk)
+}
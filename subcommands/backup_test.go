@@ -0,0 +1,287 @@
+package subcommands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+// An in-memory objectStore, which can be made to fail after a number
+// of puts.
+type memStore struct {
+	mu        sync.Mutex
+	objects   map[string][]byte
+	puts      int
+	failAfter int // Fail puts after this many, if > 0.
+}
+
+var errInjected = errors.New("injected failure")
+
+func newMemStore() *memStore {
+	return &memStore{objects: make(map[string][]byte)}
+}
+
+func (s *memStore) put(ctx context.Context, key string, r io.Reader, size int64) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failAfter > 0 && s.puts >= s.failAfter {
+		return errInjected
+	}
+	s.puts++
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memStore) get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, found := s.objects[key]
+	if !found {
+		return nil, errObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memStore) remove(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.objects, key)
+	return nil
+}
+
+func populateCache(t *testing.T, dir string, kind cache.EntryKind, n int) {
+	c, err := disk.New(dir, 10*1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < n; i++ {
+		data, hash := testutils.RandomDataAndHash(100)
+		err = c.Put(context.Background(), kind, hash, int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func countEntries(t *testing.T, dir string) map[cache.EntryKind]int {
+	counts := make(map[cache.EntryKind]int)
+	err := disk.Walk(dir, func(e disk.EntryInfo) error {
+		counts[e.Kind]++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return counts
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	populateCache(t, dir, cache.AC, 5)
+	populateCache(t, dir, cache.CAS, 3)
+	populateCache(t, dir, cache.RAW, 2)
+
+	store := newMemStore()
+	opts := backupOptions{dir: dir, concurrency: 4, checkpointInterval: 1000}
+
+	stats, err := runBackup(ctx, store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.uploaded != 5 || stats.unchanged != 0 {
+		t.Errorf("Expected 5 AC entries to be uploaded, got %+v", stats)
+	}
+
+	// A second backup should be incremental.
+	opts.includeCAS = true
+	stats, err = runBackup(ctx, store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.uploaded != 3 || stats.unchanged != 5 {
+		t.Errorf("Expected 3 CAS entries to be uploaded, got %+v", stats)
+	}
+
+	m, err := loadManifest(ctx, store, latestManifestKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m.Completed == nil || len(m.Entries) != 8 {
+		t.Fatalf("Unexpected latest manifest: %+v", m)
+	}
+
+	restoreDir := testutils.TempDir(t)
+	defer os.RemoveAll(restoreDir)
+
+	rstats, err := runRestore(ctx, store, restoreOptions{dir: restoreDir, concurrency: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rstats.restored != 8 || rstats.skipped != 0 {
+		t.Errorf("Expected 8 restored entries, got %+v", rstats)
+	}
+
+	counts := countEntries(t, restoreDir)
+	if counts[cache.AC] != 5 || counts[cache.CAS] != 3 || counts[cache.RAW] != 0 {
+		t.Errorf("Unexpected restored entries: %v", counts)
+	}
+
+	// The restored directory should be usable by the disk cache.
+	c, err := disk.New(restoreDir, 10*1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range m.Entries {
+		kind, _ := e.entryKind()
+		found, _ := c.Contains(ctx, kind, e.Hash, -1)
+		if !found {
+			t.Errorf("Restored entry %s not found in the cache", e.lookupKey())
+		}
+	}
+
+	// Restoring again should not download anything.
+	rstats, err = runRestore(ctx, store, restoreOptions{dir: restoreDir, concurrency: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rstats.restored != 0 || rstats.skipped != 8 {
+		t.Errorf("Expected all entries to be skipped, got %+v", rstats)
+	}
+}
+
+func TestBackupResume(t *testing.T) {
+	ctx := context.Background()
+
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	populateCache(t, dir, cache.AC, 10)
+
+	store := newMemStore()
+	store.failAfter = 4
+	opts := backupOptions{dir: dir, concurrency: 1, checkpointInterval: 2}
+
+	_, err := runBackup(ctx, store, opts)
+	if !errors.Is(err, errInjected) {
+		t.Fatalf("Expected an injected error, got %v", err)
+	}
+
+	inProgress, err := loadManifest(ctx, store, inProgressManifestKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inProgress == nil || len(inProgress.Entries) == 0 {
+		t.Fatalf("Expected a checkpoint manifest, got %+v", inProgress)
+	}
+	done := len(inProgress.Entries)
+
+	store.failAfter = 0
+	stats, err := runBackup(ctx, store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stats.resumed || stats.unchanged != done || stats.uploaded != 10-done {
+		t.Errorf("Expected to resume after %d entries, got %+v", done, stats)
+	}
+
+	_, found := store.objects[inProgressManifestKey]
+	if found {
+		t.Error("Expected the checkpoint manifest to be removed")
+	}
+}
+
+func TestRestoreOverwrittenEntry(t *testing.T) {
+	ctx := context.Background()
+
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	c, err := disk.New(dir, 10*1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldData, hash := testutils.RandomDataAndHash(100)
+	err = c.Put(ctx, cache.AC, hash, int64(len(oldData)), bytes.NewReader(oldData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := newMemStore()
+	opts := backupOptions{dir: dir, concurrency: 1, checkpointInterval: 1000}
+	_, err = runBackup(ctx, store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.objects["manifests/old.json"] = store.objects[latestManifestKey]
+
+	// A later backup of the overwritten entry must not replace the
+	// object which the older manifest refers to.
+	newData, _ := testutils.RandomDataAndHash(100)
+	err = c.Put(ctx, cache.AC, hash, int64(len(newData)), bytes.NewReader(newData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The replaced file is removed in the background.
+	for i := 0; countEntries(t, dir)[cache.AC] != 1; i++ {
+		if i == 100 {
+			t.Fatal("Expected the replaced file to be removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stats, err := runBackup(ctx, store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.uploaded != 1 {
+		t.Errorf("Expected the overwritten entry to be uploaded, got %+v", stats)
+	}
+
+	for manifest, expected := range map[string][]byte{"old.json": oldData, "": newData} {
+		restoreDir := testutils.TempDir(t)
+		defer os.RemoveAll(restoreDir)
+
+		_, err = runRestore(ctx, store, restoreOptions{dir: restoreDir, manifest: manifest, concurrency: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rc, err := disk.New(restoreDir, 10*1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _, err := rc.Get(ctx, cache.AC, hash, -1, 0)
+		if err != nil || r == nil {
+			t.Fatalf("Expected to find the restored entry, got %v", err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, expected) {
+			t.Errorf("Expected the manifest %q to restore the data which it was backed up with", manifest)
+		}
+	}
+}
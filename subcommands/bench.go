@@ -0,0 +1,694 @@
+package subcommands
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+func benchCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "bench",
+		Usage: "Send a configurable mix of requests to a running cache, and report their latency.",
+		UsageText: "bazel-remote bench --url <url> [--qps <n>] [--duration <duration>] [--mix <op=weight,...>] [--blob_sizes <size=weight,...>]\n\n" +
+			"The URL is http(s)://host:port for the HTTP API, or grpc(s)://host:port for the gRPC API,\n" +
+			"optionally with an instance name as its path and basic authentication credentials.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "url",
+				Usage:   "The URL of the bazel-remote instance to benchmark. This flag is required.",
+				EnvVars: []string{"BAZEL_REMOTE_BENCH_URL"},
+			},
+			&cli.Float64Flag{
+				Name:        "qps",
+				Usage:       "The number of requests to start per second. Latencies are measured from when each request should have started, so they include the time spent waiting for a free connection if the instance falls behind.",
+				DefaultText: "0, ie as many as --concurrency allows",
+			},
+			&cli.DurationFlag{
+				Name:  "duration",
+				Value: 30 * time.Second,
+				Usage: "How long to send requests for.",
+			},
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Value: 16,
+				Usage: "The maximum number of requests in flight.",
+			},
+			&cli.StringFlag{
+				Name:  "mix",
+				Value: "read=70,write=20,findmissing=10",
+				Usage: "The relative weights of reads, writes and findmissing requests. Over HTTP, findmissing requests are HEAD requests for a single blob.",
+			},
+			&cli.StringFlag{
+				Name:  "blob_sizes",
+				Value: "4KiB=60,64KiB=30,1MiB=9,16MiB=1",
+				Usage: "The relative weights of the sizes of the blobs written, in bytes or with a B, KiB, MiB or GiB suffix.",
+			},
+			&cli.IntFlag{
+				Name:  "preload",
+				Value: 100,
+				Usage: "The number of blobs to write before the benchmark starts, for the reads to find. Ignored with --trace.",
+			},
+			&cli.IntFlag{
+				Name:  "find_missing_batch",
+				Value: 100,
+				Usage: "The number of digests in each gRPC findmissing request, half of them of blobs which were written.",
+			},
+			&cli.Int64Flag{
+				Name:  "seed",
+				Value: 1,
+				Usage: "The seed for the random requests and blob contents, so that runs with the same flags send the same workload.",
+			},
+			&cli.StringFlag{
+				Name:  "trace",
+				Usage: "Replay the reads and writes in this event stream file, eg written with --event_stream.file, instead of sending random requests. --mix, --blob_sizes, --preload and --qps are ignored, and by default the whole trace is replayed.",
+			},
+			&cli.Float64Flag{
+				Name:  "speed",
+				Value: 1,
+				Usage: "How much faster than they were recorded to replay the requests in the --trace file, or 0 to replay them as fast as possible.",
+			},
+			&cli.StringFlag{
+				Name:        "tls_ca_file",
+				Usage:       "A PEM file with the CA certificate to verify an https:// or grpcs:// server's certificate with.",
+				DefaultText: "the system's CA certificates",
+			},
+		},
+		Action: bench,
+	}
+}
+
+type benchOpKind int
+
+const (
+	opRead benchOpKind = iota
+	opWrite
+	opFindMissing
+	numBenchOpKinds
+)
+
+var benchOpNames = [numBenchOpKinds]string{"read", "write", "findmissing"}
+
+func (k benchOpKind) String() string {
+	return benchOpNames[k]
+}
+
+// A request to send during a benchmark.
+type benchOp struct {
+	kind benchOpKind
+
+	// When the request should start, relative to the start of the run.
+	at time.Duration
+
+	// For writes, the ID and size of the blob to write.
+	id   uint64
+	size int64
+
+	// For reads, which of the blobs written so far to read, between 0
+	// and 1.
+	pick float64
+
+	// For reads of a particular blob, eg when replaying a trace, whether
+	// to read the blob with the ID and size above, or a blob which
+	// doesn't exist.
+	target  bool
+	missing bool
+}
+
+// The digest of a blob, in the form which clients send.
+type blobDigest struct {
+	hash string
+	size int64
+}
+
+type weighted[T any] struct {
+	value  T
+	weight float64
+}
+
+// Pick one of the values, with a probability proportional to its weight.
+func pickWeighted[T any](rng *rand.Rand, values []weighted[T]) T {
+	var total float64
+	for _, v := range values {
+		total += v.weight
+	}
+	x := rng.Float64() * total
+	for _, v := range values {
+		if x < v.weight {
+			return v.value
+		}
+		x -= v.weight
+	}
+	return values[len(values)-1].value
+}
+
+// Parse a comma-separated list of name=weight pairs.
+func parseWeights[T any](value string, what string, parse func(string) (T, error)) ([]weighted[T], error) {
+	var values []weighted[T]
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		name, w, found := strings.Cut(field, "=")
+		if !found {
+			return nil, fmt.Errorf("Invalid %s: %q, expected <%s>=<weight>", what, field, what)
+		}
+		v, err := parse(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(w), 64)
+		if err != nil || weight < 0 || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("Invalid weight for %s %q: %q", what, name, w)
+		}
+		if weight > 0 {
+			values = append(values, weighted[T]{value: v, weight: weight})
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("No %s with a positive weight given", what)
+	}
+	return values, nil
+}
+
+func parseMix(value string) ([]weighted[benchOpKind], error) {
+	return parseWeights(value, "operation", func(name string) (benchOpKind, error) {
+		for k, n := range benchOpNames {
+			if n == name {
+				return benchOpKind(k), nil
+			}
+		}
+		return 0, fmt.Errorf("Unknown operation %q, expected read, write or findmissing", name)
+	})
+}
+
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"B", 1},
+}
+
+// Parse a size in bytes, with an optional binary unit, eg "64KiB".
+func parseByteSize(value string) (int64, error) {
+	unit := int64(1)
+	number := value
+	for _, u := range byteUnits {
+		if strings.HasSuffix(value, u.suffix) {
+			unit = u.size
+			number = strings.TrimSpace(strings.TrimSuffix(value, u.suffix))
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 || n*float64(unit) > math.MaxInt64/2 {
+		return 0, fmt.Errorf("Invalid size: %q", value)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+func parseBlobSizes(value string) ([]weighted[int64], error) {
+	return parseWeights(value, "size", parseByteSize)
+}
+
+// Generates the requests of a synthetic benchmark.
+type syntheticWorkload struct {
+	rng   *rand.Rand
+	mix   []weighted[benchOpKind]
+	sizes []weighted[int64]
+
+	// The interval between requests, or zero to send them as fast as
+	// possible.
+	interval time.Duration
+
+	n      int64
+	nextID uint64
+}
+
+func (w *syntheticWorkload) next() benchOp {
+	op := benchOp{
+		kind: pickWeighted(w.rng, w.mix),
+		at:   time.Duration(w.n) * w.interval,
+	}
+	w.n++
+
+	switch op.kind {
+	case opWrite:
+		op.id = w.nextID
+		w.nextID++
+		op.size = pickWeighted(w.rng, w.sizes)
+	case opRead, opFindMissing:
+		op.pick = w.rng.Float64()
+	}
+	return op
+}
+
+// The content of the blob with the given ID. Blobs are random, so that
+// they are not compressed better than real blobs, and unique.
+func blobData(seed int64, id uint64, size int64) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(seed ^ int64(id*0x9e3779b97f4a7c15))).Read(data)
+	return data
+}
+
+func digestOf(data []byte) blobDigest {
+	hash := sha256.Sum256(data)
+	return blobDigest{hash: hex.EncodeToString(hash[:]), size: int64(len(data))}
+}
+
+// The results of one kind of request.
+type benchStats struct {
+	requests  int
+	errors    int
+	misses    int
+	bytes     int64
+	latencies []time.Duration
+}
+
+type benchOptions struct {
+	// How long to send requests for, or zero to send all of them.
+	duration         time.Duration
+	concurrency      int
+	preload          int
+	findMissingBatch int
+	seed             int64
+
+	// Whether the requests are sent at the times given by benchOp.at,
+	// rather than as fast as possible.
+	paced bool
+}
+
+// Sends the requests of a benchmark, and collects their results.
+type benchRunner struct {
+	client benchClient
+	opts   benchOptions
+
+	mu      sync.Mutex
+	written []blobDigest
+	digests map[uint64]blobDigest // By blob ID.
+	stats   [numBenchOpKinds]benchStats
+
+	// The first error of each kind of request, to show why they fail.
+	firstErrors [numBenchOpKinds]error
+	missingID   uint64
+}
+
+func newBenchRunner(client benchClient, opts benchOptions) *benchRunner {
+	return &benchRunner{client: client, opts: opts, digests: make(map[uint64]blobDigest)}
+}
+
+// Write the blobs which the benchmark's reads start with.
+func (r *benchRunner) preload(ctx context.Context, blobs []benchOp) error {
+	ids := make(chan benchOp)
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		defer close(ids)
+		for _, op := range blobs {
+			select {
+			case ids <- op:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	for i := 0; i < r.opts.concurrency; i++ {
+		g.Go(func() error {
+			for op := range ids {
+				data := blobData(r.opts.seed, op.id, op.size)
+				d := digestOf(data)
+				err := r.client.write(ctx, d, data)
+				if err != nil {
+					return fmt.Errorf("Failed to preload a blob: %w", err)
+				}
+				r.addWritten(op.id, d)
+			}
+			return nil
+		})
+	}
+	err := g.Wait()
+	if err != nil {
+		return err
+	}
+
+	// The order of the concurrent writes varies, sort the blobs so that
+	// the reads of each run are the same.
+	sort.Slice(r.written, func(i, j int) bool { return r.written[i].hash < r.written[j].hash })
+	return nil
+}
+
+// Send the requests from next until the duration of the benchmark has
+// passed or ctx is done, and return how long it took.
+func (r *benchRunner) run(ctx context.Context, next func() (benchOp, bool)) time.Duration {
+	ops := make(chan benchOp)
+	start := time.Now()
+	var wg sync.WaitGroup
+
+	for i := 0; i < r.opts.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for op := range ops {
+				r.do(ctx, op, start)
+			}
+		}()
+	}
+
+	deadline := start.Add(r.opts.duration)
+	for {
+		op, ok := next()
+		if !ok {
+			break
+		}
+		at := start.Add(op.at)
+		if r.opts.duration > 0 && (at.After(deadline) || time.Now().After(deadline)) {
+			break
+		}
+		if d := time.Until(at); d > 0 {
+			t := time.NewTimer(d)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		ops <- op
+	}
+
+	close(ops)
+	wg.Wait()
+	return time.Since(start)
+}
+
+// Send a request, and record its result.
+func (r *benchRunner) do(ctx context.Context, op benchOp, start time.Time) {
+	var data []byte
+	if op.kind == opWrite {
+		data = blobData(r.opts.seed, op.id, op.size)
+	}
+
+	began := time.Now()
+	if r.opts.paced {
+		began = start.Add(op.at)
+	}
+
+	var err error
+	var bytes int64
+	missed := false
+
+	switch op.kind {
+	case opRead:
+		d, ok := r.readDigest(op)
+		if !ok {
+			// Nothing was written yet.
+			return
+		}
+		var found bool
+		found, err = r.client.read(ctx, d)
+		missed = err == nil && !found
+		if found {
+			bytes = d.size
+		}
+	case opWrite:
+		d := digestOf(data)
+		err = r.client.write(ctx, d, data)
+		if err == nil {
+			bytes = d.size
+			r.addWritten(op.id, d)
+		}
+	case opFindMissing:
+		err = r.client.findMissing(ctx, r.findMissingDigests(op.pick))
+	}
+
+	latency := time.Since(began)
+	if ctx.Err() != nil {
+		// Interrupted requests would skew the results.
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := &r.stats[op.kind]
+	s.requests++
+	s.bytes += bytes
+	s.latencies = append(s.latencies, latency)
+	if missed {
+		s.misses++
+	}
+	if err != nil {
+		s.errors++
+		if r.firstErrors[op.kind] == nil {
+			r.firstErrors[op.kind] = err
+		}
+	}
+}
+
+func (r *benchRunner) addWritten(id uint64, d blobDigest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.written = append(r.written, d)
+	r.digests[id] = d
+}
+
+// Returns the digest of the blob to read, and false if there is none.
+func (r *benchRunner) readDigest(op benchOp) (blobDigest, bool) {
+	if op.missing {
+		return missingDigest(op.id), true
+	}
+
+	r.mu.Lock()
+	if op.target {
+		d, found := r.digests[op.id]
+		r.mu.Unlock()
+		if !found {
+			// The blob is being written, or its write failed.
+			d = digestOf(blobData(r.opts.seed, op.id, op.size))
+		}
+		return d, true
+	}
+	defer r.mu.Unlock()
+
+	if len(r.written) == 0 {
+		return blobDigest{}, false
+	}
+	return r.written[int(op.pick*float64(len(r.written)))], true
+}
+
+// Returns the digest of a blob which doesn't exist.
+func missingDigest(id uint64) blobDigest {
+	hash := sha256.Sum256([]byte("missing-" + strconv.FormatUint(id, 10)))
+	return blobDigest{hash: hex.EncodeToString(hash[:]), size: 1}
+}
+
+// Returns the digests for a findmissing request, half of them of blobs
+// which were written, and half of blobs which don't exist.
+func (r *benchRunner) findMissingDigests(pick float64) []blobDigest {
+	n := r.opts.findMissingBatch
+	digests := make([]blobDigest, 0, n)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := 0; i < n; i++ {
+		if i%2 == 0 && len(r.written) > 0 {
+			j := (int(pick*float64(len(r.written))) + i) % len(r.written)
+			digests = append(digests, r.written[j])
+			continue
+		}
+		r.missingID++
+		digests = append(digests, missingDigest(r.missingID))
+	}
+	return digests
+}
+
+// Returns the latency at percentile p of latencies, which must be sorted.
+func percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p/100*float64(len(latencies)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return latencies[i]
+}
+
+// Format a latency with a precision which suits its magnitude.
+func formatLatency(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
+
+func (r *benchRunner) printResults(out io.Writer, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := 0
+	errors := 0
+	for _, s := range r.stats {
+		total += s.requests
+		errors += s.errors
+	}
+	fmt.Fprintf(out, "Sent %d requests in %s (%.1f per second), %d failed.\n\n",
+		total, elapsed.Round(time.Millisecond), float64(total)/elapsed.Seconds(), errors)
+
+	w := tabwriter.NewWriter(out, 1, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "OPERATION\tREQUESTS\tERRORS\tMISSES\tTHROUGHPUT\tP50\tP90\tP99\tP99.9\tMAX\t")
+	for k := range r.stats {
+		s := &r.stats[k]
+		if s.requests == 0 {
+			continue
+		}
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s/s\t%s\t%s\t%s\t%s\t%s\t\n",
+			benchOpKind(k), s.requests, s.errors, s.misses,
+			formatBytes(int64(float64(s.bytes)/elapsed.Seconds())),
+			formatLatency(percentile(s.latencies, 50)),
+			formatLatency(percentile(s.latencies, 90)),
+			formatLatency(percentile(s.latencies, 99)),
+			formatLatency(percentile(s.latencies, 99.9)),
+			formatLatency(s.latencies[len(s.latencies)-1]))
+	}
+	w.Flush()
+
+	for k, err := range r.firstErrors {
+		if err != nil {
+			fmt.Fprintf(out, "\nFirst %s error: %v\n", benchOpKind(k), err)
+		}
+	}
+}
+
+func bench(ctx *cli.Context) error {
+	err := checkArgs(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	opts, err := benchOptionsFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.String("trace") != "" {
+		return replay(ctx, opts)
+	}
+
+	mix, err := parseMix(ctx.String("mix"))
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	sizes, err := parseBlobSizes(ctx.String("blob_sizes"))
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	qps := ctx.Float64("qps")
+	if qps < 0 || math.IsInf(qps, 0) || math.IsNaN(qps) {
+		return cli.Exit("The 'qps' flag must not be negative", 1)
+	}
+
+	workload := &syntheticWorkload{
+		rng:   rand.New(rand.NewSource(opts.seed)),
+		mix:   mix,
+		sizes: sizes,
+	}
+	if qps > 0 {
+		workload.interval = time.Duration(float64(time.Second) / qps)
+		opts.paced = true
+	}
+
+	// The IDs of the preloaded blobs are above those of the blobs written
+	// by the benchmark.
+	rng := rand.New(rand.NewSource(opts.seed))
+	preload := make([]benchOp, opts.preload)
+	for i := range preload {
+		preload[i] = benchOp{id: math.MaxUint64 - uint64(i), size: pickWeighted(rng, sizes)}
+	}
+
+	return runBench(ctx, opts, preload, fmt.Sprintf("Sending requests for %s...", opts.duration), func() (benchOp, bool) {
+		return workload.next(), true
+	})
+}
+
+// Parse the flags which are common to all benchmarks.
+func benchOptionsFromFlags(ctx *cli.Context) (benchOptions, error) {
+	opts := benchOptions{
+		duration:         ctx.Duration("duration"),
+		concurrency:      ctx.Int("concurrency"),
+		preload:          ctx.Int("preload"),
+		findMissingBatch: ctx.Int("find_missing_batch"),
+		seed:             ctx.Int64("seed"),
+	}
+
+	if ctx.String("url") == "" {
+		return opts, cli.Exit("The 'url' flag must be set", 1)
+	}
+	if opts.duration <= 0 {
+		return opts, cli.Exit("The 'duration' flag must be positive", 1)
+	}
+	if ctx.String("trace") != "" && !ctx.IsSet("duration") {
+		opts.duration = 0
+	}
+	if opts.concurrency <= 0 {
+		return opts, cli.Exit("The 'concurrency' flag must be positive", 1)
+	}
+	if opts.preload < 0 {
+		return opts, cli.Exit("The 'preload' flag must not be negative", 1)
+	}
+	if opts.findMissingBatch <= 0 {
+		return opts, cli.Exit("The 'find_missing_batch' flag must be positive", 1)
+	}
+
+	return opts, nil
+}
+
+// Connect to the instance, write the preload blobs, send the requests
+// from next and print the results.
+func runBench(ctx *cli.Context, opts benchOptions, preload []benchOp, what string, next func() (benchOp, bool)) error {
+	client, err := newBenchClient(ctx.String("url"), ctx.String("tls_ca_file"), opts.concurrency)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	defer client.close()
+
+	r := newBenchRunner(client, opts)
+
+	if len(preload) > 0 {
+		fmt.Fprintf(ctx.App.Writer, "Writing %d blobs...\n", len(preload))
+		err = r.preload(ctx.Context, preload)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+	}
+
+	fmt.Fprintln(ctx.App.Writer, what)
+	elapsed := r.run(ctx.Context, next)
+	r.printResults(ctx.App.Writer, elapsed)
+	return nil
+}
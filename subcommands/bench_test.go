@@ -0,0 +1,139 @@
+package subcommands
+
+import (
+	"bytes"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	"github.com/buchgr/bazel-remote/v2/server"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestParseMix(t *testing.T) {
+	mix, err := parseMix("read=70, write=30,findmissing=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []weighted[benchOpKind]{{opRead, 70}, {opWrite, 30}}
+	if !reflect.DeepEqual(mix, expected) {
+		t.Errorf("Expected %v, got %v", expected, mix)
+	}
+
+	for _, invalid := range []string{"", "read", "read=x", "read=-1", "delete=1", "read=0"} {
+		_, err := parseMix(invalid)
+		if err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestParseBlobSizes(t *testing.T) {
+	sizes, err := parseBlobSizes("100=1,4KiB=2,1.5MiB=3,1GiB=4,0B=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []weighted[int64]{{100, 1}, {4096, 2}, {1536 * 1024, 3}, {1 << 30, 4}, {0, 5}}
+	if !reflect.DeepEqual(sizes, expected) {
+		t.Errorf("Expected %v, got %v", expected, sizes)
+	}
+
+	for _, invalid := range []string{"4KB=1", "-1=1", "KiB=1"} {
+		_, err := parseBlobSizes(invalid)
+		if err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestSyntheticWorkloadIsReproducible(t *testing.T) {
+	newWorkload := func() *syntheticWorkload {
+		return &syntheticWorkload{
+			rng:      rand.New(rand.NewSource(42)),
+			mix:      []weighted[benchOpKind]{{opRead, 1}, {opWrite, 1}, {opFindMissing, 1}},
+			sizes:    []weighted[int64]{{10, 1}, {1000, 1}},
+			interval: 10 * time.Millisecond,
+		}
+	}
+
+	w1, w2 := newWorkload(), newWorkload()
+	for i := 0; i < 100; i++ {
+		op1, op2 := w1.next(), w2.next()
+		if op1 != op2 {
+			t.Fatalf("Expected the same requests for the same seed, got %+v and %+v", op1, op2)
+		}
+		if op1.at != time.Duration(i)*10*time.Millisecond {
+			t.Fatalf("Unexpected start time of request %d: %v", i, op1.at)
+		}
+	}
+
+	if !bytes.Equal(blobData(1, 5, 100), blobData(1, 5, 100)) {
+		t.Error("Expected the same blob data for the same seed and ID")
+	}
+	if bytes.Equal(blobData(1, 5, 100), blobData(1, 6, 100)) {
+		t.Error("Expected different blob data for different IDs")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	for p, expected := range map[float64]time.Duration{
+		50:   50 * time.Millisecond,
+		99:   99 * time.Millisecond,
+		99.9: 100 * time.Millisecond,
+		0:    time.Millisecond,
+	} {
+		if actual := percentile(latencies, p); actual != expected {
+			t.Errorf("Expected p%v to be %v, got %v", p, expected, actual)
+		}
+	}
+}
+
+func TestBenchHTTP(t *testing.T) {
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	c, err := disk.New(dir, 10*1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := server.NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(),
+		true, false, nil, false, false, nil, validate.SymlinksAllow, false, server.PeerConfig{}, "")
+	ts := httptest.NewServer(http.HandlerFunc(h.CacheHandler))
+	defer ts.Close()
+
+	output := new(bytes.Buffer)
+	app := &cli.App{
+		Name:     "bazel-remote",
+		Writer:   output,
+		Commands: Commands(),
+	}
+
+	err = app.Run([]string{"bazel-remote", "bench", "--url", ts.URL,
+		"--duration", "200ms", "--concurrency", "2", "--preload", "5",
+		"--blob_sizes", "1KiB=1,8KiB=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"Writing 5 blobs...", ", 0 failed.", "read", "write", "findmissing"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected the output to contain %q, got %q", expected, output.String())
+		}
+	}
+	if strings.Contains(output.String(), "error:") {
+		t.Errorf("Unexpected errors in the output: %q", output.String())
+	}
+}
@@ -0,0 +1,273 @@
+package subcommands
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/bytestream"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+)
+
+// The requests which a benchmark sends, over HTTP or gRPC.
+type benchClient interface {
+	// Read a CAS blob, and return false if it was not found.
+	read(ctx context.Context, d blobDigest) (bool, error)
+
+	// Write a CAS blob.
+	write(ctx context.Context, d blobDigest, data []byte) error
+
+	// Check which of the CAS blobs exist.
+	findMissing(ctx context.Context, digests []blobDigest) error
+
+	close()
+}
+
+// Returns a client for the bazel-remote instance at rawURL, with at most
+// concurrency connections.
+func newBenchClient(rawURL string, caFile string, concurrency int) (benchClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || u.RawQuery != "" {
+		return nil, fmt.Errorf("Invalid URL: %q, expected http(s)://host:port or grpc(s)://host:port, optionally with an instance name as its path", rawURL)
+	}
+
+	var tlsConfig *tls.Config
+	if u.Scheme == "https" || u.Scheme == "grpcs" {
+		tlsConfig = &tls.Config{}
+		if caFile != "" {
+			caCert, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("Error reading the TLS CA file: %w", err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+				return nil, fmt.Errorf("Failed to add the TLS CA certificate to the cert pool")
+			}
+		}
+	} else if caFile != "" {
+		return nil, fmt.Errorf("The 'tls_ca_file' flag can only be used with an https:// or grpcs:// URL")
+	}
+
+	instance := strings.Trim(u.Path, "/")
+
+	switch u.Scheme {
+	case "http", "https":
+		c := &httpBenchClient{
+			client: &http.Client{Transport: &http.Transport{
+				TLSClientConfig:     tlsConfig,
+				MaxIdleConnsPerHost: concurrency,
+			}},
+			baseURL: u.Scheme + "://" + u.Host + "/",
+		}
+		if instance != "" {
+			c.baseURL += instance + "/"
+		}
+		if u.User != nil {
+			c.username = u.User.Username()
+			c.password, _ = u.User.Password()
+		}
+		return c, nil
+
+	case "grpc", "grpcs":
+		creds := insecure.NewCredentials()
+		if tlsConfig != nil {
+			creds = credentials.NewTLS(tlsConfig)
+		}
+		opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+		if u.User != nil {
+			// Like bazel, send the credentials in the authority.
+			password, _ := u.User.Password()
+			authority := fmt.Sprintf("%s:%s@%s", u.User.Username(), password, u.Host)
+			opts = append(opts, grpc.WithAuthority(authority))
+		}
+		conn, err := grpc.Dial(u.Host, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to connect to %q: %w", u.Host, err)
+		}
+		return &grpcBenchClient{
+			conn:     conn,
+			bs:       bytestream.NewByteStreamClient(conn),
+			cas:      pb.NewContentAddressableStorageClient(conn),
+			instance: instance,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("Invalid URL: %q, expected http(s)://host:port or grpc(s)://host:port", rawURL)
+}
+
+type httpBenchClient struct {
+	client   *http.Client
+	baseURL  string
+	username string
+	password string
+}
+
+func (c *httpBenchClient) do(ctx context.Context, method string, d blobDigest, body []byte) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"cas/"+d.hash, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = int64(len(body))
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return c.client.Do(req)
+}
+
+// Returns the response to a request for d, and whether the blob exists.
+func (c *httpBenchClient) get(ctx context.Context, method string, d blobDigest) (bool, error) {
+	resp, err := c.do(ctx, method, d, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		_, err = io.Copy(io.Discard, resp.Body)
+		return true, err
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("%s %s: %s", method, d.hash, resp.Status)
+}
+
+func (c *httpBenchClient) read(ctx context.Context, d blobDigest) (bool, error) {
+	return c.get(ctx, http.MethodGet, d)
+}
+
+func (c *httpBenchClient) write(ctx context.Context, d blobDigest, data []byte) error {
+	resp, err := c.do(ctx, http.MethodPut, d, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("PUT %s: %s", d.hash, resp.Status)
+	}
+	return nil
+}
+
+// The HTTP API has no batch request to check which blobs exist, so this
+// only checks the first one.
+func (c *httpBenchClient) findMissing(ctx context.Context, digests []blobDigest) error {
+	_, err := c.get(ctx, http.MethodHead, digests[0])
+	return err
+}
+
+func (c *httpBenchClient) close() {
+	c.client.CloseIdleConnections()
+}
+
+type grpcBenchClient struct {
+	conn     *grpc.ClientConn
+	bs       bytestream.ByteStreamClient
+	cas      pb.ContentAddressableStorageClient
+	instance string
+}
+
+func (c *grpcBenchClient) resourceName(parts ...string) string {
+	name := strings.Join(parts, "/")
+	if c.instance != "" {
+		name = c.instance + "/" + name
+	}
+	return name
+}
+
+func (c *grpcBenchClient) read(ctx context.Context, d blobDigest) (bool, error) {
+	stream, err := c.bs.Read(ctx, &bytestream.ReadRequest{
+		ResourceName: c.resourceName("blobs", d.hash, fmt.Sprint(d.size)),
+	})
+	if err != nil {
+		return false, err
+	}
+	for {
+		_, err = stream.Recv()
+		if err == io.EOF {
+			return true, nil
+		}
+		if status.Code(err) == codes.NotFound {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+// The size of the chunks which blobs are written in.
+const benchWriteChunkSize = 1024 * 1024
+
+func (c *grpcBenchClient) write(ctx context.Context, d blobDigest, data []byte) error {
+	stream, err := c.bs.Write(ctx)
+	if err != nil {
+		return err
+	}
+
+	name := c.resourceName("uploads", uuid.New().String(), "blobs", d.hash, fmt.Sprint(d.size))
+	offset := 0
+	for {
+		end := offset + benchWriteChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		req := &bytestream.WriteRequest{
+			WriteOffset: int64(offset),
+			Data:        data[offset:end],
+			FinishWrite: end == len(data),
+		}
+		if offset == 0 {
+			req.ResourceName = name
+		}
+		err = stream.Send(req)
+		if err == io.EOF {
+			// The server closed the stream, eg because the blob
+			// already exists. CloseAndRecv returns the result.
+			break
+		}
+		if err != nil {
+			return err
+		}
+		offset = end
+		if offset == len(data) {
+			break
+		}
+	}
+
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+func (c *grpcBenchClient) findMissing(ctx context.Context, digests []blobDigest) error {
+	req := &pb.FindMissingBlobsRequest{InstanceName: c.instance}
+	for _, d := range digests {
+		req.BlobDigests = append(req.BlobDigests, &pb.Digest{Hash: d.hash, SizeBytes: d.size})
+	}
+	_, err := c.cas.FindMissingBlobs(ctx, req)
+	return err
+}
+
+func (c *grpcBenchClient) close() {
+	c.conn.Close()
+}
@@ -0,0 +1,146 @@
+package subcommands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache/eventstream"
+
+	"github.com/urfave/cli/v2"
+)
+
+// The requests of a recorded trace, to replay with the bench subcommand.
+type benchTrace struct {
+	ops []benchOp
+
+	// The blobs which were read before they were written in the trace,
+	// which must be written before the trace is replayed.
+	preload []benchOp
+
+	// The number of records which were skipped, because they are not
+	// reads or writes.
+	skipped int
+}
+
+// Load a trace from event stream records, one per line. Each entry in
+// the trace is replaced by a random CAS blob of the same size, so
+// anonymized traces can be replayed too. Lines which are not records are
+// ignored. Requests are replayed speed times faster than they were
+// recorded, or as fast as possible if speed is zero.
+func loadTrace(r io.Reader, speed float64) (*benchTrace, error) {
+	t := &benchTrace{}
+
+	type blob struct {
+		id      uint64
+		size    int64
+		written bool
+	}
+	blobs := make(map[string]*blob)
+	var first time.Time
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var rec eventstream.Record
+		if json.Unmarshal([]byte(line), &rec) != nil || rec.Hash == "" {
+			continue
+		}
+		if rec.Op != eventstream.OpRead && rec.Op != eventstream.OpWrite {
+			t.skipped++
+			continue
+		}
+
+		key := rec.Kind + "/" + rec.Hash
+		b := blobs[key]
+		if b == nil {
+			b = &blob{id: uint64(len(blobs))}
+			blobs[key] = b
+		}
+		miss := rec.Op == eventstream.OpRead && rec.Result != "hit"
+		if !b.written && !miss {
+			// The size of a miss is unknown, the blob has the size of
+			// its first write or hit.
+			b.size = rec.Size
+		}
+
+		if first.IsZero() {
+			first = rec.Time
+		}
+		op := benchOp{id: b.id, size: b.size}
+		if speed > 0 && rec.Time.After(first) {
+			op.at = time.Duration(float64(rec.Time.Sub(first)) / speed)
+		}
+
+		switch {
+		case rec.Op == eventstream.OpWrite:
+			op.kind = opWrite
+			b.written = true
+		case !miss:
+			op.kind = opRead
+			op.target = true
+			if !b.written {
+				t.preload = append(t.preload, benchOp{id: b.id, size: b.size})
+				b.written = true
+			}
+		default:
+			op.kind = opRead
+			op.size = 0
+			op.missing = true
+		}
+		t.ops = append(t.ops, op)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Replay the trace given by the --trace flag.
+func replay(ctx *cli.Context, opts benchOptions) error {
+	speed := ctx.Float64("speed")
+	if speed < 0 || math.IsInf(speed, 0) || math.IsNaN(speed) {
+		return cli.Exit("The 'speed' flag must not be negative", 1)
+	}
+	opts.paced = speed > 0
+
+	f, err := os.Open(ctx.String("trace"))
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	t, err := loadTrace(f, speed)
+	f.Close()
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to read the trace: %v", err), 1)
+	}
+	if len(t.ops) == 0 {
+		return cli.Exit("The trace has no reads or writes to replay", 1)
+	}
+
+	what := fmt.Sprintf("Replaying %d requests", len(t.ops))
+	if t.skipped > 0 {
+		what += fmt.Sprintf(" (skipped %d evictions)", t.skipped)
+	}
+	if opts.duration > 0 {
+		what += fmt.Sprintf(" for at most %s", opts.duration)
+	}
+
+	i := 0
+	return runBench(ctx, opts, t.preload, what+"...", func() (benchOp, bool) {
+		if i == len(t.ops) {
+			return benchOp{}, false
+		}
+		i++
+		return t.ops[i-1], true
+	})
+}
@@ -0,0 +1,109 @@
+package subcommands
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	"github.com/buchgr/bazel-remote/v2/server"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+
+	"github.com/urfave/cli/v2"
+)
+
+const testTrace = `{"op":"read","kind":"cas","hash":"aaaa","size":10,"result":"hit","time":"2024-05-01T12:00:00Z"}
+{"op":"read","kind":"ac","hash":"bbbb","size":0,"result":"miss","time":"2024-05-01T12:00:01Z"}
+not a record
+{"op":"write","kind":"ac","hash":"bbbb","size":20,"time":"2024-05-01T12:00:02Z"}
+{"op":"read","kind":"ac","hash":"bbbb","size":20,"result":"hit","time":"2024-05-01T12:00:03Z"}
+{"op":"evict","kind":"cas","hash":"aaaa","size":10,"time":"2024-05-01T12:00:04Z"}
+{"op":"read","kind":"cas","hash":"aaaa","size":10,"result":"hit","time":"2024-05-01T12:00:04Z"}
+`
+
+func TestLoadTrace(t *testing.T) {
+	trace, err := loadTrace(strings.NewReader(testTrace), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []benchOp{
+		{kind: opRead, id: 0, size: 10, target: true},
+		{kind: opRead, id: 1, at: 500 * time.Millisecond, missing: true},
+		{kind: opWrite, id: 1, size: 20, at: time.Second},
+		{kind: opRead, id: 1, size: 20, at: 1500 * time.Millisecond, target: true},
+		{kind: opRead, id: 0, size: 10, at: 2 * time.Second, target: true},
+	}
+	if len(trace.ops) != len(expected) {
+		t.Fatalf("Expected %d requests, got %+v", len(expected), trace.ops)
+	}
+	for i := range expected {
+		if trace.ops[i] != expected[i] {
+			t.Errorf("Expected request %d to be %+v, got %+v", i, expected[i], trace.ops[i])
+		}
+	}
+
+	// Only the blob which was read before it was written is preloaded.
+	if len(trace.preload) != 1 || trace.preload[0] != (benchOp{id: 0, size: 10}) {
+		t.Errorf("Unexpected preload blobs: %+v", trace.preload)
+	}
+	if trace.skipped != 1 {
+		t.Errorf("Expected 1 skipped record, got %d", trace.skipped)
+	}
+}
+
+func TestBenchReplay(t *testing.T) {
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	c, err := disk.New(filepath.Join(dir, "cache"), 10*1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := server.NewHTTPCache(c, testutils.NewSilentLogger(), testutils.NewSilentLogger(),
+		true, false, nil, false, false, nil, validate.SymlinksAllow, false, server.PeerConfig{}, "")
+	ts := httptest.NewServer(http.HandlerFunc(h.CacheHandler))
+	defer ts.Close()
+
+	tracePath := filepath.Join(dir, "trace.jsonl")
+	err = os.WriteFile(tracePath, []byte(testTrace), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := new(bytes.Buffer)
+	app := &cli.App{
+		Name:     "bazel-remote",
+		Writer:   output,
+		Commands: Commands(),
+	}
+
+	err = app.Run([]string{"bazel-remote", "bench", "--url", ts.URL,
+		"--trace", tracePath, "--speed", "0", "--concurrency", "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"Writing 1 blobs...",
+		"Replaying 5 requests (skipped 1 evictions)...",
+		"Sent 5 requests",
+		", 0 failed.",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected the output to contain %q, got %q", expected, output.String())
+		}
+	}
+
+	// The miss is the only read which doesn't find its blob.
+	if !regexp.MustCompile(`read +4 +0 +1 `).MatchString(output.String()) {
+		t.Errorf("Expected 4 reads with 1 miss, got %q", output.String())
+	}
+}
@@ -0,0 +1,42 @@
+package subcommands
+
+import (
+	"fmt"
+
+	"github.com/buchgr/bazel-remote/v2/config"
+
+	"github.com/urfave/cli/v2"
+)
+
+func checkConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "check-config",
+		Usage:     "Validate a configuration file, and print the effective configuration.",
+		UsageText: "bazel-remote check-config <file>",
+		Action:    checkConfig,
+	}
+}
+
+func checkConfig(ctx *cli.Context) error {
+	err := checkArgs(ctx, 1)
+	if err != nil {
+		return err
+	}
+
+	file := ctx.Args().Get(0)
+	c, err := config.NewFromYamlFileStrict(file)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Invalid config file %s: %v", file, err), 1)
+	}
+
+	data, err := c.Redacted().YAML()
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	// Start with a YAML comment, so the output can be used as a config file
+	// once any secrets are filled in.
+	fmt.Fprintf(ctx.App.Writer, "# Effective configuration for %s, including defaults, with secrets redacted:\n%s", file, data)
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package subcommands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestCheckConfig(t *testing.T) {
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "config.yml")
+	err := os.WriteFile(file, []byte("dir: /opt/cache-dir\nmax_size: 42\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := new(bytes.Buffer)
+	app := &cli.App{
+		Name:     "bazel-remote",
+		Writer:   output,
+		Commands: Commands(),
+	}
+
+	err = app.Run([]string{"bazel-remote", "check-config", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The defaults should be included.
+	for _, expected := range []string{"max_size: 42\n", "storage_mode: zstd\n"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected the output to contain %q, got %q", expected, output.String())
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package subcommands
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+)
+
+func decodeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "decode",
+		Usage:     "Show the header and chunk table of a compressed CAS blob file.",
+		UsageText: "bazel-remote decode <file>",
+		Action:    decode,
+	}
+}
+
+func decode(ctx *cli.Context) error {
+	err := checkArgs(ctx, 1)
+	if err != nil {
+		return err
+	}
+
+	name := ctx.Args().First()
+	info, err := readInfo(name)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to decode %q: %v", name, err), 1)
+	}
+
+	fileSize := info.ChunkOffsets[info.NumChunks()]
+
+	out := ctx.App.Writer
+	fmt.Fprintf(out, "Uncompressed size:  %d\n", info.UncompressedSize)
+	fmt.Fprintf(out, "File size:          %d\n", fileSize)
+	fmt.Fprintf(out, "Header size:        %d\n", info.HeaderSize)
+	fmt.Fprintf(out, "Compression:        %s\n", info.Compression)
+	fmt.Fprintf(out, "Chunk size:         %d\n", info.ChunkSize)
+	fmt.Fprintf(out, "Chunks:             %d\n", info.NumChunks())
+	if fileSize > 0 {
+		fmt.Fprintf(out, "Compression ratio:  %.2f\n",
+			float64(info.UncompressedSize)/float64(fileSize))
+	}
+	fmt.Fprintln(out)
+
+	w := tabwriter.NewWriter(out, 1, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "CHUNK\tOFFSET\tCOMPRESSED SIZE\t")
+	for i := 0; i < info.NumChunks(); i++ {
+		fmt.Fprintf(w, "%d\t%d\t%d\t\n", i, info.ChunkOffsets[i],
+			info.ChunkOffsets[i+1]-info.ChunkOffsets[i])
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,75 @@
+package subcommands
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+
+	"github.com/urfave/cli/v2"
+)
+
+type usage struct {
+	items       int64
+	incomplete  int64
+	logicalSize int64
+	sizeOnDisk  int64
+}
+
+func (u *usage) add(e disk.EntryInfo) {
+	u.items++
+	if e.Incomplete {
+		u.incomplete++
+	}
+	u.logicalSize += e.LogicalSize
+	u.sizeOnDisk += e.SizeOnDisk
+}
+
+func duCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "du",
+		Usage:     "Show the disk usage of each kind of entry in a cache directory.",
+		UsageText: "bazel-remote du <dir>",
+		Action:    du,
+	}
+}
+
+func du(ctx *cli.Context) error {
+	err := checkArgs(ctx, 1)
+	if err != nil {
+		return err
+	}
+
+	perKind := make(map[cache.EntryKind]*usage)
+	for _, kind := range []cache.EntryKind{cache.AC, cache.CAS, cache.RAW} {
+		perKind[kind] = &usage{}
+	}
+
+	err = disk.Walk(ctx.Args().First(), func(e disk.EntryInfo) error {
+		perKind[e.Kind].add(e)
+		return nil
+	})
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	w := tabwriter.NewWriter(ctx.App.Writer, 1, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "KIND\tITEMS\tINCOMPLETE\tLOGICAL BYTES\tBYTES ON DISK\t")
+
+	var total usage
+	for _, kind := range []cache.EntryKind{cache.AC, cache.CAS, cache.RAW} {
+		u := perKind[kind]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t\n", kind,
+			u.items, u.incomplete, u.logicalSize, u.sizeOnDisk)
+
+		total.items += u.items
+		total.incomplete += u.incomplete
+		total.logicalSize += u.logicalSize
+		total.sizeOnDisk += u.sizeOnDisk
+	}
+	fmt.Fprintf(w, "total\t%d\t%d\t%d\t%d\t\n",
+		total.items, total.incomplete, total.logicalSize, total.sizeOnDisk)
+
+	return w.Flush()
+}
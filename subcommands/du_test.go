@@ -0,0 +1,67 @@
+package subcommands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestDu(t *testing.T) {
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	c, err := disk.New(dir, 1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, hash := testutils.RandomDataAndHash(100)
+	err = c.Put(context.Background(), cache.RAW, hash, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := new(bytes.Buffer)
+	app := &cli.App{
+		Name:     "bazel-remote",
+		Writer:   output,
+		Commands: Commands(),
+	}
+
+	err = app.Run([]string{"bazel-remote", "du", dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 lines of output, got:\n%s", output.String())
+	}
+
+	expected := map[string]string{
+		"ac":    "0 0 0 0",
+		"cas":   "0 0 0 0",
+		"raw":   "1 0 100 100",
+		"total": "1 0 100 100",
+	}
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		want, ok := expected[fields[0]]
+		if !ok {
+			t.Errorf("Unexpected line: %q", line)
+			continue
+		}
+		got := strings.Join(fields[1:], " ")
+		if got != want {
+			t.Errorf("Expected %q for %s, got %q", want, fields[0], got)
+		}
+	}
+}
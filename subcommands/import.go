@@ -0,0 +1,197 @@
+package subcommands
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	"github.com/buchgr/bazel-remote/v2/server"
+	"github.com/buchgr/bazel-remote/v2/utils/bufpool"
+	"github.com/buchgr/bazel-remote/v2/utils/tempfile"
+
+	"github.com/urfave/cli/v2"
+)
+
+func importCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "import",
+		Usage:     "Merge a cache directory or snapshot tarball into a running cache.",
+		UsageText: "bazel-remote import --admin_address <address> <cache dir | snapshot tarball | ->",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "admin_address",
+				Usage:   "The admin API address of the bazel-remote instance to import into, eg localhost:9095 or unix:///path/to/socket. This flag is required.",
+				EnvVars: []string{"BAZEL_REMOTE_IMPORT_ADMIN_ADDRESS"},
+			},
+		},
+		Action: importEntries,
+	}
+}
+
+func importEntries(ctx *cli.Context) error {
+	err := checkArgs(ctx, 1)
+	if err != nil {
+		return err
+	}
+
+	adminAddress := ctx.String("admin_address")
+	if adminAddress == "" {
+		return cli.Exit("The 'admin_address' flag must be set", 1)
+	}
+
+	source, err := openImportSource(ctx.Args().Get(0))
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	defer source.Close()
+
+	sigCtx, stop := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, url := adminClient(adminAddress)
+	req, err := http.NewRequestWithContext(sigCtx, http.MethodPost, url+"/import", source)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	req.Header.Set("Content-Type", "application/x-tar")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Import failed: %v", err), 1)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return cli.Exit(fmt.Sprintf("Import failed: %s: %s", resp.Status, msg), 1)
+	}
+
+	var stats disk.ImportStats
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to parse the import result: %v", err), 1)
+	}
+
+	fmt.Fprintf(ctx.App.Writer, "Imported %d entries (%d bytes).\n", stats.Imported, stats.ImportedBytes)
+	fmt.Fprintf(ctx.App.Writer, "Skipped %d entries which were already in the cache, and %d which did not fit.\n",
+		stats.Duplicates, stats.NoSpace)
+	if stats.Failed > 0 {
+		return cli.Exit(fmt.Sprintf("Failed to import %d entries, see the server's log", stats.Failed), 1)
+	}
+
+	return nil
+}
+
+// Open a snapshot tarball, or - for stdin, or stream a snapshot of the
+// cache directory name.
+func openImportSource(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return os.Open(name)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		_ = pw.CloseWithError(writeDirSnapshot(pw, name))
+	}()
+
+	return pr, nil
+}
+
+// Write a tarball of the entries in the cache directory dir, in the
+// format of a snapshot. The cache directory should not be in use.
+func writeDirSnapshot(w io.Writer, dir string) error {
+	var entries []disk.EntryInfo
+	err := disk.Walk(dir, func(e disk.EntryInfo) error {
+		if !e.Incomplete {
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Like a snapshot, from the least to the most recently used.
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Atime.Before(entries[j].Atime)
+	})
+
+	m := manifest{
+		Started: time.Now().UTC(),
+		Entries: make([]manifestEntry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		m.Entries = append(m.Entries, newManifestEntry(e))
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:    server.SnapshotManifestName,
+		Mode:    tempfile.FinalMode,
+		Size:    int64(len(data)),
+		ModTime: m.Started,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		err = writeDirSnapshotEntry(tw, dir, e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+func writeDirSnapshotEntry(tw *tar.Writer, dir string, e disk.EntryInfo) error {
+	name, err := filepath.Rel(dir, e.Path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(e.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:    filepath.ToSlash(name),
+		Mode:    tempfile.FinalMode,
+		Size:    e.SizeOnDisk,
+		ModTime: e.Mtime,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = bufpool.Copy(tw, f)
+	return err
+}
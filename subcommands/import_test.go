@@ -0,0 +1,89 @@
+package subcommands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestImport(t *testing.T) {
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	admin := newSnapshotServer(t, filepath.Join(dir, "cache"))
+	defer admin.Close()
+
+	sourceDir := filepath.Join(dir, "source")
+	populateCache(t, sourceDir, cache.CAS, 2)
+	populateCache(t, sourceDir, cache.AC, 1)
+
+	run := func(source string) string {
+		output := new(bytes.Buffer)
+		app := &cli.App{
+			Name:     "bazel-remote",
+			Writer:   output,
+			Commands: Commands(),
+		}
+
+		err := app.Run([]string{"bazel-remote", "import",
+			"--admin_address", strings.TrimPrefix(admin.URL, "http://"), source})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output.String()
+	}
+
+	output := run(sourceDir)
+	if !strings.Contains(output, "Imported 3 entries (300 bytes).") {
+		t.Errorf("Unexpected output: %q", output)
+	}
+
+	// A snapshot tarball of the same entries only has duplicates.
+	tarball := filepath.Join(dir, "snapshot.tar")
+	f, err := os.Create(tarball)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writeDirSnapshot(f, sourceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output = run(tarball)
+	if !strings.Contains(output, "Imported 0 entries") ||
+		!strings.Contains(output, "Skipped 3 entries which were already in the cache, and 0 which did not fit.") {
+		t.Errorf("Unexpected output: %q", output)
+	}
+}
+
+func TestWriteDirSnapshot(t *testing.T) {
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	populateCache(t, dir, cache.RAW, 3)
+
+	// The tarball is in the same format as a snapshot.
+	buf := new(bytes.Buffer)
+	err := writeDirSnapshot(buf, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := saveSnapshot(buf, new(bytes.Buffer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.entries != 3 || stats.bytes != 300 {
+		t.Errorf("Expected 3 entries of 100 bytes, got %+v", stats)
+	}
+}
@@ -0,0 +1,141 @@
+package subcommands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+)
+
+// Backups are stored with the following layout, relative to the
+// object store prefix:
+//
+//	entries/<kind dir>/<hash[:2]>/<hash>-<random>   cache files, stored as-is
+//	manifests/<timestamp>.json                      completed backups
+//	manifests/latest.json                           a copy of the newest manifest
+//	manifests/in-progress.json                      checkpoint of an unfinished backup
+//
+// The random part of the cache filename is included in the object key, so
+// that an overwritten entry is uploaded to a new object instead of
+// replacing the one that older manifests refer to.
+const (
+	latestManifestKey     = "manifests/latest.json"
+	inProgressManifestKey = "manifests/in-progress.json"
+
+	manifestTimeFormat = "20060102T150405Z"
+)
+
+// Matches the random part of cache filenames, which is part of the object
+// keys.
+var randomRegex = regexp.MustCompile("^[0-9a-zA-Z]+$")
+
+// A manifest lists the cache entries that make up a backup.
+type manifest struct {
+	Started   time.Time       `json:"started"`
+	Completed *time.Time      `json:"completed,omitempty"`
+	Entries   []manifestEntry `json:"entries"`
+}
+
+type manifestEntry struct {
+	Kind        string `json:"kind"`
+	Hash        string `json:"hash"`
+	LogicalSize int64  `json:"logical_size"`
+	SizeOnDisk  int64  `json:"size_on_disk"`
+	Legacy      bool   `json:"legacy,omitempty"`
+
+	// The random part of the cache filename. This changes when an AC
+	// entry is overwritten, so we can tell when it needs to be uploaded
+	// again.
+	Random string `json:"random"`
+}
+
+func newManifestEntry(e disk.EntryInfo) manifestEntry {
+	return manifestEntry{
+		Kind:        e.Kind.String(),
+		Hash:        e.Hash,
+		LogicalSize: e.LogicalSize,
+		SizeOnDisk:  e.SizeOnDisk,
+		Legacy:      e.Legacy,
+		Random:      e.Random,
+	}
+}
+
+func (e *manifestEntry) entryKind() (cache.EntryKind, error) {
+	switch e.Kind {
+	case cache.AC.String():
+		return cache.AC, nil
+	case cache.CAS.String():
+		return cache.CAS, nil
+	case cache.RAW.String():
+		return cache.RAW, nil
+	}
+	return 0, fmt.Errorf("Unknown entry kind in manifest: %q", e.Kind)
+}
+
+func (e *manifestEntry) lookupKey() string {
+	return e.Kind + "/" + e.Hash
+}
+
+func (e *manifestEntry) objectKey() (string, error) {
+	kind, err := e.entryKind()
+	if err != nil {
+		return "", err
+	}
+	return path.Join("entries", kind.DirName(), e.Hash[:2], e.Hash+"-"+e.Random), nil
+}
+
+func (m *manifest) sort() {
+	sort.Slice(m.Entries, func(i, j int) bool {
+		return m.Entries[i].lookupKey() < m.Entries[j].lookupKey()
+	})
+}
+
+// Returns a nil manifest and no error if key does not exist.
+func loadManifest(ctx context.Context, store objectStore, key string) (*manifest, error) {
+	rc, err := store.get(ctx, key)
+	if err == errObjectNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	var m manifest
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse manifest %q: %w", key, err)
+	}
+
+	for _, e := range m.Entries {
+		if !validate.HashKeyRegex.MatchString(e.Hash) {
+			return nil, fmt.Errorf("Invalid hash in manifest %q: %q", key, e.Hash)
+		}
+		if !randomRegex.MatchString(e.Random) {
+			return nil, fmt.Errorf("Invalid random part in manifest %q: %q", key, e.Random)
+		}
+		if _, err = e.entryKind(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &m, nil
+}
+
+func putManifest(ctx context.Context, store objectStore, key string, data []byte) error {
+	return store.put(ctx, key, bytes.NewReader(data), int64(len(data)))
+}
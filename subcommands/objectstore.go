@@ -0,0 +1,173 @@
+package subcommands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/buchgr/bazel-remote/v2/cache/s3proxy"
+	"github.com/buchgr/bazel-remote/v2/config"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/urfave/cli/v2"
+)
+
+// objectStore is the minimal set of operations that backup and restore
+// need from the remote storage.
+type objectStore interface {
+	put(ctx context.Context, key string, r io.Reader, size int64) error
+
+	// Returns errObjectNotFound if the key does not exist.
+	get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	remove(ctx context.Context, key string) error
+}
+
+var errObjectNotFound = errors.New("object not found")
+
+type s3Store struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+func (s *s3Store) objectKey(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+	return path.Join(s.prefix, key)
+}
+
+func (s *s3Store) put(ctx context.Context, key string, r io.Reader, size int64) error {
+	_, err := s.client.PutObject(ctx, s.bucket, s.objectKey(key), r, size,
+		minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	return err
+}
+
+func (s *s3Store) get(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, s.objectKey(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// GetObject doesn't make a request until the object is read,
+	// so check that it exists first.
+	_, err = obj.Stat()
+	if err != nil {
+		obj.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, errObjectNotFound
+		}
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (s *s3Store) remove(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, s.objectKey(key), minio.RemoveObjectOptions{})
+}
+
+func s3StoreFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "s3.endpoint",
+			Usage:   "The S3/minio endpoint to store backups in.",
+			EnvVars: []string{"BAZEL_REMOTE_BACKUP_S3_ENDPOINT"},
+		},
+		&cli.StringFlag{
+			Name:    "s3.bucket",
+			Usage:   "The S3/minio bucket to store backups in. This flag is required.",
+			EnvVars: []string{"BAZEL_REMOTE_BACKUP_S3_BUCKET"},
+		},
+		&cli.StringFlag{
+			Name:    "s3.prefix",
+			Usage:   "The S3/minio object prefix to store backups under.",
+			EnvVars: []string{"BAZEL_REMOTE_BACKUP_S3_PREFIX"},
+		},
+		&cli.StringFlag{
+			Name:    "s3.auth_method",
+			Usage:   fmt.Sprintf("The S3/minio authentication method. Allowed values: %s.", strings.Join(s3proxy.GetAuthMethods(), ", ")),
+			EnvVars: []string{"BAZEL_REMOTE_BACKUP_S3_AUTH_METHOD"},
+		},
+		&cli.StringFlag{
+			Name:    "s3.access_key_id",
+			Usage:   "The S3/minio access key.",
+			EnvVars: []string{"BAZEL_REMOTE_BACKUP_S3_ACCESS_KEY_ID"},
+		},
+		&cli.StringFlag{
+			Name:    "s3.secret_access_key",
+			Usage:   "The S3/minio secret access key.",
+			EnvVars: []string{"BAZEL_REMOTE_BACKUP_S3_SECRET_ACCESS_KEY"},
+		},
+		&cli.StringFlag{
+			Name:    "s3.aws_shared_credentials_file",
+			Usage:   "Path to the AWS credentials file.",
+			EnvVars: []string{"BAZEL_REMOTE_BACKUP_S3_AWS_SHARED_CREDENTIALS_FILE", "AWS_SHARED_CREDENTIALS_FILE"},
+		},
+		&cli.StringFlag{
+			Name:    "s3.aws_profile",
+			Value:   "default",
+			Usage:   "The aws credentials profile to use from within s3.aws_shared_credentials_file.",
+			EnvVars: []string{"BAZEL_REMOTE_BACKUP_S3_AWS_PROFILE", "AWS_PROFILE"},
+		},
+		&cli.BoolFlag{
+			Name:    "s3.disable_ssl",
+			Usage:   "Whether to disable TLS/SSL.",
+			EnvVars: []string{"BAZEL_REMOTE_BACKUP_S3_DISABLE_SSL"},
+		},
+		&cli.StringFlag{
+			Name:    "s3.iam_role_endpoint",
+			Usage:   "Endpoint for using IAM security credentials.",
+			EnvVars: []string{"BAZEL_REMOTE_BACKUP_S3_IAM_ROLE_ENDPOINT"},
+		},
+		&cli.StringFlag{
+			Name:    "s3.region",
+			Usage:   "The AWS region.",
+			EnvVars: []string{"BAZEL_REMOTE_BACKUP_S3_REGION"},
+		},
+	}
+}
+
+func newS3Store(ctx *cli.Context) (objectStore, error) {
+	s3c := config.S3CloudStorageConfig{
+		Endpoint:                 ctx.String("s3.endpoint"),
+		Bucket:                   ctx.String("s3.bucket"),
+		Prefix:                   ctx.String("s3.prefix"),
+		AuthMethod:               ctx.String("s3.auth_method"),
+		AccessKeyID:              ctx.String("s3.access_key_id"),
+		SecretAccessKey:          ctx.String("s3.secret_access_key"),
+		DisableSSL:               ctx.Bool("s3.disable_ssl"),
+		IAMRoleEndpoint:          ctx.String("s3.iam_role_endpoint"),
+		Region:                   ctx.String("s3.region"),
+		AWSProfile:               ctx.String("s3.aws_profile"),
+		AWSSharedCredentialsFile: ctx.String("s3.aws_shared_credentials_file"),
+	}
+
+	if s3c.Bucket == "" {
+		return nil, errors.New("The 's3.bucket' flag must be set")
+	}
+
+	creds, err := s3c.GetCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := minio.New(s3c.Endpoint, &minio.Options{
+		Creds:  creds,
+		Region: s3c.Region,
+		Secure: !s3c.DisableSSL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3Store{
+		client: client,
+		bucket: s3c.Bucket,
+		prefix: s3c.Prefix,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package subcommands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+
+	"github.com/urfave/cli/v2"
+)
+
+func purgeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "purge",
+		Usage:     "Remove all entries last accessed before a given time from a running cache.",
+		UsageText: "bazel-remote purge --admin_address <address> --older_than <time> [--kind <kind>...]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "admin_address",
+				Usage:   "The admin API address of the bazel-remote instance to purge, eg localhost:9095 or unix:///path/to/socket. This flag is required.",
+				EnvVars: []string{"BAZEL_REMOTE_PURGE_ADMIN_ADDRESS"},
+			},
+			&cli.StringFlag{
+				Name:    "older_than",
+				Usage:   "Remove the entries last accessed before this RFC 3339 timestamp or YYYY-MM-DD date (UTC). This flag is required.",
+				EnvVars: []string{"BAZEL_REMOTE_PURGE_OLDER_THAN"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "kind",
+				Usage:   "Only remove entries of this kind: ac, cas or raw. This flag can be given more than once. By default entries of all kinds are removed.",
+				EnvVars: []string{"BAZEL_REMOTE_PURGE_KIND"},
+			},
+		},
+		Action: purge,
+	}
+}
+
+func purge(ctx *cli.Context) error {
+	err := checkArgs(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	adminAddress := ctx.String("admin_address")
+	if adminAddress == "" {
+		return cli.Exit("The 'admin_address' flag must be set", 1)
+	}
+	olderThan := ctx.String("older_than")
+	if olderThan == "" {
+		return cli.Exit("The 'older_than' flag must be set", 1)
+	}
+
+	query := url.Values{"older_than": {olderThan}}
+	for _, kind := range ctx.StringSlice("kind") {
+		query.Add("kind", kind)
+	}
+
+	// The purge continues on the server if this is interrupted.
+	client, baseURL := adminClient(adminAddress)
+	resp, err := client.Post(baseURL+"/purge?"+query.Encode(), "", nil)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Purge failed: %v", err), 1)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return cli.Exit(fmt.Sprintf("Purge failed: %s: %s", resp.Status, msg), 1)
+	}
+
+	stats, err := readPurgeProgress(resp.Body, ctx.App.Writer)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to read the purge progress: %v", err), 1)
+	}
+
+	fmt.Fprintf(ctx.App.Writer, "Purged %d entries (%d bytes).\n", stats.Entries, stats.Bytes)
+
+	return nil
+}
+
+// Print the progress reports from the admin API to w, and return the
+// final result.
+func readPurgeProgress(r io.Reader, w io.Writer) (disk.PurgeStats, error) {
+	var stats disk.PurgeStats
+	reports := 0
+	dec := json.NewDecoder(r)
+	for {
+		var s disk.PurgeStats
+		err := dec.Decode(&s)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return stats, err
+		}
+
+		stats = s
+		reports++
+		if stats.Remaining > 0 {
+			fmt.Fprintf(w, "Purged %d entries (%d bytes), %d left to check...\n",
+				stats.Entries, stats.Bytes, stats.Remaining)
+		}
+	}
+
+	if reports == 0 || stats.Remaining > 0 {
+		return stats, io.ErrUnexpectedEOF
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,63 @@
+package subcommands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestPurge(t *testing.T) {
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	admin := newSnapshotServer(t, filepath.Join(dir, "cache"))
+	defer admin.Close()
+
+	output := new(bytes.Buffer)
+	app := &cli.App{
+		Name:     "bazel-remote",
+		Writer:   output,
+		Commands: Commands(),
+	}
+
+	tomorrow := time.Now().Add(24 * time.Hour).Format("2006-01-02")
+	err := app.Run([]string{"bazel-remote", "purge",
+		"--admin_address", admin.URL, "--older_than", tomorrow, "--kind", "ac", "--kind", "raw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output.String(), "Purged 4 entries (400 bytes).") {
+		t.Errorf("Unexpected output: %q", output.String())
+	}
+}
+
+func TestReadPurgeProgress(t *testing.T) {
+	progress := `{"entries":10000,"bytes":40960000,"remaining":5}
+{"entries":10005,"bytes":40980480,"remaining":0}
+`
+	output := new(bytes.Buffer)
+	stats, err := readPurgeProgress(strings.NewReader(progress), output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 10005 || stats.Bytes != 40980480 {
+		t.Errorf("Unexpected final result: %+v", stats)
+	}
+	if output.String() != "Purged 10000 entries (40960000 bytes), 5 left to check...\n" {
+		t.Errorf("Unexpected output: %q", output.String())
+	}
+
+	// The server stopped before the purge finished.
+	firstLine := progress[:strings.Index(progress, "\n")+1]
+	_, err = readPurgeProgress(strings.NewReader(firstLine), new(bytes.Buffer))
+	if err == nil {
+		t.Error("Expected an error for incomplete progress")
+	}
+}
@@ -0,0 +1,221 @@
+package subcommands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	"github.com/buchgr/bazel-remote/v2/utils/bufpool"
+	"github.com/buchgr/bazel-remote/v2/utils/tempfile"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+func restoreCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "restore",
+		Usage:     "Restore cache entries from a backup in S3.",
+		UsageText: "bazel-remote restore --dir <dir> --s3.bucket <bucket> [options]",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "dir",
+				Usage:   "The cache directory to restore into. This flag is required.",
+				EnvVars: []string{"BAZEL_REMOTE_DIR"},
+			},
+			&cli.StringFlag{
+				Name:        "manifest",
+				Usage:       "The name of the backup manifest to restore, eg \"20221016T030000Z.json\".",
+				DefaultText: "the most recent backup",
+				EnvVars:     []string{"BAZEL_REMOTE_RESTORE_MANIFEST"},
+			},
+			&cli.IntFlag{
+				Name:    "concurrency",
+				Value:   16,
+				Usage:   "The number of entries to download in parallel.",
+				EnvVars: []string{"BAZEL_REMOTE_RESTORE_CONCURRENCY"},
+			},
+		}, s3StoreFlags()...),
+		Action: restore,
+	}
+}
+
+type restoreOptions struct {
+	dir         string
+	manifest    string
+	concurrency int
+}
+
+type restoreStats struct {
+	restored int
+	bytes    int64
+	skipped  int
+}
+
+func restore(ctx *cli.Context) error {
+	err := checkArgs(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	opts := restoreOptions{
+		dir:         ctx.String("dir"),
+		manifest:    ctx.String("manifest"),
+		concurrency: ctx.Int("concurrency"),
+	}
+	if opts.dir == "" {
+		return cli.Exit("The 'dir' flag must be set", 1)
+	}
+	if opts.concurrency < 1 {
+		return cli.Exit("The 'concurrency' flag must be set to a value > 0", 1)
+	}
+
+	store, err := newS3Store(ctx)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	sigCtx, stop := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	stats, err := runRestore(sigCtx, store, opts)
+	fmt.Fprintf(ctx.App.Writer, "Restored %d entries (%d bytes), %d already present.\n",
+		stats.restored, stats.bytes, stats.skipped)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Restore failed, re-run to resume: %v", err), 1)
+	}
+
+	return nil
+}
+
+func runRestore(ctx context.Context, store objectStore, opts restoreOptions) (restoreStats, error) {
+	var stats restoreStats
+
+	key := latestManifestKey
+	if opts.manifest != "" {
+		key = path.Join("manifests", opts.manifest)
+	}
+
+	m, err := loadManifest(ctx, store, key)
+	if err != nil {
+		return stats, err
+	}
+	if m == nil {
+		return stats, fmt.Errorf("Backup manifest not found: %s", key)
+	}
+
+	type pendingRestore struct {
+		entry manifestEntry
+		kind  cache.EntryKind
+	}
+	var todo []pendingRestore
+
+	for _, e := range m.Entries {
+		kind, err := e.entryKind()
+		if err != nil {
+			return stats, err
+		}
+
+		// Skip entries which already exist, eg from a previous
+		// interrupted restore.
+		existing, err := disk.FindEntries(opts.dir, e.Hash)
+		if err != nil {
+			return stats, err
+		}
+		present := false
+		for _, x := range existing {
+			if x.Kind == kind && !x.Incomplete {
+				present = true
+				break
+			}
+		}
+		if present {
+			stats.skipped++
+			continue
+		}
+
+		todo = append(todo, pendingRestore{entry: e, kind: kind})
+	}
+
+	tfc := tempfile.NewCreator()
+
+	var mu sync.Mutex // Protects stats.
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(opts.concurrency)
+
+	for _, p := range todo {
+		p := p
+		g.Go(func() error {
+			err := restoreEntry(gctx, store, tfc, opts.dir, p.kind, p.entry)
+			if err != nil {
+				return fmt.Errorf("Failed to restore %s: %w", p.entry.lookupKey(), err)
+			}
+
+			mu.Lock()
+			stats.restored++
+			stats.bytes += p.entry.SizeOnDisk
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	err = g.Wait()
+	return stats, err
+}
+
+func restoreEntry(ctx context.Context, store objectStore, tfc *tempfile.Creator, dir string, kind cache.EntryKind, e manifestEntry) error {
+	key, err := e.objectKey()
+	if err != nil {
+		return err
+	}
+
+	shardDir := filepath.Join(dir, kind.DirName(), e.Hash[:2])
+	err = os.MkdirAll(shardDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	// This must match diskCache.FileLocationBase.
+	base := filepath.Join(shardDir, e.Hash)
+	if kind == cache.CAS && !e.Legacy {
+		base += "-" + strconv.FormatInt(e.LogicalSize, 10)
+	}
+
+	rc, err := store.get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	f, _, err := tfc.CreateTemp(base, e.Legacy)
+	if err != nil {
+		return err
+	}
+
+	n, err := bufpool.Copy(f, rc)
+	if err == nil && n != e.SizeOnDisk {
+		err = fmt.Errorf("expected %d bytes, downloaded %d", e.SizeOnDisk, n)
+	}
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), tempfile.FinalName(f.Name()))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return nil
+}
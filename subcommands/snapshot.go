@@ -0,0 +1,281 @@
+package subcommands
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/server"
+	"github.com/buchgr/bazel-remote/v2/utils/validate"
+
+	"github.com/urfave/cli/v2"
+)
+
+func snapshotCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "snapshot",
+		Usage:     "Export a consistent snapshot of a running cache to a tarball or S3.",
+		UsageText: "bazel-remote snapshot --admin_address <address> (--output <file> | --s3.bucket <bucket> [options])",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "admin_address",
+				Usage:   "The admin API address of the bazel-remote instance to export, eg localhost:9095 or unix:///path/to/socket. This flag is required.",
+				EnvVars: []string{"BAZEL_REMOTE_SNAPSHOT_ADMIN_ADDRESS"},
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Usage:   "Write the snapshot to this tarball, or - for stdout, instead of uploading it to S3.",
+				EnvVars: []string{"BAZEL_REMOTE_SNAPSHOT_OUTPUT"},
+			},
+		}, s3StoreFlags()...),
+		Action: snapshot,
+	}
+}
+
+type snapshotStats struct {
+	entries  int
+	bytes    int64
+	manifest string // The manifest written to the object store, if any.
+}
+
+func snapshot(ctx *cli.Context) error {
+	err := checkArgs(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	adminAddress := ctx.String("admin_address")
+	if adminAddress == "" {
+		return cli.Exit("The 'admin_address' flag must be set", 1)
+	}
+	output := ctx.String("output")
+
+	var store objectStore
+	if output == "" {
+		store, err = newS3Store(ctx)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+	}
+
+	sigCtx, stop := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, url := adminClient(adminAddress)
+	req, err := http.NewRequestWithContext(sigCtx, http.MethodGet, url+"/snapshot", nil)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return cli.Exit(fmt.Sprintf("Failed to take a snapshot: %s", resp.Status), 1)
+	}
+
+	var stats snapshotStats
+	switch output {
+	case "":
+		stats, err = uploadSnapshot(sigCtx, resp.Body, store)
+	case "-":
+		stats, err = saveSnapshot(resp.Body, os.Stdout)
+	default:
+		stats, err = saveSnapshotFile(resp.Body, output)
+	}
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Snapshot failed: %v", err), 1)
+	}
+
+	if output == "-" {
+		return nil
+	}
+	fmt.Fprintf(ctx.App.Writer, "Exported %d entries (%d bytes).\n", stats.entries, stats.bytes)
+	if stats.manifest != "" {
+		fmt.Fprintf(ctx.App.Writer, "Wrote manifest %s\n", stats.manifest)
+	}
+
+	return nil
+}
+
+// Returns an HTTP client and base URL for the admin API at address, which
+// is in the format of the admin_address setting.
+func adminClient(address string) (*http.Client, string) {
+	if strings.HasPrefix(address, "unix://") {
+		socket := address[len("unix://"):]
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &http.Client{Transport: transport}, "http://unix"
+	}
+
+	if strings.HasPrefix(address, "http://") || strings.HasPrefix(address, "https://") {
+		return http.DefaultClient, strings.TrimSuffix(address, "/")
+	}
+
+	return http.DefaultClient, "http://" + address
+}
+
+// Read the manifest at the start of a snapshot tarball.
+func readSnapshotManifest(tr *tar.Reader) (*manifest, error) {
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, err
+	}
+	if hdr.Name != server.SnapshotManifestName {
+		return nil, fmt.Errorf("Expected the snapshot to start with %s, found %q",
+			server.SnapshotManifestName, hdr.Name)
+	}
+
+	var m manifest
+	err = json.NewDecoder(tr).Decode(&m)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the snapshot manifest: %w", err)
+	}
+
+	for _, e := range m.Entries {
+		if !validate.HashKeyRegex.MatchString(e.Hash) {
+			return nil, fmt.Errorf("Invalid hash in the snapshot manifest: %q", e.Hash)
+		}
+		if _, err = e.entryKind(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &m, nil
+}
+
+// Copy a snapshot tarball from r to w, checking that it is complete.
+func saveSnapshot(r io.Reader, w io.Writer) (snapshotStats, error) {
+	var stats snapshotStats
+
+	tr := tar.NewReader(io.TeeReader(r, w))
+	m, err := readSnapshotManifest(tr)
+	if err != nil {
+		return stats, err
+	}
+
+	for range m.Entries {
+		hdr, err := tr.Next()
+		if err != nil {
+			return stats, err
+		}
+		stats.entries++
+		stats.bytes += hdr.Size
+	}
+
+	err = checkSnapshotEnd(tr, m)
+	if err != nil {
+		return stats, err
+	}
+
+	// Copy any padding after the end of the archive.
+	_, err = io.Copy(w, r)
+	return stats, err
+}
+
+// Check that there are no more files after the entries in m.
+func checkSnapshotEnd(tr *tar.Reader, m *manifest) error {
+	_, err := tr.Next()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("Expected %d entries in the snapshot, found more", len(m.Entries))
+}
+
+// Save a snapshot tarball to a file, which is only created once the
+// whole snapshot has been received.
+func saveSnapshotFile(r io.Reader, name string) (snapshotStats, error) {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return snapshotStats{}, err
+	}
+	defer os.Remove(f.Name())
+
+	stats, err := saveSnapshot(r, f)
+	if err != nil {
+		f.Close()
+		return stats, err
+	}
+	err = f.Close()
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, os.Rename(f.Name(), name)
+}
+
+// Upload the entries in a snapshot tarball to the object store, in the
+// format of a backup which can be restored with the restore subcommand.
+func uploadSnapshot(ctx context.Context, r io.Reader, store objectStore) (snapshotStats, error) {
+	var stats snapshotStats
+
+	tr := tar.NewReader(r)
+	m, err := readSnapshotManifest(tr)
+	if err != nil {
+		return stats, err
+	}
+
+	// The files are in the same order as the manifest entries.
+	for _, e := range m.Entries {
+		hdr, err := tr.Next()
+		if err != nil {
+			return stats, err
+		}
+		if !strings.Contains(hdr.Name, e.Hash) {
+			return stats, fmt.Errorf("Expected a file for %s in the snapshot, found %q",
+				e.lookupKey(), hdr.Name)
+		}
+
+		key, err := e.objectKey()
+		if err != nil {
+			return stats, err
+		}
+		err = store.put(ctx, key, tr, hdr.Size)
+		if err != nil {
+			return stats, fmt.Errorf("Failed to upload %s: %w", e.lookupKey(), err)
+		}
+
+		stats.entries++
+		stats.bytes += hdr.Size
+	}
+
+	err = checkSnapshotEnd(tr, m)
+	if err != nil {
+		return stats, err
+	}
+
+	completed := time.Now().UTC()
+	m.Completed = &completed
+	m.sort()
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.manifest = "manifests/" + m.Started.Format(manifestTimeFormat) + ".json"
+	err = putManifest(ctx, store, stats.manifest, data)
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, putManifest(ctx, store, latestManifestKey, data)
+}
@@ -0,0 +1,158 @@
+package subcommands
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	"github.com/buchgr/bazel-remote/v2/server"
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+	"github.com/buchgr/bazel-remote/v2/utils/maintenance"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Serve the admin API of a cache with some entries of each kind.
+func newSnapshotServer(t *testing.T, dir string) *httptest.Server {
+	c, err := disk.New(dir, 10*1024*1024, disk.WithAccessLogger(testutils.NewSilentLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for kind, n := range map[cache.EntryKind]int{cache.AC: 3, cache.CAS: 2, cache.RAW: 1} {
+		for i := 0; i < n; i++ {
+			data, hash := testutils.RandomDataAndHash(100)
+			err = c.Put(context.Background(), kind, hash, int64(len(data)), bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	return httptest.NewServer(server.NewAdminHandler(c, maintenance.NewWindow(nil, time.Hour),
+		nil, testutils.NewSilentLogger()))
+}
+
+func TestSnapshotToFile(t *testing.T) {
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	admin := newSnapshotServer(t, filepath.Join(dir, "cache"))
+	defer admin.Close()
+
+	output := new(bytes.Buffer)
+	app := &cli.App{
+		Name:     "bazel-remote",
+		Writer:   output,
+		Commands: Commands(),
+	}
+
+	tarball := filepath.Join(dir, "snapshot.tar")
+	err := app.Run([]string{"bazel-remote", "snapshot",
+		"--admin_address", strings.TrimPrefix(admin.URL, "http://"), "--output", tarball})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output.String(), "Exported 6 entries") {
+		t.Errorf("Unexpected output: %q", output.String())
+	}
+
+	// The tarball can be read again, eg to check it.
+	f, err := os.Open(tarball)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	stats, err := saveSnapshot(f, new(bytes.Buffer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.entries != 6 {
+		t.Errorf("Expected 6 entries in the tarball, got %+v", stats)
+	}
+}
+
+func TestSnapshotToObjectStore(t *testing.T) {
+	ctx := context.Background()
+
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	admin := newSnapshotServer(t, filepath.Join(dir, "cache"))
+	defer admin.Close()
+
+	client, url := adminClient(admin.URL)
+	resp, err := client.Get(url + "/snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	store := newMemStore()
+	stats, err := uploadSnapshot(ctx, resp.Body, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.entries != 6 || !strings.HasPrefix(stats.manifest, "manifests/") {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// The snapshot can be restored like a backup.
+	restoreDir := filepath.Join(dir, "restored")
+	rstats, err := runRestore(ctx, store, restoreOptions{dir: restoreDir, concurrency: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rstats.restored != 6 {
+		t.Errorf("Expected 6 restored entries, got %+v", rstats)
+	}
+
+	counts := countEntries(t, restoreDir)
+	if counts[cache.AC] != 3 || counts[cache.CAS] != 2 || counts[cache.RAW] != 1 {
+		t.Errorf("Unexpected restored entries: %v", counts)
+	}
+}
+
+func TestSnapshotTruncated(t *testing.T) {
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	admin := newSnapshotServer(t, filepath.Join(dir, "cache"))
+	defer admin.Close()
+
+	client, url := adminClient(admin.URL)
+	resp, err := client.Get(url + "/snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	full := new(bytes.Buffer)
+	_, err = full.ReadFrom(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	truncated := bytes.NewReader(full.Bytes()[:full.Len()/2])
+	_, err = saveSnapshot(truncated, new(bytes.Buffer))
+	if err == nil {
+		t.Error("Expected an error for a truncated snapshot")
+	}
+
+	store := newMemStore()
+	_, err = uploadSnapshot(context.Background(), bytes.NewReader(full.Bytes()[:full.Len()/2]), store)
+	if err == nil {
+		t.Error("Expected an error for a truncated snapshot")
+	}
+	if _, found := store.objects[latestManifestKey]; found {
+		t.Error("Expected no manifest to be written for a truncated snapshot")
+	}
+}
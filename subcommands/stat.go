@@ -0,0 +1,84 @@
+package subcommands
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/buchgr/bazel-remote/v2/cache"
+	"github.com/buchgr/bazel-remote/v2/cache/disk"
+	"github.com/buchgr/bazel-remote/v2/cache/disk/casblob"
+
+	"github.com/urfave/cli/v2"
+)
+
+func statCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "stat",
+		Usage:     "Show details of the cache entries with the given hash.",
+		UsageText: "bazel-remote stat <dir> <hash>",
+		Action:    stat,
+	}
+}
+
+func stat(ctx *cli.Context) error {
+	err := checkArgs(ctx, 2)
+	if err != nil {
+		return err
+	}
+
+	hash := ctx.Args().Get(1)
+	entries, err := disk.FindEntries(ctx.Args().Get(0), hash)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	if len(entries) == 0 {
+		return cli.Exit(fmt.Sprintf("No entries found for %s", hash), 1)
+	}
+
+	out := ctx.App.Writer
+	for i, e := range entries {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		fmt.Fprintf(out, "Path:          %s\n", e.Path)
+		fmt.Fprintf(out, "Kind:          %s\n", e.Kind)
+		fmt.Fprintf(out, "Logical size:  %d\n", e.LogicalSize)
+		fmt.Fprintf(out, "Size on disk:  %d\n", e.SizeOnDisk)
+		fmt.Fprintf(out, "Complete:      %t\n", !e.Incomplete)
+		fmt.Fprintf(out, "Access time:   %s\n", e.Atime.UTC().Format(time.RFC3339))
+		fmt.Fprintf(out, "Modify time:   %s\n", e.Mtime.UTC().Format(time.RFC3339))
+
+		if e.Kind != cache.CAS {
+			continue
+		}
+
+		if e.Legacy {
+			fmt.Fprintf(out, "Storage:       uncompressed\n")
+			continue
+		}
+
+		info, err := readInfo(e.Path)
+		if err != nil {
+			fmt.Fprintf(out, "Storage:       invalid casblob: %v\n", err)
+			continue
+		}
+
+		fmt.Fprintf(out, "Storage:       casblob, %s compression, %d chunk(s)\n",
+			info.Compression, info.NumChunks())
+	}
+
+	return nil
+}
+
+func readInfo(name string) (*casblob.Info, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return casblob.ReadInfo(f)
+}
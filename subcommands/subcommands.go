@@ -0,0 +1,35 @@
+// Package subcommands implements bazel-remote's offline maintenance
+// commands, which operate directly on a cache directory.
+package subcommands
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Commands returns the list of subcommands supported by bazel-remote.
+func Commands() []*cli.Command {
+	return []*cli.Command{
+		duCommand(),
+		statCommand(),
+		decodeCommand(),
+		backupCommand(),
+		restoreCommand(),
+		snapshotCommand(),
+		importCommand(),
+		purgeCommand(),
+		checkConfigCommand(),
+		analyzeCommand(),
+		benchCommand(),
+	}
+}
+
+// Check that exactly n positional arguments were given.
+func checkArgs(ctx *cli.Context, n int) error {
+	if ctx.NArg() != n {
+		return cli.Exit(fmt.Sprintf("Error: %s expects %d argument(s), found %d\n\nUSAGE: %s",
+			ctx.Command.Name, n, ctx.NArg(), ctx.Command.UsageText), 1)
+	}
+	return nil
+}
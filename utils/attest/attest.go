@@ -0,0 +1,143 @@
+// Package attest parses and verifies attestations, eg in-toto statements
+// such as SLSA provenance, in DSSE envelopes.
+//
+// See https://github.com/secure-systems-lab/dsse/blob/master/envelope.md
+// and https://github.com/in-toto/attestation/tree/main/spec.
+package attest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// InTotoPayloadType is the DSSE payload type of in-toto statements.
+const InTotoPayloadType = "application/vnd.in-toto+json"
+
+// Envelope is a DSSE envelope.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     []byte      `json:"payload"` // Base64 encoded in JSON.
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is a signature of a DSSE envelope's payload.
+type Signature struct {
+	KeyID string `json:"keyid,omitempty"`
+	Sig   []byte `json:"sig"` // Base64 encoded in JSON.
+}
+
+// The fields of an in-toto statement which say what it is about.
+type statement struct {
+	Type    string `json:"_type"`
+	Subject []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+}
+
+// ParseEnvelope parses a DSSE envelope, and checks that it has a payload
+// type, a payload and at least one signature. If the payload is an
+// in-toto statement, it must have a subject with the given SHA256 digest.
+func ParseEnvelope(data []byte, sha256Hash string) (*Envelope, error) {
+	var env Envelope
+	err := json.Unmarshal(data, &env)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid DSSE envelope: %w", err)
+	}
+
+	if env.PayloadType == "" || len(env.Payload) == 0 {
+		return nil, errors.New("Invalid DSSE envelope: missing payloadType or payload")
+	}
+	if len(env.Signatures) == 0 {
+		return nil, errors.New("Invalid DSSE envelope: no signatures")
+	}
+
+	if env.PayloadType != InTotoPayloadType {
+		return &env, nil
+	}
+
+	var s statement
+	err = json.Unmarshal(env.Payload, &s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid in-toto statement: %w", err)
+	}
+	for _, subject := range s.Subject {
+		if subject.Digest["sha256"] == sha256Hash {
+			return &env, nil
+		}
+	}
+
+	return nil, fmt.Errorf("The in-toto statement has no subject with sha256 digest %s", sha256Hash)
+}
+
+// PAE returns the DSSE pre-authentication encoding of the envelope's
+// payload, which is what its signatures sign.
+func (e *Envelope) PAE() []byte {
+	b := []byte("DSSEv1 ")
+	b = strconv.AppendInt(b, int64(len(e.PayloadType)), 10)
+	b = append(b, ' ')
+	b = append(b, e.PayloadType...)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, int64(len(e.Payload)), 10)
+	b = append(b, ' ')
+	return append(b, e.Payload...)
+}
+
+// ParsePublicKey parses a PEM encoded PKIX public key: ECDSA, Ed25519 or
+// RSA.
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("No PEM data found")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey, *rsa.PublicKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("Unsupported public key type: %T", key)
+}
+
+// Returns true if sig is a valid signature of message with key. ECDSA
+// and RSA (PKCS #1 v1.5) signatures are of the message's SHA256 digest.
+func verify(key crypto.PublicKey, message []byte, sig []byte) bool {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return ecdsa.VerifyASN1(k, digest[:], sig)
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, message, sig)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil
+	}
+	return false
+}
+
+// Verify returns nil if at least one of the envelope's signatures was
+// made by one of keys.
+func (e *Envelope) Verify(keys []crypto.PublicKey) error {
+	pae := e.PAE()
+	for _, sig := range e.Signatures {
+		for _, key := range keys {
+			if verify(key, pae, sig.Sig) {
+				return nil
+			}
+		}
+	}
+	return errors.New("None of the signatures was made by a trusted key")
+}
@@ -0,0 +1,147 @@
+package attest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func TestPAE(t *testing.T) {
+	// The example from the DSSE protocol specification.
+	e := Envelope{PayloadType: "http://example.com/HelloWorld", Payload: []byte("hello world")}
+	expected := "DSSEv1 29 http://example.com/HelloWorld 11 hello world"
+	if string(e.PAE()) != expected {
+		t.Errorf("Expected %q, got %q", expected, e.PAE())
+	}
+}
+
+const subjectHash = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+
+func statementEnvelope(t *testing.T, hash string) []byte {
+	payload := `{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"out.tar","digest":{"sha256":"` +
+		hash + `"}}],"predicateType":"https://slsa.dev/provenance/v1","predicate":{}}`
+	data, err := json.Marshal(Envelope{
+		PayloadType: InTotoPayloadType,
+		Payload:     []byte(payload),
+		Signatures:  []Signature{{Sig: []byte("sig")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseEnvelope(t *testing.T) {
+	_, err := ParseEnvelope(statementEnvelope(t, subjectHash), subjectHash)
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, err = ParseEnvelope(statementEnvelope(t, strings.Repeat("0", 64)), subjectHash)
+	if err == nil {
+		t.Error("Expected an error for a statement about another subject")
+	}
+
+	// Payloads which are not in-toto statements aren't checked.
+	_, err = ParseEnvelope([]byte(`{"payloadType":"text/plain","payload":"aGk=","signatures":[{"sig":"c2ln"}]}`), subjectHash)
+	if err != nil {
+		t.Error(err)
+	}
+
+	invalid := []string{
+		`not json`,
+		`{"payload":"aGk=","signatures":[{"sig":"c2ln"}]}`,
+		`{"payloadType":"text/plain","signatures":[{"sig":"c2ln"}]}`,
+		`{"payloadType":"text/plain","payload":"aGk="}`,
+		`{"payloadType":"application/vnd.in-toto+json","payload":"aGk=","signatures":[{"sig":"c2ln"}]}`,
+	}
+	for _, data := range invalid {
+		_, err = ParseEnvelope([]byte(data), subjectHash)
+		if err == nil {
+			t.Errorf("Expected an error for %s", data)
+		}
+	}
+}
+
+func encodePublicKey(t *testing.T, key crypto.PublicKey) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestVerify(t *testing.T) {
+	env, err := ParseEnvelope(statementEnvelope(t, subjectHash), subjectHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pae := env.PAE()
+	digest := sha256.Sum256(pae)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecSig, err := ecdsa.SignASN1(rand.Reader, ecKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edSig := ed25519.Sign(edKey, pae)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaSig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tcs := []struct {
+		pub crypto.PublicKey
+		sig []byte
+	}{
+		{&ecKey.PublicKey, ecSig},
+		{edPub, edSig},
+		{&rsaKey.PublicKey, rsaSig},
+	}
+
+	for _, tc := range tcs {
+		key, err := ParsePublicKey(encodePublicKey(t, tc.pub))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		env.Signatures = []Signature{{Sig: []byte("bogus")}, {Sig: tc.sig}}
+		err = env.Verify([]crypto.PublicKey{key})
+		if err != nil {
+			t.Errorf("Expected a valid %T signature, got %v", key, err)
+		}
+
+		env.Signatures = env.Signatures[:1]
+		err = env.Verify([]crypto.PublicKey{key})
+		if err == nil {
+			t.Errorf("Expected an invalid %T signature", key)
+		}
+	}
+
+	_, err = ParsePublicKey([]byte("not pem"))
+	if err == nil {
+		t.Error("Expected an error for a key which isn't PEM encoded")
+	}
+}
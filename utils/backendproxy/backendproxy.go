@@ -1,7 +1,10 @@
 package backendproxy
 
 import (
+	"context"
 	"io"
+	"sync/atomic"
+	"time"
 
 	"github.com/buchgr/bazel-remote/v2/cache"
 )
@@ -18,6 +21,10 @@ type Uploader interface {
 	UploadFile(item UploadReq)
 }
 
+// The number of uploads which have been queued by Enqueue, but have not
+// finished yet.
+var pending atomic.Int64
+
 func StartUploaders(u Uploader, numUploaders int, maxQueuedUploads int) chan UploadReq {
 	if maxQueuedUploads <= 0 || numUploaders <= 0 {
 		return nil
@@ -29,9 +36,40 @@ func StartUploaders(u Uploader, numUploaders int, maxQueuedUploads int) chan Upl
 		go func() {
 			for item := range uploadQueue {
 				u.UploadFile(item)
+				pending.Add(-1)
 			}
 		}()
 	}
 
 	return uploadQueue
 }
+
+// Enqueue adds item to uploadQueue without blocking, and returns false if
+// the queue is full.
+func Enqueue(uploadQueue chan<- UploadReq, item UploadReq) bool {
+	pending.Add(1)
+	select {
+	case uploadQueue <- item:
+		return true
+	default:
+		pending.Add(-1)
+		return false
+	}
+}
+
+// Flush waits until all the uploads queued by Enqueue have finished, or
+// until ctx is done.
+func Flush(ctx context.Context) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for pending.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package backendproxy
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+	"io"
+)
+
+// ErrChecksumMismatch is returned by the readers from VerifyChecksum if the
+// data doesn't match the checksum which the proxy backend sent with it.
+var ErrChecksumMismatch = errors.New("The data from the proxy backend does not match its checksum")
+
+// Checksum returns the checksum of the rest of r computed with h, and
+// rewinds r to where it was, so that it can be sent with the checksum. It
+// returns nil if r can't be rewound, eg because it isn't a file.
+func Checksum(r io.Reader, h hash.Hash) ([]byte, error) {
+	s, ok := r.(io.ReadSeeker)
+	if !ok {
+		return nil, nil
+	}
+
+	start, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.Copy(h, s)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.Seek(start, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+// VerifyChecksum returns a reader of the size bytes of rc, or of all of it
+// if size is negative, which hashes them with h while they are read, and
+// returns ErrChecksumMismatch instead of the end of the data if they don't
+// match want. This way the data is verified as it is streamed, rather than
+// in a second pass after it has been downloaded. mismatch is called for
+// each mismatch, eg to count them.
+func VerifyChecksum(rc io.ReadCloser, h hash.Hash, want []byte, size int64, mismatch func()) io.ReadCloser {
+	return &checksumVerifier{
+		ReadCloser: rc,
+		h:          h,
+		want:       want,
+		size:       size,
+		mismatch:   mismatch,
+	}
+}
+
+type checksumVerifier struct {
+	io.ReadCloser
+	h        hash.Hash
+	want     []byte
+	size     int64
+	n        int64
+	mismatch func()
+	err      error // The result of the verification, once it is done.
+}
+
+func (v *checksumVerifier) Read(p []byte) (int, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+
+	n, err := v.ReadCloser.Read(p)
+	v.h.Write(p[:n])
+	v.n += int64(n)
+
+	if err != nil && err != io.EOF {
+		return n, err
+	}
+
+	// Readers of a known size may stop without reading io.EOF, so verify
+	// once all of it has been read.
+	if err == nil && (v.size < 0 || v.n < v.size) {
+		return n, nil
+	}
+
+	if (v.size < 0 || v.n == v.size) && bytes.Equal(v.h.Sum(nil), v.want) {
+		v.err = io.EOF
+		return n, err
+	}
+
+	v.mismatch()
+	v.err = ErrChecksumMismatch
+	return n, v.err
+}
@@ -0,0 +1,102 @@
+package backendproxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChecksum(t *testing.T) {
+	data := []byte("header and data")
+	expected := sha256.Sum256(data[len("header "):])
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, err = f.Write(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Seek(int64(len("header ")), io.SeekStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := Checksum(f, sha256.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sum, expected[:]) {
+		t.Errorf("Expected the checksum of the rest of the file %x, got %x", expected, sum)
+	}
+
+	// The file is rewound, to be sent with the checksum.
+	rest, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rest) != "and data" {
+		t.Errorf("Expected the file to be rewound, read %q", rest)
+	}
+
+	// Readers which can't be rewound are sent without a checksum.
+	sum, err = Checksum(io.MultiReader(bytes.NewReader(data)), sha256.New())
+	if err != nil || sum != nil {
+		t.Errorf("Expected no checksum for a reader which can't be rewound, got %x, %v", sum, err)
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("some data from the proxy backend")
+	sum := sha256.Sum256(data)
+
+	testCases := []struct {
+		name     string
+		data     []byte
+		size     int64
+		mismatch bool
+	}{
+		{"match", data, int64(len(data)), false},
+		{"match of unknown size", data, -1, false},
+		{"corrupt", append([]byte("S"), data[1:]...), int64(len(data)), true},
+		{"corrupt of unknown size", append([]byte("S"), data[1:]...), -1, true},
+		{"truncated", data[:10], int64(len(data)), true},
+		{"too long", append(data, 'x'), int64(len(data)), true},
+	}
+
+	for _, tc := range testCases {
+		mismatches := 0
+		rc := VerifyChecksum(io.NopCloser(bytes.NewReader(tc.data)), sha256.New(), sum[:], tc.size,
+			func() { mismatches++ })
+
+		_, err := io.ReadAll(rc)
+		if tc.mismatch {
+			if err != ErrChecksumMismatch || mismatches != 1 {
+				t.Errorf("%s: expected one mismatch, got %d and error %v", tc.name, mismatches, err)
+			}
+		} else if err != nil || mismatches != 0 {
+			t.Errorf("%s: expected no mismatch, got %d and error %v", tc.name, mismatches, err)
+		}
+
+		// Reading again returns the same result.
+		_, again := rc.Read(make([]byte, 1))
+		if tc.mismatch && again != ErrChecksumMismatch || !tc.mismatch && again != io.EOF {
+			t.Errorf("%s: unexpected error when reading again: %v", tc.name, again)
+		}
+	}
+
+	// Readers of a known size are verified once all of it has been read,
+	// even if they don't read io.EOF.
+	mismatches := 0
+	rc := VerifyChecksum(io.NopCloser(bytes.NewReader(append([]byte("S"), data[1:]...))), sha256.New(), sum[:],
+		int64(len(data)), func() { mismatches++ })
+	n, err := rc.Read(make([]byte, len(data)))
+	if n != len(data) || err != ErrChecksumMismatch || mismatches != 1 {
+		t.Errorf("Expected the mismatch to be found without reading io.EOF, got %d and error %v", mismatches, err)
+	}
+}
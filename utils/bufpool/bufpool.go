@@ -0,0 +1,134 @@
+// Package bufpool provides pools of byte slices in a range of sizes, so
+// that the read and write paths can reuse transfer buffers instead of
+// allocating new ones for each request.
+package bufpool
+
+import (
+	"io"
+	"math/bits"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// The pooled buffer sizes are the powers of two from 1<<minSizeBits to
+// 1<<maxSizeBits bytes, ie 4 KiB to 4 MiB.
+const (
+	minSizeBits = 12
+	maxSizeBits = 22
+)
+
+// CopyBufferSize is the size of the buffers used by Copy.
+const CopyBufferSize = 32 * 1024
+
+// The size label of buffers which are too large to be pooled.
+const oversized = "oversized"
+
+var (
+	gets = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bazel_remote_buffer_pool_gets_total",
+		Help: "The total number of buffers taken from the buffer pools, by pool size in bytes",
+	}, []string{"size"})
+
+	allocations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bazel_remote_buffer_pool_allocations_total",
+		Help: "The total number of buffers which had to be allocated because the buffer pool was empty, by pool size in bytes. The pool efficiency is 1 - allocations / gets",
+	}, []string{"size"})
+)
+
+type pool struct {
+	sync.Pool
+	gets prometheus.Counter
+}
+
+var pools [maxSizeBits - minSizeBits + 1]pool
+
+var (
+	oversizedGets        = gets.WithLabelValues(oversized)
+	oversizedAllocations = allocations.WithLabelValues(oversized)
+)
+
+func init() {
+	for i := range pools {
+		size := 1 << (minSizeBits + i)
+		label := strconv.Itoa(size)
+
+		allocated := allocations.WithLabelValues(label)
+		pools[i].New = func() any {
+			allocated.Inc()
+			buf := make([]byte, size)
+			return &buf
+		}
+		pools[i].gets = gets.WithLabelValues(label)
+	}
+}
+
+// Returns the index of the smallest pool with buffers of at least size
+// bytes, or -1 if size is too large to be pooled.
+func poolIndex(size int) int {
+	if size <= 1<<minSizeBits {
+		return 0
+	}
+	if size > 1<<maxSizeBits {
+		return -1
+	}
+	return bits.Len(uint(size-1)) - minSizeBits
+}
+
+// Get returns a buffer of length size. It should be returned with Put
+// when it is no longer used. Buffers larger than the largest pool size
+// are allocated, and are not kept by Put.
+func Get(size int) *[]byte {
+	i := poolIndex(size)
+	if i < 0 {
+		oversizedGets.Inc()
+		oversizedAllocations.Inc()
+		buf := make([]byte, size)
+		return &buf
+	}
+
+	pools[i].gets.Inc()
+	buf := pools[i].Get().(*[]byte)
+	*buf = (*buf)[:size]
+	return buf
+}
+
+// Put returns a buffer from Get to its pool. The buffer must not be used
+// afterwards.
+func Put(buf *[]byte) {
+	size := cap(*buf)
+	i := poolIndex(size)
+	if i < 0 || size != 1<<(minSizeBits+i) {
+		return
+	}
+
+	*buf = (*buf)[:size]
+	pools[i].Put(buf)
+}
+
+// Copy is like io.Copy, but uses a pooled buffer if one is needed.
+//
+// If dst is an *os.File and src isn't, the data is copied through the
+// buffer, rather than with dst's ReadFrom method, which can only avoid
+// copying through userspace for some types of src and would otherwise
+// allocate its own buffer.
+func Copy(dst io.Writer, src io.Reader) (int64, error) {
+	if _, ok := dst.(*os.File); ok {
+		if _, ok := src.(*os.File); !ok {
+			dst = writerOnly{dst}
+		}
+	}
+
+	buf := Get(CopyBufferSize)
+	defer Put(buf)
+
+	return io.CopyBuffer(dst, src, *buf)
+}
+
+// Hides the ReadFrom method of an io.Writer from io.CopyBuffer.
+type writerOnly struct {
+	io.Writer
+}
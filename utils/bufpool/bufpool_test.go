@@ -0,0 +1,94 @@
+package bufpool
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPoolIndex(t *testing.T) {
+	tests := []struct {
+		size  int
+		index int
+	}{
+		{0, 0},
+		{1, 0},
+		{4096, 0},
+		{4097, 1},
+		{8192, 1},
+		{1024 * 1024, 8},
+		{4 * 1024 * 1024, 10},
+		{4*1024*1024 + 1, -1},
+	}
+
+	for _, tc := range tests {
+		if i := poolIndex(tc.size); i != tc.index {
+			t.Errorf("Expected pool %d for size %d, got %d", tc.index, tc.size, i)
+		}
+	}
+}
+
+func TestGetPut(t *testing.T) {
+	for _, size := range []int{0, 100, 4096, 5000, 1024 * 1024} {
+		buf := Get(size)
+		if len(*buf) != size {
+			t.Errorf("Expected a buffer of length %d, got %d", size, len(*buf))
+		}
+		if c := cap(*buf); c != 1<<(minSizeBits+poolIndex(size)) {
+			t.Errorf("Expected a buffer from the pool for size %d, got capacity %d", size, c)
+		}
+		Put(buf)
+	}
+
+	before := testutil.ToFloat64(oversizedAllocations)
+	buf := Get(8 * 1024 * 1024)
+	if len(*buf) != 8*1024*1024 {
+		t.Errorf("Expected an oversized buffer of length %d, got %d", 8*1024*1024, len(*buf))
+	}
+	Put(buf)
+	if n := testutil.ToFloat64(oversizedAllocations) - before; n != 1 {
+		t.Errorf("Expected one oversized allocation, got %f", n)
+	}
+
+	// Buffers which don't match a pool size are dropped.
+	odd := make([]byte, 5000)
+	Put(&odd)
+}
+
+func TestCopy(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 10000)
+
+	var out bytes.Buffer
+	n, err := Copy(&out, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(data)) || !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("Expected to copy %d bytes, copied %d", len(data), n)
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	n, err = Copy(f, bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(data)) {
+		t.Errorf("Expected to copy %d bytes to the file, copied %d", len(data), n)
+	}
+
+	written, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written, data) {
+		t.Error("The file has the wrong content")
+	}
+}
@@ -0,0 +1,147 @@
+// Package discovery finds the bazel-remote instances which form a cluster,
+// or which replicate writes to each other, in DNS or Kubernetes, and
+// checks whether they are healthy.
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Resolver looks up the instances named by a discovery URL.
+type Resolver struct {
+	LookupHost func(ctx context.Context, host string) ([]string, error)
+	LookupSRV  func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+
+	// If set, the instances are the endpoints of a Kubernetes service
+	// instead, see NewKubernetesResolver. Namespace is used for services
+	// without one.
+	LookupEndpoints func(ctx context.Context, namespace, name string) ([]EndpointSubset, error)
+	Namespace       string
+}
+
+// DefaultResolver uses the system's DNS resolver.
+var DefaultResolver = &Resolver{
+	LookupHost: net.DefaultResolver.LookupHost,
+	LookupSRV:  net.DefaultResolver.LookupSRV,
+}
+
+// IsSRV returns true if hostname is the name of SRV records, ie it starts
+// with an underscore, eg _http._tcp.bazel-remote.example.com.
+func IsSRV(hostname string) bool {
+	return strings.HasPrefix(hostname, "_")
+}
+
+// Resolve returns the base URLs of the instances named by discover,
+// which keep its scheme, credentials and path. If its hostname is the
+// name of SRV records, see IsSRV, each record's target and port is an
+// instance. Otherwise each address of the hostname is an instance with
+// the port of discover. With LookupEndpoints, each ready endpoint of the
+// service is an instance.
+func (r *Resolver) Resolve(ctx context.Context, discover *url.URL) ([]string, error) {
+	var hosts []string
+
+	if r.LookupEndpoints != nil {
+		var err error
+		hosts, err = r.resolveKubernetes(ctx, discover)
+		if err != nil {
+			return nil, err
+		}
+	} else if IsSRV(discover.Hostname()) {
+		_, srvs, err := r.LookupSRV(ctx, "", "", discover.Hostname())
+		if err != nil {
+			return nil, err
+		}
+		for _, srv := range srvs {
+			target := strings.TrimSuffix(srv.Target, ".")
+			hosts = append(hosts, net.JoinHostPort(target, strconv.Itoa(int(srv.Port))))
+		}
+	} else {
+		addrs, err := r.LookupHost(ctx, discover.Hostname())
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			if port := discover.Port(); port != "" {
+				hosts = append(hosts, net.JoinHostPort(addr, port))
+			} else if strings.Contains(addr, ":") {
+				hosts = append(hosts, "["+addr+"]")
+			} else {
+				hosts = append(hosts, addr)
+			}
+		}
+	}
+
+	baseURLs := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		u := *discover
+		u.Host = host
+		baseURLs = append(baseURLs, u.String())
+	}
+
+	return baseURLs, nil
+}
+
+// ErrUnavailable is wrapped by the errors of CheckHealth for instances
+// which respond, but don't serve clients, eg standbys.
+var ErrUnavailable = errors.New("unavailable")
+
+// CheckHealth returns nil if the bazel-remote instance at baseURL
+// responds to a request for its /status page with HTTP status 200.
+func CheckHealth(ctx context.Context, client *http.Client, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/status", nil)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	_, _ = io.Copy(io.Discard, rsp.Body)
+
+	switch rsp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("%w: status %d", ErrUnavailable, rsp.StatusCode)
+	}
+	return fmt.Errorf("unexpected status: %d", rsp.StatusCode)
+}
+
+// IsLocalHost returns true if host, without a port, is this host's name
+// or one of the addresses of its network interfaces.
+func IsLocalHost(host string) bool {
+	if hostname, err := os.Hostname(); err == nil && strings.EqualFold(host, hostname) {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() {
+		return true
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
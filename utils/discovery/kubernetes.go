@@ -0,0 +1,217 @@
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The discovery modes, which decide how the hostname of a discovery URL
+// is resolved.
+const (
+	// ModeDNS resolves the hostname in DNS, see Resolver.Resolve.
+	ModeDNS = "dns"
+
+	// ModeKubernetes looks up the ready endpoints of the Kubernetes
+	// service named by the hostname, through the API server.
+	ModeKubernetes = "kubernetes"
+)
+
+// GetModes returns the valid discovery mode names.
+func GetModes() []string {
+	return []string{ModeDNS, ModeKubernetes}
+}
+
+// IsValidMode returns true if mode is a valid discovery mode name.
+func IsValidMode(mode string) bool {
+	for _, m := range GetModes() {
+		if mode == m {
+			return true
+		}
+	}
+	return false
+}
+
+// NewResolver returns a Resolver for mode, see GetModes.
+func NewResolver(mode string) (*Resolver, error) {
+	switch mode {
+	case ModeDNS:
+		return DefaultResolver, nil
+	case ModeKubernetes:
+		return NewKubernetesResolver()
+	}
+	return nil, fmt.Errorf("Invalid discovery mode: %q", mode)
+}
+
+// EndpointSubset is a group of ready addresses of a Kubernetes service,
+// which serve the same ports.
+type EndpointSubset struct {
+	Addresses []string
+	Ports     map[string]int // By port name, which may be empty.
+}
+
+// The service account files which are mounted into each pod.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// NewKubernetesResolver returns a Resolver which looks up the endpoints
+// of Kubernetes services through the API server, using the credentials
+// of the pod's service account. The service account needs permission to
+// get endpoints.
+func NewKubernetesResolver() (*Resolver, error) {
+	host := os.Getenv("KUBERNETES_SERVICE_HOST")
+	port := os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("Kubernetes discovery requires running in a Kubernetes pod: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	}
+
+	namespace, err := os.ReadFile(filepath.Join(serviceAccountDir, "namespace"))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the pod's namespace: %w", err)
+	}
+
+	caCert, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the Kubernetes CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("Failed to parse the Kubernetes CA certificate")
+	}
+
+	k := &kubernetesClient{
+		apiURL:    "https://" + net.JoinHostPort(host, port),
+		tokenFile: filepath.Join(serviceAccountDir, "token"),
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: pool},
+			},
+		},
+	}
+
+	return &Resolver{
+		LookupEndpoints: k.lookupEndpoints,
+		Namespace:       strings.TrimSpace(string(namespace)),
+	}, nil
+}
+
+type kubernetesClient struct {
+	apiURL    string
+	tokenFile string
+	client    *http.Client
+}
+
+// The parts of a v1 Endpoints object which we use.
+type endpoints struct {
+	Subsets []struct {
+		Addresses []struct {
+			IP string `json:"ip"`
+		} `json:"addresses"`
+		Ports []struct {
+			Name string `json:"name"`
+			Port int    `json:"port"`
+		} `json:"ports"`
+	} `json:"subsets"`
+}
+
+func (k *kubernetesClient) lookupEndpoints(ctx context.Context, namespace string, name string) ([]EndpointSubset, error) {
+	// Read the token for each request, since it is rotated.
+	token, err := os.ReadFile(k.tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the service account token: %w", err)
+	}
+
+	u := k.apiURL + "/api/v1/namespaces/" + url.PathEscape(namespace) + "/endpoints/" + url.PathEscape(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+
+	rsp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(rsp.Body, 1024))
+		return nil, fmt.Errorf("Failed to get the endpoints of %s/%s: status %d: %s",
+			namespace, name, rsp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return parseEndpoints(rsp.Body)
+}
+
+func parseEndpoints(r io.Reader) ([]EndpointSubset, error) {
+	var eps endpoints
+	err := json.NewDecoder(r).Decode(&eps)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the endpoints: %w", err)
+	}
+
+	subsets := make([]EndpointSubset, 0, len(eps.Subsets))
+	for _, s := range eps.Subsets {
+		subset := EndpointSubset{Ports: make(map[string]int, len(s.Ports))}
+		for _, a := range s.Addresses {
+			subset.Addresses = append(subset.Addresses, a.IP)
+		}
+		for _, p := range s.Ports {
+			subset.Ports[p.Name] = p.Port
+		}
+		subsets = append(subsets, subset)
+	}
+
+	return subsets, nil
+}
+
+// Returns the hosts of the ready endpoints of the service named by
+// discover's hostname, "name" or "name.namespace". The port is discover's
+// port if it has one, otherwise the service port named after discover's
+// scheme, or the service's only port.
+func (r *Resolver) resolveKubernetes(ctx context.Context, discover *url.URL) ([]string, error) {
+	name, namespace, found := strings.Cut(discover.Hostname(), ".")
+	if !found {
+		namespace = r.Namespace
+	}
+
+	subsets, err := r.LookupEndpoints(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []string
+	for _, s := range subsets {
+		port := discover.Port()
+		if port == "" {
+			p, found := s.Ports[discover.Scheme]
+			if !found && len(s.Ports) == 1 {
+				for _, only := range s.Ports {
+					p, found = only, true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("The service %s/%s has no port named %q, specify the port in the discovery URL",
+					namespace, name, discover.Scheme)
+			}
+			port = strconv.Itoa(p)
+		}
+
+		for _, addr := range s.Addresses {
+			hosts = append(hosts, net.JoinHostPort(addr, port))
+		}
+	}
+
+	return hosts, nil
+}
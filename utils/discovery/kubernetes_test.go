@@ -0,0 +1,115 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testEndpoints = `{
+  "kind": "Endpoints",
+  "apiVersion": "v1",
+  "metadata": {"name": "bazel-remote", "namespace": "cache"},
+  "subsets": [
+    {
+      "addresses": [{"ip": "10.0.0.1"}, {"ip": "10.0.0.2"}],
+      "notReadyAddresses": [{"ip": "10.0.0.3"}],
+      "ports": [{"name": "http", "port": 8080}, {"name": "grpc", "port": 9092}]
+    }
+  ]
+}`
+
+func TestKubernetesLookupEndpoints(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/cache/endpoints/bazel-remote" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(testEndpoints))
+	}))
+	defer srv.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	err := os.WriteFile(tokenFile, []byte("secret\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k := &kubernetesClient{
+		apiURL:    srv.URL,
+		tokenFile: tokenFile,
+		client:    srv.Client(),
+	}
+
+	subsets, err := k.lookupEndpoints(context.Background(), "cache", "bazel-remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []EndpointSubset{{
+		Addresses: []string{"10.0.0.1", "10.0.0.2"},
+		Ports:     map[string]int{"http": 8080, "grpc": 9092},
+	}}
+	if !reflect.DeepEqual(subsets, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, subsets)
+	}
+
+	_, err = k.lookupEndpoints(context.Background(), "cache", "missing")
+	if err == nil {
+		t.Error("Expected an error for a missing service")
+	}
+}
+
+func TestResolveKubernetes(t *testing.T) {
+	r := &Resolver{
+		LookupEndpoints: func(ctx context.Context, namespace, name string) ([]EndpointSubset, error) {
+			if name != "bazel-remote" {
+				t.Errorf("Unexpected service: %s", name)
+			}
+			ports := map[string]int{"http": 8080, "grpc": 9092}
+			if namespace == "other" {
+				ports = map[string]int{"": 8081}
+			}
+			return []EndpointSubset{{Addresses: []string{"10.0.0.1", "fd00::2"}, Ports: ports}}, nil
+		},
+		Namespace: "cache",
+	}
+
+	tcs := []struct {
+		discover string
+		expected []string
+	}{
+		{"http://bazel-remote", []string{"http://10.0.0.1:8080", "http://[fd00::2]:8080"}},
+		{"http://bazel-remote:9000", []string{"http://10.0.0.1:9000", "http://[fd00::2]:9000"}},
+		{"https://bazel-remote.other", []string{"https://10.0.0.1:8081", "https://[fd00::2]:8081"}},
+	}
+
+	for _, tc := range tcs {
+		u, err := url.Parse(tc.discover)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		baseURLs, err := r.Resolve(context.Background(), u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(baseURLs, tc.expected) {
+			t.Errorf("Expected %v for %s, got %v", tc.expected, tc.discover, baseURLs)
+		}
+	}
+
+	u, _ := url.Parse("https://bazel-remote")
+	_, err := r.Resolve(context.Background(), u)
+	if err == nil {
+		t.Error("Expected an error for a service without an https port")
+	}
+}
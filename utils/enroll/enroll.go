@@ -0,0 +1,217 @@
+// Package enroll issues short-lived client certificates, signed by a
+// local CA, to clients which present an enrollment token, so that a
+// fleet of CI agents can be set up for mTLS without distributing a
+// certificate to each of them.
+package enroll
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// Issued certificates are valid from this long before they were issued,
+// to allow for clock skew.
+const clockSkew = time.Minute
+
+// Issuer checks enrollment tokens and signs certificate signing requests.
+type Issuer struct {
+	caCert   *x509.Certificate
+	caKey    crypto.Signer
+	tokens   [][sha256.Size]byte
+	lifetime time.Duration
+	now      func() time.Time
+}
+
+// NewIssuer returns an Issuer which signs client certificates, valid for
+// the given lifetime, with the CA in the given PEM encoded certificate
+// and private key, for clients which present one of the tokens.
+func NewIssuer(caCertPEM []byte, caKeyPEM []byte, tokens []string, lifetime time.Duration) (*Issuer, error) {
+	block, _ := pem.Decode(caCertPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("Invalid CA certificate: expected a PEM encoded CERTIFICATE")
+	}
+	caCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid CA certificate: %w", err)
+	}
+	if !caCert.IsCA {
+		return nil, errors.New("Invalid CA certificate: it is not a CA certificate")
+	}
+
+	caKey, err := parsePrivateKey(caKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := caKey.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(caCert.PublicKey) {
+		return nil, errors.New("The CA private key does not match the CA certificate")
+	}
+
+	if len(tokens) == 0 {
+		return nil, errors.New("No enrollment tokens")
+	}
+	hashes := make([][sha256.Size]byte, 0, len(tokens))
+	for _, token := range tokens {
+		hashes = append(hashes, sha256.Sum256([]byte(token)))
+	}
+
+	if lifetime <= 0 {
+		return nil, errors.New("The certificate lifetime must be positive")
+	}
+
+	return &Issuer{
+		caCert:   caCert,
+		caKey:    caKey,
+		tokens:   hashes,
+		lifetime: lifetime,
+		now:      time.Now,
+	}, nil
+}
+
+// LoadIssuer is like NewIssuer, but reads the CA certificate, the CA
+// private key and the tokens from files. The tokens file has one token
+// per line, and lines which are empty or start with "#" are ignored.
+func LoadIssuer(caCertFile string, caKeyFile string, tokensFile string, lifetime time.Duration) (*Issuer, error) {
+	caCertPEM, err := os.ReadFile(caCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the enrollment CA certificate: %w", err)
+	}
+
+	caKeyPEM, err := os.ReadFile(caKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the enrollment CA private key: %w", err)
+	}
+
+	data, err := os.ReadFile(tokensFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the enrollment tokens: %w", err)
+	}
+	var tokens []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the enrollment tokens: %w", err)
+	}
+
+	return NewIssuer(caCertPEM, caKeyPEM, tokens, lifetime)
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("Invalid CA private key: expected a PEM encoded private key")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("Invalid CA private key: unsupported PEM type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid CA private key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("Invalid CA private key: it can't be used for signing")
+	}
+	return signer, nil
+}
+
+// ValidToken returns true if token is one of the enrollment tokens. The
+// comparison takes the same time for every token of the same length.
+func (i *Issuer) ValidToken(token string) bool {
+	hash := sha256.Sum256([]byte(token))
+
+	valid := 0
+	for _, t := range i.tokens {
+		valid |= subtle.ConstantTimeCompare(hash[:], t[:])
+	}
+	return valid == 1
+}
+
+// Issue verifies the PEM encoded certificate signing request, and returns
+// a PEM encoded client certificate for its public key and common name,
+// followed by the CA certificate, and the parsed client certificate.
+// Other fields of the request, eg alternative names, are ignored.
+func (i *Issuer) Issue(csrPEM []byte) ([]byte, *x509.Certificate, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, nil, errors.New("Invalid certificate signing request: expected a PEM encoded CERTIFICATE REQUEST")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid certificate signing request: %w", err)
+	}
+	err = csr.CheckSignature()
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid certificate signing request signature: %w", err)
+	}
+	if csr.Subject.CommonName == "" {
+		return nil, nil, errors.New("Invalid certificate signing request: the subject has no common name")
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := i.now()
+	expiry := now.Add(i.lifetime)
+	if expiry.After(i.caCert.NotAfter) {
+		expiry = i.caCert.NotAfter
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: csr.Subject.CommonName},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              expiry,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, i.caCert, csr.PublicKey, i.caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to sign the client certificate: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var buf bytes.Buffer
+	_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: i.caCert.Raw})
+
+	return buf.Bytes(), cert, nil
+}
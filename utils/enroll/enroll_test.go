@@ -0,0 +1,215 @@
+package enroll
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Returns a PEM encoded CA certificate and private key, valid for the
+// given duration.
+func newTestCA(t *testing.T, validity time.Duration) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func newTestCSR(t *testing.T, commonName string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader,
+		&x509.CertificateRequest{Subject: pkix.Name{CommonName: commonName}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func TestIssue(t *testing.T) {
+	caCertPEM, caKeyPEM := newTestCA(t, 365*24*time.Hour)
+	issuer, err := NewIssuer(caCertPEM, caKeyPEM, []string{"token1", "token2"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for token, valid := range map[string]bool{"token1": true, "token2": true, "token": false, "": false} {
+		if issuer.ValidToken(token) != valid {
+			t.Errorf("Expected ValidToken(%q) to be %t", token, valid)
+		}
+	}
+
+	certPEM, cert, err := issuer.Issue(newTestCSR(t, "ci-agent-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The response has the client certificate, followed by the CA.
+	block, rest := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		t.Fatal("Expected a PEM encoded certificate")
+	}
+	caBlock, _ := pem.Decode(rest)
+	if caBlock == nil {
+		t.Fatal("Expected the CA certificate after the client certificate")
+	}
+	caCert, err := x509.ParseCertificate(caBlock.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		t.Errorf("Expected the client certificate to be valid for client authentication: %v", err)
+	}
+
+	if cert.Subject.CommonName != "ci-agent-1" {
+		t.Errorf("Expected the common name ci-agent-1, got %q", cert.Subject.CommonName)
+	}
+	lifetime := time.Until(cert.NotAfter)
+	if lifetime > time.Hour || lifetime < 59*time.Minute {
+		t.Errorf("Expected the certificate to expire in an hour, got %s", lifetime)
+	}
+	if cert.IsCA {
+		t.Error("Expected the client certificate not to be a CA")
+	}
+}
+
+func TestIssueCappedAtCAExpiry(t *testing.T) {
+	caCertPEM, caKeyPEM := newTestCA(t, time.Hour)
+	issuer, err := NewIssuer(caCertPEM, caKeyPEM, []string{"token"}, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, cert, err := issuer.Issue(newTestCSR(t, "ci-agent-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(cert.NotAfter) > time.Hour {
+		t.Errorf("Expected the certificate to expire with the CA, got %s", cert.NotAfter)
+	}
+}
+
+func TestInvalidRequests(t *testing.T) {
+	caCertPEM, caKeyPEM := newTestCA(t, time.Hour)
+	issuer, err := NewIssuer(caCertPEM, caKeyPEM, []string{"token"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csr := newTestCSR(t, "ci-agent-1")
+	block, _ := pem.Decode(csr)
+	block.Bytes[len(block.Bytes)-1] ^= 0xff
+	badSignature := pem.EncodeToMemory(block)
+
+	for name, request := range map[string][]byte{
+		"empty":           nil,
+		"not PEM":         []byte("ci-agent-1"),
+		"a certificate":   caCertPEM,
+		"no common name":  newTestCSR(t, ""),
+		"a bad signature": badSignature,
+	} {
+		_, _, err := issuer.Issue(request)
+		if err == nil {
+			t.Errorf("Expected an error for a request with %s", name)
+		}
+	}
+}
+
+func TestInvalidIssuers(t *testing.T) {
+	caCertPEM, caKeyPEM := newTestCA(t, time.Hour)
+	_, otherKeyPEM := newTestCA(t, time.Hour)
+
+	_, err := NewIssuer(caCertPEM, otherKeyPEM, []string{"token"}, time.Hour)
+	if err == nil {
+		t.Error("Expected an error for a key which doesn't match the CA certificate")
+	}
+	_, err = NewIssuer(caCertPEM, caKeyPEM, nil, time.Hour)
+	if err == nil {
+		t.Error("Expected an error without tokens")
+	}
+	_, err = NewIssuer(caCertPEM, caKeyPEM, []string{"token"}, 0)
+	if err == nil {
+		t.Error("Expected an error for a zero lifetime")
+	}
+	_, err = NewIssuer(caKeyPEM, caKeyPEM, []string{"token"}, time.Hour)
+	if err == nil {
+		t.Error("Expected an error for a CA certificate which is a key")
+	}
+}
+
+func TestLoadIssuer(t *testing.T) {
+	dir := t.TempDir()
+	caCertPEM, caKeyPEM := newTestCA(t, time.Hour)
+
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, data, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	caCertFile := write("ca.pem", caCertPEM)
+	caKeyFile := write("ca.key", caKeyPEM)
+	tokensFile := write("tokens", []byte("# CI agents\ntoken1\n\n  token2  \n"))
+
+	issuer, err := LoadIssuer(caCertFile, caKeyFile, tokensFile, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for token, valid := range map[string]bool{"token1": true, "token2": true, "# CI agents": false} {
+		if issuer.ValidToken(token) != valid {
+			t.Errorf("Expected ValidToken(%q) to be %t", token, valid)
+		}
+	}
+
+	_, err = LoadIssuer(caCertFile, caKeyFile, write("empty", []byte("# no tokens\n")), time.Hour)
+	if err == nil {
+		t.Error("Expected an error for a tokens file without tokens")
+	}
+	_, err = LoadIssuer(caCertFile, filepath.Join(dir, "missing"), tokensFile, time.Hour)
+	if err == nil {
+		t.Error("Expected an error for a missing key file")
+	}
+}
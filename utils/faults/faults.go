@@ -0,0 +1,212 @@
+// Package faults injects faults into responses, eg errors, corrupt data
+// and latency, according to configurable rules, so that client behaviour
+// can be tested against a misbehaving cache. It is meant for test and
+// staging environments, never for production.
+package faults
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/codes"
+)
+
+var injectedFaults = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "bazel_remote_injected_faults_total",
+	Help: "The total number of faults injected into responses, by endpoint and by fault (error, corrupt or latency)",
+}, []string{"endpoint", "fault"})
+
+// Rule describes the faults to inject into the responses of an endpoint.
+// Endpoints are named by the HTTP method, eg "GET", or by the gRPC
+// service and method, eg "ByteStream/Read".
+type Rule struct {
+	Endpoint string
+
+	// The HTTP status, or the gRPC code, of the error which replaces the
+	// response. Zero means no error.
+	Error int
+
+	// Whether to corrupt the blob data in the response.
+	Corrupt bool
+
+	// How long to delay the request.
+	Latency time.Duration
+
+	// The probability that the rule applies to a request, between 0
+	// (exclusive) and 1.
+	Probability float64
+}
+
+// The endpoints whose responses contain blob data which can be corrupted.
+var corruptibleEndpoints = map[string]bool{
+	http.MethodGet:    true,
+	"ByteStream/Read": true,
+	"ContentAddressableStorage/BatchReadBlobs": true,
+}
+
+// IsHTTPEndpoint returns true if endpoint names an HTTP method rather
+// than a gRPC method.
+func IsHTTPEndpoint(endpoint string) bool {
+	return !strings.Contains(endpoint, "/")
+}
+
+// ParseRule parses a rule in the form "endpoint:fault,...", where each
+// fault is "error=<status>" with an HTTP status for HTTP endpoints, or
+// "error=<code>" with a gRPC code name like UNAVAILABLE for gRPC
+// endpoints, "corrupt", "latency=<duration>" or "probability=<p>", eg
+// "ByteStream/Read:error=UNAVAILABLE,probability=0.1".
+func ParseRule(s string) (Rule, error) {
+	endpoint, faults, found := strings.Cut(s, ":")
+	if !found || faults == "" {
+		return Rule{}, fmt.Errorf("Invalid fault injection rule %q, expected endpoint:fault,...", s)
+	}
+
+	r := Rule{Endpoint: endpoint, Probability: 1}
+
+	switch endpoint {
+	case http.MethodGet, http.MethodHead, http.MethodPut:
+	default:
+		service, method, found := strings.Cut(endpoint, "/")
+		if !found || service == "" || method == "" || strings.Contains(method, "/") {
+			return Rule{}, fmt.Errorf("Invalid endpoint %q in fault injection rule %q, expected an HTTP method or a gRPC Service/Method", endpoint, s)
+		}
+	}
+
+	for _, fault := range strings.Split(faults, ",") {
+		name, value, _ := strings.Cut(fault, "=")
+
+		var err error
+		switch name {
+		case "error":
+			r.Error, err = parseError(endpoint, value)
+		case "corrupt":
+			if value != "" || !corruptibleEndpoints[endpoint] {
+				err = fmt.Errorf("only GET, ByteStream/Read and ContentAddressableStorage/BatchReadBlobs responses can be corrupted")
+			}
+			r.Corrupt = true
+		case "latency":
+			r.Latency, err = time.ParseDuration(value)
+			if err == nil && r.Latency <= 0 {
+				err = fmt.Errorf("the latency must be positive")
+			}
+		case "probability":
+			r.Probability, err = strconv.ParseFloat(value, 64)
+			if err == nil && (r.Probability <= 0 || r.Probability > 1) {
+				err = fmt.Errorf("the probability must be greater than 0 and at most 1")
+			}
+		default:
+			err = fmt.Errorf("unknown fault %q", name)
+		}
+		if err != nil {
+			return Rule{}, fmt.Errorf("Invalid fault injection rule %q: %w", s, err)
+		}
+	}
+
+	if r.Error == 0 && !r.Corrupt && r.Latency == 0 {
+		return Rule{}, fmt.Errorf("Invalid fault injection rule %q: expected error, corrupt or latency", s)
+	}
+
+	return r, nil
+}
+
+// Returns the HTTP status or gRPC code of an "error" fault.
+func parseError(endpoint string, value string) (int, error) {
+	if IsHTTPEndpoint(endpoint) {
+		status, err := strconv.Atoi(value)
+		if err != nil || status < 400 || status > 599 {
+			return 0, fmt.Errorf("expected an HTTP error status, got %q", value)
+		}
+		return status, nil
+	}
+
+	var code codes.Code
+	err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(value))))
+	if err != nil || code == codes.OK {
+		return 0, fmt.Errorf("expected a gRPC error code name, got %q", value)
+	}
+	return int(code), nil
+}
+
+// Faults are the faults to inject into the response to a request.
+type Faults struct {
+	Error   int
+	Corrupt bool
+	Latency time.Duration
+}
+
+// Injector decides which faults to inject into responses.
+type Injector struct {
+	rules map[string][]Rule
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// New returns an Injector which applies the given rules.
+func New(rules []Rule) *Injector {
+	i := &Injector{
+		rules: make(map[string][]Rule),
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	for _, r := range rules {
+		i.rules[r.Endpoint] = append(i.rules[r.Endpoint], r)
+	}
+
+	return i
+}
+
+// Pick returns the faults to inject into the response to a request to
+// endpoint. Each rule for the endpoint applies with its probability, and
+// the faults of all the rules which apply are combined: their latencies
+// add up, and the first error wins.
+func (i *Injector) Pick(endpoint string) Faults {
+	var f Faults
+
+	rules := i.rules[endpoint]
+	if len(rules) == 0 {
+		return f
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, r := range rules {
+		if r.Probability < 1 && i.rand.Float64() >= r.Probability {
+			continue
+		}
+
+		if f.Error == 0 {
+			f.Error = r.Error
+		}
+		f.Corrupt = f.Corrupt || r.Corrupt
+		f.Latency += r.Latency
+	}
+
+	if f.Error != 0 {
+		injectedFaults.WithLabelValues(endpoint, "error").Inc()
+	}
+	if f.Corrupt {
+		injectedFaults.WithLabelValues(endpoint, "corrupt").Inc()
+	}
+	if f.Latency > 0 {
+		injectedFaults.WithLabelValues(endpoint, "latency").Inc()
+	}
+
+	return f
+}
+
+// CorruptBytes flips a bit in every 64th byte of data, starting with the
+// first, so that it no longer matches its digest.
+func CorruptBytes(data []byte) {
+	for i := 0; i < len(data); i += 64 {
+		data[i] ^= 0x01
+	}
+}
@@ -0,0 +1,102 @@
+package faults
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestParseRule(t *testing.T) {
+	tcs := []struct {
+		rule     string
+		expected Rule
+	}{
+		{"GET:error=503", Rule{Endpoint: "GET", Error: 503, Probability: 1}},
+		{"GET:corrupt,probability=0.5", Rule{Endpoint: "GET", Corrupt: true, Probability: 0.5}},
+		{"PUT:latency=2s,error=500", Rule{Endpoint: "PUT", Error: 500, Latency: 2 * time.Second, Probability: 1}},
+		{"ByteStream/Read:error=unavailable", Rule{Endpoint: "ByteStream/Read", Error: int(codes.Unavailable), Probability: 1}},
+		{"ContentAddressableStorage/BatchReadBlobs:corrupt", Rule{Endpoint: "ContentAddressableStorage/BatchReadBlobs", Corrupt: true, Probability: 1}},
+	}
+
+	for _, tc := range tcs {
+		r, err := ParseRule(tc.rule)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", tc.rule, err)
+			continue
+		}
+		if r != tc.expected {
+			t.Errorf("Expected %+v for %q, got %+v", tc.expected, tc.rule, r)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"GET",
+		"GET:",
+		"POST:error=500",
+		"ByteStream:error=UNAVAILABLE",
+		"GET:error=200",
+		"GET:error=UNAVAILABLE",
+		"ByteStream/Read:error=503",
+		"ByteStream/Read:error=OK",
+		"PUT:corrupt",
+		"ByteStream/Write:corrupt",
+		"GET:latency=-1s",
+		"GET:error=503,probability=0",
+		"GET:error=503,probability=1.5",
+		"GET:probability=0.5",
+		"GET:error=503,timeout=1s",
+	}
+
+	for _, rule := range invalid {
+		_, err := ParseRule(rule)
+		if err == nil {
+			t.Errorf("Expected an error for %q", rule)
+		}
+	}
+}
+
+func TestPick(t *testing.T) {
+	i := New([]Rule{
+		{Endpoint: "GET", Error: 503, Probability: 1},
+		{Endpoint: "GET", Error: 500, Latency: time.Second, Probability: 1},
+		{Endpoint: "GET", Corrupt: true, Probability: 1},
+		{Endpoint: "PUT", Latency: time.Second, Probability: 0.5},
+	})
+
+	f := i.Pick("GET")
+	expected := Faults{Error: 503, Corrupt: true, Latency: time.Second}
+	if f != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, f)
+	}
+
+	if f := i.Pick("HEAD"); f != (Faults{}) {
+		t.Fatalf("Expected no faults for an endpoint without rules, got %+v", f)
+	}
+
+	delayed := 0
+	for n := 0; n < 1000; n++ {
+		if i.Pick("PUT").Latency > 0 {
+			delayed++
+		}
+	}
+	if delayed < 350 || delayed > 650 {
+		t.Fatalf("Expected about half of the PUT requests to be delayed, got %d of 1000", delayed)
+	}
+}
+
+func TestCorruptBytes(t *testing.T) {
+	data := make([]byte, 130)
+	CorruptBytes(data)
+
+	for i, b := range data {
+		expected := byte(0)
+		if i%64 == 0 {
+			expected = 1
+		}
+		if b != expected {
+			t.Fatalf("Unexpected byte %d at offset %d", b, i)
+		}
+	}
+}
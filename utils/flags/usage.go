@@ -9,6 +9,8 @@ import (
 	"strings"
 	"text/tabwriter"
 	"text/template"
+
+	"github.com/urfave/cli/v2"
 )
 
 const (
@@ -24,15 +26,39 @@ const (
 var Template = `bazel-remote - A remote build cache for Bazel and other REAPI clients
 
 USAGE:
-   {{.Name}} [options]
+   {{.Name}} [options]{{if .VisibleCommands}}
+   {{.Name}} <command> [arguments]
+
+COMMANDS:{{range .VisibleCommands}}
+   {{.Name}}{{"\t"}}{{.Usage}}{{end}}{{end}}
 
 OPTIONS:
    {{range $index, $option := .VisibleFlags}}{{if $index}}
    {{end}}{{wrap $option.String 6}}
 {{end}}`
 
+// CommandTemplate describes the help text format for subcommands.
+var CommandTemplate = `NAME:
+   {{.HelpName}} - {{.Usage}}
+
+USAGE:
+   {{.UsageText}}{{if .VisibleFlags}}
+
+OPTIONS:
+   {{range $index, $option := .VisibleFlags}}{{if $index}}
+   {{end}}{{wrap $option.String 6}}
+{{end}}{{end}}`
+
+// The default urfave/cli help printer, for any other help templates.
+var defaultHelpPrinter = cli.HelpPrinterCustom
+
 // HelpPrinter writes our custom-formatted help text to `out`.
 func HelpPrinter(out io.Writer, templ string, data interface{}, customFuncs map[string]interface{}) {
+	if templ != Template && templ != CommandTemplate {
+		defaultHelpPrinter(out, templ, data, customFuncs)
+		return
+	}
+
 	maxLineLength := getConsoleWidth()
 
 	funcMap := template.FuncMap{
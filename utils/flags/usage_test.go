@@ -132,9 +132,10 @@ OPTIONS:
 	output := new(bytes.Buffer)
 
 	app := &cli.App{
-		Name:   "cli.test",
-		Writer: output,
-		Flags:  flags,
+		Name:            "cli.test",
+		Writer:          output,
+		Flags:           flags,
+		HideHelpCommand: true,
 	}
 
 	// Reset HelpPrinter after this test.
@@ -0,0 +1,284 @@
+// Package handoff passes listening sockets from a running bazel-remote
+// process to a new one, so the server can be upgraded or restarted
+// without refusing connections.
+//
+// The old process starts the new one with its listeners as inherited
+// file descriptors, and waits until the new process reports that it has
+// taken them over before shutting down gracefully. The new process then
+// waits until the old one has exited before it uses the cache directory,
+// so that only one process at a time indexes, writes and cleans it up.
+// Connections which arrive in the meantime are queued by the kernel and
+// accepted by the new process.
+package handoff
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// A comma separated list of "network:address" names for the
+	// inherited listeners, which start at file descriptor 3.
+	listenersEnv = "BAZEL_REMOTE_HANDOFF_LISTENERS"
+
+	// The file descriptor which the new process writes to once it is
+	// ready to serve.
+	readyEnv = "BAZEL_REMOTE_HANDOFF_READY_FD"
+
+	// The file descriptor which the new process reads io.EOF from once
+	// the old process has exited, since the old process holds the write
+	// end open until then.
+	releasedEnv = "BAZEL_REMOTE_HANDOFF_RELEASED_FD"
+)
+
+// The first file descriptor passed via exec.Cmd.ExtraFiles.
+const firstExtraFD = 3
+
+type listener struct {
+	name string
+	ln   net.Listener
+}
+
+// Handoff keeps track of the listeners which this process serves on, and
+// passes them to new processes.
+type Handoff struct {
+	mu        sync.Mutex
+	inherited map[string]*os.File
+	listeners []listener
+	ready     *os.File
+
+	// In the new process, the read end of the pipe which is closed when
+	// the previous process exits. In the old process, its write end,
+	// which is kept open until this process exits.
+	released *os.File
+}
+
+// New returns a Handoff for this process, with any listeners which were
+// inherited from the process that started it.
+func New() (*Handoff, error) {
+	h := &Handoff{inherited: make(map[string]*os.File)}
+
+	names := os.Getenv(listenersEnv)
+	readyFD := os.Getenv(readyEnv)
+	releasedFD := os.Getenv(releasedEnv)
+	os.Unsetenv(listenersEnv)
+	os.Unsetenv(readyEnv)
+	os.Unsetenv(releasedEnv)
+
+	if names != "" {
+		for i, name := range strings.Split(names, ",") {
+			h.inherited[name] = os.NewFile(uintptr(firstExtraFD+i), name)
+		}
+	}
+
+	if readyFD != "" {
+		fd, err := strconv.Atoi(readyFD)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %q", readyEnv, readyFD)
+		}
+		h.ready = os.NewFile(uintptr(fd), "handoff-ready")
+	}
+
+	if releasedFD != "" {
+		fd, err := strconv.Atoi(releasedFD)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %q", releasedEnv, releasedFD)
+		}
+		h.released = os.NewFile(uintptr(fd), "handoff-released")
+	}
+
+	return h, nil
+}
+
+// Inherited returns true if this process was started by another
+// bazel-remote process, via Restart.
+func (h *Handoff) Inherited() bool {
+	return h.ready != nil
+}
+
+// Listen returns the listener for the given network and address which
+// was inherited from the previous process, or creates a new one.
+func (h *Handoff) Listen(network string, addr string) (net.Listener, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	name := network + ":" + addr
+	if strings.Contains(name, ",") {
+		return nil, fmt.Errorf("unsupported address: %q", addr)
+	}
+
+	var ln net.Listener
+	var err error
+
+	f, found := h.inherited[name]
+	if found {
+		delete(h.inherited, name)
+		ln, err = net.FileListener(f)
+		f.Close()
+	} else {
+		ln, err = net.Listen(network, addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	h.listeners = append(h.listeners, listener{name: name, ln: ln})
+
+	return ln, nil
+}
+
+// Ready tells the previous process that this process has taken over its
+// listeners, so it can shut down. Inherited listeners which were not
+// requested via Listen, eg because the configuration changed, are closed.
+func (h *Handoff) Ready() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for name, f := range h.inherited {
+		f.Close()
+		delete(h.inherited, name)
+	}
+
+	if h.ready == nil {
+		return nil
+	}
+
+	_, err := h.ready.Write([]byte{1})
+	closeErr := h.ready.Close()
+	h.ready = nil
+	if err != nil {
+		return err
+	}
+
+	return closeErr
+}
+
+// WaitForPrevious blocks until the previous process, which started this
+// one via Restart, has exited, after Ready told it to shut down. It
+// returns immediately if this process was not started via Restart.
+func (h *Handoff) WaitForPrevious() error {
+	h.mu.Lock()
+	released := h.released
+	h.released = nil
+	h.mu.Unlock()
+
+	if released == nil {
+		return nil
+	}
+	defer released.Close()
+
+	// Nothing is written to the pipe, the read returns io.EOF once the
+	// previous process has exited.
+	_, err := io.Copy(io.Discard, released)
+	return err
+}
+
+// Restart starts a new instance of this executable with the same
+// arguments, passes the listeners to it, and waits until it has taken
+// them over. If an error is returned, the new process has been stopped
+// and this process should continue serving. Otherwise this process
+// should shut down and exit, which the new process waits for with
+// WaitForPrevious.
+func (h *Handoff) Restart() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	names := make([]string, 0, len(h.listeners))
+	files := make([]*os.File, 0, len(h.listeners)+1)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for _, l := range h.listeners {
+		fl, ok := l.ln.(interface{ File() (*os.File, error) })
+		if !ok {
+			return fmt.Errorf("unable to pass on listener %s", l.name)
+		}
+		f, err := fl.File()
+		if err != nil {
+			return fmt.Errorf("unable to pass on listener %s: %w", l.name, err)
+		}
+		names = append(names, l.name)
+		files = append(files, f)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	files = append(files, w)
+
+	releasedR, releasedW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	files = append(files, releasedR)
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		listenersEnv+"="+strings.Join(names, ","),
+		readyEnv+"="+strconv.Itoa(firstExtraFD+len(names)),
+		releasedEnv+"="+strconv.Itoa(firstExtraFD+len(names)+1))
+
+	err = cmd.Start()
+	if err != nil {
+		releasedW.Close()
+		return err
+	}
+
+	// Close our copy of the write end, so the read below returns if the
+	// new process exits without becoming ready.
+	w.Close()
+	releasedR.Close()
+	files = files[:len(files)-2]
+
+	buf := make([]byte, 1)
+	n, _ := r.Read(buf)
+	if n != 1 {
+		_ = cmd.Process.Kill()
+		err = cmd.Wait()
+		if err == nil {
+			err = errors.New("exited before it was ready")
+		}
+		releasedW.Close()
+		return fmt.Errorf("new process %d failed: %w", cmd.Process.Pid, err)
+	}
+
+	// Keep the write end open until this process exits, which tells the
+	// new process that it can use the cache directory. It is not
+	// inherited by other child processes.
+	h.released = releasedW
+
+	// The new process now serves on the unix sockets, so they must
+	// not be removed when we shut down.
+	for _, l := range h.listeners {
+		ul, ok := l.ln.(*net.UnixListener)
+		if ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+
+	// The new process outlives this one, and is reparented when we exit.
+	_ = cmd.Process.Release()
+
+	return nil
+}
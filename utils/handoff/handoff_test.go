@@ -0,0 +1,76 @@
+package handoff
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRestart(t *testing.T) {
+	h, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := h.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h.Inherited() {
+		// This is the new process, started by Restart below.
+		err = h.Ready()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		released := make(chan error, 1)
+		go func() {
+			released <- h.WaitForPrevious()
+		}()
+
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The previous process is still running, until it has read our
+		// reply.
+		reply := "new"
+		select {
+		case <-released:
+			reply = "released before the previous process exited"
+		case <-time.After(100 * time.Millisecond):
+		}
+		_, _ = conn.Write([]byte(reply))
+		conn.Close()
+
+		err = <-released
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	err = h.Restart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	data, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("Expected the new process to accept the connection, and wait for this one to exit, got %q", data)
+	}
+}
@@ -5,21 +5,37 @@ import (
 	"time"
 )
 
+// ExitCode is the exit status used when the server shuts itself down
+// after the idle timeout, so that supervisors can tell this apart from
+// a normal shutdown or a crash.
+const ExitCode = 3
+
 // Timer keeps track of the request activity and notifies
 // registered channels once an idle timeout has been reached.
 type Timer struct {
 	mu          sync.Mutex
 	timeout     time.Duration
 	notify      chan struct{}
+	started     time.Time
 	lastRequest time.Time
+	numRequests int64
+}
+
+// Stats summarizes the request activity seen by a Timer.
+type Stats struct {
+	Uptime      time.Duration
+	IdleFor     time.Duration
+	NumRequests int64
 }
 
 // NewTimer creates a new Timer that will send notifications on
 // any registered channels once the idle timeout has been reached.
 func NewTimer(timeout time.Duration, notificationChan chan struct{}) *Timer {
+	now := time.Now()
 	return &Timer{
 		timeout:     timeout,
-		lastRequest: time.Now(),
+		started:     now,
+		lastRequest: now,
 		notify:      notificationChan,
 	}
 }
@@ -52,5 +68,20 @@ func (t *Timer) ResetTimer() {
 	now := time.Now()
 	t.mu.Lock()
 	t.lastRequest = now
+	t.numRequests++
 	t.mu.Unlock()
 }
+
+// Stats returns the uptime, the time since the last request and the
+// number of requests seen so far.
+func (t *Timer) Stats() Stats {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return Stats{
+		Uptime:      now.Sub(t.started),
+		IdleFor:     now.Sub(t.lastRequest),
+		NumRequests: t.numRequests,
+	}
+}
@@ -28,3 +28,19 @@ func TestIdleTimer(t *testing.T) {
 		t.Fatal("expected idle timer to trigger")
 	}
 }
+
+func TestIdleTimerStats(t *testing.T) {
+	it := idle.NewTimer(time.Hour, make(chan struct{}))
+
+	it.ResetTimer()
+	it.ResetTimer()
+	time.Sleep(10 * time.Millisecond)
+
+	stats := it.Stats()
+	if stats.NumRequests != 2 {
+		t.Errorf("Expected 2 requests, got %d", stats.NumRequests)
+	}
+	if stats.IdleFor < 10*time.Millisecond || stats.Uptime < stats.IdleFor {
+		t.Errorf("Unexpected durations: %+v", stats)
+	}
+}
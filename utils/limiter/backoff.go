@@ -0,0 +1,91 @@
+package limiter
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MaxRetryAfter is the longest that clients are asked to wait before
+// retrying a rejected request, before the jitter which Backoff adds.
+const MaxRetryAfter = 30 * time.Second
+
+// Backoff returns how long a client should wait before retrying a request
+// which was rejected while backlog requests, or bytes, were waiting for
+// the given capacity: base, plus base for each capacity's worth of
+// backlog, at most MaxRetryAfter. Up to half of that again is added at
+// random, so that the clients which were rejected together don't all
+// retry at the same time.
+func Backoff(base time.Duration, backlog int64, capacity int64) time.Duration {
+	d := base
+	if backlog > 0 && capacity > 0 {
+		d += time.Duration(float64(base) * float64(backlog) / float64(capacity))
+	}
+	if d > MaxRetryAfter || d < 0 {
+		d = MaxRetryAfter
+	}
+
+	if d >= 2 {
+		d += time.Duration(rand.Int63n(int64(d / 2)))
+	}
+	return d
+}
+
+// RetryAfterHeader returns the value of an HTTP Retry-After header for
+// the delay d, in whole seconds, rounded to the nearest, and at least 1.
+func RetryAfterHeader(d time.Duration) string {
+	seconds := int(math.Round(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
+
+// Counts the requests rejected in the last RetryAfter, which are likely
+// to be retried at about the same time, and are the backlog for the
+// requests rejected after them.
+type shedCounter struct {
+	mu    sync.Mutex
+	start time.Time // Of the current interval.
+	curr  int64     // The number rejected in the current interval.
+	prev  int64     // The number rejected in the previous interval.
+}
+
+// Record a rejected request at now.
+func (c *shedCounter) add(now time.Time) {
+	c.mu.Lock()
+	c.advance(now)
+	c.curr++
+	c.mu.Unlock()
+}
+
+// Return the number of requests rejected in the last RetryAfter, before
+// now, estimated from the current and previous intervals.
+func (c *shedCounter) count(now time.Time) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(now)
+	elapsed := now.Sub(c.start)
+	prevWeight := float64(RetryAfter-elapsed) / float64(RetryAfter)
+	return c.curr + int64(float64(c.prev)*prevWeight)
+}
+
+// Must be called with c.mu held.
+func (c *shedCounter) advance(now time.Time) {
+	elapsed := now.Sub(c.start)
+	if elapsed < RetryAfter {
+		return
+	}
+
+	if elapsed < 2*RetryAfter {
+		c.prev = c.curr
+		c.start = c.start.Add(RetryAfter)
+	} else {
+		c.prev = 0
+		c.start = now
+	}
+	c.curr = 0
+}
@@ -0,0 +1,83 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		backlog  int64
+		capacity int64
+		expected time.Duration // Before jitter.
+	}{
+		{0, 10, time.Second},
+		{5, 10, 1500 * time.Millisecond},
+		{20, 10, 3 * time.Second},
+		{20, 0, time.Second},
+		{1 << 40, 1, MaxRetryAfter},
+	} {
+		for i := 0; i < 100; i++ {
+			d := Backoff(time.Second, tc.backlog, tc.capacity)
+			if d < tc.expected || d >= tc.expected+tc.expected/2 {
+				t.Fatalf("Expected %v plus jitter for a backlog of %d and capacity %d, got %v",
+					tc.expected, tc.backlog, tc.capacity, d)
+			}
+		}
+	}
+
+	// The jitter spreads the delays.
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		seen[Backoff(time.Second, 0, 1)] = true
+	}
+	if len(seen) < 10 {
+		t.Errorf("Expected the delays to vary, got %d different delays", len(seen))
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	for d, expected := range map[time.Duration]string{
+		0:                       "1",
+		1400 * time.Millisecond: "1",
+		1600 * time.Millisecond: "2",
+		30 * time.Second:        "30",
+	} {
+		if h := RetryAfterHeader(d); h != expected {
+			t.Errorf("Expected %q for %v, got %q", expected, d, h)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := New(2, map[string]int{"PUT": 1})
+	l.now = func() time.Time { return now }
+
+	l.Acquire("GET")
+	l.Acquire("GET")
+
+	// The delay grows with the number of requests rejected recently,
+	// relative to the limit.
+	for i := int64(0); i < 4; i++ {
+		if l.Acquire("GET") {
+			t.Fatal("Expected a GET over the limit to be rejected")
+		}
+		expected := time.Second + time.Duration(i)*time.Second/2
+		if d := l.RetryAfter("GET"); d < expected || d >= expected+expected/2 {
+			t.Errorf("Expected %v plus jitter after %d rejections, got %v", expected, i+1, d)
+		}
+	}
+
+	// The lower endpoint limit applies.
+	if d := l.RetryAfter("PUT"); d < 4*time.Second {
+		t.Errorf("Expected at least 4s for PUTs, with a backlog of 3 and a limit of 1, got %v", d)
+	}
+
+	// Old rejections are forgotten.
+	now = now.Add(2 * RetryAfter)
+	l.Acquire("GET")
+	if d := l.RetryAfter("GET"); d >= 1500*time.Millisecond {
+		t.Errorf("Expected the base delay after the backlog was retried, got %v", d)
+	}
+}
@@ -0,0 +1,124 @@
+// Package limiter limits the number of concurrent requests, so that
+// bazel-remote sheds load instead of running out of memory or file
+// descriptors when many clients connect at once.
+package limiter
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// RetryAfter is how long clients are asked to wait before retrying a
+// rejected request, when few requests are being rejected. See Backoff.
+const RetryAfter = time.Second
+
+var shedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "bazel_remote_shed_requests_total",
+	Help: "The total number of requests rejected because of a concurrency limit, by endpoint and by which limit was reached (global or endpoint)",
+}, []string{"endpoint", "limit"})
+
+type slot struct {
+	limit    int64
+	inFlight atomic.Int64
+}
+
+func (s *slot) acquire() bool {
+	if s.inFlight.Add(1) > s.limit {
+		s.inFlight.Add(-1)
+		return false
+	}
+	return true
+}
+
+func (s *slot) release() {
+	s.inFlight.Add(-1)
+}
+
+// Limiter limits the number of concurrent requests, in total and per
+// endpoint. Endpoints are named by the HTTP method, eg "GET", or by the
+// gRPC service and method, eg "ByteStream/Write".
+type Limiter struct {
+	global      *slot // nil if there is no global limit.
+	perEndpoint map[string]*slot
+
+	shed shedCounter
+	now  func() time.Time
+}
+
+// New returns a Limiter which allows at most global concurrent requests
+// in total, and the given number of concurrent requests per endpoint.
+// A global limit of 0 means no limit.
+func New(global int, perEndpoint map[string]int) *Limiter {
+	l := &Limiter{
+		perEndpoint: make(map[string]*slot, len(perEndpoint)),
+		now:         time.Now,
+	}
+
+	if global > 0 {
+		l.global = &slot{limit: int64(global)}
+	}
+
+	for endpoint, limit := range perEndpoint {
+		l.perEndpoint[endpoint] = &slot{limit: int64(limit)}
+	}
+
+	return l
+}
+
+// Acquire reserves room for a request to endpoint. If that would exceed
+// a limit, it returns false and the request should be rejected. Otherwise
+// Release must be called when the request has finished.
+func (l *Limiter) Acquire(endpoint string) bool {
+	if l.global != nil && !l.global.acquire() {
+		shedRequests.WithLabelValues(endpoint, "global").Inc()
+		l.shed.add(l.now())
+		return false
+	}
+
+	s, found := l.perEndpoint[endpoint]
+	if found && !s.acquire() {
+		if l.global != nil {
+			l.global.release()
+		}
+		shedRequests.WithLabelValues(endpoint, "endpoint").Inc()
+		l.shed.add(l.now())
+		return false
+	}
+
+	return true
+}
+
+// RetryAfter returns how long a client should wait before retrying a
+// request to endpoint which was rejected by Acquire. The more requests
+// were rejected recently, relative to the limit, the longer the delay.
+func (l *Limiter) RetryAfter(endpoint string) time.Duration {
+	var capacity int64
+	if l.global != nil {
+		capacity = l.global.limit
+	}
+	s, found := l.perEndpoint[endpoint]
+	if found && (capacity == 0 || s.limit < capacity) {
+		capacity = s.limit
+	}
+
+	// The rejected requests which are likely to be retried before this
+	// one, not counting this one.
+	backlog := l.shed.count(l.now()) - 1
+
+	return Backoff(RetryAfter, backlog, capacity)
+}
+
+// Release frees the room reserved for a request by Acquire.
+func (l *Limiter) Release(endpoint string) {
+	s, found := l.perEndpoint[endpoint]
+	if found {
+		s.release()
+	}
+
+	if l.global != nil {
+		l.global.release()
+	}
+}
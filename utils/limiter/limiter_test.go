@@ -0,0 +1,45 @@
+package limiter
+
+import (
+	"testing"
+)
+
+func TestLimiter(t *testing.T) {
+	l := New(3, map[string]int{"PUT": 1})
+
+	if !l.Acquire("PUT") {
+		t.Fatal("Expected the first PUT to be allowed")
+	}
+	if l.Acquire("PUT") {
+		t.Fatal("Expected the second concurrent PUT to be rejected")
+	}
+
+	if !l.Acquire("GET") || !l.Acquire("GET") {
+		t.Fatal("Expected GETs to be allowed up to the global limit")
+	}
+	if l.Acquire("GET") {
+		t.Fatal("Expected a GET over the global limit to be rejected")
+	}
+
+	// The rejected PUT must not hold on to room under the global limit.
+	l.Release("GET")
+	if !l.Acquire("GET") {
+		t.Fatal("Expected a GET to be allowed after another finished")
+	}
+
+	l.Release("PUT")
+	l.Release("GET")
+	if !l.Acquire("PUT") {
+		t.Fatal("Expected a PUT to be allowed after the first one finished")
+	}
+}
+
+func TestUnlimited(t *testing.T) {
+	l := New(0, nil)
+
+	for i := 0; i < 1000; i++ {
+		if !l.Acquire("GET") {
+			t.Fatal("Expected no limit")
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package maintenance
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression, with the usual five fields:
+// minute, hour, day of month, month and day of week. Each field is
+// either "*", or a comma separated list of values, ranges ("1-5") and
+// steps ("*/15" or "0-30/10"). Times are in the local time zone.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// Day of month and day of week are combined like in cron: if both
+	// are restricted, a day matches if either field matches.
+	domRestricted, dowRestricted bool
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7}, // 0 and 7 are both Sunday.
+}
+
+// Give up looking for the next matching time after this long, so that
+// schedules which never match, like "0 0 30 2 *", are detected.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// ParseSchedule parses a cron expression.
+func ParseSchedule(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid schedule %q: expected %d fields, found %d",
+			expr, len(fields), len(parts))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, f := range fields {
+		var err error
+		bits[i], err = parseField(parts[i], f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", expr, err)
+		}
+	}
+
+	// Sunday can be either 0 or 7.
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	s := &Schedule{
+		minute:        bits[0],
+		hour:          bits[1],
+		dom:           bits[2],
+		month:         bits[3],
+		dow:           bits[4],
+		domRestricted: parts[2] != "*",
+		dowRestricted: parts[4] != "*",
+	}
+
+	if s.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid schedule %q: never matches", expr)
+	}
+
+	return s, nil
+}
+
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+
+	for _, term := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(term, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %s field: %q", f.name, term)
+			}
+		}
+
+		first, last := f.min, f.max
+		if rng != "*" {
+			lo, hi, isRange := strings.Cut(rng, "-")
+
+			var err error
+			first, err = strconv.Atoi(lo)
+			if err != nil {
+				return 0, fmt.Errorf("invalid %s field: %q", f.name, term)
+			}
+			last = first
+			if isRange {
+				last, err = strconv.Atoi(hi)
+				if err != nil {
+					return 0, fmt.Errorf("invalid %s field: %q", f.name, term)
+				}
+			} else if hasStep {
+				// "5/15" means every 15 starting at 5.
+				last = f.max
+			}
+		}
+
+		if first < f.min || last > f.max || first > last {
+			return 0, fmt.Errorf("%s field out of range %d-%d: %q", f.name, f.min, f.max, term)
+		}
+
+		for v := first; v <= last; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := has(s.dom, t.Day())
+	dow := has(s.dow, int(t.Weekday()))
+
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+
+	return dom && dow
+}
+
+// Next returns the first time after t which matches the schedule, or the
+// zero time if there is none.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+
+	for t.Before(limit) {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
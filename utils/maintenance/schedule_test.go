@@ -0,0 +1,59 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	invalid := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"0 0 30 2 *", // Never matches.
+	}
+	for _, expr := range invalid {
+		_, err := ParseSchedule(expr)
+		if err == nil {
+			t.Errorf("Expected an error for %q", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	from := time.Date(2024, time.March, 15, 10, 30, 0, 0, time.UTC) // A Friday.
+
+	testCases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.March, 15, 10, 31, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2024, time.March, 16, 2, 0, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2024, time.March, 15, 10, 40, 0, 0, time.UTC)},
+		{"15,45 10-11 * * *", time.Date(2024, time.March, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 1 * * 0", time.Date(2024, time.March, 17, 1, 0, 0, 0, time.UTC)},
+		{"0 1 * * 7", time.Date(2024, time.March, 17, 1, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// Either the day of month or the day of week must match.
+		{"0 0 1 * 1", time.Date(2024, time.March, 18, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		s, err := ParseSchedule(tc.expr)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", tc.expr, err)
+		}
+		next := s.Next(from)
+		if !next.Equal(tc.expected) {
+			t.Errorf("Expected %q to match next at %v, got %v", tc.expr, tc.expected, next)
+		}
+	}
+}
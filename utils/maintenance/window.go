@@ -0,0 +1,128 @@
+// Package maintenance restricts heavy background work, like scrubbing
+// the cache directory, to maintenance windows.
+package maintenance
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Window keeps track of when maintenance windows are open. Windows open
+// at the times given by an optional Schedule, or when triggered manually,
+// and stay open for a fixed duration.
+type Window struct {
+	schedule *Schedule // nil if windows are only opened manually.
+	duration time.Duration
+
+	mu          sync.Mutex
+	manualStart time.Time
+	triggered   chan struct{} // Closed and replaced by Trigger.
+
+	now func() time.Time
+}
+
+// NewWindow returns a Window which opens at the times given by schedule,
+// which may be nil, for the given duration.
+func NewWindow(schedule *Schedule, duration time.Duration) *Window {
+	return &Window{
+		schedule:  schedule,
+		duration:  duration,
+		triggered: make(chan struct{}),
+		now:       time.Now,
+	}
+}
+
+// Returns the start of the window which is open at t, or the zero time.
+// Must be called with w.mu held.
+func (w *Window) current(t time.Time) time.Time {
+	var start time.Time
+
+	if w.schedule != nil {
+		s := w.schedule.Next(t.Add(-w.duration))
+		if !s.IsZero() && !s.After(t) {
+			start = s
+		}
+	}
+
+	if !w.manualStart.IsZero() && t.Before(w.manualStart.Add(w.duration)) &&
+		w.manualStart.After(start) {
+		start = w.manualStart
+	}
+
+	return start
+}
+
+// Active returns true if a maintenance window is open.
+func (w *Window) Active() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return !w.current(w.now()).IsZero()
+}
+
+// Next returns the start of the next scheduled window, or the zero time
+// if there is no schedule.
+func (w *Window) Next() time.Time {
+	if w.schedule == nil {
+		return time.Time{}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.schedule.Next(w.now())
+}
+
+// Trigger opens a new maintenance window now.
+func (w *Window) Trigger() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.manualStart = w.now()
+	close(w.triggered)
+	w.triggered = make(chan struct{})
+}
+
+// Wait blocks until a maintenance window which opened after the given
+// time is open, and returns the start of that window. Pass the zero time
+// to wait for any window.
+func (w *Window) Wait(ctx context.Context, after time.Time) (time.Time, error) {
+	for {
+		w.mu.Lock()
+		now := w.now()
+		start := w.current(now)
+		triggered := w.triggered
+		var next time.Time
+		if w.schedule != nil {
+			next = w.schedule.Next(now)
+		}
+		w.mu.Unlock()
+
+		if !start.IsZero() && start.After(after) {
+			return start, nil
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(next.Sub(now))
+			timeout = timer.C
+		}
+
+		var err error
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-triggered:
+		case <-timeout:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	s, err := ParseSchedule("0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, time.March, 15, 1, 0, 0, 0, time.UTC)
+	w := NewWindow(s, 2*time.Hour)
+	w.now = func() time.Time { return now }
+
+	if w.Active() {
+		t.Error("Expected the window to be closed before the scheduled time")
+	}
+	if expected := now.Add(time.Hour); !w.Next().Equal(expected) {
+		t.Errorf("Expected the next window at %v, got %v", expected, w.Next())
+	}
+
+	now = now.Add(2 * time.Hour)
+	if !w.Active() {
+		t.Error("Expected the window to be open")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if w.Active() {
+		t.Error("Expected the window to be closed after the duration")
+	}
+
+	w.Trigger()
+	if !w.Active() {
+		t.Error("Expected a triggered window to be open")
+	}
+
+	start, err := w.Wait(context.Background(), time.Time{})
+	if err != nil || !start.Equal(now) {
+		t.Errorf("Expected the triggered window to start at %v, got %v, %v", now, start, err)
+	}
+}
+
+func TestWindowWait(t *testing.T) {
+	w := NewWindow(nil, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := w.Wait(ctx, time.Time{})
+	if err == nil {
+		t.Fatal("Expected to time out without a schedule or trigger")
+	}
+
+	started := make(chan time.Time)
+	go func() {
+		start, _ := w.Wait(context.Background(), time.Time{})
+		started <- start
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	w.Trigger()
+
+	var first time.Time
+	select {
+	case first = <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected the trigger to open a window")
+	}
+
+	// Waiting for a window after the current one blocks until the next
+	// trigger.
+	go func() {
+		start, _ := w.Wait(context.Background(), first)
+		started <- start
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-started:
+		t.Fatal("Expected to wait for a new window")
+	default:
+	}
+
+	time.Sleep(time.Millisecond)
+	w.Trigger()
+	select {
+	case second := <-started:
+		if !second.After(first) {
+			t.Errorf("Expected a later window, got %v after %v", second, first)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected the second trigger to open a window")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package sharedfile
+
+import "os"
+
+// Open opens the named file for reading.
+func Open(name string) (*os.File, error) {
+	return os.Open(name)
+}
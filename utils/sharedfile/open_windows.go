@@ -0,0 +1,28 @@
+//go:build windows
+// +build windows
+
+package sharedfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// Open opens the named file for reading. Unlike os.Open, the file can
+// be renamed or removed by other handles while it is open, eg when a
+// blob is evicted while it is being downloaded.
+func Open(name string) (*os.File, error) {
+	p, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	h, err := syscall.CreateFile(p, syscall.GENERIC_READ,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return os.NewFile(uintptr(h), name), nil
+}
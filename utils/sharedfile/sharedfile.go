@@ -0,0 +1,4 @@
+// Package sharedfile opens cache files for reading in a way that does not
+// prevent them from being renamed or removed while they are open. This is
+// the default on unix, but on windows open files are normally locked.
+package sharedfile
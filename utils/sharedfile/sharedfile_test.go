@@ -0,0 +1,50 @@
+package sharedfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	testutils "github.com/buchgr/bazel-remote/v2/utils"
+)
+
+func TestOpen(t *testing.T) {
+	dir := testutils.TempDir(t)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "blob")
+	err := os.WriteFile(name, []byte("data"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Both of these fail on windows if the file is opened with os.Open.
+	err = os.Rename(name, name+"-renamed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Remove(name + "-renamed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "data" {
+		t.Errorf("Expected to read %q from the removed file, got %q", "data", data)
+	}
+
+	_, err = Open(name)
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected a not-exist error for a missing file, got %v", err)
+	}
+}
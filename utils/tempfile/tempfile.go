@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -82,3 +83,55 @@ func (c *Creator) Create(base string, legacy bool) (*os.File, string, error) {
 	}
 	return nil, "", errNoTempfile
 }
+
+// Suffix is appended to the names of the files created by CreateTemp.
+const Suffix = ".tmp"
+
+// CreateTemp is like Create, except the file name has a Suffix and the
+// file is created with FinalMode. Once the file has been successfully
+// written by the caller, it should be renamed to FinalName(f.Name()),
+// so files with the Suffix can be treated as incomplete.
+func (c *Creator) CreateTemp(base string, legacy bool) (*os.File, string, error) {
+	var err error
+	var f *os.File
+	var name string
+	var random string
+
+	for i := 0; i < 10000; i++ {
+		random = c.ranqd1()
+		if legacy {
+			name = base + "-" + random + ".v1"
+		} else {
+			name = base + "-" + random
+		}
+
+		// Don't pick a name which would replace an existing file when
+		// it is renamed.
+		_, err = os.Lstat(name)
+		if err == nil {
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return nil, "", err
+		}
+
+		f, err = os.OpenFile(name+Suffix, flags, FinalMode)
+		if err == nil {
+			return f, random, nil
+		}
+		if os.IsExist(err) {
+			// Tempfile collision. Try again.
+			continue
+		}
+
+		// Unexpected error.
+		return nil, "", err
+	}
+	return nil, "", errNoTempfile
+}
+
+// FinalName returns the name of a file created by CreateTemp without
+// the Suffix.
+func FinalName(name string) string {
+	return strings.TrimSuffix(name, Suffix)
+}
@@ -41,3 +41,38 @@ func TestTempfileCreator(t *testing.T) {
 			tf.Name(), expectedPrefix)
 	}
 }
+
+func TestTempfileCreatorCreateTemp(t *testing.T) {
+	tfc := tempfile.NewCreator()
+
+	dir, err := os.MkdirTemp("", "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	targetFileBase := path.Join(dir, "foo")
+	tf, random, err := tfc.CreateTemp(targetFileBase, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tf.Close()
+
+	if !strings.HasSuffix(tf.Name(), tempfile.Suffix) {
+		t.Fatalf("Expected tempfile %q to have suffix %q", tf.Name(), tempfile.Suffix)
+	}
+
+	expectedName := targetFileBase + "-" + random + ".v1"
+	if tempfile.FinalName(tf.Name()) != expectedName {
+		t.Fatalf("Expected final name %q, got %q", expectedName,
+			tempfile.FinalName(tf.Name()))
+	}
+
+	fi, err := os.Stat(tf.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode()&os.ModeSetgid != 0 {
+		t.Fatal("Expected the setgid bit to be unset")
+	}
+}
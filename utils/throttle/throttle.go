@@ -0,0 +1,221 @@
+// Package throttle limits the bandwidth used to serve clients, so that a
+// few large downloads can't starve the other clients of a cache server.
+//
+// Bandwidth is shaped with token buckets: one per connection, and one per
+// client identity, eg an authenticated user. Concurrent downloads on the
+// same connection or by the same identity share its bucket.
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// The largest number of bytes that Wait reserves at once, so that
+// concurrent downloads sharing a bucket take turns.
+const maxChunk = 64 * 1024
+
+var (
+	throttledBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bazel_remote_throttled_bytes_total",
+		Help: "The total number of bytes which were delayed by a bandwidth limit, by which limit delayed them the longest (connection or identity)",
+	}, []string{"limit"})
+
+	throttleDelay = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bazel_remote_throttle_delay_seconds_total",
+		Help: "The total time that downloads were delayed by bandwidth limits, by which limit delayed them the longest (connection or identity)",
+	}, []string{"limit"})
+)
+
+// A token bucket, which holds up to rate tokens (one second's worth) and
+// is refilled at rate tokens per second. Tokens are bytes.
+type bucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+
+	refs int // The number of open Streams using the bucket.
+}
+
+// Add the tokens refilled since the last call. Must be called with the
+// Throttler's mutex held.
+func (b *bucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+}
+
+// Take n tokens from b, and return how long to wait until they would
+// have been available. Must be called with the Throttler's mutex held.
+func (b *bucket) reserve(n int, now time.Time) time.Duration {
+	b.refill(now)
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// Throttler limits the bandwidth per connection and per identity.
+type Throttler struct {
+	perConnection float64
+	perIdentity   float64
+
+	now func() time.Time
+
+	mu          sync.Mutex
+	connections map[string]*bucket
+	identities  map[string]*bucket
+	lastSweep   time.Time
+}
+
+// New returns a Throttler which allows perConnection bytes per second on
+// each connection, and perIdentity bytes per second for each identity.
+// A limit of 0 means no limit.
+func New(perConnection int64, perIdentity int64) *Throttler {
+	return &Throttler{
+		perConnection: float64(perConnection),
+		perIdentity:   float64(perIdentity),
+		now:           time.Now,
+		connections:   make(map[string]*bucket),
+		identities:    make(map[string]*bucket),
+	}
+}
+
+// Returns the bucket for key in buckets, creating it if necessary. Must
+// be called with t.mu held.
+func (t *Throttler) ref(buckets map[string]*bucket, key string, rate float64) *bucket {
+	if rate <= 0 {
+		return nil
+	}
+
+	b, found := buckets[key]
+	if !found {
+		b = &bucket{rate: rate, tokens: rate, last: t.now()}
+		buckets[key] = b
+	}
+	b.refs++
+	return b
+}
+
+// Must be called with t.mu held.
+func (t *Throttler) unref(b *bucket) {
+	if b != nil {
+		b.refs--
+	}
+}
+
+// Remove the unused buckets which are full, at most once per second.
+// Unused buckets which aren't full are kept, so that clients can't get
+// more bandwidth by starting new downloads. Must be called with t.mu
+// held.
+func (t *Throttler) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < time.Second {
+		return
+	}
+	t.lastSweep = now
+
+	for _, buckets := range []map[string]*bucket{t.connections, t.identities} {
+		for key, b := range buckets {
+			if b.refs > 0 {
+				continue
+			}
+			b.refill(now)
+			if b.tokens >= b.rate {
+				delete(buckets, key)
+			}
+		}
+	}
+}
+
+// Open returns a Stream which throttles a download on the given
+// connection, by the given identity. Close must be called when the
+// download has finished.
+func (t *Throttler) Open(connection string, identity string) *Stream {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sweep(t.now())
+
+	return &Stream{
+		t:          t,
+		connBucket: t.ref(t.connections, connection, t.perConnection),
+		idBucket:   t.ref(t.identities, identity, t.perIdentity),
+	}
+}
+
+// Stream throttles a single download.
+type Stream struct {
+	t          *Throttler
+	connBucket *bucket // nil if there is no per-connection limit.
+	idBucket   *bucket // nil if there is no per-identity limit.
+}
+
+// Wait blocks until n more bytes may be sent, or ctx is done.
+func (s *Stream) Wait(ctx context.Context, n int) error {
+	for n > 0 {
+		chunk := n
+		if chunk > maxChunk {
+			chunk = maxChunk
+		}
+		n -= chunk
+
+		err := s.wait(ctx, chunk)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Stream) wait(ctx context.Context, n int) error {
+	var connDelay, idDelay time.Duration
+
+	s.t.mu.Lock()
+	now := s.t.now()
+	if s.connBucket != nil {
+		connDelay = s.connBucket.reserve(n, now)
+	}
+	if s.idBucket != nil {
+		idDelay = s.idBucket.reserve(n, now)
+	}
+	s.t.mu.Unlock()
+
+	delay, limit := connDelay, "connection"
+	if idDelay > connDelay {
+		delay, limit = idDelay, "identity"
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	throttledBytes.WithLabelValues(limit).Add(float64(n))
+	throttleDelay.WithLabelValues(limit).Add(delay.Seconds())
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close releases the Stream's buckets.
+func (s *Stream) Close() {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+
+	s.t.unref(s.connBucket)
+	s.t.unref(s.idBucket)
+}
@@ -0,0 +1,102 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	start := time.Now()
+	b := &bucket{rate: 1000, tokens: 1000, last: start}
+
+	if d := b.reserve(1000, start); d != 0 {
+		t.Fatal("Expected a full bucket to allow a burst of one second, got a delay of", d)
+	}
+
+	if d := b.reserve(500, start); d != 500*time.Millisecond {
+		t.Fatal("Expected a delay of 500ms for an empty bucket, got", d)
+	}
+
+	// The bucket refills at 1000 bytes per second, and is in debt by 500.
+	if d := b.reserve(500, start.Add(time.Second)); d != 0 {
+		t.Fatal("Expected no delay after the bucket refilled, got", d)
+	}
+
+	// The bucket never holds more than one second's worth.
+	if d := b.reserve(1500, start.Add(time.Hour)); d != 500*time.Millisecond {
+		t.Fatal("Expected a delay of 500ms for more than a full bucket, got", d)
+	}
+}
+
+func TestSharedBuckets(t *testing.T) {
+	now := time.Now()
+	th := New(1000, 2000)
+	th.now = func() time.Time { return now }
+
+	s1 := th.Open("10.0.0.1:1234", "user:a")
+	s2 := th.Open("10.0.0.1:1234", "user:a")
+	s3 := th.Open("10.0.0.2:1234", "user:a")
+
+	if s1.connBucket != s2.connBucket || s1.connBucket == s3.connBucket {
+		t.Fatal("Expected streams to share buckets by connection")
+	}
+	if s1.idBucket != s3.idBucket {
+		t.Fatal("Expected streams to share buckets by identity")
+	}
+
+	s1.Close()
+	s2.Close()
+	s3.Close()
+
+	// Unused buckets which aren't full are kept.
+	th.connections["10.0.0.1:1234"].tokens = -1000
+	now = now.Add(time.Second)
+	th.Open("10.0.0.3:1234", "user:b").Close()
+	if _, found := th.connections["10.0.0.1:1234"]; !found {
+		t.Fatal("Expected an unused bucket which isn't full to be kept")
+	}
+	if _, found := th.connections["10.0.0.2:1234"]; found {
+		t.Fatal("Expected an unused full bucket to be removed")
+	}
+
+	now = now.Add(time.Second)
+	th.Open("10.0.0.3:1234", "user:b").Close()
+	if _, found := th.connections["10.0.0.1:1234"]; found {
+		t.Fatal("Expected an unused bucket to be removed once full")
+	}
+	if len(th.connections) != 1 || len(th.identities) != 1 {
+		t.Fatal("Expected only the buckets of the last stream, found",
+			len(th.connections), len(th.identities))
+	}
+}
+
+func TestWait(t *testing.T) {
+	th := New(0, 1000)
+	s := th.Open("10.0.0.1:1234", "ip:10.0.0.1")
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := s.Wait(ctx, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	err = s.Wait(ctx, 1000)
+	if err != context.Canceled {
+		t.Fatal("Expected a canceled wait, got", err)
+	}
+}
+
+func TestUnlimited(t *testing.T) {
+	th := New(0, 0)
+	s := th.Open("10.0.0.1:1234", "ip:10.0.0.1")
+	defer s.Close()
+
+	err := s.Wait(context.Background(), 1<<30)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,156 @@
+package validate
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+)
+
+// Output symlinks with absolute targets only work on the machine which
+// created them, so ActionResults which contain them can break the builds
+// of other clients which get cache hits for the action.
+
+// SymlinkPolicy says how uploaded ActionResults with absolute output
+// symlink targets are handled.
+type SymlinkPolicy int
+
+const (
+	// Store ActionResults with absolute symlink targets as they are.
+	SymlinksAllow SymlinkPolicy = iota
+
+	// Reject ActionResults with absolute symlink targets.
+	SymlinksRejectAbsolute
+
+	// Rewrite absolute symlink targets inside a Bazel exec root to be
+	// relative to the symlink, and reject ActionResults with other
+	// absolute symlink targets.
+	SymlinksRewriteAbsolute
+)
+
+var symlinkPolicyNames = map[SymlinkPolicy]string{
+	SymlinksAllow:           "allow",
+	SymlinksRejectAbsolute:  "reject_absolute",
+	SymlinksRewriteAbsolute: "rewrite",
+}
+
+func (p SymlinkPolicy) String() string {
+	return symlinkPolicyNames[p]
+}
+
+// ParseSymlinkPolicy returns the SymlinkPolicy with the given name:
+// "allow", "reject_absolute" or "rewrite". An empty name is the same as
+// "allow".
+func ParseSymlinkPolicy(name string) (SymlinkPolicy, error) {
+	if name == "" {
+		return SymlinksAllow, nil
+	}
+	for p, n := range symlinkPolicyNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return SymlinksAllow, fmt.Errorf("Invalid symlink policy: %q, expected \"allow\", \"reject_absolute\" or \"rewrite\"", name)
+}
+
+// AllowsAbsolute returns true if ActionResults with absolute symlink
+// targets are accepted with policy p, possibly after being rewritten.
+func (p SymlinkPolicy) AllowsAbsolute() bool {
+	return p != SymlinksRejectAbsolute
+}
+
+// Symlinks checks the targets of ar's output symlinks against policy p,
+// and returns an error if ar must be rejected. With
+// SymlinksRewriteAbsolute, absolute targets are rewritten in ar.
+func Symlinks(ar *pb.ActionResult, p SymlinkPolicy) error {
+	if p == SymlinksAllow {
+		return nil
+	}
+
+	lists := []struct {
+		field    string
+		symlinks []*pb.OutputSymlink
+	}{
+		{"output file symlink", ar.GetOutputFileSymlinks()},
+		{"output directory symlink", ar.GetOutputDirectorySymlinks()},
+		{"output symlink", ar.GetOutputSymlinks()},
+	}
+
+	for _, l := range lists {
+		for _, s := range l.symlinks {
+			if s == nil || !strings.HasPrefix(s.Target, "/") {
+				continue
+			}
+
+			if p == SymlinksRewriteAbsolute {
+				target, ok := relativeSymlinkTarget(s.Path, s.Target)
+				if ok {
+					s.Target = target
+					continue
+				}
+				return fmt.Errorf("absolute target in %s %q, which is not inside a Bazel exec root: %q",
+					l.field, s.Path, s.Target)
+			}
+
+			return fmt.Errorf("absolute target in %s %q: %q", l.field, s.Path, s.Target)
+		}
+	}
+
+	return nil
+}
+
+// Returns target, an absolute path inside a Bazel exec root, eg
+// "/home/user/.cache/bazel/_bazel_user/<hash>/execroot/_main/bazel-out/x",
+// as a path relative to the directory of the symlink at linkPath, which
+// is relative to the exec root. Returns false if target is not inside
+// an exec root.
+func relativeSymlinkTarget(linkPath string, target string) (string, bool) {
+	const marker = "/execroot/"
+	i := strings.LastIndex(target, marker)
+	if i < 0 {
+		return "", false
+	}
+
+	// Skip the workspace name, which follows "execroot".
+	rest := target[i+len(marker):]
+	j := strings.IndexByte(rest, '/')
+	if j < 0 {
+		return "", false
+	}
+
+	inRoot := path.Clean(rest[j+1:])
+	if inRoot == ".." || strings.HasPrefix(inRoot, "../") {
+		return "", false
+	}
+
+	return relativePath(path.Dir(path.Clean(linkPath)), inRoot), true
+}
+
+// Returns the slash-separated path to, relative to the directory from.
+// Both must be clean relative paths.
+func relativePath(from string, to string) string {
+	split := func(p string) []string {
+		if p == "." {
+			return nil
+		}
+		return strings.Split(p, "/")
+	}
+	f := split(from)
+	t := split(to)
+
+	common := 0
+	for common < len(f) && common < len(t) && f[common] == t[common] {
+		common++
+	}
+
+	var parts []string
+	for range f[common:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, t[common:]...)
+	if len(parts) == 0 {
+		return "."
+	}
+	return strings.Join(parts, "/")
+}
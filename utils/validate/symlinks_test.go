@@ -0,0 +1,86 @@
+package validate
+
+import (
+	"testing"
+
+	pb "github.com/buchgr/bazel-remote/v2/genproto/build/bazel/remote/execution/v2"
+)
+
+func TestParseSymlinkPolicy(t *testing.T) {
+	for _, p := range []SymlinkPolicy{SymlinksAllow, SymlinksRejectAbsolute, SymlinksRewriteAbsolute} {
+		parsed, err := ParseSymlinkPolicy(p.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != p {
+			t.Errorf("Expected %q to be parsed as %d, got %d", p.String(), p, parsed)
+		}
+	}
+
+	parsed, err := ParseSymlinkPolicy("")
+	if err != nil || parsed != SymlinksAllow {
+		t.Errorf("Expected an empty policy to be parsed as \"allow\", got %q, %v", parsed, err)
+	}
+
+	_, err = ParseSymlinkPolicy("reject")
+	if err == nil {
+		t.Error("Expected an error for an invalid policy")
+	}
+}
+
+func TestSymlinks(t *testing.T) {
+	const execRoot = "/home/user/.cache/bazel/_bazel_user/0123/execroot/_main"
+
+	tcs := []struct {
+		path     string
+		target   string
+		policy   SymlinkPolicy
+		expected string // The expected target, or "" if ar is rejected.
+	}{
+		{"bazel-out/bin/link", "../x", SymlinksRejectAbsolute, "../x"},
+		{"bazel-out/bin/link", "/usr/bin/python3", SymlinksAllow, "/usr/bin/python3"},
+		{"bazel-out/bin/link", "/usr/bin/python3", SymlinksRejectAbsolute, ""},
+		{"bazel-out/bin/link", "/usr/bin/python3", SymlinksRewriteAbsolute, ""},
+		{"bazel-out/bin/link", execRoot + "/bazel-out/bin/x", SymlinksRewriteAbsolute, "x"},
+		{"bazel-out/bin/link", execRoot + "/bazel-out/bin/a/b", SymlinksRewriteAbsolute, "a/b"},
+		{"bazel-out/bin/pkg/link", execRoot + "/external/repo/f", SymlinksRewriteAbsolute, "../../../external/repo/f"},
+		{"link", execRoot + "/f", SymlinksRewriteAbsolute, "f"},
+		{"bazel-out/bin/link", execRoot + "/bazel-out/bin", SymlinksRewriteAbsolute, "."},
+		{"bazel-out/bin/link", execRoot + "/../x", SymlinksRewriteAbsolute, ""},
+		{"bazel-out/bin/link", "/x/execroot", SymlinksRewriteAbsolute, ""},
+	}
+
+	for _, tc := range tcs {
+		ar := &pb.ActionResult{
+			OutputSymlinks: []*pb.OutputSymlink{{Path: tc.path, Target: tc.target}},
+		}
+
+		err := Symlinks(ar, tc.policy)
+		if tc.expected == "" {
+			if err == nil {
+				t.Errorf("Expected %q -> %q to be rejected with policy %q", tc.path, tc.target, tc.policy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected %q -> %q to be accepted with policy %q, got: %v", tc.path, tc.target, tc.policy, err)
+			continue
+		}
+		if ar.OutputSymlinks[0].Target != tc.expected {
+			t.Errorf("Expected %q -> %q to have target %q with policy %q, got %q",
+				tc.path, tc.target, tc.expected, tc.policy, ar.OutputSymlinks[0].Target)
+		}
+	}
+}
+
+func TestSymlinksAllLists(t *testing.T) {
+	for _, ar := range []*pb.ActionResult{
+		{OutputFileSymlinks: []*pb.OutputSymlink{{Path: "a", Target: "/b"}}},
+		{OutputDirectorySymlinks: []*pb.OutputSymlink{{Path: "a", Target: "/b"}}},
+		{OutputSymlinks: []*pb.OutputSymlink{{Path: "a", Target: "/b"}}},
+	} {
+		if Symlinks(ar, SymlinksRejectAbsolute) == nil {
+			t.Errorf("Expected an absolute target to be rejected: %v", ar)
+		}
+	}
+}